@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// logLevelBody is the body served and accepted by the log level endpoint
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// registerAdmin adds the admin endpoints to mux, they require the ADMIN_TOKEN bearer token and are
+// not served at all without one
+func registerAdmin(mux *http.ServeMux, config *Config) {
+	if config.AdminToken == "" {
+		return
+	}
+	mux.Handle("GET /admin/log-level", requireToken(config.AdminToken, http.HandlerFunc(serveLogLevel)))
+	mux.Handle("PUT /admin/log-level", requireToken(config.AdminToken, http.HandlerFunc(changeLogLevel)))
+}
+
+// serveLogLevel serves the current level of the logger
+func serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(logLevelBody{Level: strings.ToLower(logLevel.Level().String())})
+}
+
+// changeLogLevel changes the level of the logger to the one of the body, e.g. {"level": "debug"}.
+// The level holds until the process restarts or a reloaded configuration changes LOG_LEVEL
+func changeLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body logLevelBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(logLevels, body.Level) {
+		http.Error(w, "level must be one of "+strings.Join(logLevels, ", "), http.StatusBadRequest)
+		return
+	}
+
+	from := strings.ToLower(logLevel.Level().String())
+	setLogLevel(body.Level)
+	slog.WarnContext(r.Context(), "Log level changed", "from", from, "to", body.Level, "remote", r.RemoteAddr)
+	serveLogLevel(w, r)
+}
+
+// requireToken wraps handler so it only serves the requests authenticated with the bearer token,
+// no request is served when token is empty
+func requireToken(token string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/alert"
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/storage"
+)
+
+// alertCheckInterval is how often the runs of the scrapers are checked for alerts
+const alertCheckInterval = 30 * time.Second
+
+// newAlerter creates an alerter sending to the notifiers of the configuration, nil when none is set
+func newAlerter(config *Config) *alert.Alerter {
+	var notifiers []alert.Notifier
+	if config.AlertSlackWebhookURL != "" {
+		notifiers = append(notifiers, alert.NewSlackNotifier(config.AlertSlackWebhookURL))
+	}
+	if config.AlertWebhookURL != "" {
+		notifiers = append(notifiers, alert.NewWebhookNotifier(config.AlertWebhookURL))
+	}
+	if config.AlertSMTPHost != "" {
+		notifiers = append(notifiers, alert.NewEmailNotifier(alert.EmailOptions{
+			Host:     config.AlertSMTPHost,
+			Port:     config.AlertSMTPPort,
+			Username: config.AlertSMTPUsername,
+			Password: config.AlertSMTPPassword,
+			From:     config.AlertEmailFrom,
+			To:       config.AlertEmailTo,
+		}))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return alert.New(notifiers, alert.Options{Repeat: time.Duration(config.AlertRepeatInterval) * time.Minute})
+}
+
+// alertMonitor alerts on scrapers failing repeatedly and on scrapers whose data is older than
+// their freshness SLA
+type alertMonitor struct {
+	alerter   *alert.Alerter
+	tracker   *runTracker
+	threshold int
+
+	mu     sync.Mutex
+	maxAge map[string]time.Duration
+}
+
+// newAlertMonitor creates a monitor of the runs kept by tracker
+func newAlertMonitor(alerter *alert.Alerter, tracker *runTracker, config *Config) *alertMonitor {
+	m := &alertMonitor{alerter: alerter, tracker: tracker, threshold: config.AlertFailureThreshold}
+	m.configure(config)
+	return m
+}
+
+// configure applies the freshness SLAs of a, possibly reloaded, configuration
+func (m *alertMonitor) configure(config *Config) {
+	maxAge := make(map[string]time.Duration)
+	for name, settings := range config.Scrapers {
+		if settings.MaxAge > 0 {
+			maxAge[name] = settings.MaxAge
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxAge = maxAge
+}
+
+// Run checks the runs every alertCheckInterval until the context is cancelled
+func (m *alertMonitor) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Alert monitor started", "failure_threshold", m.threshold)
+	defer slog.InfoContext(context.Background(), "Alert monitor stopped")
+
+	ticker := time.NewTicker(alertCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx, time.Now())
+		}
+	}
+}
+
+// check fires or resolves the alerts of every scraper
+func (m *alertMonitor) check(ctx context.Context, now time.Time) {
+	m.mu.Lock()
+	maxAge := m.maxAge
+	m.mu.Unlock()
+
+	snapshot := m.tracker.snapshot()
+	for _, name := range slices.Sorted(maps.Keys(snapshot)) {
+		runs := snapshot[name]
+
+		key := "failures:" + name
+		// A source that changed its format fails until the scraper is adapted, there is no point
+		// in waiting for the threshold
+		permanent := runs.LastErrorClass == errclass.Parse || runs.LastErrorClass == errclass.SourceChanged
+		if runs.ConsecutiveFailures > 0 && permanent {
+			m.alerter.Fire(ctx, alert.Alert{
+				Key:   key,
+				Title: fmt.Sprintf("Scraper %s cannot handle the responses of its source, it may have changed", name),
+				Text:  fmt.Sprintf("Last error (%s): %s", runs.LastErrorClass, runs.LastError),
+			})
+		} else if runs.ConsecutiveFailures >= m.threshold {
+			m.alerter.Fire(ctx, alert.Alert{
+				Key:   key,
+				Title: fmt.Sprintf("Scraper %s failed %d times in a row", name, runs.ConsecutiveFailures),
+				Text:  fmt.Sprintf("Last error (%s): %s", runs.LastErrorClass, runs.LastError),
+			})
+		} else if runs.ConsecutiveFailures == 0 {
+			m.alerter.Resolve(ctx, key, fmt.Sprintf("Scraper %s succeeded again", name))
+		}
+
+		limit, ok := maxAge[name]
+		if !ok {
+			continue
+		}
+		key = "freshness:" + name
+		if age := m.tracker.sinceSuccess(runs, now); age > limit {
+			text := "No successful run since the process started"
+			if !runs.LastSuccess.IsZero() {
+				text = "Last successful run at " + runs.LastSuccess.Format(time.RFC3339)
+			}
+			m.alerter.Fire(ctx, alert.Alert{
+				Key:   key,
+				Title: fmt.Sprintf("Scraper %s data is %s old, its SLA is %s", name, age.Round(time.Second), limit),
+				Text:  text,
+			})
+		} else {
+			m.alerter.Resolve(ctx, key, fmt.Sprintf("Scraper %s data is fresh again", name))
+		}
+	}
+}
+
+// alertDeadLetter alerts on a message dead-lettered on topic, further messages of the topic are
+// throttled by the alerter
+func alertDeadLetter(ctx context.Context, alerter *alert.Alerter, topic string, message queue.Message, cause error) {
+	alerter.Fire(ctx, alert.Alert{
+		Key:   "dlq:" + topic,
+		Title: fmt.Sprintf("Message dead-lettered on %s", topic),
+		Text:  fmt.Sprintf("Message %s (%s): %v", message.ID, errclass.Of(cause), cause),
+	})
+}
+
+// alertAnomaly alerts on an implausible value, further anomalies of the series are throttled by
+// the alerter
+func alertAnomaly(ctx context.Context, alerter *alert.Alerter, anomaly storage.Anomaly) {
+	point := anomaly.Point
+	action := "stored with an anomaly tag"
+	if anomaly.Quarantined {
+		action = "quarantined"
+	}
+	alerter.Fire(ctx, alert.Alert{
+		Key:   "anomaly:" + point.Source + "/" + point.Code,
+		Title: fmt.Sprintf("Implausible value of %s/%s", point.Source, point.Code),
+		Text: fmt.Sprintf("Value %g at %s after %g, %s score %.1f, %s", point.Value, point.Timestamp.Format(time.RFC3339),
+			anomaly.Previous, anomaly.Reason, anomaly.Score, action),
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"macrochain/scraper/pkg/backup"
+	"macrochain/scraper/pkg/featureflag"
+)
+
+// backupOptions returns the backup options of the configuration
+func backupOptions(config *Config) backup.Options {
+	return backup.Options{
+		Format:  config.BackupFormat,
+		Sources: config.BackupSources,
+		Keep:    config.BackupKeep,
+	}
+}
+
+// runBackupCommand runs the backup subcommand, it exports the stored points to the given
+// directory, by default a new timestamped directory under BACKUP_DIR whose old backups beyond
+// BACKUP_KEEP are then deleted
+func runBackupCommand(ctx context.Context, config *Config, args []string) error {
+	dir := filepath.Join(config.BackupDir, time.Now().UTC().Format(backup.DirLayout))
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	pool, err := newDBPool(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	repository, err := newStorage(pool, config)
+	if err != nil {
+		return err
+	}
+	manifest, err := backup.Export(ctx, repository, dir, backupOptions(config))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d files to %s\n", len(manifest.Files), dir)
+
+	if len(args) == 0 {
+		deleted, err := backup.Prune(config.BackupDir, config.BackupKeep)
+		if err != nil {
+			return err
+		}
+		for _, name := range deleted {
+			fmt.Printf("Deleted old backup %s\n", name)
+		}
+	}
+	return nil
+}
+
+// runRestoreCommand runs the restore subcommand, it writes the points of a backup directory
+// to the configured storage backend
+func runRestoreCommand(ctx context.Context, config *Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected the backup directory to restore")
+	}
+
+	store, closeStorage, err := newStorageBackend(ctx, config, featureflag.New(config.FeatureFlags))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	written, err := backup.Restore(ctx, args[0], store)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d points from %s\n", written, args[0])
+	return nil
+}
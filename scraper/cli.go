@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"macrochain/scraper/pkg/storage"
+)
+
+// flagKeys maps the command line flags to the configuration options they override
+var flagKeys = map[string]string{
+	"log-level": "LOG_LEVEL",
+	"profile":   "PROFILE",
+	"scraper":   "ENABLED_SCRAPERS",
+}
+
+// cli holds the state shared by the commands
+type cli struct {
+	configFile string
+	once       bool
+	flags      *pflag.FlagSet
+	config     *Config
+}
+
+// load reads the configuration with the flags of the command applied over the file and environment
+func (c *cli) load() (*Config, error) {
+	return LoadConfig(c.configFile, c.flags)
+}
+
+// watch reloads the configuration in the background, see watchConfig
+func (c *cli) watch(cmd *cobra.Command, apply func(*Config)) error {
+	remote, err := watchRemoteConfig(cmd.Context(), c.config)
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(c.config.ConfigWatchInterval) * time.Second
+	go watchConfig(cmd.Context(), c.configFile, interval, remote, c.config, c.load, apply)
+	return nil
+}
+
+// newRootCommand creates the scraper command, it scrapes on schedule without a subcommand
+func newRootCommand() *cobra.Command {
+	c := &cli{}
+
+	root := &cobra.Command{
+		Use:          "scraper",
+		Short:        "Scrape macroeconomic and market data and publish the results",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			c.flags = cmd.Flags()
+			config, err := c.load()
+			if err != nil {
+				return err
+			}
+			c.config = config
+
+			slog.SetDefault(SetupLogger(config))
+			slog.InfoContext(cmd.Context(), "Effective configuration", "config", redactedConfig(config))
+			ctx, stop := context.WithCancelCause(cmd.Context())
+			cmd.SetContext(ctx)
+			watchSecrets(ctx, config, stop)
+			return nil
+		},
+		// A command stopped by a secret rotation fails, so the process exits with exitSecretsRotated
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			if errors.Is(context.Cause(cmd.Context()), errSecretsRotated) {
+				return errSecretsRotated
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScrapeCommand(cmd, c)
+		},
+	}
+
+	root.PersistentFlags().StringVar(&c.configFile, "config", os.Getenv("CONFIG_FILE"), "path of a yaml, toml or json config file, environment variables override its values")
+	root.PersistentFlags().String("profile", "", "set of defaults (dev, staging or prod), overrides PROFILE")
+	root.PersistentFlags().String("log-level", "", "log level (debug, info, warn or error), overrides LOG_LEVEL")
+	root.Flags().BoolVar(&c.once, "once", false, "run every enabled scraper once and exit")
+	root.Flags().StringSlice("scraper", nil, "scraper to run, may be repeated, overrides ENABLED_SCRAPERS")
+
+	var showFormat string
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration with the secrets masked",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigShowCommand(cmd.OutOrStdout(), c.config, showFormat)
+		},
+	}
+	show.Flags().StringVarP(&showFormat, "output", "o", "yaml", "output format, yaml or json")
+	configCommand := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	configCommand.AddCommand(show)
+
+	flagsCommand := &cobra.Command{
+		Use:   "flags",
+		Short: "List and toggle the feature flags",
+	}
+	flagsCommand.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "Print the value of every feature flag",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runFlagsListCommand(cmd.Context(), cmd.OutOrStdout(), c.config)
+			},
+		},
+		&cobra.Command{
+			Use:   "set <name> <true|false>",
+			Short: "Toggle a feature flag in Redis for every running process",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runFlagsSetCommand(cmd.Context(), c.config, args[0], args[1])
+			},
+		},
+		&cobra.Command{
+			Use:   "unset <name>",
+			Short: "Remove a feature flag from Redis so its configured value applies again",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runFlagsSetCommand(cmd.Context(), c.config, args[0], "")
+			},
+		},
+	)
+
+	var quarantineSource, quarantineCode string
+	quarantineList := &cobra.Command{
+		Use:   "list",
+		Short: "Print the implausible points held back by the anomaly detector",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQuarantineListCommand(cmd.Context(), cmd.OutOrStdout(), c.config, quarantineSource, quarantineCode)
+		},
+	}
+	quarantineList.Flags().StringVar(&quarantineSource, "source", "", "only print the points of a source")
+	quarantineList.Flags().StringVar(&quarantineCode, "code", "", "only print the points of a series")
+	quarantineCommand := &cobra.Command{
+		Use:   "quarantine",
+		Short: "Review the quarantined points",
+	}
+	quarantineCommand.AddCommand(
+		quarantineList,
+		&cobra.Command{
+			Use:   "release <source> [code]",
+			Short: "Store the quarantined points of a source or series, tagged as anomalous",
+			Args:  cobra.RangeArgs(1, 2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runQuarantineReleaseCommand(cmd.Context(), cmd.OutOrStdout(), c.config, args[0], optionalArg(args, 1), false)
+			},
+		},
+		&cobra.Command{
+			Use:   "discard <source> [code]",
+			Short: "Delete the quarantined points of a source or series",
+			Args:  cobra.RangeArgs(1, 2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runQuarantineReleaseCommand(cmd.Context(), cmd.OutOrStdout(), c.config, args[0], optionalArg(args, 1), true)
+			},
+		},
+	)
+
+	var runsFilter storage.RunFilter
+	var runsSince time.Duration
+	runsCommand := &cobra.Command{
+		Use:   "runs",
+		Short: "Print the recorded scraper runs, the latest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runsSince > 0 {
+				runsFilter.Since = time.Now().Add(-runsSince)
+			}
+			return runRunsCommand(cmd.Context(), cmd.OutOrStdout(), c.config, runsFilter)
+		},
+	}
+	runsCommand.Flags().StringVar(&runsFilter.Source, "source", "", "only print the runs of a scraper")
+	runsCommand.Flags().StringVar(&runsFilter.Status, "status", "", "only print the runs with a status (running, succeeded or failed)")
+	runsCommand.Flags().StringVar(&runsFilter.ErrorClass, "class", "", "only print the failed runs with an error class (transient, rate_limited, parse, source_changed or unknown)")
+	runsCommand.Flags().DurationVar(&runsSince, "since", 0, "only print the runs started within a duration, e.g. 24h")
+	runsCommand.Flags().IntVarP(&runsFilter.Limit, "limit", "n", 50, "maximum number of runs printed")
+
+	var freshnessSource string
+	var freshnessStale bool
+	freshnessCommand := &cobra.Command{
+		Use:   "freshness",
+		Short: "Print the age of the newest observation of every cataloged series against its staleness budget",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFreshnessCommand(cmd.Context(), cmd.OutOrStdout(), c.config, freshnessSource, freshnessStale)
+		},
+	}
+	freshnessCommand.Flags().StringVar(&freshnessSource, "source", "", "only print the series of a source")
+	freshnessCommand.Flags().BoolVar(&freshnessStale, "stale", false, "only print the stale series")
+
+	var backfillSince string
+	backfillCommand := &cobra.Command{
+		Use:   "backfill-secondary",
+		Short: "Copy the points stored in the primary backend to the secondary backend",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBackfillCommand(cmd.Context(), c.config, backfillSince)
+		},
+	}
+	backfillCommand.Flags().StringVar(&backfillSince, "since", "", "only copy the points from a date on, e.g. 2020-01-01, by default the whole history")
+
+	root.AddCommand(
+		configCommand,
+		flagsCommand,
+		runsCommand,
+		quarantineCommand,
+		freshnessCommand,
+		backfillCommand,
+		&cobra.Command{
+			Use:   "migrate [up|down [steps]|force <version>|version]",
+			Short: "Apply, revert or show the database schema migrations",
+			Args:  cobra.MaximumNArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runMigrateCommand(cmd.Context(), c.config, args)
+			},
+		},
+		&cobra.Command{
+			Use:   "backup [dir]",
+			Short: "Export the stored points to a backup directory",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runBackupCommand(cmd.Context(), c.config, args)
+			},
+		},
+		&cobra.Command{
+			Use:   "restore <dir>",
+			Short: "Write the points of a backup directory to the storage backend",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runRestoreCommand(cmd.Context(), c.config, args)
+			},
+		},
+		&cobra.Command{
+			Use:   "persist",
+			Short: "Store the results published by the scrapers",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := c.watch(cmd, nil); err != nil {
+					return err
+				}
+				return runPersistCommand(cmd.Context(), c.config)
+			},
+		},
+	)
+	return root
+}
+
+// optionalArg returns the argument at i, empty when there are fewer arguments
+func optionalArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
@@ -1,11 +1,23 @@
 package main
 
 import (
+	"cmp"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/secrets"
+
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the scraper
 type Config struct {
+	Profile        string `mapstructure:"PROFILE"`
 	LogLevel       string `mapstructure:"LOG_LEVEL"`
 	DBHost         string `mapstructure:"DB_HOST"`
 	DBPort         int    `mapstructure:"DB_PORT"`
@@ -14,29 +26,623 @@ type Config struct {
 	DBName         string `mapstructure:"DB_NAME"`
 	RedisHost      string `mapstructure:"REDIS_HOST"`
 	RedisPort      int    `mapstructure:"REDIS_PORT"`
+	RedisUsername  string `mapstructure:"REDIS_USERNAME"`
+	RedisPassword  string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB        int    `mapstructure:"REDIS_DB"`
+	RedisTLS       bool   `mapstructure:"REDIS_TLS"`
+	RedisTLSCA     string `mapstructure:"REDIS_TLS_CA_FILE"`
+	RedisTLSCert   string `mapstructure:"REDIS_TLS_CERT_FILE"`
+	RedisTLSKey    string `mapstructure:"REDIS_TLS_KEY_FILE"`
 	ScrapeInterval int    `mapstructure:"SCRAPE_INTERVAL"`
+
+	RedisPoolSize     int `mapstructure:"REDIS_POOL_SIZE"`
+	RedisMinIdleConns int `mapstructure:"REDIS_MIN_IDLE_CONNS"`
+	RedisDialTimeout  int `mapstructure:"REDIS_DIAL_TIMEOUT"`
+	RedisReadTimeout  int `mapstructure:"REDIS_READ_TIMEOUT"`
+	RedisWriteTimeout int `mapstructure:"REDIS_WRITE_TIMEOUT"`
+
+	DBMaxConns          int32 `mapstructure:"DB_MAX_CONNS"`
+	DBMinConns          int32 `mapstructure:"DB_MIN_CONNS"`
+	DBMaxConnIdleTime   int   `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DBMaxConnLifetime   int   `mapstructure:"DB_MAX_CONN_LIFETIME"`
+	DBHealthCheckPeriod int   `mapstructure:"DB_HEALTH_CHECK_PERIOD"`
+	DBStatementTimeout  int   `mapstructure:"DB_STATEMENT_TIMEOUT"`
+
+	QueueBackend              string   `mapstructure:"QUEUE_BACKEND"`
+	QueueCodec                string   `mapstructure:"QUEUE_CODEC"`
+	QueueCompression          string   `mapstructure:"QUEUE_COMPRESSION"`
+	QueueCompressionThreshold int      `mapstructure:"QUEUE_COMPRESSION_THRESHOLD"`
+	QueueMaxDecompressedSize  int      `mapstructure:"QUEUE_MAX_DECOMPRESSED_SIZE"`
+	RedisDurableTopics        []string `mapstructure:"REDIS_DURABLE_TOPICS"`
+	StreamingResultTTL        int      `mapstructure:"STREAMING_RESULT_TTL"`
+	MigrateOnStart            bool     `mapstructure:"MIGRATE_ON_START"`
+	StorageEnabled            bool     `mapstructure:"STORAGE_ENABLED"`
+	StorageConflictPolicy     string   `mapstructure:"STORAGE_CONFLICT_POLICY"`
+	StorageVintages           bool     `mapstructure:"STORAGE_VINTAGES"`
+	PayloadArchiveEnabled     bool     `mapstructure:"PAYLOAD_ARCHIVE_ENABLED"`
+	StorageBackend            string   `mapstructure:"STORAGE_BACKEND"`
+	StorageSecondaryBackend   string   `mapstructure:"STORAGE_SECONDARY_BACKEND"`
+	StorageSecondaryDBURL     string   `mapstructure:"STORAGE_SECONDARY_DB_URL"`
+	DualWriteVerifyInterval   int      `mapstructure:"DUAL_WRITE_VERIFY_INTERVAL"`
+	DualWriteVerifyLookback   int      `mapstructure:"DUAL_WRITE_VERIFY_LOOKBACK"`
+	DualWriteRepair           bool     `mapstructure:"DUAL_WRITE_REPAIR"`
+	ClickHouseURL             string   `mapstructure:"CLICKHOUSE_URL"`
+	ClickHouseDatabase        string   `mapstructure:"CLICKHOUSE_DATABASE"`
+	ClickHouseUser            string   `mapstructure:"CLICKHOUSE_USER"`
+	ClickHousePassword        string   `mapstructure:"CLICKHOUSE_PASSWORD"`
+	ClickHouseAsyncInsert     bool     `mapstructure:"CLICKHOUSE_ASYNC_INSERT"`
+	InfluxURL                 string   `mapstructure:"INFLUXDB_URL"`
+	InfluxOrg                 string   `mapstructure:"INFLUXDB_ORG"`
+	InfluxBucket              string   `mapstructure:"INFLUXDB_BUCKET"`
+	InfluxToken               string   `mapstructure:"INFLUXDB_TOKEN"`
+	StorageTimescale          bool     `mapstructure:"STORAGE_TIMESCALE"`
+	ArchiveEnabled            bool     `mapstructure:"ARCHIVE_ENABLED"`
+	ArchiveInterval           int      `mapstructure:"ARCHIVE_INTERVAL"`
+	ArchiveLookback           int      `mapstructure:"ARCHIVE_LOOKBACK"`
+	ArchivePrefix             string   `mapstructure:"ARCHIVE_PREFIX"`
+	BackupEnabled             bool     `mapstructure:"BACKUP_ENABLED"`
+	BackupDir                 string   `mapstructure:"BACKUP_DIR"`
+	BackupInterval            int      `mapstructure:"BACKUP_INTERVAL"`
+	BackupFormat              string   `mapstructure:"BACKUP_FORMAT"`
+	BackupSources             []string `mapstructure:"BACKUP_SOURCES"`
+	BackupKeep                int      `mapstructure:"BACKUP_KEEP"`
+	OHLCEnabled               bool     `mapstructure:"OHLC_ENABLED"`
+	OHLCSources               []string `mapstructure:"OHLC_SOURCES"`
+	OHLCCodes                 []string `mapstructure:"OHLC_CODES"`
+	OHLCIntervals             []string `mapstructure:"OHLC_INTERVALS"`
+	S3Endpoint                string   `mapstructure:"S3_ENDPOINT"`
+	S3Region                  string   `mapstructure:"S3_REGION"`
+	S3Bucket                  string   `mapstructure:"S3_BUCKET"`
+	S3AccessKey               string   `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey               string   `mapstructure:"S3_SECRET_KEY"`
+	S3UseSSL                  bool     `mapstructure:"S3_USE_SSL"`
+	StorageChunkInterval      int      `mapstructure:"STORAGE_CHUNK_INTERVAL"`
+	StorageCompressAfter      int      `mapstructure:"STORAGE_COMPRESS_AFTER"`
+	StorageRetention          int      `mapstructure:"STORAGE_RETENTION"`
+	StoragePartitioning       bool     `mapstructure:"STORAGE_PARTITIONING"`
+	StoragePartitionPremake   int      `mapstructure:"STORAGE_PARTITION_PREMAKE"`
+	StoragePartitionInterval  int      `mapstructure:"STORAGE_PARTITION_INTERVAL"`
+	RetentionEnabled          bool     `mapstructure:"RETENTION_ENABLED"`
+	RetentionPolicies         []string `mapstructure:"RETENTION_POLICIES"`
+	RetentionInterval         int      `mapstructure:"RETENTION_INTERVAL"`
+	GapsEnabled               bool     `mapstructure:"GAPS_ENABLED"`
+	GapsInterval              int      `mapstructure:"GAPS_INTERVAL"`
+	GapsLookback              int      `mapstructure:"GAPS_LOOKBACK"`
+	GapsLag                   int      `mapstructure:"GAPS_LAG"`
+	GapsHolidays              []string `mapstructure:"GAPS_HOLIDAYS"`
+	OutboxEnabled             bool     `mapstructure:"OUTBOX_ENABLED"`
+	PersisterPattern          string   `mapstructure:"PERSISTER_PATTERN"`
+	PersisterConcurrency      int      `mapstructure:"PERSISTER_CONCURRENCY"`
+	PersisterBufferDir        string   `mapstructure:"PERSISTER_BUFFER_DIR"`
+	PersisterBufferInterval   int      `mapstructure:"PERSISTER_BUFFER_INTERVAL"`
+	OutboxInterval            int      `mapstructure:"OUTBOX_INTERVAL"`
+	OutboxBatchSize           int      `mapstructure:"OUTBOX_BATCH_SIZE"`
+	RedisStreamGroup          string   `mapstructure:"REDIS_STREAM_GROUP"`
+	RedisStreamConsumer       string   `mapstructure:"REDIS_STREAM_CONSUMER"`
+	RedisStreamMaxLen         int64    `mapstructure:"REDIS_STREAM_MAX_LEN"`
+	RedisStreamClaimIdle      int      `mapstructure:"REDIS_STREAM_CLAIM_IDLE"`
+
+	KafkaBrokers           []string `mapstructure:"KAFKA_BROKERS"`
+	KafkaGroupID           string   `mapstructure:"KAFKA_GROUP_ID"`
+	KafkaPartitions        int      `mapstructure:"KAFKA_PARTITIONS"`
+	KafkaReplicationFactor int      `mapstructure:"KAFKA_REPLICATION_FACTOR"`
+	KafkaPartitionKey      string   `mapstructure:"KAFKA_PARTITION_KEY"`
+
+	EnabledScrapers []string `mapstructure:"ENABLED_SCRAPERS"`
+	EthRPCURL       string   `mapstructure:"ETH_RPC_URL"`
+
+	// Scrapers holds the configuration section of each scraper by name, set in the config file
+	Scrapers map[string]scraper.Settings `mapstructure:"SCRAPERS"`
+	// APIKeys holds the credential of each data source by scraper name, the api_key of a scraper
+	// section and the legacy per-source options such as EIA_API_KEY are used when it has none
+	APIKeys map[string]scraper.Credential `mapstructure:"API_KEYS"`
+
+	StablecoinContracts   []string `mapstructure:"STABLECOIN_CONTRACTS"`
+	ContractLogFilters    string   `mapstructure:"CONTRACT_LOG_FILTERS"`
+	ContractLogStartBlock uint64   `mapstructure:"CONTRACT_LOG_START_BLOCK"`
+
+	BeaconchainAPIURL string `mapstructure:"BEACONCHAIN_API_URL"`
+	BeaconchainAPIKey string `mapstructure:"BEACONCHAIN_API_KEY"`
+
+	BitcoinRPCURL      string `mapstructure:"BITCOIN_RPC_URL"`
+	BitcoinRPCUser     string `mapstructure:"BITCOIN_RPC_USER"`
+	BitcoinRPCPassword string `mapstructure:"BITCOIN_RPC_PASSWORD"`
+	MempoolSpaceAPIURL string `mapstructure:"MEMPOOL_SPACE_API_URL"`
+
+	DefiLlamaAPIURL    string   `mapstructure:"DEFILLAMA_API_URL"`
+	DefiLlamaProtocols []string `mapstructure:"DEFILLAMA_PROTOCOLS"`
+	UniswapSubgraphURL string   `mapstructure:"UNISWAP_SUBGRAPH_URL"`
+	UniswapPools       []string `mapstructure:"UNISWAP_POOLS"`
+	AaveDataProvider   string   `mapstructure:"AAVE_DATA_PROVIDER"`
+	AaveReserves       []string `mapstructure:"AAVE_RESERVES"`
+	CompoundMarkets    []string `mapstructure:"COMPOUND_MARKETS"`
+	CurvePools         []string `mapstructure:"CURVE_POOLS"`
+	LidoAPIURL         string   `mapstructure:"LIDO_API_URL"`
+	MakerVaultTypes    []string `mapstructure:"MAKER_VAULT_TYPES"`
+	ChainlinkFeeds     []string `mapstructure:"CHAINLINK_FEEDS"`
+
+	EtherscanAPIURL string `mapstructure:"ETHERSCAN_API_URL"`
+	EtherscanAPIKey string `mapstructure:"ETHERSCAN_API_KEY"`
+
+	CoinGeckoAPIKey        string   `mapstructure:"COINGECKO_API_KEY"`
+	CoinGeckoPro           bool     `mapstructure:"COINGECKO_PRO"`
+	CoinGeckoCoins         []string `mapstructure:"COINGECKO_COINS"`
+	CoinGeckoMonthlyBudget int      `mapstructure:"COINGECKO_MONTHLY_BUDGET"`
+
+	BinanceAPIURL  string   `mapstructure:"BINANCE_API_URL"`
+	BinanceSymbols []string `mapstructure:"BINANCE_SYMBOLS"`
+
+	BinanceStreamURL string   `mapstructure:"BINANCE_STREAM_URL"`
+	BinanceStreams   []string `mapstructure:"BINANCE_STREAMS"`
+
+	CoinbaseAPIURL            string   `mapstructure:"COINBASE_API_URL"`
+	CoinbaseProducts          []string `mapstructure:"COINBASE_PRODUCTS"`
+	CoinbaseCandleGranularity int      `mapstructure:"COINBASE_CANDLE_GRANULARITY"`
+
+	KrakenAPIURL string   `mapstructure:"KRAKEN_API_URL"`
+	KrakenPairs  []string `mapstructure:"KRAKEN_PAIRS"`
+
+	BinanceFuturesAPIURL string   `mapstructure:"BINANCE_FUTURES_API_URL"`
+	BybitAPIURL          string   `mapstructure:"BYBIT_API_URL"`
+	OKXAPIURL            string   `mapstructure:"OKX_API_URL"`
+	DeribitAPIURL        string   `mapstructure:"DERIBIT_API_URL"`
+	DerivativesAssets    []string `mapstructure:"DERIVATIVES_ASSETS"`
+
+	L2Chains []string `mapstructure:"L2_CHAINS"`
+
+	YahooFinanceAPIURL string   `mapstructure:"YAHOO_FINANCE_API_URL"`
+	EquityIndices      []string `mapstructure:"EQUITY_INDICES"`
+
+	VIXHistoryURL string `mapstructure:"VIX_HISTORY_URL"`
+
+	LBMAAPIURL        string `mapstructure:"LBMA_API_URL"`
+	FrankfurterAPIURL string `mapstructure:"FRANKFURTER_API_URL"`
+
+	EIAAPIURL string   `mapstructure:"EIA_API_URL"`
+	EIAAPIKey string   `mapstructure:"EIA_API_KEY"`
+	EIASeries []string `mapstructure:"EIA_SERIES"`
+
+	FXBase    string   `mapstructure:"FX_BASE"`
+	FXSymbols []string `mapstructure:"FX_SYMBOLS"`
+
+	MockSeries []string `mapstructure:"MOCK_SERIES"`
+
+	SecretsBackend         string `mapstructure:"SECRETS_BACKEND"`
+	SecretsCacheTTL        int    `mapstructure:"SECRETS_CACHE_TTL"`
+	SecretsRefreshInterval int    `mapstructure:"SECRETS_REFRESH_INTERVAL"`
+	VaultAddr              string `mapstructure:"VAULT_ADDR"`
+	VaultToken             string `mapstructure:"VAULT_TOKEN"`
+	VaultNamespace         string `mapstructure:"VAULT_NAMESPACE"`
+	AWSRegion              string `mapstructure:"AWS_REGION"`
+	AWSAccessKeyID         string `mapstructure:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey     string `mapstructure:"AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken        string `mapstructure:"AWS_SESSION_TOKEN"`
+	SecretsManagerEndpoint string `mapstructure:"SECRETS_MANAGER_ENDPOINT"`
+
+	ConfigWatchInterval int `mapstructure:"CONFIG_WATCH_INTERVAL"`
+
+	RemoteConfigStore         string `mapstructure:"REMOTE_CONFIG_STORE"`
+	RemoteConfigAddress       string `mapstructure:"REMOTE_CONFIG_ADDRESS"`
+	RemoteConfigKey           string `mapstructure:"REMOTE_CONFIG_KEY"`
+	RemoteConfigFormat        string `mapstructure:"REMOTE_CONFIG_FORMAT"`
+	RemoteConfigToken         string `mapstructure:"REMOTE_CONFIG_TOKEN"`
+	RemoteConfigWatchInterval int    `mapstructure:"REMOTE_CONFIG_WATCH_INTERVAL"`
+
+	HTTPProxy  string `mapstructure:"HTTP_PROXY"`
+	HTTPSProxy string `mapstructure:"HTTPS_PROXY"`
+	NoProxy    string `mapstructure:"NO_PROXY"`
+
+	// FeatureFlags enables or disables features by name, set in the config file
+	FeatureFlags                map[string]bool `mapstructure:"FEATURE_FLAGS"`
+	FeatureFlagsRedis           bool            `mapstructure:"FEATURE_FLAGS_REDIS"`
+	FeatureFlagsRedisKey        string          `mapstructure:"FEATURE_FLAGS_REDIS_KEY"`
+	FeatureFlagsRefreshInterval int             `mapstructure:"FEATURE_FLAGS_REFRESH_INTERVAL"`
+
+	HTTPAddr           string `mapstructure:"HTTP_ADDR"`
+	HealthStallTimeout int    `mapstructure:"HEALTH_STALL_TIMEOUT"`
+
+	TracingEnabled     bool    `mapstructure:"TRACING_ENABLED"`
+	TracingEndpoint    string  `mapstructure:"TRACING_ENDPOINT"`
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	AlertSlackWebhookURL  string   `mapstructure:"ALERT_SLACK_WEBHOOK_URL"`
+	AlertWebhookURL       string   `mapstructure:"ALERT_WEBHOOK_URL"`
+	AlertSMTPHost         string   `mapstructure:"ALERT_SMTP_HOST"`
+	AlertSMTPPort         int      `mapstructure:"ALERT_SMTP_PORT"`
+	AlertSMTPUsername     string   `mapstructure:"ALERT_SMTP_USERNAME"`
+	AlertSMTPPassword     string   `mapstructure:"ALERT_SMTP_PASSWORD"`
+	AlertEmailFrom        string   `mapstructure:"ALERT_EMAIL_FROM"`
+	AlertEmailTo          []string `mapstructure:"ALERT_EMAIL_TO"`
+	AlertFailureThreshold int      `mapstructure:"ALERT_FAILURE_THRESHOLD"`
+	AlertRepeatInterval   int      `mapstructure:"ALERT_REPEAT_INTERVAL"`
+
+	SentryDSN            string  `mapstructure:"SENTRY_DSN"`
+	SentryEnvironment    string  `mapstructure:"SENTRY_ENVIRONMENT"`
+	SentrySampleRate     float64 `mapstructure:"SENTRY_SAMPLE_RATE"`
+	SentryPayloadSnippet int     `mapstructure:"SENTRY_PAYLOAD_SNIPPET"`
+
+	LogPayloads           bool `mapstructure:"LOG_PAYLOADS"`
+	LogSamplingFirst      int  `mapstructure:"LOG_SAMPLING_FIRST"`
+	LogSamplingThereafter int  `mapstructure:"LOG_SAMPLING_THEREAFTER"`
+
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	HeartbeatEnabled  bool   `mapstructure:"HEARTBEAT_ENABLED"`
+	HeartbeatInterval int    `mapstructure:"HEARTBEAT_INTERVAL"`
+	HeartbeatTopic    string `mapstructure:"HEARTBEAT_TOPIC"`
+	HeartbeatPingURL  string `mapstructure:"HEARTBEAT_PING_URL"`
+
+	FreshnessEnabled  bool     `mapstructure:"FRESHNESS_ENABLED"`
+	FreshnessInterval int      `mapstructure:"FRESHNESS_INTERVAL"`
+	FreshnessBudgets  []string `mapstructure:"FRESHNESS_BUDGETS"`
+
+	AnomalyEnabled      bool     `mapstructure:"ANOMALY_ENABLED"`
+	AnomalySources      []string `mapstructure:"ANOMALY_SOURCES"`
+	AnomalyAction       string   `mapstructure:"ANOMALY_ACTION"`
+	AnomalyWindow       int      `mapstructure:"ANOMALY_WINDOW"`
+	AnomalyMinHistory   int      `mapstructure:"ANOMALY_MIN_HISTORY"`
+	AnomalyZScore       float64  `mapstructure:"ANOMALY_ZSCORE"`
+	AnomalyMinDeviation float64  `mapstructure:"ANOMALY_MIN_DEVIATION"`
+	AnomalyMaxJump      float64  `mapstructure:"ANOMALY_MAX_JUMP"`
+	AnomalyRegimeChange int      `mapstructure:"ANOMALY_REGIME_CHANGE"`
+
+	QuotaDeferThreshold float64 `mapstructure:"QUOTA_DEFER_THRESHOLD"`
+	QuotaRedis          bool    `mapstructure:"QUOTA_REDIS"`
+	QuotaRedisPrefix    string  `mapstructure:"QUOTA_REDIS_PREFIX"`
+
+	CursorRedis       bool   `mapstructure:"CURSOR_REDIS"`
+	CursorRedisPrefix string `mapstructure:"CURSOR_REDIS_PREFIX"`
+
+	// secrets resolved the secret references of the configuration, nil without a secrets backend
+	secrets *secrets.Resolver
+	// remote holds the settings of the remote configuration read, nil without a remote store or
+	// when it was unavailable
+	remote map[string]any
 }
 
-func LoadConfig() (*Config, error) {
+// clearableLists are the list keys an empty environment variable clears
+var clearableLists = []string{"REDIS_DURABLE_TOPICS"}
+
+// LoadConfig loads the configuration from the defaults, the optional config file at path, the
+// environment and the command line flags set in flags, in increasing order of precedence. The file
+// format follows its extension (yaml, toml or json) and its keys are the environment variable
+// names in any case, e.g. db_host
+func LoadConfig(path string, flags *pflag.FlagSet) (*Config, error) {
 	v := viper.New()
 
+	v.SetDefault("PROFILE", "") // dev, staging or prod, selects a set of defaults
 	v.SetDefault("LOG_LEVEL", "info")
-	v.SetDefault("DB_HOST", "localhost")
+	v.SetDefault("CONFIG_WATCH_INTERVAL", 10) // Seconds between checks of the config file for changes, 0 reloads on SIGHUP only
+	v.SetDefault("REMOTE_CONFIG_STORE", "")   // consul or etcd, shared configuration read over the config file
+	v.SetDefault("REMOTE_CONFIG_ADDRESS", "http://localhost:8500")
+	v.SetDefault("REMOTE_CONFIG_KEY", "macrochain/scraper.yaml")
+	v.SetDefault("REMOTE_CONFIG_FORMAT", "yaml")     // yaml, toml or json
+	v.SetDefault("REMOTE_CONFIG_TOKEN", "")          // Consul ACL token or etcd auth token
+	v.SetDefault("REMOTE_CONFIG_WATCH_INTERVAL", 30) // Seconds between checks of the remote configuration for changes, 0 disables them
+	v.SetDefault("DB_HOST", "")                      // Without one the scraper runs without a database unless a feature needs it
 	v.SetDefault("DB_PORT", 5432)
 	v.SetDefault("DB_USER", "postgres")
 	v.SetDefault("DB_PASSWORD", "postgres")
 	v.SetDefault("DB_NAME", "macrochain")
+	v.SetDefault("DB_MAX_CONNS", 10)
+	v.SetDefault("DB_MIN_CONNS", 0)
+	v.SetDefault("DB_MAX_CONN_IDLE_TIME", 300) // Seconds before an idle connection is closed
+	v.SetDefault("DB_MAX_CONN_LIFETIME", 3600) // Seconds before a connection is replaced
+	v.SetDefault("DB_HEALTH_CHECK_PERIOD", 30) // Seconds between checks of idle connections
+	v.SetDefault("DB_STATEMENT_TIMEOUT", 0)    // Milliseconds a statement may run, 0 disables the timeout
 	v.SetDefault("REDIS_HOST", "localhost")
 	v.SetDefault("REDIS_PORT", 6379)
-	v.SetDefault("SCRAPE_INTERVAL", 60) // 1 minute in seconds
+	v.SetDefault("REDIS_USERNAME", "")
+	v.SetDefault("REDIS_PASSWORD", "")
+	v.SetDefault("REDIS_DB", 0)
+	v.SetDefault("REDIS_TLS", false)
+	v.SetDefault("REDIS_TLS_CA_FILE", "") // Defaults to the system CAs
+	v.SetDefault("REDIS_TLS_CERT_FILE", "")
+	v.SetDefault("REDIS_TLS_KEY_FILE", "")
+	v.SetDefault("REDIS_POOL_SIZE", 10)
+	v.SetDefault("REDIS_MIN_IDLE_CONNS", 2)
+	v.SetDefault("REDIS_DIAL_TIMEOUT", 5000)            // Milliseconds to establish a connection
+	v.SetDefault("REDIS_READ_TIMEOUT", 3000)            // Milliseconds a command may wait for its reply, blocking reads wait longer
+	v.SetDefault("REDIS_WRITE_TIMEOUT", 3000)           // Milliseconds to send a command
+	v.SetDefault("SCRAPE_INTERVAL", 60)                 // 1 minute in seconds
+	v.SetDefault("QUEUE_BACKEND", "redis")              // redis (pub/sub), redis_streams or kafka
+	v.SetDefault("QUEUE_CODEC", "json")                 // json, protobuf or msgpack, consumers decode all of them
+	v.SetDefault("QUEUE_COMPRESSION", "zstd")           // gzip, zstd or empty to disable, consumers decompress both
+	v.SetDefault("QUEUE_MAX_DECOMPRESSED_SIZE", 64<<20) // Bytes a compressed body may decompress to, larger messages are invalid
+	v.SetDefault("QUEUE_COMPRESSION_THRESHOLD", 256*1024)
+	v.SetDefault("REDIS_DURABLE_TOPICS", []string{})        // Glob patterns of the topics delivered at least once by the redis backend, e.g. scraper_results.*
+	v.SetDefault("MIGRATE_ON_START", true)                  // Apply pending schema migrations when the database is used
+	v.SetDefault("STORAGE_ENABLED", false)                  // Persist result data points in Postgres
+	v.SetDefault("STORAGE_CONFLICT_POLICY", "overwrite")    // overwrite revised observations or keep the first one
+	v.SetDefault("STORAGE_VINTAGES", false)                 // Keep every revision of a data point with the time it was observed
+	v.SetDefault("PAYLOAD_ARCHIVE_ENABLED", false)          // Store the raw payloads fetched by scrapers in Postgres for reprocessing
+	v.SetDefault("STORAGE_BACKEND", "postgres")             // postgres, timescale, clickhouse or influxdb, used by the persist command
+	v.SetDefault("STORAGE_SECONDARY_BACKEND", "")           // Backend also written while migrating to it, empty disables dual writes
+	v.SetDefault("STORAGE_SECONDARY_DB_URL", "")            // Postgres URL of a postgres or timescale secondary backend
+	v.SetDefault("DUAL_WRITE_VERIFY_INTERVAL", 60)          // Minutes between comparisons of the primary and secondary backends
+	v.SetDefault("DUAL_WRITE_VERIFY_LOOKBACK", 24)          // Hours of data points compared
+	v.SetDefault("DUAL_WRITE_REPAIR", false)                // Copy the points of the primary to the secondary for the series that differ
+	v.SetDefault("CLICKHOUSE_URL", "http://localhost:8123") // HTTP interface of the clickhouse backend
+	v.SetDefault("CLICKHOUSE_DATABASE", "default")
+	v.SetDefault("CLICKHOUSE_USER", "default")
+	v.SetDefault("CLICKHOUSE_PASSWORD", "")
+	v.SetDefault("CLICKHOUSE_ASYNC_INSERT", true)         // Let the server batch small inserts such as ticks
+	v.SetDefault("INFLUXDB_URL", "http://localhost:8086") // API of the influxdb backend
+	v.SetDefault("INFLUXDB_ORG", "macrochain")
+	v.SetDefault("INFLUXDB_BUCKET", "macrochain")
+	v.SetDefault("INFLUXDB_TOKEN", "")
+	v.SetDefault("STORAGE_TIMESCALE", false)                              // Store data points in a TimescaleDB hypertable
+	v.SetDefault("STORAGE_CHUNK_INTERVAL", 168)                           // Hours of data points per hypertable chunk
+	v.SetDefault("STORAGE_COMPRESS_AFTER", 30)                            // Days before chunks are compressed, -1 disables compression
+	v.SetDefault("STORAGE_RETENTION", 0)                                  // Days data points are kept, 0 keeps them forever
+	v.SetDefault("STORAGE_PARTITIONING", false)                           // Partition data points by month with native Postgres partitions, instead of TimescaleDB
+	v.SetDefault("STORAGE_PARTITION_PREMAKE", 3)                          // Months partitions are created ahead
+	v.SetDefault("STORAGE_PARTITION_INTERVAL", 6)                         // Hours between partition maintenance runs of the persist command
+	v.SetDefault("RETENTION_ENABLED", false)                              // Downsample and purge data points in the background, run by the persist command
+	v.SetDefault("RETENTION_POLICIES", []string{"binance_stream:7:90:0"}) // sources:raw_days:hourly_days:purge_days, 0 keeps forever
+	v.SetDefault("RETENTION_INTERVAL", 60)                                // Minutes between retention runs
+	v.SetDefault("GAPS_ENABLED", false)                                   // Record and warn about missing observations of cataloged series, run by the persist command
+	v.SetDefault("GAPS_INTERVAL", 60)                                     // Minutes between checks
+	v.SetDefault("GAPS_LOOKBACK", 30)                                     // Days checked
+	v.SetDefault("GAPS_LAG", 24)                                          // Hours after its date an observation may be published
+	v.SetDefault("GAPS_HOLIDAYS", []string{})                             // source=date or source/code=date, days without observation such as exchange holidays
+	v.SetDefault("ARCHIVE_ENABLED", false)                                // Export completed days of data points and raw payloads to Parquet on S3, run by the persist command
+	v.SetDefault("ARCHIVE_INTERVAL", 60)                                  // Minutes between looks for days to archive
+	v.SetDefault("ARCHIVE_LOOKBACK", 7)                                   // Completed days archived when missing or changed since
+	v.SetDefault("ARCHIVE_PREFIX", "macrochain")
+	v.SetDefault("BACKUP_ENABLED", false)      // Export every series to BACKUP_DIR periodically, run by the persist command
+	v.SetDefault("BACKUP_DIR", "backups")      // Backups are written to timestamped directories below it
+	v.SetDefault("BACKUP_INTERVAL", 24)        // Hours between backups
+	v.SetDefault("BACKUP_FORMAT", "csv")       // csv (gzip) or parquet
+	v.SetDefault("BACKUP_SOURCES", []string{}) // Empty backs up every source
+	v.SetDefault("BACKUP_KEEP", 7)             // Complete backups kept in BACKUP_DIR, older ones are deleted, 0 keeps them all
+	v.SetDefault("OHLC_ENABLED", false)        // Roll ticks into OHLCV bar series, run by the persist command
+	v.SetDefault("OHLC_SOURCES", []string{"binance_stream"})
+	v.SetDefault("OHLC_CODES", []string{"*_trade_price"}) // Glob patterns of the tick series
+	v.SetDefault("OHLC_INTERVALS", []string{"1m", "5m", "1h", "1d"})
+	v.SetDefault("S3_ENDPOINT", "localhost:9000") // S3 or MinIO host
+	v.SetDefault("S3_REGION", "")
+	v.SetDefault("S3_BUCKET", "macrochain-archive")
+	v.SetDefault("S3_ACCESS_KEY", "")
+	v.SetDefault("S3_SECRET_KEY", "")
+	v.SetDefault("S3_USE_SSL", false)
+	v.SetDefault("OUTBOX_ENABLED", false) // Publish results through a Postgres outbox table
+	v.SetDefault("OUTBOX_INTERVAL", 1000) // Milliseconds between polls of an empty outbox
+	v.SetDefault("OUTBOX_BATCH_SIZE", 100)
+	v.SetDefault("PERSISTER_PATTERN", "scraper_results.*") // Result topics stored by the persist command
+	v.SetDefault("PERSISTER_CONCURRENCY", 1)
+	v.SetDefault("PERSISTER_BUFFER_DIR", "")     // Buffer results on disk while the storage backend is unreachable, empty disables buffering
+	v.SetDefault("PERSISTER_BUFFER_INTERVAL", 5) // Seconds between attempts to drain the buffer
+	v.SetDefault("STREAMING_RESULT_TTL", 300)    // Seconds before real-time results are discarded unprocessed
+	v.SetDefault("REDIS_STREAM_GROUP", "macrochain")
+	v.SetDefault("REDIS_STREAM_CONSUMER", "") // Defaults to the hostname
+	v.SetDefault("REDIS_STREAM_MAX_LEN", 100000)
+	v.SetDefault("REDIS_STREAM_CLAIM_IDLE", 60) // 1 minute in seconds
+	v.SetDefault("KAFKA_BROKERS", []string{"localhost:9092"})
+	v.SetDefault("KAFKA_GROUP_ID", "macrochain")
+	v.SetDefault("KAFKA_PARTITIONS", 3)
+	v.SetDefault("KAFKA_REPLICATION_FACTOR", 1)
+	v.SetDefault("KAFKA_PARTITION_KEY", "source")                    // Keeps the results of a source in order
+	v.SetDefault("ENABLED_SCRAPERS", []string{"snb_interest_rates"}) // A scraper section may enable or disable a scraper as well
+	v.SetDefault("ETH_RPC_URL", "")
+	v.SetDefault("STABLECOIN_CONTRACTS", scraper.DefaultStablecoinContracts)
+	v.SetDefault("CONTRACT_LOG_FILTERS", "") // label:address:signature entries separated by ";"
+	v.SetDefault("CONTRACT_LOG_START_BLOCK", 0)
+	v.SetDefault("BEACONCHAIN_API_URL", "https://beaconcha.in")
+	v.SetDefault("BEACONCHAIN_API_KEY", "")
+	v.SetDefault("BITCOIN_RPC_URL", "")
+	v.SetDefault("BITCOIN_RPC_USER", "")
+	v.SetDefault("BITCOIN_RPC_PASSWORD", "")
+	v.SetDefault("MEMPOOL_SPACE_API_URL", "https://mempool.space")
+	v.SetDefault("DEFILLAMA_API_URL", "https://api.llama.fi")
+	v.SetDefault("DEFILLAMA_PROTOCOLS", scraper.DefaultDefiLlamaProtocols)
+	v.SetDefault("UNISWAP_SUBGRAPH_URL", "") // The Graph gateway URL including the API key
+	v.SetDefault("UNISWAP_POOLS", scraper.DefaultUniswapPools)
+	v.SetDefault("AAVE_DATA_PROVIDER", scraper.DefaultAaveDataProvider)
+	v.SetDefault("AAVE_RESERVES", scraper.DefaultAaveReserves)
+	v.SetDefault("COMPOUND_MARKETS", scraper.DefaultCompoundMarkets)
+	v.SetDefault("CURVE_POOLS", scraper.DefaultCurvePools)
+	v.SetDefault("LIDO_API_URL", "https://eth-api.lido.fi")
+	v.SetDefault("MAKER_VAULT_TYPES", scraper.DefaultMakerVaultTypes)
+	v.SetDefault("CHAINLINK_FEEDS", scraper.DefaultChainlinkFeeds)
+	v.SetDefault("ETHERSCAN_API_URL", "https://api.etherscan.io")
+	v.SetDefault("ETHERSCAN_API_KEY", "")
+	v.SetDefault("COINGECKO_API_KEY", "")
+	v.SetDefault("COINGECKO_PRO", false)
+	v.SetDefault("COINGECKO_COINS", scraper.DefaultCoinGeckoCoins)
+	v.SetDefault("COINGECKO_MONTHLY_BUDGET", 10000) // Demo plan call credits
+	v.SetDefault("BINANCE_API_URL", "https://api.binance.com")
+	v.SetDefault("BINANCE_SYMBOLS", scraper.DefaultBinanceSymbols)
+	v.SetDefault("BINANCE_STREAM_URL", "wss://stream.binance.com:9443")
+	v.SetDefault("BINANCE_STREAMS", scraper.DefaultBinanceStreams)
+	v.SetDefault("COINBASE_API_URL", "https://api.exchange.coinbase.com")
+	v.SetDefault("COINBASE_PRODUCTS", scraper.DefaultCoinbaseProducts)
+	v.SetDefault("COINBASE_CANDLE_GRANULARITY", scraper.DefaultCoinbaseGranularity)
+	v.SetDefault("KRAKEN_API_URL", "https://api.kraken.com")
+	v.SetDefault("KRAKEN_PAIRS", scraper.DefaultKrakenPairs)
+	v.SetDefault("BINANCE_FUTURES_API_URL", scraper.DefaultBinanceFuturesAPIURL)
+	v.SetDefault("BYBIT_API_URL", scraper.DefaultBybitAPIURL)
+	v.SetDefault("OKX_API_URL", scraper.DefaultOKXAPIURL)
+	v.SetDefault("DERIBIT_API_URL", scraper.DefaultDeribitAPIURL)
+	v.SetDefault("DERIVATIVES_ASSETS", scraper.DefaultDerivativesAssets)
+	v.SetDefault("L2_CHAINS", scraper.DefaultL2Chains)
+	v.SetDefault("YAHOO_FINANCE_API_URL", "https://query1.finance.yahoo.com")
+	v.SetDefault("EQUITY_INDICES", scraper.DefaultEquityIndices)
+	v.SetDefault("VIX_HISTORY_URL", scraper.DefaultVIXHistoryURL)
+	v.SetDefault("LBMA_API_URL", "https://prices.lbma.org.uk")
+	v.SetDefault("FRANKFURTER_API_URL", "https://api.frankfurter.app")
+	v.SetDefault("EIA_API_URL", "https://api.eia.gov")
+	v.SetDefault("EIA_API_KEY", "")
+	v.SetDefault("EIA_SERIES", scraper.DefaultEIASeries)
+	v.SetDefault("FX_BASE", scraper.DefaultFXBase)
+	v.SetDefault("FX_SYMBOLS", scraper.DefaultFXSymbols)
+	v.SetDefault("MOCK_SERIES", scraper.DefaultMockSeries)
+	v.SetDefault("SECRETS_BACKEND", "")         // vault or aws, values of the form secret:name#key are then read from it
+	v.SetDefault("SECRETS_CACHE_TTL", 5)        // Minutes secrets are cached
+	v.SetDefault("SECRETS_REFRESH_INTERVAL", 0) // Minutes between checks for rotated secrets, the process exits with code 75 to be restarted with them, 0 disables the checks
+	v.SetDefault("VAULT_ADDR", "http://localhost:8200")
+	v.SetDefault("VAULT_TOKEN", "")
+	v.SetDefault("VAULT_NAMESPACE", "")
+	v.SetDefault("AWS_REGION", "us-east-1")
+	v.SetDefault("AWS_ACCESS_KEY_ID", "")
+	v.SetDefault("AWS_SECRET_ACCESS_KEY", "")
+	v.SetDefault("AWS_SESSION_TOKEN", "")
+	v.SetDefault("SECRETS_MANAGER_ENDPOINT", "") // Defaults to the regional endpoint
+	v.SetDefault("HTTP_PROXY", "")               // Proxy of the scraper requests to http sources, the lowercase variables are read as well
+	v.SetDefault("HTTPS_PROXY", "")              // Proxy of the scraper requests to https sources
+	v.SetDefault("NO_PROXY", "")                 // Comma separated hosts, domains and CIDRs reached directly
+
+	v.SetDefault("FEATURE_FLAGS_REDIS", false) // Flags toggled at runtime in a Redis hash override the configured ones
+	v.SetDefault("FEATURE_FLAGS_REDIS_KEY", "macrochain:feature_flags")
+	v.SetDefault("FEATURE_FLAGS_REFRESH_INTERVAL", 10) // Seconds between reads of the flags toggled in Redis
+	v.SetDefault("HTTP_ADDR", ":8080")                 // Address of the /healthz and /readyz endpoints, empty disables them
+	v.SetDefault("HEALTH_STALL_TIMEOUT", 300)          // Seconds without progress of the scheduler loop before /healthz fails
+	v.SetDefault("TRACING_ENABLED", false)             // Export OpenTelemetry spans of the scrapes, queue and storage
+	v.SetDefault("TRACING_ENDPOINT", "http://localhost:4318/v1/traces")
+	v.SetDefault("TRACING_SAMPLE_RATIO", 1.0) // Share of the traces exported, from 0 to 1
+
+	v.SetDefault("ALERT_SLACK_WEBHOOK_URL", "") // Slack incoming webhook receiving the alerts
+	v.SetDefault("ALERT_WEBHOOK_URL", "")       // HTTP endpoint receiving the alerts as JSON
+	v.SetDefault("ALERT_SMTP_HOST", "")         // SMTP server sending the alerts by email to ALERT_EMAIL_TO
+	v.SetDefault("ALERT_SMTP_PORT", 587)
+	v.SetDefault("ALERT_SMTP_USERNAME", "")
+	v.SetDefault("ALERT_SMTP_PASSWORD", "")
+	v.SetDefault("ALERT_EMAIL_FROM", "")
+	v.SetDefault("ALERT_EMAIL_TO", []string{})
+	v.SetDefault("ALERT_FAILURE_THRESHOLD", 3) // Consecutive failed runs of a scraper before an alert
+	v.SetDefault("ALERT_REPEAT_INTERVAL", 60)  // Minutes before an alert of a lasting problem is sent again
+
+	v.SetDefault("SENTRY_DSN", "")         // Sentry DSN receiving the errors and panics of the runs, empty disables reporting
+	v.SetDefault("SENTRY_ENVIRONMENT", "") // Defaults to PROFILE
+	v.SetDefault("SENTRY_SAMPLE_RATE", 1.0)
+	v.SetDefault("SENTRY_PAYLOAD_SNIPPET", 1024) // Bytes of the raw payload attached to an event, 0 attaches none
 
+	v.SetDefault("LOG_PAYLOADS", false)          // Log the body of every message received from Redis
+	v.SetDefault("LOG_SAMPLING_FIRST", 100)      // Records of a message logged per second before sampling, 0 disables sampling
+	v.SetDefault("LOG_SAMPLING_THEREAFTER", 100) // Log every Nth record of a message once sampling started, 0 drops them
+
+	v.SetDefault("ADMIN_TOKEN", "") // Bearer token required by the /admin endpoints, empty disables them
+
+	v.SetDefault("HEARTBEAT_ENABLED", false)
+	v.SetDefault("HEARTBEAT_INTERVAL", 30)                  // Seconds between two heartbeats
+	v.SetDefault("HEARTBEAT_TOPIC", "monitoring.heartbeat") // Topic the heartbeats are published to, empty publishes none
+	v.SetDefault("HEARTBEAT_PING_URL", "")                  // Dead-man's switch URL requested on every heartbeat, e.g. a healthchecks.io check
+
+	v.SetDefault("FRESHNESS_ENABLED", false)
+	v.SetDefault("FRESHNESS_INTERVAL", 15)        // Minutes between two checks of the freshness of the cataloged series
+	v.SetDefault("FRESHNESS_BUDGETS", []string{}) // source=duration or source/code=duration, overriding the budget of the frequency of series
+
+	v.SetDefault("ANOMALY_ENABLED", false)
+	v.SetDefault("ANOMALY_SOURCES", []string{"*"}) // Glob patterns of the sources whose new observations are checked
+	v.SetDefault("ANOMALY_ACTION", "tag")          // tag stores implausible values with anomaly metadata, quarantine holds them back
+	v.SetDefault("ANOMALY_WINDOW", 30)             // Recent values of a series a new observation is compared to
+	v.SetDefault("ANOMALY_MIN_HISTORY", 10)        // Values a series needs before the z-score applies
+	v.SetDefault("ANOMALY_ZSCORE", 6.0)            // Deviations from the mean of the window above which a value is implausible
+	v.SetDefault("ANOMALY_MIN_DEVIATION", 0.05)    // Lowest deviation as a share of the mean, for series that were constant
+	v.SetDefault("ANOMALY_MAX_JUMP", 10.0)         // Largest change relative to the previous value, 0 disables the check
+	v.SetDefault("ANOMALY_REGIME_CHANGE", 3)       // Consecutive implausible values at one level that become the new level, 0 disables it
+
+	v.SetDefault("QUOTA_DEFER_THRESHOLD", 0.9) // Share of the daily or monthly request quota of a scraper after which its runs are deferred
+	v.SetDefault("QUOTA_REDIS", false)         // Count the quota windows in Redis, shared by the replicas and kept across restarts
+	v.SetDefault("QUOTA_REDIS_PREFIX", "macrochain:quota")
+
+	v.SetDefault("CURSOR_REDIS", true) // Keep the scraper cursors, e.g. the last scanned block, in Redis across restarts
+	v.SetDefault("CURSOR_REDIS_PREFIX", "macrochain:cursor")
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+	// Keys of the file sections are overridden by their path, e.g. SCRAPERS_FX_RATES_URL
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	// Viper ignores empty variables, an empty list variable clears the list of the config file
+	for _, key := range clearableLists {
+		if value, ok := os.LookupEnv(key); ok && value == "" {
+			v.Set(key, []string{})
+		}
+	}
+	if err := bindScraperEnv(v); err != nil {
+		return nil, err
+	}
+	// REDIS_TLS_ENABLED is accepted as well for REDIS_TLS
+	if err := v.BindEnv("REDIS_TLS", "REDIS_TLS", "REDIS_TLS_ENABLED"); err != nil {
+		return nil, fmt.Errorf("failed to bind REDIS_TLS: %w", err)
+	}
+	for name, key := range flagKeys {
+		if flags == nil || flags.Lookup(name) == nil {
+			continue
+		}
+		if err := v.BindPFlag(key, flags.Lookup(name)); err != nil {
+			return nil, fmt.Errorf("failed to bind flag --%s: %w", name, err)
+		}
+	}
+	if err := applyProfile(v); err != nil {
+		return nil, err
+	}
+	remote, err := readRemoteConfig(v)
+	if err != nil {
+		return nil, err
+	}
 
 	var config Config
-	err := v.Unmarshal(&config)
+	err = v.Unmarshal(&config)
 	if err != nil {
 		return nil, err
 	}
+	config.remote = remote
+	// The flags of the file are merged with the defaults instead of replacing them
+	config.FeatureFlags = withDefaultFlags(config.FeatureFlags)
 
+	if config.SecretsBackend != "" {
+		if err := resolveSecrets(&config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
+
+// bindScraperEnv binds the SCRAPERS_<NAME>_<KEY> variables that are set to the keys of the scraper
+// sections. AutomaticEnv only overrides keys viper knows, i.e. the ones of the config file
+func bindScraperEnv(v *viper.Viper) error {
+	keys := settingsKeys()
+	for _, env := range os.Environ() {
+		variable, _, _ := strings.Cut(env, "=")
+		rest, ok := strings.CutPrefix(variable, "SCRAPERS_")
+		if !ok {
+			continue
+		}
+		for _, key := range keys {
+			name, ok := strings.CutSuffix(rest, "_"+strings.ToUpper(key))
+			if !ok || name == "" {
+				continue
+			}
+			if err := v.BindEnv("scrapers."+strings.ToLower(name)+"."+key, variable); err != nil {
+				return fmt.Errorf("failed to bind %s: %w", variable, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// settingsKeys returns the keys of a scraper section, longest first so a variable matches the
+// key it ends with instead of a shorter one
+func settingsKeys() []string {
+	settings := reflect.TypeFor[scraper.Settings]()
+	keys := make([]string, 0, settings.NumField())
+	for i := range settings.NumField() {
+		if key := settings.Field(i).Tag.Get("mapstructure"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+	return keys
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+)
+
+// newCursorStore creates the store of the scraper cursors in Redis when CURSOR_REDIS is set, nil
+// keeps them in memory
+func newCursorStore(config *Config) (*scraper.RedisCursorStore, error) {
+	if !config.CursorRedis {
+		return nil, nil
+	}
+	client, err := queue.NewRedisClient(config.RedisHost, config.RedisPort, newRedisConnection(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return scraper.NewRedisCursorStore(client, config.CursorRedisPrefix+":"), nil
+}
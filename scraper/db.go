@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"macrochain/scraper/pkg/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newDBPool connects to the Postgres database of the configuration, with the pool limits of the configuration
+func newDBPool(ctx context.Context, config *Config) (*pgxpool.Pool, error) {
+	return connectDB(ctx, config, "")
+}
+
+// connectDB connects to the Postgres database at dsn, or to the one of the configuration when dsn
+// is empty, with the pool limits of the configuration
+func connectDB(ctx context.Context, config *Config, dsn string) (*pgxpool.Pool, error) {
+	poolConfig, err := newDBPoolConfig(config, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return pool, nil
+}
+
+// newDBPoolConfig builds the pool configuration of dsn, defaulting to the database of the
+// configuration. Non-positive limits keep the pgxpool defaults
+func newDBPoolConfig(config *Config, dsn string) (*pgxpool.Config, error) {
+	if dsn == "" {
+		if config.DBHost == "" {
+			return nil, fmt.Errorf("DB_HOST is not set")
+		}
+		dsn = (&url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(config.DBUser, config.DBPassword),
+			Host:   net.JoinHostPort(config.DBHost, strconv.Itoa(config.DBPort)),
+			Path:   config.DBName,
+		}).String()
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database configuration: %w", err)
+	}
+
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = min(config.DBMinConns, poolConfig.MaxConns)
+	}
+	if config.DBMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = time.Duration(config.DBMaxConnIdleTime) * time.Second
+	}
+	if config.DBMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = time.Duration(config.DBMaxConnLifetime) * time.Second
+	}
+	if config.DBHealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = time.Duration(config.DBHealthCheckPeriod) * time.Second
+	}
+	// The timeout is a session setting so it applies to every statement of every connection,
+	// including the ones of transactions
+	if config.DBStatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(config.DBStatementTimeout)
+	}
+	return poolConfig, nil
+}
+
+// newStorage creates the data point repository of the configuration
+func newStorage(pool *pgxpool.Pool, config *Config) (*storage.PostgresRepository, error) {
+	conflict, err := storage.ParseConflictPolicy(config.StorageConflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewPostgresRepository(pool, storage.PostgresOptions{
+		Conflict: conflict,
+		Vintages: config.StorageVintages,
+		Timescale: storage.TimescaleOptions{
+			Enabled:       config.StorageTimescale || config.StorageBackend == "timescale",
+			ChunkInterval: time.Duration(config.StorageChunkInterval) * time.Hour,
+			CompressAfter: time.Duration(config.StorageCompressAfter) * 24 * time.Hour,
+			RetainFor:     time.Duration(config.StorageRetention) * 24 * time.Hour,
+		},
+		Partitioning: storage.PartitionOptions{
+			Enabled:   config.StoragePartitioning,
+			Premake:   config.StoragePartitionPremake,
+			RetainFor: time.Duration(config.StorageRetention) * 24 * time.Hour,
+		},
+	}), nil
+}
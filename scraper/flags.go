@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"macrochain/scraper/pkg/featureflag"
+	"macrochain/scraper/pkg/queue"
+)
+
+// Feature flags of the pipeline, any other flag of FEATURE_FLAGS may gate a scraper with the flag
+// setting of its section
+const (
+	// flagPayloadArchive gates storing the raw payloads of the scrapes when PAYLOAD_ARCHIVE_ENABLED is set
+	flagPayloadArchive = "payload_archive"
+	// flagSecondaryStorage gates the writes to STORAGE_SECONDARY_BACKEND, it is read at startup
+	flagSecondaryStorage = "secondary_storage"
+)
+
+// defaultFeatureFlags are the flags enabled unless FEATURE_FLAGS disables them
+var defaultFeatureFlags = map[string]bool{
+	flagPayloadArchive:   true,
+	flagSecondaryStorage: true,
+}
+
+// withDefaultFlags returns the configured flags with the default flags they do not set
+func withDefaultFlags(configured map[string]bool) map[string]bool {
+	flags := maps.Clone(defaultFeatureFlags)
+	maps.Copy(flags, configured)
+	return flags
+}
+
+// newFeatureFlags creates the feature flags of the configuration. When the flags are toggled in
+// Redis they are read before returning and refreshed in the background until the context is cancelled
+func newFeatureFlags(ctx context.Context, config *Config) (*featureflag.Flags, error) {
+	flags := featureflag.New(config.FeatureFlags)
+	if !config.FeatureFlagsRedis {
+		return flags, nil
+	}
+
+	store, err := newFeatureFlagStore(config)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := flags.Refresh(ctx, store); err != nil {
+		store.Close()
+		return nil, err
+	}
+	go func() {
+		defer store.Close()
+		flags.Watch(ctx, store, time.Duration(config.FeatureFlagsRefreshInterval)*time.Second)
+	}()
+	return flags, nil
+}
+
+// newFeatureFlagStore creates the store of the flags toggled in Redis
+func newFeatureFlagStore(config *Config) (*featureflag.RedisStore, error) {
+	client, err := queue.NewRedisClient(config.RedisHost, config.RedisPort, newRedisConnection(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return featureflag.NewRedisStore(client, config.FeatureFlagsRedisKey), nil
+}
+
+// runFlagsListCommand runs the flags list subcommand, it prints the value of every feature flag
+func runFlagsListCommand(ctx context.Context, out io.Writer, config *Config) error {
+	flags := featureflag.New(config.FeatureFlags)
+	if config.FeatureFlagsRedis {
+		store, err := newFeatureFlagStore(config)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		if _, err := flags.Refresh(ctx, store); err != nil {
+			return err
+		}
+	}
+
+	all := flags.All()
+	for _, name := range slices.Sorted(maps.Keys(all)) {
+		fmt.Fprintf(out, "%s\t%t\n", name, all[name])
+	}
+	return nil
+}
+
+// runFlagsSetCommand runs the flags set and unset subcommands, it toggles a feature flag in Redis
+// for every running process, or removes it so the configured value applies again when value is empty
+func runFlagsSetCommand(ctx context.Context, config *Config, name, value string) error {
+	if !config.FeatureFlagsRedis {
+		return fmt.Errorf("feature flags are toggled in Redis, set FEATURE_FLAGS_REDIS to enable it")
+	}
+	store, err := newFeatureFlagStore(config)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if value == "" {
+		return store.Delete(ctx, name)
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q of flag %s, expected true or false", value, name)
+	}
+	return store.Set(ctx, name, enabled)
+}
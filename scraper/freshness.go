@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"macrochain/scraper/pkg/alert"
+	"macrochain/scraper/pkg/storage"
+)
+
+// freshnessMonitor warns, and alerts when an alerter is set, about the cataloged series whose
+// newest stored observation exceeds its staleness budget
+type freshnessMonitor struct {
+	repository *storage.PostgresRepository
+	budgets    storage.StalenessBudgets
+	interval   time.Duration
+	alerter    *alert.Alerter
+	// stale holds the series found stale by the last check, by "source/code"
+	stale map[string]bool
+}
+
+// newFreshnessMonitor creates a monitor checking every series each interval, alerter may be nil
+func newFreshnessMonitor(repository *storage.PostgresRepository, budgets storage.StalenessBudgets, interval time.Duration, alerter *alert.Alerter) *freshnessMonitor {
+	return &freshnessMonitor{
+		repository: repository,
+		budgets:    budgets,
+		interval:   interval,
+		alerter:    alerter,
+		stale:      make(map[string]bool),
+	}
+}
+
+// Run checks the series each interval until the context is cancelled
+func (m *freshnessMonitor) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Freshness monitor started", "interval", m.interval)
+	defer slog.InfoContext(context.Background(), "Freshness monitor stopped")
+
+	for {
+		if err := m.check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to check series freshness", "error", err)
+		}
+
+		select {
+		case <-time.After(m.interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check fires or resolves the freshness alert of every series. Series are logged when they turn
+// stale or fresh again rather than on every check
+func (m *freshnessMonitor) check(ctx context.Context, now time.Time) error {
+	freshness, err := m.repository.Freshness(ctx, "", m.budgets)
+	if err != nil {
+		return err
+	}
+
+	stale := make(map[string]bool)
+	for _, series := range freshness {
+		name := series.Source + "/" + series.Code
+		key := "series_freshness:" + name
+		if !series.Stale(now) {
+			if m.stale[name] {
+				slog.InfoContext(ctx, "Series is fresh again", "source", series.Source, "code", series.Code)
+			}
+			if m.alerter != nil {
+				m.alerter.Resolve(ctx, key, fmt.Sprintf("Series %s is fresh again", name))
+			}
+			continue
+		}
+
+		stale[name] = true
+		age := series.Age(now).Round(time.Minute)
+		if !m.stale[name] {
+			slog.WarnContext(ctx, "Series is stale", "source", series.Source, "code", series.Code,
+				"frequency", series.Frequency, "age", age, "budget", series.Budget)
+		}
+		if m.alerter != nil {
+			text := "No observation stored since the series was registered"
+			if !series.Newest.IsZero() {
+				text = "Newest observation at " + series.Newest.UTC().Format(time.RFC3339)
+			}
+			m.alerter.Fire(ctx, alert.Alert{
+				Key:   key,
+				Title: fmt.Sprintf("Series %s is %s old, its budget is %s", name, age, series.Budget),
+				Text:  text,
+			})
+		}
+	}
+	m.stale = stale
+	return nil
+}
+
+// runFreshnessCommand runs the freshness subcommand, it prints the age of the newest observation
+// of the cataloged series of source, or of every source when it is empty, and only the stale
+// ones when staleOnly is set
+func runFreshnessCommand(ctx context.Context, out io.Writer, config *Config, source string, staleOnly bool) error {
+	budgets, err := storage.ParseStalenessBudgets(config.FreshnessBudgets)
+	if err != nil {
+		return err
+	}
+	pool, err := newDBPool(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	freshness, err := storage.NewPostgresRepository(pool, storage.PostgresOptions{}).Freshness(ctx, source, budgets)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tCODE\tFREQUENCY\tNEWEST\tAGE\tBUDGET\tSTATUS")
+	for _, series := range freshness {
+		stale := series.Stale(now)
+		if staleOnly && !stale {
+			continue
+		}
+		newest, budget, status := "-", "-", "fresh"
+		if !series.Newest.IsZero() {
+			newest = series.Newest.UTC().Format(time.RFC3339)
+		}
+		if series.Budget > 0 {
+			budget = series.Budget.String()
+		} else {
+			status = "unmonitored"
+		}
+		if stale {
+			status = "stale"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", series.Source, series.Code, series.Frequency, newest,
+			series.Age(now).Round(time.Minute), budget, status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"macrochain/scraper/pkg/health"
+	"macrochain/scraper/pkg/queue"
+)
+
+// heartbeatType is the type of the heartbeat messages on the monitoring topic
+const heartbeatType = "heartbeat"
+
+// newHeartbeat creates the heartbeat of service publishing to the HEARTBEAT_TOPIC of q and pinging
+// HEARTBEAT_PING_URL, alive is nil for the processes without a scheduler loop
+func newHeartbeat(config *Config, q queue.Queue, service string, alive func() error) *health.Heartbeat {
+	interval := time.Duration(config.HeartbeatInterval) * time.Second
+	instance, _ := os.Hostname()
+	options := health.HeartbeatOptions{
+		Service:  service,
+		Instance: instance,
+		Interval: interval,
+		Alive:    alive,
+		PingURL:  config.HeartbeatPingURL,
+	}
+	if config.HeartbeatTopic != "" {
+		options.Publish = func(ctx context.Context, beat health.Beat) error {
+			body, err := json.Marshal(beat)
+			if err != nil {
+				return fmt.Errorf("failed to marshal heartbeat: %w", err)
+			}
+			// A beat missed by the monitor is superseded by the next one
+			return q.Send(ctx, config.HeartbeatTopic, queue.Message{
+				Type:          heartbeatType,
+				SchemaVersion: 1,
+				Body:          body,
+				TTL:           2 * interval,
+				Metadata:      map[string]string{"source": service},
+			})
+		}
+	}
+	return health.NewHeartbeat(options)
+}
@@ -3,12 +3,35 @@ package main
 import (
 	"log/slog"
 	"os"
+
+	"macrochain/scraper/pkg/logging"
 )
 
+// logLevel is the level of the logger, it can be changed while the process runs
+var logLevel = new(slog.LevelVar)
+
 // SetupLogger configures the slog logger based on configuration
-func SetupLogger(logLevel string) *slog.Logger {
+func SetupLogger(config *Config) *slog.Logger {
+	setLogLevel(config.LogLevel)
+
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	})
+
+	// Messages logged on hot paths, such as every message a streaming scraper receives, are sampled
+	sampled := logging.NewSampler(handler, logging.SamplerOptions{
+		First:      config.LogSamplingFirst,
+		Thereafter: config.LogSamplingThereafter,
+	})
+
+	// Records logged with the context of a scraper run carry its name and run ID
+	return slog.New(logging.NewHandler(sampled))
+}
+
+// setLogLevel changes the level of the logger, unknown levels fall back to info
+func setLogLevel(name string) {
 	var level slog.Level
-	switch logLevel {
+	switch name {
 	case "debug":
 		level = slog.LevelDebug
 	case "info":
@@ -20,10 +43,5 @@ func SetupLogger(logLevel string) *slog.Logger {
 	default:
 		level = slog.LevelInfo
 	}
-
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-	})
-
-	return slog.New(handler)
+	logLevel.Set(level)
 }
@@ -2,57 +2,271 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/health"
+	"macrochain/scraper/pkg/metrics"
+	"macrochain/scraper/pkg/outbox"
 	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"github.com/spf13/cobra"
 )
 
 func main() {
-	config, err := LoadConfig()
+	// Commands shut down gracefully on SIGTERM, e.g. when Kubernetes stops the pod
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := newRootCommand().ExecuteContext(ctx)
+	stop()
+	if errors.Is(err, errSecretsRotated) {
+		os.Exit(exitSecretsRotated)
+	}
 	if err != nil {
-		panic("Failed to load configuration: " + err.Error())
+		os.Exit(1)
 	}
+}
 
-	logger := SetupLogger(config.LogLevel)
-	slog.SetDefault(logger)
+// runScrapeCommand runs the scrapers on their schedules until the process is stopped, or every
+// enabled scraper once with --once
+func runScrapeCommand(cmd *cobra.Command, c *cli) error {
+	ctx, config, logger := cmd.Context(), c.config, slog.Default()
+
+	shutdownTracing, err := setupTracing(ctx, config, "macrochain-scraper")
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	reporter, flushReporter, err := newErrorReporter(config, "macrochain-scraper")
+	if err != nil {
+		return err
+	}
+	defer flushReporter()
 
-	ctx := context.Background()
 	logger.InfoContext(ctx, "Starting Macrochain scraper",
 		"db_host", config.DBHost,
 		"redis_host", config.RedisHost,
+		"queue_backend", config.QueueBackend,
 		"scrape_interval", config.ScrapeInterval)
 
-	redisQueue, err := queue.NewRedisQueue(ctx, config.RedisHost, config.RedisPort)
+	// Kubernetes restarts the process when the loop stalls and routes to it while its dependencies are reachable
+	checks := health.New(health.Options{StallTimeout: time.Duration(config.HealthStallTimeout) * time.Second})
+	tracker := newRunTracker()
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.CollectorFunc(tracker.collect))
+	quotas, err := newQuotaRegistry(config)
+	if err != nil {
+		return err
+	}
+	defer quotas.Close()
+	registry.MustRegister(metrics.CollectorFunc(quotas.collect))
+	queueMetrics := queue.NewMetricsRecorder()
+	registry.MustRegister(queueMetricsCollector(queueMetrics))
+	// The status is served once the scrapers are scheduled
+	mux := http.NewServeMux()
+	mux.Handle("/", checks.Handler())
+	mux.Handle("GET /metrics", metrics.Handler(registry))
+	registerAdmin(mux, config)
+	if config.HTTPAddr != "" && !c.once {
+		if err := serveHTTP(ctx, config.HTTPAddr, mux); err != nil {
+			return err
+		}
+	}
+
+	q, err := newQueue(ctx, config, queueMetrics)
 	if err != nil {
-		panic("Failed to connect to Redis queue: " + err.Error())
+		return fmt.Errorf("failed to connect to queue: %w", err)
+	}
+	defer q.Close()
+	if pinger, ok := q.(queue.Pinger); ok {
+		checks.AddCheck("queue", pinger.Ping)
+	}
+	// The beats stop while the scheduler loop is stalled, so a stuck process trips the dead-man's switch
+	if config.HeartbeatEnabled && !c.once {
+		go newHeartbeat(config, q, "macrochain-scraper", checks.Alive).Run(ctx)
+	}
+
+	flags, err := newFeatureFlags(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+
+	// Results are stored and published directly, or through the outbox when it is enabled
+	results := &pipeline{codec: config.QueueCodec, queue: q, flags: flags, tracker: tracker, reporter: reporter}
+	if config.OutboxEnabled || config.StorageEnabled || config.PayloadArchiveEnabled || config.DBHost != "" {
+		pool, err := newDBPool(ctx, config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer pool.Close()
+		checks.AddCheck("database", pool.Ping)
+
+		if config.MigrateOnStart {
+			if err := migrate(ctx, pool); err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+		}
+
+		if config.StorageEnabled {
+			if results.storage, err = newStorage(pool, config); err != nil {
+				return fmt.Errorf("failed to create storage: %w", err)
+			}
+			if err := results.storage.Migrate(ctx); err != nil {
+				return fmt.Errorf("failed to migrate storage: %w", err)
+			}
+		}
+
+		if config.PayloadArchiveEnabled {
+			results.payloads = storage.NewPostgresRepository(pool, storage.PostgresOptions{})
+		}
+		results.runs = storage.NewPostgresRepository(pool, storage.PostgresOptions{})
+
+		if config.OutboxEnabled {
+			results.outbox = outbox.New(pool)
+			relay := outbox.NewRelay(pool, q, outbox.RelayOptions{
+				Interval:  time.Duration(config.OutboxInterval) * time.Millisecond,
+				BatchSize: config.OutboxBatchSize,
+			})
+			go relay.Run(ctx)
+		}
+	}
+
+	cursors, err := newCursorStore(config)
+	if err != nil {
+		return err
+	}
+	if cursors != nil {
+		defer cursors.Close()
+		scraper.SetCursorStore(cursors)
+	}
+	scraper.SetProxy(proxyConfig(config))
+	scrapers, err := buildScrapers(config)
+	if err != nil {
+		return fmt.Errorf("failed to build scrapers: %w", err)
+	}
+	scrapers = initScrapers(ctx, scrapers, results.runs)
+	tracker.track(scraperNames(scrapers)...)
+	var monitor *alertMonitor
+	if alerter := newAlerter(config); alerter != nil && !c.once {
+		monitor = newAlertMonitor(alerter, tracker, config)
+		go monitor.Run(ctx)
+	}
+	var streaming []string
+	if !c.once {
+		streamingTTL := time.Duration(config.StreamingResultTTL) * time.Second
+		streaming = startStreamingScrapers(ctx, results, streamingTTL, buildStreamingScrapers(config))
+	}
+	plans, err := planScrapers(config, scrapers, quotas)
+	if err != nil {
+		return fmt.Errorf("failed to schedule scrapers: %w", err)
+	}
+	// A single run does not wait for the scheduled slots
+	nextRun := make(map[string]time.Time)
+	if !c.once {
+		for _, s := range scrapers {
+			nextRun[s.Name()] = plans[s.Name()].schedule.Start(time.Now())
+		}
+	}
+	board := newStatusBoard(tracker, flags)
+	board.update(config, plans, nextRun, streaming)
+	mux.Handle("GET /status", board)
+
+	// Reloaded configurations are applied between cycles
+	reloads := make(chan *Config)
+	if !c.once {
+		err := c.watch(cmd, func(updated *Config) {
+			select {
+			case reloads <- updated:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch configuration: %w", err)
+		}
 	}
-	defer redisQueue.Close()
 
 	// Main scraper loop
 	for {
-		// Example log for demonstration
+		checks.Progress()
 		logger.InfoContext(ctx, "Scraper cycle starting")
 
-		// Example of sending a message to a queue
 		message := queue.Message{
-			Body:     []byte("Scraper cycle started"),
-			Metadata: map[string]string{"source": "scraper", "type": "cycle_start"},
+			Type:          "cycle_start",
+			SchemaVersion: 1,
+			Body:          []byte("Scraper cycle started"),
+			Metadata:      map[string]string{"source": "scraper", queue.MetadataType: "cycle_start"},
 		}
 
-		err := redisQueue.Send(ctx, "scraper_events", message)
+		err := q.Send(ctx, "scraper_events", message)
 		if err != nil {
 			logger.ErrorContext(ctx, "Failed to send message to queue", "error", err)
 		}
 
-		// TODO: Implement scrapers for different data sources
-		// - FED data
-		// - SNB data
-		// - Ethereum on-chain data
-		// - DeFi protocols
+		// Run every scraper whose schedule is due
+		now := time.Now()
+		for _, s := range scrapers {
+			if now.Before(nextRun[s.Name()]) {
+				continue
+			}
+			plan := plans[s.Name()]
+			nextRun[s.Name()] = plan.schedule.Next(now)
+			if plan.flag != "" && !flags.Enabled(plan.flag) {
+				logger.DebugContext(ctx, "Scraper skipped, its feature flag is disabled", "scraper", s.Name(), "flag", plan.flag)
+				continue
+			}
+
+			// Deferred runs leave the rest of the quota to the next window
+			if window := plan.quota.Depleted(ctx, config.QuotaDeferThreshold); window != "" {
+				logger.WarnContext(ctx, "Scraper deferred, its request quota is nearly exhausted", "scraper", s.Name(), "quota", plan.quota.Name(), "window", window)
+				continue
+			}
+
+			runCtx := scraper.WithQuota(ctx, plan.quota)
+			if plan.limiter != nil {
+				runCtx = scraper.WithRateLimiter(runCtx, plan.limiter)
+			}
+			if plan.proxy != nil {
+				runCtx = scraper.WithProxy(runCtx, plan.proxy)
+			}
+			if err := runScraper(runCtx, results, s); err != nil {
+				logger.ErrorContext(ctx, "Scraper run failed", "scraper", s.Name(), "error", err, "class", errclass.Of(err))
+			}
+			checks.Progress()
+		}
 
+		board.reschedule(nextRun)
 		logger.InfoContext(ctx, "Scraper cycle completed")
+		if c.once {
+			return nil
+		}
 
 		// Sleep until next cycle
-		time.Sleep(time.Duration(config.ScrapeInterval) * time.Second)
+		select {
+		case <-time.After(time.Duration(config.ScrapeInterval) * time.Second):
+		case <-ctx.Done():
+			logger.InfoContext(context.Background(), "Scraper stopped")
+			return nil
+		case updated := <-reloads:
+			reloaded, reloadedPlans, err := reloadScrapers(ctx, config, updated, scrapers, nextRun, results.runs, quotas)
+			if err != nil {
+				logger.ErrorContext(ctx, "Failed to apply reloaded scrapers", "error", err)
+			} else {
+				scrapers, plans = reloaded, reloadedPlans
+				tracker.track(scraperNames(scrapers)...)
+				board.update(updated, plans, nextRun, streaming)
+			}
+			flags.SetDefaults(updated.FeatureFlags)
+			if monitor != nil {
+				monitor.configure(updated)
+			}
+			config = updated
+		}
 	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"macrochain/scraper/pkg/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrate applies the embedded schema migrations to the database
+func migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+// runMigrateCommand runs the migrate subcommand: up, down [steps], force <version> or version
+func runMigrateCommand(ctx context.Context, config *Config, args []string) error {
+	pool, err := newDBPool(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migrations\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migrations\n", reverted)
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version to force")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Printf("Forced schema version %d\n", version)
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Schema version %d\n", version)
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down [steps], force <version> or version", command)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"macrochain/scraper/pkg/archive"
+	"macrochain/scraper/pkg/backup"
+	"macrochain/scraper/pkg/health"
+	"macrochain/scraper/pkg/metrics"
+	"macrochain/scraper/pkg/persister"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/storage"
+)
+
+// runPersistCommand runs the persist subcommand, it stores the results published by the scrapers
+// until the process is interrupted, finishing the messages in progress before returning
+func runPersistCommand(ctx context.Context, config *Config) error {
+	slog.InfoContext(ctx, "Starting Macrochain persister",
+		"db_host", config.DBHost,
+		"queue_backend", config.QueueBackend,
+		"storage_backend", config.StorageBackend,
+		"secondary_backend", config.StorageSecondaryBackend,
+		"pattern", config.PersisterPattern)
+
+	shutdownTracing, err := setupTracing(ctx, config, "macrochain-persister")
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+	reporter, flushReporter, err := newErrorReporter(config, "macrochain-persister")
+	if err != nil {
+		return err
+	}
+	defer flushReporter()
+
+	queueMetrics := queue.NewMetricsRecorder()
+	q, err := newQueue(ctx, config, queueMetrics)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	// The persister has no scheduler loop, it is ready while its queue and database are reachable
+	checks := health.New(health.Options{StallTimeout: -1})
+	if pinger, ok := q.(queue.Pinger); ok {
+		checks.AddCheck("queue", pinger.Ping)
+	}
+	if config.HTTPAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", checks.Handler())
+		registry := metrics.NewRegistry()
+		registry.MustRegister(queueMetricsCollector(queueMetrics))
+		if reporter, ok := q.(queue.BacklogReporter); ok {
+			registry.MustRegister(backlogCollector(reporter, config.PersisterPattern))
+		}
+		mux.Handle("GET /metrics", metrics.Handler(registry))
+		registerAdmin(mux, config)
+		if err := serveHTTP(ctx, config.HTTPAddr, mux); err != nil {
+			return err
+		}
+	}
+	if config.HeartbeatEnabled {
+		go newHeartbeat(config, q, "macrochain-persister", nil).Run(ctx)
+	}
+
+	flags, err := newFeatureFlags(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	store, closeStorage, err := newStorageBackend(ctx, config, flags)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	alerter := newAlerter(config)
+	primary := store
+	if writer, ok := store.(*storage.DualWriter); ok {
+		primary = writer.Primary()
+		go storage.NewDualWriteVerifier(writer, storage.VerifyOptions{
+			Interval: time.Duration(config.DualWriteVerifyInterval) * time.Minute,
+			Lookback: time.Duration(config.DualWriteVerifyLookback) * time.Hour,
+			Repair:   config.DualWriteRepair,
+		}).Run(ctx)
+	}
+	if pinger, ok := primary.(storage.Pinger); ok {
+		checks.AddCheck("database", pinger.Ping)
+	}
+
+	// Maintenance jobs need the Postgres schema, they only run on the primary backend
+	if repository, ok := primary.(*storage.PostgresRepository); ok {
+		if err := registerCatalog(ctx, config, repository); err != nil {
+			slog.ErrorContext(ctx, "Failed to register series catalog", "error", err)
+		}
+		if config.RetentionEnabled {
+			policies, err := storage.ParseRetentionPolicies(config.RetentionPolicies)
+			if err != nil {
+				return err
+			}
+			interval := time.Duration(config.RetentionInterval) * time.Minute
+			go storage.NewRetentionJob(repository, policies, interval).Run(ctx)
+		}
+		if config.StoragePartitioning {
+			interval := time.Duration(config.StoragePartitionInterval) * time.Hour
+			go storage.NewPartitionJob(repository, interval).Run(ctx)
+		}
+		if config.GapsEnabled {
+			holidays, err := storage.ParseHolidayCalendars(config.GapsHolidays)
+			if err != nil {
+				return err
+			}
+			interval := time.Duration(config.GapsInterval) * time.Minute
+			go storage.NewGapDetector(repository, interval, storage.GapOptions{
+				Lookback: time.Duration(config.GapsLookback) * 24 * time.Hour,
+				Lag:      time.Duration(config.GapsLag) * time.Hour,
+				Holidays: holidays,
+			}).Run(ctx)
+		}
+		if config.FreshnessEnabled {
+			budgets, err := storage.ParseStalenessBudgets(config.FreshnessBudgets)
+			if err != nil {
+				return err
+			}
+			interval := time.Duration(config.FreshnessInterval) * time.Minute
+			go newFreshnessMonitor(repository, budgets, interval, alerter).Run(ctx)
+		}
+		if config.BackupEnabled {
+			interval := time.Duration(config.BackupInterval) * time.Hour
+			go backup.NewJob(repository, config.BackupDir, interval, backupOptions(config)).Run(ctx)
+		}
+		if config.ArchiveEnabled {
+			objects, err := archive.NewS3Store(archive.S3Options{
+				Endpoint:  config.S3Endpoint,
+				Region:    config.S3Region,
+				Bucket:    config.S3Bucket,
+				AccessKey: config.S3AccessKey,
+				SecretKey: config.S3SecretKey,
+				UseSSL:    config.S3UseSSL,
+			})
+			if err != nil {
+				return err
+			}
+			archiver := archive.New(repository, objects, archive.Options{
+				Prefix:   config.ArchivePrefix,
+				Interval: time.Duration(config.ArchiveInterval) * time.Minute,
+				Lookback: config.ArchiveLookback,
+			})
+			go archiver.Run(ctx)
+		}
+	}
+
+	if config.OHLCEnabled {
+		intervals, err := storage.ParseBarIntervals(config.OHLCIntervals)
+		if err != nil {
+			return err
+		}
+		aggregator := storage.NewBarAggregator(store, storage.BarOptions{
+			Sources:   config.OHLCSources,
+			Codes:     config.OHLCCodes,
+			Intervals: intervals,
+		})
+		go aggregator.Run(ctx)
+		store = aggregator
+	}
+
+	// New observations are checked last, so quarantined ticks are not rolled into bars either
+	if config.AnomalyEnabled {
+		options := storage.AnomalyOptions{
+			Sources:      config.AnomalySources,
+			Window:       config.AnomalyWindow,
+			MinHistory:   config.AnomalyMinHistory,
+			ZScore:       config.AnomalyZScore,
+			MinDeviation: config.AnomalyMinDeviation,
+			MaxJump:      config.AnomalyMaxJump,
+			RegimeChange: config.AnomalyRegimeChange,
+		}
+		if repository, ok := primary.(*storage.PostgresRepository); ok {
+			options.History = repository
+			if config.AnomalyAction == "quarantine" {
+				options.Quarantine = repository
+			}
+		}
+		if alerter != nil {
+			options.OnAnomaly = func(ctx context.Context, anomaly storage.Anomaly) {
+				alertAnomaly(ctx, alerter, anomaly)
+			}
+		}
+		store = storage.NewAnomalyDetector(store, options)
+	}
+
+	var sink persister.Store = store
+	if config.PersisterBufferDir != "" {
+		buffer, err := persister.NewBuffer(store, config.PersisterBufferDir, persister.BufferOptions{
+			Interval: time.Duration(config.PersisterBufferInterval) * time.Second,
+		})
+		if err != nil {
+			return err
+		}
+		go buffer.Run(ctx)
+		sink = buffer
+	}
+
+	options := queue.ConsumerOptions{
+		Retry:       queue.DefaultRetryPolicy,
+		Concurrency: config.PersisterConcurrency,
+	}
+	if alerter != nil || reporter != nil {
+		options.OnDeadLetter = func(ctx context.Context, topic string, message queue.Message, cause error) {
+			if alerter != nil {
+				alertDeadLetter(ctx, alerter, topic, message, cause)
+			}
+			if reporter != nil {
+				reporter.captureDeadLetter(ctx, topic, message, cause)
+			}
+		}
+	}
+	return persister.New(sink).Run(ctx, q, config.PersisterPattern, options)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"macrochain/scraper/pkg/featureflag"
+	"macrochain/scraper/pkg/outbox"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// pipeline persists scrape results and publishes them to the queue
+type pipeline struct {
+	codec string
+	queue queue.Queue
+	// outbox publishes through Postgres in the transaction storing the result, nil when disabled
+	outbox *outbox.Outbox
+	// storage persists the points of every result, nil when disabled
+	storage *storage.PostgresRepository
+	// payloads stores the raw payloads fetched by every scrape, nil when disabled
+	payloads *storage.PostgresRepository
+	// runs records every scraper run and catalogs the series of the scrapers, nil without a database
+	runs *storage.PostgresRepository
+	// flags gates the optional behaviors of the pipeline
+	flags *featureflag.Flags
+	// tracker keeps the outcome of the runs for the metrics
+	tracker *runTracker
+	// reporter captures the failed runs in Sentry, nil when disabled
+	reporter *errorReporter
+}
+
+// process stores a result and publishes it to the topic of its source, the message carries the
+// trace of the publication to the consumers
+func (p *pipeline) process(ctx context.Context, ttl time.Duration, result scraper.Result) (err error) {
+	message, err := resultMessage(p.codec, ttl, result)
+	if err != nil {
+		return err
+	}
+	topic := resultTopic(result.Source)
+	ctx, span := queue.StartPublishSpan(ctx, topic, &message)
+	defer func() { endSpan(span, err) }()
+
+	if p.outbox != nil {
+		// The result is only published if it was stored, and is published even if the process stops right after storing it
+		return p.outbox.InTx(ctx, func(tx pgx.Tx) error {
+			if p.storage != nil {
+				storeCtx, storeSpan := startStoreSpan(ctx, result)
+				_, err := p.storage.Save(storeCtx, tx, result)
+				endSpan(storeSpan, err)
+				if err != nil {
+					return err
+				}
+			}
+			if err := p.outbox.Add(ctx, tx, topic, message); err != nil {
+				return fmt.Errorf("failed to publish result: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if p.storage != nil {
+		storeCtx, storeSpan := startStoreSpan(ctx, result)
+		_, err := p.storage.WritePoints(storeCtx, result)
+		endSpan(storeSpan, err)
+		if err != nil {
+			return err
+		}
+	}
+	if err := p.queue.Send(ctx, topic, message); err != nil {
+		return fmt.Errorf("failed to publish result: %w", err)
+	}
+	return nil
+}
+
+// startStoreSpan starts the span of writing the points of a result to the database
+func startStoreSpan(ctx context.Context, result scraper.Result) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "store "+result.Source, trace.WithAttributes(attribute.String("source", result.Source)))
+}
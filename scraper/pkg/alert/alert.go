@@ -0,0 +1,111 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Alert is a notification about a problem of the scrapers, or about its resolution
+type Alert struct {
+	// Key identifies the condition, e.g. failures:fred, repeated alerts of a key are throttled
+	Key string `json:"key"`
+	// Title is a one line summary, e.g. "fred failed 3 times in a row"
+	Title string `json:"title"`
+	// Text describes the problem, e.g. the last error
+	Text string `json:"text,omitempty"`
+	// Resolved tells that the condition of Key cleared
+	Resolved bool      `json:"resolved"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier delivers alerts to a channel such as Slack or email
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Options configures an Alerter
+type Options struct {
+	// Repeat is how long an alert of a key is not sent again while its condition lasts, defaults
+	// to an hour
+	Repeat time.Duration
+}
+
+// Alerter sends alerts to every notifier, throttling the alerts of a condition that lasts
+type Alerter struct {
+	notifiers []Notifier
+	options   Options
+
+	mu     sync.Mutex
+	active map[string]time.Time
+	now    func() time.Time
+}
+
+// New creates an alerter sending to notifiers
+func New(notifiers []Notifier, options Options) *Alerter {
+	if options.Repeat <= 0 {
+		options.Repeat = time.Hour
+	}
+	return &Alerter{
+		notifiers: notifiers,
+		options:   options,
+		active:    make(map[string]time.Time),
+		now:       time.Now,
+	}
+}
+
+// Fire sends an alert unless one of its key was sent within the repeat interval
+func (a *Alerter) Fire(ctx context.Context, alert Alert) {
+	now := a.now()
+	a.mu.Lock()
+	sent, ok := a.active[alert.Key]
+	if ok && now.Sub(sent) < a.options.Repeat {
+		a.mu.Unlock()
+		return
+	}
+	a.active[alert.Key] = now
+	a.mu.Unlock()
+
+	alert.Time = now
+	a.send(ctx, alert)
+}
+
+// Resolve sends the resolution of the condition of key when an alert was sent for it, the next
+// alert of key is sent right away
+func (a *Alerter) Resolve(ctx context.Context, key, title string) {
+	a.mu.Lock()
+	_, ok := a.active[key]
+	delete(a.active, key)
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	a.send(ctx, Alert{Key: key, Title: title, Resolved: true, Time: a.now()})
+}
+
+// send delivers an alert to every notifier, a failing notifier does not keep it from the others
+func (a *Alerter) send(ctx context.Context, alert Alert) {
+	var errs []error
+	for _, notifier := range a.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		slog.ErrorContext(ctx, "Failed to send alert", "key", alert.Key, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Alert sent", "key", alert.Key, "resolved", alert.Resolved)
+}
+
+// subject returns the one line summary of an alert, marking resolutions
+func subject(alert Alert) string {
+	if alert.Resolved {
+		return fmt.Sprintf("[resolved] %s", alert.Title)
+	}
+	return fmt.Sprintf("[alert] %s", alert.Title)
+}
@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder keeps the alerts it is notified of
+type recorder struct {
+	alerts []Alert
+	err    error
+}
+
+func (r *recorder) Notify(ctx context.Context, alert Alert) error {
+	r.alerts = append(r.alerts, alert)
+	return r.err
+}
+
+func TestAlerterThrottlesRepeatedAlerts(t *testing.T) {
+	ctx := context.Background()
+	notifier := &recorder{}
+	alerter := New([]Notifier{notifier}, Options{Repeat: time.Hour})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerter.now = func() time.Time { return now }
+
+	alerter.Fire(ctx, Alert{Key: "failures:fred", Title: "fred failed"})
+	now = now.Add(30 * time.Minute)
+	alerter.Fire(ctx, Alert{Key: "failures:fred", Title: "fred failed"})
+	alerter.Fire(ctx, Alert{Key: "failures:eia", Title: "eia failed"})
+	assert.Len(t, notifier.alerts, 2)
+	assert.Equal(t, now.Add(-30*time.Minute), notifier.alerts[0].Time)
+
+	now = now.Add(time.Hour)
+	alerter.Fire(ctx, Alert{Key: "failures:fred", Title: "fred failed"})
+	assert.Len(t, notifier.alerts, 3)
+}
+
+func TestAlerterResolve(t *testing.T) {
+	ctx := context.Background()
+	notifier := &recorder{}
+	alerter := New([]Notifier{notifier}, Options{})
+
+	// Nothing was fired, there is nothing to resolve
+	alerter.Resolve(ctx, "failures:fred", "fred recovered")
+	assert.Empty(t, notifier.alerts)
+
+	alerter.Fire(ctx, Alert{Key: "failures:fred", Title: "fred failed"})
+	alerter.Resolve(ctx, "failures:fred", "fred recovered")
+	if assert.Len(t, notifier.alerts, 2) {
+		assert.True(t, notifier.alerts[1].Resolved)
+		assert.Equal(t, "fred recovered", notifier.alerts[1].Title)
+	}
+
+	// A resolved condition alerts again right away
+	alerter.Fire(ctx, Alert{Key: "failures:fred", Title: "fred failed"})
+	assert.Len(t, notifier.alerts, 3)
+}
+
+func TestAlerterSendsToEveryNotifier(t *testing.T) {
+	failing := &recorder{err: errors.New("unreachable")}
+	working := &recorder{}
+	alerter := New([]Notifier{failing, working}, Options{})
+
+	alerter.Fire(context.Background(), Alert{Key: "dlq:scraper_results.fred", Title: "message dead-lettered"})
+	assert.Len(t, failing.alerts, 1)
+	assert.Len(t, working.alerts, 1)
+}
@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// EmailOptions configures an EmailNotifier
+type EmailOptions struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth, an empty username sends without auth
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailNotifier sends alerts by email through an SMTP server
+type EmailNotifier struct {
+	options EmailOptions
+	// sendMail is smtp.SendMail, replaced in tests
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a notifier sending through the SMTP server of options
+func NewEmailNotifier(options EmailOptions) *EmailNotifier {
+	return &EmailNotifier{options: options, sendMail: smtp.SendMail}
+}
+
+// Notify sends the alert, the SMTP exchange does not observe the context
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if n.options.Username != "" {
+		auth = smtp.PlainAuth("", n.options.Username, n.options.Password, n.options.Host)
+	}
+	addr := net.JoinHostPort(n.options.Host, strconv.Itoa(n.options.Port))
+	if err := n.sendMail(addr, auth, n.options.From, n.options.To, n.message(alert)); err != nil {
+		return fmt.Errorf("failed to send alert email: %w", err)
+	}
+	return nil
+}
+
+// message formats the alert as a plain text email
+func (n *EmailNotifier) message(alert Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.options.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.options.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.ReplaceAll(subject(alert), "\n", " "))
+	fmt.Fprintf(&b, "Date: %s\r\n", alert.Time.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(alert.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"context"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailNotifier(t *testing.T) {
+	var addr, from string
+	var to []string
+	var message []byte
+	var auth smtp.Auth
+	notifier := NewEmailNotifier(EmailOptions{
+		Host:     "smtp.example.com",
+		Port:     587,
+		Username: "alerts",
+		Password: "password",
+		From:     "scraper@example.com",
+		To:       []string{"ops@example.com", "data@example.com"},
+	})
+	notifier.sendMail = func(a string, au smtp.Auth, f string, t []string, msg []byte) error {
+		addr, auth, from, to, message = a, au, f, t, msg
+		return nil
+	}
+
+	alert := Alert{Title: "fred failed", Text: "timeout\nafter 3 attempts", Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, notifier.Notify(context.Background(), alert))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.NotNil(t, auth)
+	assert.Equal(t, "scraper@example.com", from)
+	assert.Equal(t, []string{"ops@example.com", "data@example.com"}, to)
+	assert.Equal(t, "From: scraper@example.com\r\n"+
+		"To: ops@example.com, data@example.com\r\n"+
+		"Subject: [alert] fred failed\r\n"+
+		"Date: Thu, 01 Jan 2026 00:00:00 +0000\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n"+
+		"timeout\r\nafter 3 attempts\r\n", string(message))
+}
@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// notifyTimeout bounds the requests of the webhook notifiers
+const notifyTimeout = 10 * time.Second
+
+// WebhookNotifier posts alerts as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, httpClient: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Notify posts the alert
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, n.httpClient, n.url, alert)
+}
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to the incoming webhook url
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{url: url, httpClient: &http.Client{Timeout: notifyTimeout}}
+}
+
+// slackMessage is the payload of an incoming webhook
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notify posts the alert as a message
+func (n *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	text := "*" + subject(alert) + "*"
+	if alert.Text != "" {
+		text += "\n" + alert.Text
+	}
+	return postJSON(ctx, n.httpClient, n.url, slackMessage{Text: text})
+}
+
+// postJSON posts payload encoded as JSON to url
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("alert webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	alert := Alert{Key: "failures:fred", Title: "fred failed", Text: "timeout", Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, NewWebhookNotifier(server.URL).Notify(context.Background(), alert))
+	assert.Equal(t, alert, received)
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var received slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil || received.Text == "" {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	require.NoError(t, notifier.Notify(context.Background(), Alert{Title: "fred failed", Text: "timeout"}))
+	assert.Equal(t, "*[alert] fred failed*\ntimeout", received.Text)
+
+	require.NoError(t, notifier.Notify(context.Background(), Alert{Title: "fred recovered", Resolved: true}))
+	assert.Equal(t, "*[resolved] fred recovered*", received.Text)
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Alert{Title: "fred failed"})
+	assert.ErrorContains(t, err, "404: no_service")
+}
@@ -0,0 +1,294 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/storage"
+)
+
+// Reader streams the stored points and raw payloads of a time range, it is implemented by
+// storage.PostgresRepository
+type Reader interface {
+	EachPoint(ctx context.Context, from, to time.Time, fn func(storage.Point) error) error
+	LastPointChange(ctx context.Context, from, to time.Time) (time.Time, error)
+	EachPayload(ctx context.Context, from, to time.Time, fn func(storage.StoredPayload) error) error
+	LastPayloadChange(ctx context.Context, from, to time.Time) (time.Time, error)
+}
+
+// Archived datasets, each is exported to its own prefix
+const (
+	datasetPoints   = "data_points"
+	datasetPayloads = "raw_payloads"
+)
+
+// Options configures what an Archiver exports and how often
+type Options struct {
+	// Prefix is prepended to every object key, defaults to macrochain
+	Prefix string
+	// Interval is how often pending days are looked for, defaults to 1 hour
+	Interval time.Duration
+	// Lookback is how many completed days are archived if missing, defaults to 7
+	Lookback int
+}
+
+// Archiver exports the data points and raw payloads of every completed UTC day to Parquet files
+// partitioned by date and source, e.g. macrochain/data_points/date=2024-05-01/source=snb/part-0.parquet,
+// so the archive can be queried with DuckDB or Spark. Once all files of a day are written it is
+// marked with a _SUCCESS object holding when its rows last changed, a day is exported again when
+// late or revised rows are stored for it
+type Archiver struct {
+	reader  Reader
+	store   ObjectStore
+	options Options
+	now     func() time.Time
+}
+
+// New creates an archiver exporting the points and payloads of reader to store
+func New(reader Reader, store ObjectStore, options Options) *Archiver {
+	if options.Prefix == "" {
+		options.Prefix = "macrochain"
+	}
+	if options.Interval <= 0 {
+		options.Interval = 1 * time.Hour
+	}
+	if options.Lookback <= 0 {
+		options.Lookback = 7
+	}
+
+	return &Archiver{
+		reader:  reader,
+		store:   store,
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// Run archives pending days until the context is cancelled
+func (a *Archiver) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Archiver started", "interval", a.options.Interval, "lookback", a.options.Lookback)
+
+	for {
+		if _, err := a.ArchivePending(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to archive data points", "error", err)
+		}
+
+		select {
+		case <-time.After(a.options.Interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Archiver stopped")
+			return
+		}
+	}
+}
+
+// ArchivePending exports the completed days of the lookback window whose rows are not archived
+// yet or changed since, oldest first, returning how many days were exported
+func (a *Archiver) ArchivePending(ctx context.Context) (int, error) {
+	today := a.now().UTC().Truncate(24 * time.Hour)
+
+	archived := 0
+	for i := a.options.Lookback; i >= 1; i-- {
+		day := today.AddDate(0, 0, -i)
+		exported := false
+		for _, dataset := range []string{datasetPoints, datasetPayloads} {
+			changed, err := a.lastChange(ctx, dataset, day)
+			if err != nil {
+				return archived, err
+			}
+			marked, err := a.marker(ctx, dataset, day)
+			if err != nil {
+				return archived, err
+			}
+			if marked != nil && !changed.After(*marked) {
+				continue
+			}
+
+			if err := a.archive(ctx, dataset, day, changed); err != nil {
+				return archived, err
+			}
+			exported = true
+		}
+		if exported {
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+// ArchiveDay exports the points and payloads of a UTC day, one file per source, replacing earlier
+// exports of the day
+func (a *Archiver) ArchiveDay(ctx context.Context, day time.Time) error {
+	day = day.UTC().Truncate(24 * time.Hour)
+	for _, dataset := range []string{datasetPoints, datasetPayloads} {
+		changed, err := a.lastChange(ctx, dataset, day)
+		if err != nil {
+			return err
+		}
+		if err := a.archive(ctx, dataset, day, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastChange returns when the rows of a dataset stored for a day last changed
+func (a *Archiver) lastChange(ctx context.Context, dataset string, day time.Time) (time.Time, error) {
+	if dataset == datasetPayloads {
+		return a.reader.LastPayloadChange(ctx, day, day.AddDate(0, 0, 1))
+	}
+	return a.reader.LastPointChange(ctx, day, day.AddDate(0, 0, 1))
+}
+
+// marker returns the last change recorded by the _SUCCESS object of a day, nil when the day was
+// not archived. Markers written before changes were recorded hold the zero time
+func (a *Archiver) marker(ctx context.Context, dataset string, day time.Time) (*time.Time, error) {
+	data, err := a.store.Get(ctx, a.dayKey(dataset, day, "_SUCCESS"))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changed time.Time
+	if len(data) > 0 {
+		if changed, err = time.Parse(time.RFC3339Nano, string(data)); err != nil {
+			return nil, fmt.Errorf("invalid archive marker of %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+	return &changed, nil
+}
+
+// archive exports a dataset of a day and marks it with changed, the last change read before the
+// export, so rows stored during the export get the day exported again
+func (a *Archiver) archive(ctx context.Context, dataset string, day, changed time.Time) error {
+	from, to := day, day.AddDate(0, 0, 1)
+	var files int
+	var err error
+	if dataset == datasetPayloads {
+		files, err = exportDay(ctx, a, dataset, day, func(fn func(storage.StoredPayload) error) error {
+			return a.reader.EachPayload(ctx, from, to, fn)
+		}, func(payload storage.StoredPayload) string {
+			return payload.Source
+		}, func(w io.Writer) (rowWriter[storage.StoredPayload], error) {
+			return NewPayloadWriter(w)
+		})
+	} else {
+		files, err = exportDay(ctx, a, dataset, day, func(fn func(storage.Point) error) error {
+			return a.reader.EachPoint(ctx, from, to, fn)
+		}, func(point storage.Point) string {
+			return point.Source
+		}, func(w io.Writer) (rowWriter[storage.Point], error) {
+			return NewPointWriter(w)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to archive %s of %s: %w", dataset, day.Format(time.DateOnly), err)
+	}
+
+	marker := strings.NewReader(changed.UTC().Format(time.RFC3339Nano))
+	if err := a.store.Put(ctx, a.dayKey(dataset, day, "_SUCCESS"), marker, "text/plain"); err != nil {
+		return fmt.Errorf("failed to mark %s of %s as archived: %w", dataset, day.Format(time.DateOnly), err)
+	}
+
+	slog.InfoContext(ctx, "Successfully archived day", "dataset", dataset, "date", day.Format(time.DateOnly), "files", files)
+	return nil
+}
+
+// rowWriter writes the rows of a Parquet file, it is implemented by PointWriter and PayloadWriter
+type rowWriter[T any] interface {
+	Write(row T) error
+	Close() error
+}
+
+// exportDay streams the rows passed by each to one file per source, rows have to arrive ordered
+// by source. Files are uploaded while they are written, returning how many were written
+func exportDay[T any](ctx context.Context, a *Archiver, dataset string, day time.Time, each func(fn func(T) error) error,
+	source func(T) string, create func(io.Writer) (rowWriter[T], error)) (int, error) {
+	files := 0
+	var current string
+	var file *upload
+	var rows rowWriter[T]
+	finish := func() error {
+		if file == nil {
+			return nil
+		}
+		err := file.Close(rows.Close())
+		file = nil
+		if err == nil {
+			files++
+		}
+		return err
+	}
+
+	err := each(func(row T) error {
+		if file != nil && source(row) != current {
+			if err := finish(); err != nil {
+				return err
+			}
+		}
+		if file == nil {
+			current = source(row)
+			file = a.upload(ctx, a.dayKey(dataset, day, "source="+current, "part-0.parquet"))
+			var err error
+			if rows, err = create(file); err != nil {
+				file.Close(err)
+				file = nil
+				return err
+			}
+		}
+		return rows.Write(row)
+	})
+	if err != nil {
+		if file != nil {
+			file.Close(err)
+		}
+		return files, err
+	}
+	return files, finish()
+}
+
+// upload streams a file to the object store while it is written
+type upload struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+// upload starts uploading the object of key, it is written with Write and has to be closed
+func (a *Archiver) upload(ctx context.Context, key string) *upload {
+	pr, pw := io.Pipe()
+	u := &upload{pw: pw, done: make(chan error, 1)}
+	go func() {
+		err := a.store.Put(ctx, key, pr, ContentTypeParquet)
+		// Writes fail instead of blocking once the store stopped reading
+		pr.CloseWithError(err)
+		u.done <- err
+	}()
+	return u
+}
+
+func (u *upload) Write(p []byte) (int, error) {
+	return u.pw.Write(p)
+}
+
+// Close completes the upload, or aborts it when err is not nil, returning the error of either
+func (u *upload) Close(err error) error {
+	if err != nil {
+		u.pw.CloseWithError(err)
+		<-u.done
+		return err
+	}
+	u.pw.Close()
+	return <-u.done
+}
+
+// dayKey returns the key of an object in the partition of a day of a dataset
+func (a *Archiver) dayKey(dataset string, day time.Time, elem ...string) string {
+	return path.Join(append([]string{a.options.Prefix, dataset, "date=" + day.Format(time.DateOnly)}, elem...)...)
+}
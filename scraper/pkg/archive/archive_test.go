@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+// memoryStore keeps uploaded objects in memory
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// memoryReader serves rows the way PostgresRepository does, ordered by source, rows of a range
+// all changed at the time of changed
+type memoryReader struct {
+	points   []storage.Point
+	payloads []storage.StoredPayload
+	changed  time.Time
+}
+
+func (r *memoryReader) EachPoint(ctx context.Context, from, to time.Time, fn func(storage.Point) error) error {
+	for _, point := range r.points {
+		if !point.Timestamp.Before(from) && point.Timestamp.Before(to) {
+			if err := fn(point); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryReader) LastPointChange(ctx context.Context, from, to time.Time) (time.Time, error) {
+	for _, point := range r.points {
+		if !point.Timestamp.Before(from) && point.Timestamp.Before(to) {
+			return r.changed, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func (r *memoryReader) EachPayload(ctx context.Context, from, to time.Time, fn func(storage.StoredPayload) error) error {
+	for _, payload := range r.payloads {
+		if !payload.FetchedAt.Before(from) && payload.FetchedAt.Before(to) {
+			if err := fn(payload); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryReader) LastPayloadChange(ctx context.Context, from, to time.Time) (time.Time, error) {
+	for _, payload := range r.payloads {
+		if !payload.FetchedAt.Before(from) && payload.FetchedAt.Before(to) {
+			return r.changed, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+func readPoints(t *testing.T, data []byte) []parquetPoint {
+	t.Helper()
+	pr, err := reader.NewParquetReader(newBufferFile(data), new(parquetPoint), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	rows := make([]parquetPoint, pr.GetNumRows())
+	require.NoError(t, pr.Read(&rows))
+	return rows
+}
+
+func readPayloads(t *testing.T, data []byte) []parquetPayload {
+	t.Helper()
+	pr, err := reader.NewParquetReader(newBufferFile(data), new(parquetPayload), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	rows := make([]parquetPayload, pr.GetNumRows())
+	require.NoError(t, pr.Read(&rows))
+	return rows
+}
+
+func TestArchivePending(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	reader := &memoryReader{
+		points: []storage.Point{
+			{Source: "binance", Code: "BTCUSDT", Timestamp: day.Add(time.Hour), Value: 60000, Unit: "usdt"},
+			{Source: "binance", Code: "BTCUSDT", Timestamp: day.Add(2 * time.Hour), Value: 60100, Unit: "usdt"},
+			{Source: "snb", Code: "SARON", Timestamp: day, Value: 1.45, Unit: "%", Metadata: map[string]string{"description": "Swiss Average Rate Overnight"}},
+			// Today is not complete yet
+			{Source: "snb", Code: "SARON", Timestamp: day.AddDate(0, 0, 2), Value: 1.5, Unit: "%"},
+		},
+		payloads: []storage.StoredPayload{{
+			Payload: scraper.Payload{URL: "https://data.snb.ch/api/cube/zimoma/data/csv/en", ContentType: "text/csv", Body: []byte("Date;D0;Value"), FetchedAt: day.Add(time.Minute)},
+			Source:  "snb",
+			SHA256:  "9f2c",
+		}},
+		changed: day.AddDate(0, 0, 1).Add(time.Minute),
+	}
+
+	store := &memoryStore{objects: make(map[string][]byte)}
+	archiver := New(reader, store, Options{Lookback: 2})
+	archiver.now = func() time.Time { return day.AddDate(0, 0, 2).Add(6 * time.Hour) }
+
+	archived, err := archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	keys := make([]string, 0, len(store.objects))
+	for key := range store.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{
+		"macrochain/data_points/date=2024-05-01/_SUCCESS",
+		"macrochain/data_points/date=2024-05-01/source=binance/part-0.parquet",
+		"macrochain/data_points/date=2024-05-01/source=snb/part-0.parquet",
+		"macrochain/data_points/date=2024-05-02/_SUCCESS",
+		"macrochain/raw_payloads/date=2024-05-01/_SUCCESS",
+		"macrochain/raw_payloads/date=2024-05-01/source=snb/part-0.parquet",
+		"macrochain/raw_payloads/date=2024-05-02/_SUCCESS",
+	}, keys)
+	assert.Equal(t, "2024-05-02T00:01:00Z", string(store.objects["macrochain/data_points/date=2024-05-01/_SUCCESS"]))
+
+	binance := readPoints(t, store.objects["macrochain/data_points/date=2024-05-01/source=binance/part-0.parquet"])
+	require.Len(t, binance, 2)
+	assert.Equal(t, parquetPoint{Source: "binance", Code: "BTCUSDT", Time: day.Add(time.Hour).UnixMilli(), Value: 60000, Unit: "usdt"}, binance[0])
+
+	snb := readPoints(t, store.objects["macrochain/data_points/date=2024-05-01/source=snb/part-0.parquet"])
+	require.Len(t, snb, 1)
+	assert.JSONEq(t, `{"description": "Swiss Average Rate Overnight"}`, snb[0].Metadata)
+
+	payloads := readPayloads(t, store.objects["macrochain/raw_payloads/date=2024-05-01/source=snb/part-0.parquet"])
+	require.Len(t, payloads, 1)
+	assert.Equal(t, parquetPayload{
+		Source:      "snb",
+		URL:         "https://data.snb.ch/api/cube/zimoma/data/csv/en",
+		FetchedAt:   day.Add(time.Minute).UnixMilli(),
+		SHA256:      "9f2c",
+		ContentType: "text/csv",
+		Body:        "Date;D0;Value",
+	}, payloads[0])
+
+	// Archived days are skipped
+	archived, err = archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+
+	// A point stored late gets its day exported again
+	reader.points = append(reader.points, storage.Point{Source: "snb", Code: "SARON", Timestamp: day.Add(time.Hour), Value: 1.46, Unit: "%"})
+	reader.changed = day.AddDate(0, 0, 2).Add(time.Hour)
+	archived, err = archiver.ArchivePending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	snb = readPoints(t, store.objects["macrochain/data_points/date=2024-05-01/source=snb/part-0.parquet"])
+	assert.Len(t, snb, 2)
+	assert.Equal(t, "2024-05-03T01:00:00Z", string(store.objects["macrochain/data_points/date=2024-05-01/_SUCCESS"]))
+}
+
+// failingStore fails uploads after reading part of them
+type failingStore struct {
+	memoryStore
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		return err
+	}
+	return errors.New("connection reset")
+}
+
+func TestArchiveDay_UploadFailure(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	reader := &memoryReader{
+		points: []storage.Point{{Source: "snb", Code: "SARON", Timestamp: day, Value: 1.45, Unit: "%"}},
+	}
+	store := &failingStore{memoryStore{objects: make(map[string][]byte)}}
+
+	err := New(reader, store, Options{}).ArchiveDay(context.Background(), day)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection reset")
+	assert.Empty(t, store.objects, "a day is not marked when its files failed")
+}
@@ -0,0 +1,198 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"macrochain/scraper/pkg/storage"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ContentTypeParquet is the content type of the archived files
+const ContentTypeParquet = "application/vnd.apache.parquet"
+
+// parquetPoint is the row of a data point file, metadata is kept as a JSON object
+type parquetPoint struct {
+	Source   string  `parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Code     string  `parquet:"name=code, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Time     int64   `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Value    float64 `parquet:"name=value, type=DOUBLE"`
+	Unit     string  `parquet:"name=unit, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Metadata string  `parquet:"name=metadata, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// EncodePoints writes points as a snappy compressed Parquet file
+func EncodePoints(points []storage.Point) ([]byte, error) {
+	var buf bytes.Buffer
+	pw, err := NewPointWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		if err := pw.Write(point); err != nil {
+			return nil, err
+		}
+	}
+	if err := pw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PointWriter streams points to a snappy compressed Parquet file, only the current row group is
+// held in memory
+type PointWriter struct {
+	pw *writer.ParquetWriter
+}
+
+// NewPointWriter creates a writer of the Parquet file written to w, it has to be closed to write
+// the footer of the file
+func NewPointWriter(w io.Writer) (*PointWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetPoint), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &PointWriter{pw: pw}, nil
+}
+
+// Write adds a point to the file
+func (w *PointWriter) Write(point storage.Point) error {
+	row := parquetPoint{
+		Source: point.Source,
+		Code:   point.Code,
+		Time:   point.Timestamp.UnixMilli(),
+		Value:  point.Value,
+		Unit:   point.Unit,
+	}
+	if len(point.Metadata) > 0 {
+		metadata, err := json.Marshal(point.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata of %s: %w", point.Code, err)
+		}
+		row.Metadata = string(metadata)
+	}
+	if err := w.pw.Write(row); err != nil {
+		return fmt.Errorf("failed to write point %s: %w", point.Code, err)
+	}
+	return nil
+}
+
+// Close flushes the last row group and writes the footer of the file
+func (w *PointWriter) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+// parquetPayload is the row of a raw payload file, bodies are stored uncompressed so they can be
+// read without the scraper
+type parquetPayload struct {
+	Source      string `parquet:"name=source, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	URL         string `parquet:"name=url, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	FetchedAt   int64  `parquet:"name=fetched_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	SHA256      string `parquet:"name=sha256, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ContentType string `parquet:"name=content_type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Body        string `parquet:"name=body, type=BYTE_ARRAY"`
+}
+
+// PayloadWriter streams raw payloads to a snappy compressed Parquet file like PointWriter
+type PayloadWriter struct {
+	pw *writer.ParquetWriter
+}
+
+// NewPayloadWriter creates a writer of the Parquet file written to w, it has to be closed to
+// write the footer of the file
+func NewPayloadWriter(w io.Writer) (*PayloadWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetPayload), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &PayloadWriter{pw: pw}, nil
+}
+
+// Write adds a payload to the file
+func (w *PayloadWriter) Write(payload storage.StoredPayload) error {
+	row := parquetPayload{
+		Source:      payload.Source,
+		URL:         payload.URL,
+		FetchedAt:   payload.FetchedAt.UnixMilli(),
+		SHA256:      payload.SHA256,
+		ContentType: payload.ContentType,
+		Body:        string(payload.Body),
+	}
+	if err := w.pw.Write(row); err != nil {
+		return fmt.Errorf("failed to write payload %s: %w", payload.SHA256, err)
+	}
+	return nil
+}
+
+// Close flushes the last row group and writes the footer of the file
+func (w *PayloadWriter) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		return fmt.Errorf("failed to finish parquet file: %w", err)
+	}
+	return nil
+}
+
+// DecodePoints reads the points of a file written by EncodePoints
+func DecodePoints(data []byte) ([]storage.Point, error) {
+	pr, err := reader.NewParquetReader(newBufferFile(data), new(parquetPoint), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+	defer pr.ReadStop()
+
+	rows := make([]parquetPoint, pr.GetNumRows())
+	if err := pr.Read(&rows); err != nil {
+		return nil, fmt.Errorf("failed to read parquet file: %w", err)
+	}
+
+	points := make([]storage.Point, 0, len(rows))
+	for _, row := range rows {
+		point := storage.Point{
+			Source:    row.Source,
+			Code:      row.Code,
+			Timestamp: time.UnixMilli(row.Time).UTC(),
+			Value:     row.Value,
+			Unit:      row.Unit,
+		}
+		if row.Metadata != "" {
+			if err := json.Unmarshal([]byte(row.Metadata), &point.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of %s: %w", row.Code, err)
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// bufferFile is a read-only ParquetFile over an encoded file
+type bufferFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferFile(data []byte) bufferFile {
+	return bufferFile{Reader: bytes.NewReader(data), data: data}
+}
+
+func (f bufferFile) Write(p []byte) (int, error) { return 0, errors.New("read only") }
+func (f bufferFile) Close() error                { return nil }
+
+// Open returns a new reader since the parquet reader opens the file once per column
+func (f bufferFile) Open(name string) (source.ParquetFile, error) { return newBufferFile(f.data), nil }
+
+func (f bufferFile) Create(name string) (source.ParquetFile, error) {
+	return nil, errors.New("read only")
+}
@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ErrNotFound is returned by ObjectStore.Get for objects that do not exist
+var ErrNotFound = errors.New("object not found")
+
+// uploadPartSize is the size of the parts of streamed uploads, each is buffered in memory
+const uploadPartSize = 16 << 20
+
+// ObjectStore stores archive files by key
+type ObjectStore interface {
+	// Put uploads the object read from r until EOF, replacing any object with the same key. An
+	// error of r aborts the upload
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get returns the content of an object, ErrNotFound when it does not exist
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// S3Options configures the bucket of an S3Store
+type S3Options struct {
+	// Endpoint is the host of the S3 compatible service, e.g. s3.amazonaws.com or minio:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// UseSSL connects over HTTPS
+	UseSSL bool
+}
+
+// S3Store stores objects in a bucket of Amazon S3 or a compatible service such as MinIO
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store creates a store writing to the bucket of options
+func NewS3Store(options S3Options) (*S3Store, error) {
+	client, err := minio.New(options.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: options.UseSSL,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: options.Bucket,
+	}, nil
+}
+
+// Put uploads an object of unknown size in parts, replacing any object with the same key
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    uploadPartSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,454 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"macrochain/scraper/pkg/archive"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+)
+
+// Formats of the backup files
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// ManifestName is the file describing a backup, it is written last so a backup without it is incomplete
+const ManifestName = "manifest.json"
+
+// manifestVersion is the version of the manifest layout written by Export
+const manifestVersion = 1
+
+// restoreBatchSize is the number of points written per call to the store during a restore
+const restoreBatchSize = 1000
+
+// unsafeFileChars matches the characters of a source name that are replaced in file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// DirLayout names the backup directories after their UTC start time, e.g. 20240501T120000Z
+const DirLayout = "20060102T150405Z"
+
+// PointReader lists the sources holding points and streams the points of a source, it is
+// implemented by storage.PostgresRepository
+type PointReader interface {
+	StoredSources(ctx context.Context) ([]string, error)
+	EachSourcePoint(ctx context.Context, source string, from, to time.Time, fn func(storage.Point) error) error
+}
+
+// PointWriter stores restored points, it is implemented by every storage.Storage
+type PointWriter interface {
+	WritePoints(ctx context.Context, result scraper.Result) (int, error)
+}
+
+// Options selects what Export writes
+type Options struct {
+	// Format is FormatCSV (gzip compressed) or FormatParquet (snappy compressed), defaults to FormatCSV
+	Format string
+	// Sources limits the backup to these sources, empty backs up every source
+	Sources []string
+	// Keep is the number of complete backups a Job keeps under its root, older ones are deleted
+	// after each backup. Zero keeps every backup
+	Keep int
+}
+
+// Manifest describes the files of a backup
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Format    string    `json:"format"`
+	Sources   []string  `json:"sources,omitempty"`
+	Files     []File    `json:"files"`
+}
+
+// File is a backup file holding the points of one source
+type File struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Points int    `json:"points"`
+	SHA256 string `json:"sha256"`
+}
+
+// Export writes the points of reader to dir, one file per source, followed by the manifest. The
+// points of a source are streamed to its file, so no source has to fit in memory
+func Export(ctx context.Context, reader PointReader, dir string, options Options) (Manifest, error) {
+	if options.Format == "" {
+		options.Format = FormatCSV
+	}
+	if options.Format != FormatCSV && options.Format != FormatParquet {
+		return Manifest{}, fmt.Errorf("unsupported backup format %q", options.Format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Manifest{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	sources := options.Sources
+	if len(sources) == 0 {
+		var err error
+		if sources, err = reader.StoredSources(ctx); err != nil {
+			return Manifest{}, fmt.Errorf("failed to list sources: %w", err)
+		}
+	}
+
+	manifest := Manifest{
+		Version:   manifestVersion,
+		CreatedAt: time.Now().UTC(),
+		Format:    options.Format,
+		Sources:   options.Sources,
+	}
+	for _, source := range sources {
+		file, err := writeFile(ctx, reader, dir, options.Format, source)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("failed to export points: %w", err)
+		}
+		if file.Points > 0 {
+			manifest.Files = append(manifest.Files, file)
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestName), data, 0o644); err != nil {
+		return Manifest{}, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully exported backup", "dir", dir, "format", options.Format, "files", len(manifest.Files))
+	return manifest, nil
+}
+
+// pointEncoder streams points to a backup file
+type pointEncoder interface {
+	Write(point storage.Point) error
+	Close() error
+}
+
+// writeFile streams the points of source to a file in format and returns its manifest entry. A
+// source without points leaves no file and an entry without points
+func writeFile(ctx context.Context, reader PointReader, dir, format, source string) (File, error) {
+	name := unsafeFileChars.ReplaceAllString(source, "_") + ".csv.gz"
+	if format == FormatParquet {
+		name = unsafeFileChars.ReplaceAllString(source, "_") + ".parquet"
+	}
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	file := File{Name: name, Source: source}
+	hash := sha256.New()
+	out := bufio.NewWriter(io.MultiWriter(f, hash))
+	var encoder pointEncoder
+	if format == FormatParquet {
+		encoder, err = archive.NewPointWriter(out)
+	} else {
+		encoder, err = newCSVEncoder(out)
+	}
+	if err == nil {
+		err = reader.EachSourcePoint(ctx, source, time.Unix(0, 0), time.Now().AddDate(1, 0, 0), func(point storage.Point) error {
+			file.Points++
+			return encoder.Write(point)
+		})
+	}
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || file.Points == 0 {
+		os.Remove(path)
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("failed to write points of %s: %w", source, err)
+	}
+
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// Restore writes the points of the backup in dir to store, returning how many were written.
+// Files are checked against their manifest checksum before any of their points are written
+func Restore(ctx context.Context, dir string, store PointWriter) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Version != manifestVersion {
+		return 0, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+
+	written := 0
+	for _, file := range manifest.Files {
+		points, err := readFile(dir, manifest.Format, file)
+		if err != nil {
+			return written, err
+		}
+
+		for batch := range slices.Chunk(points, restoreBatchSize) {
+			stored, err := store.WritePoints(ctx, result(file.Source, batch))
+			if err != nil {
+				return written, fmt.Errorf("failed to restore %s: %w", file.Name, err)
+			}
+			written += stored
+		}
+		slog.InfoContext(ctx, "Successfully restored backup file", "file", file.Name, "points", len(points))
+	}
+	return written, nil
+}
+
+// readFile reads and verifies a backup file
+func readFile(dir, format string, file File) ([]storage.Point, error) {
+	if filepath.Base(file.Name) != file.Name {
+		return nil, fmt.Errorf("invalid backup file name %q", file.Name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, file.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != file.SHA256 {
+		return nil, fmt.Errorf("checksum mismatch of %s", file.Name)
+	}
+
+	var points []storage.Point
+	switch format {
+	case FormatParquet:
+		points, err = archive.DecodePoints(data)
+	case FormatCSV:
+		points, err = decodeCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported backup format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", file.Name, err)
+	}
+	if len(points) != file.Points {
+		return nil, fmt.Errorf("%s holds %d points, the manifest lists %d", file.Name, len(points), file.Points)
+	}
+	return points, nil
+}
+
+// result wraps restored points in a result of their source
+func result(source string, points []storage.Point) scraper.Result {
+	data := make([]scraper.TimeSeriesPoint, 0, len(points))
+	for _, point := range points {
+		data = append(data, scraper.TimeSeriesPoint{
+			Code:      point.Code,
+			Value:     point.Value,
+			Unit:      point.Unit,
+			Timestamp: point.Timestamp,
+			Metadata:  point.Metadata,
+		})
+	}
+	return scraper.Result{Source: source, Timestamp: time.Now(), Data: data}
+}
+
+// csvHeader is the header row of the CSV files
+var csvHeader = []string{"source", "code", "time", "value", "unit", "metadata"}
+
+// csvEncoder writes points as a gzip compressed CSV file, metadata is kept as a JSON object
+type csvEncoder struct {
+	zw *gzip.Writer
+	w  *csv.Writer
+}
+
+// newCSVEncoder creates an encoder of the file written to w and writes its header
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	zw := gzip.NewWriter(w)
+	encoder := &csvEncoder{zw: zw, w: csv.NewWriter(zw)}
+	if err := encoder.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return encoder, nil
+}
+
+// Write adds a point to the file
+func (e *csvEncoder) Write(point storage.Point) error {
+	var metadata []byte
+	if len(point.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(point.Metadata); err != nil {
+			return fmt.Errorf("failed to marshal metadata of %s: %w", point.Code, err)
+		}
+	}
+	return e.w.Write([]string{
+		point.Source,
+		point.Code,
+		point.Timestamp.UTC().Format(time.RFC3339Nano),
+		strconv.FormatFloat(point.Value, 'g', -1, 64),
+		point.Unit,
+		string(metadata),
+	})
+}
+
+// Close flushes the rows and finishes the gzip stream
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+// decodeCSV reads the points of a file written by encodeCSV
+func decodeCSV(data []byte) ([]storage.Point, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(zr)
+	r.FieldsPerRecord = len(csvHeader)
+
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	var points []storage.Point
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		timestamp, err := time.Parse(time.RFC3339Nano, record[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q: %w", record[2], err)
+		}
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", record[3], err)
+		}
+		point := storage.Point{Source: record[0], Code: record[1], Timestamp: timestamp, Value: value, Unit: record[4]}
+		if record[5] != "" {
+			if err := json.Unmarshal([]byte(record[5]), &point.Metadata); err != nil {
+				return nil, fmt.Errorf("invalid metadata of %s: %w", point.Code, err)
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// Prune deletes the complete backups under root older than the keep newest ones, along with the
+// incomplete backups older than the oldest one kept. Only directories named after DirLayout are
+// considered, it returns the deleted ones. Zero keeps every backup
+func Prune(root string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	// Names sort by time, newest first
+	var dirs []string
+	for _, entry := range entries {
+		if _, err := time.Parse(DirLayout, entry.Name()); entry.IsDir() && err == nil {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	slices.Sort(dirs)
+	slices.Reverse(dirs)
+
+	var deleted []string
+	complete := 0
+	for _, dir := range dirs {
+		path := filepath.Join(root, dir)
+		if _, err := os.Stat(filepath.Join(path, ManifestName)); err == nil {
+			complete++
+			if complete <= keep {
+				continue
+			}
+		} else if complete < keep {
+			// The backup may still be written, or failed after the oldest one kept
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return deleted, fmt.Errorf("failed to delete backup %s: %w", dir, err)
+		}
+		deleted = append(deleted, dir)
+	}
+	return deleted, nil
+}
+
+// Job exports a backup to a new directory under its root every interval
+type Job struct {
+	reader   PointReader
+	root     string
+	interval time.Duration
+	options  Options
+}
+
+// NewJob creates a job backing up reader under root every interval, defaulting to 24 hours
+func NewJob(reader PointReader, root string, interval time.Duration, options Options) *Job {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &Job{
+		reader:   reader,
+		root:     root,
+		interval: interval,
+		options:  options,
+	}
+}
+
+// Run exports a backup, named after its UTC start time, each interval until the context is
+// cancelled. The backups beyond the ones kept are deleted once a backup succeeded
+func (j *Job) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Backup job started", "root", j.root, "interval", j.interval, "format", j.options.Format, "keep", j.options.Keep)
+
+	for {
+		dir := filepath.Join(j.root, time.Now().UTC().Format(DirLayout))
+		if _, err := Export(ctx, j.reader, dir, j.options); err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to export backup", "dir", dir, "error", err)
+			}
+		} else {
+			deleted, err := Prune(j.root, j.options.Keep)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to delete old backups", "root", j.root, "error", err)
+			} else if len(deleted) > 0 {
+				slog.InfoContext(ctx, "Successfully deleted old backups", "root", j.root, "backups", deleted)
+			}
+		}
+
+		select {
+		case <-time.After(j.interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Backup job stopped")
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pointList serves points the way PostgresRepository does, ordered by source, code and time
+type pointList []storage.Point
+
+func (l pointList) StoredSources(ctx context.Context) ([]string, error) {
+	var sources []string
+	for _, point := range l {
+		if !slices.Contains(sources, point.Source) {
+			sources = append(sources, point.Source)
+		}
+	}
+	return sources, nil
+}
+
+func (l pointList) EachSourcePoint(ctx context.Context, source string, from, to time.Time, fn func(storage.Point) error) error {
+	for _, point := range l {
+		if point.Source == source && !point.Timestamp.Before(from) && point.Timestamp.Before(to) {
+			if err := fn(point); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// memoryWriter keeps the points of the written results
+type memoryWriter struct {
+	points []storage.Point
+}
+
+func (w *memoryWriter) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	points, err := storage.Normalize(result)
+	w.points = append(w.points, points...)
+	return len(points), err
+}
+
+var testPoints = pointList{
+	{Source: "binance", Code: "BTCUSDT", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC), Value: 60000.5, Unit: "usdt"},
+	{Source: "binance", Code: "ETHUSDT", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Value: 3000, Unit: "usdt"},
+	{Source: "snb", Code: "SARON", Timestamp: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Value: 1.45, Unit: "%", Metadata: map[string]string{"description": "Swiss Average Rate Overnight, \"SARON\""}},
+}
+
+func TestExportRestore(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatParquet} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			manifest, err := Export(context.Background(), testPoints, dir, Options{Format: format})
+			require.NoError(t, err)
+			require.Len(t, manifest.Files, 2)
+			assert.Equal(t, "binance", manifest.Files[0].Source)
+			assert.Equal(t, 2, manifest.Files[0].Points)
+			assert.FileExists(t, filepath.Join(dir, ManifestName))
+
+			writer := &memoryWriter{}
+			restored, err := Restore(context.Background(), dir, writer)
+			require.NoError(t, err)
+			assert.Equal(t, 3, restored)
+			assert.Equal(t, []storage.Point(testPoints), writer.points)
+		})
+	}
+}
+
+func TestExportSources(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := Export(context.Background(), testPoints, dir, Options{Sources: []string{"snb"}})
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 1)
+	assert.Equal(t, "snb.csv.gz", manifest.Files[0].Name)
+	assert.NoFileExists(t, filepath.Join(dir, "binance.csv.gz"))
+
+	_, err = Export(context.Background(), testPoints, dir, Options{Format: "xlsx"})
+	assert.Error(t, err)
+}
+
+func TestRestoreRejectsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Export(context.Background(), testPoints, dir, Options{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "snb.csv.gz"), []byte("corrupt"), 0o644))
+
+	writer := &memoryWriter{}
+	restored, err := Restore(context.Background(), dir, writer)
+	assert.ErrorContains(t, err, "checksum mismatch of snb.csv.gz")
+	assert.Equal(t, 2, restored, "Files before the corrupt one are restored")
+}
+
+func TestExportSkipsSourcesWithoutPoints(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := Export(context.Background(), testPoints, dir, Options{Sources: []string{"snb", "ecb"}})
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 1)
+	assert.NoFileExists(t, filepath.Join(dir, "ecb.csv.gz"))
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	backup := func(name string, complete bool) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, name), 0o755))
+		if complete {
+			require.NoError(t, os.WriteFile(filepath.Join(root, name, ManifestName), []byte("{}"), 0o644))
+		}
+	}
+	backup("20240501T000000Z", true)
+	backup("20240502T000000Z", false)
+	backup("20240503T000000Z", true)
+	backup("20240504T000000Z", true)
+	backup("20240505T000000Z", false)
+	backup("manual", true)
+
+	deleted, err := Prune(root, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20240502T000000Z", "20240501T000000Z"}, deleted)
+	for _, name := range []string{"20240503T000000Z", "20240504T000000Z", "20240505T000000Z", "manual"} {
+		assert.DirExists(t, filepath.Join(root, name), "Newer, running and foreign backups are kept")
+	}
+
+	deleted, err = Prune(root, 0)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+}
@@ -0,0 +1,63 @@
+// Package errclass classifies failures, so retry policies, metrics and alerts can tell a rate
+// limited source apart from one whose format changed. Errors are classified by wrapping one of
+// the sentinel errors, e.g. fmt.Errorf("%w: %w", errclass.ErrParse, err)
+package errclass
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrTransient marks failures that are likely to succeed when retried, such as timeouts,
+	// connection errors and server errors
+	ErrTransient = errors.New("temporary failure")
+	// ErrRateLimited marks requests refused because too many were sent, they succeed once the
+	// limit of the source resets
+	ErrRateLimited = errors.New("rate limited")
+	// ErrParse marks responses or messages that could not be decoded, retrying them fails again
+	ErrParse = errors.New("malformed data")
+	// ErrSourceChanged marks responses that decoded but no longer have the expected shape, or
+	// endpoints that are gone, the scraper needs to be adapted to the source
+	ErrSourceChanged = errors.New("source changed")
+)
+
+// Classes of errors as reported in metrics and alerts
+const (
+	Transient     = "transient"
+	RateLimited   = "rate_limited"
+	Parse         = "parse"
+	SourceChanged = "source_changed"
+	// Unknown is the class of errors that were not classified
+	Unknown = "unknown"
+)
+
+// Of returns the class of err, empty when err is nil. Errors carrying several classes get the
+// least recoverable one, timeouts count as transient
+func Of(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrSourceChanged):
+		return SourceChanged
+	case errors.Is(err, ErrParse):
+		return Parse
+	case errors.Is(err, ErrRateLimited):
+		return RateLimited
+	case errors.Is(err, ErrTransient), errors.Is(err, context.DeadlineExceeded):
+		return Transient
+	default:
+		return Unknown
+	}
+}
+
+// Permanent reports whether err is certain to happen again when the operation is retried
+// unchanged, unclassified errors are not permanent
+func Permanent(err error) bool {
+	switch Of(err) {
+	case Parse, SourceChanged:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"unclassified", errors.New("boom"), Unknown},
+		{"transient", fmt.Errorf("%w: failed to fetch: %w", ErrTransient, errors.New("connection reset")), Transient},
+		{"timeout", fmt.Errorf("failed to fetch: %w", context.DeadlineExceeded), Transient},
+		{"rate limited", fmt.Errorf("failed to fetch gas oracle: %w", ErrRateLimited), RateLimited},
+		{"parse", fmt.Errorf("%w: failed to parse response: %w", ErrParse, errors.New("invalid character")), Parse},
+		{"source changed", fmt.Errorf("%w: unexpected result", ErrSourceChanged), SourceChanged},
+		{"least recoverable wins", fmt.Errorf("%w: %w", ErrTransient, ErrSourceChanged), SourceChanged},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, Of(test.err))
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	assert.True(t, Permanent(fmt.Errorf("wrapped: %w", ErrSourceChanged)))
+	assert.True(t, Permanent(ErrParse))
+	assert.False(t, Permanent(ErrRateLimited))
+	assert.False(t, Permanent(errors.New("boom")), "unclassified errors may be retried")
+	assert.False(t, Permanent(nil))
+}
@@ -0,0 +1,110 @@
+package featureflag
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store holds the flags toggled at runtime, they take precedence over the configured flags
+type Store interface {
+	// Load returns the flags of the store by name
+	Load(ctx context.Context) (map[string]bool, error)
+	// Set enables or disables a flag
+	Set(ctx context.Context, name string, enabled bool) error
+	// Delete removes a flag so the configured value applies again
+	Delete(ctx context.Context, name string) error
+}
+
+// Flags gates experimental features by name, unknown flags are disabled. The configured flags
+// are overridden by the flags of a store once it is refreshed
+type Flags struct {
+	mu        sync.RWMutex
+	defaults  map[string]bool
+	overrides map[string]bool
+}
+
+// New creates the flags with their configured values
+func New(defaults map[string]bool) *Flags {
+	return &Flags{defaults: maps.Clone(defaults)}
+}
+
+// Enabled reports whether the flag name is enabled
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if enabled, ok := f.overrides[name]; ok {
+		return enabled
+	}
+	return f.defaults[name]
+}
+
+// SetDefaults replaces the configured values, e.g. after the configuration is reloaded
+func (f *Flags) SetDefaults(defaults map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.defaults = maps.Clone(defaults)
+}
+
+// All returns the value of every known flag by name
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	all := maps.Clone(f.defaults)
+	if all == nil {
+		all = make(map[string]bool, len(f.overrides))
+	}
+	maps.Copy(all, f.overrides)
+	return all
+}
+
+// Refresh loads the flags of store, it returns the names of the flags whose value changed
+func (f *Flags) Refresh(ctx context.Context, store Store) ([]string, error) {
+	overrides, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	before := f.All()
+
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+
+	after := f.All()
+	var changed []string
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		if before[name] != after[name] {
+			changed = append(changed, name)
+		}
+	}
+	return changed, nil
+}
+
+// Watch refreshes the flags from store each interval until the context is cancelled, logging
+// every flag toggled. The last flags loaded are kept while the store is unavailable
+func (f *Flags) Watch(ctx context.Context, store Store, interval time.Duration) {
+	slog.InfoContext(ctx, "Feature flag watcher started", "interval", interval)
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Feature flag watcher stopped")
+			return
+		}
+
+		changed, err := f.Refresh(ctx, store)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to refresh feature flags", "error", err)
+			}
+			continue
+		}
+		for _, name := range changed {
+			slog.InfoContext(ctx, "Feature flag toggled", "flag", name, "enabled", f.Enabled(name))
+		}
+	}
+}
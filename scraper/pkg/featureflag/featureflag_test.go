@@ -0,0 +1,67 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a store of flags in memory
+type memoryStore struct {
+	flags map[string]bool
+	err   error
+}
+
+func (s *memoryStore) Load(ctx context.Context) (map[string]bool, error) {
+	return maps.Clone(s.flags), s.err
+}
+
+func (s *memoryStore) Set(ctx context.Context, name string, enabled bool) error {
+	s.flags[name] = enabled
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, name string) error {
+	delete(s.flags, name)
+	return nil
+}
+
+func TestFlags(t *testing.T) {
+	flags := New(map[string]bool{"payload_archive": true, "beta": false})
+	assert.True(t, flags.Enabled("payload_archive"))
+	assert.False(t, flags.Enabled("beta"))
+	assert.False(t, flags.Enabled("unknown"), "Unknown flags are disabled")
+
+	flags.SetDefaults(map[string]bool{"beta": true})
+	assert.True(t, flags.Enabled("beta"))
+	assert.False(t, flags.Enabled("payload_archive"))
+}
+
+func TestFlagsRefresh(t *testing.T) {
+	ctx := context.Background()
+	flags := New(map[string]bool{"payload_archive": true, "beta": false})
+	store := &memoryStore{flags: map[string]bool{}}
+
+	require.NoError(t, store.Set(ctx, "beta", true))
+	require.NoError(t, store.Set(ctx, "payload_archive", true))
+	changed, err := flags.Refresh(ctx, store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beta"}, changed)
+	assert.True(t, flags.Enabled("beta"), "Stored flags override the configured ones")
+	assert.Equal(t, map[string]bool{"payload_archive": true, "beta": true}, flags.All())
+
+	require.NoError(t, store.Delete(ctx, "beta"))
+	changed, err = flags.Refresh(ctx, store)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"beta"}, changed)
+	assert.False(t, flags.Enabled("beta"), "Deleted flags fall back to the configured value")
+
+	store.err = errors.New("unavailable")
+	_, err = flags.Refresh(ctx, store)
+	assert.Error(t, err)
+	assert.True(t, flags.Enabled("payload_archive"), "The flags are kept when the store is unavailable")
+}
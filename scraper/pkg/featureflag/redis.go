@@ -0,0 +1,61 @@
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore keeps the flags toggled at runtime in a Redis hash, mapping a flag name to true or false
+type RedisStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisStore creates a store of the flags in the hash at key, the store closes client on Close
+func NewRedisStore(client *redis.Client, key string) *RedisStore {
+	return &RedisStore{client: client, key: key}
+}
+
+// Load returns the flags of the hash by name, fields that are not booleans are ignored
+func (s *RedisStore) Load(ctx context.Context) (map[string]bool, error) {
+	fields, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %w", err)
+	}
+
+	flags := make(map[string]bool, len(fields))
+	for name, value := range fields {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring invalid feature flag", "flag", name, "value", value)
+			continue
+		}
+		flags[name] = enabled
+	}
+	return flags, nil
+}
+
+// Set enables or disables a flag
+func (s *RedisStore) Set(ctx context.Context, name string, enabled bool) error {
+	if err := s.client.HSet(ctx, s.key, name, strconv.FormatBool(enabled)).Err(); err != nil {
+		return fmt.Errorf("failed to set feature flag %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete removes a flag so the configured value applies again
+func (s *RedisStore) Delete(ctx context.Context, name string) error {
+	if err := s.client.HDel(ctx, s.key, name).Err(); err != nil {
+		return fmt.Errorf("failed to delete feature flag %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close closes the Redis client of the store
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,48 @@
+//go:build integration
+// +build integration
+
+package featureflag
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStoreIntegration(t *testing.T) {
+	host, port := "localhost", 6379
+	if value, ok := os.LookupEnv("REDIS_HOST"); ok {
+		host = value
+	}
+	if value, ok := os.LookupEnv("REDIS_PORT"); ok {
+		var err error
+		port, err = strconv.Atoi(value)
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+	client, err := queue.NewRedisClient(host, port, queue.RedisConnection{})
+	require.NoError(t, err)
+	store := NewRedisStore(client, "test:feature_flags")
+	defer store.Close()
+	defer client.Del(ctx, "test:feature_flags")
+
+	require.NoError(t, store.Set(ctx, "beta", true))
+	require.NoError(t, store.Set(ctx, "payload_archive", false))
+	require.NoError(t, client.HSet(ctx, "test:feature_flags", "invalid", "maybe").Err())
+
+	flags, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"beta": true, "payload_archive": false}, flags)
+
+	require.NoError(t, store.Delete(ctx, "beta"))
+	flags, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, flags, "beta")
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Check returns an error when a dependency is unreachable
+type Check func(ctx context.Context) error
+
+// Options configures the checks of a Health
+type Options struct {
+	// StallTimeout is how long the scheduler loop may go without progress before the process is
+	// reported as not alive, defaults to 5 minutes. A negative timeout disables the check for
+	// processes without a scheduler loop
+	StallTimeout time.Duration
+	// CheckTimeout bounds every readiness check, defaults to 2 seconds
+	CheckTimeout time.Duration
+}
+
+// Health reports the liveness of the scheduler loop and the readiness of the dependencies of the
+// process, served by Handler at /healthz and /readyz
+type Health struct {
+	options Options
+
+	mu       sync.RWMutex
+	checks   map[string]Check
+	progress time.Time
+}
+
+// New creates a Health, the loop counts as progressing from now on
+func New(options Options) *Health {
+	if options.StallTimeout == 0 {
+		options.StallTimeout = 5 * time.Minute
+	}
+	if options.CheckTimeout <= 0 {
+		options.CheckTimeout = 2 * time.Second
+	}
+	return &Health{options: options, checks: make(map[string]Check), progress: time.Now()}
+}
+
+// AddCheck adds a readiness check of a dependency
+func (h *Health) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// Progress records that the scheduler loop made progress, it is called on every cycle and run
+func (h *Health) Progress() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.progress = time.Now()
+}
+
+// Alive returns an error when the scheduler loop has not progressed within the stall timeout
+func (h *Health) Alive() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.options.StallTimeout > 0 && time.Since(h.progress) > h.options.StallTimeout {
+		return &StalledError{Since: h.progress}
+	}
+	return nil
+}
+
+// StalledError is returned by Alive when the scheduler loop stopped progressing
+type StalledError struct {
+	// Since is the last time the loop progressed
+	Since time.Time
+}
+
+func (e *StalledError) Error() string {
+	return "scheduler loop has not progressed since " + e.Since.UTC().Format(time.RFC3339)
+}
+
+// Ready runs every readiness check concurrently and returns the errors of the failed ones by name
+func (h *Health) Ready(ctx context.Context) map[string]error {
+	h.mu.RLock()
+	checks := maps.Clone(h.checks)
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.options.CheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := check(ctx); err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+// status is the body of the health endpoints
+type status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Handler serves /healthz, failing while the scheduler loop is stalled, and /readyz, failing
+// while a dependency is unreachable. Both answer 200 or 503 with a JSON status
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		if err := h.Alive(); err != nil {
+			writeStatus(w, http.StatusServiceUnavailable, status{Status: "stalled", Checks: map[string]string{"scheduler": err.Error()}})
+			return
+		}
+		writeStatus(w, http.StatusOK, status{Status: "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		failed := h.Ready(r.Context())
+		if len(failed) == 0 {
+			writeStatus(w, http.StatusOK, status{Status: "ok"})
+			return
+		}
+		checks := make(map[string]string, len(failed))
+		for _, name := range slices.Sorted(maps.Keys(failed)) {
+			checks[name] = failed[name].Error()
+		}
+		writeStatus(w, http.StatusServiceUnavailable, status{Status: "unavailable", Checks: checks})
+	})
+	return mux
+}
+
+// writeStatus writes a status as JSON with the status code
+func writeStatus(w http.ResponseWriter, code int, body status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, status) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body status
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	return recorder.Code, body
+}
+
+func TestHealthz(t *testing.T) {
+	h := New(Options{StallTimeout: time.Minute})
+	code, body := get(t, h.Handler(), "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body.Status)
+
+	h.progress = time.Now().Add(-2 * time.Minute)
+	code, body = get(t, h.Handler(), "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "stalled", body.Status)
+	assert.Contains(t, body.Checks, "scheduler")
+
+	h.Progress()
+	code, _ = get(t, h.Handler(), "/healthz")
+	assert.Equal(t, http.StatusOK, code, "Progress revives the loop")
+}
+
+func TestReadyz(t *testing.T) {
+	h := New(Options{CheckTimeout: 50 * time.Millisecond})
+	h.AddCheck("database", func(ctx context.Context) error { return nil })
+	code, body := get(t, h.Handler(), "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body.Status)
+
+	h.AddCheck("queue", func(ctx context.Context) error { return errors.New("connection refused") })
+	h.AddCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	code, body = get(t, h.Handler(), "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, map[string]string{
+		"queue": "connection refused",
+		"slow":  context.DeadlineExceeded.Error(),
+	}, body.Checks)
+}
+
+func TestAliveWithoutStallTimeout(t *testing.T) {
+	h := New(Options{StallTimeout: -1})
+	h.progress = time.Now().Add(-24 * time.Hour)
+	assert.NoError(t, h.Alive(), "Processes without a scheduler loop never stall")
+}
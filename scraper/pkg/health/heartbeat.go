@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Beat is the liveness of a process published by a Heartbeat
+type Beat struct {
+	Service  string    `json:"service"`
+	Instance string    `json:"instance"`
+	Time     time.Time `json:"time"`
+	Started  time.Time `json:"started"`
+}
+
+// HeartbeatOptions configures a Heartbeat
+type HeartbeatOptions struct {
+	// Service and Instance identify the process in the beats, e.g. "macrochain-scraper" and its hostname
+	Service  string
+	Instance string
+	// Interval is the time between two beats, defaults to 30 seconds
+	Interval time.Duration
+	// Alive reports whether the process still makes progress, no beat is sent while it returns an
+	// error. Nil considers the process alive as long as the heartbeat runs
+	Alive func() error
+	// Publish sends a beat, e.g. to a monitoring topic, nil publishes none
+	Publish func(ctx context.Context, beat Beat) error
+	// PingURL is requested on every beat, e.g. the URL of a healthchecks.io check, so an external
+	// dead-man's switch fires when the beats stop. Empty pings none
+	PingURL string
+}
+
+// Heartbeat periodically announces that a process is alive
+type Heartbeat struct {
+	options    HeartbeatOptions
+	started    time.Time
+	httpClient *http.Client
+}
+
+// NewHeartbeat creates a heartbeat, it beats once Run is called
+func NewHeartbeat(options HeartbeatOptions) *Heartbeat {
+	if options.Interval <= 0 {
+		options.Interval = 30 * time.Second
+	}
+	return &Heartbeat{
+		options:    options,
+		started:    time.Now(),
+		httpClient: &http.Client{Timeout: min(options.Interval, 10*time.Second)},
+	}
+}
+
+// Run beats immediately and then every interval until the context is cancelled
+func (h *Heartbeat) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Heartbeat started", "interval", h.options.Interval)
+	defer slog.InfoContext(context.Background(), "Heartbeat stopped")
+
+	ticker := time.NewTicker(h.options.Interval)
+	defer ticker.Stop()
+	for {
+		h.beat(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// beat publishes a beat and pings the dead-man's switch unless the process stalled, failures are
+// logged and the next beat is attempted anyway
+func (h *Heartbeat) beat(ctx context.Context, now time.Time) {
+	if h.options.Alive != nil {
+		if err := h.options.Alive(); err != nil {
+			slog.WarnContext(ctx, "Heartbeat skipped, the process is not alive", "error", err)
+			return
+		}
+	}
+
+	if h.options.Publish != nil {
+		beat := Beat{Service: h.options.Service, Instance: h.options.Instance, Time: now, Started: h.started}
+		if err := h.options.Publish(ctx, beat); err != nil {
+			slog.WarnContext(ctx, "Failed to publish heartbeat", "error", err)
+		}
+	}
+	if h.options.PingURL != "" {
+		if err := h.ping(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to ping dead-man's switch", "error", err)
+		}
+	}
+}
+
+// ping requests the dead-man's switch URL
+func (h *Heartbeat) ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.options.PingURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
+	}
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		// The URL of the error usually embeds the credential of the check
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to ping: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ping returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatBeat(t *testing.T) {
+	var pings atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings.Add(1)
+	}))
+	defer server.Close()
+
+	var beats []Beat
+	var alive error
+	h := NewHeartbeat(HeartbeatOptions{
+		Service:  "macrochain-scraper",
+		Instance: "host-1",
+		Alive:    func() error { return alive },
+		Publish: func(ctx context.Context, beat Beat) error {
+			beats = append(beats, beat)
+			return nil
+		},
+		PingURL: server.URL,
+	})
+
+	now := time.Now()
+	h.beat(context.Background(), now)
+	require.Len(t, beats, 1)
+	assert.Equal(t, "macrochain-scraper", beats[0].Service)
+	assert.Equal(t, "host-1", beats[0].Instance)
+	assert.Equal(t, now, beats[0].Time)
+	assert.Equal(t, h.started, beats[0].Started)
+	assert.EqualValues(t, 1, pings.Load())
+
+	alive = &StalledError{Since: now.Add(-time.Hour)}
+	h.beat(context.Background(), now.Add(time.Minute))
+	assert.Len(t, beats, 1, "a stalled process does not beat")
+	assert.EqualValues(t, 1, pings.Load(), "a stalled process does not ping")
+}
+
+func TestHeartbeatPublishFailureStillPings(t *testing.T) {
+	var pings atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings.Add(1)
+	}))
+	defer server.Close()
+
+	h := NewHeartbeat(HeartbeatOptions{
+		Publish: func(ctx context.Context, beat Beat) error { return errors.New("queue down") },
+		PingURL: server.URL,
+	})
+	h.beat(context.Background(), time.Now())
+	assert.EqualValues(t, 1, pings.Load())
+}
+
+func TestHeartbeatPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h := NewHeartbeat(HeartbeatOptions{PingURL: server.URL + "/secret-check"})
+	err := h.ping(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	server.Close()
+	err = h.ping(context.Background())
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-check", "the error does not leak the check URL")
+}
+
+func TestHeartbeatRun(t *testing.T) {
+	beats := make(chan Beat, 10)
+	h := NewHeartbeat(HeartbeatOptions{
+		Interval: 10 * time.Millisecond,
+		Publish: func(ctx context.Context, beat Beat) error {
+			beats <- beat
+			return nil
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	for range 2 {
+		select {
+		case <-beats:
+		case <-time.After(time.Second):
+			t.Fatal("heartbeat did not beat")
+		}
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// attrsKey is the context key of the attributes added to the log records
+type attrsKey struct{}
+
+// With returns a context whose log records carry the attributes of args, as key-value pairs or
+// slog.Attr, in addition to the ones of ctx
+func With(ctx context.Context, args ...any) context.Context {
+	parent := attrsFrom(ctx)
+	attrs := make([]slog.Attr, 0, len(parent)+len(args))
+	attrs = append(attrs, parent...)
+	attrs = append(attrs, argsToAttrs(args)...)
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+// attrsFrom returns the attributes added to the log records of ctx
+func attrsFrom(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// argsToAttrs converts key-value pairs and slog.Attr to attributes the way slog.Logger.With does
+func argsToAttrs(args []any) []slog.Attr {
+	var record slog.Record
+	record.Add(args...)
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrs
+}
+
+// contextHandler adds the attributes of the context to the records it handles
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler wraps handler so the records logged with a context of With carry its attributes
+func NewHandler(handler slog.Handler) slog.Handler {
+	return &contextHandler{Handler: handler}
+}
+
+// Handle adds the attributes of ctx to the record
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := attrsFrom(ctx); len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler adding attrs and the attributes of the context
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler nesting the attributes in group
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
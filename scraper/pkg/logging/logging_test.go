@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWith(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})))
+
+	ctx := With(context.Background(), "scraper", "fx_rates")
+	run := With(ctx, slog.String("run", "42"))
+	logger.InfoContext(run, "Scraper run completed", "results", 1)
+	logger.With("component", "queue").InfoContext(run, "Message sent")
+	logger.InfoContext(ctx, "Scraper cycle completed")
+	logger.Info("No context")
+
+	assert.Equal(t, `level=INFO msg="Scraper run completed" results=1 scraper=fx_rates run=42
+level=INFO msg="Message sent" component=queue scraper=fx_rates run=42
+level=INFO msg="Scraper cycle completed" scraper=fx_rates
+level=INFO msg="No context"
+`, out.String())
+}
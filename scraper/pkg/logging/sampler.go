@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplerOptions configures the sampling of the records of a handler
+type SamplerOptions struct {
+	// First is the number of records of a message passed on per interval before sampling starts,
+	// zero disables sampling
+	First int
+	// Thereafter passes every Thereafter-th record of a message once First were passed, zero
+	// drops them all
+	Thereafter int
+	// Interval is the window the records are counted in, defaults to a second
+	Interval time.Duration
+}
+
+// sampleCounts counts the records of every message within the current interval, it is shared by
+// the handlers derived with WithAttrs and WithGroup
+type sampleCounts struct {
+	mu      sync.Mutex
+	start   time.Time
+	counts  map[string]int
+	dropped int
+}
+
+// sampler drops the records of the messages logged more often than its options allow, records at
+// warning level and above are never dropped
+type sampler struct {
+	slog.Handler
+	options SamplerOptions
+	counts  *sampleCounts
+	now     func() time.Time
+}
+
+// NewSampler wraps handler so hot paths, such as logging every received message, cannot flood
+// the logs. The number of dropped records is logged when an interval ends
+func NewSampler(handler slog.Handler, options SamplerOptions) slog.Handler {
+	if options.First <= 0 {
+		return handler
+	}
+	if options.Interval <= 0 {
+		options.Interval = time.Second
+	}
+	return &sampler{
+		Handler: handler,
+		options: options,
+		counts:  &sampleCounts{counts: make(map[string]int)},
+		now:     time.Now,
+	}
+}
+
+// Handle passes the record on unless its message exceeded the sampling limits of the interval
+func (s *sampler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelWarn {
+		return s.Handler.Handle(ctx, record)
+	}
+
+	now := s.now()
+	s.counts.mu.Lock()
+	dropped := 0
+	if now.Sub(s.counts.start) >= s.options.Interval {
+		dropped = s.counts.dropped
+		s.counts.start, s.counts.dropped = now, 0
+		clear(s.counts.counts)
+	}
+	key := record.Level.String() + " " + record.Message
+	s.counts.counts[key]++
+	n := s.counts.counts[key] - s.options.First
+	keep := n <= 0 || (s.options.Thereafter > 0 && n%s.options.Thereafter == 0)
+	if !keep {
+		s.counts.dropped++
+	}
+	s.counts.mu.Unlock()
+
+	if dropped > 0 {
+		summary := slog.NewRecord(now, slog.LevelInfo, "Dropped sampled log records", 0)
+		summary.AddAttrs(slog.Int("dropped", dropped))
+		if err := s.Handler.Handle(ctx, summary); err != nil {
+			return err
+		}
+	}
+	if !keep {
+		return nil
+	}
+	return s.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a sampler of the handler with attrs, sharing the counts of s
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{Handler: s.Handler.WithAttrs(attrs), options: s.options, counts: s.counts, now: s.now}
+}
+
+// WithGroup returns a sampler of the handler with group name, sharing the counts of s
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{Handler: s.Handler.WithGroup(name), options: s.options, counts: s.counts, now: s.now}
+}
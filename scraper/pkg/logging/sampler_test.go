@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampler(t *testing.T) {
+	var out bytes.Buffer
+	handler := NewSampler(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}), SamplerOptions{First: 2, Thereafter: 3, Interval: time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler.(*sampler).now = func() time.Time { return now }
+	logger := slog.New(handler)
+
+	// Derived loggers share the counts of a message
+	for i := 1; i <= 8; i++ {
+		logger.With("i", i).Info("Received message")
+	}
+	logger.Info("Scraper cycle completed")
+	for range 3 {
+		logger.Warn("Discarded expired message")
+	}
+
+	now = now.Add(time.Second)
+	logger.Info("Received message", "i", 9)
+
+	assert.Equal(t, `level=INFO msg="Received message" i=1
+level=INFO msg="Received message" i=2
+level=INFO msg="Received message" i=5
+level=INFO msg="Received message" i=8
+level=INFO msg="Scraper cycle completed"
+level=WARN msg="Discarded expired message"
+level=WARN msg="Discarded expired message"
+level=WARN msg="Discarded expired message"
+level=INFO msg="Dropped sampled log records" dropped=4
+level=INFO msg="Received message" i=9
+`, out.String())
+}
+
+func TestSamplerDisabled(t *testing.T) {
+	var out bytes.Buffer
+	text := slog.NewTextHandler(&out, nil)
+	assert.Same(t, text, NewSampler(text, SamplerOptions{}))
+
+	logger := slog.New(NewSampler(text, SamplerOptions{}))
+	for range 5 {
+		logger.InfoContext(context.Background(), "Received message")
+	}
+	assert.Equal(t, 5, strings.Count(out.String(), "Received message"))
+}
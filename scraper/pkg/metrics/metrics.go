@@ -0,0 +1,39 @@
+// Package metrics exposes the metrics of the scraper and the persister to Prometheus with
+// client_golang. Metrics are named macrochain_<component>_<name>, e.g. macrochain_scraper_last_run_items
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// CollectorFunc is a collector sending the current values of its metrics on every scrape, for
+// state kept by the pipeline such as run outcomes and queue counters. Its metrics are not
+// described beforehand, the registry checks them when they are collected
+type CollectorFunc func(ch chan<- prometheus.Metric)
+
+// Describe sends nothing, which makes the collector unchecked
+func (f CollectorFunc) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect sends the current values of the metrics
+func (f CollectorFunc) Collect(ch chan<- prometheus.Metric) {
+	f(ch)
+}
+
+// NewRegistry creates a registry with the metrics of the Go runtime and of the process
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler serves the metrics of registry for Prometheus to scrape
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
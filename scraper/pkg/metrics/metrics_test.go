@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryHandler(t *testing.T) {
+	items := prometheus.NewDesc("macrochain_scraper_last_run_items", "Items of the last run", []string{"scraper"}, nil)
+	failures := prometheus.NewDesc("macrochain_scraper_failures_total", "Failed runs\nby class", []string{"scraper", "class"}, nil)
+	registry := NewRegistry()
+	registry.MustRegister(CollectorFunc(func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(items, prometheus.GaugeValue, 12, "fx_rates")
+		ch <- prometheus.MustNewConstMetric(items, prometheus.GaugeValue, 0.5, `odd"name`)
+	}))
+	// Several unchecked collectors share the registry
+	registry.MustRegister(CollectorFunc(func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(failures, prometheus.CounterValue, 3, "fx_rates", "transient")
+	}))
+
+	recorder := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+	body := recorder.Body.String()
+	assert.Contains(t, body, `# HELP macrochain_scraper_last_run_items Items of the last run
+# TYPE macrochain_scraper_last_run_items gauge
+macrochain_scraper_last_run_items{scraper="fx_rates"} 12
+macrochain_scraper_last_run_items{scraper="odd\"name"} 0.5
+`)
+	assert.Contains(t, body, `# HELP macrochain_scraper_failures_total Failed runs\nby class
+# TYPE macrochain_scraper_failures_total counter
+macrochain_scraper_failures_total{class="transient",scraper="fx_rates"} 3
+`)
+	assert.Contains(t, body, "go_goroutines", "The registry exposes the Go runtime metrics")
+}
+
+func TestRegistryHandlerRejectsInconsistentMetrics(t *testing.T) {
+	gauge := prometheus.NewDesc("macrochain_test", "Test", []string{"scraper"}, nil)
+	registry := NewRegistry()
+	registry.MustRegister(CollectorFunc(func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(gauge, prometheus.GaugeValue, 1, "fx_rates")
+		ch <- prometheus.MustNewConstMetric(gauge, prometheus.GaugeValue, 2, "fx_rates")
+	}))
+
+	recorder := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code, "Duplicate samples are reported instead of served")
+}
@@ -0,0 +1,165 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Source returns the migrations shipped with the scraper, named the golang-migrate way, e.g.
+// 000001_create_data_points.up.sql
+func Source() (source.Driver, error) {
+	driver, err := httpfs.New(http.FS(files), "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	return driver, nil
+}
+
+// Migrator applies the embedded migrations with golang-migrate, recording the applied version in
+// schema_migrations. Migrators hold an advisory lock while they run, so several instances
+// starting together apply every migration once
+type Migrator struct {
+	migrate *migrate.Migrate
+	source  source.Driver
+}
+
+// New creates a migrator of the embedded migrations, it has to be closed after use
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	src, err := Source()
+	if err != nil {
+		return nil, err
+	}
+	database, err := postgres.WithInstance(stdlib.OpenDBFromPool(pool), &postgres.Config{})
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("failed to open migration database: %w", err)
+	}
+	m, err := migrate.NewWithInstance("httpfs", src, "postgres", database)
+	if err != nil {
+		src.Close()
+		database.Close()
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return &Migrator{
+		migrate: m,
+		source:  src,
+	}, nil
+}
+
+// Close releases the connection of the migrator
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// Up applies every pending migration, returning how many were applied. Cancelling ctx stops
+// after the migration being applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	before, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stop := context.AfterFunc(ctx, m.stop)
+	defer stop()
+	err = m.migrate.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return 0, nil
+	}
+
+	after, versionErr := m.Version(ctx)
+	applied := m.count(before, after)
+	if err != nil {
+		return applied, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	if versionErr != nil {
+		return applied, versionErr
+	}
+	slog.InfoContext(ctx, "Successfully applied migrations", "applied", applied, "version", after)
+	return applied, nil
+}
+
+// Down reverts the latest steps applied migrations, returning how many were reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	stop := context.AfterFunc(ctx, m.stop)
+	defer stop()
+
+	err := m.migrate.Steps(-steps)
+	var short migrate.ErrShortLimit
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		return 0, nil
+	case errors.As(err, &short):
+		// Fewer migrations than steps were applied, they were all reverted
+		steps -= int(short.Short)
+	case err != nil:
+		return 0, fmt.Errorf("failed to revert migrations: %w", err)
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return steps, err
+	}
+	slog.InfoContext(ctx, "Successfully reverted migrations", "reverted", steps, "version", version)
+	return steps, nil
+}
+
+// Force records version as applied without running any migration, it clears the dirty flag left
+// by a migration that failed halfway once the schema was repaired by hand
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if err := m.migrate.Force(int(version)); err != nil {
+		return fmt.Errorf("failed to force schema version %d: %w", version, err)
+	}
+	return nil
+}
+
+// Version returns the latest applied migration, 0 when none was applied. A migration that failed
+// halfway leaves a dirty version, which is reported as an error
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	version, dirty, err := m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return int64(version), fmt.Errorf("schema version %d is dirty, repair it and force the version", version)
+	}
+	return int64(version), nil
+}
+
+// stop asks golang-migrate to stop once the running migration is done
+func (m *Migrator) stop() {
+	select {
+	case m.migrate.GracefulStop <- true:
+	default:
+	}
+}
+
+// count returns how many migrations there are after from up to and including to
+func (m *Migrator) count(from, to int64) int {
+	count := 0
+	version, err := m.source.First()
+	for err == nil && int64(version) <= to {
+		if int64(version) > from {
+			count++
+		}
+		version, err = m.source.Next(version)
+	}
+	return count
+}
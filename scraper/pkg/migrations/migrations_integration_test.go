@@ -0,0 +1,71 @@
+//go:build integration
+// +build integration
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func TestMigratorIntegration(t *testing.T) {
+	ctx := context.Background()
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		getEnv("DB_USER", "postgres"),
+		getEnv("DB_PASSWORD", "postgres"),
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "5432"),
+		getEnv("DB_NAME", "macrochain_test"),
+	)
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err, "Failed to connect to database")
+	t.Cleanup(pool.Close)
+
+	migrator, err := New(pool)
+	require.NoError(t, err)
+	t.Cleanup(func() { migrator.Close() })
+	latest := int64(migrator.count(0, math.MaxInt64))
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	// Applying again is a no-op
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	var tables int
+	err = pool.QueryRow(ctx, `
+		SELECT count(*) FROM information_schema.tables
+		WHERE table_name IN ('data_points', 'latest_data_points', 'queue_outbox', 'series', 'scrape_runs', 'series_gaps')`).Scan(&tables)
+	require.NoError(t, err)
+	assert.Equal(t, 6, tables)
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	driver, err := Source()
+	require.NoError(t, err)
+	defer driver.Close()
+
+	version, err := driver.First()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+
+	// Versions are contiguous and come in up and down pairs so a missing file is noticed
+	for expected := uint(1); err == nil; expected++ {
+		assert.Equal(t, expected, version)
+		for _, read := range []func(uint) (io.ReadCloser, string, error){driver.ReadUp, driver.ReadDown} {
+			body, name, err := read(version)
+			require.NoError(t, err, "migration %d", version)
+			data, err := io.ReadAll(body)
+			body.Close()
+			require.NoError(t, err)
+			assert.NotEmpty(t, data, name)
+		}
+		version, err = driver.Next(version)
+	}
+}
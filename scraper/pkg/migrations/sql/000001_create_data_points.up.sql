@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS data_points (
+	source   TEXT NOT NULL,
+	code     TEXT NOT NULL,
+	time     TIMESTAMPTZ NOT NULL,
+	value    DOUBLE PRECISION NOT NULL,
+	unit     TEXT NOT NULL DEFAULT '',
+	metadata JSONB,
+	PRIMARY KEY (source, code, time)
+);
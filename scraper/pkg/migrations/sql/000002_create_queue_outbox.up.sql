@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS queue_outbox (
+	id         BIGSERIAL PRIMARY KEY,
+	topic      TEXT NOT NULL,
+	message    JSONB NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	attempts   INT NOT NULL DEFAULT 0,
+	last_error TEXT
+);
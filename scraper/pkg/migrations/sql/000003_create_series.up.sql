@@ -0,0 +1,10 @@
+-- Every series stored in data_points, with the attributes shared by its points
+CREATE TABLE IF NOT EXISTS series (
+	source      TEXT NOT NULL,
+	code        TEXT NOT NULL,
+	unit        TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	first_seen  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	last_seen   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (source, code)
+);
@@ -0,0 +1,12 @@
+-- History of scraper runs and their outcome
+CREATE TABLE IF NOT EXISTS scrape_runs (
+	id          BIGSERIAL PRIMARY KEY,
+	source      TEXT NOT NULL,
+	started_at  TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ,
+	status      TEXT NOT NULL,
+	points      INT NOT NULL DEFAULT 0,
+	error       TEXT
+);
+
+CREATE INDEX IF NOT EXISTS scrape_runs_source_started_at_idx ON scrape_runs (source, started_at DESC);
@@ -0,0 +1,15 @@
+-- Hourly and daily aggregates replacing raw data points once they are downsampled by a retention policy
+CREATE TABLE IF NOT EXISTS data_point_aggregates (
+	source     TEXT NOT NULL,
+	code       TEXT NOT NULL,
+	resolution TEXT NOT NULL,
+	bucket     TIMESTAMPTZ NOT NULL,
+	open       DOUBLE PRECISION NOT NULL,
+	high       DOUBLE PRECISION NOT NULL,
+	low        DOUBLE PRECISION NOT NULL,
+	close      DOUBLE PRECISION NOT NULL,
+	average    DOUBLE PRECISION NOT NULL,
+	points     BIGINT NOT NULL,
+	unit       TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (source, code, resolution, bucket)
+);
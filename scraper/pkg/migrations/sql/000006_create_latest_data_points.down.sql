@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW IF EXISTS latest_data_points;
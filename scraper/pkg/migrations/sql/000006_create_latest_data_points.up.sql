@@ -0,0 +1,8 @@
+-- Latest observation of every series, so current values are read without scanning history
+CREATE MATERIALIZED VIEW IF NOT EXISTS latest_data_points AS
+SELECT DISTINCT ON (source, code) source, code, time, value, unit, metadata
+FROM data_points
+ORDER BY source, code, time DESC;
+
+-- The unique index allows refreshing the view concurrently with readers
+CREATE UNIQUE INDEX IF NOT EXISTS latest_data_points_series_idx ON latest_data_points (source, code);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scrape_payloads;
+DROP TABLE IF EXISTS raw_payloads;
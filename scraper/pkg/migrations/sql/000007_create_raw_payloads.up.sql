@@ -0,0 +1,20 @@
+-- Original payloads fetched by the scrapers, compressed and stored once per distinct content
+CREATE TABLE IF NOT EXISTS raw_payloads (
+	sha256       TEXT PRIMARY KEY,
+	content_type TEXT NOT NULL DEFAULT '',
+	encoding     TEXT NOT NULL,
+	size         INT NOT NULL,
+	body         BYTEA NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Payloads fetched by every scrape, an unchanged source references the payload stored earlier
+CREATE TABLE IF NOT EXISTS scrape_payloads (
+	id         BIGSERIAL PRIMARY KEY,
+	source     TEXT NOT NULL,
+	url        TEXT NOT NULL,
+	fetched_at TIMESTAMPTZ NOT NULL,
+	sha256     TEXT NOT NULL REFERENCES raw_payloads (sha256)
+);
+
+CREATE INDEX IF NOT EXISTS scrape_payloads_source_fetched_at_idx ON scrape_payloads (source, fetched_at DESC);
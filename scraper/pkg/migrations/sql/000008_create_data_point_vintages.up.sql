@@ -0,0 +1,12 @@
+-- Every distinct value observed for a data point, keyed by when it was observed, so revised
+-- series can be read as they were known on a past date
+CREATE TABLE IF NOT EXISTS data_point_vintages (
+	source      TEXT NOT NULL,
+	code        TEXT NOT NULL,
+	time        TIMESTAMPTZ NOT NULL,
+	observed_at TIMESTAMPTZ NOT NULL,
+	value       DOUBLE PRECISION NOT NULL,
+	unit        TEXT NOT NULL DEFAULT '',
+	metadata    JSONB,
+	PRIMARY KEY (source, code, time, observed_at)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS series_id_idx;
+
+ALTER TABLE series
+	DROP COLUMN IF EXISTS id,
+	DROP COLUMN IF EXISTS frequency,
+	DROP COLUMN IF EXISTS country;
@@ -0,0 +1,7 @@
+-- Turns the series table into a catalog describing every series, filled in by the scrapers on start
+ALTER TABLE series
+	ADD COLUMN IF NOT EXISTS id        BIGSERIAL,
+	ADD COLUMN IF NOT EXISTS frequency TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS country   TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS series_id_idx ON series (id);
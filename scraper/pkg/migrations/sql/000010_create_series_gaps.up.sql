@@ -0,0 +1,13 @@
+-- Observation dates missing from series with a known frequency, found by the gap detector.
+-- A gap is resolved once the missing observation is stored, e.g. by a backfill
+CREATE TABLE IF NOT EXISTS series_gaps (
+	source      TEXT NOT NULL,
+	code        TEXT NOT NULL,
+	date        DATE NOT NULL,
+	frequency   TEXT NOT NULL,
+	detected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	resolved_at TIMESTAMPTZ,
+	PRIMARY KEY (source, code, date)
+);
+
+CREATE INDEX IF NOT EXISTS series_gaps_open_idx ON series_gaps (source, code) WHERE resolved_at IS NULL;
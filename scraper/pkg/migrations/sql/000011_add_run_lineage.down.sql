@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS scrape_payloads_run_id_idx;
+ALTER TABLE scrape_payloads DROP COLUMN IF EXISTS run_id;
+
+ALTER TABLE data_point_vintages DROP COLUMN IF EXISTS run_id;
+
+DROP INDEX IF EXISTS data_points_run_id_idx;
+ALTER TABLE data_points DROP COLUMN IF EXISTS run_id;
+
+DROP INDEX IF EXISTS scrape_runs_run_id_idx;
+ALTER TABLE scrape_runs DROP COLUMN IF EXISTS run_id;
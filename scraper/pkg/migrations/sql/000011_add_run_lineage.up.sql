@@ -0,0 +1,12 @@
+-- Links stored rows to the scraper run that produced them, runs are identified by the ID carried in
+-- the result metadata
+ALTER TABLE scrape_runs ADD COLUMN IF NOT EXISTS run_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS scrape_runs_run_id_idx ON scrape_runs (run_id);
+
+ALTER TABLE data_points ADD COLUMN IF NOT EXISTS run_id TEXT;
+CREATE INDEX IF NOT EXISTS data_points_run_id_idx ON data_points (run_id) WHERE run_id IS NOT NULL;
+
+ALTER TABLE data_point_vintages ADD COLUMN IF NOT EXISTS run_id TEXT;
+
+ALTER TABLE scrape_payloads ADD COLUMN IF NOT EXISTS run_id TEXT;
+CREATE INDEX IF NOT EXISTS scrape_payloads_run_id_idx ON scrape_payloads (run_id) WHERE run_id IS NOT NULL;
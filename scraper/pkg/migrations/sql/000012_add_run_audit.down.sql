@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS scrape_runs_started_at_idx;
+
+ALTER TABLE scrape_runs DROP COLUMN IF EXISTS bytes_fetched;
+ALTER TABLE scrape_runs DROP COLUMN IF EXISTS duration_ms;
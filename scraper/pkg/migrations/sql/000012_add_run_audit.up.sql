@@ -0,0 +1,8 @@
+-- Audit details of the scraper runs, and an index for the history of all scrapers
+ALTER TABLE scrape_runs ADD COLUMN IF NOT EXISTS duration_ms BIGINT;
+ALTER TABLE scrape_runs ADD COLUMN IF NOT EXISTS bytes_fetched BIGINT NOT NULL DEFAULT 0;
+
+UPDATE scrape_runs SET duration_ms = (EXTRACT(EPOCH FROM finished_at - started_at) * 1000)::BIGINT
+WHERE finished_at IS NOT NULL AND duration_ms IS NULL;
+
+CREATE INDEX IF NOT EXISTS scrape_runs_started_at_idx ON scrape_runs (started_at DESC);
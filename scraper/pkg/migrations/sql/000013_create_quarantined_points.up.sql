@@ -0,0 +1,16 @@
+-- Observations held back by the anomaly detector as implausible, e.g. a value parsed from the
+-- wrong field. They are kept for review instead of data_points
+CREATE TABLE IF NOT EXISTS quarantined_points (
+	source      TEXT NOT NULL,
+	code        TEXT NOT NULL,
+	time        TIMESTAMPTZ NOT NULL,
+	value       DOUBLE PRECISION NOT NULL,
+	unit        TEXT NOT NULL DEFAULT '',
+	metadata    JSONB,
+	run_id      TEXT,
+	reason      TEXT NOT NULL,
+	score       DOUBLE PRECISION NOT NULL,
+	previous    DOUBLE PRECISION,
+	detected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (source, code, time)
+);
@@ -0,0 +1,2 @@
+-- Class of the error of failed runs, e.g. rate_limited or source_changed
+ALTER TABLE scrape_runs ADD COLUMN IF NOT EXISTS error_class TEXT;
@@ -0,0 +1,19 @@
+-- The latest observation of every series is upserted with the points instead of refreshing a view,
+-- which rescanned the whole history
+DROP MATERIALIZED VIEW IF EXISTS latest_data_points;
+
+CREATE TABLE IF NOT EXISTS latest_data_points (
+	source   TEXT NOT NULL,
+	code     TEXT NOT NULL,
+	time     TIMESTAMPTZ NOT NULL,
+	value    DOUBLE PRECISION NOT NULL,
+	unit     TEXT NOT NULL DEFAULT '',
+	metadata JSONB,
+	PRIMARY KEY (source, code)
+);
+
+INSERT INTO latest_data_points (source, code, time, value, unit, metadata)
+SELECT DISTINCT ON (source, code) source, code, time, value, unit, metadata
+FROM data_points
+ORDER BY source, code, time DESC
+ON CONFLICT (source, code) DO NOTHING;
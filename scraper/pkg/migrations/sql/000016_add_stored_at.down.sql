@@ -0,0 +1,3 @@
+ALTER TABLE scrape_payloads DROP COLUMN IF EXISTS stored_at;
+
+ALTER TABLE data_points DROP COLUMN IF EXISTS updated_at;
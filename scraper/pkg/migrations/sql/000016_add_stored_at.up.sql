@@ -0,0 +1,5 @@
+-- When rows were last written, so archived days are exported again once late or revised points
+-- and payloads are stored for them
+ALTER TABLE data_points ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE scrape_payloads ADD COLUMN IF NOT EXISTS stored_at TIMESTAMPTZ NOT NULL DEFAULT now();
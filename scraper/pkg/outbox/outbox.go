@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Execer runs a statement, it is implemented by pgx connections, pools and transactions
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Outbox stores messages in Postgres until a Relay publishes them to the queue. Adding a message
+// in the transaction that persists the data it announces avoids publishing data that was rolled
+// back, or losing the message when the process stops between the commit and the publish
+type Outbox struct {
+	pool *pgxpool.Pool
+}
+
+// New creates an outbox storing its messages through the given pool
+func New(pool *pgxpool.Pool) *Outbox {
+	return &Outbox{pool: pool}
+}
+
+// Add stores a message for topic using db, which is usually the transaction persisting the data
+// the message describes. The ID is assigned here so consumers can recognize a republished message
+func (o *Outbox) Add(ctx context.Context, db Execer, topic string, message queue.Message) error {
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	_, err = db.Exec(ctx, `INSERT INTO queue_outbox (topic, message) VALUES ($1, $2)`, topic, data)
+	if err != nil {
+		return fmt.Errorf("failed to add message to outbox: %w", err)
+	}
+	return nil
+}
+
+// Send stores a message on its own, it lets the outbox stand in for a queue when publishing
+func (o *Outbox) Send(ctx context.Context, topic string, message queue.Message) error {
+	return o.Add(ctx, o.pool, topic, message)
+}
+
+// InTx runs fn in a transaction, messages added to the transaction are only published once it commits
+func (o *Outbox) InTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, o.pool, fn)
+}
@@ -0,0 +1,124 @@
+//go:build integration
+// +build integration
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/migrations"
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func newTestOutbox(t *testing.T, ctx context.Context) (*Outbox, *pgxpool.Pool) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		getEnv("DB_USER", "postgres"),
+		getEnv("DB_PASSWORD", "postgres"),
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "5432"),
+		getEnv("DB_NAME", "macrochain_test"),
+	)
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	defer migrator.Close()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	box := New(pool)
+	if _, err := pool.Exec(ctx, `TRUNCATE queue_outbox`); err != nil {
+		t.Fatalf("Failed to empty outbox: %v", err)
+	}
+	return box, pool
+}
+
+func TestOutboxRelayIntegration(t *testing.T) {
+	ctx := context.Background()
+	box, pool := newTestOutbox(t, ctx)
+
+	// A rolled back transaction must not publish anything
+	err := box.InTx(ctx, func(tx pgx.Tx) error {
+		if err := box.Add(ctx, tx, "results", queue.Message{Body: []byte("rolled back")}); err != nil {
+			return err
+		}
+		return errors.New("persistence failed")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+
+	err = box.InTx(ctx, func(tx pgx.Tx) error {
+		for _, body := range []string{"first", "second"} {
+			if err := box.Add(ctx, tx, "results", queue.Message{Body: []byte(body)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to add messages: %v", err)
+	}
+	if err := box.Send(ctx, "events", queue.Message{Body: []byte("third")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	q := &recordingQueue{failing: true}
+	relay := NewRelay(pool, q, RelayOptions{BatchSize: 10})
+
+	// Failed publishes keep the messages in the outbox
+	if published, err := relay.publishBatch(ctx); err == nil || published != 0 {
+		t.Fatalf("Expected the publish to fail, got %d published and %v", published, err)
+	}
+	var attempts int
+	if err := pool.QueryRow(ctx, `SELECT max(attempts) FROM queue_outbox`).Scan(&attempts); err != nil || attempts != 1 {
+		t.Errorf("Expected the failed attempt to be recorded, got %d, %v", attempts, err)
+	}
+
+	q.failing = false
+	if published, err := relay.publishBatch(ctx); err != nil || published != 3 {
+		t.Fatalf("Expected 3 published messages, got %d and %v", published, err)
+	}
+
+	expected := []string{"results:first", "results:second", "events:third"}
+	if len(q.sent) != len(expected) {
+		t.Fatalf("Expected %v, got %d messages", expected, len(q.sent))
+	}
+	for i, message := range q.sent {
+		if got := message.Topic + ":" + string(message.Body); got != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], got)
+		}
+		if message.ID == "" || time.Since(message.Timestamp) > time.Minute {
+			t.Errorf("Expected an ID and timestamp assigned by the outbox, got %+v", message)
+		}
+	}
+
+	var remaining int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM queue_outbox`).Scan(&remaining); err != nil || remaining != 0 {
+		t.Errorf("Expected an empty outbox, got %d, %v", remaining, err)
+	}
+}
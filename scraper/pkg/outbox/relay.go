@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RelayOptions configures how often and how much a Relay publishes
+type RelayOptions struct {
+	// Interval is how long the relay waits after it emptied the outbox, defaults to 1 second
+	Interval time.Duration
+	// BatchSize is the number of messages published per transaction, defaults to 100
+	BatchSize int
+}
+
+// Beginner starts transactions, it is implemented by pgx pools and connections
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Relay publishes the messages of the outbox to the queue in the order they were added.
+// A message is deleted once the queue accepted it, so it is published at least once
+type Relay struct {
+	db      Beginner
+	queue   queue.Queue
+	options RelayOptions
+}
+
+// NewRelay creates a relay from the outbox table of db, usually a pool, to q
+func NewRelay(db Beginner, q queue.Queue, options RelayOptions) *Relay {
+	if options.Interval <= 0 {
+		options.Interval = 1 * time.Second
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 100
+	}
+
+	return &Relay{
+		db:      db,
+		queue:   q,
+		options: options,
+	}
+}
+
+// Run publishes the outbox until the context is cancelled. Several relays may run at once,
+// every pending message is locked by one of them
+func (r *Relay) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Outbox relay started", "interval", r.options.Interval)
+
+	for {
+		published, err := r.publishBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to relay outbox messages", "error", err)
+		}
+
+		// Keep going while the outbox is full, otherwise wait for new messages
+		if err == nil && published == r.options.BatchSize {
+			continue
+		}
+		select {
+		case <-time.After(r.options.Interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Outbox relay stopped")
+			return
+		}
+	}
+}
+
+// publishBatch publishes the oldest messages of the outbox, returning how many were published
+func (r *Relay) publishBatch(ctx context.Context) (int, error) {
+	published := 0
+	var sendErr error
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT id, topic, message FROM queue_outbox
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED`, r.options.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to read outbox: %w", err)
+		}
+
+		type entry struct {
+			id    int64
+			topic string
+			data  []byte
+		}
+		entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entry, error) {
+			var e entry
+			err := row.Scan(&e.id, &e.topic, &e.data)
+			return e, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read outbox: %w", err)
+		}
+
+		var sent []int64
+		for _, e := range entries {
+			var message queue.Message
+			if err := json.Unmarshal(e.data, &message); err != nil {
+				// A malformed entry can never be published, drop it instead of blocking the outbox
+				slog.ErrorContext(ctx, "Dropped malformed outbox message", "id", e.id, "topic", e.topic, "error", err)
+				sent = append(sent, e.id)
+				continue
+			}
+
+			if sendErr = r.queue.Send(ctx, e.topic, message); sendErr != nil {
+				// Later messages wait so the order of the outbox is kept
+				_, err := tx.Exec(ctx, `UPDATE queue_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, e.id, sendErr.Error())
+				if err != nil {
+					return fmt.Errorf("failed to record outbox failure: %w", err)
+				}
+				sendErr = fmt.Errorf("failed to publish outbox message %d: %w", e.id, sendErr)
+				break
+			}
+			sent = append(sent, e.id)
+		}
+
+		if len(sent) > 0 {
+			if _, err := tx.Exec(ctx, `DELETE FROM queue_outbox WHERE id = ANY($1)`, sent); err != nil {
+				return fmt.Errorf("failed to delete published outbox messages: %w", err)
+			}
+		}
+		published = len(sent)
+		// The transaction commits even after a failed publish to keep the published messages deleted
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, sendErr
+}
@@ -0,0 +1,232 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// recordingQueue records sent messages and fails while failing is set, only Send is implemented
+type recordingQueue struct {
+	queue.Queue
+
+	mu      sync.Mutex
+	failing bool
+	sent    []queue.Message
+}
+
+func (q *recordingQueue) Send(ctx context.Context, topic string, message queue.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.failing {
+		return errors.New("queue unavailable")
+	}
+	message.Topic = topic
+	q.sent = append(q.sent, message)
+	return nil
+}
+
+// outboxRow is a row of the queue_outbox table
+type outboxRow struct {
+	id        int64
+	topic     string
+	message   []byte
+	attempts  int
+	lastError string
+}
+
+// memoryOutbox serves the statements of the relay from rows in memory, changes of a transaction
+// are only kept once it commits
+type memoryOutbox struct {
+	rows []outboxRow
+	// claims are the LIMIT arguments of the queries locking rows
+	claims []int
+}
+
+func (o *memoryOutbox) add(t *testing.T, topic, body string) {
+	t.Helper()
+	data, err := json.Marshal(queue.Message{ID: body, Body: []byte(body)})
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	o.rows = append(o.rows, outboxRow{id: int64(len(o.rows) + 1), topic: topic, message: data})
+}
+
+func (o *memoryOutbox) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &memoryTx{outbox: o, rows: slices.Clone(o.rows)}, nil
+}
+
+// memoryTx is a transaction of a memoryOutbox, only the methods used by the relay are implemented
+type memoryTx struct {
+	pgx.Tx
+
+	outbox *memoryOutbox
+	rows   []outboxRow
+	closed bool
+}
+
+func (tx *memoryTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if !strings.Contains(sql, "FOR UPDATE SKIP LOCKED") {
+		return nil, errors.New("outbox rows must be locked while they are published")
+	}
+	limit := args[0].(int)
+	tx.outbox.claims = append(tx.outbox.claims, limit)
+	return &memoryRows{rows: tx.rows[:min(limit, len(tx.rows))], index: -1}, nil
+}
+
+func (tx *memoryTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(sql), "UPDATE queue_outbox SET attempts"):
+		for i := range tx.rows {
+			if tx.rows[i].id == args[0].(int64) {
+				tx.rows[i].attempts++
+				tx.rows[i].lastError = args[1].(string)
+			}
+		}
+	case strings.HasPrefix(strings.TrimSpace(sql), "DELETE FROM queue_outbox"):
+		ids := args[0].([]int64)
+		tx.rows = slices.DeleteFunc(tx.rows, func(row outboxRow) bool {
+			return slices.Contains(ids, row.id)
+		})
+	default:
+		return pgconn.CommandTag{}, errors.New("unexpected statement: " + sql)
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *memoryTx) Commit(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	tx.outbox.rows = tx.rows
+	return nil
+}
+
+func (tx *memoryTx) Rollback(ctx context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+// memoryRows iterates over outbox rows as selected by the relay
+type memoryRows struct {
+	pgx.Rows
+
+	rows  []outboxRow
+	index int
+}
+
+func (r *memoryRows) Next() bool {
+	r.index++
+	return r.index < len(r.rows)
+}
+
+func (r *memoryRows) Scan(dest ...any) error {
+	row := r.rows[r.index]
+	*dest[0].(*int64) = row.id
+	*dest[1].(*string) = row.topic
+	*dest[2].(*[]byte) = row.message
+	return nil
+}
+
+func (r *memoryRows) Close()                                       {}
+func (r *memoryRows) Err() error                                   { return nil }
+func (r *memoryRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+
+func TestRelayPublishBatch(t *testing.T) {
+	ctx := context.Background()
+	outbox := &memoryOutbox{}
+	for _, body := range []string{"first", "second", "third"} {
+		outbox.add(t, "results", body)
+	}
+
+	q := &recordingQueue{}
+	relay := NewRelay(outbox, q, RelayOptions{BatchSize: 2})
+
+	// The oldest messages are claimed and published in order, then deleted
+	published, err := relay.publishBatch(ctx)
+	if err != nil || published != 2 {
+		t.Fatalf("Expected 2 published messages, got %d and %v", published, err)
+	}
+	if len(outbox.claims) != 1 || outbox.claims[0] != 2 {
+		t.Errorf("Expected a claim of the batch size, got %v", outbox.claims)
+	}
+	if len(q.sent) != 2 || string(q.sent[0].Body) != "first" || string(q.sent[1].Body) != "second" || q.sent[0].Topic != "results" {
+		t.Errorf("Expected first and second to be published to results, got %+v", q.sent)
+	}
+	if len(outbox.rows) != 1 || outbox.rows[0].id != 3 {
+		t.Errorf("Expected only the third message to remain, got %+v", outbox.rows)
+	}
+
+	published, err = relay.publishBatch(ctx)
+	if err != nil || published != 1 {
+		t.Fatalf("Expected 1 published message, got %d and %v", published, err)
+	}
+	if len(outbox.rows) != 0 {
+		t.Errorf("Expected an empty outbox, got %+v", outbox.rows)
+	}
+
+	published, err = relay.publishBatch(ctx)
+	if err != nil || published != 0 {
+		t.Errorf("Expected nothing to publish, got %d and %v", published, err)
+	}
+}
+
+func TestRelayPublishBatchFailure(t *testing.T) {
+	ctx := context.Background()
+	outbox := &memoryOutbox{}
+	outbox.add(t, "results", "first")
+	outbox.add(t, "results", "second")
+
+	q := &recordingQueue{failing: true}
+	relay := NewRelay(outbox, q, RelayOptions{BatchSize: 10})
+
+	// A failed publish is recorded and keeps the later messages waiting
+	published, err := relay.publishBatch(ctx)
+	if err == nil || published != 0 {
+		t.Fatalf("Expected the publish to fail, got %d published and %v", published, err)
+	}
+	if len(outbox.rows) != 2 || outbox.rows[0].attempts != 1 || outbox.rows[0].lastError != "queue unavailable" || outbox.rows[1].attempts != 0 {
+		t.Errorf("Expected the failed attempt of the first message to be recorded, got %+v", outbox.rows)
+	}
+
+	q.failing = false
+	published, err = relay.publishBatch(ctx)
+	if err != nil || published != 2 {
+		t.Fatalf("Expected 2 published messages, got %d and %v", published, err)
+	}
+	if len(q.sent) != 2 || string(q.sent[0].Body) != "first" {
+		t.Errorf("Expected the messages to be published in order, got %+v", q.sent)
+	}
+}
+
+func TestRelayDropsMalformedMessages(t *testing.T) {
+	ctx := context.Background()
+	outbox := &memoryOutbox{}
+	outbox.rows = append(outbox.rows, outboxRow{id: 1, topic: "results", message: []byte("not json")})
+	outbox.add(t, "results", "valid")
+
+	q := &recordingQueue{}
+	published, err := NewRelay(outbox, q, RelayOptions{}).publishBatch(ctx)
+	if err != nil || published != 2 {
+		t.Fatalf("Expected both messages to leave the outbox, got %d and %v", published, err)
+	}
+	if len(q.sent) != 1 || string(q.sent[0].Body) != "valid" {
+		t.Errorf("Expected only the valid message to be published, got %+v", q.sent)
+	}
+	if len(outbox.rows) != 0 {
+		t.Errorf("Expected an empty outbox, got %+v", outbox.rows)
+	}
+}
@@ -0,0 +1,238 @@
+package persister
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+)
+
+// bufferExt is the extension of buffered results, results that cannot be stored once the store
+// is back are renamed with failedExt and kept for inspection
+const (
+	bufferExt = ".json"
+	failedExt = ".failed"
+)
+
+// BufferOptions configures a Buffer
+type BufferOptions struct {
+	// Interval is how often the buffer tries to drain into the store, defaults to 5 seconds
+	Interval time.Duration
+	// Unavailable reports whether a store error is an outage worth buffering, defaults to storage.IsUnavailable
+	Unavailable func(error) bool
+}
+
+// bufferedResult is a result as written to the buffer directory
+type bufferedResult struct {
+	Source    string                    `json:"source"`
+	Timestamp time.Time                 `json:"timestamp"`
+	Metadata  map[string]string         `json:"metadata,omitempty"`
+	Points    []scraper.TimeSeriesPoint `json:"points"`
+}
+
+// Buffer is a Store writing results to a local write-ahead buffer while its store is unavailable,
+// so the messages are acknowledged instead of being dead-lettered during an outage. Every result
+// is a file named after its sequence number, the files are drained into the store in order and
+// new results are buffered until the buffer is empty so they are not stored ahead of older ones
+type Buffer struct {
+	store   Store
+	dir     string
+	options BufferOptions
+
+	mu      sync.Mutex
+	next    uint64
+	pending int
+}
+
+// NewBuffer creates a buffer for store in dir, results buffered by an earlier process are drained first
+func NewBuffer(store Store, dir string, options BufferOptions) (*Buffer, error) {
+	if options.Interval <= 0 {
+		options.Interval = 5 * time.Second
+	}
+	if options.Unavailable == nil {
+		options.Unavailable = storage.IsUnavailable
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create buffer directory: %w", err)
+	}
+
+	b := &Buffer{store: store, dir: dir, options: options}
+	sequences, err := b.sequences()
+	if err != nil {
+		return nil, err
+	}
+	b.pending = len(sequences)
+	if len(sequences) > 0 {
+		b.next = sequences[len(sequences)-1] + 1
+		slog.Info("Found buffered results", "dir", dir, "results", len(sequences))
+	}
+	return b, nil
+}
+
+// WritePoints stores a result, or buffers it when the store is unavailable or older results are
+// still buffered. Buffered points are reported as written
+func (b *Buffer) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	if b.Pending() == 0 {
+		written, err := b.store.WritePoints(ctx, result)
+		if err == nil || !b.options.Unavailable(err) || ctx.Err() != nil {
+			return written, err
+		}
+		slog.WarnContext(ctx, "Store unavailable, buffering results", "source", result.Source, "error", err)
+	}
+
+	points, _ := result.Data.([]scraper.TimeSeriesPoint)
+	if err := b.append(bufferedResult{Source: result.Source, Timestamp: result.Timestamp, Metadata: result.Metadata, Points: points}); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
+// Pending returns the number of buffered results
+func (b *Buffer) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pending
+}
+
+// append writes a result to the end of the buffer, it is synced before returning so an
+// acknowledged message survives a crash
+func (b *Buffer) append(result bufferedResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal buffered result of %s: %w", result.Source, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	name := filepath.Join(b.dir, fmt.Sprintf("%020d", b.next)+bufferExt)
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create buffered result: %w", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return fmt.Errorf("failed to write buffered result: %w", err)
+	}
+
+	b.next++
+	b.pending++
+	return nil
+}
+
+// Drain stores the buffered results in order until the buffer is empty or the store fails,
+// returning how many results were stored
+func (b *Buffer) Drain(ctx context.Context) (int, error) {
+	drained := 0
+	for {
+		sequences, err := b.sequences()
+		if err != nil || len(sequences) == 0 {
+			return drained, err
+		}
+
+		for _, sequence := range sequences {
+			if err := b.drainOne(ctx, sequence); err != nil {
+				return drained, err
+			}
+			drained++
+		}
+	}
+}
+
+// drainOne stores a buffered result and removes it from the buffer. Results the store rejects
+// for another reason than an outage would block the buffer forever, they are set aside
+func (b *Buffer) drainOne(ctx context.Context, sequence uint64) error {
+	name := filepath.Join(b.dir, fmt.Sprintf("%020d", sequence)+bufferExt)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read buffered result: %w", err)
+	}
+
+	var buffered bufferedResult
+	err = json.Unmarshal(data, &buffered)
+	if err == nil {
+		_, err = b.store.WritePoints(ctx, scraper.Result{
+			Source:    buffered.Source,
+			Timestamp: buffered.Timestamp,
+			Metadata:  buffered.Metadata,
+			Data:      buffered.Points,
+		})
+		if err != nil && (b.options.Unavailable(err) || ctx.Err() != nil) {
+			return err
+		}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to store buffered result, setting it aside", "file", name, "error", err)
+		if err := os.Rename(name, strings.TrimSuffix(name, bufferExt)+failedExt); err != nil {
+			return fmt.Errorf("failed to set aside buffered result: %w", err)
+		}
+	} else if err := os.Remove(name); err != nil {
+		return fmt.Errorf("failed to remove buffered result: %w", err)
+	}
+
+	b.mu.Lock()
+	b.pending--
+	b.mu.Unlock()
+	return nil
+}
+
+// sequences returns the sequence numbers of the buffered results in order
+func (b *Buffer) sequences() ([]uint64, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buffered results: %w", err)
+	}
+
+	var sequences []uint64
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), bufferExt)
+		if !ok {
+			continue
+		}
+		if sequence, err := strconv.ParseUint(base, 10, 64); err == nil {
+			sequences = append(sequences, sequence)
+		}
+	}
+	slices.Sort(sequences)
+	return sequences, nil
+}
+
+// Run drains the buffer each interval until the context is cancelled
+func (b *Buffer) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Write-ahead buffer started", "dir", b.dir, "interval", b.options.Interval)
+
+	for {
+		if b.Pending() > 0 {
+			drained, err := b.Drain(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to drain buffered results", "drained", drained, "pending", b.Pending(), "error", err)
+			} else if drained > 0 {
+				slog.InfoContext(ctx, "Successfully drained buffered results", "results", drained)
+			}
+		}
+
+		select {
+		case <-time.After(b.options.Interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Write-ahead buffer stopped", "pending", b.Pending())
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package persister
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore fails with a connection error while it is down and rejects the sources in invalid
+type flakyStore struct {
+	memoryStore
+	down    bool
+	invalid map[string]bool
+}
+
+func (s *flakyStore) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	if s.down {
+		return 0, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	if s.invalid[result.Source] {
+		return 0, errors.New("value out of range")
+	}
+	return s.memoryStore.WritePoints(ctx, result)
+}
+
+func TestBuffer(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := &flakyStore{down: true, invalid: map[string]bool{"broken": true}}
+	buffer, err := NewBuffer(store, dir, BufferOptions{})
+	require.NoError(t, err)
+
+	result := func(source string) scraper.Result {
+		return scraper.Result{
+			Source:    source,
+			Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Data:      []scraper.TimeSeriesPoint{{Code: "rate", Value: 1.5, Timestamp: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}},
+		}
+	}
+
+	written, err := buffer.WritePoints(ctx, result("first"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	_, err = buffer.WritePoints(ctx, result("broken"))
+	require.NoError(t, err)
+
+	// Results stay behind the buffered ones once the store is back
+	store.down = false
+	_, err = buffer.WritePoints(ctx, result("second"))
+	require.NoError(t, err)
+	assert.Empty(t, store.results)
+	assert.Equal(t, 3, buffer.Pending())
+
+	// Another process finds the buffered results
+	reopened, err := NewBuffer(store, dir, BufferOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, reopened.Pending())
+
+	drained, err := buffer.Drain(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, drained)
+	assert.Zero(t, buffer.Pending())
+	require.Len(t, store.results, 2)
+	assert.Equal(t, "first", store.results[0].Source)
+	assert.Equal(t, "second", store.results[1].Source)
+	assert.Equal(t, result("first").Data, store.results[0].Data)
+
+	// The rejected result is set aside
+	failed, err := filepath.Glob(filepath.Join(dir, "*"+failedExt))
+	require.NoError(t, err)
+	assert.Len(t, failed, 1)
+
+	_, err = buffer.WritePoints(ctx, result("third"))
+	require.NoError(t, err)
+	assert.Len(t, store.results, 3)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestBuffer_DrainStopsWhileDown(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{down: true}
+	buffer, err := NewBuffer(store, t.TempDir(), BufferOptions{})
+	require.NoError(t, err)
+
+	_, err = buffer.WritePoints(ctx, scraper.Result{Source: "snb"})
+	require.NoError(t, err)
+
+	_, err = buffer.Drain(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, buffer.Pending())
+}
@@ -0,0 +1,142 @@
+package persister
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+)
+
+// wireResult is a result as encoded in JSON message bodies, its data is decoded once the shape is known
+type wireResult struct {
+	Source    string            `json:"source"`
+	Timestamp time.Time         `json:"timestamp"`
+	Data      json.RawMessage   `json:"data"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// wirePoint covers the JSON fields of every series type published by the scrapers,
+// such as TimeSeriesPoint and SNBInterestRate
+type wirePoint struct {
+	Code        string            `json:"code"`
+	Value       *float64          `json:"value"`
+	Unit        string            `json:"unit"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Date        time.Time         `json:"date"`
+	Description string            `json:"description"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// DecodeResult decodes and validates the scrape result carried by a message. Series data is
+// returned as []scraper.TimeSeriesPoint, data without series such as contract events as nil.
+// Payloads that can never be stored fail with queue.ErrInvalidMessage
+func DecodeResult(message queue.Message) (scraper.Result, error) {
+	var result scraper.Result
+	switch contentType := message.Metadata[queue.MetadataContentType]; contentType {
+	case queue.ContentTypeProtobuf:
+		decoded, err := scraper.UnmarshalResultProto(message.Body)
+		if err != nil {
+			return scraper.Result{}, fmt.Errorf("%w: %w", queue.ErrInvalidMessage, err)
+		}
+		result = decoded
+	case queue.ContentTypeJSON, "":
+		var wire wireResult
+		if err := json.Unmarshal(message.Body, &wire); err != nil {
+			return scraper.Result{}, fmt.Errorf("%w: failed to unmarshal result: %w", queue.ErrInvalidMessage, err)
+		}
+		result = scraper.Result{
+			Source:    wire.Source,
+			Timestamp: wire.Timestamp,
+			Data:      wire.Data,
+			Metadata:  wire.Metadata,
+		}
+	default:
+		return scraper.Result{}, fmt.Errorf("%w: unsupported content type %q", queue.ErrInvalidMessage, contentType)
+	}
+
+	// Only time series are decoded natively, any other data is left as JSON
+	if raw, ok := result.Data.(json.RawMessage); ok {
+		points, err := decodeSeries(raw)
+		if err != nil {
+			return scraper.Result{}, fmt.Errorf("%w: invalid data of %s: %w", queue.ErrInvalidMessage, result.Source, err)
+		}
+		result.Data = nil
+		if points != nil {
+			result.Data = points
+		}
+	}
+
+	if err := validate(result); err != nil {
+		return scraper.Result{}, fmt.Errorf("%w: %w", queue.ErrInvalidMessage, err)
+	}
+	return result, nil
+}
+
+// decodeSeries decodes the points of series data, returning nil for data holding no series
+func decodeSeries(raw json.RawMessage) ([]scraper.TimeSeriesPoint, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var wire []wirePoint
+	if err := json.Unmarshal(raw, &wire); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	var points []scraper.TimeSeriesPoint
+	for i, point := range wire {
+		// Elements without a code, such as contract events, are not series
+		if point.Code == "" && point.Value == nil {
+			continue
+		}
+		if point.Value == nil {
+			return nil, fmt.Errorf("point %d of %s has no value", i, point.Code)
+		}
+
+		timestamp := point.Timestamp
+		if timestamp.IsZero() {
+			timestamp = point.Date
+		}
+		metadata := point.Metadata
+		if point.Description != "" {
+			if metadata == nil {
+				metadata = make(map[string]string, 1)
+			}
+			metadata["description"] = point.Description
+		}
+
+		points = append(points, scraper.TimeSeriesPoint{
+			Code:      point.Code,
+			Value:     *point.Value,
+			Unit:      point.Unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
+
+// validate checks that a result names its source and that every point can be stored
+func validate(result scraper.Result) error {
+	if result.Source == "" {
+		return errors.New("result has no source")
+	}
+
+	points, _ := result.Data.([]scraper.TimeSeriesPoint)
+	for i, point := range points {
+		switch {
+		case point.Code == "":
+			return fmt.Errorf("point %d of %s has no code", i, result.Source)
+		case point.Timestamp.IsZero():
+			return fmt.Errorf("point %s of %s has no timestamp", point.Code, result.Source)
+		case math.IsNaN(point.Value) || math.IsInf(point.Value, 0):
+			return fmt.Errorf("point %s of %s has the non-finite value %v", point.Code, result.Source, point.Value)
+		}
+	}
+	return nil
+}
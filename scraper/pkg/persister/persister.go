@@ -0,0 +1,84 @@
+package persister
+
+import (
+	"context"
+	"log/slog"
+
+	"macrochain/scraper/pkg/logging"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the writes of the persisted results
+var tracer = otel.Tracer("macrochain/scraper/pkg/persister")
+
+// ResultType is the message type of scrape results and ResultSchemaVersion the newest schema understood
+const (
+	ResultType          = "scrape_result"
+	ResultSchemaVersion = 1
+)
+
+// Store persists the points of a scrape result, it is implemented by every storage.Storage
+type Store interface {
+	WritePoints(ctx context.Context, result scraper.Result) (int, error)
+}
+
+// Persister consumes the scrape results published by the scrapers and writes them to a store
+type Persister struct {
+	store Store
+}
+
+// New creates a persister writing to store
+func New(store Store) *Persister {
+	return &Persister{store: store}
+}
+
+// Run consumes the result topics matching pattern until the context is cancelled. Invalid payloads
+// are dead-lettered right away, results that fail to be stored are retried per the consumer options
+func (p *Persister) Run(ctx context.Context, q queue.Queue, pattern string, options queue.ConsumerOptions) error {
+	router := queue.NewRouter()
+	router.Handle(ResultType, ResultSchemaVersion, p.Handle)
+
+	slog.InfoContext(ctx, "Persister started", "pattern", pattern, "concurrency", options.Concurrency)
+	return queue.ConsumePattern(ctx, q, pattern, router.Dispatch, options)
+}
+
+// Handle decodes, validates and stores the result carried by a message
+func (p *Persister) Handle(ctx context.Context, message queue.Message) error {
+	result, err := DecodeResult(message)
+	if err != nil {
+		return err
+	}
+	// Publishers may only carry the run in the message metadata
+	if runID := message.Metadata[scraper.MetadataRunID]; runID != "" && result.Metadata[scraper.MetadataRunID] == "" {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]string, 1)
+		}
+		result.Metadata[scraper.MetadataRunID] = runID
+	}
+	// The records of the write are grouped with the ones of the scraper run
+	ctx = logging.With(ctx, "source", result.Source)
+	if runID := result.Metadata[scraper.MetadataRunID]; runID != "" {
+		ctx = logging.With(ctx, "run", runID)
+	}
+
+	ctx, span := tracer.Start(ctx, "store "+result.Source, trace.WithAttributes(attribute.String("source", result.Source)))
+	stored, err := p.store.WritePoints(ctx, result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return storage.Classify(err)
+	}
+	span.SetAttributes(attribute.Int("points", stored))
+	span.End()
+
+	slog.DebugContext(ctx, "Successfully persisted result", "messageID", message.ID, "points", stored)
+	return nil
+}
@@ -0,0 +1,158 @@
+package persister
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore records the stored results
+type memoryStore struct {
+	results []scraper.Result
+}
+
+func (s *memoryStore) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	s.results = append(s.results, result)
+	points, _ := result.Data.([]scraper.TimeSeriesPoint)
+	return len(points), nil
+}
+
+func jsonMessage(t *testing.T, result any) queue.Message {
+	t.Helper()
+	body, err := json.Marshal(result)
+	require.NoError(t, err)
+	return queue.Message{
+		Type:          ResultType,
+		SchemaVersion: ResultSchemaVersion,
+		Body:          body,
+		Metadata:      map[string]string{queue.MetadataContentType: queue.ContentTypeJSON},
+	}
+}
+
+func TestDecodeResult_TimeSeries(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	points := []scraper.TimeSeriesPoint{
+		{Code: "base_fee", Value: 20, Unit: "gwei", Timestamp: timestamp, Metadata: map[string]string{"block": "100"}},
+		{Code: "empty_blocks", Value: 0, Unit: "blocks", Timestamp: timestamp},
+	}
+	result := scraper.Result{Source: "ethereum", Timestamp: timestamp, Data: points}
+
+	decoded, err := DecodeResult(jsonMessage(t, result))
+	require.NoError(t, err)
+	assert.Equal(t, "ethereum", decoded.Source)
+	assert.Equal(t, points, decoded.Data)
+
+	body, err := scraper.MarshalResultProto(result)
+	require.NoError(t, err)
+	decoded, err = DecodeResult(queue.Message{
+		Body:     body,
+		Metadata: map[string]string{queue.MetadataContentType: queue.ContentTypeProtobuf},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, points, decoded.Data)
+}
+
+func TestDecodeResult_InterestRates(t *testing.T) {
+	date := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+	result := scraper.Result{
+		Source: "snb",
+		Data:   []scraper.SNBInterestRate{{Code: "SARON", Value: 1.5, Unit: "%", Date: date, Description: "Swiss Average Rate Overnight"}},
+	}
+
+	decoded, err := DecodeResult(jsonMessage(t, result))
+	require.NoError(t, err)
+	assert.Equal(t, []scraper.TimeSeriesPoint{{
+		Code:      "SARON",
+		Value:     1.5,
+		Unit:      "%",
+		Timestamp: date,
+		Metadata:  map[string]string{"description": "Swiss Average Rate Overnight"},
+	}}, decoded.Data)
+}
+
+func TestDecodeResult_DataWithoutSeries(t *testing.T) {
+	result := scraper.Result{
+		Source: "contract_logs",
+		Data:   []scraper.ContractEvent{{Label: "usdc", Event: "Transfer", BlockNumber: 100, Timestamp: time.Now()}},
+	}
+
+	decoded, err := DecodeResult(jsonMessage(t, result))
+	require.NoError(t, err)
+	assert.Nil(t, decoded.Data)
+}
+
+func TestDecodeResult_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		message queue.Message
+	}{
+		{name: "malformed body", message: queue.Message{Body: []byte("{")}},
+		{name: "missing source", message: jsonMessage(t, map[string]any{"data": []any{}})},
+		{name: "missing value", message: jsonMessage(t, map[string]any{
+			"source": "fed",
+			"data":   []any{map[string]any{"code": "DFF", "timestamp": time.Now()}},
+		})},
+		{name: "missing timestamp", message: jsonMessage(t, map[string]any{
+			"source": "fed",
+			"data":   []any{map[string]any{"code": "DFF", "value": 5.33}},
+		})},
+		{name: "data not a list", message: jsonMessage(t, map[string]any{"source": "fed", "data": "DFF"})},
+		{name: "unsupported content type", message: queue.Message{
+			Body:     []byte("<result/>"),
+			Metadata: map[string]string{queue.MetadataContentType: "application/xml"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeResult(tt.message)
+			assert.ErrorIs(t, err, queue.ErrInvalidMessage)
+		})
+	}
+}
+
+func TestPersister_Handle(t *testing.T) {
+	store := &memoryStore{}
+	persister := New(store)
+
+	result := scraper.Result{
+		Source: "fed",
+		Data:   []scraper.TimeSeriesPoint{{Code: "DFF", Value: 5.33, Unit: "%", Timestamp: time.Now().UTC()}},
+	}
+	require.NoError(t, persister.Handle(context.Background(), jsonMessage(t, result)))
+	require.Len(t, store.results, 1)
+	assert.Equal(t, "fed", store.results[0].Source)
+
+	// The run of a result may only be carried by the message
+	message := jsonMessage(t, result)
+	message.Metadata[scraper.MetadataRunID] = "run-1"
+	require.NoError(t, persister.Handle(context.Background(), message))
+	require.Len(t, store.results, 2)
+	assert.Equal(t, "run-1", store.results[1].Metadata[scraper.MetadataRunID])
+
+	err := persister.Handle(context.Background(), jsonMessage(t, map[string]any{"data": nil}))
+	assert.ErrorIs(t, err, queue.ErrInvalidMessage)
+	assert.Len(t, store.results, 2)
+}
+
+func TestPersister_HandleClassifiesOutages(t *testing.T) {
+	store := &flakyStore{down: true, invalid: map[string]bool{"broken": true}}
+	persister := New(store)
+	point := []scraper.TimeSeriesPoint{{Code: "DFF", Value: 5.33, Unit: "%", Timestamp: time.Now().UTC()}}
+
+	err := persister.Handle(context.Background(), jsonMessage(t, scraper.Result{Source: "fed", Data: point}))
+	assert.ErrorIs(t, err, errclass.ErrTransient, "Store outages should be retried")
+
+	store.down = false
+	err = persister.Handle(context.Background(), jsonMessage(t, scraper.Result{Source: "broken", Data: point}))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errclass.ErrTransient)
+}
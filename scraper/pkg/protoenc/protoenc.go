@@ -0,0 +1,152 @@
+// Package protoenc contains the protowire helpers shared by the hand written protobuf encoders,
+// see proto/macrochain.proto for the schemas
+package protoenc
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// AppendString appends a string field, empty strings are omitted as in proto3
+func AppendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+// AppendBytes appends a bytes field, empty values are omitted as in proto3
+func AppendBytes(b []byte, num protowire.Number, value []byte) []byte {
+	if len(value) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// AppendInt appends an int32 field, zero is omitted as in proto3
+func AppendInt(b []byte, num protowire.Number, value int) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(value)))
+}
+
+// AppendTimestamp appends a google.protobuf.Timestamp field, the zero time is omitted
+func AppendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	var ts []byte
+	if seconds := t.Unix(); seconds != 0 {
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(seconds))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(nanos))
+	}
+	return AppendMessage(b, num, ts)
+}
+
+// AppendStringMap appends a map<string, string> field as repeated key/value entries
+func AppendStringMap(b []byte, num protowire.Number, m map[string]string) []byte {
+	for key, value := range m {
+		var entry []byte
+		entry = AppendString(entry, 1, key)
+		entry = AppendString(entry, 2, value)
+		b = AppendMessage(b, num, entry)
+	}
+	return b
+}
+
+// AppendMessage appends an already encoded embedded message
+func AppendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+// Field is a single decoded field, only the value matching its wire type is set
+type Field struct {
+	Number protowire.Number
+	Bytes  []byte
+	Varint uint64
+	Fixed  uint64
+}
+
+// Fields decodes the top-level fields of an encoded message, calling fn for each of them
+func Fields(b []byte, fn func(field Field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		field := Field{Number: num}
+		switch typ {
+		case protowire.VarintType:
+			field.Varint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			field.Fixed, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			field.Fixed = uint64(v)
+		case protowire.BytesType:
+			field.Bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Timestamp decodes an embedded google.protobuf.Timestamp as UTC time
+func Timestamp(b []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := Fields(b, func(field Field) error {
+		switch field.Number {
+		case 1:
+			seconds = int64(field.Varint)
+		case 2:
+			nanos = int64(field.Varint)
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+// MapEntry decodes an embedded map<string, string> entry and stores it in m
+func MapEntry(b []byte, m map[string]string) error {
+	var key, value string
+	err := Fields(b, func(field Field) error {
+		switch field.Number {
+		case 1:
+			key = string(field.Bytes)
+		case 2:
+			value = string(field.Bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m[key] = value
+	return nil
+}
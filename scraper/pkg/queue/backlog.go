@@ -0,0 +1,21 @@
+package queue
+
+import "context"
+
+// Backlog is the state of a consumer group on a topic
+type Backlog struct {
+	Topic string
+	Group string
+	// Pending counts the messages delivered to a consumer and not acknowledged yet, -1 when the
+	// backend does not track deliveries
+	Pending int64
+	// Lag counts the messages not delivered to the group yet, -1 when the backend cannot tell
+	Lag int64
+}
+
+// BacklogReporter is implemented by the queues whose consumer groups keep their position on the
+// backend, so the backlog building up behind slow consumers can be observed
+type BacklogReporter interface {
+	// Backlog returns the backlog of every consumer group on the topics matching a glob pattern
+	Backlog(ctx context.Context, pattern string) ([]Backlog, error)
+}
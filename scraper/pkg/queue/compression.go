@@ -0,0 +1,123 @@
+package queue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"maps"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MetadataContentEncoding is the metadata key naming the compression of a message body
+const MetadataContentEncoding = "content_encoding"
+
+// Compression configures the compression of large message bodies
+type Compression struct {
+	// Algorithm is "gzip" or "zstd", empty disables compression
+	Algorithm string
+	// Threshold is the body size in bytes above which bodies are compressed
+	Threshold int
+	// MaxSize is the largest size in bytes a body may decompress to, larger bodies are invalid
+	// messages so a small message cannot exhaust the memory. Defaults to DefaultMaxDecompressedSize
+	MaxSize int
+}
+
+// DefaultMaxDecompressedSize is the largest size a body decompresses to unless configured
+const DefaultMaxDecompressedSize = 64 << 20
+
+// maxSize returns the largest size a body may decompress to
+func (c Compression) maxSize() int {
+	if c.MaxSize <= 0 {
+		return DefaultMaxDecompressedSize
+	}
+	return c.MaxSize
+}
+
+// EncodeAll is safe for concurrent use, so a single encoder is shared
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+// validate checks if the compression algorithm is supported
+func (c Compression) validate() error {
+	switch c.Algorithm {
+	case "", "gzip", "zstd":
+		return nil
+	}
+	return fmt.Errorf("unsupported compression %q", c.Algorithm)
+}
+
+// compressBody compresses the body of a message larger than the threshold, unless that does not make it smaller
+func compressBody(message Message, compression Compression) (Message, error) {
+	if compression.Algorithm == "" || len(message.Body) <= compression.Threshold || message.Metadata[MetadataContentEncoding] != "" {
+		return message, nil
+	}
+
+	var compressed []byte
+	switch compression.Algorithm {
+	case "gzip":
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(message.Body); err != nil {
+			return Message{}, fmt.Errorf("failed to compress message: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return Message{}, fmt.Errorf("failed to compress message: %w", err)
+		}
+		compressed = buf.Bytes()
+	case "zstd":
+		compressed = zstdEncoder.EncodeAll(message.Body, nil)
+	}
+	if len(compressed) >= len(message.Body) {
+		return message, nil
+	}
+
+	message.Body = compressed
+	message.Metadata = maps.Clone(message.Metadata)
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]string)
+	}
+	message.Metadata[MetadataContentEncoding] = compression.Algorithm
+	return message, nil
+}
+
+// decompressBody restores the body of a message compressed by compressBody, bodies decompressing
+// to more than maxSize bytes fail with ErrInvalidMessage
+func decompressBody(message Message, maxSize int) (Message, error) {
+	encoding := message.Metadata[MetadataContentEncoding]
+	if encoding == "" {
+		return message, nil
+	}
+
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case "gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(message.Body))
+	case "zstd":
+		var decoder *zstd.Decoder
+		if decoder, err = zstd.NewReader(bytes.NewReader(message.Body), zstd.WithDecoderConcurrency(1)); err == nil {
+			reader = decoder.IOReadCloser()
+		}
+	default:
+		err = fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	defer reader.Close()
+
+	// One byte past the limit tells a body of exactly maxSize bytes from a larger one
+	body, err := io.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	if len(body) > maxSize {
+		return Message{}, fmt.Errorf("%w: body decompresses to more than %d bytes", ErrInvalidMessage, maxSize)
+	}
+
+	message.Body = body
+	message.Metadata = maps.Clone(message.Metadata)
+	delete(message.Metadata, MetadataContentEncoding)
+	return message, nil
+}
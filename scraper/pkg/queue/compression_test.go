@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCodecCompressesLargeBodies(t *testing.T) {
+	large := bytes.Repeat([]byte(`{"code":"usd_chf","value":0.9125},`), 1000)
+
+	for _, algorithm := range []string{"gzip", "zstd"} {
+		codec, err := NewCodec("json", Compression{Algorithm: algorithm, Threshold: 1024})
+		if err != nil {
+			t.Fatalf("Failed to create codec: %v", err)
+		}
+
+		for _, body := range [][]byte{large, []byte("small")} {
+			data, err := codec.Encode(Message{ID: "id-1", Body: body, Metadata: map[string]string{"source": "test"}})
+			if err != nil {
+				t.Fatalf("Failed to encode message: %v", err)
+			}
+			compressed := len(body) > 1024
+			if compressed && len(data) >= len(body) {
+				t.Errorf("Expected %s to shrink a %d byte body, got %d bytes", algorithm, len(body), len(data))
+			}
+
+			// Consumers without compression configured still decompress
+			decoded, err := defaultCodec.Decode(data)
+			if err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+			if !bytes.Equal(decoded.Body, body) {
+				t.Errorf("Expected %s body to survive a round trip", algorithm)
+			}
+			if _, ok := decoded.Metadata[MetadataContentEncoding]; ok || decoded.Metadata["source"] != "test" {
+				t.Errorf("Expected only the content encoding to be removed, got %v", decoded.Metadata)
+			}
+		}
+	}
+}
+
+func TestCodecRejectsUnknownCompression(t *testing.T) {
+	if _, err := NewCodec("json", Compression{Algorithm: "lz4"}); err == nil {
+		t.Error("Expected an error for an unsupported compression")
+	}
+
+	data, err := defaultCodec.Encode(Message{ID: "id-1", Body: []byte("x"), Metadata: map[string]string{MetadataContentEncoding: "lz4"}})
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	if _, err := defaultCodec.Decode(data); err == nil {
+		t.Error("Expected an error for an unsupported content encoding")
+	}
+}
+
+func TestCodecRejectsDecompressionBombs(t *testing.T) {
+	zeros := make([]byte, 1<<20)
+	for _, algorithm := range []string{"gzip", "zstd"} {
+		codec, err := NewCodec("json", Compression{Algorithm: algorithm, MaxSize: len(zeros)})
+		if err != nil {
+			t.Fatalf("Failed to create codec: %v", err)
+		}
+		data, err := codec.Encode(Message{ID: "id-1", Body: zeros})
+		if err != nil {
+			t.Fatalf("Failed to encode message: %v", err)
+		}
+		if _, err := codec.Decode(data); err != nil {
+			t.Errorf("Expected a %s body of the maximum size to decode, got %v", algorithm, err)
+		}
+
+		limited, err := NewCodec("json", Compression{MaxSize: len(zeros) - 1})
+		if err != nil {
+			t.Fatalf("Failed to create codec: %v", err)
+		}
+		if _, err := limited.Decode(data); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("Expected ErrInvalidMessage for a %s body above the maximum size, got %v", algorithm, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Handler processes a received message, returning an error when it could not be handled
+type Handler func(ctx context.Context, message Message) error
+
+// ErrInvalidMessage is returned by handlers for messages whose content can never be handled,
+// they are dead-lettered without being retried. It is classified as errclass.ErrParse
+var ErrInvalidMessage = fmt.Errorf("%w: invalid message", errclass.ErrParse)
+
+// RetryPolicy configures how often and how fast a failing message is handled again
+type RetryPolicy struct {
+	// MaxAttempts is how often a message is handled before it is moved to the dead-letter queue
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, zero means no cap
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every attempt, defaults to 2
+	Multiplier float64
+	// Jitter randomizes every delay by up to this fraction, e.g. 0.2 for +-20%
+	Jitter float64
+	// RateLimitedBackoff is the least delay after an attempt that failed with errclass.ErrRateLimited,
+	// giving the limit time to reset
+	RateLimitedBackoff time.Duration
+}
+
+// DefaultRetryPolicy retries a message five times within roughly half a minute, or within a few
+// minutes when it is rate limited
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:        5,
+	InitialBackoff:     1 * time.Second,
+	MaxBackoff:         30 * time.Second,
+	Multiplier:         2,
+	Jitter:             0.2,
+	RateLimitedBackoff: 1 * time.Minute,
+}
+
+// Backoff returns the delay before the given attempt, the first attempt is not delayed
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt <= 1 || p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-2))
+	if p.MaxBackoff > 0 {
+		delay = math.Min(delay, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// Delay returns the delay before the given attempt after the previous one failed with cause,
+// rate limited failures wait at least RateLimitedBackoff
+func (p RetryPolicy) Delay(attempt int, cause error) time.Duration {
+	delay := p.Backoff(attempt)
+	if attempt > 1 && errclass.Of(cause) == errclass.RateLimited {
+		delay = max(delay, p.RateLimitedBackoff)
+	}
+	return delay
+}
+
+// ConsumerOptions configures how Consume runs the handler and handles failing messages
+type ConsumerOptions struct {
+	// Retry controls the redelivery of messages whose handler failed
+	Retry RetryPolicy
+	// Concurrency is the number of messages handled at once, defaults to 1. Messages handled
+	// concurrently may complete out of order, Kafka only commits the offset of a message once
+	// the earlier messages of its partition were acknowledged
+	Concurrency int
+	// Subscribe configures the delivery of the subscription, e.g. its buffer size
+	Subscribe []SubscribeOption
+	// OnDeadLetter is called after a message was moved to the dead-letter queue of topic, with the
+	// error of its last attempt
+	OnDeadLetter func(ctx context.Context, topic string, message Message, cause error)
+}
+
+// Consume subscribes to a topic and passes every message to handler until the context is
+// cancelled. Handled messages are acknowledged, messages that keep failing are dead-lettered.
+// It returns once the subscription ended and every received message was settled
+func Consume(ctx context.Context, q Queue, topic string, handler Handler, options ConsumerOptions) error {
+	messages, err := q.Subscribe(ctx, topic, options.Subscribe...)
+	if err != nil {
+		return err
+	}
+	consume(ctx, q, topic, messages, handler, options)
+	return nil
+}
+
+// ConsumePattern works like Consume for every topic matching a glob pattern such as "scraper_results.*",
+// messages are acknowledged and dead-lettered on the topic they were received from
+func ConsumePattern(ctx context.Context, q Queue, pattern string, handler Handler, options ConsumerOptions) error {
+	messages, err := q.SubscribePattern(ctx, pattern, options.Subscribe...)
+	if err != nil {
+		return err
+	}
+	consume(ctx, q, pattern, messages, handler, options)
+	return nil
+}
+
+// consume handles the messages of a subscription with a pool of workers until it ends
+func consume(ctx context.Context, q Queue, topic string, messages <-chan Message, handler Handler, options ConsumerOptions) {
+	if options.Retry.MaxAttempts <= 0 {
+		options.Retry.MaxAttempts = 1
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for range options.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range messages {
+				topic := cmp.Or(message.Topic, topic)
+				if err := handleMessage(ctx, q, topic, message, handler, options); err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "Failed to settle message", "topic", topic, "messageID", message.ID, "error", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handleMessage runs the handler until it succeeds or runs out of attempts, then acknowledges the message
+func handleMessage(ctx context.Context, q Queue, topic string, message Message, handler Handler, options ConsumerOptions) error {
+	ctx, span := startProcessSpan(ctx, topic, message)
+	defer span.End()
+
+	var err error
+	attempts := 0
+	for attempts < options.Retry.MaxAttempts {
+		attempts++
+		if delay := options.Retry.Delay(attempts, err); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				// The message is not acknowledged so durable backends deliver it again
+				return ctx.Err()
+			}
+		}
+
+		if err = safeHandle(ctx, handler, message); err == nil {
+			return q.Ack(ctx, topic, message)
+		}
+		slog.WarnContext(ctx, "Failed to handle message",
+			"topic", topic,
+			"messageID", message.ID,
+			"attempt", attempts,
+			"maxAttempts", options.Retry.MaxAttempts,
+			"error", err,
+		)
+
+		// Retrying cannot help with failures classified as permanent, such as messages this
+		// consumer does not understand
+		if errclass.Permanent(err) {
+			break
+		}
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "message dead-lettered")
+	if err := MoveToDeadLetter(ctx, q, topic, message, err, attempts); err != nil {
+		// The message is not acknowledged so durable backends deliver it again
+		return err
+	}
+	if options.OnDeadLetter != nil {
+		options.OnDeadLetter(ctx, topic, message, err)
+	}
+	return q.Ack(ctx, topic, message)
+}
+
+// safeHandle runs the handler, turning a panic into an error
+func safeHandle(ctx context.Context, handler Handler, message Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, message)
+}
@@ -0,0 +1,339 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// memoryQueue is an in-memory Queue recording sent and acknowledged messages
+type memoryQueue struct {
+	mu       sync.Mutex
+	messages chan Message
+	sent     map[string][]Message
+	acked    []string
+}
+
+func newMemoryQueue(messages ...Message) *memoryQueue {
+	q := &memoryQueue{
+		messages: make(chan Message, len(messages)),
+		sent:     make(map[string][]Message),
+	}
+	for _, message := range messages {
+		q.messages <- message
+	}
+	close(q.messages)
+	return q
+}
+
+func (q *memoryQueue) Send(ctx context.Context, topic string, message Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sent[topic] = append(q.sent[topic], message)
+	return nil
+}
+
+func (q *memoryQueue) Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error) {
+	return q.messages, nil
+}
+
+func (q *memoryQueue) SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error) {
+	return q.messages, nil
+}
+
+func (q *memoryQueue) SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error) {
+	return q.messages, nil
+}
+
+func (q *memoryQueue) Unsubscribe(ctx context.Context, topic string) error {
+	return nil
+}
+
+func (q *memoryQueue) Ack(ctx context.Context, topic string, message Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.acked = append(q.acked, message.ID)
+	return nil
+}
+
+func (q *memoryQueue) Close() error {
+	return nil
+}
+
+func TestConsumeDeadLettersFailingMessages(t *testing.T) {
+	ctx := context.Background()
+	q := newMemoryQueue(
+		Message{ID: "ok", Body: []byte("ok")},
+		Message{ID: "bad", Body: []byte("bad"), Metadata: map[string]string{"source": "test"}},
+	)
+
+	attempts := make(map[string]int)
+	handler := func(ctx context.Context, message Message) error {
+		attempts[message.ID]++
+		if message.ID == "bad" {
+			return errors.New("cannot handle message")
+		}
+		return nil
+	}
+
+	var notified []string
+	options := ConsumerOptions{
+		Retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		OnDeadLetter: func(ctx context.Context, topic string, message Message, cause error) {
+			notified = append(notified, fmt.Sprintf("%s/%s: %v", topic, message.ID, cause))
+		},
+	}
+	if err := Consume(ctx, q, "results", handler, options); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+
+	if attempts["ok"] != 1 || attempts["bad"] != 3 {
+		t.Errorf("Expected 1 and 3 attempts, got %v", attempts)
+	}
+	if len(q.acked) != 2 {
+		t.Errorf("Expected both messages to be acknowledged, got %v", q.acked)
+	}
+	if len(notified) != 1 || notified[0] != "results/bad: cannot handle message" {
+		t.Errorf("Expected the dead-lettered message to be notified, got %v", notified)
+	}
+
+	dead := q.sent[DeadLetterTopic("results")]
+	if len(dead) != 1 {
+		t.Fatalf("Expected 1 dead-lettered message, got %d", len(dead))
+	}
+	metadata := dead[0].Metadata
+	if metadata[MetadataDLQTopic] != "results" || metadata[MetadataDLQAttempts] != "3" || metadata[MetadataDLQError] != "cannot handle message" {
+		t.Errorf("Unexpected failure metadata: %v", metadata)
+	}
+	if _, err := time.Parse(time.RFC3339, metadata[MetadataDLQFailedAt]); err != nil {
+		t.Errorf("Expected failure time, got %q", metadata[MetadataDLQFailedAt])
+	}
+	if metadata["source"] != "test" {
+		t.Errorf("Expected original metadata to be kept, got %v", metadata)
+	}
+
+	revived := revive(dead[0])
+	if len(revived.Metadata) != 1 || revived.Metadata["source"] != "test" {
+		t.Errorf("Expected failure metadata to be removed on requeue, got %v", revived.Metadata)
+	}
+}
+
+func TestConsumeRecoversHandlerPanic(t *testing.T) {
+	q := newMemoryQueue(Message{ID: "panic"})
+
+	handler := func(ctx context.Context, message Message) error {
+		panic("boom")
+	}
+
+	if err := Consume(context.Background(), q, "results", handler, ConsumerOptions{}); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+	if len(q.sent[DeadLetterTopic("results")]) != 1 {
+		t.Errorf("Expected the panicking message to be dead-lettered")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	expected := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for attempt, delay := range expected {
+		if got := policy.Backoff(attempt); got != delay {
+			t.Errorf("Expected backoff %v before attempt %d, got %v", delay, attempt, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(3); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("Expected jittered backoff within 50%% of 200ms, got %v", got)
+		}
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, RateLimitedBackoff: time.Second}
+	throttled := fmt.Errorf("%w: too many requests", errclass.ErrRateLimited)
+
+	if got := policy.Delay(2, errors.New("boom")); got != 100*time.Millisecond {
+		t.Errorf("Expected the backoff after an unclassified failure, got %v", got)
+	}
+	if got := policy.Delay(2, throttled); got != time.Second {
+		t.Errorf("Expected the rate limited backoff after a throttled failure, got %v", got)
+	}
+	if got := policy.Delay(1, throttled); got != 0 {
+		t.Errorf("Expected no delay before the first attempt, got %v", got)
+	}
+}
+
+func TestConsumeStopsRetryingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := newMemoryQueue(Message{ID: "slow"})
+
+	handler := func(ctx context.Context, message Message) error {
+		cancel()
+		return errors.New("temporary failure")
+	}
+
+	options := ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}}
+	if err := Consume(ctx, q, "results", handler, options); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+	if len(q.acked) != 0 || len(q.sent) != 0 {
+		t.Errorf("Expected the message to be left for redelivery, got acked %v and sent %v", q.acked, q.sent)
+	}
+}
+
+func TestConsumeDeadLettersUnsupportedSchemaWithoutRetrying(t *testing.T) {
+	q := newMemoryQueue(
+		Message{ID: "current", Type: "scrape_result", SchemaVersion: 1},
+		Message{ID: "newer", Type: "scrape_result", SchemaVersion: 2},
+		Message{ID: "unknown", Type: "cycle_start"},
+	)
+
+	handled := 0
+	router := NewRouter()
+	router.Handle("scrape_result", 1, func(ctx context.Context, message Message) error {
+		handled++
+		return nil
+	})
+
+	options := ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute}}
+	if err := Consume(context.Background(), q, "results", router.Dispatch, options); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+
+	if handled != 1 {
+		t.Errorf("Expected only the current schema to be handled, got %d", handled)
+	}
+	dead := q.sent[DeadLetterTopic("results")]
+	if len(dead) != 2 {
+		t.Fatalf("Expected 2 dead-lettered messages, got %d", len(dead))
+	}
+	for _, message := range dead {
+		if message.Metadata[MetadataDLQAttempts] != "1" {
+			t.Errorf("Expected message %s to be dead-lettered after 1 attempt, got %v", message.ID, message.Metadata)
+		}
+		if message.Metadata[MetadataDLQErrorClass] != errclass.Parse {
+			t.Errorf("Expected message %s to be dead-lettered as %s, got %v", message.ID, errclass.Parse, message.Metadata)
+		}
+	}
+}
+
+func TestConsumeDeadLettersPermanentFailuresWithoutRetrying(t *testing.T) {
+	q := newMemoryQueue(
+		Message{ID: "malformed"},
+		Message{ID: "throttled"},
+	)
+
+	attempts := make(map[string]int)
+	handler := func(ctx context.Context, message Message) error {
+		attempts[message.ID]++
+		if message.ID == "malformed" {
+			return fmt.Errorf("%w: failed to parse result", errclass.ErrParse)
+		}
+		return fmt.Errorf("%w: too many requests", errclass.ErrRateLimited)
+	}
+
+	options := ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 3}}
+	if err := Consume(context.Background(), q, "results", handler, options); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+
+	if attempts["malformed"] != 1 || attempts["throttled"] != 3 {
+		t.Errorf("Expected only the retryable failure to be retried, got %v", attempts)
+	}
+	dead := q.sent[DeadLetterTopic("results")]
+	if len(dead) != 2 {
+		t.Fatalf("Expected 2 dead-lettered messages, got %d", len(dead))
+	}
+	classes := map[string]string{}
+	for _, message := range dead {
+		classes[message.ID] = message.Metadata[MetadataDLQErrorClass]
+	}
+	if classes["malformed"] != errclass.Parse || classes["throttled"] != errclass.RateLimited {
+		t.Errorf("Expected the error classes in the metadata, got %v", classes)
+	}
+}
+
+func TestConsumeHandlesMessagesConcurrently(t *testing.T) {
+	q := newMemoryQueue(Message{ID: "1"}, Message{ID: "2"}, Message{ID: "3"}, Message{ID: "4"}, Message{ID: "5"})
+
+	// Every handler waits until four messages are in progress at once
+	var started sync.WaitGroup
+	started.Add(4)
+	all := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(all)
+	}()
+
+	var mu sync.Mutex
+	handled := 0
+	handler := func(ctx context.Context, message Message) error {
+		mu.Lock()
+		handled++
+		first := handled <= 4
+		mu.Unlock()
+		if first {
+			started.Done()
+		}
+
+		select {
+		case <-all:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("messages were not handled concurrently")
+		}
+	}
+
+	if err := Consume(context.Background(), q, "results", handler, ConsumerOptions{Concurrency: 4}); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+	if len(q.acked) != 5 {
+		t.Errorf("Expected every message to be acknowledged, got %v", q.acked)
+	}
+	if dead := q.sent[DeadLetterTopic("results")]; len(dead) != 0 {
+		t.Errorf("Expected no dead-lettered messages, got %d", len(dead))
+	}
+}
+
+func TestConsumePatternSettlesOnReceivedTopic(t *testing.T) {
+	q := newMemoryQueue(
+		Message{ID: "valid", Topic: "scraper_results.snb"},
+		Message{ID: "invalid", Topic: "scraper_results.fed"},
+	)
+
+	attempts := 0
+	handler := func(ctx context.Context, message Message) error {
+		if message.ID == "invalid" {
+			attempts++
+			return fmt.Errorf("%w: missing source", ErrInvalidMessage)
+		}
+		return nil
+	}
+
+	options := ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute}}
+	if err := ConsumePattern(context.Background(), q, "scraper_results.*", handler, options); err != nil {
+		t.Fatalf("ConsumePattern returned an error: %v", err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("Expected the invalid message to be handled once, got %d attempts", attempts)
+	}
+	if len(q.acked) != 2 {
+		t.Errorf("Expected both messages to be acknowledged, got %v", q.acked)
+	}
+	if dead := q.sent[DeadLetterTopic("scraper_results.fed")]; len(dead) != 1 {
+		t.Errorf("Expected the invalid message on the dead-letter queue of its topic, got %v", q.sent)
+	}
+}
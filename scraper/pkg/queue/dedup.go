@@ -0,0 +1,152 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultDedupTTL is how long processed message IDs are remembered by default
+const DefaultDedupTTL = 24 * time.Hour
+
+// DefaultDedupLease is how long a message ID is reserved while its message is handled, a consumer
+// crashing meanwhile leaves the lease to expire so the redelivered message is handled again
+const DefaultDedupLease = time.Minute
+
+// ErrMessageInProgress is returned by DedupStore.Claim when another consumer holds the lease of a message
+var ErrMessageInProgress = errors.New("message is being handled")
+
+// DedupStore remembers the IDs of messages being handled and of processed messages
+type DedupStore interface {
+	// Claim takes a processing lease on a message ID for lease, returning false when the message
+	// was already processed and ErrMessageInProgress when another lease is held
+	Claim(ctx context.Context, id string, lease time.Duration) (bool, error)
+	// Complete marks a claimed message ID as processed for ttl
+	Complete(ctx context.Context, id string, ttl time.Duration) error
+	// Release forgets a message ID so the message can be processed again
+	Release(ctx context.Context, id string) error
+}
+
+// Deduplicate wraps a handler so messages whose ID was handled successfully within ttl are
+// skipped, turning the at-least-once delivery of durable backends into effectively-once handling.
+// A message is only remembered as processed once the handler succeeded, while it runs its ID is
+// held by a short lease so concurrent deliveries are retried instead of acknowledged
+func Deduplicate(store DedupStore, ttl time.Duration, handler Handler) Handler {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+
+	return func(ctx context.Context, message Message) error {
+		// Messages without an ID cannot be recognized again
+		if message.ID == "" {
+			return handler(ctx, message)
+		}
+
+		claimed, err := store.Claim(ctx, message.ID, DefaultDedupLease)
+		if errors.Is(err, ErrMessageInProgress) {
+			return fmt.Errorf("%w: %w", errclass.ErrTransient, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim message %s: %w", message.ID, err)
+		}
+		if !claimed {
+			slog.DebugContext(ctx, "Skipping duplicate message", "topic", message.Topic, "messageID", message.ID)
+			return nil
+		}
+
+		if err := safeHandle(ctx, handler, message); err != nil {
+			// Forget the failed attempt so a retry or redelivery is handled again
+			if releaseErr := store.Release(ctx, message.ID); releaseErr != nil {
+				slog.WarnContext(ctx, "Failed to release message claim", "messageID", message.ID, "error", releaseErr)
+			}
+			return err
+		}
+		// The message was handled, failing to remember it only risks handling a redelivery again
+		if err := store.Complete(ctx, message.ID, ttl); err != nil {
+			slog.WarnContext(ctx, "Failed to mark message as processed", "messageID", message.ID, "error", err)
+		}
+		return nil
+	}
+}
+
+// RedisDedupStore keeps processed message IDs as Redis keys expiring after their TTL
+type RedisDedupStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisDedupStore creates a deduplication store keeping its keys under the given prefix,
+// consumers handling the same topics should share a prefix
+func NewRedisDedupStore(client redis.Cmdable, prefix string) *RedisDedupStore {
+	return &RedisDedupStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Values of the keys of RedisDedupStore
+const (
+	dedupProcessing = "processing"
+	dedupDone       = "done"
+)
+
+// Claim takes the lease of a message ID unless the ID is leased or was processed
+func (s *RedisDedupStore) Claim(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	claimed, err := s.client.SetNX(ctx, s.key(id), dedupProcessing, lease).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lease message ID: %w", err)
+	}
+	if claimed {
+		return true, nil
+	}
+
+	state, err := s.client.Get(ctx, s.key(id)).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		// The lease expired meanwhile, the next attempt takes it
+		return false, ErrMessageInProgress
+	case err != nil:
+		return false, fmt.Errorf("failed to read message ID: %w", err)
+	case state == dedupDone:
+		return false, nil
+	default:
+		return false, ErrMessageInProgress
+	}
+}
+
+// Complete replaces the lease of a message ID with its processed marker
+func (s *RedisDedupStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.key(id), dedupDone, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to record message ID: %w", err)
+	}
+	return nil
+}
+
+// Release forgets a message ID
+func (s *RedisDedupStore) Release(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, s.key(id)).Err(); err != nil {
+		return fmt.Errorf("failed to forget message ID: %w", err)
+	}
+	return nil
+}
+
+// key returns the Redis key of a message ID
+func (s *RedisDedupStore) key(id string) string {
+	return s.prefix + ":" + id
+}
+
+// DedupStore returns a deduplication store sharing the connection of the queue
+func (q *RedisQueue) DedupStore(prefix string) *RedisDedupStore {
+	return NewRedisDedupStore(q.client, prefix)
+}
+
+// DedupStore returns a deduplication store sharing the connection of the queue
+func (q *RedisStreamsQueue) DedupStore(prefix string) *RedisDedupStore {
+	return NewRedisDedupStore(q.client, prefix)
+}
@@ -0,0 +1,119 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// memoryDedupStore is an in-memory DedupStore ignoring TTLs, it maps IDs to whether they are done
+type memoryDedupStore struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func (s *memoryDedupStore) Claim(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	done, ok := s.ids[id]
+	if !ok {
+		s.ids[id] = false
+		return true, nil
+	}
+	if !done {
+		return false, ErrMessageInProgress
+	}
+	return false, nil
+}
+
+func (s *memoryDedupStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[id] = true
+	return nil
+}
+
+func (s *memoryDedupStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+	return nil
+}
+
+func TestDeduplicateSkipsProcessedMessages(t *testing.T) {
+	q := newMemoryQueue(
+		Message{ID: "a"},
+		Message{ID: "b"},
+		Message{ID: "a"},
+		Message{ID: "flaky"},
+		Message{ID: "b"},
+	)
+	store := &memoryDedupStore{ids: make(map[string]bool)}
+
+	handled := make(map[string]int)
+	failed := false
+	handler := func(ctx context.Context, message Message) error {
+		handled[message.ID]++
+		if message.ID == "flaky" && !failed {
+			failed = true
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+
+	options := ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}}
+	if err := Consume(context.Background(), q, "results", Deduplicate(store, time.Hour, handler), options); err != nil {
+		t.Fatalf("Consume returned an error: %v", err)
+	}
+
+	if handled["a"] != 1 || handled["b"] != 1 {
+		t.Errorf("Expected duplicates to be handled once, got %v", handled)
+	}
+	if handled["flaky"] != 2 {
+		t.Errorf("Expected the failed attempt to be released and retried, got %d attempts", handled["flaky"])
+	}
+	if len(q.acked) != 5 {
+		t.Errorf("Expected duplicates to be acknowledged, got %v", q.acked)
+	}
+	if len(q.sent[DeadLetterTopic("results")]) != 0 {
+		t.Errorf("Expected no dead-lettered messages")
+	}
+}
+
+func TestDeduplicateRetriesMessagesInProgress(t *testing.T) {
+	store := &memoryDedupStore{ids: make(map[string]bool)}
+	handled := 0
+	handler := Deduplicate(store, time.Hour, func(ctx context.Context, message Message) error {
+		handled++
+		return nil
+	})
+
+	// A consumer that crashed while handling the message left its lease behind
+	if claimed, err := store.Claim(context.Background(), "a", DefaultDedupLease); err != nil || !claimed {
+		t.Fatalf("Expected the lease to be taken, got %v, %v", claimed, err)
+	}
+	err := handler(context.Background(), Message{ID: "a"})
+	if !errors.Is(err, ErrMessageInProgress) || errclass.Of(err) != errclass.Transient {
+		t.Fatalf("Expected a retryable in-progress error, got %v", err)
+	}
+	if handled != 0 {
+		t.Errorf("Expected the leased message not to be handled, got %d", handled)
+	}
+
+	// Once the lease is gone the redelivery is handled and remembered
+	if err := store.Release(context.Background(), "a"); err != nil {
+		t.Fatalf("Failed to release lease: %v", err)
+	}
+	for range 2 {
+		if err := handler(context.Background(), Message{ID: "a"}); err != nil {
+			t.Fatalf("Failed to handle message: %v", err)
+		}
+	}
+	if handled != 1 || !store.ids["a"] {
+		t.Errorf("Expected the message to be handled once and marked done, got %d handled", handled)
+	}
+}
@@ -0,0 +1,306 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// DeliveryPolicy decides what happens to received messages while a subscription buffer is full
+type DeliveryPolicy string
+
+const (
+	// DeliveryBlock stops reading from the backend until the consumer catches up
+	DeliveryBlock DeliveryPolicy = "block"
+	// DeliveryDropOldest discards the oldest buffered message of the lowest priority to make room
+	DeliveryDropOldest DeliveryPolicy = "drop_oldest"
+	// DeliveryDropNewest discards the received message
+	DeliveryDropNewest DeliveryPolicy = "drop_newest"
+	// DeliveryBufferToDisk spills received messages to a temporary file until there is room again
+	DeliveryBufferToDisk DeliveryPolicy = "buffer_to_disk"
+)
+
+// subscribeOptions configures the delivery of a single subscription
+type subscribeOptions struct {
+	policy     DeliveryPolicy
+	bufferSize int
+	bufferDir  string
+	metrics    Metrics
+	// dropped is called with every message dropped while the buffer is full, nil when the backend
+	// has nothing to settle
+	dropped func(Message)
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*subscribeOptions)
+
+// WithDeliveryPolicy sets what happens to messages while the consumer is too slow, defaults to DeliveryBlock
+func WithDeliveryPolicy(policy DeliveryPolicy) SubscribeOption {
+	return func(o *subscribeOptions) { o.policy = policy }
+}
+
+// WithBufferSize sets how many messages are held in memory for the consumer, defaults to 100
+func WithBufferSize(size int) SubscribeOption {
+	return func(o *subscribeOptions) { o.bufferSize = size }
+}
+
+// WithBufferDir sets the directory used by DeliveryBufferToDisk, defaults to the system temp directory
+func WithBufferDir(dir string) SubscribeOption {
+	return func(o *subscribeOptions) { o.bufferDir = dir }
+}
+
+// withMetrics sets where the subscription reports its deliveries, defaults to the metrics of the queue
+func withMetrics(metrics Metrics) SubscribeOption {
+	return func(o *subscribeOptions) { o.metrics = metrics }
+}
+
+// withDropHandler sets the function called with the messages dropped for a slow consumer, so
+// backends settle them like acknowledged messages
+func withDropHandler(dropped func(Message)) SubscribeOption {
+	return func(o *subscribeOptions) { o.dropped = dropped }
+}
+
+// newSubscribeOptions applies options on top of the defaults
+func newSubscribeOptions(opts []SubscribeOption) (subscribeOptions, error) {
+	options := subscribeOptions{
+		policy:     DeliveryBlock,
+		bufferSize: subscriptionBuffer,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	switch options.policy {
+	case DeliveryBlock, DeliveryDropOldest, DeliveryDropNewest, DeliveryBufferToDisk:
+	default:
+		return subscribeOptions{}, fmt.Errorf("unsupported delivery policy %q", options.policy)
+	}
+	if options.bufferSize <= 0 {
+		return subscribeOptions{}, fmt.Errorf("buffer size must be positive")
+	}
+	return options, nil
+}
+
+// dispatch buffers the messages received from a backend and hands them to the consumer, highest
+// priority first unless ordered is set. The options decide what happens once the buffer is full.
+// The returned channel is closed after in is closed and every buffered message was delivered
+func dispatch(ctx context.Context, topic string, in <-chan Message, options subscribeOptions, ordered bool) (<-chan Message, error) {
+	var disk *diskBuffer
+	if options.policy == DeliveryBufferToDisk {
+		var err error
+		if disk, err = newDiskBuffer(options.bufferDir); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := options.metrics
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+	// Pattern subscriptions report messages under the topic they were received from
+	topicOf := func(message Message) string {
+		if message.Topic != "" {
+			return message.Topic
+		}
+		return topic
+	}
+
+	dropped := func(message Message) {
+		if options.dropped != nil {
+			options.dropped(message)
+		}
+	}
+
+	out := make(chan Message)
+
+	go func() {
+		defer close(out)
+		// Whatever is left in a closed subscription is gone
+		defer metrics.BufferOccupancy(topic, 0)
+		if disk != nil {
+			defer disk.close()
+		}
+
+		pending := messageHeap{ordered: ordered}
+		var seq uint64
+		push := func(message Message) {
+			heap.Push(&pending, pendingMessage{message: message, seq: seq})
+			seq++
+		}
+
+		for in != nil || pending.Len() > 0 || disk.len() > 0 {
+			// Spilled messages are loaded back in arrival order as soon as there is room
+			for disk.len() > 0 && pending.Len() < options.bufferSize {
+				message, err := disk.pop()
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to read buffered message from disk", "topic", topic, "error", err)
+					metrics.MessageDropped(topic, DropBufferFailure)
+					continue
+				}
+				push(message)
+			}
+			metrics.BufferOccupancy(topic, pending.Len()+disk.len())
+
+			// Only block the backend when the policy asks for it, a nil channel blocks forever
+			receive := in
+			if options.policy == DeliveryBlock && pending.Len() >= options.bufferSize {
+				receive = nil
+			}
+			// Only offer a message to the consumer when there is one
+			var send chan<- Message
+			var next Message
+			if pending.Len() > 0 {
+				send = out
+				next = pending.items[0].message
+			}
+
+			select {
+			case message, ok := <-receive:
+				if !ok {
+					in = nil
+					continue
+				}
+				if pending.Len() < options.bufferSize && disk.len() == 0 {
+					push(message)
+					continue
+				}
+
+				switch options.policy {
+				case DeliveryDropNewest:
+					slog.WarnContext(ctx, "Dropped message for slow consumer", "topic", topic, "messageID", message.ID)
+					metrics.MessageDropped(topicOf(message), DropSlowConsumer)
+					dropped(message)
+				case DeliveryDropOldest:
+					oldest := heap.Remove(&pending, pending.oldestLeastUrgent()).(pendingMessage)
+					slog.WarnContext(ctx, "Dropped message for slow consumer", "topic", topic, "messageID", oldest.message.ID)
+					metrics.MessageDropped(topicOf(oldest.message), DropSlowConsumer)
+					dropped(oldest.message)
+					push(message)
+				case DeliveryBufferToDisk:
+					if err := disk.push(message); err != nil {
+						slog.ErrorContext(ctx, "Failed to buffer message on disk, dropping it", "topic", topic, "messageID", message.ID, "error", err)
+						metrics.MessageDropped(topicOf(message), DropBufferFailure)
+						dropped(message)
+					}
+				}
+			case send <- next:
+				heap.Pop(&pending)
+				metrics.MessageConsumed(topicOf(next))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// spilledMessage is the disk format of a buffered message, it keeps the fields the wire format omits
+type spilledMessage struct {
+	Message
+	Topic string
+	Group string
+	AckID string
+}
+
+// diskBuffer is a FIFO of length prefixed messages in a temporary file
+type diskBuffer struct {
+	file   *os.File
+	read   int64
+	write  int64
+	length int
+}
+
+// newDiskBuffer creates an empty buffer file in dir
+func newDiskBuffer(dir string) (*diskBuffer, error) {
+	file, err := os.CreateTemp(dir, "queue-buffer-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create disk buffer: %w", err)
+	}
+	return &diskBuffer{file: file}, nil
+}
+
+// len returns the number of buffered messages, a nil buffer is empty
+func (b *diskBuffer) len() int {
+	if b == nil {
+		return 0
+	}
+	return b.length
+}
+
+// push appends a message to the end of the buffer
+func (b *diskBuffer) push(message Message) error {
+	data, err := json.Marshal(spilledMessage{Message: message, Topic: message.Topic, Group: message.Group, AckID: message.AckID})
+	if err != nil {
+		return err
+	}
+
+	record := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	record = append(record, data...)
+	if _, err := b.file.WriteAt(record, b.write); err != nil {
+		return err
+	}
+	b.write += int64(len(record))
+	b.length++
+	return nil
+}
+
+// pop removes the message at the start of the buffer
+func (b *diskBuffer) pop() (Message, error) {
+	data, err := b.next()
+	if err != nil {
+		// The rest of the file cannot be framed anymore
+		b.length = 0
+	}
+	if b.length == 0 {
+		b.reset()
+	}
+	if err != nil {
+		return Message{}, err
+	}
+
+	var spilled spilledMessage
+	if err := json.Unmarshal(data, &spilled); err != nil {
+		return Message{}, err
+	}
+	message := spilled.Message
+	message.Topic = spilled.Topic
+	message.Group = spilled.Group
+	message.AckID = spilled.AckID
+	return message, nil
+}
+
+// next reads the record at the start of the buffer
+func (b *diskBuffer) next() ([]byte, error) {
+	var size [4]byte
+	if _, err := b.file.ReadAt(size[:], b.read); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := b.file.ReadAt(data, b.read+4); err != nil && err != io.EOF {
+		return nil, err
+	}
+	b.read += 4 + int64(len(data))
+	b.length--
+	return data, nil
+}
+
+// reset truncates the drained buffer file so it does not grow forever
+func (b *diskBuffer) reset() {
+	b.read, b.write = 0, 0
+	if err := b.file.Truncate(0); err != nil {
+		slog.ErrorContext(context.Background(), "Failed to truncate disk buffer", "error", err)
+	}
+}
+
+// close removes the buffer file, messages still in it are lost
+func (b *diskBuffer) close() {
+	b.file.Close()
+	os.Remove(b.file.Name())
+}
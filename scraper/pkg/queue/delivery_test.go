@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestDispatch starts a delivery buffer reading from the returned channel
+func newTestDispatch(t *testing.T, opts ...SubscribeOption) (chan<- Message, <-chan Message) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		t.Fatalf("Invalid subscribe options: %v", err)
+	}
+
+	in := make(chan Message)
+	out, err := dispatch(ctx, "test", in, options, false)
+	if err != nil {
+		t.Fatalf("Failed to start delivery buffer: %v", err)
+	}
+	return in, out
+}
+
+// drain collects the IDs of every delivered message
+func drain(out <-chan Message) []string {
+	var ids []string
+	for message := range out {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func assertOrder(t *testing.T, expected, got []string) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestDispatchDeliversHigherPrioritiesFirst(t *testing.T) {
+	in, out := newTestDispatch(t, WithBufferSize(10))
+
+	// The consumer is busy while these arrive, so all of them are buffered
+	for _, message := range []Message{
+		{ID: "bulk-1", Priority: PriorityLow},
+		{ID: "result-1"},
+		{ID: "bulk-2", Priority: PriorityLow},
+		{ID: "alert", Priority: PriorityHigh},
+		{ID: "result-2"},
+	} {
+		in <- message
+	}
+	close(in)
+
+	assertOrder(t, []string{"alert", "result-1", "result-2", "bulk-1", "bulk-2"}, drain(out))
+}
+
+func TestDispatchBlocksWhenFull(t *testing.T) {
+	in, out := newTestDispatch(t, WithBufferSize(2))
+
+	in <- Message{ID: "1"}
+	in <- Message{ID: "2"}
+	select {
+	case in <- Message{ID: "3"}:
+		t.Fatal("Expected a full buffer not to accept more messages")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-out
+	select {
+	case in <- Message{ID: "3"}:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the buffer to accept a message once the consumer read one")
+	}
+}
+
+func TestDispatchDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy   DeliveryPolicy
+		expected []string
+	}{
+		{DeliveryDropNewest, []string{"urgent", "1", "2"}},
+		{DeliveryDropOldest, []string{"urgent", "3", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			in, out := newTestDispatch(t, WithDeliveryPolicy(tt.policy), WithBufferSize(3))
+
+			// A full buffer never blocks the backend with a drop policy
+			for _, message := range []Message{{ID: "urgent", Priority: PriorityHigh}, {ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}} {
+				select {
+				case in <- message:
+				case <-time.After(time.Second):
+					t.Fatalf("Expected message %s to be accepted", message.ID)
+				}
+			}
+			close(in)
+
+			assertOrder(t, tt.expected, drain(out))
+		})
+	}
+}
+
+func TestDispatchBuffersToDisk(t *testing.T) {
+	dir := t.TempDir()
+	in, out := newTestDispatch(t, WithDeliveryPolicy(DeliveryBufferToDisk), WithBufferSize(2), WithBufferDir(dir))
+
+	var expected []string
+	for i := 0; i < 50; i++ {
+		id := strconv.Itoa(i)
+		in <- Message{ID: id, Body: []byte("body " + id), AckID: "ack-" + id}
+		expected = append(expected, id)
+	}
+	close(in)
+
+	var got []string
+	for message := range out {
+		if message.AckID != "ack-"+message.ID || string(message.Body) != "body "+message.ID {
+			t.Errorf("Expected message %s to survive the disk buffer, got %+v", message.ID, message)
+		}
+		got = append(got, message.ID)
+	}
+	assertOrder(t, expected, got)
+}
+
+func TestSubscribeOptionsValidation(t *testing.T) {
+	if _, err := newSubscribeOptions([]SubscribeOption{WithDeliveryPolicy("discard")}); err == nil {
+		t.Error("Expected an error for an unsupported delivery policy")
+	}
+	if _, err := newSubscribeOptions([]SubscribeOption{WithBufferSize(0)}); err == nil {
+		t.Error("Expected an error for an empty buffer")
+	}
+}
@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// Metadata keys describing why a message was dead-lettered
+const (
+	MetadataDLQTopic    = "dlq_topic"
+	MetadataDLQError    = "dlq_error"
+	MetadataDLQAttempts = "dlq_attempts"
+	MetadataDLQFailedAt = "dlq_failed_at"
+	// MetadataDLQErrorClass is the errclass class of the error, e.g. "parse"
+	MetadataDLQErrorClass = "dlq_error_class"
+)
+
+// DeadLetterQueue is implemented by queues that can store, list and requeue dead-lettered messages
+type DeadLetterQueue interface {
+	// AddDeadLetter stores a message that could not be handled on the dead-letter queue of topic
+	AddDeadLetter(ctx context.Context, topic string, message Message) error
+	// DeadLetters returns up to limit dead-lettered messages of topic, oldest first
+	DeadLetters(ctx context.Context, topic string, limit int) ([]Message, error)
+	// Requeue removes a message from the dead-letter queue of topic and sends it to topic again
+	Requeue(ctx context.Context, topic string, messageID string) error
+}
+
+// DeadLetterTopic returns the dead-letter topic of a topic
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// MoveToDeadLetter stores a message with its failure details on the dead-letter queue of topic.
+// Queues that do not implement DeadLetterQueue get the message published to the dead-letter topic
+func MoveToDeadLetter(ctx context.Context, q Queue, topic string, message Message, cause error, attempts int) error {
+	slog.WarnContext(ctx, "Attempt to move message to dead-letter queue", "topic", topic, "messageID", message.ID, "attempts", attempts)
+
+	dead := message
+	dead.AckID = ""
+	dead.Metadata = maps.Clone(message.Metadata)
+	if dead.Metadata == nil {
+		dead.Metadata = make(map[string]string)
+	}
+	dead.Metadata[MetadataDLQTopic] = topic
+	dead.Metadata[MetadataDLQAttempts] = strconv.Itoa(attempts)
+	dead.Metadata[MetadataDLQFailedAt] = time.Now().UTC().Format(time.RFC3339)
+	if cause != nil {
+		dead.Metadata[MetadataDLQError] = cause.Error()
+		dead.Metadata[MetadataDLQErrorClass] = errclass.Of(cause)
+	}
+
+	var err error
+	if dlq, ok := q.(DeadLetterQueue); ok {
+		err = dlq.AddDeadLetter(ctx, topic, dead)
+	} else {
+		err = q.Send(ctx, DeadLetterTopic(topic), dead)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move message to dead-letter queue: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully moved message to dead-letter queue", "topic", topic, "messageID", message.ID)
+	return nil
+}
+
+// revive strips the failure details from a dead-lettered message before it is requeued
+func revive(message Message) Message {
+	message.AckID = ""
+	message.Metadata = maps.Clone(message.Metadata)
+	for _, key := range []string{MetadataDLQTopic, MetadataDLQError, MetadataDLQErrorClass, MetadataDLQAttempts, MetadataDLQFailedAt} {
+		delete(message.Metadata, key)
+	}
+	return message
+}
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// EnvelopeVersion is the version of the wire format written by EnvelopeCodec
+const EnvelopeVersion = 1
+
+// MetadataType is the metadata key repeating Message.Type, consumers that predate the type field
+// filter on it
+const MetadataType = "type"
+
+// ErrUnsupportedSchema is returned for messages with a newer schema than the consumer understands,
+// it is classified as errclass.ErrParse
+var ErrUnsupportedSchema = fmt.Errorf("%w: unsupported schema version", errclass.ErrParse)
+
+// Codec converts messages to and from their wire format
+type Codec interface {
+	Encode(message Message) ([]byte, error)
+	Decode(data []byte) (Message, error)
+}
+
+// envelope is the JSON and MessagePack wire format of a message. JSON field names are matched
+// case-insensitively, so messages written before the envelope existed decode as envelope version 0
+type envelope struct {
+	Version       int               `json:"envelope_version"`
+	ID            string            `json:"id"`
+	Type          string            `json:"type,omitempty"`
+	SchemaVersion int               `json:"schema_version,omitempty"`
+	Priority      int               `json:"priority,omitempty"`
+	Timestamp     time.Time         `json:"timestamp"`
+	TTLMillis     int64             `json:"ttl_ms,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Body          []byte            `json:"body"`
+}
+
+// newEnvelope wraps a message in an envelope of the current version
+func newEnvelope(message Message) envelope {
+	return envelope{
+		Version:       EnvelopeVersion,
+		ID:            message.ID,
+		Type:          message.Type,
+		SchemaVersion: message.SchemaVersion,
+		Priority:      message.Priority,
+		Timestamp:     message.Timestamp,
+		TTLMillis:     message.TTL.Milliseconds(),
+		Metadata:      message.Metadata,
+		Body:          message.Body,
+	}
+}
+
+// message unwraps an envelope, failing with ErrUnsupportedSchema if it was written by a newer envelope version
+func (e envelope) message() (Message, error) {
+	message := Message{
+		ID:            e.ID,
+		Type:          e.Type,
+		SchemaVersion: e.SchemaVersion,
+		Priority:      e.Priority,
+		Timestamp:     e.Timestamp,
+		TTL:           time.Duration(e.TTLMillis) * time.Millisecond,
+		Metadata:      e.Metadata,
+		Body:          e.Body,
+	}
+	if e.Version > EnvelopeVersion {
+		return message, fmt.Errorf("%w: envelope version %d", ErrUnsupportedSchema, e.Version)
+	}
+	return message, nil
+}
+
+// EnvelopeCodec encodes messages as versioned JSON envelopes
+type EnvelopeCodec struct{}
+
+func (EnvelopeCodec) Encode(message Message) ([]byte, error) {
+	data, err := json.Marshal(newEnvelope(message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return data, nil
+}
+
+// Decode decodes an envelope, failing with ErrUnsupportedSchema if it was written by a newer envelope version
+func (EnvelopeCodec) Decode(data []byte) (Message, error) {
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Message{}, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return e.message()
+}
+
+// defaultCodec is used by queue backends without a configured codec
+var defaultCodec Codec = negotiatingCodec{encoder: EnvelopeCodec{}}
+
+// ErrUnknownType is returned by a Router for messages without a registered handler, it is
+// classified as errclass.ErrParse
+var ErrUnknownType = fmt.Errorf("%w: unknown message type", errclass.ErrParse)
+
+// route is a handler registered for a message type
+type route struct {
+	maxSchemaVersion int
+	handler          Handler
+}
+
+// Router dispatches messages to handlers by their type and rejects schemas newer than the handler supports
+type Router struct {
+	routes map[string]route
+}
+
+// NewRouter creates an empty message router
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]route)}
+}
+
+// Handle registers a handler for a message type understanding schemas up to maxSchemaVersion
+func (r *Router) Handle(messageType string, maxSchemaVersion int, handler Handler) {
+	r.routes[messageType] = route{maxSchemaVersion: maxSchemaVersion, handler: handler}
+}
+
+// Dispatch passes a message to the handler registered for its type, it can be used as the Handler of Consume
+func (r *Router) Dispatch(ctx context.Context, message Message) error {
+	route, ok := r.routes[message.Type]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, message.Type)
+	}
+	if message.SchemaVersion > route.maxSchemaVersion {
+		return fmt.Errorf("%w: %s version %d, supported up to %d", ErrUnsupportedSchema, message.Type, message.SchemaVersion, route.maxSchemaVersion)
+	}
+	return route.handler(ctx, message)
+}
@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvelopeCodecRoundTrip(t *testing.T) {
+	message := Message{
+		ID:            "id-1",
+		Type:          "scrape_result",
+		SchemaVersion: 3,
+		Priority:      PriorityHigh,
+		TTL:           90 * time.Second,
+		Body:          []byte(`{"source":"fx_rates"}`),
+		Timestamp:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Metadata:      map[string]string{"source": "fx_rates"},
+		AckID:         "1-0",
+	}
+
+	codec := EnvelopeCodec{}
+	data, err := codec.Encode(message)
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	decoded, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+
+	if decoded.ID != message.ID || decoded.Type != message.Type || decoded.SchemaVersion != message.SchemaVersion || decoded.Priority != message.Priority || decoded.TTL != message.TTL {
+		t.Errorf("Expected %+v, got %+v", message, decoded)
+	}
+	if string(decoded.Body) != string(message.Body) || !decoded.Timestamp.Equal(message.Timestamp) || decoded.Metadata["source"] != "fx_rates" {
+		t.Errorf("Expected %+v, got %+v", message, decoded)
+	}
+	if decoded.AckID != "" {
+		t.Errorf("Expected the AckID not to be encoded, got %q", decoded.AckID)
+	}
+}
+
+func TestEnvelopeCodecDecodesLegacyMessages(t *testing.T) {
+	// Messages written before the envelope existed used the Go field names
+	legacy := `{"ID":"id-1","Body":"aGVsbG8=","Timestamp":"2024-05-01T12:00:00Z","Metadata":{"type":"cycle_start"}}`
+
+	decoded, err := EnvelopeCodec{}.Decode([]byte(legacy))
+	if err != nil {
+		t.Fatalf("Failed to decode legacy message: %v", err)
+	}
+	if decoded.ID != "id-1" || string(decoded.Body) != "hello" || decoded.Metadata["type"] != "cycle_start" {
+		t.Errorf("Unexpected legacy message %+v", decoded)
+	}
+}
+
+func TestEnvelopeCodecRejectsNewerEnvelopes(t *testing.T) {
+	_, err := EnvelopeCodec{}.Decode([]byte(`{"envelope_version":99,"id":"id-1"}`))
+	if !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("Expected ErrUnsupportedSchema, got %v", err)
+	}
+}
+
+func TestCodecsNegotiateFormat(t *testing.T) {
+	message := Message{
+		ID:            "id-1",
+		Type:          "scrape_result",
+		SchemaVersion: 1,
+		Priority:      PriorityLow,
+		TTL:           5 * time.Minute,
+		Body:          []byte{0x0a, 0x00, 0xff},
+		Timestamp:     time.Date(2024, 5, 1, 12, 0, 0, 123, time.UTC),
+		Metadata:      map[string]string{"source": "fx_rates", "content_type": ContentTypeProtobuf},
+	}
+
+	jsonCodec, err := NewCodec("json", Compression{})
+	if err != nil {
+		t.Fatalf("Failed to create JSON codec: %v", err)
+	}
+	protobufCodec, err := NewCodec("protobuf", Compression{})
+	if err != nil {
+		t.Fatalf("Failed to create protobuf codec: %v", err)
+	}
+	msgpackCodec, err := NewCodec("msgpack", Compression{})
+	if err != nil {
+		t.Fatalf("Failed to create msgpack codec: %v", err)
+	}
+	codecs := []Codec{jsonCodec, protobufCodec, msgpackCodec}
+
+	for _, producer := range codecs {
+		data, err := producer.Encode(message)
+		if err != nil {
+			t.Fatalf("Failed to encode message: %v", err)
+		}
+
+		// Consumers decode every format regardless of their own codec
+		for _, consumer := range codecs {
+			decoded, err := consumer.Decode(data)
+			if err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+			if decoded.ID != message.ID || decoded.Type != message.Type || decoded.SchemaVersion != message.SchemaVersion ||
+				decoded.Priority != message.Priority || decoded.TTL != message.TTL || string(decoded.Body) != string(message.Body) || !decoded.Timestamp.Equal(message.Timestamp) ||
+				len(decoded.Metadata) != 2 || decoded.Metadata["source"] != "fx_rates" {
+				t.Errorf("Expected %+v, got %+v", message, decoded)
+			}
+		}
+	}
+
+	if _, err := NewCodec("xml", Compression{}); err == nil {
+		t.Error("Expected an error for an unsupported codec")
+	}
+}
+
+func TestMessageExpired(t *testing.T) {
+	sent := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		message  Message
+		expected bool
+	}{
+		{Message{Timestamp: sent}, false},
+		{Message{Timestamp: sent, TTL: time.Hour}, false},
+		{Message{Timestamp: sent, TTL: time.Minute}, true},
+		{Message{TTL: time.Minute}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.message.Expired(sent.Add(10 * time.Minute)); got != tt.expected {
+			t.Errorf("Expected Expired to be %v for TTL %v, got %v", tt.expected, tt.message.TTL, got)
+		}
+	}
+}
+
+func TestMsgpackCodecRejectsNewerEnvelopes(t *testing.T) {
+	data, err := MsgpackCodec{}.Encode(Message{ID: "id-1"})
+	if err != nil {
+		t.Fatalf("Failed to encode message: %v", err)
+	}
+	// The envelope version is the first field of the map, written as a positive fixint
+	newer := bytes.Replace(data, append([]byte("envelope_version"), EnvelopeVersion), append([]byte("envelope_version"), EnvelopeVersion+1), 1)
+
+	if _, err := (MsgpackCodec{}).Decode(newer); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("Expected ErrUnsupportedSchema, got %v", err)
+	}
+}
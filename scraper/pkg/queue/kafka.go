@@ -0,0 +1,730 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaOptions configures the consumer group and topic creation of a KafkaQueue
+type KafkaOptions struct {
+	// GroupID is the consumer group shared by all instances consuming a topic
+	GroupID string
+	// Partitions is the number of partitions of automatically created topics
+	Partitions int
+	// ReplicationFactor is the replication factor of automatically created topics
+	ReplicationFactor int
+	// PartitionKey is the metadata entry used as message key, messages with the same key keep their order
+	PartitionKey string
+	// Codec encodes messages on the wire, defaults to JSON envelopes
+	Codec Codec
+	// Metrics observes published, consumed and dropped messages, defaults to discarding them
+	Metrics Metrics
+}
+
+type KafkaQueue struct {
+	brokers []string
+	options KafkaOptions
+	writer  *kafka.Writer
+
+	mu      sync.Mutex
+	topics  map[string]bool
+	readers map[string]*kafka.Reader
+	// offsets orders the commits of every reader
+	offsets map[*kafka.Reader]*offsetTracker
+	// patterns cancels the pattern subscriptions by pattern, their reader changes with the topics
+	patterns map[string]context.CancelFunc
+}
+
+func NewKafkaQueue(ctx context.Context, brokers []string, options KafkaOptions) (*KafkaQueue, error) {
+	slog.InfoContext(ctx, "Attempt to create new Kafka queue", "brokers", brokers, "group", options.GroupID)
+
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+	if options.GroupID == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	if options.Partitions <= 0 {
+		options.Partitions = 1
+	}
+	if options.ReplicationFactor <= 0 {
+		options.ReplicationFactor = 1
+	}
+	if options.Codec == nil {
+		options.Codec = defaultCodec
+	}
+	if options.Metrics == nil {
+		options.Metrics = nopMetrics{}
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Brokers(); err != nil {
+		return nil, fmt.Errorf("failed to fetch kafka brokers: %w", err)
+	}
+
+	queue := &KafkaQueue{
+		brokers: brokers,
+		options: options,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+			WriteTimeout: 10 * time.Second,
+		},
+		topics:   make(map[string]bool),
+		readers:  make(map[string]*kafka.Reader),
+		offsets:  make(map[*kafka.Reader]*offsetTracker),
+		patterns: make(map[string]context.CancelFunc),
+	}
+
+	slog.InfoContext(ctx, "Successfully created new Kafka queue", "brokers", brokers)
+	return queue, nil
+}
+
+func (q *KafkaQueue) Send(ctx context.Context, topic string, message Message) error {
+	slog.InfoContext(ctx, "Attempt to send message", "topic", topic, "messageID", message.ID)
+
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	if err := q.ensureTopic(ctx, topic); err != nil {
+		return err
+	}
+
+	data, err := q.options.Codec.Encode(message)
+	if err != nil {
+		return err
+	}
+
+	record := kafka.Message{
+		Topic: topic,
+		Value: data,
+		Time:  message.Timestamp,
+	}
+	// Messages without a key are spread over all partitions
+	if key := message.Metadata[q.options.PartitionKey]; key != "" {
+		record.Key = []byte(key)
+	}
+
+	start := time.Now()
+	err = q.writer.WriteMessages(ctx, record)
+	q.options.Metrics.MessagePublished(topic, time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully sent message", "topic", topic, "messageID", message.ID)
+	return nil
+}
+
+// ensureTopic creates a topic once per queue, topics that already exist are left untouched
+func (q *KafkaQueue) ensureTopic(ctx context.Context, topic string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.topics[topic] {
+		return nil
+	}
+
+	conn, err := kafka.DialContext(ctx, "tcp", q.brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	// Topics can only be created through the controller
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find kafka controller: %w", err)
+	}
+	controllerConn, err := kafka.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	err = controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     q.options.Partitions,
+		ReplicationFactor: q.options.ReplicationFactor,
+	})
+	if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+		return fmt.Errorf("failed to create topic %s: %w", topic, err)
+	}
+
+	q.topics[topic] = true
+	return nil
+}
+
+func (q *KafkaQueue) Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to topic", "topic", topic, "group", q.options.GroupID)
+
+	return q.subscribeTopic(ctx, topic, topic, kafka.ReaderConfig{
+		Brokers: q.brokers,
+		GroupID: q.options.GroupID,
+		Topic:   topic,
+		// A new group starts at the oldest retained message so nothing sent before it is lost
+		StartOffset: kafka.FirstOffset,
+	}, opts)
+}
+
+// SubscribeGroup joins a consumer group on a topic, the partitions of the topic are balanced
+// over the members of the group. The consumer name is sent as client ID. Cancel the context to leave the group
+func (q *KafkaQueue) SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to topic", "topic", topic, "group", group, "consumer", consumerName)
+
+	if group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+
+	config := kafka.ReaderConfig{
+		Brokers:     q.brokers,
+		GroupID:     group,
+		Topic:       topic,
+		StartOffset: kafka.FirstOffset,
+	}
+	if consumerName != "" {
+		config.Dialer = &kafka.Dialer{
+			ClientID:  consumerName,
+			Timeout:   10 * time.Second,
+			DualStack: true,
+		}
+	}
+	return q.subscribeTopic(ctx, group+"/"+topic, topic, config, opts)
+}
+
+// subscribeTopic starts a reader of a single topic registered under name
+func (q *KafkaQueue) subscribeTopic(ctx context.Context, name, topic string, config kafka.ReaderConfig, opts []SubscribeOption) (<-chan Message, error) {
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.ensureTopic(ctx, topic); err != nil {
+		return nil, err
+	}
+
+	delivered, err := q.subscribe(ctx, name, config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully subscribed to topic", "topic", topic, "group", config.GroupID)
+	return delivered, nil
+}
+
+// SubscribePattern delivers the messages of every topic matching a glob pattern. Kafka readers
+// have a fixed topic list, so the matching topics are listed again every patternRefresh and the
+// reader restarted when they changed. No topic has to match yet, e.g. before anything was sent
+func (q *KafkaQueue) SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to pattern", "pattern", pattern, "group", q.options.GroupID)
+
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := q.matchTopics(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q.mu.Lock()
+	if _, ok := q.patterns[pattern]; ok {
+		q.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("already subscribed to %s", pattern)
+	}
+	q.patterns[pattern] = cancel
+	q.mu.Unlock()
+
+	msgChan := make(chan Message)
+	delivered, err := dispatch(ctx, pattern, msgChan, q.readerOptions(options, q.options.GroupID), true)
+	if err != nil {
+		q.forgetPattern(pattern)
+		cancel()
+		return nil, err
+	}
+	go q.followPattern(ctx, pattern, topics, msgChan)
+
+	slog.InfoContext(ctx, "Successfully subscribed to pattern", "pattern", pattern, "topics", topics)
+	return delivered, nil
+}
+
+// followPattern consumes the topics matching pattern until ctx is done, then closes msgChan. The
+// matches are listed every patternRefresh, a reader is started once a topic matches and
+// restarted with the new topic list when it changes
+func (q *KafkaQueue) followPattern(ctx context.Context, pattern string, topics []string, msgChan chan<- Message) {
+	defer slog.InfoContext(context.Background(), "Subscription closed", "topic", pattern)
+	defer close(msgChan)
+	defer q.forgetPattern(pattern)
+
+	var current []string
+	stopReader := func() {}
+	var done <-chan struct{}
+	defer func() { stopReader() }()
+
+	ticker := time.NewTicker(patternRefresh)
+	defer ticker.Stop()
+	for {
+		if len(topics) > 0 && !slices.Equal(topics, current) {
+			stopReader()
+			readerCtx, cancel := context.WithCancel(ctx)
+			readerDone, err := q.startReader(readerCtx, pattern, kafka.ReaderConfig{
+				Brokers:     q.brokers,
+				GroupID:     q.options.GroupID,
+				GroupTopics: topics,
+				StartOffset: kafka.FirstOffset,
+			}, msgChan)
+			if err != nil {
+				cancel()
+				slog.ErrorContext(ctx, "Failed to consume pattern topics", "pattern", pattern, "topics", topics, "error", err)
+				stopReader, done, current = func() {}, nil, nil
+			} else {
+				stopReader = func() {
+					cancel()
+					<-readerDone
+				}
+				done, current = readerDone, topics
+				slog.InfoContext(ctx, "Consuming pattern topics", "pattern", pattern, "topics", topics)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			// The reader failed or was closed, like a topic subscription the pattern ends
+			stopReader = func() {}
+			return
+		case <-ticker.C:
+		}
+
+		matched, err := q.matchTopics(ctx, pattern)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "Failed to list pattern topics", "pattern", pattern, "error", err)
+			}
+			continue
+		}
+		topics = matched
+	}
+}
+
+// forgetPattern removes a pattern subscription
+func (q *KafkaQueue) forgetPattern(pattern string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.patterns, pattern)
+}
+
+// matchTopics returns the existing topics matching a pattern, except dead-letter topics
+func (q *KafkaQueue) matchTopics(ctx context.Context, pattern string) ([]string, error) {
+	partitions, err := q.matchPartitions(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(partitions)), nil
+}
+
+// matchPartitions returns the partitions of the existing topics matching a pattern, except dead-letter topics
+func (q *KafkaQueue) matchPartitions(ctx context.Context, pattern string) (map[string][]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", q.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kafka topics: %w", err)
+	}
+
+	topics := make(map[string][]int)
+	for _, partition := range partitions {
+		match, err := path.Match(pattern, partition.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if match && !strings.HasSuffix(partition.Topic, DeadLetterTopic("")) {
+			topics[partition.Topic] = append(topics[partition.Topic], partition.ID)
+		}
+	}
+	return topics, nil
+}
+
+// subscribe starts a reader of a topic registered under name, the returned channel is closed once
+// the reader stops
+func (q *KafkaQueue) subscribe(ctx context.Context, name string, config kafka.ReaderConfig, options subscribeOptions) (<-chan Message, error) {
+	// Messages are delivered in partition order regardless of their priority, acknowledgements
+	// are committed in partition order by the offset tracker
+	msgChan := make(chan Message)
+	delivered, err := dispatch(ctx, name, msgChan, q.readerOptions(options, config.GroupID), true)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := q.startReader(ctx, name, config, msgChan)
+	if err != nil {
+		close(msgChan)
+		return nil, err
+	}
+	go func() {
+		<-done
+		close(msgChan)
+		slog.InfoContext(context.Background(), "Subscription closed", "topic", name)
+	}()
+
+	return delivered, nil
+}
+
+// readerOptions completes the options of a subscription of group, dropped messages are settled
+// since an offset left in progress would stop the commits of its partition
+func (q *KafkaQueue) readerOptions(options subscribeOptions, group string) subscribeOptions {
+	if options.metrics == nil {
+		options.metrics = q.options.Metrics
+	}
+	options.dropped = settleDropped(func(topic string, partition int, offset int64) error {
+		return q.commitOffset(context.Background(), topic, group, partition, offset)
+	})
+	return options
+}
+
+// startReader starts a reader registered under name, a topic or a pattern, which sends the
+// messages it fetches to msgChan. The reader is closed once ctx is done or fetching fails, after
+// which the returned channel is closed
+func (q *KafkaQueue) startReader(ctx context.Context, name string, config kafka.ReaderConfig, msgChan chan<- Message) (<-chan struct{}, error) {
+	reader := kafka.NewReader(config)
+
+	q.mu.Lock()
+	if _, ok := q.readers[name]; ok {
+		q.mu.Unlock()
+		reader.Close()
+		return nil, fmt.Errorf("already subscribed to %s", name)
+	}
+	q.readers[name] = reader
+	offsets := newOffsetTracker()
+	q.offsets[reader] = offsets
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer q.closeReader(name, reader)
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(context.Background(), "Panic in subscription goroutine",
+					"topic", name,
+					"error", r,
+				)
+			}
+		}()
+
+		for {
+			record, err := reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() == nil && !errors.Is(err, io.EOF) {
+					slog.ErrorContext(context.Background(), "Failed to fetch message", "topic", name, "error", err)
+				}
+				return
+			}
+
+			message, err := q.options.Codec.Decode(record.Value)
+			if err != nil {
+				slog.ErrorContext(context.Background(), "Failed to unmarshal message",
+					"topic", record.Topic,
+					"partition", record.Partition,
+					"offset", record.Offset,
+					"error", err,
+				)
+				q.options.Metrics.MessageDropped(record.Topic, DropMalformed)
+				// A malformed record would block the partition, it is settled to skip it
+				offsets.deliver(record.Topic, record.Partition, record.Offset)
+				if err := q.commit(context.Background(), reader, offsets, record.Topic, record.Partition, record.Offset); err != nil {
+					slog.ErrorContext(context.Background(), "Failed to commit malformed message", "topic", name, "error", err)
+				}
+				continue
+			}
+			message.Topic = record.Topic
+			message.Group = config.GroupID
+			message.AckID = fmt.Sprintf("%d:%d", record.Partition, record.Offset)
+
+			offsets.deliver(record.Topic, record.Partition, record.Offset)
+			if message.Expired(time.Now()) {
+				slog.WarnContext(context.Background(), "Discarded expired message", "topic", record.Topic, "messageID", message.ID, "timestamp", message.Timestamp)
+				q.options.Metrics.MessageDropped(record.Topic, DropExpired)
+				if err := q.commit(context.Background(), reader, offsets, record.Topic, record.Partition, record.Offset); err != nil {
+					slog.ErrorContext(context.Background(), "Failed to commit expired message", "topic", name, "error", err)
+				}
+				continue
+			}
+
+			slog.InfoContext(context.Background(), "Received message from Kafka",
+				"topic", record.Topic,
+				"messageID", message.ID,
+				"partition", record.Partition,
+				"offset", record.Offset,
+			)
+
+			select {
+			case msgChan <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return done, nil
+}
+
+// closeReader closes the reader of a topic and forgets it if it is still the active one
+func (q *KafkaQueue) closeReader(topic string, reader *kafka.Reader) {
+	q.mu.Lock()
+	if q.readers[topic] == reader {
+		delete(q.readers, topic)
+	}
+	delete(q.offsets, reader)
+	q.mu.Unlock()
+
+	if err := reader.Close(); err != nil {
+		slog.ErrorContext(context.Background(), "Failed to close kafka reader", "topic", topic, "error", err)
+	}
+}
+
+func (q *KafkaQueue) Unsubscribe(ctx context.Context, topic string) error {
+	slog.InfoContext(ctx, "Attempt to unsubscribe from topic", "topic", topic)
+
+	q.mu.Lock()
+	reader, ok := q.readers[topic]
+	cancelPattern, isPattern := q.patterns[topic]
+	q.mu.Unlock()
+	if isPattern {
+		// Stopping the pattern closes its reader and then the channel
+		cancelPattern()
+		slog.InfoContext(ctx, "Successfully unsubscribed from topic", "topic", topic)
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("not subscribed to topic %s", topic)
+	}
+
+	// Closing the reader stops the subscription goroutine, which closes the channel
+	q.closeReader(topic, reader)
+
+	slog.InfoContext(ctx, "Successfully unsubscribed from topic", "topic", topic)
+	return nil
+}
+
+// Ack settles the offset of a message. Offsets are committed per partition, so the offset is
+// only committed once the earlier messages of its partition were acknowledged as well
+func (q *KafkaQueue) Ack(ctx context.Context, topic string, message Message) error {
+	partition, offset, err := parseAckID(message)
+	if err != nil {
+		return err
+	}
+
+	return q.commitOffset(ctx, topic, cmp.Or(message.Group, q.options.GroupID), partition, offset)
+}
+
+// commitOffset settles an offset of the reader consuming topic for group
+func (q *KafkaQueue) commitOffset(ctx context.Context, topic, group string, partition int, offset int64) error {
+	reader, ok := q.readerFor(topic, group)
+	if !ok {
+		return fmt.Errorf("not subscribed to topic %s", topic)
+	}
+	q.mu.Lock()
+	offsets := q.offsets[reader]
+	q.mu.Unlock()
+	return q.commit(ctx, reader, offsets, topic, partition, offset)
+}
+
+// parseAckID returns the partition and offset of a message received from Kafka
+func parseAckID(message Message) (int, int64, error) {
+	partitionID, offsetID, ok := strings.Cut(message.AckID, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("message %s was not received from kafka", message.ID)
+	}
+	partition, err := strconv.Atoi(partitionID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid partition in ack ID %q: %w", message.AckID, err)
+	}
+	offset, err := strconv.ParseInt(offsetID, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid offset in ack ID %q: %w", message.AckID, err)
+	}
+	return partition, offset, nil
+}
+
+// settleDropped returns the drop handler of a subscription, it settles the offsets of dropped
+// messages with commit like acknowledged ones
+func settleDropped(commit func(topic string, partition int, offset int64) error) func(Message) {
+	return func(message Message) {
+		partition, offset, err := parseAckID(message)
+		if err != nil {
+			slog.ErrorContext(context.Background(), "Failed to settle dropped message", "topic", message.Topic, "messageID", message.ID, "error", err)
+			return
+		}
+		if err := commit(message.Topic, partition, offset); err != nil {
+			slog.ErrorContext(context.Background(), "Failed to commit dropped message", "topic", message.Topic, "messageID", message.ID, "error", err)
+		}
+	}
+}
+
+// commit settles an offset of a reader and commits the offsets of its partition that are settled
+func (q *KafkaQueue) commit(ctx context.Context, reader *kafka.Reader, offsets *offsetTracker, topic string, partition int, offset int64) error {
+	if offsets != nil {
+		var ok bool
+		if offset, ok = offsets.complete(topic, partition, offset); !ok {
+			return nil
+		}
+	}
+	if err := reader.CommitMessages(ctx, kafka.Message{Topic: topic, Partition: partition, Offset: offset}); err != nil {
+		return fmt.Errorf("failed to commit message: %w", err)
+	}
+	return nil
+}
+
+// readerFor returns the reader consuming a topic for a consumer group, directly or through a pattern
+func (q *KafkaQueue) readerFor(topic, group string) (*kafka.Reader, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, reader := range q.readers {
+		config := reader.Config()
+		if config.GroupID == group && (config.Topic == topic || slices.Contains(config.GroupTopics, topic)) {
+			return reader, true
+		}
+	}
+	return nil, false
+}
+
+func (q *KafkaQueue) Close() error {
+	ctx := context.Background()
+	slog.InfoContext(ctx, "Attempt to close Kafka queue")
+
+	q.mu.Lock()
+	readers := q.readers
+	q.readers = make(map[string]*kafka.Reader)
+	q.offsets = make(map[*kafka.Reader]*offsetTracker)
+	patterns := q.patterns
+	q.patterns = make(map[string]context.CancelFunc)
+	q.mu.Unlock()
+
+	for _, cancel := range patterns {
+		cancel()
+	}
+
+	for topic, reader := range readers {
+		if err := reader.Close(); err != nil {
+			slog.ErrorContext(ctx, "Failed to close kafka reader", "topic", topic, "error", err)
+		}
+	}
+
+	err := q.writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close kafka writer: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully closed Kafka queue")
+	return nil
+}
+
+// Ping checks the connection to the first broker
+func (q *KafkaQueue) Ping(ctx context.Context) error {
+	conn, err := kafka.DialContext(ctx, "tcp", q.brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Brokers(); err != nil {
+		return fmt.Errorf("failed to fetch kafka brokers: %w", err)
+	}
+	return nil
+}
+
+// Backlog reports the lag of the consumer group on the topics matching a pattern, the messages
+// between its committed offsets and the end of each partition. Kafka does not track deliveries,
+// the pending count is -1
+func (q *KafkaQueue) Backlog(ctx context.Context, pattern string) ([]Backlog, error) {
+	partitions, err := q.matchPartitions(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+
+	client := &kafka.Client{Addr: kafka.TCP(q.brokers...)}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: q.options.GroupID, Topics: partitions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	if committed.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", committed.Error)
+	}
+
+	requests := make(map[string][]kafka.OffsetRequest, len(partitions))
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			requests[topic] = append(requests[topic], kafka.FirstOffsetOf(id), kafka.LastOffsetOf(id))
+		}
+	}
+	offsets, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: requests})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partition offsets: %w", err)
+	}
+
+	backlog := make([]Backlog, 0, len(partitions))
+	for _, topic := range slices.Sorted(maps.Keys(partitions)) {
+		positions := make(map[int]int64)
+		for _, partition := range committed.Topics[topic] {
+			if partition.Error != nil {
+				return nil, fmt.Errorf("failed to fetch committed offset of %s/%d: %w", topic, partition.Partition, partition.Error)
+			}
+			positions[partition.Partition] = partition.CommittedOffset
+		}
+
+		var lag int64
+		for _, partition := range offsets.Topics[topic] {
+			if partition.Error != nil {
+				return nil, fmt.Errorf("failed to list offsets of %s/%d: %w", topic, partition.Partition, partition.Error)
+			}
+			// A group without a committed offset starts reading at the first offset
+			position, ok := positions[partition.Partition]
+			if !ok || position < 0 {
+				position = partition.FirstOffset
+			}
+			lag += max(partition.LastOffset-position, 0)
+		}
+		backlog = append(backlog, Backlog{Topic: topic, Group: q.options.GroupID, Pending: -1, Lag: lag})
+	}
+	return backlog, nil
+}
@@ -0,0 +1,185 @@
+//go:build integration
+// +build integration
+
+package queue
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKafkaQueue(t *testing.T, ctx context.Context, groupID string) *KafkaQueue {
+	t.Helper()
+
+	brokers := strings.Split(getEnv("KAFKA_BROKERS", "localhost:9092"), ",")
+	queue, err := NewKafkaQueue(ctx, brokers, KafkaOptions{
+		GroupID:      groupID,
+		Partitions:   3,
+		PartitionKey: "source",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Kafka queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	return queue
+}
+
+func TestKafkaQueueIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-topic-" + suffix
+	queue := newTestKafkaQueue(t, ctx, "test-group-"+suffix)
+
+	// The topic is created on first send and the message kept for the group
+	testMessages := []Message{
+		{Body: []byte("first"), Metadata: map[string]string{"source": "test"}},
+		{Body: []byte("second"), Metadata: map[string]string{"source": "test"}},
+	}
+	for _, message := range testMessages {
+		if err := queue.Send(ctx, topic, message); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	messages, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+
+	// Messages with the same partition key keep their order
+	for _, expected := range testMessages {
+		select {
+		case received := <-messages:
+			if string(received.Body) != string(expected.Body) {
+				t.Errorf("Expected message body %q, got %q", expected.Body, received.Body)
+			}
+			if received.AckID == "" {
+				t.Fatal("Expected received message to have an AckID")
+			}
+			if err := queue.Ack(ctx, topic, received); err != nil {
+				t.Fatalf("Failed to acknowledge message: %v", err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+}
+
+func TestKafkaQueueRedeliveryIntegration(t *testing.T) {
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-topic-redelivery-" + suffix
+	group := "test-group-" + suffix
+
+	// The first consumer receives the message without acknowledging it
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	first := newTestKafkaQueue(t, firstCtx, group)
+	if err := first.Send(ctx, topic, Message{Body: []byte("unacknowledged")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	firstMessages, err := first.Subscribe(firstCtx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe first consumer: %v", err)
+	}
+	select {
+	case <-firstMessages:
+	case <-time.After(30 * time.Second):
+		t.Fatal("First consumer timed out waiting for message")
+	}
+	stopFirst()
+	first.Close()
+
+	// A new consumer of the same group receives it again
+	second := newTestKafkaQueue(t, ctx, group)
+	secondCtx, stopSecond := context.WithCancel(ctx)
+	defer stopSecond()
+	secondMessages, err := second.Subscribe(secondCtx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe second consumer: %v", err)
+	}
+	select {
+	case received := <-secondMessages:
+		if string(received.Body) != "unacknowledged" {
+			t.Errorf("Expected the unacknowledged message to be redelivered, got %q", received.Body)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("Second consumer timed out waiting for redelivered message")
+	}
+}
+
+func TestKafkaQueuePatternIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := newTestKafkaQueue(t, ctx, "test-group-"+suffix)
+
+	topics := []string{"test-pattern-" + suffix + ".a", "test-pattern-" + suffix + ".b"}
+	for _, topic := range topics {
+		if err := queue.Send(ctx, topic, Message{Body: []byte(topic)}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	messages, err := queue.SubscribePattern(ctx, "test-pattern-"+suffix+".*")
+	if err != nil {
+		t.Fatalf("Failed to subscribe to pattern: %v", err)
+	}
+
+	received := make(map[string]bool)
+	for range topics {
+		select {
+		case message := <-messages:
+			if string(message.Body) != message.Topic {
+				t.Errorf("Expected message from %s, got %q", message.Topic, message.Body)
+			}
+			received[message.Topic] = true
+			if err := queue.Ack(ctx, message.Topic, message); err != nil {
+				t.Fatalf("Failed to acknowledge message: %v", err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+	if len(received) != len(topics) {
+		t.Errorf("Expected messages from %v, got %v", topics, received)
+	}
+}
+
+func TestKafkaQueuePatternWithoutTopicsIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := newTestKafkaQueue(t, ctx, "test-group-"+suffix)
+
+	// On a fresh cluster nothing matches yet, the topics are picked up once they are created
+	messages, err := queue.SubscribePattern(ctx, "test-pattern-late-"+suffix+".*")
+	if err != nil {
+		t.Fatalf("Failed to subscribe to pattern without topics: %v", err)
+	}
+
+	topic := "test-pattern-late-" + suffix + ".a"
+	if err := queue.Send(ctx, topic, Message{Body: []byte(topic)}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	select {
+	case message := <-messages:
+		if message.Topic != topic {
+			t.Errorf("Expected a message from %s, got %s", topic, message.Topic)
+		}
+		if err := queue.Ack(ctx, message.Topic, message); err != nil {
+			t.Fatalf("Failed to acknowledge message: %v", err)
+		}
+	case <-time.After(patternRefresh + 30*time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+}
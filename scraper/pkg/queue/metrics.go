@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons passed to Metrics.MessageDropped
+const (
+	// DropExpired is a message received after its TTL
+	DropExpired = "expired"
+	// DropMalformed is a message that could not be decoded
+	DropMalformed = "malformed"
+	// DropSlowConsumer is a message discarded by the delivery policy of a full subscription
+	DropSlowConsumer = "slow_consumer"
+	// DropBufferFailure is a message that could not be spilled to disk
+	DropBufferFailure = "buffer_failure"
+)
+
+// Metrics observes the health of a queue per topic, implementations must be safe for concurrent use
+type Metrics interface {
+	// MessagePublished records a sent message and how long the backend took to accept it,
+	// err is set when the backend rejected it
+	MessagePublished(topic string, latency time.Duration, err error)
+	// MessageConsumed records a message handed to a subscriber
+	MessageConsumed(topic string)
+	// MessageDropped records a message discarded before it reached a subscriber
+	MessageDropped(topic string, reason string)
+	// BufferOccupancy records how many messages wait in the buffer of a subscription,
+	// named after its topic or pattern
+	BufferOccupancy(subscription string, buffered int)
+}
+
+// nopMetrics discards all observations, it is used when no Metrics are configured
+type nopMetrics struct{}
+
+func (nopMetrics) MessagePublished(topic string, latency time.Duration, err error) {}
+func (nopMetrics) MessageConsumed(topic string)                                    {}
+func (nopMetrics) MessageDropped(topic string, reason string)                      {}
+func (nopMetrics) BufferOccupancy(subscription string, buffered int)               {}
+
+// LatencyBuckets are the upper bounds of the publish latency histogram of MetricsRecorder
+var LatencyBuckets = []time.Duration{
+	1 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	5 * time.Second,
+}
+
+// TopicMetrics are the metrics recorded for a single topic
+type TopicMetrics struct {
+	Published     int64            `json:"published"`
+	PublishErrors int64            `json:"publish_errors"`
+	Consumed      int64            `json:"consumed"`
+	Dropped       map[string]int64 `json:"dropped,omitempty"`
+	// LatencyCounts holds the number of publishes per LatencyBuckets entry, the last count is for slower publishes
+	LatencyCounts []int64       `json:"latency_counts"`
+	LatencySum    time.Duration `json:"latency_sum"`
+	// Buffered is the last reported buffer occupancy of the subscription with this name
+	Buffered int `json:"buffered"`
+}
+
+// MetricsRecorder is an in-memory Metrics implementation keeping counters and histograms per topic
+type MetricsRecorder struct {
+	mu     sync.Mutex
+	topics map[string]*TopicMetrics
+}
+
+// NewMetricsRecorder creates an empty recorder
+func NewMetricsRecorder() *MetricsRecorder {
+	return &MetricsRecorder{topics: make(map[string]*TopicMetrics)}
+}
+
+// topic returns the metrics of a topic, the caller must hold the lock
+func (r *MetricsRecorder) topic(name string) *TopicMetrics {
+	metrics, ok := r.topics[name]
+	if !ok {
+		metrics = &TopicMetrics{
+			Dropped:       make(map[string]int64),
+			LatencyCounts: make([]int64, len(LatencyBuckets)+1),
+		}
+		r.topics[name] = metrics
+	}
+	return metrics
+}
+
+func (r *MetricsRecorder) MessagePublished(topic string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := r.topic(topic)
+	if err != nil {
+		metrics.PublishErrors++
+		return
+	}
+	metrics.Published++
+	metrics.LatencySum += latency
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool { return latency <= LatencyBuckets[i] })
+	metrics.LatencyCounts[bucket]++
+}
+
+func (r *MetricsRecorder) MessageConsumed(topic string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topic(topic).Consumed++
+}
+
+func (r *MetricsRecorder) MessageDropped(topic string, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topic(topic).Dropped[reason]++
+}
+
+func (r *MetricsRecorder) BufferOccupancy(subscription string, buffered int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topic(subscription).Buffered = buffered
+}
+
+// Snapshot returns a copy of the metrics recorded so far by topic
+func (r *MetricsRecorder) Snapshot() map[string]TopicMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]TopicMetrics, len(r.topics))
+	for name, metrics := range r.topics {
+		copied := *metrics
+		copied.Dropped = maps.Clone(metrics.Dropped)
+		copied.LatencyCounts = slices.Clone(metrics.LatencyCounts)
+		snapshot[name] = copied
+	}
+	return snapshot
+}
@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecorderPublishLatency(t *testing.T) {
+	recorder := NewMetricsRecorder()
+	recorder.MessagePublished("results", 3*time.Millisecond, nil)
+	recorder.MessagePublished("results", 2*time.Second, nil)
+	recorder.MessagePublished("results", time.Minute, nil)
+	recorder.MessagePublished("results", time.Millisecond, errors.New("connection refused"))
+
+	metrics := recorder.Snapshot()["results"]
+	if metrics.Published != 3 || metrics.PublishErrors != 1 {
+		t.Errorf("Expected 3 published and 1 failed message, got %+v", metrics)
+	}
+	if metrics.LatencySum != time.Minute+2*time.Second+3*time.Millisecond {
+		t.Errorf("Unexpected latency sum %v", metrics.LatencySum)
+	}
+
+	expected := map[int]int64{1: 1, 7: 1, len(LatencyBuckets): 1}
+	for bucket, count := range metrics.LatencyCounts {
+		if count != expected[bucket] {
+			t.Errorf("Expected %d publishes in bucket %d, got %d", expected[bucket], bucket, count)
+		}
+	}
+}
+
+func TestDispatchReportsMetrics(t *testing.T) {
+	recorder := NewMetricsRecorder()
+	in, out := newTestDispatch(t, WithBufferSize(1), WithDeliveryPolicy(DeliveryDropNewest), withMetrics(recorder))
+
+	in <- Message{ID: "1", Topic: "results.btc"}
+	in <- Message{ID: "2", Topic: "results.eth"}
+	close(in)
+	assertOrder(t, []string{"1"}, drain(out))
+
+	snapshot := recorder.Snapshot()
+	if snapshot["results.btc"].Consumed != 1 {
+		t.Errorf("Expected 1 consumed message, got %+v", snapshot["results.btc"])
+	}
+	if snapshot["results.eth"].Dropped[DropSlowConsumer] != 1 {
+		t.Errorf("Expected 1 dropped message, got %+v", snapshot["results.eth"])
+	}
+	if snapshot["test"].Buffered != 0 {
+		t.Errorf("Expected the drained buffer to be empty, got %d", snapshot["test"].Buffered)
+	}
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"sync"
+)
+
+// topicPartition identifies a partition of a topic
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets are the delivered offsets of a partition that were not committed yet
+type partitionOffsets struct {
+	// pending holds the delivered offsets in ascending order
+	pending []int64
+	done    map[int64]bool
+}
+
+// offsetTracker orders the commits of a Kafka reader. Committing an offset commits every earlier
+// offset of its partition, so messages completed out of order, e.g. by concurrent handlers, are
+// only committed up to the highest offset below which every delivered message completed
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+// newOffsetTracker creates a tracker without delivered offsets
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+// deliver records an offset handed to the consumer, offsets of a partition must be delivered in order
+func (t *offsetTracker) deliver(topic string, partition int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := topicPartition{topic: topic, partition: partition}
+	offsets, ok := t.partitions[key]
+	if !ok {
+		offsets = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[key] = offsets
+	}
+	offsets.pending = append(offsets.pending, offset)
+}
+
+// complete records a settled offset and returns the offset to commit, false while earlier
+// offsets of the partition are still in progress
+func (t *offsetTracker) complete(topic string, partition int, offset int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets, ok := t.partitions[topicPartition{topic: topic, partition: partition}]
+	if !ok {
+		// Offsets delivered before the tracker existed are committed as they are
+		return offset, true
+	}
+	offsets.done[offset] = true
+
+	commit, advanced := int64(0), false
+	for len(offsets.pending) > 0 && offsets.done[offsets.pending[0]] {
+		commit, advanced = offsets.pending[0], true
+		delete(offsets.done, commit)
+		offsets.pending = offsets.pending[1:]
+	}
+	return commit, advanced
+}
@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestOffsetTrackerCommitsContiguousOffsets(t *testing.T) {
+	tracker := newOffsetTracker()
+	for offset := int64(10); offset < 14; offset++ {
+		tracker.deliver("results", 0, offset)
+	}
+	tracker.deliver("results", 1, 7)
+
+	// Later offsets completing first are held back until the earlier ones complete
+	if _, ok := tracker.complete("results", 0, 12); ok {
+		t.Error("Expected offset 12 not to be committed while 10 and 11 are in progress")
+	}
+	if commit, ok := tracker.complete("results", 0, 10); !ok || commit != 10 {
+		t.Errorf("Expected offset 10 to be committed, got %d, %v", commit, ok)
+	}
+	if commit, ok := tracker.complete("results", 1, 7); !ok || commit != 7 {
+		t.Errorf("Expected partitions to be tracked separately, got %d, %v", commit, ok)
+	}
+	if commit, ok := tracker.complete("results", 0, 11); !ok || commit != 12 {
+		t.Errorf("Expected offset 12 to be committed once 11 completed, got %d, %v", commit, ok)
+	}
+	if commit, ok := tracker.complete("results", 0, 13); !ok || commit != 13 {
+		t.Errorf("Expected offset 13 to be committed, got %d, %v", commit, ok)
+	}
+}
+
+func TestDroppedMessagesDoNotStallCommits(t *testing.T) {
+	for _, policy := range []DeliveryPolicy{DeliveryDropNewest, DeliveryDropOldest} {
+		t.Run(string(policy), func(t *testing.T) {
+			tracker := newOffsetTracker()
+			var committed []int64
+			commit := func(topic string, partition int, offset int64) error {
+				if offset, ok := tracker.complete(topic, partition, offset); ok {
+					committed = append(committed, offset)
+				}
+				return nil
+			}
+
+			in, out := newTestDispatch(t, WithBufferSize(1), WithDeliveryPolicy(policy), withDropHandler(settleDropped(commit)))
+			// The consumer is busy while these arrive, only one of them is kept
+			for offset := int64(0); offset < 3; offset++ {
+				tracker.deliver("results", 0, offset)
+				in <- Message{ID: strconv.FormatInt(offset, 10), Topic: "results", AckID: "0:" + strconv.FormatInt(offset, 10)}
+			}
+
+			kept := <-out
+			partition, offset, err := parseAckID(kept)
+			if err != nil {
+				t.Fatalf("Failed to parse ack ID: %v", err)
+			}
+			if err := commit(kept.Topic, partition, offset); err != nil {
+				t.Fatalf("Failed to commit: %v", err)
+			}
+			if len(committed) == 0 || committed[len(committed)-1] != 2 {
+				t.Errorf("Expected the committed offset to advance past the dropped messages to 2, got %v", committed)
+			}
+		})
+	}
+}
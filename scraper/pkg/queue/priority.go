@@ -0,0 +1,53 @@
+package queue
+
+// Message priorities, higher priorities are delivered first
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// subscriptionBuffer is the default number of received messages a subscription holds for its consumer
+const subscriptionBuffer = 100
+
+// pendingMessage is a buffered message, seq keeps messages of equal priority in arrival order
+type pendingMessage struct {
+	message Message
+	seq     uint64
+}
+
+// messageHeap orders buffered messages by priority, then by arrival. An ordered heap ignores priorities
+type messageHeap struct {
+	items   []pendingMessage
+	ordered bool
+}
+
+func (h *messageHeap) Len() int { return len(h.items) }
+func (h *messageHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if !h.ordered && a.message.Priority != b.message.Priority {
+		return a.message.Priority > b.message.Priority
+	}
+	return a.seq < b.seq
+}
+func (h *messageHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *messageHeap) Push(x any)    { h.items = append(h.items, x.(pendingMessage)) }
+func (h *messageHeap) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// oldestLeastUrgent returns the index of the oldest message with the lowest priority
+func (h *messageHeap) oldestLeastUrgent() int {
+	found := 0
+	for i, item := range h.items {
+		current := h.items[found]
+		lower := !h.ordered && item.message.Priority < current.message.Priority
+		same := h.ordered || item.message.Priority == current.message.Priority
+		if lower || (same && item.seq < current.seq) {
+			found = i
+		}
+	}
+	return found
+}
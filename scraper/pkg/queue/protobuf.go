@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"macrochain/scraper/pkg/protoenc"
+)
+
+// Content types of message bodies, stored in the MetadataContentType metadata key
+const (
+	MetadataContentType = "content_type"
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// ProtobufCodec encodes messages as the Message protobuf defined in proto/macrochain.proto
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Encode(message Message) ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, message.ID)
+	b = protoenc.AppendString(b, 2, message.Type)
+	b = protoenc.AppendInt(b, 3, message.SchemaVersion)
+	b = protoenc.AppendTimestamp(b, 4, message.Timestamp)
+	b = protoenc.AppendStringMap(b, 5, message.Metadata)
+	b = protoenc.AppendBytes(b, 6, message.Body)
+	b = protoenc.AppendInt(b, 7, EnvelopeVersion)
+	b = protoenc.AppendInt(b, 8, message.Priority)
+	b = protoenc.AppendInt(b, 9, int(message.TTL.Milliseconds()))
+	return b, nil
+}
+
+// Decode decodes a protobuf message, failing with ErrUnsupportedSchema if it was written by a newer envelope version
+func (ProtobufCodec) Decode(data []byte) (Message, error) {
+	var message Message
+	var version int
+	err := protoenc.Fields(data, func(field protoenc.Field) error {
+		switch field.Number {
+		case 1:
+			message.ID = string(field.Bytes)
+		case 2:
+			message.Type = string(field.Bytes)
+		case 3:
+			message.SchemaVersion = int(int32(field.Varint))
+		case 4:
+			timestamp, err := protoenc.Timestamp(field.Bytes)
+			if err != nil {
+				return err
+			}
+			message.Timestamp = timestamp
+		case 5:
+			if message.Metadata == nil {
+				message.Metadata = make(map[string]string)
+			}
+			return protoenc.MapEntry(field.Bytes, message.Metadata)
+		case 6:
+			message.Body = bytes.Clone(field.Bytes)
+		case 7:
+			version = int(int32(field.Varint))
+		case 8:
+			message.Priority = int(int32(field.Varint))
+		case 9:
+			message.TTL = time.Duration(field.Varint) * time.Millisecond
+		}
+		return nil
+	})
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	if version > EnvelopeVersion {
+		return message, fmt.Errorf("%w: envelope version %d", ErrUnsupportedSchema, version)
+	}
+	return message, nil
+}
+
+// negotiatingCodec encodes with the configured codec but decodes every format,
+// so producers can switch formats without coordinating with their consumers.
+// Large bodies are compressed on encode, compressed bodies are always restored on decode
+type negotiatingCodec struct {
+	encoder     Codec
+	compression Compression
+}
+
+// NewCodec returns the codec for a wire format, "json", "protobuf" or "msgpack", compressing bodies as configured
+func NewCodec(format string, compression Compression) (Codec, error) {
+	if err := compression.validate(); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", "json":
+		return negotiatingCodec{encoder: EnvelopeCodec{}, compression: compression}, nil
+	case "protobuf":
+		return negotiatingCodec{encoder: ProtobufCodec{}, compression: compression}, nil
+	case "msgpack":
+		return negotiatingCodec{encoder: MsgpackCodec{}, compression: compression}, nil
+	}
+	return nil, fmt.Errorf("unsupported codec %q", format)
+}
+
+func (c negotiatingCodec) Encode(message Message) ([]byte, error) {
+	message, err := compressBody(message, c.compression)
+	if err != nil {
+		return nil, err
+	}
+	return c.encoder.Encode(message)
+}
+
+// Decode detects the format of a message, a JSON envelope always starts with "{" which
+// as a protobuf tag would be field 15 with the unused group wire type
+func (c negotiatingCodec) Decode(data []byte) (Message, error) {
+	var message Message
+	var err error
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		message, err = EnvelopeCodec{}.Decode(data)
+	} else if isMsgpackMap(data) {
+		message, err = MsgpackCodec{}.Decode(data)
+	} else {
+		message, err = ProtobufCodec{}.Decode(data)
+	}
+	if err != nil {
+		return message, err
+	}
+	return decompressBody(message, c.compression.maxSize())
+}
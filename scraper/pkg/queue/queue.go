@@ -6,15 +6,48 @@ import (
 )
 
 type Message struct {
-	ID        string
+	ID string
+	// Type identifies the kind of payload carried in Body, e.g. "scrape_result"
+	Type string
+	// SchemaVersion is the version of the payload schema of Type
+	SchemaVersion int
+	// Priority orders the messages waiting for a consumer, higher priorities are delivered first
+	Priority  int
 	Body      []byte
 	Timestamp time.Time
-	Metadata  map[string]string
+	// TTL discards the message when it is received more than TTL after its timestamp, zero never expires
+	TTL      time.Duration
+	Metadata map[string]string
+	// Topic is the topic a message was received from, it is set by Subscribe and never serialized
+	Topic string `json:"-"`
+	// Group is the consumer group a message was received by, it is set by Subscribe and never serialized
+	Group string `json:"-"`
+	// AckID identifies a received message within the backend, it is set by Subscribe and never serialized
+	AckID string `json:"-"`
+}
+
+// Expired reports whether a message outlived its TTL
+func (m Message) Expired(now time.Time) bool {
+	return m.TTL > 0 && !m.Timestamp.IsZero() && now.After(m.Timestamp.Add(m.TTL))
+}
+
+// Pinger is implemented by the queues that can check the connection to their backend
+type Pinger interface {
+	// Ping returns an error when the backend is unreachable
+	Ping(ctx context.Context) error
 }
 
 type Queue interface {
 	Send(ctx context.Context, topic string, message Message) error
-	Subscribe(ctx context.Context, topic string) (<-chan Message, error)
+	// Subscribe delivers the messages of a topic until the context is cancelled
+	Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error)
+	// SubscribePattern delivers the messages of every topic matching a glob pattern such as "scraper_results.*"
+	SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error)
+	// SubscribeGroup joins a consumer group on a topic, every message is delivered to a single member
+	// of the group so replicas share the load. An empty consumerName defaults to the configured consumer
+	SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error)
 	Unsubscribe(ctx context.Context, topic string) error
+	// Ack confirms that a received message has been processed and must not be redelivered
+	Ack(ctx context.Context, topic string, message Message) error
 	Close() error
 }
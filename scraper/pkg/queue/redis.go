@@ -2,47 +2,111 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
+	"path"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 )
 
+// RedisOptions configures a RedisQueue
+type RedisOptions struct {
+	// Connection configures authentication, database selection and TLS
+	Connection RedisConnection
+	// Codec encodes messages on the wire, defaults to JSON envelopes
+	Codec Codec
+	// DurableTopics are glob patterns, e.g. "scraper_results.*", of topics delivered at least once
+	// through Redis Streams instead of pub/sub. Their messages must be acknowledged
+	DurableTopics []string
+	// Durable configures the consumer group and retention of the durable topics
+	Durable RedisStreamsOptions
+	// Metrics observes published, consumed and dropped messages, defaults to discarding them
+	Metrics Metrics
+	// LogPayloads includes the body of every received message in its log record, message bodies
+	// may hold licensed data and are left out by default
+	LogPayloads bool
+}
+
 type RedisQueue struct {
-	client *redis.Client
+	client        *redis.Client
+	codec         Codec
+	durableTopics []string
+	durable       *RedisStreamsQueue
+	metrics       Metrics
+	logPayloads   bool
 }
 
-func NewRedisQueue(ctx context.Context, redisHost string, redisPort int) (*RedisQueue, error) {
+func NewRedisQueue(ctx context.Context, redisHost string, redisPort int, options RedisOptions) (*RedisQueue, error) {
 	slog.InfoContext(ctx, "Attempt to create new Redis queue", "host", redisHost, "port", redisPort)
 
-	client := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", redisHost, redisPort),
-		Password:     "",
-		DB:           0,
-		PoolSize:     10,
-		MinIdleConns: 2,
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-	})
+	if options.Codec == nil {
+		options.Codec = defaultCodec
+	}
+	if options.Metrics == nil {
+		options.Metrics = nopMetrics{}
+	}
+	for _, pattern := range options.DurableTopics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid durable topic pattern %q: %w", pattern, err)
+		}
+	}
+
+	client, err := newRedisClient(redisHost, redisPort, options.Connection, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := client.Ping(ctx).Result()
+	_, err = client.Ping(ctx).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
 	queue := &RedisQueue{
-		client: client,
+		client:        client,
+		codec:         options.Codec,
+		durableTopics: options.DurableTopics,
+		metrics:       options.Metrics,
+		logPayloads:   options.LogPayloads,
+	}
+
+	if len(options.DurableTopics) > 0 {
+		if options.Durable.Codec == nil {
+			options.Durable.Codec = options.Codec
+		}
+		// The durable topics live on the same server
+		options.Durable.Connection = options.Connection
+		if options.Durable.Metrics == nil {
+			options.Durable.Metrics = options.Metrics
+		}
+		durable, err := NewRedisStreamsQueue(ctx, redisHost, redisPort, options.Durable)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to create durable queue: %w", err)
+		}
+		queue.durable = durable
 	}
 
 	slog.InfoContext(ctx, "Successfully created new Redis queue", "host", redisHost, "port", redisPort)
 	return queue, nil
 }
 
+// isDurable reports whether a topic is delivered through Redis Streams
+func (q *RedisQueue) isDurable(topic string) bool {
+	for _, pattern := range q.durableTopics {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (q *RedisQueue) Send(ctx context.Context, topic string, message Message) error {
+	if q.isDurable(topic) {
+		return q.durable.Send(ctx, topic, message)
+	}
+
 	slog.InfoContext(ctx, "Attempt to send message", "topic", topic, "messageID", message.ID)
 
 	if message.ID == "" {
@@ -53,12 +117,14 @@ func (q *RedisQueue) Send(ctx context.Context, topic string, message Message) er
 		message.Timestamp = time.Now()
 	}
 
-	data, err := json.Marshal(message)
+	data, err := q.codec.Encode(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return err
 	}
 
+	start := time.Now()
 	err = q.client.Publish(ctx, topic, data).Err()
+	q.metrics.MessagePublished(topic, time.Since(start), err)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
@@ -67,21 +133,94 @@ func (q *RedisQueue) Send(ctx context.Context, topic string, message Message) er
 	return nil
 }
 
-func (q *RedisQueue) Subscribe(ctx context.Context, topic string) (<-chan Message, error) {
+func (q *RedisQueue) Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error) {
+	if q.isDurable(topic) {
+		return q.durable.Subscribe(ctx, topic, opts...)
+	}
+
 	slog.InfoContext(ctx, "Attempt to subscribe to topic", "topic", topic)
 
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a subscription
 	pubsub := q.client.Subscribe(ctx, topic)
 
 	// Confirm that the subscription is working
-	_, err := pubsub.Receive(ctx)
+	_, err = pubsub.Receive(ctx)
 	if err != nil {
 		pubsub.Close()
 		return nil, fmt.Errorf("failed to subscribe: %w", err)
 	}
 
-	// Create message channel
-	msgChan := make(chan Message, 100)
+	delivered, err := q.receive(ctx, topic, pubsub, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully subscribed to topic", "topic", topic)
+	return delivered, nil
+}
+
+// SubscribeGroup joins a consumer group on a durable topic, pub/sub topics are delivered to every subscriber
+func (q *RedisQueue) SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error) {
+	if !q.isDurable(topic) {
+		return nil, fmt.Errorf("consumer groups require a durable topic, %s is delivered through pub/sub", topic)
+	}
+	return q.durable.SubscribeGroup(ctx, topic, group, consumerName, opts...)
+}
+
+// SubscribePattern delivers the messages of every topic matching a Redis glob pattern. Durable
+// topics matching the pattern are consumed from their streams alongside the pub/sub topics
+func (q *RedisQueue) SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to pattern", "pattern", pattern)
+
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pubsub := q.client.PSubscribe(ctx, pattern)
+	_, err = pubsub.Receive(ctx)
+	if err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	var durable <-chan Message
+	if q.durable != nil {
+		// The durable subscription blocks while this one is full, its delivery policy applies here
+		// Its deliveries are reported by this subscription
+		if durable, err = q.durable.SubscribePattern(ctx, pattern, withMetrics(nopMetrics{})); err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+	}
+
+	delivered, err := q.receive(ctx, pattern, pubsub, durable, options)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully subscribed to pattern", "pattern", pattern)
+	return delivered, nil
+}
+
+// receive delivers the messages of a pub/sub subscription, and of a durable subscription if
+// there is one, to the consumer until the context is cancelled
+func (q *RedisQueue) receive(ctx context.Context, name string, pubsub *redis.PubSub, durable <-chan Message, options subscribeOptions) (<-chan Message, error) {
+	// Create message channel, the delivery buffer behind it applies the delivery policy
+	msgChan := make(chan Message)
+	if options.metrics == nil {
+		options.metrics = q.metrics
+	}
+	delivered, err := dispatch(ctx, name, msgChan, options, false)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
 
 	// Create a done channel to signal when consumer is done
 	done := make(chan struct{})
@@ -91,65 +230,74 @@ func (q *RedisQueue) Subscribe(ctx context.Context, topic string) (<-chan Messag
 		defer func() {
 			if r := recover(); r != nil {
 				slog.ErrorContext(context.Background(), "Panic in subscription goroutine",
-					"topic", topic,
+					"topic", name,
 					"error", r,
 				)
 			}
 			close(msgChan)
-			slog.InfoContext(context.Background(), "Subscription closed", "topic", topic)
+			slog.InfoContext(context.Background(), "Subscription closed", "topic", name)
 		}()
 
 		channel := pubsub.Channel()
 
 		for {
+			var message Message
 			select {
 			case <-done:
-				// Consumer has closed the channel, clean up
-				err := pubsub.Unsubscribe(context.Background(), topic)
-				if err != nil {
-					slog.ErrorContext(context.Background(), "Failed to unsubscribe", "topic", topic, "error", err)
-				}
-				err = pubsub.Close()
+				// Consumer has closed the channel, closing the connection drops its subscriptions
+				err := pubsub.Close()
 				if err != nil {
-					slog.ErrorContext(context.Background(), "Failed to close pubsub", "topic", topic, "error", err)
+					slog.ErrorContext(context.Background(), "Failed to close pubsub", "topic", name, "error", err)
 				}
 				return
 
+			case received, ok := <-durable:
+				if !ok {
+					// Only the durable subscription ended, a nil channel is never selected again
+					durable = nil
+					continue
+				}
+				message = received
+
 			case msg, ok := <-channel:
 				if !ok {
 					// Channel was closed, exit
 					return
 				}
 
-				var message Message
-				err := json.Unmarshal([]byte(msg.Payload), &message)
+				var err error
+				message, err = q.codec.Decode([]byte(msg.Payload))
 				if err != nil {
 					slog.ErrorContext(context.Background(), "Failed to unmarshal message",
-						"topic", topic,
+						"topic", msg.Channel,
 						"error", err,
 					)
+					q.metrics.MessageDropped(msg.Channel, DropMalformed)
 					continue
 				}
+				message.Topic = msg.Channel
 
-				// Log received message
-				slog.InfoContext(context.Background(), "Received message from Redis",
-					"topic", topic,
-					"messageID", message.ID,
-					"payload", string(message.Body),
-				)
+				if message.Expired(time.Now()) {
+					slog.WarnContext(context.Background(), "Discarded expired message", "topic", msg.Channel, "messageID", message.ID, "timestamp", message.Timestamp)
+					q.metrics.MessageDropped(msg.Channel, DropExpired)
+					continue
+				}
 
-				// Try to send to the channel
-				select {
-				case msgChan <- message:
-					// Message sent successfully
-				case <-done:
-					// Consumer has closed the channel, clean up
-					return
-				case <-time.After(1 * time.Second):
-					slog.WarnContext(context.Background(), "Timed out sending message to consumer",
-						"topic", topic,
-					)
+				// Log received message, the logger samples these records on busy topics
+				attrs := []any{"topic", msg.Channel, "messageID", message.ID}
+				if q.logPayloads {
+					attrs = append(attrs, "payload", string(message.Body))
 				}
+				slog.InfoContext(context.Background(), "Received message from Redis", attrs...)
+			}
+
+			// With DeliveryBlock this stalls the pub/sub connection until the consumer catches up
+			select {
+			case msgChan <- message:
+				// Message sent successfully
+			case <-done:
+				// Consumer has closed the channel, clean up
+				return
 			}
 		}
 	}()
@@ -160,8 +308,7 @@ func (q *RedisQueue) Subscribe(ctx context.Context, topic string) (<-chan Messag
 		close(done)
 	}()
 
-	slog.InfoContext(ctx, "Successfully subscribed to topic", "topic", topic)
-	return msgChan, nil
+	return delivered, nil
 }
 
 func (q *RedisQueue) Unsubscribe(ctx context.Context, topic string) error {
@@ -169,10 +316,97 @@ func (q *RedisQueue) Unsubscribe(ctx context.Context, topic string) error {
 	return nil
 }
 
+func (q *RedisQueue) Ack(ctx context.Context, topic string, message Message) error {
+	if q.isDurable(topic) {
+		return q.durable.Ack(ctx, topic, message)
+	}
+	// Pub/sub delivers at most once, there is nothing to acknowledge
+	return nil
+}
+
+// AddDeadLetter appends a message to the dead-letter list of topic, pub/sub would lose it without a subscriber
+func (q *RedisQueue) AddDeadLetter(ctx context.Context, topic string, message Message) error {
+	if q.isDurable(topic) {
+		return q.durable.AddDeadLetter(ctx, topic, message)
+	}
+
+	data, err := q.codec.Encode(message)
+	if err != nil {
+		return err
+	}
+
+	err = q.client.RPush(ctx, DeadLetterTopic(topic), data).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add message to dead-letter list: %w", err)
+	}
+	return nil
+}
+
+func (q *RedisQueue) DeadLetters(ctx context.Context, topic string, limit int) ([]Message, error) {
+	if q.isDurable(topic) {
+		return q.durable.DeadLetters(ctx, topic, limit)
+	}
+
+	entries, err := q.client.LRange(ctx, DeadLetterTopic(topic), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter list: %w", err)
+	}
+
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		message, err := q.codec.Decode([]byte(entry))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode dead-lettered message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (q *RedisQueue) Requeue(ctx context.Context, topic string, messageID string) error {
+	if q.isDurable(topic) {
+		return q.durable.Requeue(ctx, topic, messageID)
+	}
+
+	slog.InfoContext(ctx, "Attempt to requeue dead-lettered message", "topic", topic, "messageID", messageID)
+
+	entries, err := q.client.LRange(ctx, DeadLetterTopic(topic), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read dead-letter list: %w", err)
+	}
+
+	for _, entry := range entries {
+		message, err := q.codec.Decode([]byte(entry))
+		if err != nil || message.ID != messageID {
+			continue
+		}
+
+		// The entry is only removed once the message was sent, so a failed send keeps it in the
+		// dead-letter list. A concurrent requeue may send it twice, which consumers already handle
+		// as messages are delivered at least once
+		if err := q.Send(ctx, topic, revive(message)); err != nil {
+			return err
+		}
+		if err := q.client.LRem(ctx, DeadLetterTopic(topic), 1, entry).Err(); err != nil {
+			return fmt.Errorf("failed to remove requeued message from dead-letter list: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Successfully requeued dead-lettered message", "topic", topic, "messageID", messageID)
+		return nil
+	}
+	return fmt.Errorf("message %s not found in dead-letter queue of %s", messageID, topic)
+}
+
 func (q *RedisQueue) Close() error {
 	ctx := context.Background()
 	slog.InfoContext(ctx, "Attempt to close Redis queue")
 
+	if q.durable != nil {
+		if err := q.durable.Close(); err != nil {
+			return err
+		}
+	}
+
 	// Close Redis client
 	err := q.client.Close()
 	if err != nil {
@@ -182,3 +416,20 @@ func (q *RedisQueue) Close() error {
 	slog.InfoContext(ctx, "Successfully closed Redis queue")
 	return nil
 }
+
+// Ping checks the connection to Redis
+func (q *RedisQueue) Ping(ctx context.Context) error {
+	if err := q.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Backlog reports the backlog of the durable topics matching a pattern, pub/sub topics keep no
+// messages for their subscribers
+func (q *RedisQueue) Backlog(ctx context.Context, pattern string) ([]Backlog, error) {
+	if q.durable == nil {
+		return nil, nil
+	}
+	return q.durable.Backlog(ctx, pattern)
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisConnection configures authentication, database selection, TLS, pooling and timeouts of
+// a Redis client, as required by most managed Redis services
+type RedisConnection struct {
+	// Username authenticates with Redis 6 ACLs, leave empty for password-only AUTH
+	Username string
+	// Password is sent with AUTH when not empty
+	Password string
+	// DB selects the logical database
+	DB int
+	// TLS encrypts the connection
+	TLS bool
+	// CAFile is a PEM file of the CAs trusted for the server certificate, defaults to the system pool
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// PoolSize is the maximum number of connections, defaults to 10
+	PoolSize int
+	// MinIdleConns is the number of idle connections kept open
+	MinIdleConns int
+	// DialTimeout bounds establishing a connection, defaults to 5 seconds
+	DialTimeout time.Duration
+	// ReadTimeout and WriteTimeout bound a single command, default to 3 seconds. Blocking reads
+	// wait for their block time on top of ReadTimeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// withDefaults returns the connection with the defaults of the unset pool and timeout settings
+func (c RedisConnection) withDefaults() RedisConnection {
+	if c.PoolSize <= 0 {
+		c.PoolSize = 10
+	}
+	if c.DialTimeout <= 0 {
+		c.DialTimeout = 5 * time.Second
+	}
+	if c.ReadTimeout <= 0 {
+		c.ReadTimeout = 3 * time.Second
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = 3 * time.Second
+	}
+	return c
+}
+
+// tlsConfig builds the TLS configuration of the connection, nil when TLS is disabled
+func (c RedisConnection) tlsConfig(host string) (*tls.Config, error) {
+	if !c.TLS {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewRedisClient creates a client for the given server, for the uses of Redis besides the queues
+func NewRedisClient(host string, port int, connection RedisConnection) (*redis.Client, error) {
+	return newRedisClient(host, port, connection, 0)
+}
+
+// newRedisClient creates a client for the given server, block is the longest blocking read the
+// client issues and extends the read timeout
+func newRedisClient(host string, port int, connection RedisConnection, block time.Duration) (*redis.Client, error) {
+	connection = connection.withDefaults()
+	tlsConfig, err := connection.tlsConfig(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:         fmt.Sprintf("%s:%d", host, port),
+		Username:     connection.Username,
+		Password:     connection.Password,
+		DB:           connection.DB,
+		TLSConfig:    tlsConfig,
+		PoolSize:     connection.PoolSize,
+		MinIdleConns: connection.MinIdleConns,
+		DialTimeout:  connection.DialTimeout,
+		ReadTimeout:  connection.ReadTimeout + block,
+		WriteTimeout: connection.WriteTimeout,
+	}), nil
+}
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRedisConnectionTLSConfig(t *testing.T) {
+	config, err := RedisConnection{}.tlsConfig("redis.example.com")
+	if err != nil || config != nil {
+		t.Fatalf("Expected no TLS config when TLS is disabled, got %v, %v", config, err)
+	}
+
+	config, err = RedisConnection{TLS: true}.tlsConfig("redis.example.com")
+	if err != nil {
+		t.Fatalf("Failed to build TLS config: %v", err)
+	}
+	if config.ServerName != "redis.example.com" || config.RootCAs != nil {
+		t.Errorf("Expected the system CAs to verify redis.example.com, got %+v", config)
+	}
+
+	dir := t.TempDir()
+	if _, err := (RedisConnection{TLS: true, CAFile: filepath.Join(dir, "missing.pem")}).tlsConfig("redis"); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalid, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (RedisConnection{TLS: true, CAFile: invalid}).tlsConfig("redis"); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+	if _, err := (RedisConnection{TLS: true, CertFile: invalid, KeyFile: invalid}).tlsConfig("redis"); err == nil {
+		t.Error("Expected an error for an invalid client certificate")
+	}
+}
+
+func TestRedisConnectionWithDefaults(t *testing.T) {
+	connection := RedisConnection{}.withDefaults()
+	if connection.PoolSize != 10 || connection.DialTimeout != 5*time.Second ||
+		connection.ReadTimeout != 3*time.Second || connection.WriteTimeout != 3*time.Second {
+		t.Errorf("Expected the default pool size and timeouts, got %+v", connection)
+	}
+
+	connection = RedisConnection{PoolSize: 50, MinIdleConns: 5, ReadTimeout: time.Second}.withDefaults()
+	if connection.PoolSize != 50 || connection.MinIdleConns != 5 || connection.ReadTimeout != time.Second {
+		t.Errorf("Expected the configured settings to be kept, got %+v", connection)
+	}
+}
@@ -5,10 +5,14 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 func TestRedisQueueIntegration(t *testing.T) {
@@ -25,7 +29,7 @@ func TestRedisQueueIntegration(t *testing.T) {
 	defer cancel() // Ensure context is canceled to clean up subscriptions
 
 	// Create Redis queue
-	queue, err := NewRedisQueue(ctx, redisHost, redisPort)
+	queue, err := NewRedisQueue(ctx, redisHost, redisPort, RedisOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create Redis queue: %v", err)
 	}
@@ -86,7 +90,7 @@ func TestMultipleSubscribersIntegration(t *testing.T) {
 	defer cancel() // Ensure context is canceled to clean up subscriptions
 
 	// Create Redis queue
-	queue, err := NewRedisQueue(ctx, redisHost, redisPort)
+	queue, err := NewRedisQueue(ctx, redisHost, redisPort, RedisOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create Redis queue: %v", err)
 	}
@@ -151,6 +155,63 @@ func TestMultipleSubscribersIntegration(t *testing.T) {
 	// No need to explicitly unsubscribe - canceling the context will do it
 }
 
+// failingCommand fails the Redis commands named name on key, e.g. to make sends to a topic fail
+type failingCommand struct {
+	name string
+	key  string
+}
+
+func (h failingCommand) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if args := cmd.Args(); cmd.Name() == h.name && len(args) > 1 && fmt.Sprint(args[1]) == h.key {
+		return ctx, errors.New("redis unavailable")
+	}
+	return ctx, nil
+}
+
+func (h failingCommand) AfterProcess(ctx context.Context, cmd redis.Cmder) error { return nil }
+
+func (h failingCommand) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h failingCommand) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestRedisQueueRequeueFailureIntegration(t *testing.T) {
+	redisHost := getEnv("REDIS_HOST", "localhost")
+	redisPort, err := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
+	if err != nil {
+		t.Fatalf("Invalid Redis port: %v", err)
+	}
+
+	ctx := context.Background()
+	queue, err := NewRedisQueue(ctx, redisHost, redisPort, RedisOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create Redis queue: %v", err)
+	}
+	defer queue.Close()
+
+	topic := "test-requeue-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	defer queue.client.Del(ctx, DeadLetterTopic(topic))
+	if err := queue.AddDeadLetter(ctx, topic, Message{ID: "poison", Body: []byte("poison")}); err != nil {
+		t.Fatalf("Failed to add dead letter: %v", err)
+	}
+
+	// A message that could not be sent again stays in the dead-letter queue
+	queue.client.AddHook(failingCommand{name: "publish", key: topic})
+	if err := queue.Requeue(ctx, topic, "poison"); err == nil {
+		t.Fatal("Expected the requeue to fail")
+	}
+	dead, err := queue.DeadLetters(ctx, topic, 10)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != "poison" {
+		t.Errorf("Expected the dead letter to survive the failed requeue, got %v", dead)
+	}
+}
+
 // Helper function to get environment variables with fallback
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -158,3 +219,112 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func TestRedisQueueDurableTopicsIntegration(t *testing.T) {
+	redisHost := getEnv("REDIS_HOST", "localhost")
+	redisPort, err := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
+	if err != nil {
+		t.Fatalf("Invalid Redis port: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue, err := NewRedisQueue(ctx, redisHost, redisPort, RedisOptions{
+		DurableTopics: []string{"test-durable-*"},
+		Durable: RedisStreamsOptions{
+			Group:    "test-group-" + suffix,
+			Consumer: "consumer-1",
+			Block:    200 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis queue: %v", err)
+	}
+	defer queue.Close()
+
+	// Unlike pub/sub, a durable topic keeps messages sent while nobody is subscribed
+	topic := "test-durable-" + suffix
+	if err := queue.Send(ctx, topic, Message{Body: []byte("durable message")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	messages, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+
+	select {
+	case received := <-messages:
+		if string(received.Body) != "durable message" {
+			t.Errorf("Expected message body %q, got %q", "durable message", received.Body)
+		}
+		if err := queue.Ack(ctx, topic, received); err != nil {
+			t.Fatalf("Failed to acknowledge message: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+
+	pending, err := queue.durable.client.XPending(ctx, topic, "test-group-"+suffix).Result()
+	if err != nil {
+		t.Fatalf("Failed to fetch pending messages: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("Expected no pending messages after Ack, got %d", pending.Count)
+	}
+}
+
+func TestRedisQueuePatternIntegration(t *testing.T) {
+	redisHost := getEnv("REDIS_HOST", "localhost")
+	redisPort, err := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
+	if err != nil {
+		t.Fatalf("Invalid Redis port: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	prefix := "test-pattern-" + suffix
+	queue, err := NewRedisQueue(ctx, redisHost, redisPort, RedisOptions{
+		DurableTopics: []string{prefix + ".durable"},
+		Durable:       RedisStreamsOptions{Group: "test-group-" + suffix, Block: 200 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis queue: %v", err)
+	}
+	defer queue.Close()
+
+	// The durable message is kept in its stream until the pattern subscription finds it
+	if err := queue.Send(ctx, prefix+".durable", Message{Body: []byte("durable")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	messages, err := queue.SubscribePattern(ctx, prefix+".*")
+	if err != nil {
+		t.Fatalf("Failed to subscribe to pattern: %v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	if err := queue.Send(ctx, prefix+".pubsub", Message{Body: []byte("pubsub")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	received := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		select {
+		case message := <-messages:
+			received[message.Topic] = string(message.Body)
+			if err := queue.Ack(ctx, message.Topic, message); err != nil {
+				t.Fatalf("Failed to acknowledge message: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message, received %v", received)
+		}
+	}
+	if received[prefix+".durable"] != "durable" || received[prefix+".pubsub"] != "pubsub" {
+		t.Errorf("Expected a durable and a pub/sub message, got %v", received)
+	}
+}
@@ -0,0 +1,607 @@
+package queue
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// RedisStreamsOptions configures the consumer group and retention of a RedisStreamsQueue
+type RedisStreamsOptions struct {
+	// Group is the consumer group shared by all instances consuming a topic
+	Group string
+	// Consumer identifies this instance within the group, defaults to the hostname
+	Consumer string
+	// MaxLen approximately caps the number of entries kept per stream, zero disables trimming
+	MaxLen int64
+	// ClaimIdle is how long a message may stay unacknowledged before another consumer claims it
+	ClaimIdle time.Duration
+	// Block is how long a read waits for new messages before checking for idle messages again
+	Block time.Duration
+	// Codec encodes messages on the wire, defaults to JSON envelopes
+	Codec Codec
+	// Connection configures authentication, database selection and TLS
+	Connection RedisConnection
+	// Metrics observes published, consumed and dropped messages, defaults to discarding them
+	Metrics Metrics
+}
+
+type RedisStreamsQueue struct {
+	client  *redis.Client
+	options RedisStreamsOptions
+}
+
+// streamMessageField is the stream entry field holding the JSON encoded message
+const streamMessageField = "message"
+
+func NewRedisStreamsQueue(ctx context.Context, redisHost string, redisPort int, options RedisStreamsOptions) (*RedisStreamsQueue, error) {
+	slog.InfoContext(ctx, "Attempt to create new Redis Streams queue", "host", redisHost, "port", redisPort, "group", options.Group)
+
+	if options.Group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	if options.Consumer == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine consumer name: %w", err)
+		}
+		options.Consumer = hostname
+	}
+	if options.ClaimIdle <= 0 {
+		options.ClaimIdle = 1 * time.Minute
+	}
+	if options.Block <= 0 {
+		options.Block = 5 * time.Second
+	}
+	if options.Codec == nil {
+		options.Codec = defaultCodec
+	}
+	if options.Metrics == nil {
+		options.Metrics = nopMetrics{}
+	}
+
+	// Blocking reads must not run into the read timeout
+	client, err := newRedisClient(redisHost, redisPort, options.Connection, options.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = client.Ping(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	queue := &RedisStreamsQueue{
+		client:  client,
+		options: options,
+	}
+
+	slog.InfoContext(ctx, "Successfully created new Redis Streams queue", "host", redisHost, "port", redisPort, "consumer", options.Consumer)
+	return queue, nil
+}
+
+func (q *RedisStreamsQueue) Send(ctx context.Context, topic string, message Message) error {
+	slog.InfoContext(ctx, "Attempt to send message", "topic", topic, "messageID", message.ID)
+
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+
+	data, err := q.options.Codec.Encode(message)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: topic,
+		Values: map[string]any{streamMessageField: data},
+	}
+	if q.options.MaxLen > 0 {
+		args.MaxLen = q.options.MaxLen
+		args.Approx = true
+	}
+
+	start := time.Now()
+	err = q.client.XAdd(ctx, args).Err()
+	q.options.Metrics.MessagePublished(topic, time.Since(start), err)
+	if err != nil {
+		return fmt.Errorf("failed to add message to stream: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully sent message", "topic", topic, "messageID", message.ID)
+	return nil
+}
+
+func (q *RedisStreamsQueue) Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to topic", "topic", topic, "group", q.options.Group)
+
+	return q.subscribeTopic(ctx, topic, q.defaultMember(), opts)
+}
+
+// SubscribeGroup delivers the messages of a topic to the named consumer of a group, every
+// message is delivered to a single member of the group. Cancel the context to leave the group
+func (q *RedisStreamsQueue) SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to topic", "topic", topic, "group", group, "consumer", consumerName)
+
+	if group == "" {
+		return nil, fmt.Errorf("consumer group is required")
+	}
+	member := groupMember{group: group, consumer: consumerName}
+	if member.consumer == "" {
+		member.consumer = q.options.Consumer
+	}
+	return q.subscribeTopic(ctx, topic, member, opts)
+}
+
+// subscribeTopic consumes a single stream as a member of a consumer group
+func (q *RedisStreamsQueue) subscribeTopic(ctx context.Context, topic string, member groupMember, opts []SubscribeOption) (<-chan Message, error) {
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.createGroup(ctx, topic, member.group); err != nil {
+		return nil, err
+	}
+
+	delivered, err := q.subscribe(ctx, topic, member, func(ctx context.Context) ([]string, error) {
+		return []string{topic}, nil
+	}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully subscribed to topic", "topic", topic, "group", member.group)
+	return delivered, nil
+}
+
+// SubscribePattern delivers the messages of every stream matching a Redis glob pattern,
+// streams created after subscribing are picked up within patternRefresh. Dead-letter streams are skipped
+func (q *RedisStreamsQueue) SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error) {
+	slog.InfoContext(ctx, "Attempt to subscribe to pattern", "pattern", pattern, "group", q.options.Group)
+
+	options, err := newSubscribeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered, err := q.subscribe(ctx, pattern, q.defaultMember(), func(ctx context.Context) ([]string, error) {
+		return q.matchStreams(ctx, pattern)
+	}, options)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Successfully subscribed to pattern", "pattern", pattern)
+	return delivered, nil
+}
+
+// patternRefresh is how often a pattern subscription looks for new streams
+const patternRefresh = 10 * time.Second
+
+// groupMember identifies a consumer within a consumer group
+type groupMember struct {
+	group    string
+	consumer string
+}
+
+// defaultMember returns the consumer group and name configured for the queue
+func (q *RedisStreamsQueue) defaultMember() groupMember {
+	return groupMember{group: q.options.Group, consumer: q.options.Consumer}
+}
+
+// createGroup creates a consumer group at the start of the stream so messages sent before the first subscription are kept
+func (q *RedisStreamsQueue) createGroup(ctx context.Context, topic, group string) error {
+	err := q.client.XGroupCreateMkStream(ctx, topic, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// matchStreams returns the streams matching a pattern, except dead-letter streams
+func (q *RedisStreamsQueue) matchStreams(ctx context.Context, pattern string) ([]string, error) {
+	var streams []string
+	var cursor uint64
+	for {
+		keys, next, err := q.client.ScanType(ctx, cursor, pattern, 1000, "stream").Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan streams: %w", err)
+		}
+		for _, key := range keys {
+			if !strings.HasSuffix(key, DeadLetterTopic("")) {
+				streams = append(streams, key)
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return streams, nil
+		}
+	}
+}
+
+// subscribe starts consuming the streams returned by topics, which is called again every patternRefresh
+func (q *RedisStreamsQueue) subscribe(ctx context.Context, name string, member groupMember, topics func(context.Context) ([]string, error), options subscribeOptions) (<-chan Message, error) {
+	msgChan := make(chan Message)
+	if options.metrics == nil {
+		options.metrics = q.options.Metrics
+	}
+	delivered, err := dispatch(ctx, name, msgChan, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(context.Background(), "Panic in subscription goroutine",
+					"topic", name,
+					"error", r,
+				)
+			}
+			close(msgChan)
+			slog.InfoContext(context.Background(), "Subscription closed", "topic", name)
+		}()
+
+		var streams []string
+		known := make(map[string]bool)
+		var refreshed time.Time
+		for ctx.Err() == nil {
+			if time.Since(refreshed) >= patternRefresh {
+				refreshed = time.Now()
+				current, err := topics(ctx)
+				if err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "Failed to list streams", "topic", name, "error", err)
+				}
+				for _, topic := range current {
+					if known[topic] {
+						continue
+					}
+					if err := q.createGroup(ctx, topic, member.group); err != nil {
+						slog.ErrorContext(ctx, "Failed to join stream", "topic", topic, "error", err)
+						continue
+					}
+					known[topic] = true
+					streams = append(streams, topic)
+
+					// Redeliver the messages this consumer received but did not acknowledge before a restart
+					if !q.deliverStreams(ctx, member.group, msgChan, q.readGroup(ctx, member, []string{topic}, "0")) {
+						return
+					}
+				}
+			}
+
+			if len(streams) == 0 {
+				select {
+				case <-time.After(q.options.Block):
+				case <-ctx.Done():
+				}
+				continue
+			}
+
+			// Take over messages left unacknowledged by consumers that went away
+			for _, topic := range streams {
+				if !q.deliver(ctx, topic, member.group, msgChan, q.claimIdle(ctx, member, topic)) {
+					return
+				}
+			}
+
+			if !q.deliverStreams(ctx, member.group, msgChan, q.readGroup(ctx, member, streams, ">")) {
+				return
+			}
+		}
+	}()
+
+	return delivered, nil
+}
+
+// readGroup reads messages of several streams for a group member, ">" reads new messages and "0" its pending ones
+func (q *RedisStreamsQueue) readGroup(ctx context.Context, member groupMember, topics []string, id string) []redis.XStream {
+	ids := make([]string, len(topics))
+	for i := range ids {
+		ids[i] = id
+	}
+
+	args := &redis.XReadGroupArgs{
+		Group:    member.group,
+		Consumer: member.consumer,
+		Streams:  append(append([]string{}, topics...), ids...),
+		Count:    100,
+		Block:    q.options.Block,
+	}
+	if id != ">" {
+		// Pending messages are returned immediately
+		args.Block = -1
+	}
+
+	streams, err := q.client.XReadGroup(ctx, args).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to read from stream", "topics", topics, "error", err)
+			// Avoid a busy loop while redis is unavailable
+			time.Sleep(time.Second)
+		}
+		return nil
+	}
+	return streams
+}
+
+// deliverStreams delivers the entries read from several streams, returning false once the context is done
+func (q *RedisStreamsQueue) deliverStreams(ctx context.Context, group string, msgChan chan<- Message, streams []redis.XStream) bool {
+	for _, stream := range streams {
+		if !q.deliver(ctx, stream.Stream, group, msgChan, stream.Messages) {
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// claimIdle claims messages that stayed unacknowledged for longer than ClaimIdle.
+// XPENDING and XCLAIM are used as the XAUTOCLAIM reply of Redis 7 cannot be parsed by go-redis v8
+func (q *RedisStreamsQueue) claimIdle(ctx context.Context, member groupMember, topic string) []redis.XMessage {
+	pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  member.group,
+		Idle:   q.options.ClaimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to list pending messages", "topic", topic, "error", err)
+		}
+		return nil
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, entry := range pending {
+		ids = append(ids, entry.ID)
+	}
+
+	messages, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   topic,
+		Group:    member.group,
+		Consumer: member.consumer,
+		MinIdle:  q.options.ClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to claim idle messages", "topic", topic, "error", err)
+		}
+		return nil
+	}
+	if len(messages) > 0 {
+		slog.InfoContext(ctx, "Claimed idle messages", "topic", topic, "count", len(messages))
+	}
+	return messages
+}
+
+// deliver decodes stream entries and sends them to the consumer, returning false once the context is done
+func (q *RedisStreamsQueue) deliver(ctx context.Context, topic, group string, msgChan chan<- Message, entries []redis.XMessage) bool {
+	for _, entry := range entries {
+		message, err := q.decodeStreamMessage(entry)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to unmarshal message",
+				"topic", topic,
+				"entryID", entry.ID,
+				"error", err,
+			)
+			q.options.Metrics.MessageDropped(topic, DropMalformed)
+			// A malformed entry would be redelivered forever, acknowledge it to drop it
+			if err := q.client.XAck(ctx, topic, group, entry.ID).Err(); err != nil {
+				slog.ErrorContext(ctx, "Failed to acknowledge malformed message", "topic", topic, "entryID", entry.ID, "error", err)
+			}
+			continue
+		}
+
+		message.Topic = topic
+		message.Group = group
+
+		if message.Expired(time.Now()) {
+			slog.WarnContext(ctx, "Discarded expired message", "topic", topic, "messageID", message.ID, "timestamp", message.Timestamp)
+			q.options.Metrics.MessageDropped(topic, DropExpired)
+			if err := q.client.XAck(ctx, topic, group, entry.ID).Err(); err != nil {
+				slog.ErrorContext(ctx, "Failed to acknowledge expired message", "topic", topic, "entryID", entry.ID, "error", err)
+			}
+			continue
+		}
+
+		slog.InfoContext(ctx, "Received message from Redis stream",
+			"topic", topic,
+			"messageID", message.ID,
+			"entryID", entry.ID,
+		)
+
+		select {
+		case msgChan <- message:
+		case <-ctx.Done():
+			// The message stays pending and is redelivered after a restart
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// decodeStreamMessage decodes a stream entry written by Send
+func (q *RedisStreamsQueue) decodeStreamMessage(entry redis.XMessage) (Message, error) {
+	raw, ok := entry.Values[streamMessageField].(string)
+	if !ok {
+		return Message{}, fmt.Errorf("entry has no %q field", streamMessageField)
+	}
+
+	message, err := q.options.Codec.Decode([]byte(raw))
+	if err != nil {
+		return Message{}, err
+	}
+	message.AckID = entry.ID
+	return message, nil
+}
+
+func (q *RedisStreamsQueue) Unsubscribe(ctx context.Context, topic string) error {
+	slog.InfoContext(ctx, "To unsubscribe from a topic, cancel the context used when subscribing", "topic", topic)
+	return nil
+}
+
+func (q *RedisStreamsQueue) Ack(ctx context.Context, topic string, message Message) error {
+	if message.AckID == "" {
+		return fmt.Errorf("message %s was not received from a stream", message.ID)
+	}
+
+	err := q.client.XAck(ctx, topic, cmp.Or(message.Group, q.options.Group), message.AckID).Err()
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge message: %w", err)
+	}
+	return nil
+}
+
+// AddDeadLetter adds a message to the dead-letter stream of topic
+func (q *RedisStreamsQueue) AddDeadLetter(ctx context.Context, topic string, message Message) error {
+	return q.Send(ctx, DeadLetterTopic(topic), message)
+}
+
+func (q *RedisStreamsQueue) DeadLetters(ctx context.Context, topic string, limit int) ([]Message, error) {
+	entries, err := q.client.XRangeN(ctx, DeadLetterTopic(topic), "-", "+", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter stream: %w", err)
+	}
+
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		message, err := q.decodeStreamMessage(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead-lettered message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (q *RedisStreamsQueue) Requeue(ctx context.Context, topic string, messageID string) error {
+	slog.InfoContext(ctx, "Attempt to requeue dead-lettered message", "topic", topic, "messageID", messageID)
+
+	entries, err := q.client.XRange(ctx, DeadLetterTopic(topic), "-", "+").Result()
+	if err != nil {
+		return fmt.Errorf("failed to read dead-letter stream: %w", err)
+	}
+
+	for _, entry := range entries {
+		message, err := q.decodeStreamMessage(entry)
+		if err != nil || message.ID != messageID {
+			continue
+		}
+
+		// The entry is only removed once the message was sent, so a failed send keeps it in the
+		// dead-letter stream. A concurrent requeue may send it twice, which consumers already
+		// handle as messages are delivered at least once
+		if err := q.Send(ctx, topic, revive(message)); err != nil {
+			return err
+		}
+		if err := q.client.XDel(ctx, DeadLetterTopic(topic), entry.ID).Err(); err != nil {
+			return fmt.Errorf("failed to remove requeued message from dead-letter stream: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Successfully requeued dead-lettered message", "topic", topic, "messageID", messageID)
+		return nil
+	}
+	return fmt.Errorf("message %s not found in dead-letter queue of %s", messageID, topic)
+}
+
+func (q *RedisStreamsQueue) Close() error {
+	ctx := context.Background()
+	slog.InfoContext(ctx, "Attempt to close Redis Streams queue")
+
+	err := q.client.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close redis client: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Successfully closed Redis Streams queue")
+	return nil
+}
+
+// Ping checks the connection to Redis
+func (q *RedisStreamsQueue) Ping(ctx context.Context) error {
+	if err := q.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Backlog reports the pending entries and the lag of every consumer group on the streams matching
+// a pattern, the lag requires Redis 7 and is -1 on older servers
+func (q *RedisStreamsQueue) Backlog(ctx context.Context, pattern string) ([]Backlog, error) {
+	streams, err := q.matchStreams(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var backlog []Backlog
+	for _, stream := range streams {
+		// The typed XINFO GROUPS reply of the client predates the lag field
+		reply, err := q.client.Do(ctx, "XINFO", "GROUPS", stream).Slice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read consumer groups of %s: %w", stream, err)
+		}
+		for _, group := range reply {
+			fields, ok := group.([]any)
+			if !ok {
+				return nil, fmt.Errorf("unexpected consumer group reply %v", group)
+			}
+			entry, err := parseGroupInfo(stream, fields)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse consumer groups of %s: %w", stream, err)
+			}
+			backlog = append(backlog, entry)
+		}
+	}
+	return backlog, nil
+}
+
+// parseGroupInfo reads the name, pending entries and lag of a consumer group from its XINFO GROUPS fields
+func parseGroupInfo(stream string, fields []any) (Backlog, error) {
+	entry := Backlog{Topic: stream, Pending: -1, Lag: -1}
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, _ := fields[i].(string)
+		var err error
+		switch key {
+		case "name":
+			entry.Group, _ = fields[i+1].(string)
+		case "pending":
+			entry.Pending, err = replyInt(fields[i+1])
+		case "lag":
+			entry.Lag, err = replyInt(fields[i+1])
+		}
+		if err != nil {
+			return Backlog{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return entry, nil
+}
+
+// replyInt converts an integer of a Redis reply, a nil reply is reported as -1
+func replyInt(value any) (int64, error) {
+	switch v := value.(type) {
+	case nil:
+		return -1, nil
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unexpected value %v of type %T", value, value)
+	}
+}
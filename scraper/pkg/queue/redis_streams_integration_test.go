@@ -0,0 +1,456 @@
+//go:build integration
+// +build integration
+
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestStreamsQueue(t *testing.T, ctx context.Context, group, consumer string) *RedisStreamsQueue {
+	t.Helper()
+
+	redisHost := getEnv("REDIS_HOST", "localhost")
+	redisPort, err := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
+	if err != nil {
+		t.Fatalf("Invalid Redis port: %v", err)
+	}
+
+	queue, err := NewRedisStreamsQueue(ctx, redisHost, redisPort, RedisStreamsOptions{
+		Group:     group,
+		Consumer:  consumer,
+		MaxLen:    1000,
+		ClaimIdle: 500 * time.Millisecond,
+		Block:     200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Redis Streams queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	return queue
+}
+
+func receive(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+	return Message{}
+}
+
+func TestRedisStreamsQueueIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+
+	// Messages sent before anyone subscribes must not be lost
+	testMessage := Message{
+		Body:     []byte("stream message"),
+		Metadata: map[string]string{"test": "true"},
+	}
+	if err := queue.Send(ctx, topic, testMessage); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	messages, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+
+	received := receive(t, messages)
+	if string(received.Body) != string(testMessage.Body) {
+		t.Errorf("Expected message body %q, got %q", testMessage.Body, received.Body)
+	}
+	if received.Metadata["test"] != "true" {
+		t.Errorf("Expected metadata %v, got %v", testMessage.Metadata, received.Metadata)
+	}
+	if received.AckID == "" {
+		t.Fatal("Expected received message to have an AckID")
+	}
+
+	if err := queue.Ack(ctx, topic, received); err != nil {
+		t.Fatalf("Failed to acknowledge message: %v", err)
+	}
+
+	pending, err := queue.client.XPending(ctx, topic, queue.options.Group).Result()
+	if err != nil {
+		t.Fatalf("Failed to fetch pending messages: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("Expected no pending messages after ack, got %d", pending.Count)
+	}
+}
+
+func TestRedisStreamsQueueClaimIntegration(t *testing.T) {
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-claim-" + suffix
+	group := "test-group-" + suffix
+
+	// The first consumer receives the message and goes away without acknowledging it
+	firstCtx, stopFirst := context.WithCancel(ctx)
+	first := newTestStreamsQueue(t, firstCtx, group, "consumer-1")
+	firstMessages, err := first.Subscribe(firstCtx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe first consumer: %v", err)
+	}
+
+	if err := first.Send(ctx, topic, Message{Body: []byte("unacknowledged")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	receive(t, firstMessages)
+	stopFirst()
+
+	// The second consumer claims it once it has been idle long enough
+	secondCtx, stopSecond := context.WithCancel(ctx)
+	defer stopSecond()
+	second := newTestStreamsQueue(t, secondCtx, group, "consumer-2")
+	secondMessages, err := second.Subscribe(secondCtx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe second consumer: %v", err)
+	}
+
+	claimed := receive(t, secondMessages)
+	if string(claimed.Body) != "unacknowledged" {
+		t.Errorf("Expected the unacknowledged message to be claimed, got %q", claimed.Body)
+	}
+	if err := second.Ack(ctx, topic, claimed); err != nil {
+		t.Fatalf("Failed to acknowledge claimed message: %v", err)
+	}
+}
+
+func TestRedisStreamsQueueTrimIntegration(t *testing.T) {
+	ctx := context.Background()
+	topic := "test-stream-trim-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := newTestStreamsQueue(t, ctx, "test-group", "consumer-1")
+	queue.options.MaxLen = 10
+
+	for i := 0; i < 500; i++ {
+		if err := queue.Send(ctx, topic, Message{Body: []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	// Approximate trimming removes whole nodes, so the stream may stay slightly above the limit
+	length, err := queue.client.XLen(ctx, topic).Result()
+	if err != nil {
+		t.Fatalf("Failed to fetch stream length: %v", err)
+	}
+	if length >= 500 {
+		t.Errorf("Expected stream to be trimmed, got %d entries", length)
+	}
+}
+
+func TestRedisStreamsQueueDeadLetterIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-dlq-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+
+	if err := queue.Send(ctx, topic, Message{ID: "poison", Body: []byte("poison")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	consumeCtx, stopConsuming := context.WithCancel(ctx)
+	go Consume(consumeCtx, queue, topic, func(ctx context.Context, message Message) error {
+		return fmt.Errorf("cannot handle %s", message.ID)
+	}, ConsumerOptions{Retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond}})
+
+	var dead []Message
+	deadline := time.Now().Add(5 * time.Second)
+	for len(dead) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		var err error
+		if dead, err = queue.DeadLetters(ctx, topic, 10); err != nil {
+			t.Fatalf("Failed to list dead letters: %v", err)
+		}
+	}
+	stopConsuming()
+
+	if len(dead) != 1 || dead[0].ID != "poison" {
+		t.Fatalf("Expected the poison message to be dead-lettered, got %v", dead)
+	}
+	if dead[0].Metadata[MetadataDLQAttempts] != "2" {
+		t.Errorf("Expected 2 attempts, got %v", dead[0].Metadata)
+	}
+
+	if err := queue.Requeue(ctx, topic, "poison"); err != nil {
+		t.Fatalf("Failed to requeue message: %v", err)
+	}
+	if dead, _ := queue.DeadLetters(ctx, topic, 10); len(dead) != 0 {
+		t.Errorf("Expected the dead-letter queue to be empty, got %d messages", len(dead))
+	}
+
+	messages, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+	requeued := receive(t, messages)
+	if requeued.ID != "poison" || requeued.Metadata[MetadataDLQError] != "" {
+		t.Errorf("Expected the requeued message without failure metadata, got %+v", requeued)
+	}
+}
+
+func TestRedisStreamsQueueRequeueFailureIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-requeue-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+	defer queue.client.Del(ctx, DeadLetterTopic(topic))
+	if err := queue.AddDeadLetter(ctx, topic, Message{ID: "poison", Body: []byte("poison")}); err != nil {
+		t.Fatalf("Failed to add dead letter: %v", err)
+	}
+
+	// A message that could not be sent again stays in the dead-letter queue
+	queue.client.AddHook(failingCommand{name: "xadd", key: topic})
+	if err := queue.Requeue(ctx, topic, "poison"); err == nil {
+		t.Fatal("Expected the requeue to fail")
+	}
+	dead, err := queue.DeadLetters(ctx, topic, 10)
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != "poison" {
+		t.Errorf("Expected the dead letter to survive the failed requeue, got %v", dead)
+	}
+}
+
+func TestRedisStreamsQueuePatternIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+	prefix := "test-stream-pattern-" + suffix
+
+	// Existing streams are consumed right away, dead-letter streams are skipped
+	if err := queue.Send(ctx, prefix+".a", Message{Body: []byte(prefix + ".a")}); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if err := queue.AddDeadLetter(ctx, prefix+".a", Message{Body: []byte("dead")}); err != nil {
+		t.Fatalf("Failed to add dead letter: %v", err)
+	}
+
+	messages, err := queue.SubscribePattern(ctx, prefix+".*")
+	if err != nil {
+		t.Fatalf("Failed to subscribe to pattern: %v", err)
+	}
+
+	received := receive(t, messages)
+	if received.Topic != prefix+".a" || string(received.Body) != prefix+".a" {
+		t.Errorf("Expected message from %s.a, got %q from %s", prefix, received.Body, received.Topic)
+	}
+	if err := queue.Ack(ctx, received.Topic, received); err != nil {
+		t.Fatalf("Failed to acknowledge message: %v", err)
+	}
+
+	select {
+	case message := <-messages:
+		t.Errorf("Expected no further messages, got %q from %s", message.Body, message.Topic)
+	case <-time.After(time.Second):
+	}
+}
+
+func TestRedisStreamsQueueExpiryIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-ttl-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+
+	// A tick sent before an outage is stale by the time the consumer comes back
+	stale := Message{Body: []byte("stale"), Timestamp: time.Now().Add(-time.Hour), TTL: time.Minute}
+	fresh := Message{Body: []byte("fresh"), TTL: time.Minute}
+	for _, message := range []Message{stale, fresh} {
+		if err := queue.Send(ctx, topic, message); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	messages, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+
+	received := receive(t, messages)
+	if string(received.Body) != "fresh" {
+		t.Errorf("Expected the stale message to be discarded, got %q", received.Body)
+	}
+	if err := queue.Ack(ctx, topic, received); err != nil {
+		t.Fatalf("Failed to acknowledge message: %v", err)
+	}
+
+	pending, err := queue.client.XPending(ctx, topic, "test-group-"+suffix).Result()
+	if err != nil {
+		t.Fatalf("Failed to fetch pending messages: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("Expected the expired message to be acknowledged, got %d pending", pending.Count)
+	}
+}
+
+func TestRedisDedupStoreIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+	store := queue.DedupStore("test-dedup-" + suffix)
+
+	claimed, err := store.Claim(ctx, "message-1", time.Second)
+	if err != nil || !claimed {
+		t.Fatalf("Expected first claim to succeed, got %v, %v", claimed, err)
+	}
+	if _, err := store.Claim(ctx, "message-1", time.Second); !errors.Is(err, ErrMessageInProgress) {
+		t.Fatalf("Expected leased ID to be in progress, got %v", err)
+	}
+
+	// Released IDs can be claimed again
+	if err := store.Release(ctx, "message-1"); err != nil {
+		t.Fatalf("Failed to release claim: %v", err)
+	}
+	if claimed, _ := store.Claim(ctx, "message-1", 500*time.Millisecond); !claimed {
+		t.Fatal("Expected released ID to be claimed again")
+	}
+
+	// Leases of crashed consumers expire
+	time.Sleep(time.Second)
+	if claimed, _ := store.Claim(ctx, "message-1", time.Minute); !claimed {
+		t.Error("Expected expired lease to be claimed again")
+	}
+
+	// Completed IDs are duplicates until their TTL expires
+	if err := store.Complete(ctx, "message-1", 500*time.Millisecond); err != nil {
+		t.Fatalf("Failed to complete claim: %v", err)
+	}
+	claimed, err = store.Claim(ctx, "message-1", time.Second)
+	if err != nil || claimed {
+		t.Fatalf("Expected processed ID to be a duplicate, got %v, %v", claimed, err)
+	}
+	time.Sleep(time.Second)
+	if claimed, _ := store.Claim(ctx, "message-1", time.Second); !claimed {
+		t.Error("Expected expired ID to be claimed again")
+	}
+}
+
+func TestRedisStreamsQueueSubscribeGroupIntegration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-group-" + suffix
+	group := "persisters-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+
+	replica1, err := queue.SubscribeGroup(ctx, topic, group, "replica-1")
+	if err != nil {
+		t.Fatalf("Failed to join group: %v", err)
+	}
+	replica2, err := queue.SubscribeGroup(ctx, topic, group, "replica-2")
+	if err != nil {
+		t.Fatalf("Failed to join group: %v", err)
+	}
+	// A subscriber of the default group still receives every message
+	everything, err := queue.Subscribe(ctx, topic)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to topic: %v", err)
+	}
+
+	const count = 10
+	for i := 0; i < count; i++ {
+		if err := queue.Send(ctx, topic, Message{ID: strconv.Itoa(i)}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for len(seen) < count {
+		var message Message
+		select {
+		case message = <-replica1:
+		case message = <-replica2:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out after %d of %d messages", len(seen), count)
+		}
+		if seen[message.ID] {
+			t.Errorf("Message %s was delivered to more than one member", message.ID)
+		}
+		seen[message.ID] = true
+		if message.Group != group {
+			t.Errorf("Expected message of group %s, got %q", group, message.Group)
+		}
+		if err := queue.Ack(ctx, topic, message); err != nil {
+			t.Fatalf("Failed to acknowledge message: %v", err)
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		receive(t, everything)
+	}
+
+	pending, err := queue.client.XPending(ctx, topic, group).Result()
+	if err != nil {
+		t.Fatalf("Failed to fetch pending messages: %v", err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("Expected all group messages to be acknowledged, got %d pending", pending.Count)
+	}
+}
+
+func TestRedisStreamsQueueBacklogIntegration(t *testing.T) {
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	topic := "test-stream-backlog-" + suffix
+	queue := newTestStreamsQueue(t, ctx, "test-group-"+suffix, "consumer-1")
+
+	if err := queue.createGroup(ctx, topic, queue.options.Group); err != nil {
+		t.Fatalf("Failed to create consumer group: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := queue.Send(ctx, topic, Message{Body: []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+	}
+	// One message is delivered and left unacknowledged
+	streams := queue.readGroup(ctx, queue.defaultMember(), []string{topic}, ">")
+	if len(streams) != 1 || len(streams[0].Messages) == 0 {
+		t.Fatalf("Expected to read from %s, got %v", topic, streams)
+	}
+	delivered := int64(len(streams[0].Messages))
+
+	backlog, err := queue.Backlog(ctx, "test-stream-backlog-"+suffix+"*")
+	if err != nil {
+		t.Fatalf("Failed to read backlog: %v", err)
+	}
+	if len(backlog) != 1 {
+		t.Fatalf("Expected the backlog of one group, got %v", backlog)
+	}
+	if backlog[0].Topic != topic || backlog[0].Group != queue.options.Group {
+		t.Errorf("Expected the backlog of %s/%s, got %s/%s", topic, queue.options.Group, backlog[0].Topic, backlog[0].Group)
+	}
+	if backlog[0].Pending != delivered {
+		t.Errorf("Expected %d pending messages, got %d", delivered, backlog[0].Pending)
+	}
+	// Servers before Redis 7 do not report the lag
+	if backlog[0].Lag != -1 && backlog[0].Lag != 3-delivered {
+		t.Errorf("Expected a lag of %d, got %d", 3-delivered, backlog[0].Lag)
+	}
+}
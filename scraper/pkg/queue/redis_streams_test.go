@@ -0,0 +1,35 @@
+package queue
+
+import "testing"
+
+func TestParseGroupInfo(t *testing.T) {
+	// Redis 7 reports the entries read and the lag of a group
+	backlog, err := parseGroupInfo("results", []any{
+		"name", "persister", "consumers", int64(2), "pending", int64(3),
+		"last-delivered-id", "1-0", "entries-read", int64(5), "lag", int64(7),
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse group info: %v", err)
+	}
+	if backlog != (Backlog{Topic: "results", Group: "persister", Pending: 3, Lag: 7}) {
+		t.Errorf("Unexpected backlog %+v", backlog)
+	}
+
+	// Older servers omit the lag, and Redis 7 returns nil when it cannot compute it
+	for _, fields := range [][]any{
+		{"name", "persister", "consumers", int64(1), "pending", int64(0), "last-delivered-id", "0-0"},
+		{"name", "persister", "pending", int64(0), "lag", nil},
+	} {
+		backlog, err := parseGroupInfo("results", fields)
+		if err != nil {
+			t.Fatalf("Failed to parse group info: %v", err)
+		}
+		if backlog.Lag != -1 {
+			t.Errorf("Expected an unknown lag, got %d", backlog.Lag)
+		}
+	}
+
+	if _, err := parseGroupInfo("results", []any{"name", "persister", "pending", 1.5}); err == nil {
+		t.Error("Expected an error for a pending count that is not an integer")
+	}
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Metadata keys linking a reply to its request
+const (
+	MetadataReplyTo       = "reply_to"
+	MetadataCorrelationID = "correlation_id"
+)
+
+// Requester sends requests and waits for their replies. All replies of a requester arrive on
+// a single reply topic, so backends do not create a topic for every request
+type Requester struct {
+	q          Queue
+	replyTopic string
+
+	mu      sync.Mutex
+	pending map[string]chan Message
+}
+
+// NewRequester subscribes to a reply topic unique to this requester until the context is cancelled
+func NewRequester(ctx context.Context, q Queue) (*Requester, error) {
+	r := &Requester{
+		q:          q,
+		replyTopic: "replies." + uuid.New().String(),
+		pending:    make(map[string]chan Message),
+	}
+
+	replies, err := q.Subscribe(ctx, r.replyTopic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to reply topic: %w", err)
+	}
+	go r.receive(ctx, replies)
+
+	return r, nil
+}
+
+// receive passes every reply to the request waiting for it
+func (r *Requester) receive(ctx context.Context, replies <-chan Message) {
+	for reply := range replies {
+		if err := r.q.Ack(ctx, r.replyTopic, reply); err != nil {
+			slog.ErrorContext(ctx, "Failed to acknowledge reply", "topic", r.replyTopic, "messageID", reply.ID, "error", err)
+		}
+
+		correlationID := reply.Metadata[MetadataCorrelationID]
+		r.mu.Lock()
+		waiting, ok := r.pending[correlationID]
+		delete(r.pending, correlationID)
+		r.mu.Unlock()
+
+		if !ok {
+			// The request timed out or the reply was delivered twice
+			slog.WarnContext(ctx, "Discarded reply without pending request", "correlationID", correlationID, "messageID", reply.ID)
+			continue
+		}
+		waiting <- reply
+	}
+}
+
+// Request sends a message to topic and waits for its reply until the context is done
+func (r *Requester) Request(ctx context.Context, topic string, message Message) (Message, error) {
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+	message.Metadata = maps.Clone(message.Metadata)
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]string)
+	}
+	message.Metadata[MetadataReplyTo] = r.replyTopic
+	message.Metadata[MetadataCorrelationID] = message.ID
+
+	// Register before sending so a fast reply cannot arrive before anyone waits for it
+	waiting := make(chan Message, 1)
+	r.mu.Lock()
+	r.pending[message.ID] = waiting
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, message.ID)
+		r.mu.Unlock()
+	}()
+
+	if err := r.q.Send(ctx, topic, message); err != nil {
+		return Message{}, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	select {
+	case reply := <-waiting:
+		return reply, nil
+	case <-ctx.Done():
+		return Message{}, fmt.Errorf("no reply to request %s on %s: %w", message.ID, topic, ctx.Err())
+	}
+}
+
+// Reply sends the reply to a request received from the queue
+func Reply(ctx context.Context, q Queue, request Message, reply Message) error {
+	replyTo := request.Metadata[MetadataReplyTo]
+	if replyTo == "" {
+		return fmt.Errorf("message %s does not expect a reply", request.ID)
+	}
+
+	reply.Metadata = maps.Clone(reply.Metadata)
+	if reply.Metadata == nil {
+		reply.Metadata = make(map[string]string)
+	}
+	reply.Metadata[MetadataCorrelationID] = request.Metadata[MetadataCorrelationID]
+
+	if err := q.Send(ctx, replyTo, reply); err != nil {
+		return fmt.Errorf("failed to send reply: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loopbackQueue is an in-memory Queue delivering sent messages to the subscribers of their topic
+type loopbackQueue struct {
+	mu          sync.Mutex
+	subscribers map[string]chan Message
+}
+
+func newLoopbackQueue() *loopbackQueue {
+	return &loopbackQueue{subscribers: make(map[string]chan Message)}
+}
+
+func (q *loopbackQueue) Send(ctx context.Context, topic string, message Message) error {
+	q.mu.Lock()
+	subscriber, ok := q.subscribers[topic]
+	q.mu.Unlock()
+	if ok {
+		subscriber <- message
+	}
+	return nil
+}
+
+func (q *loopbackQueue) Subscribe(ctx context.Context, topic string, opts ...SubscribeOption) (<-chan Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	subscriber := make(chan Message, 10)
+	q.subscribers[topic] = subscriber
+	return subscriber, nil
+}
+
+func (q *loopbackQueue) SubscribePattern(ctx context.Context, pattern string, opts ...SubscribeOption) (<-chan Message, error) {
+	return nil, errors.New("pattern subscriptions are not supported")
+}
+
+func (q *loopbackQueue) SubscribeGroup(ctx context.Context, topic, group, consumerName string, opts ...SubscribeOption) (<-chan Message, error) {
+	return q.Subscribe(ctx, topic, opts...)
+}
+
+func (q *loopbackQueue) Unsubscribe(ctx context.Context, topic string) error {
+	return nil
+}
+
+func (q *loopbackQueue) Ack(ctx context.Context, topic string, message Message) error {
+	return nil
+}
+
+func (q *loopbackQueue) Close() error {
+	return nil
+}
+
+func TestRequestReply(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := newLoopbackQueue()
+	requests, err := q.Subscribe(ctx, "control.status")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// The responder echoes the request body
+	go func() {
+		for request := range requests {
+			reply := Message{Body: append([]byte("status of "), request.Body...)}
+			if err := Reply(ctx, q, request, reply); err != nil {
+				t.Errorf("Failed to reply: %v", err)
+			}
+		}
+	}()
+
+	requester, err := NewRequester(ctx, q)
+	if err != nil {
+		t.Fatalf("Failed to create requester: %v", err)
+	}
+
+	for _, body := range []string{"fx_rates", "cboe_vix"} {
+		reply, err := requester.Request(ctx, "control.status", Message{Body: []byte(body)})
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if string(reply.Body) != "status of "+body {
+			t.Errorf("Expected reply %q, got %q", "status of "+body, reply.Body)
+		}
+	}
+}
+
+func TestRequestTimesOutWithoutReply(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requester, err := NewRequester(ctx, newLoopbackQueue())
+	if err != nil {
+		t.Fatalf("Failed to create requester: %v", err)
+	}
+
+	requestCtx, cancelRequest := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelRequest()
+
+	_, err = requester.Request(requestCtx, "control.status", Message{Body: []byte("fx_rates")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the request to time out, got %v", err)
+	}
+	if len(requester.pending) != 0 {
+		t.Errorf("Expected the timed out request to be forgotten, got %d pending", len(requester.pending))
+	}
+}
+
+func TestReplyRequiresReplyTo(t *testing.T) {
+	if err := Reply(context.Background(), newLoopbackQueue(), Message{ID: "id-1"}, Message{}); err == nil {
+		t.Error("Expected an error replying to a message without reply topic")
+	}
+}
@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the published and consumed messages
+var tracer = otel.Tracer("macrochain/scraper/pkg/queue")
+
+// metadataCarrier carries the trace context in the metadata of a message
+type metadataCarrier map[string]string
+
+func (c metadataCarrier) Get(key string) string { return c[key] }
+func (c metadataCarrier) Set(key, value string) { c[key] = value }
+func (c metadataCarrier) Keys() []string        { return slices.Collect(maps.Keys(c)) }
+
+// InjectTrace adds the trace context of ctx to the metadata of message, consumers continue the
+// trace from it. The metadata is copied so messages sharing it are not modified
+func InjectTrace(ctx context.Context, message *Message) {
+	metadata := make(map[string]string, len(message.Metadata)+2)
+	maps.Copy(metadata, message.Metadata)
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(metadata))
+	message.Metadata = metadata
+}
+
+// ExtractTrace returns ctx with the trace context carried by the metadata of message
+func ExtractTrace(ctx context.Context, message Message) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(message.Metadata))
+}
+
+// StartPublishSpan starts the span of publishing message to topic and carries it in the metadata
+// of message, the caller ends the span once the message is sent
+func StartPublishSpan(ctx context.Context, topic string, message *Message) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", topic),
+			attribute.String("messaging.message.type", message.Type),
+		))
+	InjectTrace(ctx, message)
+	return ctx, span
+}
+
+// startProcessSpan starts the span of handling a message received from topic, as a child of the
+// span that published it
+func startProcessSpan(ctx context.Context, topic string, message Message) (context.Context, trace.Span) {
+	return tracer.Start(ExtractTrace(ctx, message), "process "+topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", topic),
+			attribute.String("messaging.message.id", message.ID),
+			attribute.String("messaging.message.type", message.Type),
+		))
+}
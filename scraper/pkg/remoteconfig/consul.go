@@ -0,0 +1,70 @@
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ConsulProvider reads configuration from the key/value store of Consul
+type ConsulProvider struct {
+	options    Options
+	httpClient *http.Client
+}
+
+// NewConsulProvider creates a provider reading from the Consul agent of options
+func NewConsulProvider(options Options) *ConsulProvider {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	return &ConsulProvider{
+		options:    options,
+		httpClient: &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// consulEntry is an entry of a Consul KV read, Value is base64 encoded in the response
+type consulEntry struct {
+	Value       []byte `json:"Value"`
+	ModifyIndex uint64 `json:"ModifyIndex"`
+}
+
+// Get returns the value of a key and its modify index
+func (p *ConsulProvider) Get(ctx context.Context, key string) ([]byte, uint64, error) {
+	endpoint := strings.TrimSuffix(p.options.Address, "/") + "/v1/kv/" + strings.TrimPrefix(key, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if p.options.Token != "" {
+		req.Header.Set("X-Consul-Token", p.options.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reach consul: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("consul returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var entries []consulEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse consul response: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return entries[0].Value, entries[0].ModifyIndex, nil
+}
@@ -0,0 +1,40 @@
+package remoteconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsulProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/macrochain/scraper.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// c2NyYXBlX2ludGVydmFsOiAzMA== is "scrape_interval: 30"
+		_, _ = w.Write([]byte(`[{"Key":"macrochain/scraper.yaml","Value":"c2NyYXBlX2ludGVydmFsOiAzMA==","ModifyIndex":42}]`))
+	}))
+	defer server.Close()
+
+	provider := NewConsulProvider(Options{Address: server.URL, Token: "token"})
+	value, version, err := provider.Get(context.Background(), "macrochain/scraper.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "scrape_interval: 30", string(value))
+	assert.Equal(t, uint64(42), version)
+
+	_, _, err = provider.Get(context.Background(), "macrochain/missing.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	denied := NewConsulProvider(Options{Address: server.URL, Token: "expired"})
+	_, _, err = denied.Get(context.Background(), "macrochain/scraper.yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EtcdProvider reads configuration from etcd through the JSON gateway of its v3 API
+type EtcdProvider struct {
+	options    Options
+	httpClient *http.Client
+}
+
+// NewEtcdProvider creates a provider reading from the etcd cluster of options
+func NewEtcdProvider(options Options) *EtcdProvider {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	return &EtcdProvider{
+		options:    options,
+		httpClient: &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// etcdRangeResponse is the response of a range request, the gateway encodes bytes in base64 and
+// 64 bit integers as strings
+type etcdRangeResponse struct {
+	KVs []struct {
+		Value       []byte `json:"value"`
+		ModRevision string `json:"mod_revision"`
+	} `json:"kvs"`
+}
+
+// Get returns the value of a key and its modification revision
+func (p *EtcdProvider) Get(ctx context.Context, key string) ([]byte, uint64, error) {
+	body, err := json.Marshal(map[string][]byte{"key": []byte(key)})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := strings.TrimSuffix(p.options.Address, "/") + "/v3/kv/range"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.options.Token != "" {
+		req.Header.Set("Authorization", p.options.Token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reach etcd: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("etcd returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var response etcdRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse etcd response: %w", err)
+	}
+	if len(response.KVs) == 0 {
+		return nil, 0, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	revision, err := strconv.ParseUint(response.KVs[0].ModRevision, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid etcd revision %q: %w", response.KVs[0].ModRevision, err)
+	}
+	return response.KVs[0].Value, revision, nil
+}
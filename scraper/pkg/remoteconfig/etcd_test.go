@@ -0,0 +1,40 @@
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtcdProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Key []byte `json:"key"`
+		}
+		if r.URL.Path != "/v3/kv/range" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if string(request.Key) != "/macrochain/scraper.yaml" {
+			_, _ = w.Write([]byte(`{"header":{"revision":"7"}}`))
+			return
+		}
+		// c2NyYXBlX2ludGVydmFsOiAzMA== is "scrape_interval: 30"
+		_, _ = w.Write([]byte(`{"header":{"revision":"7"},"kvs":[{"key":"L21hY3JvY2hhaW4vc2NyYXBlci55YW1s","value":"c2NyYXBlX2ludGVydmFsOiAzMA==","mod_revision":"5"}],"count":"1"}`))
+	}))
+	defer server.Close()
+
+	provider := NewEtcdProvider(Options{Address: server.URL})
+	value, version, err := provider.Get(context.Background(), "/macrochain/scraper.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "scrape_interval: 30", string(value))
+	assert.Equal(t, uint64(5), version)
+
+	_, _, err = provider.Get(context.Background(), "/macrochain/missing.yaml")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,40 @@
+package remoteconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when the configuration key does not exist
+var ErrNotFound = errors.New("remote configuration not found")
+
+// Provider reads a configuration document from a key/value store
+type Provider interface {
+	// Get returns the value of key and its version, which changes whenever the value is modified
+	Get(ctx context.Context, key string) (value []byte, version uint64, err error)
+}
+
+// Options configures the provider created by New
+type Options struct {
+	// Address is the address of the store, e.g. http://consul:8500 or http://etcd:2379
+	Address string
+	// Token is the Consul ACL token or the etcd auth token, empty without authentication
+	Token string
+	// Timeout bounds every request, defaults to 10 seconds
+	Timeout time.Duration
+	// Interval is the time between the checks for changes of the viper remote providers
+	Interval time.Duration
+}
+
+// New creates the provider of a store, consul or etcd
+func New(store string, options Options) (Provider, error) {
+	switch store {
+	case "consul":
+		return NewConsulProvider(options), nil
+	case "etcd":
+		return NewEtcdProvider(options), nil
+	}
+	return nil, fmt.Errorf("unsupported remote configuration store %q, expected consul or etcd", store)
+}
@@ -0,0 +1,21 @@
+package remoteconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	provider, err := New("consul", Options{Address: "http://consul:8500"})
+	require.NoError(t, err)
+	assert.IsType(t, &ConsulProvider{}, provider)
+
+	provider, err = New("etcd", Options{Address: "http://etcd:2379"})
+	require.NoError(t, err)
+	assert.IsType(t, &EtcdProvider{}, provider)
+
+	_, err = New("zookeeper", Options{})
+	assert.Error(t, err)
+}
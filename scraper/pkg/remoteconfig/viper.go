@@ -0,0 +1,144 @@
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// viperProviders maps the stores of this package to the names of the viper remote providers,
+// etcd is read through its v3 API
+var viperProviders = map[string]string{
+	"consul": "consul",
+	"etcd":   "etcd3",
+}
+
+// ViperProvider returns the name of the viper remote provider of store, consul or etcd
+func ViperProvider(store string) (string, error) {
+	provider, ok := viperProviders[store]
+	if !ok {
+		return "", fmt.Errorf("unsupported remote configuration store %q, expected consul or etcd", store)
+	}
+	return provider, nil
+}
+
+// remoteConfig serves the remote providers of viper with the stores of this package, in place of
+// the viper/remote package. The endpoint of a provider is the address of its store and its path
+// the key of the document
+type remoteConfig struct {
+	mu      sync.Mutex
+	options Options
+}
+
+// remote is the remote configuration of viper, set up by Configure
+var remote = &remoteConfig{}
+
+func init() {
+	viper.RemoteConfig = remote
+}
+
+// Configure sets the token, timeout and watch interval of the stores read by the viper remote
+// providers, the address of options is ignored in favor of the endpoint of the provider
+func Configure(options Options) {
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	remote.options = options
+}
+
+// provider returns the store of a viper remote provider with the timeout and watch interval
+func (r *remoteConfig) provider(rp viper.RemoteProvider) (Provider, Options, error) {
+	r.mu.Lock()
+	options := r.options
+	r.mu.Unlock()
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	options.Address = rp.Endpoint()
+
+	for store, name := range viperProviders {
+		if name == rp.Provider() {
+			provider, err := New(store, options)
+			return provider, options, err
+		}
+	}
+	return nil, options, viper.UnsupportedRemoteProviderError(rp.Provider())
+}
+
+// Get returns the document of a viper remote provider. Viper replaces the errors of its providers
+// with its own, so failures are logged here
+func (r *remoteConfig) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	provider, options, err := r.provider(rp)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+	defer cancel()
+	data, _, err := provider.Get(ctx, rp.Path())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read remote configuration", "provider", rp.Provider(), "key", rp.Path(), "error", err)
+		return nil, fmt.Errorf("failed to read remote configuration %s: %w", rp.Path(), err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+// Watch returns the document of a viper remote provider after the watch interval, so the callers
+// of WatchRemoteConfig poll the store without a loop of their own
+func (r *remoteConfig) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	_, options, err := r.provider(rp)
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(options.Interval)
+	return r.Get(rp)
+}
+
+// WatchChannel sends the document of a viper remote provider whenever its version changes,
+// checking it each watch interval until quit is closed or receives a value
+func (r *remoteConfig) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	responses, quit := make(chan *viper.RemoteResponse), make(chan bool)
+	go func() {
+		provider, options, err := r.provider(rp)
+		if err != nil {
+			select {
+			case responses <- &viper.RemoteResponse{Error: err}:
+			case <-quit:
+			}
+			return
+		}
+
+		var version uint64
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), options.Timeout)
+			data, current, err := provider.Get(ctx, rp.Path())
+			cancel()
+			var response *viper.RemoteResponse
+			switch {
+			case err != nil:
+				response = &viper.RemoteResponse{Error: err}
+			case current != version:
+				version = current
+				response = &viper.RemoteResponse{Value: data}
+			}
+			if response != nil {
+				select {
+				case responses <- response:
+				case <-quit:
+					return
+				}
+			}
+
+			select {
+			case <-time.After(options.Interval):
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return responses, quit
+}
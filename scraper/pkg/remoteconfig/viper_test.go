@@ -0,0 +1,112 @@
+package remoteconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consulServer serves a document from the Consul KV API that tests modify to simulate changes
+type consulServer struct {
+	mu      sync.Mutex
+	value   string
+	version uint64
+}
+
+func (s *consulServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != "/v1/kv/macrochain/scraper.yaml" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode([]consulEntry{{Value: []byte(s.value), ModifyIndex: s.version}})
+}
+
+func (s *consulServer) set(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.value = value
+	s.version++
+}
+
+func TestViperProvider(t *testing.T) {
+	provider, err := ViperProvider("consul")
+	require.NoError(t, err)
+	assert.Equal(t, "consul", provider)
+	provider, err = ViperProvider("etcd")
+	require.NoError(t, err)
+	assert.Equal(t, "etcd3", provider)
+	_, err = ViperProvider("zookeeper")
+	assert.Error(t, err)
+}
+
+func TestViperRemoteProvider(t *testing.T) {
+	consul := &consulServer{value: "scrape_interval: 30", version: 1}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+	Configure(Options{Interval: 10 * time.Millisecond})
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.AddRemoteProvider("consul", server.URL, "macrochain/scraper.yaml"))
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, 30, v.GetInt("SCRAPE_INTERVAL"))
+
+	consul.set("scrape_interval: 60")
+	require.NoError(t, v.WatchRemoteConfig())
+	assert.Equal(t, 60, v.GetInt("SCRAPE_INTERVAL"))
+
+	missing := viper.New()
+	missing.SetConfigType("yaml")
+	require.NoError(t, missing.AddRemoteProvider("consul", server.URL, "macrochain/missing.yaml"))
+	assert.Error(t, missing.ReadRemoteConfig())
+}
+
+func TestViperRemoteProviderWatchChannel(t *testing.T) {
+	consul := &consulServer{value: "scrape_interval: 30", version: 1}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+	Configure(Options{Interval: 5 * time.Millisecond})
+
+	responses, quit := remote.WatchChannel(&viperProvider{endpoint: server.URL, path: "macrochain/scraper.yaml"})
+	defer close(quit)
+
+	receive := func() string {
+		select {
+		case response := <-responses:
+			require.NoError(t, response.Error)
+			return string(response.Value)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the document")
+			return ""
+		}
+	}
+	assert.Equal(t, "scrape_interval: 30", receive())
+	select {
+	case response := <-responses:
+		t.Fatalf("Expected no document before the version is modified, got %q", response.Value)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	consul.set("scrape_interval: 60")
+	assert.Equal(t, "scrape_interval: 60", receive())
+}
+
+// viperProvider is a viper remote provider of Consul
+type viperProvider struct {
+	endpoint string
+	path     string
+}
+
+func (p *viperProvider) Provider() string      { return "consul" }
+func (p *viperProvider) Endpoint() string      { return p.endpoint }
+func (p *viperProvider) Path() string          { return p.path }
+func (p *viperProvider) SecretKeyring() string { return "" }
@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultAaveDataProvider is the Aave v3 protocol data provider on Ethereum mainnet
+const DefaultAaveDataProvider = "0x7B4EB56E7CD4b454BA8ff71E4518426369a138a3"
+
+// DefaultAaveReserves lists the Aave v3 reserves tracked when none are configured
+var DefaultAaveReserves = []string{
+	"WETH:0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+	"USDC:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	"USDT:0xdAC17F958D2ee523a2206206994597C13D831ec7",
+	"WBTC:0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599",
+}
+
+const (
+	secondsPerYear = 365 * 24 * 60 * 60
+	ray            = 1e27
+)
+
+// AaveScraper implements the Scraper interface for Aave v3 reserve data
+type AaveScraper struct {
+	rpcURL       string
+	dataProvider common.Address
+	reserves     []Contract
+	client       *ethclient.Client
+	decimals     map[string]uint8
+}
+
+// NewAaveScraper creates a new Aave v3 scraper reading from the given protocol data provider
+func NewAaveScraper(rpcURL string, dataProvider common.Address, reserves []Contract) *AaveScraper {
+	return &AaveScraper{
+		rpcURL:       rpcURL,
+		dataProvider: dataProvider,
+		reserves:     reserves,
+		decimals:     make(map[string]uint8),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *AaveScraper) Name() string {
+	return "aave_v3_markets"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *AaveScraper) Schedule() time.Duration {
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *AaveScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if s.dataProvider == (common.Address{}) {
+		return fmt.Errorf("protocol data provider address is required")
+	}
+	if len(s.reserves) == 0 {
+		return fmt.Errorf("at least one reserve is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *AaveScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape collects supply/borrow APY, utilization and totals of every configured reserve
+func (s *AaveScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	now := time.Now()
+	var points []TimeSeriesPoint
+	for _, reserve := range s.reserves {
+		reservePoints, err := s.scrapeReserve(ctx, reserve, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape reserve %s: %w", reserve.Label, err)
+		}
+		points = append(points, reservePoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+		Metadata: map[string]string{
+			"data_provider": s.dataProvider.Hex(),
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapeReserve reads the reserve data of a single asset
+func (s *AaveScraper) scrapeReserve(ctx context.Context, reserve Contract, now time.Time) ([]TimeSeriesPoint, error) {
+	decimals, err := s.reserveDecimals(ctx, reserve)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := callContract(ctx, s.client, s.dataProvider, "getReserveData(address)", reserve.Address.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// getReserveData returns unbacked, accruedToTreasuryScaled, totalAToken, totalStableDebt,
+	// totalVariableDebt, liquidityRate, variableBorrowRate, ...
+	words := make([]*big.Int, 7)
+	for i := range words {
+		if words[i], err = wordAt(out, i); err != nil {
+			return nil, fmt.Errorf("failed to decode reserve data: %w", err)
+		}
+	}
+
+	supplied := scaleDecimals(words[2], decimals)
+	borrowed := scaleDecimals(new(big.Int).Add(words[3], words[4]), decimals)
+	var utilization float64
+	if supplied > 0 {
+		utilization = borrowed / supplied * 100
+	}
+
+	metadata := map[string]string{"asset": reserve.Address.Hex()}
+	point := func(metric string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      slug(reserve.Label) + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: now,
+			Metadata:  metadata,
+		}
+	}
+
+	return []TimeSeriesPoint{
+		point("supply_apy", aprToAPY(rayToFloat(words[5]))*100, "percent"),
+		point("borrow_apy", aprToAPY(rayToFloat(words[6]))*100, "percent"),
+		point("utilization", utilization, "percent"),
+		point("total_supplied", supplied, reserve.Label),
+		point("total_borrowed", borrowed, reserve.Label),
+	}, nil
+}
+
+// reserveDecimals returns the decimals of a reserve asset, which never change and are cached
+func (s *AaveScraper) reserveDecimals(ctx context.Context, reserve Contract) (uint8, error) {
+	if decimals, ok := s.decimals[reserve.Label]; ok {
+		return decimals, nil
+	}
+
+	out, err := callContract(ctx, s.client, s.dataProvider, "getReserveConfigurationData(address)", reserve.Address.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	value, err := wordAt(out, 0)
+	if err != nil || value.BitLen() > 8 {
+		return 0, fmt.Errorf("invalid decimals for %s", reserve.Label)
+	}
+
+	decimals := uint8(value.Uint64())
+	s.decimals[reserve.Label] = decimals
+	return decimals, nil
+}
+
+// rayToFloat converts a ray (27 decimals fixed point) value to a float
+func rayToFloat(value *big.Int) float64 {
+	return weiToFloat(value, ray)
+}
+
+// aprToAPY converts an annual rate to a yield compounded every second
+func aprToAPY(apr float64) float64 {
+	return math.Pow(1+apr/secondsPerYear, secondsPerYear) - 1
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAaveScraper_Scrape(t *testing.T) {
+	reserves, err := ParseContracts([]string{"USDC:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"})
+	require.NoError(t, err)
+	dataProvider := common.HexToAddress(DefaultAaveDataProvider)
+
+	usdc := func(amount int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1_000_000))
+	}
+	// 4% and 6% annual rates in ray
+	supplyRate, _ := new(big.Int).SetString("40000000000000000000000000", 10)
+	borrowRate, _ := new(big.Int).SetString("60000000000000000000000000", 10)
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			if call.To != dataProvider {
+				return nil, fmt.Errorf("unexpected contract %s", call.To.Hex())
+			}
+			assert.Equal(t, common.LeftPadBytes(reserves[0].Address.Bytes(), 32), call.Input[4:], "Asset address should be passed")
+
+			switch call.Selector() {
+			case selector("getReserveConfigurationData(address)"):
+				return abiWords(big.NewInt(6), big.NewInt(7500), big.NewInt(7800)), nil
+			case selector("getReserveData(address)"):
+				return abiWords(
+					big.NewInt(0),
+					big.NewInt(0),
+					usdc(1_000_000), // total supplied
+					usdc(0),         // stable debt
+					usdc(800_000),   // variable debt
+					supplyRate,
+					borrowRate,
+				), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	scraper := NewAaveScraper(server.URL, dataProvider, reserves)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "aave_v3_markets", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.InDelta(t, 4.0811, values["usdc_supply_apy"], 1e-4)
+	assert.InDelta(t, 6.1837, values["usdc_borrow_apy"], 1e-4)
+	assert.InDelta(t, 80.0, values["usdc_utilization"], 1e-9)
+	assert.Equal(t, 1_000_000.0, values["usdc_total_supplied"])
+	assert.Equal(t, 800_000.0, values["usdc_total_borrowed"])
+}
+
+func TestAprToAPY(t *testing.T) {
+	assert.Equal(t, 0.0, aprToAPY(0))
+	assert.InDelta(t, 0.105171, aprToAPY(0.1), 1e-6)
+}
@@ -0,0 +1,122 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BeaconScraper implements the Scraper interface for beacon chain staking metrics from beaconcha.in
+type BeaconScraper struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewBeaconScraper creates a new beacon chain scraper, apiKey is optional
+func NewBeaconScraper(apiURL, apiKey string) *BeaconScraper {
+	return &BeaconScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		apiKey:     apiKey,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *BeaconScraper) Name() string {
+	return "beacon_chain_staking"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *BeaconScraper) Schedule() time.Duration {
+	// An epoch lasts 6.4 minutes, staking metrics move slowly
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *BeaconScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	return nil
+}
+
+// RequiresAPIKey reports whether the scraper cannot run without an API key, the key only
+// raises the rate limit
+func (s *BeaconScraper) RequiresAPIKey() bool {
+	return false
+}
+
+// HasAPIKey reports whether the scraper has an API key
+func (s *BeaconScraper) HasAPIKey() bool {
+	return s.apiKey != ""
+}
+
+// Init performs any necessary initialization
+func (s *BeaconScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// beaconEpochResponse is the response of the latest epoch endpoint
+type beaconEpochResponse struct {
+	Data struct {
+		Epoch                   uint64  `json:"epoch"`
+		ValidatorsCount         uint64  `json:"validatorscount"`
+		TotalValidatorBalance   uint64  `json:"totalvalidatorbalance"`
+		GlobalParticipationRate float64 `json:"globalparticipationrate"`
+		Ts                      string  `json:"ts"`
+	} `json:"data"`
+}
+
+// beaconETHStoreResponse is the response of the ETH.STORE endpoint
+type beaconETHStoreResponse struct {
+	Data struct {
+		APR float64 `json:"apr"`
+		Day uint64  `json:"day"`
+	} `json:"data"`
+}
+
+// Scrape collects staked ETH, validator count, participation rate and staking APR
+func (s *BeaconScraper) Scrape(ctx context.Context) ([]Result, error) {
+	header := http.Header{}
+	if s.apiKey != "" {
+		header.Set("apikey", s.apiKey)
+	}
+
+	var epoch beaconEpochResponse
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v1/epoch/latest", header, &epoch); err != nil {
+		return nil, fmt.Errorf("failed to fetch latest epoch: %w", err)
+	}
+
+	var store beaconETHStoreResponse
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v1/ethstore/latest", header, &store); err != nil {
+		return nil, fmt.Errorf("failed to fetch ETH.STORE APR: %w", err)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, epoch.Data.Ts)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	metadata := map[string]string{"epoch": fmt.Sprint(epoch.Data.Epoch)}
+
+	points := []TimeSeriesPoint{
+		{Code: "total_staked_eth", Value: float64(epoch.Data.TotalValidatorBalance) / gweiPerEth, Unit: "ETH", Timestamp: timestamp, Metadata: metadata},
+		{Code: "validator_count", Value: float64(epoch.Data.ValidatorsCount), Unit: "validators", Timestamp: timestamp, Metadata: metadata},
+		{Code: "participation_rate", Value: epoch.Data.GlobalParticipationRate * 100, Unit: "percent", Timestamp: timestamp, Metadata: metadata},
+		{Code: "staking_apr", Value: store.Data.APR * 100, Unit: "percent", Timestamp: timestamp},
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+		Metadata: map[string]string{
+			"url": s.apiURL,
+		},
+	}
+
+	return []Result{result}, nil
+}
@@ -0,0 +1,62 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeaconScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("apikey"), "API key should be sent")
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/epoch/latest":
+			_, _ = w.Write([]byte(`{"status":"OK","data":{"epoch":350000,"validatorscount":1050000,"totalvalidatorbalance":34000000000000000,"globalparticipationrate":0.9950,"ts":"2025-04-04T10:00:23Z"}}`))
+		case "/api/v1/ethstore/latest":
+			_, _ = w.Write([]byte(`{"status":"OK","data":{"apr":0.0312,"day":1200}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewBeaconScraper(mockServer.URL+"/", "secret")
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "beacon_chain_staking", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	expected := map[string]float64{
+		"total_staked_eth":   34_000_000,
+		"validator_count":    1_050_000,
+		"participation_rate": 99.5,
+		"staking_apr":        3.12,
+	}
+	require.Len(t, points, len(expected))
+	for _, point := range points {
+		assert.InDelta(t, expected[point.Code], point.Value, 1e-9, "Metric %s should have correct value", point.Code)
+		assert.Equal(t, time.Date(2025, 4, 4, 10, 0, 23, 0, time.UTC), point.Timestamp)
+	}
+}
+
+func TestBeaconScraper_ScrapeError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mockServer.Close()
+
+	_, err := NewBeaconScraper(mockServer.URL, "").Scrape(context.Background())
+	assert.Error(t, err, "Non-200 responses should cause an error")
+}
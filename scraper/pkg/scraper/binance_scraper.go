@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBinanceSymbols lists the Binance spot symbols tracked when none are configured
+var DefaultBinanceSymbols = []string{"BTCUSDT", "ETHUSDT", "ETHBTC"}
+
+// BinanceScraper implements the Scraper interface for Binance spot 24h ticker statistics
+type BinanceScraper struct {
+	apiURL     string
+	symbols    []string
+	httpClient *http.Client
+}
+
+// NewBinanceScraper creates a new Binance ticker scraper for the given symbols
+func NewBinanceScraper(apiURL string, symbols []string) *BinanceScraper {
+	return &BinanceScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		symbols:    symbols,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *BinanceScraper) Name() string {
+	return "binance_tickers"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *BinanceScraper) Schedule() time.Duration {
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *BinanceScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if len(s.symbols) == 0 {
+		return fmt.Errorf("at least one symbol is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *BinanceScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// binanceTicker is an entry of the 24hr ticker endpoint
+type binanceTicker struct {
+	Symbol             string `json:"symbol"`
+	OpenPrice          string `json:"openPrice"`
+	HighPrice          string `json:"highPrice"`
+	LowPrice           string `json:"lowPrice"`
+	LastPrice          string `json:"lastPrice"`
+	Volume             string `json:"volume"`
+	QuoteVolume        string `json:"quoteVolume"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	CloseTime          int64  `json:"closeTime"`
+}
+
+// Scrape collects the rolling 24h OHLC and volume of every configured symbol
+func (s *BinanceScraper) Scrape(ctx context.Context) ([]Result, error) {
+	symbols, err := json.Marshal(s.symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbols: %w", err)
+	}
+	query := url.Values{}
+	query.Set("symbols", string(symbols))
+
+	var tickers []binanceTicker
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v3/ticker/24hr?"+query.Encode(), nil, &tickers); err != nil {
+		return nil, fmt.Errorf("failed to fetch tickers: %w", err)
+	}
+
+	var points []TimeSeriesPoint
+	for _, ticker := range tickers {
+		tickerPoints, err := binanceTickerMetrics(ticker)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ticker %s: %w", ticker.Symbol, err)
+		}
+		points = append(points, tickerPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// binanceTickerMetrics converts a ticker to series points
+func binanceTickerMetrics(ticker binanceTicker) ([]TimeSeriesPoint, error) {
+	timestamp := time.UnixMilli(ticker.CloseTime).UTC()
+	metadata := map[string]string{"symbol": ticker.Symbol, "window": "24h"}
+
+	fields := []struct {
+		metric string
+		raw    string
+		unit   string
+	}{
+		{"open", ticker.OpenPrice, "price"},
+		{"high", ticker.HighPrice, "price"},
+		{"low", ticker.LowPrice, "price"},
+		{"close", ticker.LastPrice, "price"},
+		{"volume", ticker.Volume, "base"},
+		{"quote_volume", ticker.QuoteVolume, "quote"},
+		{"change", ticker.PriceChangePercent, "percent"},
+	}
+
+	points := make([]TimeSeriesPoint, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", field.metric, field.raw, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      strings.ToLower(ticker.Symbol) + "_" + field.metric,
+			Value:     value,
+			Unit:      field.unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
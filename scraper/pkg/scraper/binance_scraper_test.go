@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinanceScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/ticker/24hr" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, `["BTCUSDT"]`, r.URL.Query().Get("symbols"))
+		_, _ = w.Write([]byte(`[{"symbol":"BTCUSDT","priceChange":"-1200.00","priceChangePercent":"-1.417","openPrice":"84700.00","highPrice":"85200.50","lowPrice":"82900.10","lastPrice":"83500.00","volume":"21000.5","quoteVolume":"1760000000.25","openTime":1743675300000,"closeTime":1743761700000,"count":3500000}]`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewBinanceScraper(mockServer.URL, []string{"BTCUSDT"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "binance_tickers", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+		assert.Equal(t, time.UnixMilli(1743761700000).UTC(), point.Timestamp)
+	}
+	assert.Equal(t, map[string]float64{
+		"btcusdt_open":         84700,
+		"btcusdt_high":         85200.5,
+		"btcusdt_low":          82900.1,
+		"btcusdt_close":        83500,
+		"btcusdt_volume":       21000.5,
+		"btcusdt_quote_volume": 1760000000.25,
+		"btcusdt_change":       -1.417,
+	}, values)
+}
+
+func TestBinanceScraper_InvalidSymbol(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewBinanceScraper(mockServer.URL, []string{"INVALID"}).Scrape(context.Background())
+	assert.Error(t, err)
+}
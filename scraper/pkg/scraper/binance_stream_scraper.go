@@ -0,0 +1,233 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultBinanceStreams lists the Binance WebSocket streams subscribed to when none are configured
+var DefaultBinanceStreams = []string{"btcusdt@trade", "ethusdt@trade", "btcusdt@kline_1m", "ethusdt@kline_1m"}
+
+const (
+	binanceStreamMinBackoff = 1 * time.Second
+	binanceStreamMaxBackoff = 1 * time.Minute
+)
+
+// BinanceStreamScraper implements the StreamingScraper interface for Binance WebSocket trade and kline streams
+type BinanceStreamScraper struct {
+	streamURL  string
+	streams    []string
+	minBackoff time.Duration
+}
+
+// NewBinanceStreamScraper creates a new Binance WebSocket scraper for the given streams
+func NewBinanceStreamScraper(streamURL string, streams []string) *BinanceStreamScraper {
+	return &BinanceStreamScraper{
+		streamURL:  strings.TrimSuffix(streamURL, "/"),
+		streams:    streams,
+		minBackoff: binanceStreamMinBackoff,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *BinanceStreamScraper) Name() string {
+	return "binance_stream"
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *BinanceStreamScraper) Validate(ctx context.Context) error {
+	if s.streamURL == "" {
+		return fmt.Errorf("stream URL is required")
+	}
+	if len(s.streams) == 0 {
+		return fmt.Errorf("at least one stream is required")
+	}
+	for _, stream := range s.streams {
+		if !strings.Contains(stream, "@trade") && !strings.Contains(stream, "@kline_") {
+			return fmt.Errorf("unsupported stream %q, only trade and kline streams are supported", stream)
+		}
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *BinanceStreamScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Run streams trades and closed klines until the context is cancelled, reconnecting on failure
+func (s *BinanceStreamScraper) Run(ctx context.Context, emit func(Result) error) error {
+	backoff := s.minBackoff
+	for {
+		connected, err := s.stream(ctx, emit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = s.minBackoff
+		}
+		slog.WarnContext(ctx, "Binance stream disconnected", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, binanceStreamMaxBackoff)
+	}
+}
+
+// binanceStreamMessage is the envelope of a combined stream message
+type binanceStreamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// binanceTrade is the payload of a trade stream
+type binanceTrade struct {
+	Symbol    string `json:"s"`
+	TradeID   int64  `json:"t"`
+	Price     string `json:"p"`
+	Quantity  string `json:"q"`
+	TradeTime int64  `json:"T"`
+}
+
+// binanceKline is the payload of a kline stream
+type binanceKline struct {
+	Symbol string `json:"s"`
+	Kline  struct {
+		StartTime int64  `json:"t"`
+		Interval  string `json:"i"`
+		Open      string `json:"o"`
+		High      string `json:"h"`
+		Low       string `json:"l"`
+		Close     string `json:"c"`
+		Volume    string `json:"v"`
+		Closed    bool   `json:"x"`
+	} `json:"k"`
+}
+
+// stream connects and emits results until the connection fails, reporting whether it connected
+func (s *BinanceStreamScraper) stream(ctx context.Context, emit func(Result) error) (bool, error) {
+	url := s.streamURL + "/stream?streams=" + strings.Join(s.streams, "/")
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when the context is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	slog.InfoContext(ctx, "Connected to Binance stream", "streams", s.streams)
+	for {
+		var message binanceStreamMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			return true, fmt.Errorf("failed to read message: %w", err)
+		}
+
+		points, err := parseBinanceStreamMessage(message)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to parse Binance stream message", "stream", message.Stream, "error", err)
+			continue
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		result := Result{
+			Source:    s.Name(),
+			Timestamp: time.Now(),
+			Data:      points,
+			Metadata:  map[string]string{"stream": message.Stream},
+		}
+		if err := emit(result); err != nil {
+			slog.ErrorContext(ctx, "Failed to emit Binance stream result", "stream", message.Stream, "error", err)
+		}
+	}
+}
+
+// parseBinanceStreamMessage converts a trade or closed kline to series points
+func parseBinanceStreamMessage(message binanceStreamMessage) ([]TimeSeriesPoint, error) {
+	switch {
+	case strings.HasSuffix(message.Stream, "@trade"):
+		var trade binanceTrade
+		if err := json.Unmarshal(message.Data, &trade); err != nil {
+			return nil, err
+		}
+		price, err := strconv.ParseFloat(trade.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q: %w", trade.Price, err)
+		}
+		return []TimeSeriesPoint{{
+			Code:      strings.ToLower(trade.Symbol) + "_trade_price",
+			Value:     price,
+			Unit:      "price",
+			Timestamp: time.UnixMilli(trade.TradeTime).UTC(),
+			Metadata: map[string]string{
+				"symbol":   trade.Symbol,
+				"trade_id": strconv.FormatInt(trade.TradeID, 10),
+				"quantity": trade.Quantity,
+			},
+		}}, nil
+
+	case strings.Contains(message.Stream, "@kline_"):
+		var kline binanceKline
+		if err := json.Unmarshal(message.Data, &kline); err != nil {
+			return nil, err
+		}
+		// Only closed klines are final, open ones are updated every second
+		if !kline.Kline.Closed {
+			return nil, nil
+		}
+
+		prefix := strings.ToLower(kline.Symbol) + "_kline_" + kline.Kline.Interval + "_"
+		timestamp := time.UnixMilli(kline.Kline.StartTime).UTC()
+		metadata := map[string]string{"symbol": kline.Symbol, "interval": kline.Kline.Interval}
+		fields := []struct {
+			metric string
+			raw    string
+			unit   string
+		}{
+			{"open", kline.Kline.Open, "price"},
+			{"high", kline.Kline.High, "price"},
+			{"low", kline.Kline.Low, "price"},
+			{"close", kline.Kline.Close, "price"},
+			{"volume", kline.Kline.Volume, "base"},
+		}
+
+		points := make([]TimeSeriesPoint, 0, len(fields))
+		for _, field := range fields {
+			value, err := strconv.ParseFloat(field.raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid kline %s %q: %w", field.metric, field.raw, err)
+			}
+			points = append(points, TimeSeriesPoint{
+				Code:      prefix + field.metric,
+				Value:     value,
+				Unit:      field.unit,
+				Timestamp: timestamp,
+				Metadata:  metadata,
+			})
+		}
+		return points, nil
+	}
+
+	return nil, fmt.Errorf("unsupported stream %q", message.Stream)
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinanceStreamScraper_Run(t *testing.T) {
+	messages := []string{
+		`{"stream":"btcusdt@trade","data":{"e":"trade","s":"BTCUSDT","t":12345,"p":"64000.50","q":"0.25","T":1700000000000}}`,
+		`{"stream":"btcusdt@kline_1m","data":{"e":"kline","s":"BTCUSDT","k":{"t":1699999940000,"i":"1m","o":"63900","h":"64100","l":"63850","c":"64000","v":"12.5","x":false}}}`,
+		`{"stream":"btcusdt@kline_1m","data":{"e":"kline","s":"BTCUSDT","k":{"t":1699999940000,"i":"1m","o":"63900","h":"64100","l":"63850","c":"64000.5","v":"13","x":true}}}`,
+	}
+
+	var requestedStreams string
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedStreams = r.URL.Query().Get("streams")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for _, message := range messages {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				return
+			}
+		}
+		// Keep the connection open until the client disconnects
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	scraper := NewBinanceStreamScraper("ws"+strings.TrimPrefix(server.URL, "http"), []string{"btcusdt@trade", "btcusdt@kline_1m"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	var results []Result
+	done := make(chan error)
+	go func() {
+		done <- scraper.Run(ctx, func(result Result) error {
+			results = append(results, result)
+			if len(results) == 2 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err, "Run should return cleanly when the context is cancelled")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+
+	assert.Equal(t, "btcusdt@trade/btcusdt@kline_1m", requestedStreams)
+	require.Len(t, results, 2, "Should emit the trade and the closed kline only")
+
+	trade, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, trade, 1)
+	assert.Equal(t, "binance_stream", results[0].Source)
+	assert.Equal(t, "btcusdt_trade_price", trade[0].Code)
+	assert.InDelta(t, 64000.5, trade[0].Value, 1e-9)
+	assert.Equal(t, "0.25", trade[0].Metadata["quantity"])
+	assert.Equal(t, time.UnixMilli(1700000000000).UTC(), trade[0].Timestamp)
+
+	kline, ok := results[1].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	values := make(map[string]float64)
+	for _, point := range kline {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"btcusdt_kline_1m_open":   63900,
+		"btcusdt_kline_1m_high":   64100,
+		"btcusdt_kline_1m_low":    63850,
+		"btcusdt_kline_1m_close":  64000.5,
+		"btcusdt_kline_1m_volume": 13,
+	}, values)
+}
+
+func TestBinanceStreamScraper_Validate(t *testing.T) {
+	ctx := context.Background()
+	assert.Error(t, NewBinanceStreamScraper("", DefaultBinanceStreams).Validate(ctx))
+	assert.Error(t, NewBinanceStreamScraper("wss://stream.binance.com:9443", nil).Validate(ctx))
+	assert.Error(t, NewBinanceStreamScraper("wss://stream.binance.com:9443", []string{"btcusdt@depth"}).Validate(ctx), "Unsupported streams should be rejected")
+	assert.NoError(t, NewBinanceStreamScraper("wss://stream.binance.com:9443", DefaultBinanceStreams).Validate(ctx))
+}
@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// bitcoinFeeTargets are the confirmation targets, in blocks, fee estimates are requested for
+var bitcoinFeeTargets = []int{2, 6, 144}
+
+// BitcoinScraper implements the Scraper interface for Bitcoin Core node metrics
+type BitcoinScraper struct {
+	rpcURL     string
+	rpcUser    string
+	rpcPass    string
+	httpClient *http.Client
+}
+
+// NewBitcoinScraper creates a new Bitcoin Core RPC scraper
+func NewBitcoinScraper(rpcURL, rpcUser, rpcPass string) *BitcoinScraper {
+	return &BitcoinScraper{
+		rpcURL:     rpcURL,
+		rpcUser:    rpcUser,
+		rpcPass:    rpcPass,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *BitcoinScraper) Name() string {
+	return "bitcoin_node"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *BitcoinScraper) Schedule() time.Duration {
+	// A new block is produced every 10 minutes on average
+	return 10 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *BitcoinScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *BitcoinScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Scrape collects difficulty, hashrate, mempool and fee estimate metrics
+func (s *BitcoinScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var chain struct {
+		Blocks     uint64  `json:"blocks"`
+		Difficulty float64 `json:"difficulty"`
+	}
+	if err := s.call(ctx, "getblockchaininfo", nil, &chain); err != nil {
+		return nil, err
+	}
+
+	var hashrate float64
+	if err := s.call(ctx, "getnetworkhashps", nil, &hashrate); err != nil {
+		return nil, err
+	}
+
+	var mempool struct {
+		Size  uint64 `json:"size"`
+		Bytes uint64 `json:"bytes"`
+	}
+	if err := s.call(ctx, "getmempoolinfo", nil, &mempool); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	point := func(code string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      code,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: now,
+			Metadata:  map[string]string{"block": fmt.Sprint(chain.Blocks)},
+		}
+	}
+
+	points := []TimeSeriesPoint{
+		point("difficulty", chain.Difficulty, "difficulty"),
+		point("hashrate", hashrate/1e18, "EH/s"),
+		point("mempool_tx_count", float64(mempool.Size), "transactions"),
+		point("mempool_size", float64(mempool.Bytes)/1e6, "MvB"),
+	}
+
+	for _, target := range bitcoinFeeTargets {
+		var estimate struct {
+			FeeRate float64  `json:"feerate"`
+			Errors  []string `json:"errors"`
+		}
+		if err := s.call(ctx, "estimatesmartfee", []any{target}, &estimate); err != nil {
+			return nil, err
+		}
+		// The node returns no fee rate until it has seen enough transactions
+		if len(estimate.Errors) > 0 || estimate.FeeRate <= 0 {
+			continue
+		}
+
+		// Convert BTC/kvB to sat/vB
+		points = append(points, point(fmt.Sprintf("fee_estimate_%d_blocks", target), estimate.FeeRate*1e8/1000, "sat/vB"))
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// call performs a Bitcoin Core JSON-RPC call and decodes the result into out
+func (s *BitcoinScraper) call(ctx context.Context, method string, params []any, out any) error {
+	if params == nil {
+		params = []any{}
+	}
+	payload, err := json.Marshal(map[string]any{
+		"jsonrpc": "1.0",
+		"id":      "macrochain",
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.rpcUser != "" {
+		req.SetBasicAuth(s.rpcUser, s.rpcPass)
+	}
+
+	if err := waitRateLimit(ctx); err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	// Bitcoin Core reports RPC errors with a non-200 status and a JSON body
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return fmt.Errorf("%w: unexpected %s response with status code %d: %w", errclass.ErrParse, method, resp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s failed: %s (code %d)", method, rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("%w: failed to parse %s result: %w", errclass.ErrParse, method, err)
+	}
+	return nil
+}
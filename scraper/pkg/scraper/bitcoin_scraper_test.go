@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "bitcoin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Method string `json:"method"`
+			Params []int  `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var result string
+		switch req.Method {
+		case "getblockchaininfo":
+			result = `{"chain":"main","blocks":890000,"difficulty":113757508810853.6}`
+		case "getnetworkhashps":
+			result = `8.5e+20`
+		case "getmempoolinfo":
+			result = `{"size":35000,"bytes":25000000}`
+		case "estimatesmartfee":
+			if req.Params[0] == 144 {
+				result = `{"errors":["Insufficient data or no feerate found"],"blocks":144}`
+			} else {
+				result = `{"feerate":0.00012,"blocks":2}`
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":"macrochain"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":` + result + `,"error":null,"id":"macrochain"}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewBitcoinScraper(mockServer.URL, "bitcoin", "secret")
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "bitcoin_node", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"difficulty":            113757508810853.6,
+		"hashrate":              850,
+		"mempool_tx_count":      35000,
+		"mempool_size":          25,
+		"fee_estimate_2_blocks": 12,
+		"fee_estimate_6_blocks": 12,
+	}, values, "Fee targets without an estimate should be skipped")
+}
+
+func TestBitcoinScraper_RPCError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":"macrochain"}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewBitcoinScraper(mockServer.URL, "", "").Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Loading block index")
+}
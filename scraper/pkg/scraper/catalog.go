@@ -0,0 +1,29 @@
+package scraper
+
+// Frequencies at which series are observed
+const (
+	FrequencyTick   = "tick"
+	FrequencyHourly = "hourly"
+	FrequencyDaily  = "daily"
+	// FrequencyBusinessDay series are observed Monday to Friday, e.g. exchange fixings
+	FrequencyBusinessDay = "business_day"
+	FrequencyWeekly      = "weekly"
+)
+
+// SeriesInfo describes a series produced by a scraper, so consumers can discover what data
+// exists and how to interpret it
+type SeriesInfo struct {
+	Code        string
+	Description string
+	Unit        string
+	Frequency   string
+	// Country is the ISO 3166 code of the economy the series measures, empty for global markets
+	Country string
+}
+
+// Cataloged is implemented by scrapers that know the series they produce ahead of a scrape,
+// they are registered in the series catalog once the scraper is initialized
+type Cataloged interface {
+	// Series returns the series produced by the scraper
+	Series() []SeriesInfo
+}
@@ -0,0 +1,26 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogedScrapers(t *testing.T) {
+	var _ Cataloged = (*SNBScraper)(nil)
+	var _ Cataloged = (*VIXScraper)(nil)
+	var _ Cataloged = (*EIAScraper)(nil)
+
+	fx := NewFXScraper("https://api.frankfurter.app", "chf", []string{"usd", " eur"})
+	assert.Equal(t, []SeriesInfo{
+		{Code: "usd_chf", Description: "ECB reference rate, price of one USD in CHF", Unit: "CHF", Frequency: FrequencyBusinessDay},
+		{Code: "eur_chf", Description: "ECB reference rate, price of one EUR in CHF", Unit: "CHF", Frequency: FrequencyBusinessDay},
+	}, fx.Series())
+
+	lbma := NewLBMAScraper("https://prices.lbma.org.uk", "https://api.frankfurter.app")
+	var codes []string
+	for _, series := range lbma.Series() {
+		codes = append(codes, series.Code)
+	}
+	assert.Equal(t, []string{"gold_am_usd", "gold_am_chf", "gold_pm_usd", "gold_pm_chf", "silver_usd", "silver_chf"}, codes)
+}
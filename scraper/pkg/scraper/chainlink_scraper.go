@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultChainlinkFeeds lists the Chainlink aggregators on Ethereum mainnet tracked by default
+var DefaultChainlinkFeeds = []string{
+	"ETH/USD:0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419",
+	"BTC/USD:0xF4030086522a5bEEa4988F8cA5B36dbC97BeE88c",
+	"CHF/USD:0x449d117117838fFA61263B61dA6301AA2a88B13A",
+}
+
+// ChainlinkScraper implements the Scraper interface for Chainlink price feeds
+type ChainlinkScraper struct {
+	rpcURL   string
+	feeds    []Contract
+	client   *ethclient.Client
+	decimals map[string]uint8
+}
+
+// NewChainlinkScraper creates a new Chainlink scraper for the given aggregator contracts
+func NewChainlinkScraper(rpcURL string, feeds []Contract) *ChainlinkScraper {
+	return &ChainlinkScraper{
+		rpcURL:   rpcURL,
+		feeds:    feeds,
+		decimals: make(map[string]uint8),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *ChainlinkScraper) Name() string {
+	return "chainlink_price_feeds"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *ChainlinkScraper) Schedule() time.Duration {
+	return 5 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *ChainlinkScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if len(s.feeds) == 0 {
+		return fmt.Errorf("at least one price feed is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *ChainlinkScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape reads the latest round of every configured price feed
+func (s *ChainlinkScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	var points []TimeSeriesPoint
+	for _, feed := range s.feeds {
+		point, err := s.scrapeFeed(ctx, feed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape feed %s: %w", feed.Label, err)
+		}
+		points = append(points, point)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapeFeed reads the latest round of a single aggregator
+func (s *ChainlinkScraper) scrapeFeed(ctx context.Context, feed Contract) (TimeSeriesPoint, error) {
+	decimals, err := s.feedDecimals(ctx, feed)
+	if err != nil {
+		return TimeSeriesPoint{}, err
+	}
+
+	// latestRoundData returns roundId, answer, startedAt, updatedAt and answeredInRound
+	out, err := callContract(ctx, s.client, feed.Address, "latestRoundData()")
+	if err != nil {
+		return TimeSeriesPoint{}, err
+	}
+	roundID, err := wordAt(out, 0)
+	if err != nil {
+		return TimeSeriesPoint{}, fmt.Errorf("failed to decode latest round: %w", err)
+	}
+	answer, _ := wordAt(out, 1)
+	updatedAt, _ := wordAt(out, 3)
+
+	// The answer is a signed two's complement integer
+	if answer.Bit(255) == 1 {
+		answer.Sub(answer, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+
+	_, quote, _ := strings.Cut(feed.Label, "/")
+	return TimeSeriesPoint{
+		Code:      slug(feed.Label),
+		Value:     scaleDecimals(answer, decimals),
+		Unit:      quote,
+		Timestamp: time.Unix(updatedAt.Int64(), 0).UTC(),
+		Metadata: map[string]string{
+			"aggregator": feed.Address.Hex(),
+			"round_id":   roundID.String(),
+		},
+	}, nil
+}
+
+// feedDecimals returns the decimals of a feed answer, which never change and are cached
+func (s *ChainlinkScraper) feedDecimals(ctx context.Context, feed Contract) (uint8, error) {
+	if decimals, ok := s.decimals[feed.Label]; ok {
+		return decimals, nil
+	}
+
+	out, err := callContract(ctx, s.client, feed.Address, "decimals()")
+	if err != nil {
+		return 0, err
+	}
+	value, err := wordAt(out, 0)
+	if err != nil || value.BitLen() > 8 {
+		return 0, fmt.Errorf("invalid decimals for %s", feed.Label)
+	}
+
+	decimals := uint8(value.Uint64())
+	s.decimals[feed.Label] = decimals
+	return decimals, nil
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainlinkScraper_Scrape(t *testing.T) {
+	feeds, err := ParseContracts(DefaultChainlinkFeeds)
+	require.NoError(t, err)
+
+	answers := map[string]int64{
+		feeds[0].Address.Hex(): 180_512_000_000,   // ETH/USD 1805.12
+		feeds[1].Address.Hex(): 8_350_000_000_000, // BTC/USD 83500
+		feeds[2].Address.Hex(): 116_000_000,       // CHF/USD 1.16
+	}
+	roundID, _ := new(big.Int).SetString("110680464442257320000", 10)
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			answer, ok := answers[call.To.Hex()]
+			if !ok {
+				return nil, fmt.Errorf("unexpected contract %s", call.To.Hex())
+			}
+			switch call.Selector() {
+			case selector("decimals()"):
+				return abiWords(big.NewInt(8)), nil
+			case selector("latestRoundData()"):
+				return abiWords(roundID, big.NewInt(answer), big.NewInt(1743760800), big.NewInt(1743760811), roundID), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	scraper := NewChainlinkScraper(server.URL, feeds)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "chainlink_price_feeds", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 3)
+
+	assert.Equal(t, "eth_usd", points[0].Code)
+	assert.InDelta(t, 1805.12, points[0].Value, 1e-9)
+	assert.Equal(t, "USD", points[0].Unit)
+	assert.Equal(t, time.Unix(1743760811, 0).UTC(), points[0].Timestamp, "Timestamp should be the round update time")
+	assert.Equal(t, roundID.String(), points[0].Metadata["round_id"])
+	assert.Equal(t, "btc_usd", points[1].Code)
+	assert.InDelta(t, 83500.0, points[1].Value, 1e-9)
+	assert.Equal(t, "chf_usd", points[2].Code)
+	assert.InDelta(t, 1.16, points[2].Value, 1e-9)
+}
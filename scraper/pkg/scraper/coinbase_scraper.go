@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCoinbaseProducts lists the Coinbase Exchange products tracked when none are configured
+var DefaultCoinbaseProducts = []string{"BTC-USD", "ETH-USD", "ETH-BTC"}
+
+// DefaultCoinbaseGranularity is the default candle granularity in seconds
+const DefaultCoinbaseGranularity = 3600
+
+// coinbaseGranularities lists the candle granularities supported by Coinbase Exchange
+var coinbaseGranularities = []int{60, 300, 900, 3600, 21600, 86400}
+
+// CoinbaseScraper implements the Scraper interface for Coinbase Exchange spot tickers and candles
+type CoinbaseScraper struct {
+	apiURL      string
+	products    []string
+	granularity int
+	httpClient  *http.Client
+	now         func() time.Time
+}
+
+// NewCoinbaseScraper creates a new Coinbase Exchange scraper for the given products and
+// candle granularity in seconds
+func NewCoinbaseScraper(apiURL string, products []string, granularity int) *CoinbaseScraper {
+	return &CoinbaseScraper{
+		apiURL:      strings.TrimSuffix(apiURL, "/"),
+		products:    products,
+		granularity: granularity,
+		httpClient:  newHTTPClient(),
+		now:         time.Now,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *CoinbaseScraper) Name() string {
+	return "coinbase_exchange"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *CoinbaseScraper) Schedule() time.Duration {
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *CoinbaseScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if len(s.products) == 0 {
+		return fmt.Errorf("at least one product is required")
+	}
+	if !slices.Contains(coinbaseGranularities, s.granularity) {
+		return fmt.Errorf("unsupported candle granularity %d, expected one of %v", s.granularity, coinbaseGranularities)
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *CoinbaseScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// coinbaseTicker is the response of the product ticker endpoint
+type coinbaseTicker struct {
+	Price  string    `json:"price"`
+	Bid    string    `json:"bid"`
+	Ask    string    `json:"ask"`
+	Volume string    `json:"volume"`
+	Time   time.Time `json:"time"`
+}
+
+// Scrape collects the ticker and the latest closed candle of every configured product
+func (s *CoinbaseScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var points []TimeSeriesPoint
+	for _, product := range s.products {
+		tickerPoints, err := s.ticker(ctx, product)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch ticker for %s: %w", product, err)
+		}
+		points = append(points, tickerPoints...)
+
+		candlePoints, err := s.candle(ctx, product)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch candles for %s: %w", product, err)
+		}
+		points = append(points, candlePoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+		Metadata: map[string]string{
+			"granularity": strconv.Itoa(s.granularity),
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// ticker fetches the last trade price, best bid and ask and 24h volume of a product
+func (s *CoinbaseScraper) ticker(ctx context.Context, product string) ([]TimeSeriesPoint, error) {
+	var ticker coinbaseTicker
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/products/"+url.PathEscape(product)+"/ticker", nil, &ticker); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{"product": product}
+	fields := []struct {
+		metric string
+		raw    string
+		unit   string
+	}{
+		{"price", ticker.Price, "price"},
+		{"bid", ticker.Bid, "price"},
+		{"ask", ticker.Ask, "price"},
+		{"volume_24h", ticker.Volume, "base"},
+	}
+
+	points := make([]TimeSeriesPoint, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", field.metric, field.raw, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      slug(product) + "_" + field.metric,
+			Value:     value,
+			Unit:      field.unit,
+			Timestamp: ticker.Time.UTC(),
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
+
+// candle fetches the most recent closed candle of a product
+func (s *CoinbaseScraper) candle(ctx context.Context, product string) ([]TimeSeriesPoint, error) {
+	query := url.Values{}
+	query.Set("granularity", strconv.Itoa(s.granularity))
+
+	// Candles are returned newest first as [time, low, high, open, close, volume]
+	var candles [][6]float64
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/products/"+url.PathEscape(product)+"/candles?"+query.Encode(), nil, &candles); err != nil {
+		return nil, err
+	}
+
+	// The newest candle is still in progress until its interval has elapsed
+	now := s.now().Unix()
+	for _, candle := range candles {
+		start := int64(candle[0])
+		if start+int64(s.granularity) > now {
+			continue
+		}
+
+		timestamp := time.Unix(start, 0).UTC()
+		metadata := map[string]string{"product": product, "granularity": strconv.Itoa(s.granularity)}
+		point := func(metric string, value float64, unit string) TimeSeriesPoint {
+			return TimeSeriesPoint{
+				Code:      slug(product) + "_candle_" + metric,
+				Value:     value,
+				Unit:      unit,
+				Timestamp: timestamp,
+				Metadata:  metadata,
+			}
+		}
+
+		return []TimeSeriesPoint{
+			point("open", candle[3], "price"),
+			point("high", candle[2], "price"),
+			point("low", candle[1], "price"),
+			point("close", candle[4], "price"),
+			point("volume", candle[5], "base"),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoinbaseScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/BTC-USD/ticker":
+			_, _ = w.Write([]byte(`{"ask":"64010.5","bid":"64009.5","volume":"8500.25","trade_id":123,"price":"64010.00","size":"0.01","time":"2024-11-14T22:13:20.123Z"}`))
+		case "/products/BTC-USD/candles":
+			assert.Equal(t, "3600", r.URL.Query().Get("granularity"))
+			// The first candle is still in progress
+			_, _ = w.Write([]byte(`[[1731625200,63900,64100,64000,64010,120.5],[1731621600,63500,64050,63600,64000,410.75]]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewCoinbaseScraper(mockServer.URL, []string{"BTC-USD"}, 3600)
+	scraper.now = func() time.Time { return time.Unix(1731626000, 0) }
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "coinbase_exchange", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	timestamps := make(map[string]time.Time)
+	for _, point := range points {
+		values[point.Code] = point.Value
+		timestamps[point.Code] = point.Timestamp
+	}
+	assert.Equal(t, map[string]float64{
+		"btc_usd_price":         64010,
+		"btc_usd_bid":           64009.5,
+		"btc_usd_ask":           64010.5,
+		"btc_usd_volume_24h":    8500.25,
+		"btc_usd_candle_open":   63600,
+		"btc_usd_candle_high":   64050,
+		"btc_usd_candle_low":    63500,
+		"btc_usd_candle_close":  64000,
+		"btc_usd_candle_volume": 410.75,
+	}, values)
+	assert.Equal(t, time.Unix(1731621600, 0).UTC(), timestamps["btc_usd_candle_close"], "Should use the latest closed candle")
+	assert.Equal(t, time.Date(2024, 11, 14, 22, 13, 20, 123_000_000, time.UTC), timestamps["btc_usd_price"])
+}
+
+func TestCoinbaseScraper_Validate(t *testing.T) {
+	ctx := context.Background()
+	assert.Error(t, NewCoinbaseScraper("", DefaultCoinbaseProducts, DefaultCoinbaseGranularity).Validate(ctx))
+	assert.Error(t, NewCoinbaseScraper("https://api.exchange.coinbase.com", nil, DefaultCoinbaseGranularity).Validate(ctx))
+	assert.Error(t, NewCoinbaseScraper("https://api.exchange.coinbase.com", DefaultCoinbaseProducts, 120).Validate(ctx), "Unsupported granularity should be rejected")
+}
@@ -0,0 +1,174 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultCoinGeckoCoins lists the CoinGecko coin IDs tracked when none are configured
+var DefaultCoinGeckoCoins = []string{"bitcoin", "ethereum", "tether", "usd-coin"}
+
+const (
+	coinGeckoPublicURL = "https://api.coingecko.com"
+	coinGeckoProURL    = "https://pro-api.coingecko.com"
+)
+
+// CoinGeckoScraper implements the Scraper interface for CoinGecko market data
+type CoinGeckoScraper struct {
+	apiURL     string
+	apiKey     string
+	pro        bool
+	coins      []string
+	quota      QuotaLimits
+	httpClient *http.Client
+}
+
+// NewCoinGeckoScraper creates a new CoinGecko scraper; a pro API key switches to the pro API,
+// otherwise the key is used as a demo key against the public API limited to monthlyBudget calls
+// per month by its default quota
+func NewCoinGeckoScraper(apiKey string, pro bool, coins []string, monthlyBudget int) *CoinGeckoScraper {
+	apiURL := coinGeckoPublicURL
+	if pro {
+		apiURL = coinGeckoProURL
+	}
+
+	return &CoinGeckoScraper{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		pro:    pro,
+		coins:  coins,
+		// The free tier allows 30 calls per minute
+		quota:      QuotaLimits{PerMinute: 30, PerMonth: monthlyBudget},
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *CoinGeckoScraper) Name() string {
+	return "coingecko_markets"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *CoinGeckoScraper) Schedule() time.Duration {
+	// Keeps a scrape every 15 minutes below 6000 calls per month
+	return 15 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *CoinGeckoScraper) Validate(ctx context.Context) error {
+	if len(s.coins) == 0 {
+		return fmt.Errorf("at least one coin is required")
+	}
+	if s.pro && s.apiKey == "" {
+		return fmt.Errorf("API key is required for the pro API")
+	}
+	return nil
+}
+
+// RequiresAPIKey reports whether the scraper cannot run without an API key, which is the case
+// for the pro API
+func (s *CoinGeckoScraper) RequiresAPIKey() bool {
+	return s.pro
+}
+
+// HasAPIKey reports whether the scraper has an API key
+func (s *CoinGeckoScraper) HasAPIKey() bool {
+	return s.apiKey != ""
+}
+
+// DefaultQuota returns the quota of the CoinGecko plan, it applies when the section of the scraper
+// sets no quota
+func (s *CoinGeckoScraper) DefaultQuota() QuotaLimits {
+	return s.quota
+}
+
+// Init performs any necessary initialization
+func (s *CoinGeckoScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// coinGeckoMarket is an entry of the coins markets endpoint
+type coinGeckoMarket struct {
+	ID           string    `json:"id"`
+	Symbol       string    `json:"symbol"`
+	CurrentPrice float64   `json:"current_price"`
+	MarketCap    float64   `json:"market_cap"`
+	TotalVolume  float64   `json:"total_volume"`
+	LastUpdated  time.Time `json:"last_updated"`
+}
+
+// coinGeckoGlobal is the response of the global endpoint
+type coinGeckoGlobal struct {
+	Data struct {
+		MarketCapPercentage map[string]float64 `json:"market_cap_percentage"`
+		UpdatedAt           int64              `json:"updated_at"`
+	} `json:"data"`
+}
+
+// Scrape collects price, market cap, 24h volume and dominance of the configured coins
+func (s *CoinGeckoScraper) Scrape(ctx context.Context) ([]Result, error) {
+	header := http.Header{}
+	switch {
+	case s.pro:
+		header.Set("x-cg-pro-api-key", s.apiKey)
+	case s.apiKey != "":
+		header.Set("x-cg-demo-api-key", s.apiKey)
+	}
+
+	query := url.Values{}
+	query.Set("vs_currency", "usd")
+	query.Set("ids", strings.Join(s.coins, ","))
+
+	var markets []coinGeckoMarket
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v3/coins/markets?"+query.Encode(), header, &markets); err != nil {
+		return nil, fmt.Errorf("failed to fetch markets: %w", err)
+	}
+
+	var global coinGeckoGlobal
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v3/global", header, &global); err != nil {
+		return nil, fmt.Errorf("failed to fetch global market data: %w", err)
+	}
+
+	var points []TimeSeriesPoint
+	for _, market := range markets {
+		metadata := map[string]string{"coin": market.ID}
+		point := func(metric string, value float64, unit string) TimeSeriesPoint {
+			return TimeSeriesPoint{
+				Code:      slug(market.ID) + "_" + metric,
+				Value:     value,
+				Unit:      unit,
+				Timestamp: market.LastUpdated,
+				Metadata:  metadata,
+			}
+		}
+
+		points = append(points,
+			point("price", market.CurrentPrice, "USD"),
+			point("market_cap", market.MarketCap, "USD"),
+			point("volume_24h", market.TotalVolume, "USD"),
+		)
+
+		if dominance, ok := global.Data.MarketCapPercentage[strings.ToLower(market.Symbol)]; ok {
+			points = append(points, TimeSeriesPoint{
+				Code:      slug(market.ID) + "_dominance",
+				Value:     dominance,
+				Unit:      "percent",
+				Timestamp: time.Unix(global.Data.UpdatedAt, 0).UTC(),
+				Metadata:  metadata,
+			})
+		}
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
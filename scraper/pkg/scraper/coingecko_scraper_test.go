@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoinGeckoScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "demo-key", r.Header.Get("x-cg-demo-api-key"), "Demo API key should be sent")
+
+		switch r.URL.Path {
+		case "/api/v3/coins/markets":
+			assert.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+			assert.Equal(t, "bitcoin,ethereum", r.URL.Query().Get("ids"))
+			_, _ = w.Write([]byte(`[
+				{"id":"bitcoin","symbol":"btc","current_price":83500,"market_cap":1657000000000,"total_volume":32000000000,"last_updated":"2025-04-04T10:15:21.123Z"},
+				{"id":"ethereum","symbol":"eth","current_price":1805.12,"market_cap":217000000000,"total_volume":15000000000,"last_updated":"2025-04-04T10:15:25.456Z"}
+			]`))
+		case "/api/v3/global":
+			_, _ = w.Write([]byte(`{"data":{"market_cap_percentage":{"btc":61.2,"eth":8.1,"usdt":5.4},"updated_at":1743761700}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewCoinGeckoScraper("demo-key", false, []string{"bitcoin", "ethereum"}, 10_000)
+	scraper.apiURL = mockServer.URL
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "coingecko_markets", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"bitcoin_price":       83500,
+		"bitcoin_market_cap":  1_657_000_000_000,
+		"bitcoin_volume_24h":  32_000_000_000,
+		"bitcoin_dominance":   61.2,
+		"ethereum_price":      1805.12,
+		"ethereum_market_cap": 217_000_000_000,
+		"ethereum_volume_24h": 15_000_000_000,
+		"ethereum_dominance":  8.1,
+	}, values)
+	assert.Equal(t, time.Date(2025, 4, 4, 10, 15, 21, 123_000_000, time.UTC), points[0].Timestamp)
+}
+
+func TestCoinGeckoScraper_Quota(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/api/v3/coins/markets" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewCoinGeckoScraper("", false, DefaultCoinGeckoCoins, 3)
+	scraper.apiURL = mockServer.URL
+	assert.Equal(t, QuotaLimits{PerMinute: 30, PerMonth: 3}, scraper.DefaultQuota())
+	ctx := WithQuota(context.Background(), NewQuota(scraper.Name(), scraper.DefaultQuota(), nil))
+
+	_, err := scraper.Scrape(ctx)
+	require.NoError(t, err)
+	_, err = scraper.Scrape(ctx)
+	assert.ErrorIs(t, err, ErrQuotaExhausted, "Scrape should fail once the monthly quota is exhausted")
+	assert.Equal(t, 3, requests, "No request should be sent beyond the quota")
+}
+
+func TestCoinGeckoScraper_Validate(t *testing.T) {
+	assert.Error(t, NewCoinGeckoScraper("", true, DefaultCoinGeckoCoins, 0).Validate(context.Background()), "Pro API requires a key")
+	assert.NoError(t, NewCoinGeckoScraper("", false, DefaultCoinGeckoCoins, 0).Validate(context.Background()))
+}
@@ -0,0 +1,171 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultCompoundMarkets lists the Compound v3 (Comet) markets on Ethereum mainnet
+var DefaultCompoundMarkets = []string{
+	"USDC:0xc3d688B66703497DAA19211EEdff47f25384cdc3",
+	"WETH:0xA17581A9E3356d9A858b789D68B4d866e593aE94",
+	"USDT:0x3Afdc9BCA9213A35503b077a6072F3D0d5AB0840",
+}
+
+// CompoundScraper implements the Scraper interface for Compound v3 market rates
+type CompoundScraper struct {
+	rpcURL   string
+	markets  []Contract
+	client   *ethclient.Client
+	decimals map[string]uint8
+}
+
+// NewCompoundScraper creates a new Compound v3 scraper for the given Comet markets
+func NewCompoundScraper(rpcURL string, markets []Contract) *CompoundScraper {
+	return &CompoundScraper{
+		rpcURL:   rpcURL,
+		markets:  markets,
+		decimals: make(map[string]uint8),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *CompoundScraper) Name() string {
+	return "compound_v3_markets"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *CompoundScraper) Schedule() time.Duration {
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *CompoundScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if len(s.markets) == 0 {
+		return fmt.Errorf("at least one market is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *CompoundScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape collects supply/borrow APY, utilization and totals of every configured market
+func (s *CompoundScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	now := time.Now()
+	var points []TimeSeriesPoint
+	for _, market := range s.markets {
+		marketPoints, err := s.scrapeMarket(ctx, market, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape market %s: %w", market.Label, err)
+		}
+		points = append(points, marketPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapeMarket reads the rates and totals of a single Comet market
+func (s *CompoundScraper) scrapeMarket(ctx context.Context, market Contract, now time.Time) ([]TimeSeriesPoint, error) {
+	decimals, err := s.baseDecimals(ctx, market)
+	if err != nil {
+		return nil, err
+	}
+
+	utilization, err := s.callUint(ctx, market, "getUtilization()")
+	if err != nil {
+		return nil, err
+	}
+	// Rates are per second and depend on the current utilization
+	supplyRate, err := s.callUint(ctx, market, "getSupplyRate(uint256)", utilization.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	borrowRate, err := s.callUint(ctx, market, "getBorrowRate(uint256)", utilization.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	totalSupply, err := s.callUint(ctx, market, "totalSupply()")
+	if err != nil {
+		return nil, err
+	}
+	totalBorrow, err := s.callUint(ctx, market, "totalBorrow()")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{"comet": market.Address.Hex()}
+	point := func(metric string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      slug(market.Label) + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: now,
+			Metadata:  metadata,
+		}
+	}
+
+	return []TimeSeriesPoint{
+		point("supply_apy", aprToAPY(weiToFloat(supplyRate, weiPerEth)*secondsPerYear)*100, "percent"),
+		point("borrow_apy", aprToAPY(weiToFloat(borrowRate, weiPerEth)*secondsPerYear)*100, "percent"),
+		point("utilization", weiToFloat(utilization, weiPerEth)*100, "percent"),
+		point("total_supplied", scaleDecimals(totalSupply, decimals), market.Label),
+		point("total_borrowed", scaleDecimals(totalBorrow, decimals), market.Label),
+	}, nil
+}
+
+// callUint calls a market method returning a single unsigned integer
+func (s *CompoundScraper) callUint(ctx context.Context, market Contract, signature string, args ...[]byte) (*big.Int, error) {
+	out, err := callContract(ctx, s.client, market.Address, signature, args...)
+	if err != nil {
+		return nil, err
+	}
+	value, err := wordAt(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", signature, err)
+	}
+	return value, nil
+}
+
+// baseDecimals returns the decimals of the market base asset, which never change and are cached
+func (s *CompoundScraper) baseDecimals(ctx context.Context, market Contract) (uint8, error) {
+	if decimals, ok := s.decimals[market.Label]; ok {
+		return decimals, nil
+	}
+
+	value, err := s.callUint(ctx, market, "decimals()")
+	if err != nil {
+		return 0, err
+	}
+	if value.BitLen() > 8 {
+		return 0, fmt.Errorf("invalid decimals for %s", market.Label)
+	}
+
+	decimals := uint8(value.Uint64())
+	s.decimals[market.Label] = decimals
+	return decimals, nil
+}
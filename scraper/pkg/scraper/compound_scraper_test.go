@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompoundScraper_Scrape(t *testing.T) {
+	markets, err := ParseContracts(DefaultCompoundMarkets[:1])
+	require.NoError(t, err)
+
+	utilization := big.NewInt(900_000_000_000_000_000) // 90%
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			switch call.Selector() {
+			case selector("decimals()"):
+				return abiWords(big.NewInt(6)), nil
+			case selector("getUtilization()"):
+				return abiWords(utilization), nil
+			case selector("getSupplyRate(uint256)"):
+				assert.Equal(t, abiWords(utilization), call.Input[4:], "Rates should be requested at the current utilization")
+				return abiWords(big.NewInt(1_268_391_679)), nil // ~4% APR
+			case selector("getBorrowRate(uint256)"):
+				return abiWords(big.NewInt(1_585_489_599)), nil // ~5% APR
+			case selector("totalSupply()"):
+				return abiWords(big.NewInt(500_000_000_000_000)), nil
+			case selector("totalBorrow()"):
+				return abiWords(big.NewInt(450_000_000_000_000)), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	scraper := NewCompoundScraper(server.URL, markets)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "compound_v3_markets", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.InDelta(t, 4.0811, values["usdc_supply_apy"], 1e-3)
+	assert.InDelta(t, 5.1271, values["usdc_borrow_apy"], 1e-3)
+	assert.InDelta(t, 90.0, values["usdc_utilization"], 1e-9)
+	assert.Equal(t, 500_000_000.0, values["usdc_total_supplied"])
+	assert.Equal(t, 450_000_000.0, values["usdc_total_borrowed"])
+}
@@ -0,0 +1,337 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// EventFilter selects the logs of a single event emitted by a contract
+type EventFilter struct {
+	Label   string
+	Address common.Address
+	Event   abi.Event
+}
+
+// ContractEvent represents a decoded contract event log
+type ContractEvent struct {
+	Label       string            `json:"label"`
+	Contract    string            `json:"contract"`
+	Event       string            `json:"event"`
+	BlockNumber uint64            `json:"block_number"`
+	TxHash      string            `json:"tx_hash"`
+	LogIndex    uint              `json:"log_index"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Fields      map[string]string `json:"fields"`
+}
+
+// noBlock is the cursor of a scraper that has not scanned any block, it starts at the most recent blocks
+const noBlock = math.MaxUint64
+
+// ContractLogScraper implements the Scraper interface for configurable contract event logs. The
+// last scanned block is kept in the configured CursorStore, so a restarted scraper resumes after it
+type ContractLogScraper struct {
+	rpcURL    string
+	filters   []EventFilter
+	client    *ethclient.Client
+	maxBlocks uint64
+	// lastBlock is the last scanned block, noBlock before the first scan
+	lastBlock uint64
+	cursors   CursorStore
+}
+
+// NewContractLogScraper creates a new event log scraper starting at the given block, or at the
+// most recent blocks when startBlock is zero. A saved cursor takes precedence over startBlock
+func NewContractLogScraper(rpcURL string, filters []EventFilter, startBlock uint64) *ContractLogScraper {
+	lastBlock := uint64(noBlock)
+	if startBlock > 0 {
+		lastBlock = startBlock - 1
+	}
+
+	return &ContractLogScraper{
+		rpcURL:    rpcURL,
+		filters:   filters,
+		maxBlocks: 1000,
+		lastBlock: lastBlock,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *ContractLogScraper) Name() string {
+	return "contract_logs"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *ContractLogScraper) Schedule() time.Duration {
+	return 5 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *ContractLogScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if len(s.filters) == 0 {
+		return fmt.Errorf("at least one event filter is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint and resumes from the saved cursor. An unavailable
+// cursor store is logged, the scan then starts at the configured block
+func (s *ContractLogScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+
+	if s.cursors == nil {
+		s.cursors = configuredCursorStore()
+	}
+	lastBlock, ok, err := s.cursors.Load(ctx, s.Name())
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load contract log cursor", "error", err)
+	} else if ok {
+		s.lastBlock = lastBlock
+	}
+	return nil
+}
+
+// Scrape fetches and decodes the logs emitted since the previous scrape
+func (s *ContractLogScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	latest, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block number: %w", err)
+	}
+
+	// Continue from the cursor, catching up at most maxBlocks per scrape
+	from := s.lastBlock + 1
+	if s.lastBlock == noBlock {
+		from = latest - min(latest, s.maxBlocks) + 1
+	}
+	if from > latest {
+		return nil, nil
+	}
+	to := min(latest, from+s.maxBlocks-1)
+
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+	}
+	var eventIDs []common.Hash
+	for _, filter := range s.filters {
+		query.Addresses = append(query.Addresses, filter.Address)
+		eventIDs = append(eventIDs, filter.Event.ID)
+	}
+	query.Topics = [][]common.Hash{eventIDs}
+
+	logs, err := s.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logs for blocks %d-%d: %w", from, to, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var events []ContractEvent
+	for _, log := range logs {
+		filter, ok := s.match(log)
+		if !ok || log.Removed {
+			continue
+		}
+
+		fields, err := decodeLog(filter.Event, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s log in tx %s: %w", filter.Label, log.TxHash.Hex(), err)
+		}
+
+		timestamp, ok := blockTimes[log.BlockNumber]
+		if !ok {
+			header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(log.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", log.BlockNumber, err)
+			}
+			timestamp = time.Unix(int64(header.Time), 0).UTC()
+			blockTimes[log.BlockNumber] = timestamp
+		}
+
+		events = append(events, ContractEvent{
+			Label:       filter.Label,
+			Contract:    log.Address.Hex(),
+			Event:       filter.Event.Name,
+			BlockNumber: log.BlockNumber,
+			TxHash:      log.TxHash.Hex(),
+			LogIndex:    log.Index,
+			Timestamp:   timestamp,
+			Fields:      fields,
+		})
+	}
+	s.lastBlock = to
+	if err := s.cursors.Save(ctx, s.Name(), to); err != nil {
+		slog.WarnContext(ctx, "Failed to save contract log cursor", "block", to, "error", err)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      events,
+		Metadata: map[string]string{
+			"from_block": strconv.FormatUint(from, 10),
+			"to_block":   strconv.FormatUint(to, 10),
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// match returns the filter a log belongs to
+func (s *ContractLogScraper) match(log types.Log) (EventFilter, bool) {
+	if len(log.Topics) == 0 {
+		return EventFilter{}, false
+	}
+	for _, filter := range s.filters {
+		if filter.Address == log.Address && filter.Event.ID == log.Topics[0] {
+			return filter, true
+		}
+	}
+	return EventFilter{}, false
+}
+
+// decodeLog decodes the indexed and non-indexed arguments of a log
+func decodeLog(event abi.Event, log types.Log) (map[string]string, error) {
+	values := make(map[string]any)
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(values, log.Data); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(values))
+	for name, value := range values {
+		fields[name] = formatABIValue(value)
+	}
+	return fields, nil
+}
+
+// formatABIValue renders a decoded ABI value as a string
+func formatABIValue(value any) string {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ParseEventFilters parses a ";" separated list of "label:address:signature" entries,
+// e.g. "usdc_transfers:0xA0b8...:Transfer(address indexed from, address indexed to, uint256 value)"
+func ParseEventFilters(spec string) ([]EventFilter, error) {
+	var filters []EventFilter
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid event filter %q, expected label:address:signature", entry)
+		}
+		if !common.IsHexAddress(parts[1]) {
+			return nil, fmt.Errorf("invalid address for event filter %q: %s", parts[0], parts[1])
+		}
+
+		event, err := parseEventSignature(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature for event filter %q: %w", parts[0], err)
+		}
+
+		filters = append(filters, EventFilter{
+			Label:   parts[0],
+			Address: common.HexToAddress(parts[1]),
+			Event:   event,
+		})
+	}
+	return filters, nil
+}
+
+// parseEventSignature parses a human readable event signature such as
+// "Transfer(address indexed from, address indexed to, uint256 value)"
+func parseEventSignature(signature string) (abi.Event, error) {
+	signature = strings.TrimSpace(signature)
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return abi.Event{}, fmt.Errorf("malformed signature %q", signature)
+	}
+	name := signature[:open]
+	params := strings.TrimSpace(signature[open+1 : len(signature)-1])
+
+	var inputs abi.Arguments
+	names := make(map[string]bool)
+	if params != "" {
+		for i, param := range strings.Split(params, ",") {
+			fields := strings.Fields(param)
+			if len(fields) == 0 {
+				return abi.Event{}, fmt.Errorf("empty parameter in %q", signature)
+			}
+
+			typ, err := abi.NewType(fields[0], "", nil)
+			if err != nil {
+				return abi.Event{}, fmt.Errorf("unsupported parameter type %q: %w", fields[0], err)
+			}
+			arg := abi.Argument{Type: typ}
+
+			rest := fields[1:]
+			if len(rest) > 0 && rest[0] == "indexed" {
+				arg.Indexed = true
+				rest = rest[1:]
+			}
+			if len(rest) > 1 {
+				return abi.Event{}, fmt.Errorf("malformed parameter %q", param)
+			}
+			// Positional parameters are named by their position so their values do not collide
+			arg.Name = fmt.Sprintf("arg%d", i)
+			if len(rest) == 1 {
+				arg.Name = rest[0]
+			}
+			if names[arg.Name] {
+				return abi.Event{}, fmt.Errorf("duplicate parameter %q in %q", arg.Name, signature)
+			}
+			names[arg.Name] = true
+			inputs = append(inputs, arg)
+		}
+	}
+
+	return abi.NewEvent(name, name, false, inputs), nil
+}
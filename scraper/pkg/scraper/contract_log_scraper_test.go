@@ -0,0 +1,174 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usdcTransferFilter = "usdc_transfers:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:Transfer(address indexed from, address indexed to, uint256 value)"
+
+func TestContractLogScraper_Scrape(t *testing.T) {
+	filters, err := ParseEventFilters(usdcTransferFilter)
+	require.NoError(t, err)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transfer := types.Log{
+		Address:     filters[0].Address,
+		Topics:      []common.Hash{filters[0].Event.ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        abiWords(big.NewInt(2_500_000)),
+		BlockNumber: 200,
+		TxHash:      common.HexToHash("0xabc"),
+		Index:       3,
+	}
+
+	var requestedRange [2]hexutil.Uint64
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_blockNumber": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(200), nil
+		},
+		"eth_getLogs": func(params []json.RawMessage) (any, error) {
+			var query struct {
+				FromBlock hexutil.Uint64 `json:"fromBlock"`
+				ToBlock   hexutil.Uint64 `json:"toBlock"`
+			}
+			if err := json.Unmarshal(params[0], &query); err != nil {
+				return nil, err
+			}
+			requestedRange = [2]hexutil.Uint64{query.FromBlock, query.ToBlock}
+			return []types.Log{transfer}, nil
+		},
+		"eth_getBlockByNumber": func(params []json.RawMessage) (any, error) {
+			return &types.Header{Number: big.NewInt(200), Difficulty: big.NewInt(0), Time: 1_700_000_000}, nil
+		},
+	})
+
+	scraper := NewContractLogScraper(server.URL, filters, 150)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, [2]hexutil.Uint64{150, 200}, requestedRange, "Should query from the start block to the latest block")
+
+	events, ok := results[0].Data.([]ContractEvent)
+	require.True(t, ok, "Result data should be of type []ContractEvent")
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "usdc_transfers", event.Label)
+	assert.Equal(t, "Transfer", event.Event)
+	assert.Equal(t, uint64(200), event.BlockNumber)
+	assert.Equal(t, uint(3), event.LogIndex)
+	assert.Equal(t, time.Unix(1_700_000_000, 0).UTC(), event.Timestamp)
+	assert.Equal(t, map[string]string{
+		"from":  from.Hex(),
+		"to":    to.Hex(),
+		"value": "2500000",
+	}, event.Fields)
+
+	// The cursor has reached the latest block
+	results, err = scraper.Scrape(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestContractLogScraper_Cursor(t *testing.T) {
+	filters, err := ParseEventFilters(usdcTransferFilter)
+	require.NoError(t, err)
+
+	var requestedRange [2]hexutil.Uint64
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_blockNumber": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(200), nil
+		},
+		"eth_getLogs": func(params []json.RawMessage) (any, error) {
+			var query struct {
+				FromBlock hexutil.Uint64 `json:"fromBlock"`
+				ToBlock   hexutil.Uint64 `json:"toBlock"`
+			}
+			if err := json.Unmarshal(params[0], &query); err != nil {
+				return nil, err
+			}
+			requestedRange = [2]hexutil.Uint64{query.FromBlock, query.ToBlock}
+			return []types.Log{}, nil
+		},
+	})
+	ctx := context.Background()
+
+	// Block 1 is a start block like any other, not the most recent blocks
+	scraper := NewContractLogScraper(server.URL, filters, 1)
+	scraper.cursors = NewMemoryCursorStore()
+	require.NoError(t, scraper.Init(ctx))
+	_, err = scraper.Scrape(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [2]hexutil.Uint64{1, 200}, requestedRange)
+
+	// A restarted scraper resumes after the saved cursor instead of the start block
+	store := NewMemoryCursorStore()
+	require.NoError(t, store.Save(ctx, "contract_logs", 180))
+	scraper = NewContractLogScraper(server.URL, filters, 1)
+	scraper.cursors = store
+	require.NoError(t, scraper.Init(ctx))
+	_, err = scraper.Scrape(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [2]hexutil.Uint64{181, 200}, requestedRange)
+
+	cursor, ok, err := store.Load(ctx, "contract_logs")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(200), cursor, "The scanned range should be saved")
+}
+
+func TestDecodeLog_PositionalArguments(t *testing.T) {
+	filters, err := ParseEventFilters("pairs:0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640:Pair(address indexed, uint256, uint256 amount, uint256)")
+	require.NoError(t, err)
+
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	fields, err := decodeLog(filters[0].Event, types.Log{
+		Topics: []common.Hash{filters[0].Event.ID, common.BytesToHash(owner.Bytes())},
+		Data:   abiWords(big.NewInt(1), big.NewInt(2), big.NewInt(3)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"arg0":   owner.Hex(),
+		"arg1":   "1",
+		"amount": "2",
+		"arg3":   "3",
+	}, fields, "Unnamed arguments should be named by their position")
+}
+
+func TestParseEventFilters(t *testing.T) {
+	filters, err := ParseEventFilters(usdcTransferFilter + "; pool_swaps:0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640:Swap(address indexed, address indexed, int256, int256, uint160, uint128, int24)")
+	require.NoError(t, err)
+	require.Len(t, filters, 2)
+
+	assert.Equal(t, "Transfer(address,address,uint256)", filters[0].Event.Sig)
+	assert.Equal(t, common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"), filters[0].Event.ID)
+	assert.Equal(t, "Swap(address,address,int256,int256,uint160,uint128,int24)", filters[1].Event.Sig)
+	assert.Equal(t, "arg0", filters[1].Event.Inputs[0].Name, "Unnamed parameters should get generated names")
+
+	invalid := []string{
+		"missing_signature:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		"bad_address:0x123:Transfer(address,address,uint256)",
+		"bad_type:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:Transfer(addr from)",
+		"bad_signature:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:Transfer",
+		"duplicate_name:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:Transfer(address indexed from, address indexed from, uint256 value)",
+		"positional_collision:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48:Transfer(address indexed arg1, address indexed, uint256 value)",
+	}
+	for _, spec := range invalid {
+		_, err := ParseEventFilters(spec)
+		assert.Error(t, err, "Filter '%s' should cause an error", spec)
+	}
+}
@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Contract is a labeled smart contract address
+type Contract struct {
+	Label   string
+	Address common.Address
+}
+
+// ParseContracts parses a list of "label:address" entries
+func ParseContracts(entries []string) ([]Contract, error) {
+	contracts := make([]Contract, 0, len(entries))
+	for _, entry := range entries {
+		label, address, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || label == "" {
+			return nil, fmt.Errorf("invalid contract entry %q, expected label:address", entry)
+		}
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address for contract %q: %s", label, address)
+		}
+		contracts = append(contracts, Contract{Label: label, Address: common.HexToAddress(address)})
+	}
+	return contracts, nil
+}
+
+// callContract calls a read-only contract method at the latest block
+func callContract(ctx context.Context, client *ethclient.Client, address common.Address, signature string, args ...[]byte) ([]byte, error) {
+	data := methodSelector(signature)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg, 32)...)
+	}
+
+	out, err := client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s on %s: %w", signature, address.Hex(), err)
+	}
+	return out, nil
+}
+
+// methodSelector returns the 4-byte selector of a method signature
+func methodSelector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// wordAt returns the n-th 32-byte word of an ABI encoded return value
+func wordAt(out []byte, n int) (*big.Int, error) {
+	if len(out) < (n+1)*32 {
+		return nil, fmt.Errorf("return value too short: %d bytes", len(out))
+	}
+	return new(big.Int).SetBytes(out[n*32 : (n+1)*32]), nil
+}
+
+// scaleDecimals converts a fixed point integer with the given decimals to a float
+func scaleDecimals(value *big.Int, decimals uint8) float64 {
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	result, _ := new(big.Float).Quo(new(big.Float).SetInt(value), new(big.Float).SetInt(divisor)).Float64()
+	return result
+}
@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractCall is the decoded argument of a mocked eth_call
+type contractCall struct {
+	To    common.Address
+	Input []byte
+}
+
+// Selector returns the 4-byte method selector of the call
+func (c contractCall) Selector() string {
+	return hexutil.Encode(c.Input[:4])
+}
+
+// ethCallHandler mocks eth_call by dispatching decoded calls to fn
+func ethCallHandler(fn func(call contractCall) ([]byte, error)) rpcHandler {
+	return func(params []json.RawMessage) (any, error) {
+		var arg struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		if err := json.Unmarshal(params[0], &arg); err != nil {
+			return nil, err
+		}
+		out, err := fn(contractCall{To: arg.To, Input: arg.Input})
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Bytes(out), nil
+	}
+}
+
+// selector returns the hex encoded selector of a method signature
+func selector(signature string) string {
+	return hexutil.Encode(methodSelector(signature))
+}
+
+// abiWords encodes integers as consecutive 32-byte ABI words
+func abiWords(values ...*big.Int) []byte {
+	var out []byte
+	for _, value := range values {
+		out = append(out, common.LeftPadBytes(value.Bytes(), 32)...)
+	}
+	return out
+}
+
+func TestParseContracts(t *testing.T) {
+	contracts, err := ParseContracts([]string{"USDT:0xdAC17F958D2ee523a2206206994597C13D831ec7", " DAI:0x6B175474E89094C44Da98b954EedeAC495271d0F "})
+	require.NoError(t, err)
+	require.Len(t, contracts, 2)
+	assert.Equal(t, "USDT", contracts[0].Label)
+	assert.Equal(t, common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), contracts[0].Address)
+	assert.Equal(t, "DAI", contracts[1].Label)
+
+	_, err = ParseContracts([]string{"0xdAC17F958D2ee523a2206206994597C13D831ec7"})
+	assert.Error(t, err, "Entry without label should cause an error")
+
+	_, err = ParseContracts([]string{"USDT:invalid"})
+	assert.Error(t, err, "Invalid address should cause an error")
+}
+
+func TestScaleDecimals(t *testing.T) {
+	assert.Equal(t, 1.5, scaleDecimals(big.NewInt(1_500_000), 6))
+	assert.Equal(t, 2.0, scaleDecimals(new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18)), 18))
+}
@@ -0,0 +1,24 @@
+package scraper
+
+// Credential is the API key of a data source
+type Credential struct {
+	Key string `mapstructure:"key"`
+}
+
+// Credentialed is implemented by scrapers that authenticate with an API key, so missing
+// credentials can be reported at startup
+type Credentialed interface {
+	// RequiresAPIKey reports whether the scraper cannot run without an API key
+	RequiresAPIKey() bool
+	// HasAPIKey reports whether the scraper has an API key
+	HasAPIKey() bool
+}
+
+// WithCredential returns the settings with the key of credential when they have none, so the API
+// key of a scraper section takes precedence over the registry
+func (s Settings) WithCredential(credential Credential) Settings {
+	if s.APIKey == "" {
+		s.APIKey = credential.Key
+	}
+	return s
+}
@@ -0,0 +1,31 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsWithCredential(t *testing.T) {
+	settings := Settings{}.WithCredential(Credential{Key: "registry-key"})
+	assert.Equal(t, "registry-key", settings.APIKeyOr("legacy-key"))
+
+	settings = Settings{APIKey: "section-key"}.WithCredential(Credential{Key: "registry-key"})
+	assert.Equal(t, "section-key", settings.APIKey, "The key of the scraper section takes precedence")
+
+	settings = Settings{}.WithCredential(Credential{})
+	assert.Equal(t, "legacy-key", settings.APIKeyOr("legacy-key"))
+}
+
+func TestCredentialed(t *testing.T) {
+	var etherscan Credentialed = NewEtherscanScraper("https://api.etherscan.io", "")
+	assert.True(t, etherscan.RequiresAPIKey())
+	assert.False(t, etherscan.HasAPIKey())
+
+	var beacon Credentialed = NewBeaconScraper("https://beaconcha.in", "key")
+	assert.False(t, beacon.RequiresAPIKey())
+	assert.True(t, beacon.HasAPIKey())
+
+	assert.True(t, NewCoinGeckoScraper("", true, []string{"bitcoin"}, 0).RequiresAPIKey())
+	assert.False(t, NewCoinGeckoScraper("", false, []string{"bitcoin"}, 0).RequiresAPIKey())
+}
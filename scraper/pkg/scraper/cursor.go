@@ -0,0 +1,66 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// CursorStore keeps the positions scrapers resume from, e.g. the last block scanned for logs. A
+// store outliving the process, like RedisCursorStore, lets scrapers resume after a restart
+type CursorStore interface {
+	// Load returns the cursor of key, false when none was saved
+	Load(ctx context.Context, key string) (uint64, bool, error)
+	// Save stores the cursor of key
+	Save(ctx context.Context, key string, cursor uint64) error
+}
+
+var (
+	cursorMu sync.RWMutex
+	// cursorStore keeps the cursors of the scrapers, nil keeps them in memory until
+	// SetCursorStore is called
+	cursorStore CursorStore
+)
+
+// SetCursorStore configures the store of the cursors of the scrapers initialized afterwards
+func SetCursorStore(store CursorStore) {
+	cursorMu.Lock()
+	defer cursorMu.Unlock()
+	cursorStore = store
+}
+
+// configuredCursorStore returns the configured cursor store, or a new memory store
+func configuredCursorStore() CursorStore {
+	cursorMu.RLock()
+	defer cursorMu.RUnlock()
+	if cursorStore == nil {
+		return NewMemoryCursorStore()
+	}
+	return cursorStore
+}
+
+// MemoryCursorStore keeps cursors in memory, they start over when the process restarts
+type MemoryCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]uint64
+}
+
+// NewMemoryCursorStore creates an empty store
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{cursors: make(map[string]uint64)}
+}
+
+// Load returns the cursor of key
+func (s *MemoryCursorStore) Load(ctx context.Context, key string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursor, ok := s.cursors[key]
+	return cursor, ok, nil
+}
+
+// Save stores the cursor of key
+func (s *MemoryCursorStore) Save(ctx context.Context, key string, cursor uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[key] = cursor
+	return nil
+}
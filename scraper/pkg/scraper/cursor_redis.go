@@ -0,0 +1,47 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisCursorStore keeps the cursors of the scrapers in Redis, so they survive restarts and
+// replacements of the scraper
+type RedisCursorStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCursorStore creates a store of the cursors under the keys starting with prefix, the
+// store closes client on Close
+func NewRedisCursorStore(client *redis.Client, prefix string) *RedisCursorStore {
+	return &RedisCursorStore{client: client, prefix: prefix}
+}
+
+// Load returns the cursor of key
+func (s *RedisCursorStore) Load(ctx context.Context, key string) (uint64, bool, error) {
+	cursor, err := s.client.Get(ctx, s.prefix+key).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read cursor of %s: %w", key, err)
+	}
+	return cursor, true, nil
+}
+
+// Save stores the cursor of key without expiry
+func (s *RedisCursorStore) Save(ctx context.Context, key string, cursor uint64) error {
+	if err := s.client.Set(ctx, s.prefix+key, cursor, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save cursor of %s: %w", key, err)
+	}
+	return nil
+}
+
+// Close closes the Redis client of the store
+func (s *RedisCursorStore) Close() error {
+	return s.client.Close()
+}
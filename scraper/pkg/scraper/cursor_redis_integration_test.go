@@ -0,0 +1,45 @@
+//go:build integration
+// +build integration
+
+package scraper
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisCursorStoreIntegration(t *testing.T) {
+	host, port := "localhost", 6379
+	if value, ok := os.LookupEnv("REDIS_HOST"); ok {
+		host = value
+	}
+	if value, ok := os.LookupEnv("REDIS_PORT"); ok {
+		var err error
+		port, err = strconv.Atoi(value)
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+	client, err := queue.NewRedisClient(host, port, queue.RedisConnection{})
+	require.NoError(t, err)
+	store := NewRedisCursorStore(client, "test:cursor:")
+	defer store.Close()
+	defer client.Del(ctx, "test:cursor:contract_logs")
+
+	_, ok, err := store.Load(ctx, "contract_logs")
+	require.NoError(t, err)
+	assert.False(t, ok, "No cursor was saved yet")
+
+	require.NoError(t, store.Save(ctx, "contract_logs", 19_000_000))
+	cursor, ok, err := store.Load(ctx, "contract_logs")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(19_000_000), cursor)
+}
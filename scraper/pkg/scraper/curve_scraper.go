@@ -0,0 +1,251 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultCurvePools lists the major Curve stableswap pools on Ethereum mainnet
+var DefaultCurvePools = []string{
+	"3pool:0xbEBc44782C7dB0a1A60Cb6fe97d0b483032FF1C7",
+	"steth:0xDC24316b9AE028F1497c275EB9192a3Ea0f67022",
+}
+
+// curveMaxCoins is the largest number of coins a Curve pool can hold
+const curveMaxCoins = 8
+
+// curveNativeETH is the placeholder address Curve uses for native ETH
+var curveNativeETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+// curveCoin is a token held by a Curve pool
+type curveCoin struct {
+	Symbol   string
+	Address  common.Address
+	Decimals uint8
+}
+
+// CurveScraper implements the Scraper interface for Curve stableswap pools
+type CurveScraper struct {
+	rpcURL string
+	pools  []Contract
+	client *ethclient.Client
+	coins  map[string][]curveCoin
+}
+
+// NewCurveScraper creates a new Curve scraper for the given pools
+func NewCurveScraper(rpcURL string, pools []Contract) *CurveScraper {
+	return &CurveScraper{
+		rpcURL: rpcURL,
+		pools:  pools,
+		coins:  make(map[string][]curveCoin),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *CurveScraper) Name() string {
+	return "curve_pools"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *CurveScraper) Schedule() time.Duration {
+	// Peg deviations are a stress indicator and should be observed frequently
+	return 15 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *CurveScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if len(s.pools) == 0 {
+		return fmt.Errorf("at least one pool is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *CurveScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape collects the virtual price, balances and peg deviations of every configured pool
+func (s *CurveScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	now := time.Now()
+	var points []TimeSeriesPoint
+	for _, pool := range s.pools {
+		poolPoints, err := s.scrapePool(ctx, pool, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape pool %s: %w", pool.Label, err)
+		}
+		points = append(points, poolPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapePool reads the state of a single pool
+func (s *CurveScraper) scrapePool(ctx context.Context, pool Contract, now time.Time) ([]TimeSeriesPoint, error) {
+	coins, err := s.poolCoins(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{"pool": pool.Address.Hex()}
+	point := func(metric string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      slug(pool.Label) + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: now,
+			Metadata:  metadata,
+		}
+	}
+
+	out, err := callContract(ctx, s.client, pool.Address, "get_virtual_price()")
+	if err != nil {
+		return nil, err
+	}
+	virtualPrice, err := wordAt(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode virtual price: %w", err)
+	}
+	points := []TimeSeriesPoint{point("virtual_price", weiToFloat(virtualPrice, weiPerEth), "ratio")}
+
+	balances := make([]float64, len(coins))
+	var total float64
+	for i, coin := range coins {
+		out, err := callContract(ctx, s.client, pool.Address, "balances(uint256)", big.NewInt(int64(i)).Bytes())
+		if err != nil {
+			return nil, err
+		}
+		balance, err := wordAt(out, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode balance of %s: %w", coin.Symbol, err)
+		}
+		balances[i] = scaleDecimals(balance, coin.Decimals)
+		total += balances[i]
+	}
+
+	for i, coin := range coins {
+		points = append(points, point(slug(coin.Symbol)+"_balance", balances[i], coin.Symbol))
+		if total > 0 {
+			points = append(points, point(slug(coin.Symbol)+"_share", balances[i]/total*100, "percent"))
+		}
+	}
+
+	// Peg deviation is the price of one unit of each coin quoted in the first coin of the pool
+	base := coins[0]
+	for i, coin := range coins[1:] {
+		unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(coin.Decimals)), nil)
+		out, err := callContract(ctx, s.client, pool.Address, "get_dy(int128,int128,uint256)",
+			big.NewInt(int64(i+1)).Bytes(), big.NewInt(0).Bytes(), unit.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		dy, err := wordAt(out, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode price of %s: %w", coin.Symbol, err)
+		}
+
+		price := scaleDecimals(dy, base.Decimals)
+		points = append(points,
+			point(slug(coin.Symbol)+"_price", price, base.Symbol),
+			point(slug(coin.Symbol)+"_peg_deviation", (price-1)*100, "percent"),
+		)
+	}
+
+	return points, nil
+}
+
+// poolCoins discovers the coins of a pool, which never change and are cached
+func (s *CurveScraper) poolCoins(ctx context.Context, pool Contract) ([]curveCoin, error) {
+	if coins, ok := s.coins[pool.Label]; ok {
+		return coins, nil
+	}
+
+	var coins []curveCoin
+	for i := 0; i < curveMaxCoins; i++ {
+		// coins(i) reverts past the last coin of the pool
+		out, err := callContract(ctx, s.client, pool.Address, "coins(uint256)", big.NewInt(int64(i)).Bytes())
+		if err != nil {
+			break
+		}
+		word, err := wordAt(out, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode coin %d: %w", i, err)
+		}
+
+		coin, err := s.coinInfo(ctx, common.BigToAddress(word))
+		if err != nil {
+			return nil, err
+		}
+		coins = append(coins, coin)
+	}
+	if len(coins) < 2 {
+		return nil, fmt.Errorf("failed to discover pool coins")
+	}
+
+	s.coins[pool.Label] = coins
+	return coins, nil
+}
+
+// coinInfo reads the symbol and decimals of a pool coin
+func (s *CurveScraper) coinInfo(ctx context.Context, address common.Address) (curveCoin, error) {
+	if address == curveNativeETH {
+		return curveCoin{Symbol: "ETH", Address: address, Decimals: 18}, nil
+	}
+
+	out, err := callContract(ctx, s.client, address, "decimals()")
+	if err != nil {
+		return curveCoin{}, err
+	}
+	decimals, err := wordAt(out, 0)
+	if err != nil || decimals.BitLen() > 8 {
+		return curveCoin{}, fmt.Errorf("invalid decimals for coin %s", address.Hex())
+	}
+
+	out, err = callContract(ctx, s.client, address, "symbol()")
+	if err != nil {
+		return curveCoin{}, err
+	}
+	symbol, err := decodeString(out)
+	if err != nil {
+		return curveCoin{}, fmt.Errorf("invalid symbol for coin %s: %w", address.Hex(), err)
+	}
+
+	return curveCoin{Symbol: symbol, Address: address, Decimals: uint8(decimals.Uint64())}, nil
+}
+
+// decodeString decodes an ABI encoded string return value
+func decodeString(out []byte) (string, error) {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		return "", err
+	}
+	values, err := abi.Arguments{{Type: stringType}}.Unpack(out)
+	if err != nil {
+		return "", err
+	}
+	return values[0].(string), nil
+}
@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// abiString ABI encodes a string return value
+func abiString(t *testing.T, value string) []byte {
+	t.Helper()
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	out, err := abi.Arguments{{Type: stringType}}.Pack(value)
+	require.NoError(t, err)
+	return out
+}
+
+func TestCurveScraper_Scrape(t *testing.T) {
+	pools, err := ParseContracts(DefaultCurvePools[:1])
+	require.NoError(t, err)
+
+	type coin struct {
+		address  common.Address
+		symbol   string
+		decimals int64
+		balance  *big.Int
+		dy       *big.Int
+	}
+	coins := []coin{
+		{common.HexToAddress("0x01"), "DAI", 18, new(big.Int).Mul(big.NewInt(50_000_000), big.NewInt(1e18)), nil},
+		{common.HexToAddress("0x02"), "USDC", 6, big.NewInt(30_000_000_000_000), big.NewInt(999_500_000_000_000_000)},
+		{common.HexToAddress("0x03"), "USDT", 6, big.NewInt(20_000_000_000_000), big.NewInt(1_002_000_000_000_000_000)},
+	}
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			if call.To == pools[0].Address {
+				var index int
+				if len(call.Input) >= 36 {
+					index = int(new(big.Int).SetBytes(call.Input[4:36]).Int64())
+				}
+				switch call.Selector() {
+				case selector("coins(uint256)"):
+					if index >= len(coins) {
+						return nil, fmt.Errorf("execution reverted")
+					}
+					return common.LeftPadBytes(coins[index].address.Bytes(), 32), nil
+				case selector("balances(uint256)"):
+					return abiWords(coins[index].balance), nil
+				case selector("get_virtual_price()"):
+					return abiWords(big.NewInt(1_040_000_000_000_000_000)), nil
+				case selector("get_dy(int128,int128,uint256)"):
+					return abiWords(coins[index].dy), nil
+				}
+			}
+			for _, coin := range coins {
+				if call.To != coin.address {
+					continue
+				}
+				switch call.Selector() {
+				case selector("decimals()"):
+					return abiWords(big.NewInt(coin.decimals)), nil
+				case selector("symbol()"):
+					return abiString(t, coin.symbol), nil
+				}
+			}
+			return nil, fmt.Errorf("unexpected call %s to %s", call.Selector(), call.To.Hex())
+		}),
+	})
+
+	scraper := NewCurveScraper(server.URL, pools)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "curve_pools", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.InDelta(t, 1.04, values["3pool_virtual_price"], 1e-12)
+	assert.Equal(t, 50_000_000.0, values["3pool_dai_balance"])
+	assert.Equal(t, 30_000_000.0, values["3pool_usdc_balance"])
+	assert.InDelta(t, 50.0, values["3pool_dai_share"], 1e-9)
+	assert.InDelta(t, 20.0, values["3pool_usdt_share"], 1e-9)
+	assert.InDelta(t, 0.9995, values["3pool_usdc_price"], 1e-12)
+	assert.InDelta(t, -0.05, values["3pool_usdc_peg_deviation"], 1e-9)
+	assert.InDelta(t, 0.2, values["3pool_usdt_peg_deviation"], 1e-9)
+	assert.NotContains(t, values, "3pool_dai_peg_deviation", "The quote coin has no peg deviation")
+}
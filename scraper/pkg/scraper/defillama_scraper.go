@@ -0,0 +1,142 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDefiLlamaProtocols lists the protocols tracked when none are configured
+var DefaultDefiLlamaProtocols = []string{"aave", "lido", "uniswap", "makerdao", "eigenlayer"}
+
+// DefiLlamaScraper implements the Scraper interface for DefiLlama TVL data
+type DefiLlamaScraper struct {
+	apiURL     string
+	protocols  []string
+	httpClient *http.Client
+}
+
+// NewDefiLlamaScraper creates a new DefiLlama scraper for the given protocol slugs
+func NewDefiLlamaScraper(apiURL string, protocols []string) *DefiLlamaScraper {
+	return &DefiLlamaScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		protocols:  protocols,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *DefiLlamaScraper) Name() string {
+	return "defillama_tvl"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *DefiLlamaScraper) Schedule() time.Duration {
+	// TVL is tracked as a daily series
+	return 24 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *DefiLlamaScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *DefiLlamaScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// defiLlamaChain is an entry of the chains endpoint
+type defiLlamaChain struct {
+	Name string  `json:"name"`
+	TVL  float64 `json:"tvl"`
+}
+
+// defiLlamaHistoricalTVL is an entry of the historical chain TVL endpoint
+type defiLlamaHistoricalTVL struct {
+	Date int64   `json:"date"`
+	TVL  float64 `json:"tvl"`
+}
+
+// Scrape collects the total, per-chain and per-protocol TVL
+func (s *DefiLlamaScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var history []defiLlamaHistoricalTVL
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/v2/historicalChainTvl", nil, &history); err != nil {
+		return nil, fmt.Errorf("failed to fetch total TVL: %w", err)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("total TVL history is empty")
+	}
+
+	var chains []defiLlamaChain
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/v2/chains", nil, &chains); err != nil {
+		return nil, fmt.Errorf("failed to fetch chain TVL: %w", err)
+	}
+
+	// The total is the latest entry of its history, the chain and protocol endpoints return the
+	// current TVL, which is stamped with the day it was fetched
+	latest := history[len(history)-1]
+	historyDay := time.Unix(latest.Date, 0).UTC().Truncate(24 * time.Hour)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	point := func(code string, value float64, day time.Time) TimeSeriesPoint {
+		return TimeSeriesPoint{Code: code, Value: value, Unit: "USD", Timestamp: day}
+	}
+
+	points := []TimeSeriesPoint{point("total_tvl", latest.TVL, historyDay)}
+	for _, chain := range chains {
+		if chain.TVL <= 0 {
+			continue
+		}
+		p := point("chain_tvl_"+slug(chain.Name), chain.TVL, today)
+		p.Metadata = map[string]string{"chain": chain.Name}
+		points = append(points, p)
+	}
+
+	for _, protocol := range s.protocols {
+		body, err := fetch(ctx, s.httpClient, s.apiURL+"/tvl/"+url.PathEscape(protocol), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch TVL of protocol %s: %w", protocol, err)
+		}
+		tvl, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TVL of protocol %s: %w", protocol, err)
+		}
+
+		p := point("protocol_tvl_"+slug(protocol), tvl, today)
+		p.Metadata = map[string]string{"protocol": protocol}
+		points = append(points, p)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+		Metadata: map[string]string{
+			"url": s.apiURL,
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// slug converts a display name to a lowercase series code fragment
+func slug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefiLlamaScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/historicalChainTvl":
+			_, _ = w.Write([]byte(`[{"date":1743638400,"tvl":95000000000},{"date":1743724800,"tvl":96500000000}]`))
+		case "/v2/chains":
+			_, _ = w.Write([]byte(`[{"gecko_id":"ethereum","tvl":52000000000,"tokenSymbol":"ETH","name":"Ethereum"},{"name":"BSC","tvl":5400000000},{"name":"Dead Chain","tvl":0}]`))
+		case "/tvl/aave":
+			_, _ = w.Write([]byte(`18500000000.5`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewDefiLlamaScraper(mockServer.URL, []string{"aave"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "defillama_tvl", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	// The total comes from the history, the chain and protocol TVL are current values
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+		assert.Equal(t, "USD", point.Unit)
+		if point.Code == "total_tvl" {
+			assert.Equal(t, time.Date(2025, 4, 4, 0, 0, 0, 0, time.UTC), point.Timestamp)
+		} else {
+			assert.Equal(t, today, point.Timestamp, "%s should be stamped with the day it was fetched", point.Code)
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"total_tvl":          96_500_000_000,
+		"chain_tvl_ethereum": 52_000_000_000,
+		"chain_tvl_bsc":      5_400_000_000,
+		"protocol_tvl_aave":  18_500_000_000.5,
+	}, values, "Chains without TVL should be skipped")
+}
+
+func TestDefiLlamaScraper_UnknownProtocol(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/historicalChainTvl":
+			_, _ = w.Write([]byte(`[{"date":1743724800,"tvl":96500000000}]`))
+		case "/v2/chains":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer mockServer.Close()
+
+	_, err := NewDefiLlamaScraper(mockServer.URL, []string{"unknown"}).Scrape(context.Background())
+	assert.Error(t, err, "Unknown protocols should cause an error")
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "ethereum", slug("Ethereum"))
+	assert.Equal(t, "op_mainnet", slug("OP Mainnet"))
+	assert.Equal(t, "zksync_era", slug(" zkSync-Era "))
+}
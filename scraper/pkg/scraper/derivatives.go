@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDerivativesAssets lists the assets whose perpetuals are tracked when none are configured
+var DefaultDerivativesAssets = []string{"BTC", "ETH"}
+
+// Default public API endpoints of the derivatives venues
+const (
+	DefaultBinanceFuturesAPIURL = "https://fapi.binance.com"
+	DefaultBybitAPIURL          = "https://api.bybit.com"
+	DefaultOKXAPIURL            = "https://www.okx.com"
+	DefaultDeribitAPIURL        = "https://www.deribit.com"
+)
+
+// DerivativesVenues holds the public API endpoints of the derivatives venues,
+// a venue with an empty URL is skipped
+type DerivativesVenues struct {
+	BinanceURL string
+	BybitURL   string
+	OKXURL     string
+	// DeribitURL is only used for options, Deribit perpetuals are not tracked
+	DeribitURL string
+}
+
+// derivativesVenue is a single venue and the instrument naming it uses for USDT-margined perpetuals
+type derivativesVenue struct {
+	name   string
+	apiURL string
+	symbol func(asset string) string
+}
+
+// list returns the configured perpetual venues
+func (v DerivativesVenues) list() []derivativesVenue {
+	venues := []derivativesVenue{
+		{name: "binance", apiURL: v.BinanceURL, symbol: func(asset string) string { return asset + "USDT" }},
+		{name: "bybit", apiURL: v.BybitURL, symbol: func(asset string) string { return asset + "USDT" }},
+		{name: "okx", apiURL: v.OKXURL, symbol: func(asset string) string { return asset + "-USDT-SWAP" }},
+	}
+
+	var configured []derivativesVenue
+	for _, venue := range venues {
+		if venue.apiURL != "" {
+			venue.apiURL = strings.TrimSuffix(venue.apiURL, "/")
+			configured = append(configured, venue)
+		}
+	}
+	return configured
+}
+
+// bybitResponse is the envelope of every Bybit v5 API response
+type bybitResponse[T any] struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []T `json:"list"`
+	} `json:"result"`
+}
+
+// fetchBybit fetches a Bybit v5 list endpoint and returns its first entry
+func fetchBybit[T any](ctx context.Context, client *http.Client, url string) (T, error) {
+	var resp bybitResponse[T]
+	var zero T
+	if err := fetchJSON(ctx, client, url, nil, &resp); err != nil {
+		return zero, err
+	}
+	if resp.RetCode != 0 {
+		return zero, fmt.Errorf("bybit API error %d: %s", resp.RetCode, resp.RetMsg)
+	}
+	if len(resp.Result.List) == 0 {
+		return zero, fmt.Errorf("empty response")
+	}
+	return resp.Result.List[0], nil
+}
+
+// okxResponse is the envelope of every OKX v5 API response
+type okxResponse[T any] struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []T    `json:"data"`
+}
+
+// fetchOKX fetches an OKX v5 endpoint and returns its first entry
+func fetchOKX[T any](ctx context.Context, client *http.Client, url string) (T, error) {
+	var resp okxResponse[T]
+	var zero T
+	if err := fetchJSON(ctx, client, url, nil, &resp); err != nil {
+		return zero, err
+	}
+	if resp.Code != "0" {
+		return zero, fmt.Errorf("okx API error %s: %s", resp.Code, resp.Msg)
+	}
+	if len(resp.Data) == 0 {
+		return zero, fmt.Errorf("empty response")
+	}
+	return resp.Data[0], nil
+}
+
+// parseMillis parses a millisecond unix timestamp sent as a string
+func parseMillis(raw string) (time.Time, error) {
+	millis, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", raw, err)
+	}
+	return time.UnixMilli(millis).UTC(), nil
+}
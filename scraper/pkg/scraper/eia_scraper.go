@@ -0,0 +1,210 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// DefaultEIASeries lists the EIA series tracked when none are configured
+var DefaultEIASeries = []string{
+	"wti:petroleum/pri/spt:RWTC",
+	"brent:petroleum/pri/spt:RBRTE",
+	"henry_hub:natural-gas/pri/fut:RNGWHHD",
+}
+
+// eiaRecentDays is the number of most recent observations fetched per series, so missed days are backfilled
+const eiaRecentDays = 5
+
+// EIASeries is a labeled daily series of the EIA open data API
+type EIASeries struct {
+	Label  string
+	Route  string
+	Series string
+}
+
+// ParseEIASeries parses a list of "label:route:series" entries, e.g. "wti:petroleum/pri/spt:RWTC"
+func ParseEIASeries(entries []string) ([]EIASeries, error) {
+	series := make([]EIASeries, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid series entry %q, expected label:route:series", entry)
+		}
+		series = append(series, EIASeries{Label: parts[0], Route: strings.Trim(parts[1], "/"), Series: parts[2]})
+	}
+	return series, nil
+}
+
+// EIAScraper implements the Scraper interface for EIA energy spot prices
+type EIAScraper struct {
+	apiURL     string
+	apiKey     string
+	series     []EIASeries
+	httpClient *http.Client
+}
+
+// NewEIAScraper creates a new EIA scraper for the given series
+func NewEIAScraper(apiURL, apiKey string, series []EIASeries) *EIAScraper {
+	return &EIAScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		apiKey:     apiKey,
+		series:     series,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *EIAScraper) Name() string {
+	return "eia_energy_prices"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *EIAScraper) Schedule() time.Duration {
+	// Spot prices are published daily with a lag of a few days
+	return 6 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *EIAScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if s.apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	if len(s.series) == 0 {
+		return fmt.Errorf("at least one series is required")
+	}
+	return nil
+}
+
+// RequiresAPIKey reports whether the scraper cannot run without an API key
+func (s *EIAScraper) RequiresAPIKey() bool {
+	return true
+}
+
+// HasAPIKey reports whether the scraper has an API key
+func (s *EIAScraper) HasAPIKey() bool {
+	return s.apiKey != ""
+}
+
+// Init performs any necessary initialization
+func (s *EIAScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Series returns the configured spot prices, their units are only known once fetched
+func (s *EIAScraper) Series() []SeriesInfo {
+	series := make([]SeriesInfo, 0, len(s.series))
+	for _, configured := range s.series {
+		series = append(series, SeriesInfo{
+			Code:        slug(configured.Label),
+			Description: fmt.Sprintf("EIA %s spot price (%s %s)", configured.Label, configured.Route, configured.Series),
+			Frequency:   FrequencyBusinessDay,
+			Country:     "US",
+		})
+	}
+	return series
+}
+
+// eiaValue is a numeric value the EIA API returns either as a number or a string
+type eiaValue float64
+
+// UnmarshalJSON accepts numbers and numeric strings
+func (v *eiaValue) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseFloat(string(bytes.Trim(data, `"`)), 64)
+	if err != nil {
+		return fmt.Errorf("invalid value %s: %w", data, err)
+	}
+	*v = eiaValue(value)
+	return nil
+}
+
+// eiaResponse is the response of an EIA v2 data endpoint
+type eiaResponse struct {
+	Response struct {
+		Data []struct {
+			Period string    `json:"period"`
+			Series string    `json:"series"`
+			Value  *eiaValue `json:"value"`
+			Units  string    `json:"units"`
+		} `json:"data"`
+	} `json:"response"`
+	Error string `json:"error"`
+}
+
+// Scrape collects the most recent daily observations of every configured series
+func (s *EIAScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var points []TimeSeriesPoint
+	for _, series := range s.series {
+		seriesPoints, err := s.fetchSeries(ctx, series)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", series.Series, err)
+		}
+		points = append(points, seriesPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// fetchSeries fetches the most recent daily observations of a series
+func (s *EIAScraper) fetchSeries(ctx context.Context, series EIASeries) ([]TimeSeriesPoint, error) {
+	query := url.Values{}
+	query.Set("api_key", s.apiKey)
+	query.Set("frequency", "daily")
+	query.Set("data[0]", "value")
+	query.Set("facets[series][]", series.Series)
+	query.Set("sort[0][column]", "period")
+	query.Set("sort[0][direction]", "desc")
+	query.Set("length", strconv.Itoa(eiaRecentDays))
+
+	body, err := fetch(ctx, s.httpClient, s.apiURL+"/v2/"+series.Route+"/data/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp eiaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse response: %w", errclass.ErrParse, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("EIA API error: %s", resp.Error)
+	}
+
+	metadata := map[string]string{"series": series.Series}
+	points := make([]TimeSeriesPoint, 0, len(resp.Response.Data))
+	for _, observation := range resp.Response.Data {
+		// Observations without a value are published before the price is known
+		if observation.Value == nil {
+			continue
+		}
+		date, err := parseDate(observation.Period)
+		if err != nil {
+			return nil, fmt.Errorf("invalid period %q: %w", observation.Period, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      slug(series.Label),
+			Value:     float64(*observation.Value),
+			Unit:      observation.Units,
+			Timestamp: date,
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
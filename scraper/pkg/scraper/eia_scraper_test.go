@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEIAScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "test-key", query.Get("api_key"))
+		assert.Equal(t, "daily", query.Get("frequency"))
+
+		switch r.URL.Path {
+		case "/v2/petroleum/pri/spt/data/":
+			assert.Equal(t, "RWTC", query.Get("facets[series][]"))
+			_, _ = w.Write([]byte(`{"response":{"total":2,"frequency":"daily","data":[
+				{"period":"2024-11-12","series":"RWTC","series-description":"Cushing, OK WTI Spot Price FOB (Dollars per Barrel)","value":"68.12","units":"$/BBL"},
+				{"period":"2024-11-11","series":"RWTC","series-description":"Cushing, OK WTI Spot Price FOB (Dollars per Barrel)","value":68.04,"units":"$/BBL"}
+			]}}`))
+		case "/v2/natural-gas/pri/fut/data/":
+			_, _ = w.Write([]byte(`{"response":{"total":2,"data":[
+				{"period":"2024-11-12","series":"RNGWHHD","value":null,"units":"$/MMBTU"},
+				{"period":"2024-11-11","series":"RNGWHHD","value":2.21,"units":"$/MMBTU"}
+			]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	series, err := ParseEIASeries([]string{"wti:petroleum/pri/spt:RWTC", "henry_hub:natural-gas/pri/fut:RNGWHHD"})
+	require.NoError(t, err)
+
+	scraper := NewEIAScraper(mockServer.URL, "test-key", series)
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "eia_energy_prices", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 3, "Should skip observations without a value")
+
+	assert.Equal(t, "wti", points[0].Code)
+	assert.Equal(t, 68.12, points[0].Value, "String values should be parsed")
+	assert.Equal(t, "$/BBL", points[0].Unit)
+	assert.Equal(t, time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC), points[0].Timestamp)
+	assert.Equal(t, 68.04, points[1].Value)
+	assert.Equal(t, "henry_hub", points[2].Code)
+	assert.Equal(t, 2.21, points[2].Value)
+}
+
+func TestEIAScraper_APIError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"error":"Invalid facet 'series' with value 'NOPE'","code":400}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewEIAScraper(mockServer.URL, "test-key", []EIASeries{{Label: "bad", Route: "petroleum/pri/spt", Series: "NOPE"}}).Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid facet")
+}
+
+func TestEIAScraper_Validate(t *testing.T) {
+	series, err := ParseEIASeries(DefaultEIASeries)
+	require.NoError(t, err)
+	assert.Error(t, NewEIAScraper("https://api.eia.gov", "", series).Validate(context.Background()), "Missing API key should cause an error")
+
+	_, err = ParseEIASeries([]string{"wti:RWTC"})
+	assert.Error(t, err, "Entry without route should cause an error")
+}
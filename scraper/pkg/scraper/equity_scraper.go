@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEquityIndices lists the equity indices tracked when none are configured
+var DefaultEquityIndices = []string{"sp500:^GSPC", "smi:^SSMI", "dax:^GDAXI"}
+
+// yahooUserAgent is sent with every request, Yahoo rejects requests with the default Go user agent
+const yahooUserAgent = "Mozilla/5.0 (compatible; macrochain-scraper)"
+
+// EquityIndex is a labeled Yahoo Finance index symbol
+type EquityIndex struct {
+	Label  string
+	Symbol string
+}
+
+// ParseEquityIndices parses a list of "label:symbol" entries
+func ParseEquityIndices(entries []string) ([]EquityIndex, error) {
+	indices := make([]EquityIndex, 0, len(entries))
+	for _, entry := range entries {
+		label, symbol, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || label == "" || symbol == "" {
+			return nil, fmt.Errorf("invalid index entry %q, expected label:symbol", entry)
+		}
+		indices = append(indices, EquityIndex{Label: label, Symbol: symbol})
+	}
+	return indices, nil
+}
+
+// EquityScraper implements the Scraper interface for daily equity index closes from Yahoo Finance
+type EquityScraper struct {
+	apiURL     string
+	indices    []EquityIndex
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewEquityScraper creates a new equity index scraper for the given indices
+func NewEquityScraper(apiURL string, indices []EquityIndex) *EquityScraper {
+	return &EquityScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		indices:    indices,
+		httpClient: newHTTPClient(),
+		now:        time.Now,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *EquityScraper) Name() string {
+	return "equity_indices"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *EquityScraper) Schedule() time.Duration {
+	// Closes are final once the session ends, hourly catches every market's close
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *EquityScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if len(s.indices) == 0 {
+		return fmt.Errorf("at least one index is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *EquityScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// yahooChart is the response of the Yahoo Finance chart endpoint
+type yahooChart struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Currency             string `json:"currency"`
+				Symbol               string `json:"symbol"`
+				GMTOffset            int64  `json:"gmtoffset"`
+				CurrentTradingPeriod struct {
+					Regular struct {
+						Start int64 `json:"start"`
+						End   int64 `json:"end"`
+					} `json:"regular"`
+				} `json:"currentTradingPeriod"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Close []*float64 `json:"close"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// Scrape collects the daily closes of the last sessions of every configured index
+func (s *EquityScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var points []TimeSeriesPoint
+	for _, index := range s.indices {
+		indexPoints, err := s.closes(ctx, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", index.Symbol, err)
+		}
+		points = append(points, indexPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// closes fetches the daily closes of an index, skipping the session still in progress
+func (s *EquityScraper) closes(ctx context.Context, index EquityIndex) ([]TimeSeriesPoint, error) {
+	query := url.Values{}
+	query.Set("range", "5d")
+	query.Set("interval", "1d")
+
+	header := http.Header{}
+	header.Set("User-Agent", yahooUserAgent)
+
+	var chart yahooChart
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/v8/finance/chart/"+url.PathEscape(index.Symbol)+"?"+query.Encode(), header, &chart); err != nil {
+		return nil, err
+	}
+	if chart.Chart.Error != nil {
+		return nil, fmt.Errorf("yahoo API error %s: %s", chart.Chart.Error.Code, chart.Chart.Error.Description)
+	}
+	if len(chart.Chart.Result) == 0 || len(chart.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, fmt.Errorf("empty chart")
+	}
+
+	data := chart.Chart.Result[0]
+	closes := data.Indicators.Quote[0].Close
+	session := data.Meta.CurrentTradingPeriod.Regular
+	now := s.now().Unix()
+	metadata := map[string]string{"symbol": index.Symbol}
+
+	var points []TimeSeriesPoint
+	for i, ts := range data.Timestamp {
+		// Holidays and missing sessions are reported as null
+		if i >= len(closes) || closes[i] == nil {
+			continue
+		}
+		if ts >= session.Start && now < session.End {
+			continue
+		}
+
+		// Bars are stamped with the session open, the date is taken in exchange time
+		local := time.Unix(ts+data.Meta.GMTOffset, 0).UTC()
+		points = append(points, TimeSeriesPoint{
+			Code:      slug(index.Label) + "_close",
+			Value:     *closes[i],
+			Unit:      data.Meta.Currency,
+			Timestamp: time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC),
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEquityScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v8/finance/chart/^SSMI" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "1d", r.URL.Query().Get("interval"))
+		assert.Equal(t, yahooUserAgent, r.Header.Get("User-Agent"))
+		// Sessions open at 08:00 UTC, the last one is still trading
+		_, _ = w.Write([]byte(`{"chart":{"result":[{
+			"meta":{"currency":"CHF","symbol":"^SSMI","gmtoffset":3600,"currentTradingPeriod":{"regular":{"start":1731571200,"end":1731602400}}},
+			"timestamp":[1731398400,1731484800,1731571200],
+			"indicators":{"quote":[{"close":[11950.5,null,12010.25]}]}
+		}],"error":null}}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewEquityScraper(mockServer.URL, []EquityIndex{{Label: "smi", Symbol: "^SSMI"}})
+	scraper.now = func() time.Time { return time.Unix(1731580000, 0) }
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "equity_indices", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 1, "Should skip missing and in-progress sessions")
+	assert.Equal(t, "smi_close", points[0].Code)
+	assert.Equal(t, 11950.5, points[0].Value)
+	assert.Equal(t, "CHF", points[0].Unit)
+	assert.Equal(t, time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC), points[0].Timestamp)
+
+	// Once the session has closed its close is final
+	scraper.now = func() time.Time { return time.Unix(1731610000, 0) }
+	results, err = scraper.Scrape(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, results[0].Data, 2)
+}
+
+func TestEquityScraper_APIError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewEquityScraper(mockServer.URL, []EquityIndex{{Label: "bad", Symbol: "^BAD"}}).Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "symbol may be delisted")
+}
+
+func TestParseEquityIndices(t *testing.T) {
+	indices, err := ParseEquityIndices(DefaultEquityIndices)
+	require.NoError(t, err)
+	assert.Equal(t, EquityIndex{Label: "sp500", Symbol: "^GSPC"}, indices[0])
+
+	_, err = ParseEquityIndices([]string{"^GSPC"})
+	assert.Error(t, err, "Entry without label should cause an error")
+}
@@ -0,0 +1,168 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	weiPerGwei = 1e9
+	weiPerEth  = 1e18
+	gweiPerEth = 1e9
+)
+
+// EthereumScraper implements the Scraper interface for Ethereum block and gas metrics
+type EthereumScraper struct {
+	rpcURL    string
+	client    *ethclient.Client
+	maxBlocks uint64
+	lastBlock uint64
+}
+
+// NewEthereumScraper creates a new Ethereum block scraper for the given RPC endpoint
+func NewEthereumScraper(rpcURL string) *EthereumScraper {
+	return &EthereumScraper{
+		rpcURL:    rpcURL,
+		maxBlocks: 50,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *EthereumScraper) Name() string {
+	return "ethereum_blocks"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *EthereumScraper) Schedule() time.Duration {
+	// A new block is produced every 12 seconds, blocks are collected in batches
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *EthereumScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *EthereumScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape collects metrics for every block produced since the previous scrape
+func (s *EthereumScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	latest, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block number: %w", err)
+	}
+
+	// Start after the last processed block, capped to the most recent blocks
+	from := s.lastBlock + 1
+	if s.lastBlock == 0 || latest-s.lastBlock > s.maxBlocks {
+		from = latest - min(latest, s.maxBlocks) + 1
+	}
+	if from > latest {
+		return nil, nil
+	}
+
+	// The parent header is needed to compute the block time of the first block
+	parent, err := s.header(ctx, from-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []TimeSeriesPoint
+	for number := from; number <= latest; number++ {
+		header, err := s.header(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, blockMetrics(header, parent)...)
+		parent = header
+	}
+	s.lastBlock = latest
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+		Metadata: map[string]string{
+			"from_block": strconv.FormatUint(from, 10),
+			"to_block":   strconv.FormatUint(latest, 10),
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// header fetches a single block header
+func (s *EthereumScraper) header(ctx context.Context, number uint64) (*types.Header, error) {
+	header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block %d: %w", number, err)
+	}
+	return header, nil
+}
+
+// blockMetrics derives the gas and timing metrics of a block
+func blockMetrics(header, parent *types.Header) []TimeSeriesPoint {
+	timestamp := time.Unix(int64(header.Time), 0).UTC()
+	metadata := map[string]string{
+		"block": header.Number.String(),
+	}
+
+	point := func(code string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      code,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		}
+	}
+
+	var gasUsedRatio float64
+	if header.GasLimit > 0 {
+		gasUsedRatio = float64(header.GasUsed) / float64(header.GasLimit)
+	}
+
+	points := []TimeSeriesPoint{
+		point("gas_used_ratio", gasUsedRatio, "ratio"),
+		point("block_time", float64(header.Time-parent.Time), "seconds"),
+	}
+
+	// Blocks before London do not have a base fee
+	if header.BaseFee != nil {
+		burned := new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(header.GasUsed))
+		points = append(points,
+			point("base_fee", weiToFloat(header.BaseFee, weiPerGwei), "gwei"),
+			point("burned_eth", weiToFloat(burned, weiPerEth), "ETH"),
+		)
+	}
+
+	return points
+}
+
+// weiToFloat converts a wei amount to a float in the given denomination
+func weiToFloat(wei *big.Int, denomination float64) float64 {
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(denomination)).Float64()
+	return value
+}
@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcHandler answers a single JSON-RPC method call
+type rpcHandler func(params []json.RawMessage) (any, error)
+
+// newRPCServer starts a mock JSON-RPC server dispatching on method name
+func newRPCServer(t *testing.T, handlers map[string]rpcHandler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		handler, ok := handlers[req.Method]
+		if !ok {
+			resp["error"] = map[string]any{"code": -32601, "message": "method not found: " + req.Method}
+		} else if result, err := handler(req.Params); err != nil {
+			resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEthereumScraper_Scrape(t *testing.T) {
+	headers := map[uint64]*types.Header{}
+	for number := uint64(98); number <= 100; number++ {
+		headers[number] = &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: big.NewInt(0),
+			GasLimit:   30_000_000,
+			GasUsed:    15_000_000,
+			Time:       1_700_000_000 + number*12,
+			BaseFee:    big.NewInt(20_000_000_000), // 20 gwei
+		}
+	}
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_blockNumber": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(100), nil
+		},
+		"eth_getBlockByNumber": func(params []json.RawMessage) (any, error) {
+			var number hexutil.Uint64
+			if err := json.Unmarshal(params[0], &number); err != nil {
+				return nil, err
+			}
+			return headers[uint64(number)], nil
+		},
+	})
+
+	scraper := NewEthereumScraper(server.URL)
+	scraper.maxBlocks = 2
+
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "ethereum_blocks", results[0].Source)
+	assert.Equal(t, "99", results[0].Metadata["from_block"])
+	assert.Equal(t, "100", results[0].Metadata["to_block"])
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 8, "Should return 4 metrics for each of the 2 blocks")
+
+	expected := map[string]float64{
+		"gas_used_ratio": 0.5,
+		"block_time":     12,
+		"base_fee":       20,
+		"burned_eth":     0.3,
+	}
+	for _, point := range points {
+		assert.InDelta(t, expected[point.Code], point.Value, 1e-9, "Metric %s should have correct value", point.Code)
+	}
+	assert.Equal(t, time.Unix(1_700_000_000+99*12, 0).UTC(), points[0].Timestamp)
+
+	// A second scrape without new blocks should not emit anything
+	results, err = scraper.Scrape(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestEthereumScraper_Validate(t *testing.T) {
+	assert.Error(t, NewEthereumScraper("").Validate(context.Background()))
+}
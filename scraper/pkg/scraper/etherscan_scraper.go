@@ -0,0 +1,159 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// etherscanMaxAttempts is the number of attempts made when Etherscan rate limits a request
+const etherscanMaxAttempts = 3
+
+// EtherscanScraper implements the Scraper interface for the Etherscan gas oracle
+type EtherscanScraper struct {
+	apiURL     string
+	apiKey     string
+	backoff    time.Duration
+	httpClient *http.Client
+}
+
+// NewEtherscanScraper creates a new Etherscan gas oracle scraper
+func NewEtherscanScraper(apiURL, apiKey string) *EtherscanScraper {
+	return &EtherscanScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		apiKey:     apiKey,
+		backoff:    1 * time.Second,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *EtherscanScraper) Name() string {
+	return "etherscan_gas_oracle"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *EtherscanScraper) Schedule() time.Duration {
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *EtherscanScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if s.apiKey == "" {
+		return fmt.Errorf("API key is required")
+	}
+	return nil
+}
+
+// RequiresAPIKey reports whether the scraper cannot run without an API key
+func (s *EtherscanScraper) RequiresAPIKey() bool {
+	return true
+}
+
+// HasAPIKey reports whether the scraper has an API key
+func (s *EtherscanScraper) HasAPIKey() bool {
+	return s.apiKey != ""
+}
+
+// Init performs any necessary initialization
+func (s *EtherscanScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// etherscanGasOracle is the response of the gas oracle endpoint
+type etherscanGasOracle struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Result is an object on success and an error message otherwise
+	Result any `json:"result"`
+}
+
+// Scrape collects the safe, proposed and fast gas prices
+func (s *EtherscanScraper) Scrape(ctx context.Context) ([]Result, error) {
+	query := url.Values{}
+	query.Set("chainid", "1")
+	query.Set("module", "gastracker")
+	query.Set("action", "gasoracle")
+	query.Set("apikey", s.apiKey)
+	endpoint := s.apiURL + "/v2/api?" + query.Encode()
+
+	var oracle map[string]any
+	backoff := s.backoff
+	for attempt := 1; ; attempt++ {
+		var resp etherscanGasOracle
+		err := fetchJSON(ctx, s.httpClient, endpoint, nil, &resp)
+		if err == nil && resp.Status != "1" {
+			message := fmt.Sprint(resp.Result)
+			err = fmt.Errorf("etherscan error: %s: %s", resp.Message, message)
+			if strings.Contains(strings.ToLower(message), "rate limit") {
+				err = fmt.Errorf("%w: %w", errclass.ErrRateLimited, err)
+			}
+		}
+		if err == nil {
+			result, ok := resp.Result.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: unexpected gas oracle result: %v", errclass.ErrSourceChanged, resp.Result)
+			}
+			oracle = result
+			break
+		}
+
+		if !errors.Is(err, errclass.ErrRateLimited) || attempt == etherscanMaxAttempts {
+			return nil, fmt.Errorf("failed to fetch gas oracle: %w", err)
+		}
+
+		// Back off exponentially before retrying a rate limited request
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	now := time.Now()
+	metadata := map[string]string{"block": fmt.Sprint(oracle["LastBlock"])}
+	fields := []struct {
+		key  string
+		code string
+	}{
+		{"SafeGasPrice", "safe_gas_price"},
+		{"ProposeGasPrice", "propose_gas_price"},
+		{"FastGasPrice", "fast_gas_price"},
+		{"suggestBaseFee", "suggested_base_fee"},
+	}
+
+	var points []TimeSeriesPoint
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(fmt.Sprint(oracle[field.key]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in gas oracle: %w", field.key, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      field.code,
+			Value:     value,
+			Unit:      "gwei",
+			Timestamp: now,
+			Metadata:  metadata,
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
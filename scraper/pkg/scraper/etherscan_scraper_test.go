@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtherscanScraper_Scrape(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/v2/api", r.URL.Path)
+		assert.Equal(t, "gasoracle", r.URL.Query().Get("action"))
+		assert.Equal(t, "secret", r.URL.Query().Get("apikey"))
+
+		// The first request is rate limited
+		if requests == 1 {
+			_, _ = w.Write([]byte(`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":{"LastBlock":"22200000","SafeGasPrice":"0.8","ProposeGasPrice":"0.9","FastGasPrice":"1.2","suggestBaseFee":"0.75","gasUsedRatio":"0.4,0.5"}}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewEtherscanScraper(mockServer.URL, "secret")
+	scraper.backoff = time.Millisecond
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should retry rate limited requests")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, "etherscan_gas_oracle", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+		assert.Equal(t, "22200000", point.Metadata["block"])
+	}
+	assert.Equal(t, map[string]float64{
+		"safe_gas_price":     0.8,
+		"propose_gas_price":  0.9,
+		"fast_gas_price":     1.2,
+		"suggested_base_fee": 0.75,
+	}, values)
+}
+
+func TestEtherscanScraper_InvalidKey(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"status":"0","message":"NOTOK","result":"Invalid API Key"}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewEtherscanScraper(mockServer.URL, "invalid")
+	_, err := scraper.Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid API Key")
+	assert.Equal(t, 1, requests, "Non rate limit errors should not be retried")
+}
+
+func TestEtherscanScraper_Validate(t *testing.T) {
+	assert.Error(t, NewEtherscanScraper("https://api.etherscan.io", "").Validate(context.Background()))
+}
+
+func TestEtherscanScraper_TooManyRequests(t *testing.T) {
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mockServer.Close()
+
+	scraper := NewEtherscanScraper(mockServer.URL, "secret")
+	scraper.backoff = time.Millisecond
+	_, err := scraper.Scrape(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, etherscanMaxAttempts, requests, "HTTP 429 responses should be retried")
+}
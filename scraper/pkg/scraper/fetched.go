@@ -0,0 +1,46 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+// FetchCounter counts the bytes of the response bodies read by the requests sent with a context
+type FetchCounter struct {
+	bytes atomic.Int64
+}
+
+// fetchCounterKey is the context key of the FetchCounter
+type fetchCounterKey struct{}
+
+// WithFetchCounter returns a context counting the bytes fetched with it in counter
+func WithFetchCounter(ctx context.Context, counter *FetchCounter) context.Context {
+	return context.WithValue(ctx, fetchCounterKey{}, counter)
+}
+
+// Bytes returns the number of bytes fetched so far
+func (c *FetchCounter) Bytes() int64 {
+	return c.bytes.Load()
+}
+
+// countBody counts the bytes read from body in the counter of the context, if any
+func countBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	counter, ok := ctx.Value(fetchCounterKey{}).(*FetchCounter)
+	if !ok || body == nil {
+		return body
+	}
+	return &countingBody{ReadCloser: body, counter: counter}
+}
+
+// countingBody adds the bytes read from a response body to a counter
+type countingBody struct {
+	io.ReadCloser
+	counter *FetchCounter
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.counter.bytes.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCounter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"observations":[]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &tracingTransport{base: http.DefaultTransport}}
+	fetch := func(ctx context.Context) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+	}
+
+	counter := &FetchCounter{}
+	ctx := WithFetchCounter(context.Background(), counter)
+	fetch(ctx)
+	fetch(ctx)
+	assert.Equal(t, int64(2*len(`{"observations":[]}`)), counter.Bytes())
+
+	// Requests without a counter are not counted
+	fetch(context.Background())
+	assert.Equal(t, int64(2*len(`{"observations":[]}`)), counter.Bytes())
+}
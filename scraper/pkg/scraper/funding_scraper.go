@@ -0,0 +1,266 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fundingIntervalHours is the settlement interval of BTC and ETH perpetuals on all supported venues
+const fundingIntervalHours = 8
+
+// fundingPeriodsPerYear is the number of funding settlements in a year
+const fundingPeriodsPerYear = 365 * 24 / fundingIntervalHours
+
+// fundingRates holds the last settled and the predicted funding rate of a perpetual
+type fundingRates struct {
+	settled     float64
+	settledAt   time.Time
+	predicted   float64
+	nextFunding time.Time
+}
+
+// FundingScraper implements the Scraper interface for perpetual funding rates across venues
+type FundingScraper struct {
+	venues     []derivativesVenue
+	assets     []string
+	httpClient *http.Client
+}
+
+// NewFundingScraper creates a new funding rate scraper for the given venues and assets
+func NewFundingScraper(venues DerivativesVenues, assets []string) *FundingScraper {
+	return &FundingScraper{
+		venues:     venues.list(),
+		assets:     assets,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *FundingScraper) Name() string {
+	return "perpetual_funding_rates"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *FundingScraper) Schedule() time.Duration {
+	// Predicted rates move with the premium index, settlements happen every 8 hours
+	return 15 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *FundingScraper) Validate(ctx context.Context) error {
+	if len(s.venues) == 0 {
+		return fmt.Errorf("at least one venue is required")
+	}
+	if len(s.assets) == 0 {
+		return fmt.Errorf("at least one asset is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *FundingScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Scrape collects the settled and predicted funding rate of every asset on every venue,
+// skipping venues that fail so a single outage does not hide the others
+func (s *FundingScraper) Scrape(ctx context.Context) ([]Result, error) {
+	now := time.Now().UTC()
+
+	var points []TimeSeriesPoint
+	var failed []string
+	for _, venue := range s.venues {
+		for _, asset := range s.assets {
+			symbol := venue.symbol(strings.ToUpper(asset))
+			rates, err := s.fetch(ctx, venue, symbol)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to fetch funding rate", "venue", venue.name, "symbol", symbol, "error", err)
+				failed = append(failed, venue.name+":"+symbol)
+				continue
+			}
+			points = append(points, fundingMetrics(venue.name, asset, symbol, rates, now)...)
+		}
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("failed to fetch funding rates from every venue")
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+	if len(failed) > 0 {
+		result.Metadata = map[string]string{"failed": strings.Join(failed, ",")}
+	}
+
+	return []Result{result}, nil
+}
+
+// fetch dispatches to the venue specific funding rate endpoints
+func (s *FundingScraper) fetch(ctx context.Context, venue derivativesVenue, symbol string) (fundingRates, error) {
+	switch venue.name {
+	case "binance":
+		return s.binance(ctx, venue.apiURL, symbol)
+	case "bybit":
+		return s.bybit(ctx, venue.apiURL, symbol)
+	case "okx":
+		return s.okx(ctx, venue.apiURL, symbol)
+	}
+	return fundingRates{}, fmt.Errorf("unsupported venue %q", venue.name)
+}
+
+// binance fetches the last settled rate and the premium index based estimate of the next one
+func (s *FundingScraper) binance(ctx context.Context, apiURL, symbol string) (fundingRates, error) {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+
+	var premium struct {
+		LastFundingRate string `json:"lastFundingRate"`
+		NextFundingTime int64  `json:"nextFundingTime"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, apiURL+"/fapi/v1/premiumIndex?"+query.Encode(), nil, &premium); err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch premium index: %w", err)
+	}
+
+	query.Set("limit", "1")
+	var history []struct {
+		FundingRate string `json:"fundingRate"`
+		FundingTime int64  `json:"fundingTime"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, apiURL+"/fapi/v1/fundingRate?"+query.Encode(), nil, &history); err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch funding history: %w", err)
+	}
+	if len(history) == 0 {
+		return fundingRates{}, fmt.Errorf("empty funding history")
+	}
+
+	return parseFundingRates(history[0].FundingRate, time.UnixMilli(history[0].FundingTime),
+		premium.LastFundingRate, time.UnixMilli(premium.NextFundingTime))
+}
+
+// bybit fetches the last settled rate and the ticker estimate of the next one
+func (s *FundingScraper) bybit(ctx context.Context, apiURL, symbol string) (fundingRates, error) {
+	query := url.Values{}
+	query.Set("category", "linear")
+	query.Set("symbol", symbol)
+
+	ticker, err := fetchBybit[struct {
+		FundingRate     string `json:"fundingRate"`
+		NextFundingTime string `json:"nextFundingTime"`
+	}](ctx, s.httpClient, apiURL+"/v5/market/tickers?"+query.Encode())
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch ticker: %w", err)
+	}
+	nextFunding, err := parseMillis(ticker.NextFundingTime)
+	if err != nil {
+		return fundingRates{}, err
+	}
+
+	query.Set("limit", "1")
+	history, err := fetchBybit[struct {
+		FundingRate          string `json:"fundingRate"`
+		FundingRateTimestamp string `json:"fundingRateTimestamp"`
+	}](ctx, s.httpClient, apiURL+"/v5/market/funding/history?"+query.Encode())
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch funding history: %w", err)
+	}
+	settledAt, err := parseMillis(history.FundingRateTimestamp)
+	if err != nil {
+		return fundingRates{}, err
+	}
+
+	return parseFundingRates(history.FundingRate, settledAt, ticker.FundingRate, nextFunding)
+}
+
+// okx fetches the last realized rate and the current period estimate of the next one
+func (s *FundingScraper) okx(ctx context.Context, apiURL, symbol string) (fundingRates, error) {
+	query := url.Values{}
+	query.Set("instId", symbol)
+
+	current, err := fetchOKX[struct {
+		FundingRate string `json:"fundingRate"`
+		FundingTime string `json:"fundingTime"`
+	}](ctx, s.httpClient, apiURL+"/api/v5/public/funding-rate?"+query.Encode())
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch funding rate: %w", err)
+	}
+	nextFunding, err := parseMillis(current.FundingTime)
+	if err != nil {
+		return fundingRates{}, err
+	}
+
+	query.Set("limit", "1")
+	history, err := fetchOKX[struct {
+		RealizedRate string `json:"realizedRate"`
+		FundingTime  string `json:"fundingTime"`
+	}](ctx, s.httpClient, apiURL+"/api/v5/public/funding-rate-history?"+query.Encode())
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("failed to fetch funding history: %w", err)
+	}
+	settledAt, err := parseMillis(history.FundingTime)
+	if err != nil {
+		return fundingRates{}, err
+	}
+
+	return parseFundingRates(history.RealizedRate, settledAt, current.FundingRate, nextFunding)
+}
+
+// parseFundingRates parses the raw per-period rates returned by a venue
+func parseFundingRates(settled string, settledAt time.Time, predicted string, nextFunding time.Time) (fundingRates, error) {
+	settledRate, err := strconv.ParseFloat(settled, 64)
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("invalid settled funding rate %q: %w", settled, err)
+	}
+	predictedRate, err := strconv.ParseFloat(predicted, 64)
+	if err != nil {
+		return fundingRates{}, fmt.Errorf("invalid predicted funding rate %q: %w", predicted, err)
+	}
+
+	return fundingRates{
+		settled:     settledRate,
+		settledAt:   settledAt.UTC(),
+		predicted:   predictedRate,
+		nextFunding: nextFunding.UTC(),
+	}, nil
+}
+
+// fundingMetrics converts the funding rates of a perpetual to annualized series points
+func fundingMetrics(venue, asset, symbol string, rates fundingRates, now time.Time) []TimeSeriesPoint {
+	prefix := venue + "_" + strings.ToLower(asset) + "_"
+	metadata := func(rate float64) map[string]string {
+		return map[string]string{
+			"venue":          venue,
+			"symbol":         symbol,
+			"rate":           strconv.FormatFloat(rate, 'g', -1, 64),
+			"interval_hours": strconv.Itoa(fundingIntervalHours),
+		}
+	}
+
+	predictedMetadata := metadata(rates.predicted)
+	predictedMetadata["next_funding_time"] = rates.nextFunding.Format(time.RFC3339)
+
+	return []TimeSeriesPoint{
+		{
+			Code:      prefix + "funding_rate",
+			Value:     rates.settled * fundingPeriodsPerYear * 100,
+			Unit:      "percent",
+			Timestamp: rates.settledAt,
+			Metadata:  metadata(rates.settled),
+		},
+		{
+			Code:      prefix + "predicted_funding_rate",
+			Value:     rates.predicted * fundingPeriodsPerYear * 100,
+			Unit:      "percent",
+			Timestamp: now,
+			Metadata:  predictedMetadata,
+		},
+	}
+}
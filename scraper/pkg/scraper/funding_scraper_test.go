@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDerivativesServer starts a mock server answering each path with a fixed body
+func newDerivativesServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFundingScraper_Scrape(t *testing.T) {
+	server := newDerivativesServer(t, map[string]string{
+		"/fapi/v1/premiumIndex":               `{"symbol":"BTCUSDT","markPrice":"64000","lastFundingRate":"0.00012","nextFundingTime":1731657600000,"time":1731640000000}`,
+		"/fapi/v1/fundingRate":                `[{"symbol":"BTCUSDT","fundingRate":"0.00010000","fundingTime":1731628800000,"markPrice":"63900"}]`,
+		"/v5/market/tickers":                  `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[{"symbol":"BTCUSDT","fundingRate":"0.00008","nextFundingTime":"1731657600000"}]}}`,
+		"/v5/market/funding/history":          `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[{"symbol":"BTCUSDT","fundingRate":"-0.00005","fundingRateTimestamp":"1731628800000"}]}}`,
+		"/api/v5/public/funding-rate":         `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","fundingRate":"0.0002","fundingTime":"1731657600000","nextFundingRate":""}]}`,
+		"/api/v5/public/funding-rate-history": `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","fundingRate":"0.00015","realizedRate":"0.00014","fundingTime":"1731628800000"}]}`,
+	})
+
+	venues := DerivativesVenues{BinanceURL: server.URL, BybitURL: server.URL, OKXURL: server.URL}
+	scraper := NewFundingScraper(venues, []string{"BTC"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "perpetual_funding_rates", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 6, "Should return 2 metrics for each of the 3 venues")
+
+	values := make(map[string]TimeSeriesPoint)
+	for _, point := range points {
+		values[point.Code] = point
+	}
+
+	// Rates are annualized over 3 settlements a day
+	expected := map[string]float64{
+		"binance_btc_funding_rate":           10.95,
+		"binance_btc_predicted_funding_rate": 13.14,
+		"bybit_btc_funding_rate":             -5.475,
+		"bybit_btc_predicted_funding_rate":   8.76,
+		"okx_btc_funding_rate":               15.33,
+		"okx_btc_predicted_funding_rate":     21.9,
+	}
+	for code, value := range expected {
+		require.Contains(t, values, code)
+		assert.InDelta(t, value, values[code].Value, 1e-9, "Metric %s should be annualized", code)
+	}
+
+	settled := values["binance_btc_funding_rate"]
+	assert.Equal(t, time.UnixMilli(1731628800000).UTC(), settled.Timestamp)
+	assert.Equal(t, "0.0001", settled.Metadata["rate"])
+	assert.Equal(t, "2024-11-15T08:00:00Z", values["okx_btc_predicted_funding_rate"].Metadata["next_funding_time"])
+}
+
+func TestFundingScraper_VenueFailure(t *testing.T) {
+	server := newDerivativesServer(t, map[string]string{
+		"/v5/market/tickers":         `{"retCode":10001,"retMsg":"params error: symbol invalid","result":{}}`,
+		"/v5/market/funding/history": `{"retCode":0,"retMsg":"OK","result":{"list":[]}}`,
+		"/fapi/v1/premiumIndex":      `{"symbol":"BTCUSDT","lastFundingRate":"0.0001","nextFundingTime":1731657600000}`,
+		"/fapi/v1/fundingRate":       `[{"symbol":"BTCUSDT","fundingRate":"0.0001","fundingTime":1731628800000}]`,
+	})
+
+	scraper := NewFundingScraper(DerivativesVenues{BinanceURL: server.URL, BybitURL: server.URL}, []string{"BTC"})
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "A failing venue should not fail the whole scrape")
+	require.Len(t, results, 1)
+	assert.Len(t, results[0].Data, 2, "Should only return the metrics of the healthy venue")
+	assert.Equal(t, "bybit:BTCUSDT", results[0].Metadata["failed"])
+
+	_, err = NewFundingScraper(DerivativesVenues{BybitURL: server.URL}, []string{"BTC"}).Scrape(context.Background())
+	assert.Error(t, err, "Should fail when every venue fails")
+}
+
+func TestFundingScraper_Validate(t *testing.T) {
+	ctx := context.Background()
+	assert.Error(t, NewFundingScraper(DerivativesVenues{}, DefaultDerivativesAssets).Validate(ctx))
+	assert.Error(t, NewFundingScraper(DerivativesVenues{OKXURL: DefaultOKXAPIURL}, nil).Validate(ctx))
+}
@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultFXBase is the currency rates are quoted in when none is configured
+const DefaultFXBase = "CHF"
+
+// DefaultFXSymbols lists the currencies tracked when none are configured
+var DefaultFXSymbols = []string{"USD", "EUR", "GBP", "JPY"}
+
+// FXScraper implements the Scraper interface for daily ECB reference FX rates via the Frankfurter API
+type FXScraper struct {
+	apiURL     string
+	base       string
+	symbols    []string
+	httpClient *http.Client
+}
+
+// NewFXScraper creates a new FX scraper quoting the given currencies in the base currency
+func NewFXScraper(apiURL, base string, symbols []string) *FXScraper {
+	upper := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		upper = append(upper, strings.ToUpper(strings.TrimSpace(symbol)))
+	}
+
+	return &FXScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		base:       strings.ToUpper(strings.TrimSpace(base)),
+		symbols:    upper,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *FXScraper) Name() string {
+	return "fx_rates"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *FXScraper) Schedule() time.Duration {
+	// Reference rates are published once per working day around 16:00 CET
+	return 6 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *FXScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if s.base == "" {
+		return fmt.Errorf("base currency is required")
+	}
+	if len(s.symbols) == 0 {
+		return fmt.Errorf("at least one symbol is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *FXScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Series returns the reference rate of every symbol in the base currency
+func (s *FXScraper) Series() []SeriesInfo {
+	series := make([]SeriesInfo, 0, len(s.symbols))
+	for _, symbol := range s.symbols {
+		series = append(series, SeriesInfo{
+			Code:        strings.ToLower(symbol) + "_" + strings.ToLower(s.base),
+			Description: fmt.Sprintf("ECB reference rate, price of one %s in %s", symbol, s.base),
+			Unit:        s.base,
+			Frequency:   FrequencyBusinessDay,
+		})
+	}
+	return series
+}
+
+// Scrape collects the latest reference rate of every symbol, expressed as the price of one
+// unit of the symbol in the base currency, e.g. usd_chf is the CHF price of one USD
+func (s *FXScraper) Scrape(ctx context.Context) ([]Result, error) {
+	query := url.Values{}
+	query.Set("from", s.base)
+	query.Set("to", strings.Join(s.symbols, ","))
+
+	var resp struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/latest?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+
+	date, err := parseDate(resp.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate date %q: %w", resp.Date, err)
+	}
+
+	symbols := make([]string, 0, len(resp.Rates))
+	for symbol := range resp.Rates {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	points := make([]TimeSeriesPoint, 0, len(symbols))
+	for _, symbol := range symbols {
+		rate := resp.Rates[symbol]
+		if rate == 0 {
+			continue
+		}
+		// The API returns the amount of symbol per unit of base, invert it to quote the symbol in base
+		points = append(points, TimeSeriesPoint{
+			Code:      strings.ToLower(symbol) + "_" + strings.ToLower(s.base),
+			Value:     1 / rate,
+			Unit:      s.base,
+			Timestamp: date,
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+		Metadata:  map[string]string{"base": s.base},
+	}
+
+	return []Result{result}, nil
+}
@@ -0,0 +1,51 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFXScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "CHF", r.URL.Query().Get("from"))
+		assert.Equal(t, "USD,EUR", r.URL.Query().Get("to"))
+		_, _ = w.Write([]byte(`{"amount":1.0,"base":"CHF","date":"2024-11-12","rates":{"EUR":1.0625,"USD":1.25}}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewFXScraper(mockServer.URL, "chf", []string{"usd", "EUR"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "fx_rates", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 2)
+
+	assert.Equal(t, "eur_chf", points[0].Code)
+	assert.InDelta(t, 0.941176, points[0].Value, 1e-6)
+	assert.Equal(t, "usd_chf", points[1].Code)
+	assert.Equal(t, 0.8, points[1].Value, "Rates should be quoted in the base currency")
+	assert.Equal(t, "CHF", points[1].Unit)
+	assert.Equal(t, time.Date(2024, 11, 12, 0, 0, 0, 0, time.UTC), points[1].Timestamp)
+}
+
+func TestFXScraper_Validate(t *testing.T) {
+	ctx := context.Background()
+	assert.Error(t, NewFXScraper("https://api.frankfurter.app", "", DefaultFXSymbols).Validate(ctx))
+	assert.Error(t, NewFXScraper("https://api.frankfurter.app", DefaultFXBase, nil).Validate(ctx))
+	assert.NoError(t, NewFXScraper("https://api.frankfurter.app", DefaultFXBase, DefaultFXSymbols).Validate(ctx))
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// statusError is returned when a source responds with a non-200 status code
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// Unwrap classifies the status code, throttled requests and server errors are worth retrying
+// while an endpoint that is gone needs the scraper to be adapted
+func (e *statusError) Unwrap() error {
+	switch {
+	case e.code == http.StatusTooManyRequests:
+		return errclass.ErrRateLimited
+	case e.code == http.StatusRequestTimeout || e.code >= 500:
+		return errclass.ErrTransient
+	case e.code == http.StatusNotFound || e.code == http.StatusGone:
+		return errclass.ErrSourceChanged
+	default:
+		return nil
+	}
+}
+
+// fetch performs a GET request and returns the response body
+func fetch(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return do(client, req, header)
+}
+
+// do sends a request with the given headers and returns the response body
+func do(client *http.Client, req *http.Request, header http.Header) ([]byte, error) {
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if err := waitRateLimit(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Cancelled runs are not a failure of the source
+		if req.Context().Err() != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", req.URL.Path, err)
+		}
+		return nil, fmt.Errorf("%w: failed to fetch %s: %w", errclass.ErrTransient, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read response body: %w", errclass.ErrTransient, err)
+	}
+	recordPayload(req.Context(), req.URL.String(), resp.Header.Get("Content-Type"), body)
+	return body, nil
+}
+
+// fetchJSON performs a GET request and decodes the JSON response into out
+func fetchJSON(ctx context.Context, client *http.Client, url string, header http.Header, out any) error {
+	body, err := fetch(ctx, client, url, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: failed to parse response: %w", errclass.ErrParse, err)
+	}
+	return nil
+}
+
+// postJSON sends payload as a JSON POST request and decodes the JSON response into out
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, payload, out any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := do(client, req, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: failed to parse response: %w", errclass.ErrParse, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"macrochain/scraper/pkg/errclass"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchJSONClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"rate limited", http.StatusTooManyRequests, "", errclass.RateLimited},
+		{"server error", http.StatusBadGateway, "", errclass.Transient},
+		{"endpoint gone", http.StatusNotFound, "", errclass.SourceChanged},
+		{"unauthorized", http.StatusUnauthorized, "", errclass.Unknown},
+		{"malformed body", http.StatusOK, "<html>", errclass.Parse},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer mockServer.Close()
+
+			var out map[string]any
+			err := fetchJSON(context.Background(), mockServer.Client(), mockServer.URL, nil, &out)
+			assert.Error(t, err)
+			assert.Equal(t, test.want, errclass.Of(err))
+		})
+	}
+}
+
+func TestFetchClassifiesUnreachableSourceAsTransient(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mockServer.Close()
+
+	_, err := fetch(context.Background(), http.DefaultClient, mockServer.URL, nil)
+	assert.Equal(t, errclass.Transient, errclass.Of(err), "Connection errors should be transient: %v", err)
+}
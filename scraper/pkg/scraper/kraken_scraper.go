@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultKrakenPairs lists the CHF-quoted Kraken pairs tracked when none are configured
+var DefaultKrakenPairs = []string{"XBTCHF", "ETHCHF"}
+
+// KrakenScraper implements the Scraper interface for Kraken spot tickers of CHF-quoted pairs
+type KrakenScraper struct {
+	apiURL     string
+	pairs      []string
+	httpClient *http.Client
+}
+
+// NewKrakenScraper creates a new Kraken ticker scraper for the given pairs
+func NewKrakenScraper(apiURL string, pairs []string) *KrakenScraper {
+	return &KrakenScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		pairs:      pairs,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *KrakenScraper) Name() string {
+	return "kraken_tickers"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *KrakenScraper) Schedule() time.Duration {
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *KrakenScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if len(s.pairs) == 0 {
+		return fmt.Errorf("at least one pair is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *KrakenScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// krakenTicker is an entry of the public ticker endpoint, where array fields hold
+// the value for today followed by the value for the last 24 hours
+type krakenTicker struct {
+	Ask    []string `json:"a"`
+	Bid    []string `json:"b"`
+	Last   []string `json:"c"`
+	Volume []string `json:"v"`
+	VWAP   []string `json:"p"`
+	Low    []string `json:"l"`
+	High   []string `json:"h"`
+	Open   string   `json:"o"`
+}
+
+// krakenResponse is the envelope of every Kraken public API response
+type krakenResponse[T any] struct {
+	Error  []string `json:"error"`
+	Result T        `json:"result"`
+}
+
+// Scrape collects the last price, spread and 24h statistics of every configured pair
+func (s *KrakenScraper) Scrape(ctx context.Context) ([]Result, error) {
+	query := url.Values{}
+	query.Set("pair", strings.Join(s.pairs, ","))
+
+	var resp krakenResponse[map[string]krakenTicker]
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/0/public/Ticker?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch tickers: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("kraken API error: %s", strings.Join(resp.Error, ", "))
+	}
+
+	// Kraken keys the result by its own pair name, iterate in a stable order
+	pairs := make([]string, 0, len(resp.Result))
+	for pair := range resp.Result {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	timestamp := time.Now().UTC()
+	var points []TimeSeriesPoint
+	for _, pair := range pairs {
+		tickerPoints, err := krakenTickerMetrics(pair, resp.Result[pair], timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ticker %s: %w", pair, err)
+		}
+		points = append(points, tickerPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// krakenTickerMetrics converts a ticker to series points
+func krakenTickerMetrics(pair string, ticker krakenTicker, timestamp time.Time) ([]TimeSeriesPoint, error) {
+	metadata := map[string]string{"pair": pair}
+
+	// index returns the element at i, or an empty string when the field is too short
+	index := func(values []string, i int) string {
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
+	}
+
+	fields := []struct {
+		metric string
+		raw    string
+		unit   string
+	}{
+		{"price", index(ticker.Last, 0), "price"},
+		{"bid", index(ticker.Bid, 0), "price"},
+		{"ask", index(ticker.Ask, 0), "price"},
+		{"open", ticker.Open, "price"},
+		{"high_24h", index(ticker.High, 1), "price"},
+		{"low_24h", index(ticker.Low, 1), "price"},
+		{"vwap_24h", index(ticker.VWAP, 1), "price"},
+		{"volume_24h", index(ticker.Volume, 1), "base"},
+	}
+
+	points := make([]TimeSeriesPoint, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.ParseFloat(field.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", field.metric, field.raw, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      strings.ToLower(pair) + "_" + field.metric,
+			Value:     value,
+			Unit:      field.unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKrakenScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0/public/Ticker" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "XBTCHF,ETHCHF", r.URL.Query().Get("pair"))
+		_, _ = w.Write([]byte(`{"error":[],"result":{
+			"XBTCHF":{"a":["74010.5","1","1.000"],"b":["74000.1","2","2.000"],"c":["74005.0","0.01"],"v":["12.5","40.25"],"p":["73900.0","73800.5"],"t":[100,350],"l":["73000.0","72500.0"],"h":["74500.0","75000.0"],"o":"73500.0"},
+			"ETHCHF":{"a":["2810.5","1","1.000"],"b":["2810.0","2","2.000"],"c":["2810.2","0.5"],"v":["150","420.5"],"p":["2800","2795.5"],"t":[80,300],"l":["2780","2750"],"h":["2830","2850"],"o":"2790"}
+		}}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewKrakenScraper(mockServer.URL, DefaultKrakenPairs)
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "kraken_tickers", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 16, "Should return 8 metrics for each of the 2 pairs")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, 74005.0, values["xbtchf_price"])
+	assert.Equal(t, 74000.1, values["xbtchf_bid"])
+	assert.Equal(t, 74010.5, values["xbtchf_ask"])
+	assert.Equal(t, 73500.0, values["xbtchf_open"])
+	assert.Equal(t, 75000.0, values["xbtchf_high_24h"])
+	assert.Equal(t, 72500.0, values["xbtchf_low_24h"])
+	assert.Equal(t, 73800.5, values["xbtchf_vwap_24h"])
+	assert.Equal(t, 40.25, values["xbtchf_volume_24h"])
+	assert.Equal(t, 2810.2, values["ethchf_price"])
+}
+
+func TestKrakenScraper_APIError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"error":["EQuery:Unknown asset pair"]}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewKrakenScraper(mockServer.URL, []string{"INVALID"}).Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unknown asset pair")
+}
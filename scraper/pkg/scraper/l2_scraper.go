@@ -0,0 +1,328 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultL2Chains lists the layer-2 networks tracked when none are configured
+var DefaultL2Chains = []string{
+	"arbitrum:https://arb1.arbitrum.io/rpc",
+	"optimism:https://mainnet.optimism.io",
+	"base:https://mainnet.base.org",
+}
+
+// l2Stack identifies the rollup stack of a chain, which determines where its L1 fees are read from
+type l2Stack int
+
+const (
+	l2StackUnknown l2Stack = iota
+	l2StackOP
+	l2StackArbitrum
+)
+
+// l2Stacks maps the chain IDs of known rollups to their stack
+var l2Stacks = map[uint64]l2Stack{
+	10:    l2StackOP,       // OP Mainnet
+	8453:  l2StackOP,       // Base
+	42161: l2StackArbitrum, // Arbitrum One
+	42170: l2StackArbitrum, // Arbitrum Nova
+}
+
+var (
+	// opGasPriceOracle is the OP Stack predeploy exposing the L1 fee parameters
+	opGasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+	// arbGasInfo is the Arbitrum precompile exposing the L1 fee parameters
+	arbGasInfo = common.HexToAddress("0x000000000000000000000000000000000000006C")
+)
+
+// l2TPSBlocks is the number of recent blocks the throughput is averaged over
+const l2TPSBlocks = 20
+
+// opFeeScalarUnit is the unit of the fee scalars of the OP Stack gas price oracle, which have 6 decimals
+const opFeeScalarUnit = 1e6
+
+// L2Chain is a labeled layer-2 network RPC endpoint
+type L2Chain struct {
+	Label  string
+	RPCURL string
+}
+
+// ParseL2Chains parses a list of "label:rpc_url" entries
+func ParseL2Chains(entries []string) ([]L2Chain, error) {
+	chains := make([]L2Chain, 0, len(entries))
+	for _, entry := range entries {
+		label, rpcURL, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || label == "" || rpcURL == "" {
+			return nil, fmt.Errorf("invalid chain entry %q, expected label:rpc_url", entry)
+		}
+		chains = append(chains, L2Chain{Label: label, RPCURL: rpcURL})
+	}
+	return chains, nil
+}
+
+// l2Client is an initialized connection to a layer-2 network
+type l2Client struct {
+	chain  L2Chain
+	client *ethclient.Client
+	stack  l2Stack
+}
+
+// L2Scraper implements the Scraper interface for layer-2 gas, L1 fee and throughput metrics
+type L2Scraper struct {
+	chains  []L2Chain
+	clients []l2Client
+}
+
+// NewL2Scraper creates a new layer-2 scraper for the given chains
+func NewL2Scraper(chains []L2Chain) *L2Scraper {
+	return &L2Scraper{
+		chains: chains,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *L2Scraper) Name() string {
+	return "l2_networks"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *L2Scraper) Schedule() time.Duration {
+	return 5 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *L2Scraper) Validate(ctx context.Context) error {
+	if len(s.chains) == 0 {
+		return fmt.Errorf("at least one chain is required")
+	}
+	return nil
+}
+
+// Init connects to every chain and detects its rollup stack from the chain ID. Unreachable
+// chains are skipped with a warning, Init fails when no chain is reachable
+func (s *L2Scraper) Init(ctx context.Context) error {
+	clients := make([]l2Client, 0, len(s.chains))
+	for _, chain := range s.chains {
+		client, err := s.connect(ctx, chain)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping unreachable layer-2 chain", "chain", chain.Label, "error", err)
+			continue
+		}
+		clients = append(clients, client)
+	}
+	if len(clients) == 0 {
+		return fmt.Errorf("failed to connect to any of %d chains", len(s.chains))
+	}
+	s.clients = clients
+	return nil
+}
+
+// connect dials chain and detects its stack, the client is closed when the detection fails
+func (s *L2Scraper) connect(ctx context.Context, chain L2Chain) (l2Client, error) {
+	client, err := dialEthereum(ctx, chain.RPCURL)
+	if err != nil {
+		return l2Client{}, fmt.Errorf("failed to connect to %s: %w", chain.Label, err)
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return l2Client{}, fmt.Errorf("failed to fetch chain ID of %s: %w", chain.Label, err)
+	}
+	return l2Client{chain: chain, client: client, stack: l2Stacks[chainID.Uint64()]}, nil
+}
+
+// l2Block is the subset of a block needed to compute throughput, with transaction hashes only
+type l2Block struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	BaseFee      *hexutil.Big   `json:"baseFeePerGas"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+// Scrape collects the gas price, L1 fees and throughput of every chain. Chains that fail are
+// skipped with a warning, Scrape fails when every chain fails
+func (s *L2Scraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.clients == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	var points []TimeSeriesPoint
+	var lastErr error
+	for _, c := range s.clients {
+		chainPoints, err := s.scrapeChain(ctx, c)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to scrape %s: %w", c.chain.Label, err)
+			slog.WarnContext(ctx, "Skipping failed layer-2 chain", "chain", c.chain.Label, "error", err)
+			continue
+		}
+		points = append(points, chainPoints...)
+	}
+	if len(points) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapeChain collects the metrics of a single chain
+func (s *L2Scraper) scrapeChain(ctx context.Context, c l2Client) ([]TimeSeriesPoint, error) {
+	latest, err := s.block(ctx, c, "latest")
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Unix(int64(latest.Timestamp), 0).UTC()
+	metadata := map[string]string{"chain": c.chain.Label, "block": strconv.FormatUint(uint64(latest.Number), 10)}
+
+	point := func(metric string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      slug(c.chain.Label) + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		}
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gas price: %w", err)
+	}
+	points := []TimeSeriesPoint{point("gas_price", weiToFloat(gasPrice, weiPerGwei), "gwei")}
+	if latest.BaseFee != nil {
+		points = append(points, point("base_fee", weiToFloat(latest.BaseFee.ToInt(), weiPerGwei), "gwei"))
+	}
+
+	tps, err := s.throughput(ctx, c, latest)
+	if err != nil {
+		return nil, err
+	}
+	points = append(points, point("tps", tps, "tx/s"))
+
+	// The L1 fees of posting batches, and the L1 data fee the chain charges its users for them,
+	// are exposed by stack specific system contracts
+	var fees []TimeSeriesPoint
+	switch c.stack {
+	case l2StackOP:
+		fees, err = s.opFees(ctx, c, point)
+	case l2StackArbitrum:
+		fees, err = s.arbitrumFees(ctx, c, point)
+	}
+	if err != nil {
+		return nil, err
+	}
+	points = append(points, fees...)
+
+	return points, nil
+}
+
+// opFees reads the L1 fees of an OP Stack chain from its gas price oracle. The L1 data fee per
+// compressed byte weighs the L1 base fee and blob base fee by the scalars of the chain
+func (s *L2Scraper) opFees(ctx context.Context, c l2Client, point func(string, float64, string) TimeSeriesPoint) ([]TimeSeriesPoint, error) {
+	var words [4]*big.Int
+	for i, signature := range []string{"l1BaseFee()", "blobBaseFee()", "baseFeeScalar()", "blobBaseFeeScalar()"} {
+		out, err := callContract(ctx, c.client, opGasPriceOracle, signature)
+		if err != nil {
+			return nil, err
+		}
+		if words[i], err = wordAt(out, 0); err != nil {
+			return nil, err
+		}
+	}
+	l1Fee, blobFee, l1Scalar, blobScalar := words[0], words[1], words[2], words[3]
+
+	// A compressed byte costs 16 L1 gas at the base fee or one blob byte at the blob base fee
+	dataFee := new(big.Int).Mul(l1Fee, l1Scalar)
+	dataFee.Mul(dataFee, big.NewInt(16))
+	dataFee.Add(dataFee, new(big.Int).Mul(blobFee, blobScalar))
+
+	return []TimeSeriesPoint{
+		point("l1_base_fee", weiToFloat(l1Fee, weiPerGwei), "gwei"),
+		point("l1_blob_base_fee", weiToFloat(blobFee, weiPerGwei), "gwei"),
+		point("l1_data_fee", weiToFloat(dataFee, weiPerGwei*opFeeScalarUnit), "gwei/byte"),
+	}, nil
+}
+
+// arbitrumFees reads the L1 fees of an Arbitrum chain from ArbGasInfo, getPricesInWei returns the
+// price of a transaction, of an L1 calldata byte, of a storage allocation and of ArbGas
+func (s *L2Scraper) arbitrumFees(ctx context.Context, c l2Client, point func(string, float64, string) TimeSeriesPoint) ([]TimeSeriesPoint, error) {
+	out, err := callContract(ctx, c.client, arbGasInfo, "getL1BaseFeeEstimate()")
+	if err != nil {
+		return nil, err
+	}
+	l1Fee, err := wordAt(out, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := callContract(ctx, c.client, arbGasInfo, "getPricesInWei()")
+	if err != nil {
+		return nil, err
+	}
+	dataFee, err := wordAt(prices, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return []TimeSeriesPoint{
+		point("l1_base_fee", weiToFloat(l1Fee, weiPerGwei), "gwei"),
+		point("l1_data_fee", weiToFloat(dataFee, weiPerGwei), "gwei/byte"),
+	}, nil
+}
+
+// throughput averages the user transactions per second over the most recent blocks
+func (s *L2Scraper) throughput(ctx context.Context, c l2Client, latest l2Block) (float64, error) {
+	head := uint64(latest.Number)
+	from := head - min(head, l2TPSBlocks)
+
+	first, err := s.block(ctx, c, hexutil.EncodeUint64(from))
+	if err != nil {
+		return 0, err
+	}
+
+	txs := len(latest.Transactions)
+	for number := from + 1; number < head; number++ {
+		block, err := s.block(ctx, c, hexutil.EncodeUint64(number))
+		if err != nil {
+			return 0, err
+		}
+		txs += len(block.Transactions)
+	}
+
+	// Every OP Stack and Arbitrum block starts with a system transaction that is not user activity
+	if c.stack != l2StackUnknown {
+		txs -= int(head - from)
+	}
+
+	elapsed := latest.Timestamp - first.Timestamp
+	if elapsed == 0 || txs < 0 {
+		return 0, nil
+	}
+	return float64(txs) / float64(elapsed), nil
+}
+
+// block fetches a block with its transaction hashes, the full transactions of rollups use
+// types that cannot be decoded by go-ethereum
+func (s *L2Scraper) block(ctx context.Context, c l2Client, number string) (l2Block, error) {
+	var block l2Block
+	if err := c.client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", number, false); err != nil {
+		return l2Block{}, fmt.Errorf("failed to fetch block %s: %w", number, err)
+	}
+	return block, nil
+}
@@ -0,0 +1,203 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// l2BlockHandler mocks eth_getBlockByNumber for blocks 0-100 produced every 2 seconds
+// with 5 transactions each
+func l2BlockHandler(params []json.RawMessage) (any, error) {
+	var tag string
+	if err := json.Unmarshal(params[0], &tag); err != nil {
+		return nil, err
+	}
+
+	number := uint64(100)
+	if tag != "latest" {
+		parsed, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return nil, err
+		}
+		number = parsed
+	}
+
+	txs := make([]common.Hash, 5)
+	for i := range txs {
+		txs[i] = common.BigToHash(big.NewInt(int64(number*10 + uint64(i))))
+	}
+	return map[string]any{
+		"number":        hexutil.Uint64(number),
+		"timestamp":     hexutil.Uint64(1_700_000_000 + number*2),
+		"baseFeePerGas": (*hexutil.Big)(big.NewInt(1_000_000)),
+		"transactions":  txs,
+	}, nil
+}
+
+func TestL2Scraper_Scrape(t *testing.T) {
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_chainId": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(8453), nil
+		},
+		"eth_gasPrice": func(params []json.RawMessage) (any, error) {
+			return (*hexutil.Big)(big.NewInt(5_000_000)), nil
+		},
+		"eth_getBlockByNumber": l2BlockHandler,
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			if call.To != opGasPriceOracle {
+				return nil, fmt.Errorf("unexpected contract %s", call.To.Hex())
+			}
+			switch call.Selector() {
+			case selector("l1BaseFee()"):
+				return abiWords(big.NewInt(12_000_000_000)), nil
+			case selector("blobBaseFee()"):
+				return abiWords(big.NewInt(1)), nil
+			case selector("baseFeeScalar()"):
+				return abiWords(big.NewInt(1000)), nil
+			case selector("blobBaseFeeScalar()"):
+				return abiWords(big.NewInt(1_000_000)), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	chains, err := ParseL2Chains([]string{"base:" + server.URL})
+	require.NoError(t, err)
+
+	scraper := NewL2Scraper(chains)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "l2_networks", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	// 16 L1 gas at 12 gwei scaled by 0.001, plus a blob byte at 1 wei scaled by 1
+	assert.InDelta(t, 0.192000001, values["base_l1_data_fee"], 1e-12)
+	delete(values, "base_l1_data_fee")
+	assert.Equal(t, map[string]float64{
+		"base_gas_price":        0.005,
+		"base_base_fee":         0.001,
+		"base_tps":              2, // 4 user transactions every 2 seconds
+		"base_l1_base_fee":      12,
+		"base_l1_blob_base_fee": 1e-9,
+	}, values)
+}
+
+func TestL2Scraper_Arbitrum(t *testing.T) {
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_chainId": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(42161), nil
+		},
+		"eth_gasPrice": func(params []json.RawMessage) (any, error) {
+			return (*hexutil.Big)(big.NewInt(10_000_000)), nil
+		},
+		"eth_getBlockByNumber": l2BlockHandler,
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			if call.To != arbGasInfo {
+				return nil, fmt.Errorf("unexpected contract %s", call.To.Hex())
+			}
+			switch call.Selector() {
+			case selector("getL1BaseFeeEstimate()"):
+				return abiWords(big.NewInt(20_000_000_000)), nil
+			case selector("getPricesInWei()"):
+				return abiWords(big.NewInt(1), big.NewInt(320_000_000_000), big.NewInt(3), big.NewInt(4), big.NewInt(5), big.NewInt(6)), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	scraper := NewL2Scraper([]L2Chain{{Label: "arbitrum", RPCURL: server.URL}})
+	ctx := context.Background()
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err)
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, 20.0, values["arbitrum_l1_base_fee"])
+	assert.Equal(t, 320.0, values["arbitrum_l1_data_fee"], "The price of an L1 calldata byte should be emitted")
+}
+
+func TestL2Scraper_UnreachableChain(t *testing.T) {
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_chainId": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(999), nil
+		},
+		"eth_gasPrice": func(params []json.RawMessage) (any, error) {
+			return (*hexutil.Big)(big.NewInt(5_000_000)), nil
+		},
+		"eth_getBlockByNumber": l2BlockHandler,
+	})
+	down := newRPCServer(t, map[string]rpcHandler{})
+
+	scraper := NewL2Scraper([]L2Chain{{Label: "down", RPCURL: down.URL}, {Label: "other", RPCURL: server.URL}})
+	ctx := context.Background()
+	require.NoError(t, scraper.Init(ctx), "An unreachable chain should not fail the other chains")
+	require.Len(t, scraper.clients, 1)
+	assert.Equal(t, "other", scraper.clients[0].chain.Label)
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, results[0].Data)
+
+	scraper = NewL2Scraper([]L2Chain{{Label: "down", RPCURL: down.URL}})
+	assert.Error(t, scraper.Init(ctx), "Init should fail when no chain is reachable")
+}
+
+func TestL2Scraper_UnknownChain(t *testing.T) {
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_chainId": func(params []json.RawMessage) (any, error) {
+			return hexutil.Uint64(999), nil
+		},
+		"eth_gasPrice": func(params []json.RawMessage) (any, error) {
+			return (*hexutil.Big)(big.NewInt(5_000_000)), nil
+		},
+		"eth_getBlockByNumber": l2BlockHandler,
+	})
+
+	scraper := NewL2Scraper([]L2Chain{{Label: "other", RPCURL: server.URL}})
+	ctx := context.Background()
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Unknown chains should only skip the L1 fee metrics")
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 3)
+	assert.Equal(t, "other_tps", points[2].Code)
+	assert.Equal(t, 2.5, points[2].Value, "System transactions are only known for supported stacks")
+}
+
+func TestParseL2Chains(t *testing.T) {
+	chains, err := ParseL2Chains(DefaultL2Chains)
+	require.NoError(t, err)
+	require.Len(t, chains, 3)
+	assert.Equal(t, L2Chain{Label: "arbitrum", RPCURL: "https://arb1.arbitrum.io/rpc"}, chains[0])
+
+	_, err = ParseL2Chains([]string{"arbitrum"})
+	assert.Error(t, err, "Entry without RPC URL should cause an error")
+}
@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lbmaRecentDays is the number of most recent fixings emitted per scrape, so missed days are backfilled
+const lbmaRecentDays = 5
+
+// lbmaFixings lists the LBMA price files, which are also used as series names
+var lbmaFixings = []string{"gold_am", "gold_pm", "silver"}
+
+// LBMAScraper implements the Scraper interface for the LBMA gold and silver fixings in USD and CHF
+type LBMAScraper struct {
+	apiURL     string
+	fxURL      string
+	httpClient *http.Client
+}
+
+// NewLBMAScraper creates a new LBMA scraper, USD prices are converted to CHF with the
+// ECB reference rates of the given Frankfurter API
+func NewLBMAScraper(apiURL, fxURL string) *LBMAScraper {
+	return &LBMAScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		fxURL:      strings.TrimSuffix(fxURL, "/"),
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *LBMAScraper) Name() string {
+	return "lbma_precious_metals"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *LBMAScraper) Schedule() time.Duration {
+	// Fixings are published twice a day for gold and once for silver
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *LBMAScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	if s.fxURL == "" {
+		return fmt.Errorf("FX API URL is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *LBMAScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Series returns every fixing in USD and CHF per troy ounce
+func (s *LBMAScraper) Series() []SeriesInfo {
+	var series []SeriesInfo
+	for _, fixing := range lbmaFixings {
+		name := strings.ReplaceAll(fixing, "_", " ")
+		for _, currency := range []string{"USD", "CHF"} {
+			series = append(series, SeriesInfo{
+				Code:        fixing + "_" + strings.ToLower(currency),
+				Description: fmt.Sprintf("LBMA %s price per troy ounce in %s", name, currency),
+				Unit:        currency,
+				Frequency:   FrequencyBusinessDay,
+			})
+		}
+	}
+	return series
+}
+
+// lbmaPrice is an entry of an LBMA price file, with prices in USD, GBP and EUR
+type lbmaPrice struct {
+	Date   string    `json:"d"`
+	Values []float64 `json:"v"`
+}
+
+// Scrape collects the most recent gold AM/PM and silver fixings
+func (s *LBMAScraper) Scrape(ctx context.Context) ([]Result, error) {
+	type fixing struct {
+		series string
+		date   time.Time
+		usd    float64
+	}
+
+	var fixings []fixing
+	for _, series := range lbmaFixings {
+		var prices []lbmaPrice
+		if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/json/"+series+".json", nil, &prices); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s prices: %w", series, err)
+		}
+
+		// Files hold the full history sorted by date
+		for _, price := range prices[len(prices)-min(len(prices), lbmaRecentDays):] {
+			date, err := parseDate(price.Date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s date %q: %w", series, price.Date, err)
+			}
+			// A zero price means the fixing did not take place
+			if len(price.Values) == 0 || price.Values[0] == 0 {
+				continue
+			}
+			fixings = append(fixings, fixing{series: series, date: date, usd: price.Values[0]})
+		}
+	}
+	if len(fixings) == 0 {
+		return nil, nil
+	}
+
+	start, end := fixings[0].date, fixings[0].date
+	for _, f := range fixings {
+		if f.date.Before(start) {
+			start = f.date
+		}
+		if f.date.After(end) {
+			end = f.date
+		}
+	}
+	rates, err := s.usdCHF(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []TimeSeriesPoint
+	for _, f := range fixings {
+		metadata := map[string]string{"per": "troy_ounce"}
+		points = append(points, TimeSeriesPoint{Code: f.series + "_usd", Value: f.usd, Unit: "USD", Timestamp: f.date, Metadata: metadata})
+
+		rate, ok := rateOn(rates, f.date)
+		if !ok {
+			continue
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      f.series + "_chf",
+			Value:     f.usd * rate,
+			Unit:      "CHF",
+			Timestamp: f.date,
+			Metadata:  map[string]string{"per": "troy_ounce", "usd_chf": strconv.FormatFloat(rate, 'f', -1, 64)},
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// usdCHF fetches the daily USD/CHF reference rates between two dates, starting a week
+// earlier so fixings on ECB holidays can fall back to the previous rate
+func (s *LBMAScraper) usdCHF(ctx context.Context, start, end time.Time) (map[time.Time]float64, error) {
+	query := url.Values{}
+	query.Set("from", "USD")
+	query.Set("to", "CHF")
+
+	var resp struct {
+		Rates map[string]map[string]float64 `json:"rates"`
+	}
+	period := start.AddDate(0, 0, -7).Format(time.DateOnly) + ".." + end.Format(time.DateOnly)
+	if err := fetchJSON(ctx, s.httpClient, s.fxURL+"/"+period+"?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch USD/CHF rates: %w", err)
+	}
+
+	rates := make(map[time.Time]float64, len(resp.Rates))
+	for day, values := range resp.Rates {
+		date, err := parseDate(day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate date %q: %w", day, err)
+		}
+		if rate, ok := values["CHF"]; ok {
+			rates[date] = rate
+		}
+	}
+	return rates, nil
+}
+
+// rateOn returns the rate of the given day or the closest earlier one
+func rateOn(rates map[time.Time]float64, date time.Time) (float64, bool) {
+	days := make([]time.Time, 0, len(rates))
+	for day := range rates {
+		if !day.After(date) {
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return 0, false
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].After(days[j]) })
+	return rates[days[0]], true
+}
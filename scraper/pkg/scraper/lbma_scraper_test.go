@@ -0,0 +1,74 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLBMAScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json/gold_am.json":
+			_, _ = w.Write([]byte(`[{"is_cms_locked":0,"d":"2024-11-11","v":[2620.5,2030.1,2450.2]},{"is_cms_locked":0,"d":"2024-11-12","v":[2600,2040.5,2450.75]}]`))
+		case "/json/gold_pm.json":
+			_, _ = w.Write([]byte(`[{"is_cms_locked":0,"d":"2024-11-11","v":[2610,2020,2440]},{"is_cms_locked":0,"d":"2024-11-12","v":[0,0,0]}]`))
+		case "/json/silver.json":
+			_, _ = w.Write([]byte(`[{"is_cms_locked":0,"d":"2024-11-12","v":[30.5,23.9,28.7]}]`))
+		case "/2024-11-04..2024-11-12":
+			assert.Equal(t, "USD", r.URL.Query().Get("from"))
+			assert.Equal(t, "CHF", r.URL.Query().Get("to"))
+			// No reference rate was published on the 12th
+			_, _ = w.Write([]byte(`{"amount":1.0,"base":"USD","start_date":"2024-11-04","end_date":"2024-11-11","rates":{"2024-11-08":{"CHF":0.87},"2024-11-11":{"CHF":0.88}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewLBMAScraper(mockServer.URL, mockServer.URL)
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "lbma_precious_metals", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 8, "Should skip the PM fixing that did not take place")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code+"@"+point.Timestamp.Format(time.DateOnly)] = point.Value
+	}
+	assert.InDeltaMapValues(t, map[string]float64{
+		"gold_am_usd@2024-11-11": 2620.5,
+		"gold_am_chf@2024-11-11": 2620.5 * 0.88,
+		"gold_am_usd@2024-11-12": 2600,
+		"gold_am_chf@2024-11-12": 2600 * 0.88,
+		"gold_pm_usd@2024-11-11": 2610,
+		"gold_pm_chf@2024-11-11": 2610 * 0.88,
+		"silver_usd@2024-11-12":  30.5,
+		"silver_chf@2024-11-12":  30.5 * 0.88,
+	}, values, 1e-9)
+}
+
+func TestRateOn(t *testing.T) {
+	rates := map[time.Time]float64{
+		time.Date(2024, 11, 8, 0, 0, 0, 0, time.UTC):  0.87,
+		time.Date(2024, 11, 11, 0, 0, 0, 0, time.UTC): 0.88,
+	}
+
+	rate, ok := rateOn(rates, time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, 0.87, rate, "Should fall back to the previous rate")
+
+	_, ok = rateOn(rates, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok, "Should not use a later rate")
+}
@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// lidoStETH is the stETH token contract on Ethereum mainnet
+	lidoStETH = common.HexToAddress("0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84")
+	// lidoCurvePool is the Curve ETH/stETH pool used for the secondary market rate
+	lidoCurvePool = common.HexToAddress("0xDC24316b9AE028F1497c275EB9192a3Ea0f67022")
+)
+
+// LidoScraper implements the Scraper interface for Lido staking metrics
+type LidoScraper struct {
+	rpcURL     string
+	apiURL     string
+	client     *ethclient.Client
+	httpClient *http.Client
+}
+
+// NewLidoScraper creates a new Lido scraper using the Lido API and an Ethereum RPC endpoint
+func NewLidoScraper(rpcURL, apiURL string) *LidoScraper {
+	return &LidoScraper{
+		rpcURL:     rpcURL,
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *LidoScraper) Name() string {
+	return "lido_staking"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *LidoScraper) Schedule() time.Duration {
+	// Lido rebases once a day
+	return 24 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *LidoScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *LidoScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// lidoAPRResponse is the response of the stETH APR endpoint
+type lidoAPRResponse struct {
+	Data struct {
+		APRs []struct {
+			TimeUnix int64   `json:"timeUnix"`
+			APR      float64 `json:"apr"`
+		} `json:"aprs"`
+		SMAAPR float64 `json:"smaApr"`
+	} `json:"data"`
+}
+
+// Scrape collects the stETH APR, total pooled ETH and the stETH/ETH market rate
+func (s *LidoScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	var apr lidoAPRResponse
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/v1/protocol/steth/apr/sma", nil, &apr); err != nil {
+		return nil, fmt.Errorf("failed to fetch stETH APR: %w", err)
+	}
+
+	out, err := callContract(ctx, s.client, lidoStETH, "getTotalPooledEther()")
+	if err != nil {
+		return nil, err
+	}
+	pooled, err := wordAt(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode total pooled ether: %w", err)
+	}
+
+	// Selling 1 stETH (coin 1) for ETH (coin 0) on Curve gives the secondary market rate
+	out, err = callContract(ctx, s.client, lidoCurvePool, "get_dy(int128,int128,uint256)",
+		[]byte{1}, []byte{0}, big.NewInt(weiPerEth).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	rate, err := wordAt(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode stETH/ETH rate: %w", err)
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	points := []TimeSeriesPoint{
+		{Code: "steth_apr_sma", Value: apr.Data.SMAAPR, Unit: "percent", Timestamp: day},
+		{Code: "total_pooled_eth", Value: weiToFloat(pooled, weiPerEth), Unit: "ETH", Timestamp: day},
+		{Code: "steth_eth_rate", Value: weiToFloat(rate, weiPerEth), Unit: "ETH", Timestamp: day},
+	}
+	if n := len(apr.Data.APRs); n > 0 {
+		latest := apr.Data.APRs[n-1]
+		points = append(points, TimeSeriesPoint{
+			Code:      "steth_apr",
+			Value:     latest.APR,
+			Unit:      "percent",
+			Timestamp: time.Unix(latest.TimeUnix, 0).UTC().Truncate(24 * time.Hour),
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
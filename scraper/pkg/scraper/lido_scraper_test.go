@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLidoScraper_Scrape(t *testing.T) {
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/protocol/steth/apr/sma" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"aprs":[{"timeUnix":1743638400,"apr":2.91},{"timeUnix":1743724800,"apr":3.02}],"smaApr":2.95},"meta":{"symbol":"stETH"}}`))
+	}))
+	defer apiServer.Close()
+
+	pooled, _ := new(big.Int).SetString("9400000000000000000000000", 10) // 9.4M ETH
+	rpcServer := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			switch {
+			case call.To == lidoStETH && call.Selector() == selector("getTotalPooledEther()"):
+				return abiWords(pooled), nil
+			case call.To == lidoCurvePool && call.Selector() == selector("get_dy(int128,int128,uint256)"):
+				assert.Equal(t, abiWords(big.NewInt(1), big.NewInt(0), big.NewInt(1e18)), call.Input[4:], "Should quote 1 stETH in ETH")
+				return abiWords(big.NewInt(998_700_000_000_000_000)), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s to %s", call.Selector(), call.To.Hex())
+		}),
+	})
+
+	scraper := NewLidoScraper(rpcServer.URL, apiServer.URL)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "lido_staking", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+		assert.Zero(t, point.Timestamp.Hour(), "Points should be daily")
+	}
+	assert.Equal(t, 2.95, values["steth_apr_sma"])
+	assert.Equal(t, 3.02, values["steth_apr"])
+	assert.Equal(t, 9_400_000.0, values["total_pooled_eth"])
+	assert.InDelta(t, 0.9987, values["steth_eth_rate"], 1e-12)
+}
@@ -0,0 +1,215 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// makerVat holds the debt and collateral accounting of all vaults
+	makerVat = common.HexToAddress("0x35D1b3F3D7966A1DFe207aa4514C12a259A0492B")
+	// makerSpotter holds the liquidation ratio of every vault type
+	makerSpotter = common.HexToAddress("0x65C79fcB50Ca1594B025960e539eD7A9a6D434A3")
+	// makerPot holds the DAI Savings Rate
+	makerPot = common.HexToAddress("0x197E90f9FAD81970bA7976f33CbD77088E5D7cf7")
+	// makerDAI is the DAI token contract
+	makerDAI = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+)
+
+// DefaultMakerVaultTypes lists the major vault types as "ilk:join adapter" entries
+var DefaultMakerVaultTypes = []string{
+	"ETH-A:0x2F0b23f53734252Bda2277357e97e1517d6B042A",
+	"WBTC-A:0xBF72Da2Bd84c5170618Fbe5914B0ECA9638d5eb5",
+	"WSTETH-A:0x10CD5fbe1b404B7E19Ef964B63939907bdaf42E2",
+}
+
+// makerJoin describes the collateral token of a join adapter
+type makerJoin struct {
+	gem      common.Address
+	decimals uint8
+}
+
+// MakerScraper implements the Scraper interface for MakerDAO / Sky DSR and DAI statistics
+type MakerScraper struct {
+	rpcURL     string
+	vaultTypes []Contract
+	client     *ethclient.Client
+	joins      map[string]makerJoin
+}
+
+// NewMakerScraper creates a new MakerDAO scraper for the given vault types and their join adapters
+func NewMakerScraper(rpcURL string, vaultTypes []Contract) *MakerScraper {
+	return &MakerScraper{
+		rpcURL:     rpcURL,
+		vaultTypes: vaultTypes,
+		joins:      make(map[string]makerJoin),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *MakerScraper) Name() string {
+	return "maker_dai"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *MakerScraper) Schedule() time.Duration {
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *MakerScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *MakerScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape collects the DSR, the total DAI supply and the collateralization of every vault type
+func (s *MakerScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	dsr, err := s.callWord(ctx, makerPot, "dsr()", 0)
+	if err != nil {
+		return nil, err
+	}
+	supply, err := s.callWord(ctx, makerDAI, "totalSupply()", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	points := []TimeSeriesPoint{
+		// The DSR is a per-second compounding factor in ray
+		{Code: "dsr", Value: (math.Pow(rayToFloat(dsr), secondsPerYear) - 1) * 100, Unit: "percent", Timestamp: now},
+		{Code: "dai_total_supply", Value: weiToFloat(supply, weiPerEth), Unit: "DAI", Timestamp: now},
+	}
+
+	for _, vaultType := range s.vaultTypes {
+		vaultPoints, err := s.scrapeVaultType(ctx, vaultType, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrape vault type %s: %w", vaultType.Label, err)
+		}
+		points = append(points, vaultPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// scrapeVaultType computes the debt, collateral and collateralization ratio of a vault type
+func (s *MakerScraper) scrapeVaultType(ctx context.Context, vaultType Contract, now time.Time) ([]TimeSeriesPoint, error) {
+	ilk := common.RightPadBytes([]byte(vaultType.Label), 32)
+
+	join, err := s.join(ctx, vaultType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vat.ilks returns Art (wad), rate (ray), spot (ray), line and dust
+	out, err := callContract(ctx, s.client, makerVat, "ilks(bytes32)", ilk)
+	if err != nil {
+		return nil, err
+	}
+	art, err := wordAt(out, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vat ilk: %w", err)
+	}
+	rate, _ := wordAt(out, 1)
+	spot, _ := wordAt(out, 2)
+
+	// Spotter.ilks returns the price feed and the liquidation ratio (ray)
+	mat, err := s.callWord(ctx, makerSpotter, "ilks(bytes32)", 1, ilk)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := s.callWord(ctx, join.gem, "balanceOf(address)", 0, vaultType.Address.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	debt := weiToFloat(art, weiPerEth) * rayToFloat(rate)
+	collateral := scaleDecimals(locked, join.decimals)
+	// spot is the price divided by the liquidation ratio
+	price := rayToFloat(spot) * rayToFloat(mat)
+
+	metadata := map[string]string{"ilk": vaultType.Label, "join": vaultType.Address.Hex()}
+	point := func(metric string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{
+			Code:      slug(vaultType.Label) + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: now,
+			Metadata:  metadata,
+		}
+	}
+
+	points := []TimeSeriesPoint{
+		point("debt", debt, "DAI"),
+		point("collateral", collateral, vaultType.Label),
+		point("liquidation_ratio", rayToFloat(mat)*100, "percent"),
+	}
+	if debt > 0 {
+		points = append(points, point("collateralization_ratio", collateral*price/debt*100, "percent"))
+	}
+	return points, nil
+}
+
+// join returns the collateral token of a join adapter, which never changes and is cached
+func (s *MakerScraper) join(ctx context.Context, vaultType Contract) (makerJoin, error) {
+	if join, ok := s.joins[vaultType.Label]; ok {
+		return join, nil
+	}
+
+	gem, err := s.callWord(ctx, vaultType.Address, "gem()", 0)
+	if err != nil {
+		return makerJoin{}, err
+	}
+	decimals, err := s.callWord(ctx, vaultType.Address, "dec()", 0)
+	if err != nil {
+		return makerJoin{}, err
+	}
+	if decimals.BitLen() > 8 {
+		return makerJoin{}, fmt.Errorf("invalid decimals for %s", vaultType.Label)
+	}
+
+	join := makerJoin{gem: common.BigToAddress(gem), decimals: uint8(decimals.Uint64())}
+	s.joins[vaultType.Label] = join
+	return join, nil
+}
+
+// callWord calls a contract method and returns the n-th word of the result
+func (s *MakerScraper) callWord(ctx context.Context, address common.Address, signature string, n int, args ...[]byte) (*big.Int, error) {
+	out, err := callContract(ctx, s.client, address, signature, args...)
+	if err != nil {
+		return nil, err
+	}
+	value, err := wordAt(out, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", signature, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,79 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakerScraper_Scrape(t *testing.T) {
+	vaultTypes, err := ParseContracts(DefaultMakerVaultTypes[:1])
+	require.NoError(t, err)
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+	parse := func(value string) *big.Int {
+		n, ok := new(big.Int).SetString(value, 10)
+		require.True(t, ok)
+		return n
+	}
+	ilk := common.RightPadBytes([]byte("ETH-A"), 32)
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			switch {
+			case call.To == makerPot && call.Selector() == selector("dsr()"):
+				return abiWords(parse("1000000001243680656318820312")), nil // ~4% APY
+			case call.To == makerDAI && call.Selector() == selector("totalSupply()"):
+				return abiWords(parse("5000000000000000000000000000")), nil // 5bn DAI
+			case call.To == vaultTypes[0].Address && call.Selector() == selector("gem()"):
+				return common.LeftPadBytes(weth.Bytes(), 32), nil
+			case call.To == vaultTypes[0].Address && call.Selector() == selector("dec()"):
+				return abiWords(big.NewInt(18)), nil
+			case call.To == makerVat && call.Selector() == selector("ilks(bytes32)"):
+				assert.Equal(t, ilk, call.Input[4:], "Ilk should be right padded")
+				return abiWords(
+					parse("100000000000000000000000000"),     // Art: 100M
+					parse("1100000000000000000000000000"),    // rate: 1.1
+					parse("1000000000000000000000000000000"), // spot: 1000 (price 1450 / mat 1.45)
+					big.NewInt(0),
+					big.NewInt(0),
+				), nil
+			case call.To == makerSpotter && call.Selector() == selector("ilks(bytes32)"):
+				return abiWords(big.NewInt(0), parse("1450000000000000000000000000")), nil
+			case call.To == weth && call.Selector() == selector("balanceOf(address)"):
+				return abiWords(parse("200000000000000000000000")), nil // 200k ETH
+			}
+			return nil, fmt.Errorf("unexpected call %s to %s", call.Selector(), call.To.Hex())
+		}),
+	})
+
+	scraper := NewMakerScraper(server.URL, vaultTypes)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "maker_dai", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.InDelta(t, 4.0, values["dsr"], 1e-3)
+	assert.Equal(t, 5_000_000_000.0, values["dai_total_supply"])
+	assert.InDelta(t, 110_000_000.0, values["eth_a_debt"], 1e-3)
+	assert.Equal(t, 200_000.0, values["eth_a_collateral"])
+	assert.InDelta(t, 145.0, values["eth_a_liquidation_ratio"], 1e-9)
+	// 200k ETH * 1450 USD / 110M DAI
+	assert.InDelta(t, 263.636, values["eth_a_collateralization_ratio"], 1e-3)
+}
@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// mempoolFeePercentiles are the priority fee percentiles emitted per sample
+var mempoolFeePercentiles = []int{10, 25, 50, 75, 90}
+
+// MempoolScraper implements the Scraper interface for Ethereum pending transaction pool metrics
+type MempoolScraper struct {
+	rpcURL string
+	client *rpc.Client
+}
+
+// NewMempoolScraper creates a new mempool scraper for the given RPC endpoint
+func NewMempoolScraper(rpcURL string) *MempoolScraper {
+	return &MempoolScraper{
+		rpcURL: rpcURL,
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *MempoolScraper) Name() string {
+	return "ethereum_mempool"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *MempoolScraper) Schedule() time.Duration {
+	return 1 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *MempoolScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *MempoolScraper) Init(ctx context.Context) error {
+	client, err := dialRPC(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// txpoolStatus is the response of the txpool_status RPC method
+type txpoolStatus struct {
+	Pending hexutil.Uint64 `json:"pending"`
+	Queued  hexutil.Uint64 `json:"queued"`
+}
+
+// pendingBlock is the subset of the pending block needed to sample fees
+type pendingBlock struct {
+	BaseFee      *hexutil.Big `json:"baseFeePerGas"`
+	Transactions []struct {
+		GasPrice             *hexutil.Big `json:"gasPrice"`
+		MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	} `json:"transactions"`
+}
+
+// Scrape samples the transaction pool size and the fees of pending transactions
+func (s *MempoolScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	var status txpoolStatus
+	if err := s.client.CallContext(ctx, &status, "txpool_status"); err != nil {
+		return nil, fmt.Errorf("failed to fetch txpool status: %w", err)
+	}
+
+	// The pending block holds the transactions the node would include next
+	var block pendingBlock
+	if err := s.client.CallContext(ctx, &block, "eth_getBlockByNumber", "pending", true); err != nil {
+		return nil, fmt.Errorf("failed to fetch pending block: %w", err)
+	}
+
+	var gasPrices, priorityFees []float64
+	for _, tx := range block.Transactions {
+		if tx.GasPrice == nil {
+			continue
+		}
+		gasPrice := tx.GasPrice.ToInt()
+		gasPrices = append(gasPrices, weiToFloat(gasPrice, weiPerGwei))
+
+		switch {
+		case tx.MaxPriorityFeePerGas != nil:
+			priorityFees = append(priorityFees, weiToFloat(tx.MaxPriorityFeePerGas.ToInt(), weiPerGwei))
+		case block.BaseFee != nil:
+			tip := new(big.Int).Sub(gasPrice, block.BaseFee.ToInt())
+			priorityFees = append(priorityFees, max(weiToFloat(tip, weiPerGwei), 0))
+		}
+	}
+	slices.Sort(gasPrices)
+	slices.Sort(priorityFees)
+
+	now := time.Now()
+	point := func(code string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{Code: code, Value: value, Unit: unit, Timestamp: now}
+	}
+
+	points := []TimeSeriesPoint{
+		point("pending_tx_count", float64(status.Pending), "transactions"),
+		point("queued_tx_count", float64(status.Queued), "transactions"),
+	}
+	if len(gasPrices) > 0 {
+		points = append(points, point("median_gas_price", percentile(gasPrices, 50), "gwei"))
+	}
+	if len(priorityFees) > 0 {
+		for _, p := range mempoolFeePercentiles {
+			points = append(points, point(fmt.Sprintf("priority_fee_p%d", p), percentile(priorityFees, p), "gwei"))
+		}
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+		Metadata: map[string]string{
+			"sampled_transactions": fmt.Sprint(len(gasPrices)),
+		},
+	}
+
+	return []Result{result}, nil
+}
+
+// percentile returns the p-th percentile of sorted values using linear interpolation
+func percentile(sorted []float64, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := float64(p) / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
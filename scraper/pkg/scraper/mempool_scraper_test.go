@@ -0,0 +1,63 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMempoolScraper_Scrape(t *testing.T) {
+	server := newRPCServer(t, map[string]rpcHandler{
+		"txpool_status": func(params []json.RawMessage) (any, error) {
+			return map[string]string{"pending": "0x1770", "queued": "0x64"}, nil
+		},
+		"eth_getBlockByNumber": func(params []json.RawMessage) (any, error) {
+			return map[string]any{
+				"baseFeePerGas": "0x2540be400", // 10 gwei
+				"transactions": []map[string]string{
+					{"gasPrice": "0x2cb417800", "maxPriorityFeePerGas": "0x77359400"}, // 12 gwei, tip 2 gwei
+					{"gasPrice": "0x2e90edd00"},                                       // legacy 12.5 gwei
+					{"gasPrice": "0x37e11d600", "maxPriorityFeePerGas": "0x3b9aca00"}, // 15 gwei, tip 1 gwei
+				},
+			}, nil
+		},
+	})
+
+	scraper := NewMempoolScraper(server.URL)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "ethereum_mempool", results[0].Source)
+	assert.Equal(t, "3", results[0].Metadata["sampled_transactions"])
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, 6000.0, values["pending_tx_count"])
+	assert.Equal(t, 100.0, values["queued_tx_count"])
+	assert.InDelta(t, 12.5, values["median_gas_price"], 1e-9)
+	assert.InDelta(t, 2.0, values["priority_fee_p50"], 1e-9)
+	assert.InDelta(t, 1.2, values["priority_fee_p10"], 1e-9)
+	assert.InDelta(t, 2.4, values["priority_fee_p90"], 1e-9)
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 3.0, percentile(values, 50))
+	assert.Equal(t, 4.0, percentile(values, 75))
+	assert.InDelta(t, 4.6, percentile(values, 90), 1e-9)
+	assert.Equal(t, 5.0, percentile(values, 100))
+	assert.Equal(t, 0.0, percentile(nil, 50))
+}
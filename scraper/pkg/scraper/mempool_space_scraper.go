@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MempoolSpaceScraper implements the Scraper interface for the mempool.space public API
+type MempoolSpaceScraper struct {
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewMempoolSpaceScraper creates a new mempool.space scraper
+func NewMempoolSpaceScraper(apiURL string) *MempoolSpaceScraper {
+	return &MempoolSpaceScraper{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *MempoolSpaceScraper) Name() string {
+	return "mempool_space"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *MempoolSpaceScraper) Schedule() time.Duration {
+	return 10 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *MempoolSpaceScraper) Validate(ctx context.Context) error {
+	if s.apiURL == "" {
+		return fmt.Errorf("API URL is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *MempoolSpaceScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// mempoolSpaceFees is the response of the recommended fees endpoint
+type mempoolSpaceFees struct {
+	FastestFee  float64 `json:"fastestFee"`
+	HalfHourFee float64 `json:"halfHourFee"`
+	HourFee     float64 `json:"hourFee"`
+	EconomyFee  float64 `json:"economyFee"`
+	MinimumFee  float64 `json:"minimumFee"`
+}
+
+// mempoolSpaceBacklog is the response of the mempool endpoint
+type mempoolSpaceBacklog struct {
+	Count    uint64 `json:"count"`
+	VSize    uint64 `json:"vsize"`
+	TotalFee uint64 `json:"total_fee"`
+}
+
+// mempoolSpacePools is the response of the mining pools endpoint
+type mempoolSpacePools struct {
+	BlockCount uint64 `json:"blockCount"`
+	Pools      []struct {
+		Name       string `json:"name"`
+		Slug       string `json:"slug"`
+		BlockCount uint64 `json:"blockCount"`
+	} `json:"pools"`
+}
+
+// Scrape collects recommended fees, mempool backlog and the weekly mining pool distribution
+func (s *MempoolSpaceScraper) Scrape(ctx context.Context) ([]Result, error) {
+	var fees mempoolSpaceFees
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v1/fees/recommended", nil, &fees); err != nil {
+		return nil, fmt.Errorf("failed to fetch recommended fees: %w", err)
+	}
+
+	var backlog mempoolSpaceBacklog
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/mempool", nil, &backlog); err != nil {
+		return nil, fmt.Errorf("failed to fetch mempool backlog: %w", err)
+	}
+
+	var pools mempoolSpacePools
+	if err := fetchJSON(ctx, s.httpClient, s.apiURL+"/api/v1/mining/pools/1w", nil, &pools); err != nil {
+		return nil, fmt.Errorf("failed to fetch mining pools: %w", err)
+	}
+
+	now := time.Now()
+	point := func(code string, value float64, unit string) TimeSeriesPoint {
+		return TimeSeriesPoint{Code: code, Value: value, Unit: unit, Timestamp: now}
+	}
+
+	points := []TimeSeriesPoint{
+		point("fee_fastest", fees.FastestFee, "sat/vB"),
+		point("fee_half_hour", fees.HalfHourFee, "sat/vB"),
+		point("fee_hour", fees.HourFee, "sat/vB"),
+		point("fee_economy", fees.EconomyFee, "sat/vB"),
+		point("fee_minimum", fees.MinimumFee, "sat/vB"),
+		point("mempool_tx_count", float64(backlog.Count), "transactions"),
+		point("mempool_vsize", float64(backlog.VSize)/1e6, "MvB"),
+		point("mempool_total_fee", float64(backlog.TotalFee)/1e8, "BTC"),
+	}
+
+	if pools.BlockCount > 0 {
+		for _, pool := range pools.Pools {
+			share := point("pool_share_"+pool.Slug, float64(pool.BlockCount)/float64(pools.BlockCount)*100, "percent")
+			share.Metadata = map[string]string{"pool": pool.Name, "period": "1w"}
+			points = append(points, share)
+		}
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+		Metadata: map[string]string{
+			"url": s.apiURL,
+		},
+	}
+
+	return []Result{result}, nil
+}
@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMempoolSpaceScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/fees/recommended":
+			_, _ = w.Write([]byte(`{"fastestFee":12,"halfHourFee":8,"hourFee":6,"economyFee":3,"minimumFee":1}`))
+		case "/api/mempool":
+			_, _ = w.Write([]byte(`{"count":42000,"vsize":31500000,"total_fee":25000000,"fee_histogram":[]}`))
+		case "/api/v1/mining/pools/1w":
+			_, _ = w.Write([]byte(`{"pools":[{"poolId":111,"name":"Foundry USA","slug":"foundryusa","blockCount":300},{"poolId":43,"name":"AntPool","slug":"antpool","blockCount":200}],"blockCount":1000}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	scraper := NewMempoolSpaceScraper(mockServer.URL)
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "mempool_space", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"fee_fastest":           12,
+		"fee_half_hour":         8,
+		"fee_hour":              6,
+		"fee_economy":           3,
+		"fee_minimum":           1,
+		"mempool_tx_count":      42000,
+		"mempool_vsize":         31.5,
+		"mempool_total_fee":     0.25,
+		"pool_share_foundryusa": 30,
+		"pool_share_antpool":    20,
+	}, values)
+	assert.Equal(t, "Foundry USA", points[len(points)-2].Metadata["pool"])
+}
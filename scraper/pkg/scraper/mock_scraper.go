@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// DefaultMockSeries lists the series generated by the mock scraper when none are configured
+var DefaultMockSeries = []string{"mock_price", "mock_rate"}
+
+// MockScraper implements the Scraper interface with generated data instead of an external
+// source, each series follows a random walk. It lets the pipeline run in development without
+// API keys or network access
+type MockScraper struct {
+	series []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewMockScraper creates a new mock scraper generating the given series
+func NewMockScraper(series []string) *MockScraper {
+	return &MockScraper{
+		series: series,
+		values: make(map[string]float64, len(series)),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *MockScraper) Name() string {
+	return "mock"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *MockScraper) Schedule() time.Duration {
+	return 10 * time.Second
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *MockScraper) Validate(ctx context.Context) error {
+	if len(s.series) == 0 {
+		return fmt.Errorf("at least one series is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *MockScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Series returns the generated series
+func (s *MockScraper) Series() []SeriesInfo {
+	series := make([]SeriesInfo, 0, len(s.series))
+	for _, code := range s.series {
+		series = append(series, SeriesInfo{
+			Code:        code,
+			Description: "Generated random walk for development",
+			Unit:        "index",
+			Frequency:   FrequencyTick,
+		})
+	}
+	return series
+}
+
+// Scrape moves every series by a random step of up to 1% from its previous value, starting at 100
+func (s *MockScraper) Scrape(ctx context.Context) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	points := make([]TimeSeriesPoint, 0, len(s.series))
+	for _, code := range s.series {
+		value, ok := s.values[code]
+		if !ok {
+			value = 100
+		}
+		value *= 1 + (rand.Float64()*2-1)/100
+		s.values[code] = value
+
+		points = append(points, TimeSeriesPoint{
+			Code:      code,
+			Value:     value,
+			Unit:      "index",
+			Timestamp: now,
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
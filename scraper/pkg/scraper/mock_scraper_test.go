@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockScraper_Scrape(t *testing.T) {
+	scraper := NewMockScraper(DefaultMockSeries)
+	require.NoError(t, scraper.Validate(context.Background()))
+	assert.Len(t, scraper.Series(), len(DefaultMockSeries))
+
+	previous := 100.0
+	for range 3 {
+		results, err := scraper.Scrape(context.Background())
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "mock", results[0].Source)
+
+		points, ok := results[0].Data.([]TimeSeriesPoint)
+		require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+		require.Len(t, points, 2)
+		assert.Equal(t, "mock_price", points[0].Code)
+		assert.InEpsilon(t, previous, points[0].Value, 0.01, "Each step moves by at most 1%")
+		previous = points[0].Value
+	}
+}
+
+func TestMockScraper_Validate(t *testing.T) {
+	assert.Error(t, NewMockScraper(nil).Validate(context.Background()))
+}
@@ -0,0 +1,264 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openInterest is the open interest of an asset on a venue in base units and USD
+type openInterest struct {
+	base float64
+	usd  float64
+}
+
+// OpenInterestScraper implements the Scraper interface for BTC and ETH derivatives open interest,
+// per venue and aggregated across venues
+type OpenInterestScraper struct {
+	venues     []derivativesVenue
+	deribitURL string
+	assets     []string
+	httpClient *http.Client
+}
+
+// NewOpenInterestScraper creates a new open interest scraper for the given venues and assets
+func NewOpenInterestScraper(venues DerivativesVenues, assets []string) *OpenInterestScraper {
+	return &OpenInterestScraper{
+		venues:     venues.list(),
+		deribitURL: strings.TrimSuffix(venues.DeribitURL, "/"),
+		assets:     assets,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *OpenInterestScraper) Name() string {
+	return "derivatives_open_interest"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *OpenInterestScraper) Schedule() time.Duration {
+	return 15 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *OpenInterestScraper) Validate(ctx context.Context) error {
+	if len(s.venues) == 0 && s.deribitURL == "" {
+		return fmt.Errorf("at least one venue is required")
+	}
+	if len(s.assets) == 0 {
+		return fmt.Errorf("at least one asset is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *OpenInterestScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Scrape collects the perpetual futures and options open interest of every asset on every venue,
+// skipping venues that fail so a single outage does not hide the others
+func (s *OpenInterestScraper) Scrape(ctx context.Context) ([]Result, error) {
+	timestamp := time.Now().UTC()
+
+	var points []TimeSeriesPoint
+	var failed []string
+	for _, asset := range s.assets {
+		asset = strings.ToUpper(asset)
+		prefix := strings.ToLower(asset) + "_"
+
+		point := func(code string, value float64, unit string, metadata map[string]string) TimeSeriesPoint {
+			return TimeSeriesPoint{Code: code, Value: value, Unit: unit, Timestamp: timestamp, Metadata: metadata}
+		}
+
+		var futuresUSD, optionsUSD float64
+		var futuresVenues, optionsVenues []string
+		for _, venue := range s.venues {
+			symbol := venue.symbol(asset)
+			oi, err := s.futures(ctx, venue, symbol)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to fetch open interest", "venue", venue.name, "symbol", symbol, "error", err)
+				failed = append(failed, venue.name+":"+symbol)
+				continue
+			}
+
+			metadata := map[string]string{"venue": venue.name, "symbol": symbol, "kind": "futures"}
+			points = append(points,
+				point(venue.name+"_"+prefix+"futures_open_interest", oi.base, asset, metadata),
+				point(venue.name+"_"+prefix+"futures_open_interest_usd", oi.usd, "USD", metadata),
+			)
+			futuresUSD += oi.usd
+			futuresVenues = append(futuresVenues, venue.name)
+		}
+
+		if s.deribitURL != "" {
+			oi, err := s.deribitOptions(ctx, asset)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to fetch options open interest", "venue", "deribit", "currency", asset, "error", err)
+				failed = append(failed, "deribit:"+asset)
+			} else {
+				metadata := map[string]string{"venue": "deribit", "currency": asset, "kind": "options"}
+				points = append(points,
+					point("deribit_"+prefix+"options_open_interest", oi.base, asset, metadata),
+					point("deribit_"+prefix+"options_open_interest_usd", oi.usd, "USD", metadata),
+				)
+				optionsUSD += oi.usd
+				optionsVenues = append(optionsVenues, "deribit")
+			}
+		}
+
+		// Aggregates are only meaningful in USD as venues quote contracts differently
+		if len(futuresVenues) > 0 {
+			points = append(points, point(prefix+"futures_open_interest_usd", futuresUSD, "USD",
+				map[string]string{"venues": strings.Join(futuresVenues, ","), "kind": "futures"}))
+		}
+		if len(optionsVenues) > 0 {
+			points = append(points, point(prefix+"options_open_interest_usd", optionsUSD, "USD",
+				map[string]string{"venues": strings.Join(optionsVenues, ","), "kind": "options"}))
+		}
+		if venues := slices.Concat(futuresVenues, optionsVenues); len(venues) > 0 {
+			points = append(points, point(prefix+"open_interest_usd", futuresUSD+optionsUSD, "USD",
+				map[string]string{"venues": strings.Join(venues, ",")}))
+		}
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("failed to fetch open interest from every venue")
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+	if len(failed) > 0 {
+		result.Metadata = map[string]string{"failed": strings.Join(failed, ",")}
+	}
+
+	return []Result{result}, nil
+}
+
+// futures dispatches to the venue specific perpetual open interest endpoints
+func (s *OpenInterestScraper) futures(ctx context.Context, venue derivativesVenue, symbol string) (openInterest, error) {
+	switch venue.name {
+	case "binance":
+		return s.binance(ctx, venue.apiURL, symbol)
+	case "bybit":
+		return s.bybit(ctx, venue.apiURL, symbol)
+	case "okx":
+		return s.okx(ctx, venue.apiURL, symbol)
+	}
+	return openInterest{}, fmt.Errorf("unsupported venue %q", venue.name)
+}
+
+// binance fetches the open interest in base units and values it at the mark price
+func (s *OpenInterestScraper) binance(ctx context.Context, apiURL, symbol string) (openInterest, error) {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+
+	var oi struct {
+		OpenInterest string `json:"openInterest"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, apiURL+"/fapi/v1/openInterest?"+query.Encode(), nil, &oi); err != nil {
+		return openInterest{}, fmt.Errorf("failed to fetch open interest: %w", err)
+	}
+
+	var premium struct {
+		MarkPrice string `json:"markPrice"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, apiURL+"/fapi/v1/premiumIndex?"+query.Encode(), nil, &premium); err != nil {
+		return openInterest{}, fmt.Errorf("failed to fetch mark price: %w", err)
+	}
+
+	base, err := strconv.ParseFloat(oi.OpenInterest, 64)
+	if err != nil {
+		return openInterest{}, fmt.Errorf("invalid open interest %q: %w", oi.OpenInterest, err)
+	}
+	markPrice, err := strconv.ParseFloat(premium.MarkPrice, 64)
+	if err != nil {
+		return openInterest{}, fmt.Errorf("invalid mark price %q: %w", premium.MarkPrice, err)
+	}
+	return openInterest{base: base, usd: base * markPrice}, nil
+}
+
+// bybit fetches the open interest and its value from the linear ticker
+func (s *OpenInterestScraper) bybit(ctx context.Context, apiURL, symbol string) (openInterest, error) {
+	query := url.Values{}
+	query.Set("category", "linear")
+	query.Set("symbol", symbol)
+
+	ticker, err := fetchBybit[struct {
+		OpenInterest      string `json:"openInterest"`
+		OpenInterestValue string `json:"openInterestValue"`
+	}](ctx, s.httpClient, apiURL+"/v5/market/tickers?"+query.Encode())
+	if err != nil {
+		return openInterest{}, fmt.Errorf("failed to fetch ticker: %w", err)
+	}
+
+	return parseOpenInterest(ticker.OpenInterest, ticker.OpenInterestValue)
+}
+
+// okx fetches the open interest of a swap in base currency and USD
+func (s *OpenInterestScraper) okx(ctx context.Context, apiURL, symbol string) (openInterest, error) {
+	query := url.Values{}
+	query.Set("instType", "SWAP")
+	query.Set("instId", symbol)
+
+	oi, err := fetchOKX[struct {
+		OICcy string `json:"oiCcy"`
+		OIUsd string `json:"oiUsd"`
+	}](ctx, s.httpClient, apiURL+"/api/v5/public/open-interest?"+query.Encode())
+	if err != nil {
+		return openInterest{}, fmt.Errorf("failed to fetch open interest: %w", err)
+	}
+
+	return parseOpenInterest(oi.OICcy, oi.OIUsd)
+}
+
+// deribitOptions sums the open interest of every listed option of a currency
+func (s *OpenInterestScraper) deribitOptions(ctx context.Context, currency string) (openInterest, error) {
+	query := url.Values{}
+	query.Set("currency", currency)
+	query.Set("kind", "option")
+
+	var resp struct {
+		Result []struct {
+			OpenInterest    float64 `json:"open_interest"`
+			UnderlyingPrice float64 `json:"underlying_price"`
+		} `json:"result"`
+	}
+	if err := fetchJSON(ctx, s.httpClient, s.deribitURL+"/api/v2/public/get_book_summary_by_currency?"+query.Encode(), nil, &resp); err != nil {
+		return openInterest{}, fmt.Errorf("failed to fetch book summary: %w", err)
+	}
+	if len(resp.Result) == 0 {
+		return openInterest{}, fmt.Errorf("no options listed")
+	}
+
+	// Option open interest is denominated in the underlying currency
+	var oi openInterest
+	for _, summary := range resp.Result {
+		oi.base += summary.OpenInterest
+		oi.usd += summary.OpenInterest * summary.UnderlyingPrice
+	}
+	return oi, nil
+}
+
+// parseOpenInterest parses an open interest returned as strings in base units and USD
+func parseOpenInterest(base, usd string) (openInterest, error) {
+	baseValue, err := strconv.ParseFloat(base, 64)
+	if err != nil {
+		return openInterest{}, fmt.Errorf("invalid open interest %q: %w", base, err)
+	}
+	usdValue, err := strconv.ParseFloat(usd, 64)
+	if err != nil {
+		return openInterest{}, fmt.Errorf("invalid open interest value %q: %w", usd, err)
+	}
+	return openInterest{base: baseValue, usd: usdValue}, nil
+}
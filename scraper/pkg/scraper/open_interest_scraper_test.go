@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenInterestScraper_Scrape(t *testing.T) {
+	server := newDerivativesServer(t, map[string]string{
+		"/fapi/v1/openInterest":                       `{"openInterest":"80000.5","symbol":"BTCUSDT","time":1731640000000}`,
+		"/fapi/v1/premiumIndex":                       `{"symbol":"BTCUSDT","markPrice":"64000.00","lastFundingRate":"0.0001"}`,
+		"/v5/market/tickers":                          `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[{"symbol":"BTCUSDT","openInterest":"50000","openInterestValue":"3200000000"}]}}`,
+		"/api/v5/public/open-interest":                `{"code":"0","msg":"","data":[{"instId":"BTC-USDT-SWAP","instType":"SWAP","oi":"2500000","oiCcy":"25000","oiUsd":"1600000000","ts":"1731640000000"}]}`,
+		"/api/v2/public/get_book_summary_by_currency": `{"jsonrpc":"2.0","result":[{"instrument_name":"BTC-27DEC24-70000-C","open_interest":1000,"underlying_price":64100},{"instrument_name":"BTC-27DEC24-60000-P","open_interest":500.5,"underlying_price":64200}]}`,
+	})
+
+	venues := DerivativesVenues{BinanceURL: server.URL, BybitURL: server.URL, OKXURL: server.URL, DeribitURL: server.URL}
+	scraper := NewOpenInterestScraper(venues, []string{"BTC"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "derivatives_open_interest", results[0].Source)
+	assert.Empty(t, results[0].Metadata["failed"])
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+
+	optionsUSD := 1000*64100 + 500.5*64200
+	expected := map[string]float64{
+		"binance_btc_futures_open_interest":     80000.5,
+		"binance_btc_futures_open_interest_usd": 80000.5 * 64000,
+		"bybit_btc_futures_open_interest":       50000,
+		"bybit_btc_futures_open_interest_usd":   3_200_000_000,
+		"okx_btc_futures_open_interest":         25000,
+		"okx_btc_futures_open_interest_usd":     1_600_000_000,
+		"deribit_btc_options_open_interest":     1500.5,
+		"deribit_btc_options_open_interest_usd": optionsUSD,
+		"btc_futures_open_interest_usd":         80000.5*64000 + 3_200_000_000 + 1_600_000_000,
+		"btc_options_open_interest_usd":         optionsUSD,
+		"btc_open_interest_usd":                 80000.5*64000 + 3_200_000_000 + 1_600_000_000 + optionsUSD,
+	}
+	require.Len(t, values, len(expected))
+	for code, value := range expected {
+		assert.InDelta(t, value, values[code], 1e-3, "Metric %s should have correct value", code)
+	}
+}
+
+func TestOpenInterestScraper_VenueFailure(t *testing.T) {
+	server := newDerivativesServer(t, map[string]string{
+		"/api/v5/public/open-interest": `{"code":"51001","msg":"Instrument ID does not exist","data":[]}`,
+		"/v5/market/tickers":           `{"retCode":0,"retMsg":"OK","result":{"list":[{"symbol":"ETHUSDT","openInterest":"1000","openInterestValue":"2800000"}]}}`,
+	})
+
+	venues := DerivativesVenues{BybitURL: server.URL, OKXURL: server.URL}
+	results, err := NewOpenInterestScraper(venues, []string{"ETH"}).Scrape(context.Background())
+	require.NoError(t, err, "A failing venue should not fail the whole scrape")
+	require.Len(t, results, 1)
+	assert.Equal(t, "okx:ETH-USDT-SWAP", results[0].Metadata["failed"])
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	total := points[len(points)-1]
+	assert.Equal(t, "eth_open_interest_usd", total.Code)
+	assert.Equal(t, 2_800_000.0, total.Value)
+	assert.Equal(t, "bybit", total.Metadata["venues"], "Aggregate should only include healthy venues")
+}
@@ -0,0 +1,87 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Payload is a response body fetched by a scraper, kept as received so it can be parsed again
+type Payload struct {
+	URL         string
+	ContentType string
+	Body        []byte
+	FetchedAt   time.Time
+}
+
+// PayloadRecorder collects the payloads fetched during a scrape
+type PayloadRecorder struct {
+	mu       sync.Mutex
+	payloads []Payload
+}
+
+// payloadRecorderKey is the context key of the PayloadRecorder
+type payloadRecorderKey struct{}
+
+// WithPayloadRecorder returns a context recording the payloads fetched with it in recorder
+func WithPayloadRecorder(ctx context.Context, recorder *PayloadRecorder) context.Context {
+	return context.WithValue(ctx, payloadRecorderKey{}, recorder)
+}
+
+// redactedSegment replaces the path segments of a payload URL that hold a credential
+const redactedSegment = "REDACTED"
+
+// keySegment matches path segments shaped like API keys, e.g. the project ID of Infura URLs
+var keySegment = regexp.MustCompile(`^[0-9a-fA-F]{32,}$`)
+
+// payloadURL returns the URL of a payload without its credentials, payloads are stored and
+// archived. The user info and the query, where sources take their API key, are dropped, and the
+// path segments holding a key are masked: the segment after "api" of The Graph gateway URLs and
+// segments shaped like keys
+func payloadURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+
+	segments := strings.Split(parsed.Path, "/")
+	for i, segment := range segments {
+		if keySegment.MatchString(segment) || (i > 0 && segments[i-1] == "api" && segment != "") {
+			segments[i] = redactedSegment
+		}
+	}
+	parsed.Path = strings.Join(segments, "/")
+	parsed.RawPath = ""
+	return parsed.String()
+}
+
+// recordPayload adds a fetched body to the recorder of the context, if any, the credentials of
+// url are removed
+func recordPayload(ctx context.Context, url, contentType string, body []byte) {
+	recorder, ok := ctx.Value(payloadRecorderKey{}).(*PayloadRecorder)
+	if !ok {
+		return
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.payloads = append(recorder.payloads, Payload{
+		URL:         payloadURL(url),
+		ContentType: contentType,
+		Body:        body,
+		FetchedAt:   time.Now(),
+	})
+}
+
+// Payloads returns the payloads recorded so far in the order they were fetched
+func (r *PayloadRecorder) Payloads() []Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Payload(nil), r.payloads...)
+}
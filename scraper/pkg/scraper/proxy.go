@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/http/httpproxy"
+)
+
+var (
+	proxyMu sync.RWMutex
+	// proxyFunc selects the proxy of a request URL, from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// until SetProxy is called
+	proxyFunc = httpproxy.FromEnvironment().ProxyFunc()
+)
+
+// transport is shared by the HTTP clients of the scrapers so connections are reused across runs,
+// every request is traced
+var transport = &tracingTransport{base: newTransport()}
+
+// newTransport creates a transport with the defaults of http.DefaultTransport that sends its
+// requests through the proxy of the request context or the configured proxy
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFor
+	return transport
+}
+
+// newHTTPClient creates the HTTP client of a scraper
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+// dialRPC connects to a JSON-RPC endpoint, its HTTP requests go through the shared transport so
+// they use the configured proxy like the other scraper requests
+func dialRPC(ctx context.Context, rawURL string) (*rpc.Client, error) {
+	return rpc.DialOptions(ctx, rawURL, rpc.WithHTTPClient(newHTTPClient()))
+}
+
+// dialEthereum connects to an Ethereum JSON-RPC endpoint through the shared transport
+func dialEthereum(ctx context.Context, rawURL string) (*ethclient.Client, error) {
+	client, err := dialRPC(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// SetProxy configures the proxies of the scraper requests, config follows the semantics of the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func SetProxy(config httpproxy.Config) {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+	proxyFunc = config.ProxyFunc()
+}
+
+// Proxy overrides the proxy of the requests of a scraper
+type Proxy struct {
+	// URL is the proxy to send the requests through, nil sends them directly
+	URL *url.URL
+}
+
+// proxyKey is the context key of the proxy override of a scraper
+type proxyKey struct{}
+
+// WithProxy returns a context whose HTTP requests go through proxy instead of the configured one
+func WithProxy(ctx context.Context, proxy *Proxy) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+// proxyFor returns the proxy of a request, nil to send it directly
+func proxyFor(req *http.Request) (*url.URL, error) {
+	if proxy, ok := req.Context().Value(proxyKey{}).(*Proxy); ok && proxy != nil {
+		return proxy.URL, nil
+	}
+
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	return proxyFunc(req.URL)
+}
+
+// parseProxy parses the proxy of a scraper section, direct bypasses the configured proxy
+func parseProxy(value string) (*Proxy, error) {
+	if value == "direct" {
+		return &Proxy{}, nil
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", value, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: expected an http, https or socks5 URL or direct", value)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", value)
+	}
+	return &Proxy{URL: proxyURL}, nil
+}
@@ -0,0 +1,101 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http/httpproxy"
+)
+
+func TestSettingsProxyOverride(t *testing.T) {
+	proxy, err := Settings{}.ProxyOverride()
+	require.NoError(t, err)
+	assert.Nil(t, proxy)
+
+	proxy, err = Settings{Proxy: "direct"}.ProxyOverride()
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Nil(t, proxy.URL)
+
+	proxy, err = Settings{Proxy: "http://proxy.internal:3128"}.ProxyOverride()
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.URL.Host)
+
+	for _, invalid := range []string{"proxy.internal:3128", "ftp://proxy.internal", "http://"} {
+		_, err := Settings{Proxy: invalid}.ProxyOverride()
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestProxyFor(t *testing.T) {
+	previous := proxyFunc
+	t.Cleanup(func() { proxyFunc = previous })
+	SetProxy(httpproxy.Config{HTTPSProxy: "http://corporate:8080", NoProxy: "internal.example.com"})
+
+	request := func(ctx context.Context, target string) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		require.NoError(t, err)
+		return req
+	}
+
+	proxy, err := proxyFor(request(context.Background(), "https://api.example.com/data"))
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "corporate:8080", proxy.Host)
+
+	proxy, err = proxyFor(request(context.Background(), "https://internal.example.com/data"))
+	require.NoError(t, err)
+	assert.Nil(t, proxy, "NO_PROXY hosts are reached directly")
+
+	override, err := parseProxy("http://source-proxy:3128")
+	require.NoError(t, err)
+	proxy, err = proxyFor(request(WithProxy(context.Background(), override), "https://api.example.com/data"))
+	require.NoError(t, err)
+	assert.Equal(t, "source-proxy:3128", proxy.Host)
+
+	proxy, err = proxyFor(request(WithProxy(context.Background(), &Proxy{}), "https://api.example.com/data"))
+	require.NoError(t, err)
+	assert.Nil(t, proxy, "direct bypasses the configured proxy")
+}
+
+func TestHTTPClientUsesProxy(t *testing.T) {
+	var proxied string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	proxy, err := parseProxy(server.URL)
+	require.NoError(t, err)
+	ctx := WithProxy(context.Background(), proxy)
+
+	_, err = fetch(ctx, newHTTPClient(), "http://source.example.com/v1/data", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://source.example.com/v1/data", proxied)
+}
+
+func TestRPCClientUsesProxy(t *testing.T) {
+	var proxied string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer server.Close()
+
+	proxy, err := parseProxy(server.URL)
+	require.NoError(t, err)
+	ctx := WithProxy(context.Background(), proxy)
+
+	client, err := dialEthereum(ctx, "http://rpc.example.com/v1")
+	require.NoError(t, err)
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), chainID.Int64())
+	assert.Equal(t, "http://rpc.example.com/v1", proxied, "RPC requests should go through the proxy")
+}
@@ -0,0 +1,284 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// ErrQuotaExhausted is returned for the requests exceeding the daily or monthly quota of an API,
+// the errors are classified as errclass.ErrRateLimited
+var ErrQuotaExhausted = errors.New("request quota exhausted")
+
+// Windows of a quota
+const (
+	QuotaMinute = "minute"
+	QuotaDay    = "day"
+	QuotaMonth  = "month"
+)
+
+// QuotaLimits are the requests an API allows per window, a zero limit disables its window
+type QuotaLimits struct {
+	PerMinute int
+	PerDay    int
+	PerMonth  int
+	// CostPerRequest is the price of a request on a paid plan, zero for free APIs
+	CostPerRequest float64
+}
+
+// QuotaWindow is the usage of a window of a quota
+type QuotaWindow struct {
+	Window string
+	Used   int
+	// Limit is the number of requests allowed in the window, zero when it is not limited
+	Limit int
+}
+
+// QuotaUsage is the usage of a quota, requests and cost since the process started and the counts
+// of the current windows
+type QuotaUsage struct {
+	Requests int64
+	Cost     float64
+	Windows  []QuotaWindow
+}
+
+// QuotaStore keeps the request counts of the quota windows. A store shared by the replicas of the
+// scraper, like RedisQuotaStore, makes them share the quotas and keeps the usage across restarts
+type QuotaStore interface {
+	// Increment adds n to the count of key and returns the new count, the key expires after ttl
+	Increment(ctx context.Context, key string, n int, ttl time.Duration) (int, error)
+	// Count returns the count of key, zero when it does not exist or expired
+	Count(ctx context.Context, key string) (int, error)
+}
+
+// QuotaDefaulter is implemented by the scrapers whose API has a known quota, it applies when their
+// configuration section sets none
+type QuotaDefaulter interface {
+	DefaultQuota() QuotaLimits
+}
+
+// quotaWindow is a window of a quota starting at start and ending at end
+type quotaWindow struct {
+	name  string
+	start time.Time
+	end   time.Time
+	limit int
+}
+
+// Quota counts the requests sent to an external API against its limits, it is shared by the
+// scrapers using the same API key. Windows follow the UTC calendar, like the quotas of most APIs
+type Quota struct {
+	name  string
+	store QuotaStore
+
+	mu       sync.Mutex
+	limits   QuotaLimits
+	requests int64
+	cost     float64
+	now      func() time.Time
+}
+
+// NewQuota creates a quota counting its windows in store, in memory when store is nil
+func NewQuota(name string, limits QuotaLimits, store QuotaStore) *Quota {
+	if store == nil {
+		store = NewMemoryQuotaStore()
+	}
+	return &Quota{name: name, store: store, limits: limits, now: time.Now}
+}
+
+// Name returns the name of the quota, the scraper or quota group it belongs to
+func (q *Quota) Name() string {
+	return q.name
+}
+
+// SetLimits changes the limits of the quota, keeping its usage
+func (q *Quota) SetLimits(limits QuotaLimits) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits = limits
+}
+
+// windows returns the minute, day and month windows containing now with their limits
+func (q *Quota) windows() (time.Time, []quotaWindow) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now().UTC()
+	minute := now.Truncate(time.Minute)
+	day := now.Truncate(24 * time.Hour)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return now, []quotaWindow{
+		{name: QuotaMinute, start: minute, end: minute.Add(time.Minute), limit: q.limits.PerMinute},
+		{name: QuotaDay, start: day, end: day.AddDate(0, 0, 1), limit: q.limits.PerDay},
+		{name: QuotaMonth, start: month, end: month.AddDate(0, 1, 0), limit: q.limits.PerMonth},
+	}
+}
+
+// key returns the store key of a window
+func (q *Quota) key(window quotaWindow) string {
+	return q.name + ":" + window.name + ":" + window.start.Format("20060102T1504")
+}
+
+// increment adds n to the count of a window, the count is kept a minute past the end of the window
+func (q *Quota) increment(ctx context.Context, now time.Time, window quotaWindow, n int) (int, error) {
+	return q.store.Increment(ctx, q.key(window), n, window.end.Sub(now)+time.Minute)
+}
+
+// Acquire counts a request, waiting for the next minute while the per-minute limit is reached.
+// It fails with ErrQuotaExhausted without counting when the daily or monthly limit is reached.
+// Requests are let through uncounted while the store is unavailable
+func (q *Quota) Acquire(ctx context.Context) error {
+	for {
+		now, windows := q.windows()
+		counted, err := q.acquire(ctx, now, windows)
+		if err != nil {
+			return err
+		}
+		if counted {
+			q.mu.Lock()
+			q.requests++
+			q.cost += q.limits.CostPerRequest
+			q.mu.Unlock()
+			return nil
+		}
+
+		timer := time.NewTimer(windows[0].end.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to wait for request quota: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// acquire counts a request in every window, reporting false when the minute is full. A window
+// beyond its limit takes back the counts of the request. The month and day are counted first, so
+// an exhausted quota fails without waiting for the minute
+func (q *Quota) acquire(ctx context.Context, now time.Time, windows []quotaWindow) (bool, error) {
+	windows = slices.Clone(windows)
+	slices.Reverse(windows)
+	for i, window := range windows {
+		used, err := q.increment(ctx, now, window, 1)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to count request quota", "quota", q.name, "error", err)
+			return true, nil
+		}
+		if window.limit <= 0 || used <= window.limit {
+			continue
+		}
+
+		for _, counted := range windows[:i+1] {
+			if _, err := q.increment(ctx, now, counted, -1); err != nil {
+				slog.WarnContext(ctx, "Failed to take back request quota", "quota", q.name, "error", err)
+			}
+		}
+		if window.name == QuotaMinute {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %s: %w, %d requests per %s", errclass.ErrRateLimited, q.name, ErrQuotaExhausted, window.limit, window.name)
+	}
+	return true, nil
+}
+
+// Depleted returns the daily or monthly window of which at least share of the limit is used,
+// empty when both have room left or the usage cannot be read
+func (q *Quota) Depleted(ctx context.Context, share float64) string {
+	_, windows := q.windows()
+	for _, window := range slices.Backward(windows) {
+		if window.name == QuotaMinute || window.limit <= 0 {
+			continue
+		}
+		used, err := q.store.Count(ctx, q.key(window))
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read request quota", "quota", q.name, "error", err)
+			return ""
+		}
+		if float64(used) >= share*float64(window.limit) {
+			return window.name
+		}
+	}
+	return ""
+}
+
+// Usage returns the requests counted since the process started and in the current windows
+func (q *Quota) Usage(ctx context.Context) (QuotaUsage, error) {
+	_, windows := q.windows()
+	q.mu.Lock()
+	usage := QuotaUsage{Requests: q.requests, Cost: q.cost}
+	q.mu.Unlock()
+
+	for _, window := range windows {
+		used, err := q.store.Count(ctx, q.key(window))
+		if err != nil {
+			return usage, fmt.Errorf("failed to read usage of quota %s: %w", q.name, err)
+		}
+		usage.Windows = append(usage.Windows, QuotaWindow{Window: window.name, Used: used, Limit: window.limit})
+	}
+	return usage, nil
+}
+
+// MemoryQuotaStore keeps the counts of the quota windows in memory, they start over when the
+// process restarts
+type MemoryQuotaStore struct {
+	mu     sync.Mutex
+	counts map[string]memoryCount
+}
+
+// memoryCount is a count of a MemoryQuotaStore
+type memoryCount struct {
+	count   int
+	expires time.Time
+}
+
+// NewMemoryQuotaStore creates an empty store
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counts: make(map[string]memoryCount)}
+}
+
+// Increment adds n to the count of key and returns the new count
+func (s *MemoryQuotaStore) Increment(ctx context.Context, key string, n int, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	maps.DeleteFunc(s.counts, func(_ string, count memoryCount) bool {
+		return now.After(count.expires)
+	})
+	count := memoryCount{count: s.counts[key].count + n, expires: now.Add(ttl)}
+	s.counts[key] = count
+	return count.count, nil
+}
+
+// Count returns the count of key
+func (s *MemoryQuotaStore) Count(ctx context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count, ok := s.counts[key]
+	if !ok || time.Now().After(count.expires) {
+		return 0, nil
+	}
+	return count.count, nil
+}
+
+// quotaKey is the context key of the quota of a scraper
+type quotaKey struct{}
+
+// WithQuota returns a context whose HTTP requests are counted against quota
+func WithQuota(ctx context.Context, quota *Quota) context.Context {
+	return context.WithValue(ctx, quotaKey{}, quota)
+}
+
+// acquireQuota counts a request against the quota of the context, if any
+func acquireQuota(ctx context.Context) error {
+	quota, ok := ctx.Value(quotaKey{}).(*Quota)
+	if !ok || quota == nil {
+		return nil
+	}
+	return quota.Acquire(ctx)
+}
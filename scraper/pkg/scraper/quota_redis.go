@@ -0,0 +1,54 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisQuotaStore keeps the counts of the quota windows in Redis with INCRBY and EXPIRE, so the
+// replicas of the scraper share the quotas and restarts keep the usage
+type RedisQuotaStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisQuotaStore creates a store of the counts under the keys starting with prefix, the store
+// closes client on Close
+func NewRedisQuotaStore(client *redis.Client, prefix string) *RedisQuotaStore {
+	return &RedisQuotaStore{client: client, prefix: prefix}
+}
+
+// Increment adds n to the count of key and returns the new count, setting its expiry
+func (s *RedisQuotaStore) Increment(ctx context.Context, key string, n int, ttl time.Duration) (int, error) {
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, s.prefix+key, int64(n))
+		pipe.Expire(ctx, s.prefix+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", key, err)
+	}
+	return int(incr.Val()), nil
+}
+
+// Count returns the count of key
+func (s *RedisQuotaStore) Count(ctx context.Context, key string) (int, error) {
+	count, err := s.client.Get(ctx, s.prefix+key).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read count of %s: %w", key, err)
+	}
+	return count, nil
+}
+
+// Close closes the Redis client of the store
+func (s *RedisQuotaStore) Close() error {
+	return s.client.Close()
+}
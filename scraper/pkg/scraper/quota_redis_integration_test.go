@@ -0,0 +1,60 @@
+//go:build integration
+// +build integration
+
+package scraper
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisQuotaStoreIntegration(t *testing.T) {
+	host, port := "localhost", 6379
+	if value, ok := os.LookupEnv("REDIS_HOST"); ok {
+		host = value
+	}
+	if value, ok := os.LookupEnv("REDIS_PORT"); ok {
+		var err error
+		port, err = strconv.Atoi(value)
+		require.NoError(t, err)
+	}
+
+	ctx := context.Background()
+	client, err := queue.NewRedisClient(host, port, queue.RedisConnection{})
+	require.NoError(t, err)
+	store := NewRedisQuotaStore(client, "test:quota:")
+	defer store.Close()
+	defer client.Del(ctx, "test:quota:fred:day")
+
+	count, err := store.Count(ctx, "fred:day")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = store.Increment(ctx, "fred:day", 2, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = store.Increment(ctx, "fred:day", -1, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	ttl, err := client.TTL(ctx, "test:quota:fred:day").Result()
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 5, "The count expires with its window")
+
+	// Replicas sharing the store share the quota
+	first := NewQuota("fred", QuotaLimits{PerMonth: 2}, store)
+	second := NewQuota("fred", QuotaLimits{PerMonth: 2}, store)
+	_, windows := first.windows()
+	defer client.Del(ctx, "test:quota:"+first.key(windows[0]), "test:quota:"+first.key(windows[1]), "test:quota:"+first.key(windows[2]))
+	require.NoError(t, first.Acquire(ctx))
+	require.NoError(t, second.Acquire(ctx))
+	assert.ErrorIs(t, first.Acquire(ctx), ErrQuotaExhausted)
+}
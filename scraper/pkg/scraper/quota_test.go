@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaAcquire(t *testing.T) {
+	now := time.Date(2025, 4, 30, 23, 58, 0, 0, time.UTC)
+	quota := NewQuota("fred", QuotaLimits{PerDay: 3, PerMonth: 4, CostPerRequest: 0.01}, nil)
+	quota.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		require.NoError(t, quota.Acquire(ctx))
+	}
+	err := quota.Acquire(ctx)
+	assert.ErrorIs(t, err, ErrQuotaExhausted, "Day limit should be enforced")
+	assert.ErrorIs(t, err, errclass.ErrRateLimited)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, quota.Acquire(ctx), "Day window should reset")
+	assert.NoError(t, quota.Acquire(ctx), "Month window should reset on the first of the month")
+
+	usage, err := quota.Usage(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, usage.Requests)
+	assert.InDelta(t, 0.05, usage.Cost, 1e-9)
+	assert.Equal(t, []QuotaWindow{
+		{Window: QuotaMinute, Used: 2},
+		{Window: QuotaDay, Used: 2, Limit: 3},
+		{Window: QuotaMonth, Used: 2, Limit: 4},
+	}, usage.Windows)
+}
+
+func TestQuotaAcquireWaitsForNextMinute(t *testing.T) {
+	quota := NewQuota("coingecko", QuotaLimits{PerMinute: 1}, nil)
+	now := time.Date(2025, 5, 1, 12, 0, 59, 990_000_000, time.UTC)
+	quota.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, quota.Acquire(ctx))
+	done := make(chan error)
+	go func() { done <- quota.Acquire(ctx) }()
+	select {
+	case <-done:
+		t.Fatal("Acquire should wait for the next minute")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The timer elapsed in real time, the next attempt sees the next minute
+	quota.mu.Lock()
+	now = now.Add(time.Second)
+	quota.mu.Unlock()
+	require.NoError(t, <-done)
+
+	// The minute is full again, a cancelled request stops waiting
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, quota.Acquire(cancelled), context.Canceled)
+}
+
+func TestQuotaDepleted(t *testing.T) {
+	quota := NewQuota("etherscan", QuotaLimits{PerMinute: 100, PerDay: 10, PerMonth: 1000}, nil)
+	ctx := context.Background()
+	for range 8 {
+		require.NoError(t, quota.Acquire(ctx))
+	}
+	assert.Empty(t, quota.Depleted(ctx, 0.9))
+	require.NoError(t, quota.Acquire(ctx))
+	assert.Equal(t, QuotaDay, quota.Depleted(ctx, 0.9))
+
+	quota.SetLimits(QuotaLimits{PerMonth: 10})
+	assert.Equal(t, QuotaMonth, quota.Depleted(ctx, 0.9), "Limits change without resetting the usage")
+	assert.Empty(t, NewQuota("open", QuotaLimits{}, nil).Depleted(ctx, 0.9))
+}
+
+func TestQuotaSharedStore(t *testing.T) {
+	// Replicas sharing a store share the windows of their quotas
+	store := NewMemoryQuotaStore()
+	first := NewQuota("fred", QuotaLimits{PerDay: 3}, store)
+	second := NewQuota("fred", QuotaLimits{PerDay: 3}, store)
+	ctx := context.Background()
+
+	require.NoError(t, first.Acquire(ctx))
+	require.NoError(t, second.Acquire(ctx))
+	require.NoError(t, first.Acquire(ctx))
+	assert.ErrorIs(t, second.Acquire(ctx), ErrQuotaExhausted)
+
+	usage, err := second.Usage(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, usage.Requests, "Requests are counted by the process")
+	assert.Equal(t, QuotaWindow{Window: QuotaDay, Used: 3, Limit: 3}, usage.Windows[1], "The refused request is not counted")
+}
+
+func TestMemoryQuotaStoreExpires(t *testing.T) {
+	store := NewMemoryQuotaStore()
+	ctx := context.Background()
+
+	count, err := store.Increment(ctx, "fred:day", 2, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	_, err = store.Increment(ctx, "fred:minute", 1, -time.Second)
+	require.NoError(t, err)
+
+	count, err = store.Count(ctx, "fred:day")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = store.Count(ctx, "fred:minute")
+	require.NoError(t, err)
+	assert.Zero(t, count, "Expired counts start over")
+}
+
+func TestTracingTransportCountsQuota(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	quota := NewQuota("fred", QuotaLimits{PerDay: 1}, nil)
+	ctx := WithQuota(context.Background(), quota)
+	client := &http.Client{Transport: &tracingTransport{base: http.DefaultTransport}}
+
+	_, err := fetch(ctx, client, server.URL, nil)
+	require.NoError(t, err)
+	_, err = fetch(ctx, client, server.URL, nil)
+	assert.True(t, errors.Is(err, ErrQuotaExhausted), "The request beyond the quota is not sent: %v", err)
+	usage, err := quota.Usage(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, usage.Requests)
+}
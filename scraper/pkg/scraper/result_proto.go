@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"macrochain/scraper/pkg/protoenc"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MarshalResultProto encodes a result as the Result protobuf defined in proto/macrochain.proto.
+// Time series are encoded natively, any other data is embedded as JSON
+func MarshalResultProto(result Result) ([]byte, error) {
+	var b []byte
+	b = protoenc.AppendString(b, 1, result.Source)
+	b = protoenc.AppendTimestamp(b, 2, result.Timestamp)
+	b = protoenc.AppendStringMap(b, 3, result.Metadata)
+
+	switch data := result.Data.(type) {
+	case nil:
+	case []TimeSeriesPoint:
+		for _, point := range data {
+			b = protoenc.AppendMessage(b, 4, marshalPointProto(point))
+		}
+	default:
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result data: %w", err)
+		}
+		b = protoenc.AppendBytes(b, 5, raw)
+	}
+	return b, nil
+}
+
+// marshalPointProto encodes a TimeSeriesPoint protobuf
+func marshalPointProto(point TimeSeriesPoint) []byte {
+	var b []byte
+	b = protoenc.AppendString(b, 1, point.Code)
+	if point.Value != 0 {
+		b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(point.Value))
+	}
+	b = protoenc.AppendString(b, 3, point.Unit)
+	b = protoenc.AppendTimestamp(b, 4, point.Timestamp)
+	b = protoenc.AppendStringMap(b, 5, point.Metadata)
+	return b
+}
+
+// UnmarshalResultProto decodes a Result protobuf. Time series data is returned as []TimeSeriesPoint,
+// any other data as json.RawMessage
+func UnmarshalResultProto(data []byte) (Result, error) {
+	var result Result
+	var points []TimeSeriesPoint
+	err := protoenc.Fields(data, func(field protoenc.Field) error {
+		switch field.Number {
+		case 1:
+			result.Source = string(field.Bytes)
+		case 2:
+			timestamp, err := protoenc.Timestamp(field.Bytes)
+			if err != nil {
+				return err
+			}
+			result.Timestamp = timestamp
+		case 3:
+			if result.Metadata == nil {
+				result.Metadata = make(map[string]string)
+			}
+			return protoenc.MapEntry(field.Bytes, result.Metadata)
+		case 4:
+			point, err := unmarshalPointProto(field.Bytes)
+			if err != nil {
+				return err
+			}
+			points = append(points, point)
+		case 5:
+			result.Data = json.RawMessage(field.Bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	if points != nil {
+		result.Data = points
+	}
+	return result, nil
+}
+
+// unmarshalPointProto decodes a TimeSeriesPoint protobuf
+func unmarshalPointProto(data []byte) (TimeSeriesPoint, error) {
+	var point TimeSeriesPoint
+	err := protoenc.Fields(data, func(field protoenc.Field) error {
+		switch field.Number {
+		case 1:
+			point.Code = string(field.Bytes)
+		case 2:
+			point.Value = math.Float64frombits(field.Fixed)
+		case 3:
+			point.Unit = string(field.Bytes)
+		case 4:
+			timestamp, err := protoenc.Timestamp(field.Bytes)
+			if err != nil {
+				return err
+			}
+			point.Timestamp = timestamp
+		case 5:
+			if point.Metadata == nil {
+				point.Metadata = make(map[string]string)
+			}
+			return protoenc.MapEntry(field.Bytes, point.Metadata)
+		}
+		return nil
+	})
+	return point, err
+}
@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultProto_TimeSeries(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	result := Result{
+		Source:    "fx_rates",
+		Timestamp: timestamp,
+		Metadata:  map[string]string{"base": "CHF"},
+		Data: []TimeSeriesPoint{
+			{Code: "usd_chf", Value: 0.9125, Unit: "CHF", Timestamp: timestamp, Metadata: map[string]string{"date": "2024-05-01"}},
+			{Code: "zero", Value: 0, Unit: "CHF", Timestamp: timestamp},
+			{Code: "negative", Value: -1.5, Unit: "percent", Timestamp: timestamp},
+		},
+	}
+
+	data, err := MarshalResultProto(result)
+	require.NoError(t, err)
+
+	jsonData, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(jsonData)/2, "Protobuf should be much smaller than JSON")
+
+	decoded, err := UnmarshalResultProto(data)
+	require.NoError(t, err)
+	assert.Equal(t, result, decoded, "Result should survive a round trip")
+}
+
+func TestResultProto_OtherData(t *testing.T) {
+	result := Result{
+		Source:    "contract_logs",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Data:      []ContractEvent{{Label: "usdc_transfers", Event: "Transfer"}},
+	}
+
+	data, err := MarshalResultProto(result)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalResultProto(data)
+	require.NoError(t, err)
+	raw, ok := decoded.Data.(json.RawMessage)
+	require.True(t, ok, "Non time series data should be returned as JSON")
+
+	var events []ContractEvent
+	require.NoError(t, json.Unmarshal(raw, &events))
+	assert.Equal(t, result.Data, events)
+}
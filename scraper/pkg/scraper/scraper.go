@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"context"
+	"time"
+)
+
+// Scraper is implemented by every data source collected by the scraper service
+type Scraper interface {
+	// Name returns the unique identifier for this scraper
+	Name() string
+	// Schedule returns the recommended scraping interval
+	Schedule() time.Duration
+	// Validate checks if the scraper configuration is valid
+	Validate(ctx context.Context) error
+	// Init performs any necessary initialization
+	Init(ctx context.Context) error
+	// Scrape performs the data collection process
+	Scrape(ctx context.Context) ([]Result, error)
+}
+
+// StreamingScraper is implemented by data sources that push data continuously
+type StreamingScraper interface {
+	// Name returns the unique identifier for this scraper
+	Name() string
+	// Validate checks if the scraper configuration is valid
+	Validate(ctx context.Context) error
+	// Init performs any necessary initialization
+	Init(ctx context.Context) error
+	// Run streams data until the context is cancelled, passing every result to emit
+	Run(ctx context.Context, emit func(Result) error) error
+}
+
+// MetadataRunID is the result metadata key holding the ID of the scraper run that produced the
+// result, it is kept with the stored points so every value can be traced back to its run
+const MetadataRunID = "run_id"
+
+// Result is the output of a single scrape
+type Result struct {
+	Source    string            `json:"source"`
+	Timestamp time.Time         `json:"timestamp"`
+	Data      any               `json:"data"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// TimeSeriesPoint is a single observation of a numeric series
+type TimeSeriesPoint struct {
+	Code      string            `json:"code"`
+	Value     float64           `json:"value"`
+	Unit      string            `json:"unit"`
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"golang.org/x/time/rate"
+)
+
+// Settings is the configuration section of a scraper, zero fields keep the defaults of the scraper
+type Settings struct {
+	// Enabled overrides whether the scraper is listed in the enabled scrapers
+	Enabled *bool `mapstructure:"enabled"`
+	// Interval overrides the schedule of the scraper
+	Interval time.Duration `mapstructure:"interval"`
+	// Cron schedules the runs with a standard five field expression, it takes precedence over Interval
+	Cron string `mapstructure:"cron"`
+	// URL overrides the API or RPC URL of the scraper
+	URL string `mapstructure:"url"`
+	// APIKey overrides the API key of the scraper
+	APIKey string `mapstructure:"api_key"`
+	// RateLimit is the maximum number of requests per second, zero disables the limit
+	RateLimit float64 `mapstructure:"rate_limit"`
+	// Burst is the number of requests that may be sent at once within the rate limit, defaults to 1
+	Burst int `mapstructure:"burst"`
+	// Proxy is the URL of the proxy for the requests of the scraper, direct bypasses the proxy
+	// of HTTP_PROXY and HTTPS_PROXY
+	Proxy string `mapstructure:"proxy"`
+	// Flag is the feature flag gating the scraper, it only runs while the flag is enabled
+	Flag string `mapstructure:"flag"`
+	// MaxAge is the freshness SLA of the scraper, an alert is sent when it has not succeeded for
+	// longer, zero disables the alert
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// RequestsPerMinute, RequestsPerDay and RequestsPerMonth are the quota of the API of the
+	// scraper, zero limits disable their window
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	RequestsPerDay    int `mapstructure:"requests_per_day"`
+	RequestsPerMonth  int `mapstructure:"requests_per_month"`
+	// CostPerRequest is the price of a request on a paid plan, for the cost metrics
+	CostPerRequest float64 `mapstructure:"cost_per_request"`
+	// QuotaGroup shares the quota between the scrapers of the group, e.g. the ones using the same
+	// API key. Defaults to the name of the scraper
+	QuotaGroup string `mapstructure:"quota_group"`
+}
+
+// IsEnabled reports whether the scraper runs, listed tells whether it is in the enabled scrapers
+func (s Settings) IsEnabled(listed bool) bool {
+	if s.Enabled != nil {
+		return *s.Enabled
+	}
+	return listed
+}
+
+// URLOr returns the URL of the settings, or fallback when it is not set
+func (s Settings) URLOr(fallback string) string {
+	if s.URL != "" {
+		return s.URL
+	}
+	return fallback
+}
+
+// APIKeyOr returns the API key of the settings, or fallback when it is not set
+func (s Settings) APIKeyOr(fallback string) string {
+	if s.APIKey != "" {
+		return s.APIKey
+	}
+	return fallback
+}
+
+// Limiter returns the rate limiter of the settings, nil when requests are not limited
+func (s Settings) Limiter() *rate.Limiter {
+	if s.RateLimit <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(s.RateLimit), max(s.Burst, 1))
+}
+
+// QuotaLimits returns the request quota of the settings
+func (s Settings) QuotaLimits() QuotaLimits {
+	return QuotaLimits{
+		PerMinute:      s.RequestsPerMinute,
+		PerDay:         s.RequestsPerDay,
+		PerMonth:       s.RequestsPerMonth,
+		CostPerRequest: s.CostPerRequest,
+	}
+}
+
+// ProxyOverride returns the proxy override of the settings, nil when the configured proxy applies
+func (s Settings) ProxyOverride() (*Proxy, error) {
+	if s.Proxy == "" {
+		return nil, nil
+	}
+	return parseProxy(s.Proxy)
+}
+
+// Schedule decides when a scraper runs next
+type Schedule struct {
+	interval time.Duration
+	cron     cron.Schedule
+	// expression is the cron expression of cron
+	expression string
+}
+
+// NewSchedule creates the schedule of the settings, fallback is the interval of the scraper
+func NewSchedule(settings Settings, fallback time.Duration) (*Schedule, error) {
+	schedule := &Schedule{interval: fallback}
+	if settings.Interval > 0 {
+		schedule.interval = settings.Interval
+	}
+	if settings.Cron != "" {
+		parsed, err := cron.ParseStandard(settings.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", settings.Cron, err)
+		}
+		schedule.cron = parsed
+		schedule.expression = settings.Cron
+	}
+	return schedule, nil
+}
+
+// String describes the schedule, e.g. "every 1h0m0s" or "cron 0 22 * * 1-5"
+func (s *Schedule) String() string {
+	if s.cron != nil {
+		return "cron " + s.expression
+	}
+	return "every " + s.interval.String()
+}
+
+// Start returns the time of the first run of a scraper started at now, scrapers on an interval
+// run right away
+func (s *Schedule) Start(now time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.Next(now)
+	}
+	return now
+}
+
+// Next returns the time of the run following a run at now
+func (s *Schedule) Next(now time.Time) time.Time {
+	if s.cron != nil {
+		return s.cron.Next(now)
+	}
+	return now.Add(s.interval)
+}
+
+// rateLimiterKey is the context key of the rate limiter of a scraper
+type rateLimiterKey struct{}
+
+// WithRateLimiter returns a context whose HTTP requests wait for limiter
+func WithRateLimiter(ctx context.Context, limiter *rate.Limiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// waitRateLimit blocks until the rate limiter of the context, if any, allows a request
+func waitRateLimit(ctx context.Context) error {
+	limiter, ok := ctx.Value(rateLimiterKey{}).(*rate.Limiter)
+	if !ok || limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to wait for rate limit: %w", err)
+	}
+	return nil
+}
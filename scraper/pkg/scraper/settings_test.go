@@ -0,0 +1,72 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestSettings(t *testing.T) {
+	var settings Settings
+	assert.True(t, settings.IsEnabled(true))
+	assert.False(t, settings.IsEnabled(false))
+	assert.Equal(t, "https://default", settings.URLOr("https://default"))
+	assert.Equal(t, "key", settings.APIKeyOr("key"))
+	assert.Nil(t, settings.Limiter())
+
+	disabled := false
+	settings = Settings{Enabled: &disabled, URL: "https://override", APIKey: "secret", RateLimit: 2}
+	assert.False(t, settings.IsEnabled(true))
+	assert.Equal(t, "https://override", settings.URLOr("https://default"))
+	assert.Equal(t, "secret", settings.APIKeyOr("key"))
+	require.NotNil(t, settings.Limiter())
+	assert.Equal(t, 1, settings.Limiter().Burst())
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	schedule, err := NewSchedule(Settings{}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, now, schedule.Start(now))
+	assert.Equal(t, now.Add(time.Hour), schedule.Next(now))
+	assert.Equal(t, "every 1h0m0s", schedule.String())
+
+	schedule, err = NewSchedule(Settings{Interval: 5 * time.Minute}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(5*time.Minute), schedule.Next(now))
+
+	// Cron takes precedence over the interval
+	schedule, err = NewSchedule(Settings{Interval: 5 * time.Minute, Cron: "0 22 * * 1-5"}, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC), schedule.Start(now))
+	assert.Equal(t, time.Date(2024, 5, 6, 22, 0, 0, 0, time.UTC), schedule.Next(time.Date(2024, 5, 3, 22, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "cron 0 22 * * 1-5", schedule.String())
+
+	_, err = NewSchedule(Settings{Cron: "every day"}, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	// The limiter has no tokens left so the request waits past the deadline
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	limiter.Allow()
+	ctx, cancel := context.WithTimeout(WithRateLimiter(context.Background(), limiter), 50*time.Millisecond)
+	defer cancel()
+	_, err := fetch(ctx, server.Client(), server.URL, nil)
+	assert.Error(t, err)
+
+	_, err = fetch(WithRateLimiter(context.Background(), rate.NewLimiter(rate.Inf, 1)), server.Client(), server.URL, nil)
+	assert.NoError(t, err)
+}
@@ -9,8 +9,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"macrochain/scraper/pkg/errclass"
 )
 
+// DefaultSNBRSSURL is the SNB interest rate RSS feed
+const DefaultSNBRSSURL = "https://www.snb.ch/public/en/rss/interestRates"
+
 // SNBInterestRate represents a Swiss National Bank interest rate data point
 type SNBInterestRate struct {
 	Code        string    `json:"code"`
@@ -26,11 +31,11 @@ type SNBScraper struct {
 	httpClient *http.Client
 }
 
-// NewSNBScraper creates a new SNB scraper instance
-func NewSNBScraper() *SNBScraper {
+// NewSNBScraper creates a new SNB scraper instance reading the feed at rssURL
+func NewSNBScraper(rssURL string) *SNBScraper {
 	return &SNBScraper{
-		rssURL:     "https://www.snb.ch/public/en/rss/interestRates",
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		rssURL:     rssURL,
+		httpClient: newHTTPClient(),
 	}
 }
 
@@ -59,6 +64,15 @@ func (s *SNBScraper) Init(ctx context.Context) error {
 	return nil
 }
 
+// Series returns the main rates of the feed, the feed may publish further codes
+func (s *SNBScraper) Series() []SeriesInfo {
+	return []SeriesInfo{
+		{Code: "SNBLZ", Description: "SNB policy rate", Unit: "percent", Frequency: FrequencyDaily, Country: "CH"},
+		{Code: "LSFF", Description: "Special rate (liquidity-shortage financing facility)", Unit: "percent", Frequency: FrequencyDaily, Country: "CH"},
+		{Code: "R10", Description: "Yield on 10-year Swiss Confederation bonds", Unit: "percent", Frequency: FrequencyDaily, Country: "CH"},
+	}
+}
+
 // RSS feed structures
 type RSSFeed struct {
 	XMLName xml.Name   `xml:"rss"`
@@ -89,26 +103,33 @@ func (s *SNBScraper) Scrape(ctx context.Context) ([]Result, error) {
 	}
 
 	// Execute request
+	if err := waitRateLimit(ctx); err != nil {
+		return nil, err
+	}
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch SNB RSS feed: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to fetch SNB RSS feed: %w", err)
+		}
+		return nil, fmt.Errorf("%w: failed to fetch SNB RSS feed: %w", errclass.ErrTransient, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, &statusError{code: resp.StatusCode}
 	}
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %w", errclass.ErrTransient, err)
 	}
+	recordPayload(ctx, s.rssURL, resp.Header.Get("Content-Type"), body)
 
 	// Parse XML
 	var feed RSSFeed
 	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, fmt.Errorf("failed to parse RSS feed: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse RSS feed: %w", errclass.ErrParse, err)
 	}
 
 	// Process items
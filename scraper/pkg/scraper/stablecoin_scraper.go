@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultStablecoinContracts lists the major Ethereum mainnet stablecoins
+var DefaultStablecoinContracts = []string{
+	"USDT:0xdAC17F958D2ee523a2206206994597C13D831ec7",
+	"USDC:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	"DAI:0x6B175474E89094C44Da98b954EedeAC495271d0F",
+}
+
+// StablecoinScraper implements the Scraper interface for ERC-20 stablecoin supplies
+type StablecoinScraper struct {
+	rpcURL    string
+	contracts []Contract
+	client    *ethclient.Client
+	decimals  map[string]uint8
+}
+
+// NewStablecoinScraper creates a new stablecoin supply scraper for the given contracts
+func NewStablecoinScraper(rpcURL string, contracts []Contract) *StablecoinScraper {
+	return &StablecoinScraper{
+		rpcURL:    rpcURL,
+		contracts: contracts,
+		decimals:  make(map[string]uint8),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *StablecoinScraper) Name() string {
+	return "stablecoin_supply"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *StablecoinScraper) Schedule() time.Duration {
+	return 1 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *StablecoinScraper) Validate(ctx context.Context) error {
+	if s.rpcURL == "" {
+		return fmt.Errorf("RPC URL is required")
+	}
+	if len(s.contracts) == 0 {
+		return fmt.Errorf("at least one stablecoin contract is required")
+	}
+	return nil
+}
+
+// Init connects to the Ethereum RPC endpoint
+func (s *StablecoinScraper) Init(ctx context.Context) error {
+	client, err := dialEthereum(ctx, s.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ethereum node: %w", err)
+	}
+	s.client = client
+	return nil
+}
+
+// Scrape reads the total supply of every configured stablecoin
+func (s *StablecoinScraper) Scrape(ctx context.Context) ([]Result, error) {
+	if s.client == nil {
+		return nil, fmt.Errorf("scraper is not initialized")
+	}
+
+	now := time.Now()
+	var points []TimeSeriesPoint
+	for _, contract := range s.contracts {
+		decimals, err := s.tokenDecimals(ctx, contract)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := callContract(ctx, s.client, contract.Address, "totalSupply()")
+		if err != nil {
+			return nil, err
+		}
+		supply, err := wordAt(out, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode total supply of %s: %w", contract.Label, err)
+		}
+
+		points = append(points, TimeSeriesPoint{
+			Code:      contract.Label,
+			Value:     scaleDecimals(supply, decimals),
+			Unit:      contract.Label,
+			Timestamp: now,
+			Metadata:  map[string]string{"contract": contract.Address.Hex()},
+		})
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// tokenDecimals returns the decimals of a token, which never change and are cached
+func (s *StablecoinScraper) tokenDecimals(ctx context.Context, contract Contract) (uint8, error) {
+	if decimals, ok := s.decimals[contract.Label]; ok {
+		return decimals, nil
+	}
+
+	out, err := callContract(ctx, s.client, contract.Address, "decimals()")
+	if err != nil {
+		return 0, err
+	}
+	value, err := wordAt(out, 0)
+	if err != nil || value.BitLen() > 8 {
+		return 0, fmt.Errorf("invalid decimals for %s", contract.Label)
+	}
+
+	decimals := uint8(value.Uint64())
+	s.decimals[contract.Label] = decimals
+	return decimals, nil
+}
@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStablecoinScraper_Scrape(t *testing.T) {
+	contracts, err := ParseContracts(DefaultStablecoinContracts[:2])
+	require.NoError(t, err)
+
+	supplies := map[string]*big.Int{
+		contracts[0].Address.Hex(): big.NewInt(140_000_000_000_000), // 140M USDT with 6 decimals
+		contracts[1].Address.Hex(): big.NewInt(60_000_000_000_000),  // 60M USDC with 6 decimals
+	}
+
+	server := newRPCServer(t, map[string]rpcHandler{
+		"eth_call": ethCallHandler(func(call contractCall) ([]byte, error) {
+			switch call.Selector() {
+			case selector("decimals()"):
+				return abiWords(big.NewInt(6)), nil
+			case selector("totalSupply()"):
+				return abiWords(supplies[call.To.Hex()]), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", call.Selector())
+		}),
+	})
+
+	scraper := NewStablecoinScraper(server.URL, contracts)
+	ctx := context.Background()
+	require.NoError(t, scraper.Validate(ctx))
+	require.NoError(t, scraper.Init(ctx))
+
+	results, err := scraper.Scrape(ctx)
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "stablecoin_supply", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, 2)
+	assert.Equal(t, "USDT", points[0].Code)
+	assert.Equal(t, 140_000_000.0, points[0].Value)
+	assert.Equal(t, "USDC", points[1].Code)
+	assert.Equal(t, 60_000_000.0, points[1].Value)
+}
+
+func TestStablecoinScraper_Validate(t *testing.T) {
+	ctx := context.Background()
+	assert.Error(t, NewStablecoinScraper("", []Contract{{Label: "USDT"}}).Validate(ctx))
+	assert.Error(t, NewStablecoinScraper("http://localhost:8545", nil).Validate(ctx))
+}
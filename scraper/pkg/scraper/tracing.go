@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the requests sent by the scrapers
+var tracer = otel.Tracer("macrochain/scraper/pkg/scraper")
+
+// tracingTransport records a span and a debug log record for every request sent through base,
+// counts it against the Quota of the request context and counts the bytes of its response in the
+// FetchCounter of the request context. The trace context
+// is not sent to the sources, and the query is left out as it may hold an API key
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request within a client span
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.String("url.path", req.URL.Path),
+		))
+	defer span.End()
+
+	if err := acquireQuota(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		slog.DebugContext(ctx, "Source request failed", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Source request completed", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path,
+		"status", resp.StatusCode, "duration", time.Since(started))
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	resp.Body = countBody(req.Context(), resp.Body)
+	return resp, nil
+}
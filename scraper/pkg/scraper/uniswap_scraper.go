@@ -0,0 +1,203 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUniswapPools lists the Uniswap v3 pools tracked when none are configured
+var DefaultUniswapPools = []string{
+	"0x88e6a0c2ddd26feeb64f039a2c41296fcb3f5640", // USDC/WETH 0.05%
+	"0xcbcdf9626bc03e24f779434178a73a0b4bad62ed", // WBTC/WETH 0.3%
+}
+
+// uniswapPoolsQuery selects the state and latest daily statistics of the configured pools
+const uniswapPoolsQuery = `query Pools($ids: [ID!]) {
+  pools(where: {id_in: $ids}) {
+    id
+    feeTier
+    liquidity
+    token0Price
+    token1Price
+    totalValueLockedUSD
+    token0 { symbol }
+    token1 { symbol }
+    poolDayData(first: 1, orderBy: date, orderDirection: desc) {
+      date
+      volumeUSD
+      feesUSD
+    }
+  }
+}`
+
+// UniswapScraper implements the Scraper interface for Uniswap v3 pools via the subgraph
+type UniswapScraper struct {
+	subgraphURL string
+	pools       []string
+	httpClient  *http.Client
+}
+
+// NewUniswapScraper creates a new Uniswap v3 scraper for the given pool addresses
+func NewUniswapScraper(subgraphURL string, pools []string) *UniswapScraper {
+	ids := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		// The subgraph stores pool IDs as lowercase addresses
+		ids = append(ids, strings.ToLower(strings.TrimSpace(pool)))
+	}
+
+	return &UniswapScraper{
+		subgraphURL: subgraphURL,
+		pools:       ids,
+		httpClient:  newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *UniswapScraper) Name() string {
+	return "uniswap_v3_pools"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *UniswapScraper) Schedule() time.Duration {
+	return 15 * time.Minute
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *UniswapScraper) Validate(ctx context.Context) error {
+	if s.subgraphURL == "" {
+		return fmt.Errorf("subgraph URL is required")
+	}
+	if len(s.pools) == 0 {
+		return fmt.Errorf("at least one pool is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *UniswapScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// uniswapPool is a pool entity returned by the subgraph
+type uniswapPool struct {
+	ID                  string `json:"id"`
+	FeeTier             string `json:"feeTier"`
+	Liquidity           string `json:"liquidity"`
+	Token0Price         string `json:"token0Price"`
+	Token1Price         string `json:"token1Price"`
+	TotalValueLockedUSD string `json:"totalValueLockedUSD"`
+	Token0              struct {
+		Symbol string `json:"symbol"`
+	} `json:"token0"`
+	Token1 struct {
+		Symbol string `json:"symbol"`
+	} `json:"token1"`
+	PoolDayData []struct {
+		Date      int64  `json:"date"`
+		VolumeUSD string `json:"volumeUSD"`
+		FeesUSD   string `json:"feesUSD"`
+	} `json:"poolDayData"`
+}
+
+// graphQLResponse is the envelope of a GraphQL response
+type graphQLResponse[T any] struct {
+	Data   T `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Scrape collects price, liquidity, volume and fee metrics of every configured pool
+func (s *UniswapScraper) Scrape(ctx context.Context) ([]Result, error) {
+	request := map[string]any{
+		"query":     uniswapPoolsQuery,
+		"variables": map[string]any{"ids": s.pools},
+	}
+
+	var resp graphQLResponse[struct {
+		Pools []uniswapPool `json:"pools"`
+	}]
+	if err := postJSON(ctx, s.httpClient, s.subgraphURL, nil, request, &resp); err != nil {
+		return nil, fmt.Errorf("failed to query subgraph: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("subgraph query failed: %s", resp.Errors[0].Message)
+	}
+
+	now := time.Now()
+	var points []TimeSeriesPoint
+	for _, pool := range resp.Data.Pools {
+		poolPoints, err := uniswapPoolMetrics(pool, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pool %s: %w", pool.ID, err)
+		}
+		points = append(points, poolPoints...)
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: now,
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
+
+// uniswapPoolMetrics converts a pool entity to series points
+func uniswapPoolMetrics(pool uniswapPool, now time.Time) ([]TimeSeriesPoint, error) {
+	prefix := fmt.Sprintf("%s_%s_%s", slug(pool.Token0.Symbol), slug(pool.Token1.Symbol), pool.FeeTier)
+	metadata := map[string]string{
+		"pool":   pool.ID,
+		"token0": pool.Token0.Symbol,
+		"token1": pool.Token1.Symbol,
+	}
+
+	var points []TimeSeriesPoint
+	add := func(metric, raw, unit string, timestamp time.Time) error {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", metric, raw, err)
+		}
+		points = append(points, TimeSeriesPoint{
+			Code:      prefix + "_" + metric,
+			Value:     value,
+			Unit:      unit,
+			Timestamp: timestamp,
+			Metadata:  metadata,
+		})
+		return nil
+	}
+
+	// token0Price is the price of token0 denominated in token1 and vice versa
+	if err := add("token0_price", pool.Token0Price, pool.Token1.Symbol, now); err != nil {
+		return nil, err
+	}
+	if err := add("token1_price", pool.Token1Price, pool.Token0.Symbol, now); err != nil {
+		return nil, err
+	}
+	if err := add("liquidity", pool.Liquidity, "liquidity", now); err != nil {
+		return nil, err
+	}
+	if err := add("tvl_usd", pool.TotalValueLockedUSD, "USD", now); err != nil {
+		return nil, err
+	}
+
+	// Volume and fees are accumulated per UTC day
+	if len(pool.PoolDayData) > 0 {
+		day := pool.PoolDayData[0]
+		date := time.Unix(day.Date, 0).UTC()
+		if err := add("volume_usd", day.VolumeUSD, "USD", date); err != nil {
+			return nil, err
+		}
+		if err := add("fees_usd", day.FeesUSD, "USD", date); err != nil {
+			return nil, err
+		}
+	}
+
+	return points, nil
+}
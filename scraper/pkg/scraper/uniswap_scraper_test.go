@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniswapScraper_Scrape(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string `json:"query"`
+			Variables struct {
+				IDs []string `json:"ids"`
+			} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"0x88e6a0c2ddd26feeb64f039a2c41296fcb3f5640"}, req.Variables.IDs, "Pool IDs should be lowercased")
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"pools":[{
+			"id":"0x88e6a0c2ddd26feeb64f039a2c41296fcb3f5640",
+			"feeTier":"500",
+			"liquidity":"6543210987654321",
+			"token0Price":"1805.25",
+			"token1Price":"0.000553939898",
+			"totalValueLockedUSD":"150000000.12",
+			"token0":{"symbol":"USDC"},
+			"token1":{"symbol":"WETH"},
+			"poolDayData":[{"date":1743724800,"volumeUSD":"250000000","feesUSD":"125000"}]
+		}]}}`))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewUniswapScraper(mockServer.URL, []string{"0x88E6A0c2dDD26FEEb64F039a2c41296FcB3f5640"})
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "uniswap_v3_pools", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+
+	values := make(map[string]float64)
+	for _, point := range points {
+		values[point.Code] = point.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"usdc_weth_500_token0_price": 1805.25,
+		"usdc_weth_500_token1_price": 0.000553939898,
+		"usdc_weth_500_liquidity":    6543210987654321,
+		"usdc_weth_500_tvl_usd":      150000000.12,
+		"usdc_weth_500_volume_usd":   250000000,
+		"usdc_weth_500_fees_usd":     125000,
+	}, values)
+	assert.Equal(t, time.Date(2025, 4, 4, 0, 0, 0, 0, time.UTC), points[len(points)-1].Timestamp, "Daily metrics should use the day timestamp")
+}
+
+func TestUniswapScraper_GraphQLError(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":[{"message":"auth error: missing authorization header"}]}`))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewUniswapScraper(mockServer.URL, DefaultUniswapPools).Scrape(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing authorization header")
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+)
+
+// DefaultVIXHistoryURL is the CBOE daily VIX history CSV
+const DefaultVIXHistoryURL = "https://cdn.cboe.com/api/global/us_indices/daily_prices/VIX_History.csv"
+
+// vixRecentDays is the number of most recent sessions emitted per scrape, so missed days are backfilled
+const vixRecentDays = 5
+
+// VIXScraper implements the Scraper interface for the CBOE VIX daily level
+type VIXScraper struct {
+	csvURL     string
+	httpClient *http.Client
+}
+
+// NewVIXScraper creates a new VIX scraper for the given CSV history URL
+func NewVIXScraper(csvURL string) *VIXScraper {
+	return &VIXScraper{
+		csvURL:     csvURL,
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Name returns the unique identifier for this scraper
+func (s *VIXScraper) Name() string {
+	return "cboe_vix"
+}
+
+// Schedule returns the recommended scraping interval
+func (s *VIXScraper) Schedule() time.Duration {
+	return 6 * time.Hour
+}
+
+// Validate checks if the scraper configuration is valid
+func (s *VIXScraper) Validate(ctx context.Context) error {
+	if s.csvURL == "" {
+		return fmt.Errorf("CSV URL is required")
+	}
+	return nil
+}
+
+// Init performs any necessary initialization
+func (s *VIXScraper) Init(ctx context.Context) error {
+	// No specific initialization needed
+	return nil
+}
+
+// Series returns the daily open, high, low and close of the VIX
+func (s *VIXScraper) Series() []SeriesInfo {
+	var series []SeriesInfo
+	for _, metric := range []string{"open", "high", "low", "close"} {
+		series = append(series, SeriesInfo{
+			Code:        "vix_" + metric,
+			Description: "CBOE Volatility Index daily " + metric,
+			Unit:        "index",
+			Frequency:   FrequencyBusinessDay,
+			Country:     "US",
+		})
+	}
+	return series
+}
+
+// Scrape collects the daily VIX open, high, low and close of the most recent sessions
+func (s *VIXScraper) Scrape(ctx context.Context) ([]Result, error) {
+	body, err := fetch(ctx, s.httpClient, s.csvURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch VIX history: %w", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse VIX history: %w", errclass.ErrParse, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("VIX history is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"DATE", "OPEN", "HIGH", "LOW", "CLOSE"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: VIX history is missing column %s", errclass.ErrSourceChanged, name)
+		}
+	}
+
+	// The history is sorted by date, only the latest sessions are emitted
+	rows := records[1:]
+	rows = rows[len(rows)-min(len(rows), vixRecentDays):]
+
+	var points []TimeSeriesPoint
+	for _, row := range rows {
+		date, err := time.Parse("01/02/2006", row[columns["DATE"]])
+		if err != nil {
+			return nil, fmt.Errorf("invalid VIX date %q: %w", row[columns["DATE"]], err)
+		}
+
+		for _, metric := range []string{"open", "high", "low", "close"} {
+			raw := row[columns[strings.ToUpper(metric)]]
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid VIX %s %q on %s: %w", metric, raw, date.Format(time.DateOnly), err)
+			}
+			points = append(points, TimeSeriesPoint{
+				Code:      "vix_" + metric,
+				Value:     value,
+				Unit:      "index",
+				Timestamp: date,
+			})
+		}
+	}
+
+	result := Result{
+		Source:    s.Name(),
+		Timestamp: time.Now(),
+		Data:      points,
+	}
+
+	return []Result{result}, nil
+}
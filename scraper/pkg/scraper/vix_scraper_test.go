@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVIXScraper_Scrape(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("DATE,OPEN,HIGH,LOW,CLOSE\n")
+	for day := 1; day <= 8; day++ {
+		fmt.Fprintf(&csv, "11/%02d/2024,%d.10,%d.50,%d.00,%d.25\n", day, 14+day, 15+day, 13+day, 14+day)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write([]byte(csv.String()))
+	}))
+	defer mockServer.Close()
+
+	scraper := NewVIXScraper(mockServer.URL)
+	require.NoError(t, scraper.Validate(context.Background()))
+
+	results, err := scraper.Scrape(context.Background())
+	require.NoError(t, err, "Scrape should not return an error")
+	require.Len(t, results, 1, "Should return exactly 1 result")
+	assert.Equal(t, "cboe_vix", results[0].Source)
+
+	points, ok := results[0].Data.([]TimeSeriesPoint)
+	require.True(t, ok, "Result data should be of type []TimeSeriesPoint")
+	require.Len(t, points, vixRecentDays*4, "Should return 4 metrics for each of the recent sessions")
+
+	assert.Equal(t, time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC), points[0].Timestamp, "Should start at the oldest recent session")
+	latest := points[len(points)-1]
+	assert.Equal(t, "vix_close", latest.Code)
+	assert.Equal(t, 22.25, latest.Value)
+	assert.Equal(t, time.Date(2024, 11, 8, 0, 0, 0, 0, time.UTC), latest.Timestamp)
+}
+
+func TestVIXScraper_MissingColumn(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("DATE,OPEN,HIGH,LOW\n11/01/2024,15.1,16.5,14.0\n"))
+	}))
+	defer mockServer.Close()
+
+	_, err := NewVIXScraper(mockServer.URL).Scrape(context.Background())
+	assert.ErrorIs(t, err, errclass.ErrSourceChanged, "A missing column means the source changed its format")
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// AWSOptions configures an AWSProvider
+type AWSOptions struct {
+	Region string
+	// AccessKey, SecretKey and SessionToken are static credentials, without them the credentials
+	// come from the default chain: environment, shared config files, web identity, ECS or EC2 roles
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// Endpoint overrides the regional Secrets Manager endpoint, e.g. for a VPC endpoint
+	Endpoint string
+	// Timeout bounds every request, defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. A secret name is its name or ARN, secrets
+// stored as a JSON object are returned by key
+type AWSProvider struct {
+	options     AWSOptions
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewAWSProvider creates a provider reading from Secrets Manager in the region of options, it
+// fails when the AWS configuration files cannot be read
+func NewAWSProvider(ctx context.Context, options AWSOptions) (*AWSProvider, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = "https://secretsmanager." + options.Region + ".amazonaws.com"
+	}
+
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(options.Region)}
+	if options.AccessKey != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(options.AccessKey, options.SecretKey, options.SessionToken)))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws configuration: %w", err)
+	}
+
+	return &AWSProvider{
+		options:     options,
+		credentials: awsConfig.Credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  &http.Client{Timeout: options.Timeout},
+	}, nil
+}
+
+// awsSecretValue is the response of GetSecretValue
+type awsSecretValue struct {
+	SecretString string `json:"SecretString"`
+}
+
+// awsError is the error response of the Secrets Manager API
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Secret returns the current version of a secret
+func (p *AWSProvider) Secret(ctx context.Context, name string) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(p.options.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve aws credentials: %w", err)
+	}
+	if err := p.signer.SignHTTP(ctx, creds, req, sha256Hex(body), "secretsmanager", p.options.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach secrets manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr awsError
+		_ = json.Unmarshal(data, &apiErr)
+		if strings.HasSuffix(apiErr.Type, "ResourceNotFoundException") {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		return nil, fmt.Errorf("secrets manager returned %d: %s %s", resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+
+	var secret awsSecretValue
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets manager response: %w", err)
+	}
+
+	var object map[string]any
+	if err := json.Unmarshal([]byte(secret.SecretString), &object); err != nil {
+		return map[string]string{"": secret.SecretString}, nil
+	}
+	values := make(map[string]string, len(object))
+	for key, value := range object {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		} else {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
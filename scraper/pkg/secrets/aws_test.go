@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-central-1/secretsmanager/aws4_request")
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+
+		var request struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		switch request.SecretId {
+		case "macrochain/api-keys":
+			_, _ = w.Write([]byte(`{"Name":"macrochain/api-keys","SecretString":"{\"eia\":\"eia-key\"}"}`))
+		case "macrochain/db-password":
+			_, _ = w.Write([]byte(`{"Name":"macrochain/db-password","SecretString":"hunter2"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewAWSProvider(context.Background(), AWSOptions{Region: "eu-central-1", AccessKey: "key", SecretKey: "secret", SessionToken: "token", Endpoint: server.URL})
+	require.NoError(t, err)
+	values, err := provider.Secret(context.Background(), "macrochain/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"eia": "eia-key"}, values)
+
+	values, err = provider.Secret(context.Background(), "macrochain/db-password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"": "hunter2"}, values)
+
+	_, err = provider.Secret(context.Background(), "macrochain/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
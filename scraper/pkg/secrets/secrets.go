@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix marks a configuration value as a reference to a secret, e.g.
+// secret:macrochain/api-keys#fred, the part after # selects a key of the secret
+const Prefix = "secret:"
+
+// ErrNotFound is returned when a secret or a key of a secret does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider reads secrets from a secrets backend
+type Provider interface {
+	// Secret returns the key/value pairs of a secret, a secret holding a plain string has it
+	// under the empty key
+	Secret(ctx context.Context, name string) (map[string]string, error)
+}
+
+// Reference is a parsed reference to a secret
+type Reference struct {
+	Name string
+	Key  string
+}
+
+// ParseReference parses a configuration value, ok is false when it is not a secret reference
+func ParseReference(value string) (reference Reference, ok bool, err error) {
+	rest, ok := strings.CutPrefix(value, Prefix)
+	if !ok {
+		return Reference{}, false, nil
+	}
+	reference.Name, reference.Key, _ = strings.Cut(rest, "#")
+	if reference.Name == "" {
+		return Reference{}, true, fmt.Errorf("secret reference %q has no name", value)
+	}
+	return reference, true, nil
+}
+
+// lookup returns the value of a key of a secret, without a key the secret must hold a single value
+func lookup(values map[string]string, reference Reference) (string, error) {
+	if reference.Key == "" && len(values) == 1 {
+		for _, value := range values {
+			return value, nil
+		}
+	}
+	value, ok := values[reference.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q of %s: %w", reference.Key, reference.Name, ErrNotFound)
+	}
+	return value, nil
+}
+
+// cachedSecret is a secret as last read from the provider
+type cachedSecret struct {
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+// Resolver replaces secret references with the values read from a provider. Secrets are cached
+// for a TTL, and the cached value is kept when the provider fails so a backend outage does not
+// break running processes
+type Resolver struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+// NewResolver creates a resolver caching the secrets of provider for ttl, defaulting to 5 minutes
+func NewResolver(provider Provider, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+
+	return &Resolver{
+		provider: provider,
+		ttl:      ttl,
+		cache:    make(map[string]cachedSecret),
+	}
+}
+
+// Resolve returns the secret value of a reference, values that are not references are returned as is
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	reference, ok, err := ParseReference(value)
+	if !ok || err != nil {
+		return value, err
+	}
+
+	values, err := r.secret(ctx, reference.Name)
+	if err != nil {
+		return "", err
+	}
+	return lookup(values, reference)
+}
+
+// secret returns a secret from the cache, reading it from the provider once it expired
+func (r *Resolver) secret(ctx context.Context, name string) (map[string]string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[name]
+	r.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < r.ttl {
+		return cached.values, nil
+	}
+
+	values, err := r.provider.Secret(ctx, name)
+	if err != nil {
+		if ok {
+			slog.WarnContext(ctx, "Failed to refresh secret, using the cached value", "secret", name, "error", err)
+			return cached.values, nil
+		}
+		return nil, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	r.cache[name] = cachedSecret{values: values, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return values, nil
+}
+
+// ResolveStruct replaces the secret references in the exported string fields of the struct
+// target points to, including the ones of nested structs, slices and maps
+func (r *Resolver) ResolveStruct(ctx context.Context, target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a struct, got %T", target)
+	}
+	return r.resolveValue(ctx, value.Elem())
+}
+
+// resolveValue resolves the references in a settable value
+func (r *Resolver) resolveValue(ctx context.Context, value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.String:
+		resolved, err := r.Resolve(ctx, value.String())
+		if err != nil {
+			return err
+		}
+		value.SetString(resolved)
+	case reflect.Struct:
+		for i := range value.NumField() {
+			if field := value.Field(i); field.CanSet() {
+				if err := r.resolveValue(ctx, field); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice:
+		for i := range value.Len() {
+			if err := r.resolveValue(ctx, value.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if !value.IsNil() {
+			return r.resolveValue(ctx, value.Elem())
+		}
+	case reflect.Map:
+		// Map values are not addressable, they are resolved in a copy
+		for _, key := range value.MapKeys() {
+			entry := reflect.New(value.Type().Elem()).Elem()
+			entry.Set(value.MapIndex(key))
+			if err := r.resolveValue(ctx, entry); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, entry)
+		}
+	}
+	return nil
+}
+
+// Refresh reads every cached secret from the provider again, returning the names of the ones
+// whose values changed since they were cached
+func (r *Resolver) Refresh(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	names := slices.Sorted(maps.Keys(r.cache))
+	r.mu.Unlock()
+
+	var rotated []string
+	var errs []error
+	for _, name := range names {
+		values, err := r.provider.Secret(ctx, name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read secret %s: %w", name, err))
+			continue
+		}
+
+		r.mu.Lock()
+		if !maps.Equal(r.cache[name].values, values) {
+			rotated = append(rotated, name)
+		}
+		r.cache[name] = cachedSecret{values: values, fetchedAt: time.Now()}
+		r.mu.Unlock()
+	}
+	return rotated, errors.Join(errs...)
+}
+
+// Watch refreshes the cached secrets each interval until the context is cancelled, calling
+// onRotate with the names of the secrets that changed. Values already resolved into the
+// configuration are not updated, onRotate decides how they are applied
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration, onRotate func(names []string)) {
+	slog.InfoContext(ctx, "Secret watcher started", "interval", interval)
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Secret watcher stopped")
+			return
+		}
+
+		rotated, err := r.Refresh(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "Failed to refresh secrets", "error", err)
+		}
+		if len(rotated) > 0 {
+			onRotate(rotated)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryProvider serves secrets from a map and counts the reads
+type memoryProvider struct {
+	secrets map[string]map[string]string
+	reads   int
+	failing bool
+}
+
+func (p *memoryProvider) Secret(ctx context.Context, name string) (map[string]string, error) {
+	p.reads++
+	if p.failing {
+		return nil, errors.New("connection refused")
+	}
+	values, ok := p.secrets[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return values, nil
+}
+
+func TestParseReference(t *testing.T) {
+	reference, ok, err := ParseReference("secret:macrochain/api-keys#fred")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Reference{Name: "macrochain/api-keys", Key: "fred"}, reference)
+
+	_, ok, err = ParseReference("https://api.eia.gov")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = ParseReference("secret:#fred")
+	assert.True(t, ok)
+	assert.Error(t, err)
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+	provider := &memoryProvider{secrets: map[string]map[string]string{
+		"api-keys": {"eia": "eia-key", "etherscan": "etherscan-key"},
+		"db":       {"": "db-password"},
+	}}
+	resolver := NewResolver(provider, time.Hour)
+
+	value, err := resolver.Resolve(ctx, "secret:api-keys#eia")
+	require.NoError(t, err)
+	assert.Equal(t, "eia-key", value)
+	value, err = resolver.Resolve(ctx, "secret:db")
+	require.NoError(t, err)
+	assert.Equal(t, "db-password", value)
+	value, err = resolver.Resolve(ctx, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", value)
+
+	_, err = resolver.Resolve(ctx, "secret:api-keys")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = resolver.Resolve(ctx, "secret:missing#key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Cached secrets are not read again
+	reads := provider.reads
+	_, err = resolver.Resolve(ctx, "secret:api-keys#etherscan")
+	require.NoError(t, err)
+	assert.Equal(t, reads, provider.reads)
+
+	// Rotated secrets are reported, a failing provider keeps the cached values
+	provider.secrets["api-keys"] = map[string]string{"eia": "rotated", "etherscan": "etherscan-key"}
+	rotated, err := resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api-keys"}, rotated)
+
+	provider.failing = true
+	resolver.ttl = 0
+	value, err = resolver.Resolve(ctx, "secret:api-keys#eia")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", value)
+}
+
+func TestResolver_ResolveStruct(t *testing.T) {
+	type section struct {
+		APIKey  string
+		Enabled *bool
+	}
+	type config struct {
+		Host     string
+		Password string
+		Keys     []string
+		Sections map[string]section
+		Timeout  time.Duration
+		internal string
+	}
+
+	enabled := true
+	target := config{
+		Host:     "localhost",
+		Password: "secret:db",
+		Keys:     []string{"secret:api-keys#eia", "literal"},
+		Sections: map[string]section{"eia": {APIKey: "secret:api-keys#eia", Enabled: &enabled}},
+		internal: "secret:db",
+	}
+	provider := &memoryProvider{secrets: map[string]map[string]string{
+		"api-keys": {"eia": "eia-key"},
+		"db":       {"": "db-password"},
+	}}
+	resolver := NewResolver(provider, time.Hour)
+
+	require.NoError(t, resolver.ResolveStruct(context.Background(), &target))
+	assert.Equal(t, "localhost", target.Host)
+	assert.Equal(t, "db-password", target.Password)
+	assert.Equal(t, []string{"eia-key", "literal"}, target.Keys)
+	assert.Equal(t, "eia-key", target.Sections["eia"].APIKey)
+	assert.True(t, *target.Sections["eia"].Enabled)
+	assert.Equal(t, "secret:db", target.internal)
+
+	assert.Error(t, resolver.ResolveStruct(context.Background(), target))
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultOptions configures a VaultProvider
+type VaultOptions struct {
+	// Address is the address of the Vault server, e.g. https://vault:8200
+	Address string
+	Token   string
+	// Namespace is the Vault Enterprise namespace, empty for the root namespace
+	Namespace string
+	// Timeout bounds every request, defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// VaultProvider reads secrets from the KV version 2 secrets engine of HashiCorp Vault. A secret
+// name is the mount followed by the path of the secret, e.g. secret/macrochain/api-keys
+type VaultProvider struct {
+	options    VaultOptions
+	httpClient *http.Client
+}
+
+// NewVaultProvider creates a provider reading from the Vault server of options
+func NewVaultProvider(options VaultOptions) *VaultProvider {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	return &VaultProvider{
+		options:    options,
+		httpClient: &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// vaultResponse is the response of a KV version 2 read
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// Secret returns the latest version of a secret
+func (p *VaultProvider) Secret(ctx context.Context, name string) (map[string]string, error) {
+	mount, path, ok := strings.Cut(strings.Trim(name, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("vault secret %q is not of the form mount/path", name)
+	}
+
+	endpoint := strings.TrimSuffix(p.options.Address, "/") + "/v1/" + url.PathEscape(mount) + "/data/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.options.Token)
+	if p.options.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.options.Namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	values := make(map[string]string, len(response.Data.Data))
+	for key, value := range response.Data.Data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		} else {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/macrochain/api-keys" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"eia":"eia-key","port":5432},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	provider := NewVaultProvider(VaultOptions{Address: server.URL, Token: "token"})
+	values, err := provider.Secret(context.Background(), "secret/macrochain/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"eia": "eia-key", "port": "5432"}, values)
+
+	_, err = provider.Secret(context.Background(), "secret/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = provider.Secret(context.Background(), "secret")
+	assert.Error(t, err)
+
+	denied := NewVaultProvider(VaultOptions{Address: server.URL, Token: "expired"})
+	_, err = denied.Secret(context.Background(), "secret/macrochain/api-keys")
+	assert.Error(t, err)
+}
@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Reasons of an Anomaly
+const (
+	// AnomalyZScore is a value too many deviations away from the mean of the recent values
+	AnomalyZScore = "zscore"
+	// AnomalyJump is a value changing too much relative to the previous value
+	AnomalyJump = "jump"
+)
+
+// Metadata keys of the points tagged as anomalous
+const (
+	MetadataAnomaly      = "anomaly"
+	MetadataAnomalyScore = "anomaly_score"
+)
+
+// Anomaly is an implausible observation found by an AnomalyDetector
+type Anomaly struct {
+	Point  Point
+	Reason string
+	// Score is the z-score of the value for AnomalyZScore, its relative change for AnomalyJump
+	Score float64
+	// Previous is the latest value of the series before the point
+	Previous float64
+	// Quarantined is set when the point was held back instead of stored with a tag
+	Quarantined bool
+}
+
+// HistoryReader reads the recent values of the series the detector has not seen since it started
+type HistoryReader interface {
+	// Recent returns up to limit points of a series before a time ordered by time
+	Recent(ctx context.Context, source, code string, before time.Time, limit int) ([]Point, error)
+}
+
+// QuarantineStore keeps the anomalous points held back by the detector
+type QuarantineStore interface {
+	Quarantine(ctx context.Context, anomalies []Anomaly) error
+	// Release removes points from quarantine once they were stored
+	Release(ctx context.Context, points []Point) error
+}
+
+// AnomalyOptions configures an AnomalyDetector
+type AnomalyOptions struct {
+	// Sources are glob patterns of the checked sources, e.g. "snb" or "fred"
+	Sources []string
+	// Window is the number of recent values a value is compared to, defaults to 30
+	Window int
+	// MinHistory is the number of values a series needs before the z-score applies, defaults to 10
+	MinHistory int
+	// ZScore is the number of deviations from the mean above which a value is anomalous, defaults to 6
+	ZScore float64
+	// MinDeviation is the lowest deviation as a share of the mean, so a change of a series that was
+	// constant over the window, such as a policy rate, is not anomalous by itself. Defaults to 0.05
+	MinDeviation float64
+	// MaxJump is the largest change relative to the previous value, e.g. 10 for 1000%, zero
+	// disables the check
+	MaxJump float64
+	// RegimeChange is the number of consecutive anomalous values at one level after which the
+	// series is taken to have moved to that level, e.g. a currency peg that was dropped. They
+	// become the history of the series and quarantined ones are released. Zero disables it
+	RegimeChange int
+	// History warms up the series on their first value, nil starts them without history
+	History HistoryReader
+	// Quarantine holds the anomalous points back instead of storing them tagged, nil tags them
+	Quarantine QuarantineStore
+	// OnAnomaly is called for every anomaly after the points were written, e.g. to alert
+	OnAnomaly func(ctx context.Context, anomaly Anomaly)
+}
+
+// seriesKey identifies a series across sources
+type seriesKey struct {
+	source string
+	code   string
+}
+
+// seriesHistory is the window of the recent values of a series, the latest last
+type seriesHistory struct {
+	values []float64
+	latest time.Time
+	// pending are the consecutive anomalous points since the latest stored value
+	pending []Point
+}
+
+// AnomalyDetector is a Storage comparing the points written through it to the recent values of
+// their series. Implausible values, e.g. a rate of 25.0 parsed from the wrong field, are stored
+// with the anomaly and anomaly_score metadata, or held back in quarantine. The recent values are
+// kept in memory and only include the stored points, until RegimeChange anomalous values at
+// one level replace them
+type AnomalyDetector struct {
+	Storage
+	options AnomalyOptions
+
+	mu      sync.Mutex
+	history map[seriesKey]*seriesHistory
+}
+
+// NewAnomalyDetector creates a detector storing the checked points in store
+func NewAnomalyDetector(store Storage, options AnomalyOptions) *AnomalyDetector {
+	if options.Window <= 0 {
+		options.Window = 30
+	}
+	if options.MinHistory <= 0 {
+		options.MinHistory = 10
+	}
+	options.MinHistory = min(options.MinHistory, options.Window)
+	if options.ZScore <= 0 {
+		options.ZScore = 6
+	}
+	if options.MinDeviation <= 0 {
+		options.MinDeviation = 0.05
+	}
+
+	return &AnomalyDetector{
+		Storage: store,
+		options: options,
+		history: make(map[seriesKey]*seriesHistory),
+	}
+}
+
+// WritePoints checks the points of a result before storing them. The recent values are only
+// updated once the points are stored, so a retried result is checked against the same history
+func (d *AnomalyDetector) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	if !matchesAny(d.options.Sources, result.Source) {
+		return d.Storage.WritePoints(ctx, result)
+	}
+	points, err := Normalize(result)
+	if err != nil || len(points) == 0 {
+		return d.Storage.WritePoints(ctx, result)
+	}
+
+	history := d.recent(ctx, points)
+	checked := d.inspect(points, history)
+	if len(checked.anomalies) > 0 || len(checked.released) > 0 {
+		if d.options.Quarantine != nil && len(checked.anomalies) > 0 {
+			if err := d.options.Quarantine.Quarantine(ctx, checked.anomalies); err != nil {
+				return 0, err
+			}
+		}
+		result = pointsResult(result, checked.stored)
+	}
+
+	written, err := d.Storage.WritePoints(ctx, result)
+	if err != nil {
+		return written, err
+	}
+
+	d.mu.Lock()
+	maps.Copy(d.history, history)
+	d.mu.Unlock()
+
+	// The released points were stored with the result, a failed release leaves a copy in quarantine
+	if len(checked.released) > 0 {
+		if err := d.options.Quarantine.Release(ctx, checked.released); err != nil {
+			slog.ErrorContext(ctx, "Failed to release quarantined points", "source", result.Source, "error", err)
+		}
+	}
+	for _, level := range checked.levels {
+		slog.WarnContext(ctx, "Detected new level of series", "source", level.Point.Source, "code", level.Point.Code,
+			"time", level.Point.Timestamp, "value", level.Point.Value, "previous", level.Previous)
+	}
+
+	for _, anomaly := range checked.anomalies {
+		slog.WarnContext(ctx, "Detected implausible value", "source", anomaly.Point.Source, "code", anomaly.Point.Code,
+			"time", anomaly.Point.Timestamp, "value", anomaly.Point.Value, "previous", anomaly.Previous,
+			"reason", anomaly.Reason, "score", anomaly.Score, "quarantined", anomaly.Quarantined)
+		if d.options.OnAnomaly != nil {
+			d.options.OnAnomaly(ctx, anomaly)
+		}
+	}
+	return written, nil
+}
+
+// recent returns a copy of the history of every series of points, the series seen for the first
+// time are read from the history reader
+func (d *AnomalyDetector) recent(ctx context.Context, points []Point) map[seriesKey]*seriesHistory {
+	history := make(map[seriesKey]*seriesHistory)
+	var missing []Point
+	d.mu.Lock()
+	for _, point := range points {
+		key := seriesKey{source: point.Source, code: point.Code}
+		if _, ok := history[key]; ok {
+			continue
+		}
+		if h, ok := d.history[key]; ok {
+			history[key] = &seriesHistory{values: slices.Clone(h.values), latest: h.latest, pending: slices.Clone(h.pending)}
+			continue
+		}
+		history[key] = &seriesHistory{}
+		missing = append(missing, point)
+	}
+	d.mu.Unlock()
+
+	if d.options.History == nil {
+		return history
+	}
+	for _, point := range missing {
+		recent, err := d.options.History.Recent(ctx, point.Source, point.Code, point.Timestamp, d.options.Window)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read recent values, the series starts without history", "source", point.Source, "code", point.Code, "error", err)
+			continue
+		}
+		h := history[seriesKey{source: point.Source, code: point.Code}]
+		for _, p := range recent {
+			h.values = append(h.values, p.Value)
+			h.latest = p.Timestamp
+		}
+	}
+	return history
+}
+
+// inspection is the outcome of checking the points of a result
+type inspection struct {
+	// stored are the points to store, tagged when they are anomalous
+	stored    []Point
+	anomalies []Anomaly
+	// released are the quarantined points of a new level of their series, they are stored too
+	released []Point
+	// levels are the values that completed a regime change, with the value of the old level
+	levels []Anomaly
+}
+
+// inspect checks the new observations of points in time order against history, adding the
+// stored ones to it. Observations that are not newer than the history, such as the past values
+// some sources return on every run, are stored unchecked
+func (d *AnomalyDetector) inspect(points []Point, history map[seriesKey]*seriesHistory) inspection {
+	points = slices.Clone(points)
+	slices.SortStableFunc(points, func(a, b Point) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	checked := inspection{stored: make([]Point, 0, len(points))}
+	for _, point := range points {
+		h := history[seriesKey{source: point.Source, code: point.Code}]
+		if !point.Timestamp.After(h.latest) {
+			checked.stored = append(checked.stored, point)
+			continue
+		}
+
+		anomaly, found := d.check(point, h.values)
+		if found && d.options.RegimeChange > 0 {
+			h.pending = append(h.pending, point)
+			if len(h.pending) > d.options.RegimeChange {
+				h.pending = h.pending[1:]
+			}
+			if d.newLevel(h.pending) {
+				// The pending points are the first values of the new level, the latest one is
+				// stored like any other value
+				checked.levels = append(checked.levels, anomaly)
+				if d.options.Quarantine != nil {
+					pending := h.pending[:len(h.pending)-1]
+					checked.released = append(checked.released, pending...)
+					checked.stored = append(checked.stored, pending...)
+				}
+				h.values = nil
+				for _, p := range h.pending[:len(h.pending)-1] {
+					h.values = append(h.values, p.Value)
+				}
+				found = false
+			}
+		}
+		if found {
+			anomaly.Quarantined = d.options.Quarantine != nil
+			checked.anomalies = append(checked.anomalies, anomaly)
+			if anomaly.Quarantined {
+				continue
+			}
+			point.Metadata = tagAnomaly(point.Metadata, anomaly)
+		} else {
+			h.pending = nil
+		}
+		checked.stored = append(checked.stored, point)
+
+		h.values = append(h.values, point.Value)
+		if len(h.values) > d.options.Window {
+			h.values = h.values[len(h.values)-d.options.Window:]
+		}
+		h.latest = point.Timestamp
+	}
+	return checked
+}
+
+// newLevel reports whether the pending anomalous points of a series are RegimeChange values
+// within ZScore minimum deviations of their mean, i.e. a new level rather than noise
+func (d *AnomalyDetector) newLevel(pending []Point) bool {
+	if len(pending) < d.options.RegimeChange {
+		return false
+	}
+	var sum float64
+	for _, p := range pending {
+		sum += p.Value
+	}
+	mean := sum / float64(len(pending))
+	for _, p := range pending {
+		if math.Abs(p.Value-mean) > d.options.ZScore*d.options.MinDeviation*math.Abs(mean) {
+			return false
+		}
+	}
+	return true
+}
+
+// check compares a value to the recent values of its series, the latest last
+func (d *AnomalyDetector) check(point Point, values []float64) (Anomaly, bool) {
+	if len(values) == 0 {
+		return Anomaly{}, false
+	}
+	previous := values[len(values)-1]
+
+	if d.options.MaxJump > 0 && previous != 0 {
+		change := math.Abs(point.Value-previous) / math.Abs(previous)
+		if change > d.options.MaxJump {
+			return Anomaly{Point: point, Reason: AnomalyJump, Score: change, Previous: previous}, true
+		}
+	}
+
+	if len(values) < d.options.MinHistory {
+		return Anomaly{}, false
+	}
+	mean, deviation := meanDeviation(values)
+	deviation = max(deviation, d.options.MinDeviation*math.Abs(mean))
+	if deviation == 0 {
+		return Anomaly{}, false
+	}
+	z := math.Abs(point.Value-mean) / deviation
+	if z > d.options.ZScore {
+		return Anomaly{Point: point, Reason: AnomalyZScore, Score: z, Previous: previous}, true
+	}
+	return Anomaly{}, false
+}
+
+// meanDeviation returns the mean and standard deviation of values
+func meanDeviation(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// tagAnomaly returns a copy of metadata with the reason and score of anomaly
+func tagAnomaly(metadata map[string]string, anomaly Anomaly) map[string]string {
+	tagged := maps.Clone(metadata)
+	if tagged == nil {
+		tagged = make(map[string]string, 2)
+	}
+	tagged[MetadataAnomaly] = anomaly.Reason
+	tagged[MetadataAnomalyScore] = strconv.FormatFloat(anomaly.Score, 'g', 4, 64)
+	return tagged
+}
+
+// pointsResult returns result with the points as its data, the points carry the metadata of the
+// result merged by Normalize
+func pointsResult(result scraper.Result, points []Point) scraper.Result {
+	data := make([]scraper.TimeSeriesPoint, 0, len(points))
+	for _, point := range points {
+		data = append(data, scraper.TimeSeriesPoint{
+			Code:      point.Code,
+			Value:     point.Value,
+			Unit:      point.Unit,
+			Timestamp: point.Timestamp,
+			Metadata:  point.Metadata,
+		})
+	}
+	result.Data = data
+	return result
+}
+
+// Recent returns up to limit points of a series before a time ordered by time
+func (r *PostgresRepository) Recent(ctx context.Context, source, code string, before time.Time, limit int) ([]Point, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, time, value, unit, metadata FROM (
+			SELECT source, code, time, value, unit, metadata FROM data_points
+			WHERE source = $1 AND code = $2 AND time < $3
+			ORDER BY time DESC LIMIT $4
+		) recent ORDER BY time`, source, code, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent points of %s %s: %w", source, code, err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Point, error) {
+		var point Point
+		err := row.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata)
+		return point, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent points of %s %s: %w", source, code, err)
+	}
+	return points, nil
+}
+
+// Quarantine stores anomalous points in quarantined_points for review, a point quarantined again
+// replaces the previous one
+func (r *PostgresRepository) Quarantine(ctx context.Context, anomalies []Anomaly) error {
+	batch := &pgx.Batch{}
+	for _, anomaly := range anomalies {
+		point := anomaly.Point
+		batch.Queue(`
+			INSERT INTO quarantined_points (source, code, time, value, unit, metadata, run_id, reason, score, previous)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+			ON CONFLICT (source, code, time) DO UPDATE SET
+				value = EXCLUDED.value, unit = EXCLUDED.unit, metadata = EXCLUDED.metadata, run_id = EXCLUDED.run_id,
+				reason = EXCLUDED.reason, score = EXCLUDED.score, previous = EXCLUDED.previous, detected_at = now()`,
+			point.Source, point.Code, point.Timestamp, point.Value, point.Unit, point.Metadata, point.RunID,
+			anomaly.Reason, anomaly.Score, anomaly.Previous)
+	}
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to quarantine points: %w", err)
+	}
+	return nil
+}
+
+// QuarantinedPoints returns the quarantined anomalies of a source ordered by code and time, or
+// of every source when source is empty, only of one series when code is set too
+func (r *PostgresRepository) QuarantinedPoints(ctx context.Context, source, code string) ([]Anomaly, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, time, value, unit, metadata, coalesce(run_id, ''), reason, score, coalesce(previous, 'NaN')
+		FROM quarantined_points
+		WHERE ($1 = '' OR source = $1) AND ($2 = '' OR code = $2)
+		ORDER BY source, code, time`, source, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined points: %w", err)
+	}
+
+	anomalies, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Anomaly, error) {
+		anomaly := Anomaly{Quarantined: true}
+		point := &anomaly.Point
+		err := row.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata,
+			&point.RunID, &anomaly.Reason, &anomaly.Score, &anomaly.Previous)
+		return anomaly, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantined points: %w", err)
+	}
+	return anomalies, nil
+}
+
+// Release removes points from quarantine, once they were stored or found to be wrong
+func (r *PostgresRepository) Release(ctx context.Context, points []Point) error {
+	batch := &pgx.Batch{}
+	for _, point := range points {
+		batch.Queue(`DELETE FROM quarantined_points WHERE source = $1 AND code = $2 AND time = $3`,
+			point.Source, point.Code, point.Timestamp)
+	}
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to release quarantined points: %w", err)
+	}
+	return nil
+}
+
+// ReleaseQuarantined stores quarantined anomalies in store tagged with their reason and score,
+// then removes them from quarantine. It returns how many points were stored
+func ReleaseQuarantined(ctx context.Context, store Storage, quarantine QuarantineStore, anomalies []Anomaly) (int, error) {
+	bySource := make(map[string][]Point)
+	for _, anomaly := range anomalies {
+		point := anomaly.Point
+		point.Metadata = tagAnomaly(point.Metadata, anomaly)
+		bySource[point.Source] = append(bySource[point.Source], point)
+	}
+
+	written := 0
+	for _, source := range slices.Sorted(maps.Keys(bySource)) {
+		points := bySource[source]
+		n, err := store.WritePoints(ctx, pointsResult(scraper.Result{Source: source, Timestamp: time.Now()}, points))
+		if err != nil {
+			return written, fmt.Errorf("failed to store released points of %s: %w", source, err)
+		}
+		written += n
+		if err := quarantine.Release(ctx, points); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
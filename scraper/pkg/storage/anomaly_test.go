@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryQuarantine keeps the quarantined anomalies
+type memoryQuarantine struct {
+	anomalies []Anomaly
+	err       error
+}
+
+func (q *memoryQuarantine) Quarantine(ctx context.Context, anomalies []Anomaly) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.anomalies = append(q.anomalies, anomalies...)
+	return nil
+}
+
+func (q *memoryQuarantine) Release(ctx context.Context, points []Point) error {
+	q.anomalies = slices.DeleteFunc(q.anomalies, func(anomaly Anomaly) bool {
+		return slices.ContainsFunc(points, func(point Point) bool {
+			return point.Source == anomaly.Point.Source && point.Code == anomaly.Point.Code && point.Timestamp.Equal(anomaly.Point.Timestamp)
+		})
+	})
+	return nil
+}
+
+// memoryHistory serves the recent points of a series from a fixed list
+type memoryHistory []Point
+
+func (h memoryHistory) Recent(ctx context.Context, source, code string, before time.Time, limit int) ([]Point, error) {
+	var recent []Point
+	for _, point := range h {
+		if point.Source == source && point.Code == code && point.Timestamp.Before(before) {
+			recent = append(recent, point)
+		}
+	}
+	return recent[max(0, len(recent)-limit):], nil
+}
+
+var anomalyStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// rates returns a daily rate point for every value, starting at day
+func rates(day int, values ...float64) []scraper.TimeSeriesPoint {
+	points := make([]scraper.TimeSeriesPoint, 0, len(values))
+	for i, value := range values {
+		points = append(points, scraper.TimeSeriesPoint{Code: "policy_rate", Value: value, Unit: "%", Timestamp: anomalyStart.AddDate(0, 0, day+i)})
+	}
+	return points
+}
+
+func TestAnomalyDetectorTags(t *testing.T) {
+	store := &memoryStorage{points: make(map[string][]Point)}
+	var found []Anomaly
+	detector := NewAnomalyDetector(store, AnomalyOptions{
+		Sources:    []string{"snb"},
+		MinHistory: 5,
+		OnAnomaly:  func(ctx context.Context, anomaly Anomaly) { found = append(found, anomaly) },
+	})
+	ctx := context.Background()
+
+	_, err := detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(0, 1.75, 1.75, 1.75, 1.75, 1.75)})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// A rate cut moves less than the minimum deviation allows, a wrongly parsed field does not
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(5, 1.5, 25.0)})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, AnomalyZScore, found[0].Reason)
+	assert.Equal(t, 25.0, found[0].Point.Value)
+	assert.Equal(t, 1.5, found[0].Previous)
+	assert.False(t, found[0].Quarantined)
+
+	stored := store.points["policy_rate"]
+	require.Len(t, stored, 7, "tagged points are stored")
+	assert.Empty(t, stored[5].Metadata[MetadataAnomaly])
+	assert.Equal(t, AnomalyZScore, stored[6].Metadata[MetadataAnomaly])
+	assert.NotEmpty(t, stored[6].Metadata[MetadataAnomalyScore])
+
+	// Past observations sent again are not checked
+	found = nil
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(0, 30.0)})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// Other sources are not checked
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "fred", Data: rates(0, 1, 1, 1, 1, 1, 1000)})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestAnomalyDetectorQuarantines(t *testing.T) {
+	store := &memoryStorage{points: make(map[string][]Point)}
+	quarantine := &memoryQuarantine{}
+	detector := NewAnomalyDetector(store, AnomalyOptions{
+		Sources:    []string{"*"},
+		MaxJump:    5,
+		Quarantine: quarantine,
+	})
+	ctx := context.Background()
+
+	_, err := detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(0, 1.75, 20.0, 1.75)})
+	require.NoError(t, err)
+	require.Len(t, quarantine.anomalies, 1)
+	assert.Equal(t, AnomalyJump, quarantine.anomalies[0].Reason)
+	assert.InDelta(t, 10.43, quarantine.anomalies[0].Score, 0.01)
+	assert.True(t, quarantine.anomalies[0].Quarantined)
+
+	stored := store.points["policy_rate"]
+	require.Len(t, stored, 2, "quarantined points are held back")
+	assert.Equal(t, 1.75, stored[1].Value)
+
+	// A failing quarantine fails the write so the result is retried
+	quarantine.err = errors.New("database down")
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(3, 30.0)})
+	require.Error(t, err)
+	assert.Len(t, store.points["policy_rate"], 2)
+}
+
+func TestAnomalyDetectorRegimeChange(t *testing.T) {
+	store := &memoryStorage{points: make(map[string][]Point)}
+	quarantine := &memoryQuarantine{}
+	detector := NewAnomalyDetector(store, AnomalyOptions{
+		Sources:      []string{"snb"},
+		MinHistory:   3,
+		RegimeChange: 3,
+		Quarantine:   quarantine,
+	})
+	ctx := context.Background()
+
+	_, err := detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(0, 1, 1, 1, 1)})
+	require.NoError(t, err)
+
+	// Two values at a new level are held back, the third moves the series to it
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(4, 10, 10.2)})
+	require.NoError(t, err)
+	assert.Len(t, quarantine.anomalies, 2)
+	assert.Len(t, store.points["policy_rate"], 4)
+
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(6, 10.1)})
+	require.NoError(t, err)
+	assert.Empty(t, quarantine.anomalies, "the values of the new level are released")
+	assert.Len(t, store.points["policy_rate"], 7)
+
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(7, 10.1, 1)})
+	require.NoError(t, err)
+	require.Len(t, quarantine.anomalies, 1, "values are compared to the new level")
+	assert.Equal(t, 1.0, quarantine.anomalies[0].Point.Value)
+
+	// Scattered anomalies are not a new level
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(9, 50, 200)})
+	require.NoError(t, err)
+	assert.Len(t, quarantine.anomalies, 3)
+}
+
+func TestReleaseQuarantined(t *testing.T) {
+	store := &memoryStorage{points: make(map[string][]Point)}
+	point := Point{Source: "snb", Code: "policy_rate", Timestamp: anomalyStart, Value: 25, Unit: "%"}
+	quarantine := &memoryQuarantine{anomalies: []Anomaly{{Point: point, Reason: AnomalyJump, Score: 13, Quarantined: true}}}
+
+	written, err := ReleaseQuarantined(context.Background(), store, quarantine, quarantine.anomalies)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	assert.Empty(t, quarantine.anomalies)
+	require.Len(t, store.points["policy_rate"], 1)
+	assert.Equal(t, AnomalyJump, store.points["policy_rate"][0].Metadata[MetadataAnomaly])
+}
+
+func TestAnomalyDetectorWarmsUp(t *testing.T) {
+	var history memoryHistory
+	for i := range 20 {
+		history = append(history, Point{Source: "ecb", Code: "policy_rate", Timestamp: anomalyStart.AddDate(0, 0, i), Value: 4})
+	}
+	store := &memoryStorage{points: make(map[string][]Point)}
+	var found []Anomaly
+	detector := NewAnomalyDetector(store, AnomalyOptions{
+		Sources:   []string{"ecb"},
+		History:   history,
+		OnAnomaly: func(ctx context.Context, anomaly Anomaly) { found = append(found, anomaly) },
+	})
+
+	_, err := detector.WritePoints(context.Background(), scraper.Result{Source: "ecb", Data: rates(20, 40)})
+	require.NoError(t, err)
+	require.Len(t, found, 1, "the first value is checked against the stored history")
+	assert.Equal(t, 4.0, found[0].Previous)
+}
+
+func TestAnomalyDetectorRetryKeepsHistory(t *testing.T) {
+	failing := &failingStorage{err: errors.New("timeout")}
+	var found []Anomaly
+	detector := NewAnomalyDetector(failing, AnomalyOptions{
+		Sources:    []string{"snb"},
+		MinHistory: 3,
+		OnAnomaly:  func(ctx context.Context, anomaly Anomaly) { found = append(found, anomaly) },
+	})
+	ctx := context.Background()
+
+	failing.err = nil
+	_, err := detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(0, 1, 1, 1)})
+	require.NoError(t, err)
+
+	failing.err = errors.New("timeout")
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(3, 50)})
+	require.Error(t, err)
+	assert.Empty(t, found, "anomalies of unwritten points are not reported")
+
+	failing.err = nil
+	_, err = detector.WritePoints(ctx, scraper.Result{Source: "snb", Data: rates(3, 50)})
+	require.NoError(t, err)
+	assert.Len(t, found, 1, "the retried point is checked against the same history")
+}
+
+// failingStorage fails every write with err
+type failingStorage struct {
+	Storage
+	err error
+}
+
+func (s *failingStorage) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	return 0, s.err
+}
+
+func TestMeanDeviation(t *testing.T) {
+	mean, deviation := meanDeviation([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	assert.Equal(t, 5.0, mean)
+	assert.Equal(t, 2.0, deviation)
+}
@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+)
+
+// BarInterval is the length of the bars of an aggregation, Label names the series, e.g. "5m"
+type BarInterval struct {
+	Label    string
+	Duration time.Duration
+}
+
+// ParseBarIntervals parses labels such as "1m", "5m", "1h" or "1d", days are 24 hours
+func ParseBarIntervals(labels []string) ([]BarInterval, error) {
+	intervals := make([]BarInterval, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		duration, err := time.ParseDuration(label)
+		if days, ok := strings.CutSuffix(label, "d"); ok {
+			var n int
+			n, err = strconv.Atoi(days)
+			duration = time.Duration(n) * 24 * time.Hour
+		}
+		if err != nil || duration <= 0 || (24*time.Hour)%duration != 0 && duration%(24*time.Hour) != 0 {
+			return nil, fmt.Errorf("invalid bar interval %q, expected a divisor or multiple of a day such as 1m, 5m, 1h or 1d", label)
+		}
+		intervals = append(intervals, BarInterval{Label: label, Duration: duration})
+	}
+	return intervals, nil
+}
+
+// BarOptions selects the ticks rolled into bars and the bar intervals
+type BarOptions struct {
+	// Sources and Codes are glob patterns of the aggregated series, e.g. "binance_stream" and "*_trade_price"
+	Sources []string
+	Codes   []string
+	// Intervals are the bar lengths produced for every series
+	Intervals []BarInterval
+	// Grace is how long after its end a bar waits for late ticks before it is computed, defaults to 5 seconds
+	Grace time.Duration
+	// FlushInterval is how often the bars that received ticks are computed, defaults to 10 seconds
+	FlushInterval time.Duration
+	// Lookback is the time range whose bars are computed again on start, so the bars of ticks
+	// stored before a restart are not lost. Defaults to the longest interval
+	Lookback time.Duration
+}
+
+// barWindow is the bar of a tick series over one interval
+type barWindow struct {
+	source   string
+	code     string
+	interval BarInterval
+	start    time.Time
+}
+
+// end returns the end of the bar, exclusive
+func (w barWindow) end() time.Time {
+	return w.start.Add(w.interval.Duration)
+}
+
+// BarAggregator is a Storage computing OHLCV bars from the ticks written through it, stored as
+// the series <code>_<interval>_open, _high, _low, _close and _volume of the tick source. The
+// volume sums the quantity metadata of the ticks. Bars are computed from the stored ticks once
+// they are over, so late and retried ticks are counted once, and a bar receiving a late tick
+// is computed again. The bars waiting to be computed are kept in memory, the bars of the last
+// Lookback are computed again when the aggregator starts
+type BarAggregator struct {
+	Storage
+	options BarOptions
+
+	mu    sync.Mutex
+	dirty map[barWindow]bool
+	now   func() time.Time
+}
+
+// NewBarAggregator creates an aggregator storing ticks and bars in store
+func NewBarAggregator(store Storage, options BarOptions) *BarAggregator {
+	if options.Grace <= 0 {
+		options.Grace = 5 * time.Second
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 10 * time.Second
+	}
+	if options.Lookback <= 0 {
+		for _, interval := range options.Intervals {
+			options.Lookback = max(options.Lookback, interval.Duration)
+		}
+	}
+
+	return &BarAggregator{
+		Storage: store,
+		options: options,
+		dirty:   make(map[barWindow]bool),
+		now:     time.Now,
+	}
+}
+
+// WritePoints stores the points of a result and marks the bars of the matching ticks to be
+// computed once they are over
+func (a *BarAggregator) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	written, err := a.Storage.WritePoints(ctx, result)
+	if err != nil || !matchesAny(a.options.Sources, result.Source) {
+		return written, err
+	}
+
+	points, err := Normalize(result)
+	if err != nil {
+		return written, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, point := range points {
+		if matchesAny(a.options.Codes, point.Code) {
+			a.mark(point.Source, point.Code, point.Timestamp, point.Timestamp)
+		}
+	}
+	return written, nil
+}
+
+// mark marks the bars of every interval holding ticks of a series within [from, to]
+func (a *BarAggregator) mark(source, code string, from, to time.Time) {
+	for _, interval := range a.options.Intervals {
+		for start := from.UTC().Truncate(interval.Duration); !start.After(to); start = start.Add(interval.Duration) {
+			a.dirty[barWindow{source: source, code: code, interval: interval, start: start}] = true
+		}
+	}
+}
+
+// Recompute marks the bars of the stored tick series within [from, to) to be computed again
+func (a *BarAggregator) Recompute(ctx context.Context, from, to time.Time) error {
+	series, err := storedSeries(ctx, a.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to list tick series: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, s := range series {
+		if matchesAny(a.options.Sources, s.Source) && matchesAny(a.options.Codes, s.Code) {
+			a.mark(s.Source, s.Code, from, to.Add(-time.Nanosecond))
+		}
+	}
+	return nil
+}
+
+// Flush computes the marked bars that ended more than the grace period ago from the stored
+// ticks and stores them. Bars that failed are computed again on the next flush
+func (a *BarAggregator) Flush(ctx context.Context) {
+	now := a.now()
+
+	a.mu.Lock()
+	bySeries := make(map[Series][]barWindow)
+	for window := range a.dirty {
+		if now.Sub(window.end()) >= a.options.Grace {
+			key := Series{Source: window.source, Code: window.code}
+			bySeries[key] = append(bySeries[key], window)
+			delete(a.dirty, window)
+		}
+	}
+	a.mu.Unlock()
+
+	for series, windows := range bySeries {
+		if err := a.compute(ctx, series, windows); err != nil {
+			slog.ErrorContext(ctx, "Failed to compute bars", "source", series.Source, "code", series.Code, "bars", len(windows), "error", err)
+			a.mu.Lock()
+			for _, window := range windows {
+				a.dirty[window] = true
+			}
+			a.mu.Unlock()
+		}
+	}
+}
+
+// compute reads the ticks of a series covering windows once and stores the bars of the windows
+// holding ticks
+func (a *BarAggregator) compute(ctx context.Context, series Series, windows []barWindow) error {
+	from, to := windows[0].start, windows[0].end()
+	for _, window := range windows[1:] {
+		if window.start.Before(from) {
+			from = window.start
+		}
+		if window.end().After(to) {
+			to = window.end()
+		}
+	}
+	ticks, err := a.Storage.QueryRange(ctx, series.Source, series.Code, from, to)
+	if err != nil {
+		return err
+	}
+
+	var data []scraper.TimeSeriesPoint
+	for _, window := range windows {
+		data = append(data, barPoints(window, ticks)...)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	_, err = a.Storage.WritePoints(ctx, scraper.Result{Source: series.Source, Timestamp: a.now(), Data: data})
+	return err
+}
+
+// barPoints returns the series points of the bar of window computed from the ticks within it,
+// none when it holds no tick
+func barPoints(window barWindow, ticks []Point) []scraper.TimeSeriesPoint {
+	var open, high, low, closing, volume float64
+	var openedAt, closedAt time.Time
+	var unit string
+	found := false
+	for _, tick := range ticks {
+		if tick.Timestamp.Before(window.start) || !tick.Timestamp.Before(window.end()) {
+			continue
+		}
+		if !found || tick.Timestamp.Before(openedAt) {
+			openedAt, open = tick.Timestamp, tick.Value
+		}
+		if !found || !tick.Timestamp.Before(closedAt) {
+			closedAt, closing = tick.Timestamp, tick.Value
+		}
+		if !found {
+			high, low, unit = tick.Value, tick.Value, tick.Unit
+		}
+		high = max(high, tick.Value)
+		low = min(low, tick.Value)
+		if quantity, err := strconv.ParseFloat(tick.Metadata["quantity"], 64); err == nil {
+			volume += quantity
+		}
+		found = true
+	}
+	if !found {
+		return nil
+	}
+
+	prefix := window.code + "_" + window.interval.Label + "_"
+	return []scraper.TimeSeriesPoint{
+		{Code: prefix + "open", Timestamp: window.start, Value: open, Unit: unit},
+		{Code: prefix + "high", Timestamp: window.start, Value: high, Unit: unit},
+		{Code: prefix + "low", Timestamp: window.start, Value: low, Unit: unit},
+		{Code: prefix + "close", Timestamp: window.start, Value: closing, Unit: unit},
+		{Code: prefix + "volume", Timestamp: window.start, Value: volume},
+	}
+}
+
+// Run computes the bars of the lookback again, then computes the marked bars each flush
+// interval until the context is cancelled
+func (a *BarAggregator) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Bar aggregator started", "sources", a.options.Sources, "intervals", len(a.options.Intervals), "lookback", a.options.Lookback)
+
+	now := a.now()
+	if err := a.Recompute(ctx, now.Add(-a.options.Lookback), now); err != nil {
+		slog.ErrorContext(ctx, "Failed to compute recent bars", "error", err)
+	}
+	for {
+		select {
+		case <-time.After(a.options.FlushInterval):
+			a.Flush(ctx)
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Bar aggregator stopped")
+			return
+		}
+	}
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
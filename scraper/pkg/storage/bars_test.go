@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorage keeps the written points by code
+type memoryStorage struct {
+	Storage
+	points map[string][]Point
+}
+
+func (s *memoryStorage) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	points, err := Normalize(result)
+	for _, point := range points {
+		s.points[point.Code] = append(s.points[point.Code], point)
+	}
+	return len(points), err
+}
+
+func TestParseBarIntervals(t *testing.T) {
+	intervals, err := ParseBarIntervals([]string{"1m", "5m", "1h", "1d"})
+	require.NoError(t, err)
+	assert.Equal(t, []BarInterval{
+		{Label: "1m", Duration: time.Minute},
+		{Label: "5m", Duration: 5 * time.Minute},
+		{Label: "1h", Duration: time.Hour},
+		{Label: "1d", Duration: 24 * time.Hour},
+	}, intervals)
+
+	for _, label := range []string{"7m", "0s", "day", "-1h"} {
+		_, err := ParseBarIntervals([]string{label})
+		assert.Error(t, err, label)
+	}
+}
+
+func TestBarAggregator(t *testing.T) {
+	store := newSeriesStorage()
+	intervals, err := ParseBarIntervals([]string{"1m", "1h"})
+	require.NoError(t, err)
+	aggregator := NewBarAggregator(store, BarOptions{
+		Sources:   []string{"binance_*"},
+		Codes:     []string{"*_trade_price"},
+		Intervals: intervals,
+	})
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tick := func(source string, offset time.Duration, price float64, quantity string) {
+		t.Helper()
+		_, err := aggregator.WritePoints(context.Background(), scraper.Result{
+			Source: source,
+			Data: []scraper.TimeSeriesPoint{
+				{Code: "btcusdt_trade_price", Value: price, Unit: "price", Timestamp: start.Add(offset), Metadata: map[string]string{"quantity": quantity}},
+				{Code: "btcusdt_volume_24h", Value: 1000, Timestamp: start.Add(offset)},
+			},
+		})
+		require.NoError(t, err)
+	}
+	bar := func(source, code string) (Point, bool) {
+		point, ok := store.points[Series{Source: source, Code: code}][start]
+		return point, ok
+	}
+	value := func(code string) float64 {
+		t.Helper()
+		point, ok := bar("binance_stream", code)
+		require.True(t, ok, code)
+		return point.Value
+	}
+
+	tick("binance_stream", 5*time.Second, 100, "0.5")
+	tick("binance_stream", 20*time.Second, 120, "1")
+	tick("binance_stream", 30*time.Second, 90, "0.25")
+	// A retried tick is counted once
+	tick("binance_stream", 30*time.Second, 90, "0.25")
+	tick("coinbase", 40*time.Second, 1, "1")
+	tick("binance_stream", 70*time.Second, 110, "2")
+
+	// Bars are computed once they are over
+	aggregator.now = func() time.Time { return start.Add(30 * time.Second) }
+	aggregator.Flush(context.Background())
+	_, ok := bar("binance_stream", "btcusdt_trade_price_1m_open")
+	assert.False(t, ok)
+
+	aggregator.now = func() time.Time { return start.Add(time.Minute + 10*time.Second) }
+	aggregator.Flush(context.Background())
+	assert.Equal(t, 100.0, value("btcusdt_trade_price_1m_open"))
+	assert.Equal(t, 120.0, value("btcusdt_trade_price_1m_high"))
+	assert.Equal(t, 90.0, value("btcusdt_trade_price_1m_low"))
+	assert.Equal(t, 90.0, value("btcusdt_trade_price_1m_close"))
+	assert.Equal(t, 1.75, value("btcusdt_trade_price_1m_volume"))
+	open, _ := bar("binance_stream", "btcusdt_trade_price_1m_open")
+	assert.Equal(t, "price", open.Unit)
+	_, ok = bar("binance_stream", "btcusdt_trade_price_1h_open")
+	assert.False(t, ok, "the hour is not over")
+	_, ok = bar("binance_stream", "btcusdt_volume_24h_1m_open")
+	assert.False(t, ok)
+	_, ok = bar("coinbase", "btcusdt_trade_price_1m_open")
+	assert.False(t, ok)
+
+	// A late tick computes its bar again
+	tick("binance_stream", 10*time.Second, 500, "1")
+	aggregator.Flush(context.Background())
+	assert.Equal(t, 500.0, value("btcusdt_trade_price_1m_high"))
+	assert.Equal(t, 2.75, value("btcusdt_trade_price_1m_volume"))
+
+	aggregator.now = func() time.Time { return start.Add(time.Hour + time.Minute) }
+	aggregator.Flush(context.Background())
+	assert.Equal(t, 100.0, value("btcusdt_trade_price_1h_open"))
+	assert.Equal(t, 500.0, value("btcusdt_trade_price_1h_high"))
+	assert.Equal(t, 110.0, value("btcusdt_trade_price_1h_close"))
+	assert.Equal(t, 4.75, value("btcusdt_trade_price_1h_volume"))
+}
+
+func TestBarAggregatorRecompute(t *testing.T) {
+	store := newSeriesStorage()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// Ticks stored before a restart, their bars were never computed
+	_, err := store.WritePoints(context.Background(), scraper.Result{
+		Source: "binance_stream",
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "btcusdt_trade_price", Value: 100, Timestamp: start.Add(time.Second)},
+			{Code: "btcusdt_trade_price", Value: 105, Timestamp: start.Add(2 * time.Minute)},
+		},
+	})
+	require.NoError(t, err)
+
+	intervals, err := ParseBarIntervals([]string{"1m"})
+	require.NoError(t, err)
+	aggregator := NewBarAggregator(store, BarOptions{
+		Sources:   []string{"binance_*"},
+		Codes:     []string{"*_trade_price"},
+		Intervals: intervals,
+	})
+	aggregator.now = func() time.Time { return start.Add(5 * time.Minute) }
+	require.NoError(t, aggregator.Recompute(context.Background(), start, start.Add(5*time.Minute)))
+	aggregator.Flush(context.Background())
+
+	closes := store.points[Series{Source: "binance_stream", Code: "btcusdt_trade_price_1m_close"}]
+	require.Len(t, closes, 2, "bars without ticks are not stored")
+	assert.Equal(t, 100.0, closes[start].Value)
+	assert.Equal(t, 105.0, closes[start.Add(2*time.Minute)].Value)
+}
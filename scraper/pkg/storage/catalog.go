@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Series is an entry of the series catalog
+type Series struct {
+	ID          int64
+	Source      string
+	Code        string
+	Description string
+	Unit        string
+	Frequency   string
+	Country     string
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+// selectSeries reads catalog entries in the column order of scanSeries
+const selectSeries = `SELECT id, source, code, description, unit, frequency, country, first_seen, last_seen FROM series`
+
+// scanSeries reads a catalog entry selected with selectSeries
+func scanSeries(row pgx.Row) (Series, error) {
+	var series Series
+	err := row.Scan(&series.ID, &series.Source, &series.Code, &series.Description, &series.Unit,
+		&series.Frequency, &series.Country, &series.FirstSeen, &series.LastSeen)
+	return series, err
+}
+
+// RegisterSeries adds the series of a source to the catalog or updates their description.
+// Empty attributes keep the stored value, e.g. a unit only known once the series was fetched
+func (r *PostgresRepository) RegisterSeries(ctx context.Context, source string, series []scraper.SeriesInfo) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, info := range series {
+		batch.Queue(`
+			INSERT INTO series (source, code, description, unit, frequency, country)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (source, code) DO UPDATE SET
+				description = COALESCE(NULLIF(EXCLUDED.description, ''), series.description),
+				unit = COALESCE(NULLIF(EXCLUDED.unit, ''), series.unit),
+				frequency = COALESCE(NULLIF(EXCLUDED.frequency, ''), series.frequency),
+				country = COALESCE(NULLIF(EXCLUDED.country, ''), series.country),
+				last_seen = now()`,
+			source, info.Code, info.Description, info.Unit, info.Frequency, info.Country)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to register series of %s: %w", source, err)
+	}
+	return nil
+}
+
+// CatalogSeries returns the catalog entry of a series, ErrNotFound when it is not registered
+func (r *PostgresRepository) CatalogSeries(ctx context.Context, source, code string) (Series, error) {
+	series, err := scanSeries(r.pool.QueryRow(ctx, selectSeries+` WHERE source = $1 AND code = $2`, source, code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Series{}, fmt.Errorf("series %s %s: %w", source, code, ErrNotFound)
+	}
+	if err != nil {
+		return Series{}, fmt.Errorf("failed to query series %s %s: %w", source, code, err)
+	}
+	return series, nil
+}
+
+// ListSeries returns the registered series of a source ordered by code, or of every source when
+// source is empty
+func (r *PostgresRepository) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	rows, err := r.pool.Query(ctx, selectSeries+` WHERE $1 = '' OR source = $1 ORDER BY source, code`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series catalog: %w", err)
+	}
+
+	series, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Series, error) {
+		return scanSeries(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read series catalog: %w", err)
+	}
+	return series, nil
+}
+
+// selectStoredSeries lists the distinct series of data_points with the time range of their
+// points. It walks the primary key index one series at a time instead of scanning every point
+const selectStoredSeries = `
+WITH RECURSIVE stored AS (
+	(SELECT source, code FROM data_points ORDER BY source, code LIMIT 1)
+	UNION ALL
+	SELECT next.source, next.code FROM stored, LATERAL (
+		SELECT source, code FROM data_points
+		WHERE (source, code) > (stored.source, stored.code)
+		ORDER BY source, code LIMIT 1
+	) next
+)
+SELECT source, code,
+	(SELECT min(time) FROM data_points d WHERE d.source = stored.source AND d.code = stored.code),
+	(SELECT max(time) FROM data_points d WHERE d.source = stored.source AND d.code = stored.code)
+FROM stored
+ORDER BY source, code`
+
+// selectStoredSources lists the distinct sources of data_points, walking the primary key index one
+// source at a time
+const selectStoredSources = `
+WITH RECURSIVE stored AS (
+	(SELECT source FROM data_points ORDER BY source LIMIT 1)
+	UNION ALL
+	SELECT next.source FROM stored, LATERAL (
+		SELECT source FROM data_points WHERE source > stored.source ORDER BY source LIMIT 1
+	) next
+)
+SELECT source FROM stored ORDER BY source`
+
+// StoredSources returns the sources holding data points ordered by name
+func (r *PostgresRepository) StoredSources(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, selectStoredSources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored sources: %w", err)
+	}
+	sources, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored sources: %w", err)
+	}
+	return sources, nil
+}
+
+// StoredSeries returns the series holding data points ordered by source and code, whether or
+// not they are in the catalog. FirstSeen and LastSeen are the times of their first and last point
+func (r *PostgresRepository) StoredSeries(ctx context.Context) ([]Series, error) {
+	rows, err := r.pool.Query(ctx, selectStoredSeries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored series: %w", err)
+	}
+
+	series, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Series, error) {
+		var s Series
+		err := row.Scan(&s.Source, &s.Code, &s.FirstSeen, &s.LastSeen)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored series: %w", err)
+	}
+	return series, nil
+}
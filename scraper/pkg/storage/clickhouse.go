@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+)
+
+// clickHouseSchema creates the data point table, %s is the table name. ReplacingMergeTree keeps the
+// latest insert of every (source, code, time) once parts are merged, so stored points are upserted
+const clickHouseSchema = `
+CREATE TABLE IF NOT EXISTS %s (
+	source      LowCardinality(String),
+	code        LowCardinality(String),
+	time        DateTime64(3, 'UTC'),
+	value       Float64,
+	unit        LowCardinality(String),
+	metadata    Map(String, String),
+	inserted_at DateTime64(3, 'UTC') DEFAULT now64(3)
+)
+ENGINE = ReplacingMergeTree(inserted_at)
+PARTITION BY toYYYYMM(time)
+ORDER BY (source, code, time)`
+
+// ClickHouseOptions configures the connection and inserts of a ClickHouseRepository
+type ClickHouseOptions struct {
+	// URL is the address of the HTTP interface, e.g. http://localhost:8123
+	URL      string
+	Database string
+	Username string
+	Password string
+	// Table defaults to data_points
+	Table string
+	// AsyncInsert lets the server batch the inserts of many results into one part, which is how
+	// ClickHouse absorbs frequent small writes such as ticks. Inserts still wait for the batch
+	// to be written so a stored result is never lost
+	AsyncInsert bool
+	// Timeout bounds every request, defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// ClickHouseRepository persists scrape results as data points in ClickHouse through its HTTP interface,
+// it suits high-volume series such as crypto ticks that would bloat Postgres
+type ClickHouseRepository struct {
+	client  *http.Client
+	options ClickHouseOptions
+}
+
+// clickHouseRow is a data point in the JSONEachRow format
+type clickHouseRow struct {
+	Source   string            `json:"source"`
+	Code     string            `json:"code"`
+	Time     string            `json:"time"`
+	Value    float64           `json:"value"`
+	Unit     string            `json:"unit"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// NewClickHouseRepository creates a repository storing its points in the ClickHouse server of options
+func NewClickHouseRepository(options ClickHouseOptions) *ClickHouseRepository {
+	if options.Table == "" {
+		options.Table = "data_points"
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	return &ClickHouseRepository{
+		client:  &http.Client{Timeout: options.Timeout},
+		options: options,
+	}
+}
+
+// Migrate creates the data point table if it does not exist yet
+func (r *ClickHouseRepository) Migrate(ctx context.Context) error {
+	if err := r.exec(ctx, fmt.Sprintf(clickHouseSchema, r.options.Table), nil, nil); err != nil {
+		return fmt.Errorf("failed to create data point table: %w", err)
+	}
+	return nil
+}
+
+// WritePoints stores the points of a result in a single insert, returning how many were stored
+func (r *ClickHouseRepository) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	points, err := Normalize(result)
+	if err != nil {
+		return 0, err
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, point := range points {
+		row := clickHouseRow{
+			Source:   point.Source,
+			Code:     point.Code,
+			Time:     point.Timestamp.UTC().Format(clickHouseTime),
+			Value:    point.Value,
+			Unit:     point.Unit,
+			Metadata: point.Metadata,
+		}
+		if row.Metadata == nil {
+			row.Metadata = map[string]string{}
+		}
+		if err := encoder.Encode(row); err != nil {
+			return 0, fmt.Errorf("failed to encode point %s: %w", point.Code, err)
+		}
+	}
+
+	settings := url.Values{}
+	if r.options.AsyncInsert {
+		settings.Set("async_insert", "1")
+		settings.Set("wait_for_async_insert", "1")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", r.options.Table)
+	if err := r.exec(ctx, query, settings, &body); err != nil {
+		return 0, fmt.Errorf("failed to store points of %s: %w", result.Source, err)
+	}
+
+	slog.DebugContext(ctx, "Successfully stored result", "source", result.Source, "points", len(points), "backend", "clickhouse")
+	return len(points), nil
+}
+
+// clickHouseTime is the layout of DateTime64(3) values in the JSON formats
+const clickHouseTime = "2006-01-02 15:04:05.000"
+
+// QueryRange returns the points of a series within [from, to) ordered by time
+func (r *ClickHouseRepository) QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error) {
+	query := fmt.Sprintf(`
+		SELECT source, code, time, value, unit, metadata FROM %s FINAL
+		WHERE source = {source:String} AND code = {code:String}
+			AND time >= fromUnixTimestamp64Milli({from:Int64}) AND time < fromUnixTimestamp64Milli({to:Int64})
+		ORDER BY time
+		FORMAT JSONEachRow`, r.options.Table)
+	params := url.Values{
+		"param_source": {source},
+		"param_code":   {code},
+		"param_from":   {strconv.FormatInt(from.UnixMilli(), 10)},
+		"param_to":     {strconv.FormatInt(to.UnixMilli(), 10)},
+	}
+
+	points, err := r.queryPoints(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points of %s %s: %w", source, code, err)
+	}
+	return points, nil
+}
+
+// Latest returns the latest point of a series, ErrNotFound when it has none
+func (r *ClickHouseRepository) Latest(ctx context.Context, source, code string) (Point, error) {
+	query := fmt.Sprintf(`
+		SELECT source, code, time, value, unit, metadata FROM %s FINAL
+		WHERE source = {source:String} AND code = {code:String}
+		ORDER BY time DESC
+		LIMIT 1
+		FORMAT JSONEachRow`, r.options.Table)
+	params := url.Values{"param_source": {source}, "param_code": {code}}
+
+	points, err := r.queryPoints(ctx, query, params)
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to query latest point of %s %s: %w", source, code, err)
+	}
+	if len(points) == 0 {
+		return Point{}, fmt.Errorf("latest point of %s %s: %w", source, code, ErrNotFound)
+	}
+	return points[0], nil
+}
+
+// ListSeries returns the series stored for a source ordered by code, or of every source when
+// source is empty. ClickHouse has no catalog so only the unit and time range are known
+func (r *ClickHouseRepository) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	query := fmt.Sprintf(`
+		SELECT source, code, any(unit) AS unit, min(time) AS first_seen, max(time) AS last_seen FROM %s
+		WHERE {source:String} = '' OR source = {source:String}
+		GROUP BY source, code
+		ORDER BY source, code
+		FORMAT JSONEachRow`, r.options.Table)
+
+	var series []Series
+	err := r.query(ctx, query, url.Values{"param_source": {source}}, func(decoder *json.Decoder) error {
+		var row struct {
+			Source    string `json:"source"`
+			Code      string `json:"code"`
+			Unit      string `json:"unit"`
+			FirstSeen string `json:"first_seen"`
+			LastSeen  string `json:"last_seen"`
+		}
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		firstSeen, err := time.Parse(clickHouseTime, row.FirstSeen)
+		if err != nil {
+			return err
+		}
+		lastSeen, err := time.Parse(clickHouseTime, row.LastSeen)
+		if err != nil {
+			return err
+		}
+		series = append(series, Series{Source: row.Source, Code: row.Code, Unit: row.Unit, FirstSeen: firstSeen, LastSeen: lastSeen})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+	return series, nil
+}
+
+// queryPoints runs a query selecting clickHouseRow columns in the JSONEachRow format
+func (r *ClickHouseRepository) queryPoints(ctx context.Context, query string, params url.Values) ([]Point, error) {
+	var points []Point
+	err := r.query(ctx, query, params, func(decoder *json.Decoder) error {
+		var row clickHouseRow
+		if err := decoder.Decode(&row); err != nil {
+			return err
+		}
+		timestamp, err := time.Parse(clickHouseTime, row.Time)
+		if err != nil {
+			return err
+		}
+		if len(row.Metadata) == 0 {
+			row.Metadata = nil
+		}
+		points = append(points, Point{
+			Source:    row.Source,
+			Code:      row.Code,
+			Timestamp: timestamp,
+			Value:     row.Value,
+			Unit:      row.Unit,
+			Metadata:  row.Metadata,
+		})
+		return nil
+	})
+	return points, err
+}
+
+// exec runs a query over the HTTP interface, data is sent after the query, e.g. the rows of an insert
+func (r *ClickHouseRepository) exec(ctx context.Context, query string, settings url.Values, data io.Reader) error {
+	body, err := r.do(ctx, query, settings, data)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// query runs a select in the JSONEachRow format, calling decode for every row of the response
+func (r *ClickHouseRepository) query(ctx context.Context, query string, settings url.Values, decode func(*json.Decoder) error) error {
+	body, err := r.do(ctx, query, settings, nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for decoder.More() {
+		if err := decode(decoder); err != nil {
+			return fmt.Errorf("failed to read clickhouse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// do sends a query over the HTTP interface and returns the response body
+func (r *ClickHouseRepository) do(ctx context.Context, query string, settings url.Values, data io.Reader) (io.ReadCloser, error) {
+	params := url.Values{}
+	for key, values := range settings {
+		params[key] = values
+	}
+	if r.options.Database != "" {
+		params.Set("database", r.options.Database)
+	}
+
+	// Without data the query is the body, otherwise it travels as a parameter
+	body := data
+	if data == nil {
+		body = bytes.NewBufferString(query)
+	} else {
+		params.Set("query", query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.options.URL+"/?"+params.Encode(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if r.options.Username != "" {
+		req.Header.Set("X-ClickHouse-User", r.options.Username)
+	}
+	if r.options.Password != "" {
+		req.Header.Set("X-ClickHouse-Key", r.options.Password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach clickhouse: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		// ClickHouse explains the failure in the body
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("clickhouse responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return resp.Body, nil
+}
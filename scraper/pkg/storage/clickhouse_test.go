@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickHouseRepository_SaveResult(t *testing.T) {
+	var query, user string
+	var settings map[string]string
+	var rows []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		user = r.Header.Get("X-ClickHouse-User")
+		settings = map[string]string{
+			"database":              r.URL.Query().Get("database"),
+			"async_insert":          r.URL.Query().Get("async_insert"),
+			"wait_for_async_insert": r.URL.Query().Get("wait_for_async_insert"),
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	repository := NewClickHouseRepository(ClickHouseOptions{URL: server.URL, Database: "macrochain", Username: "scraper", AsyncInsert: true})
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC)
+	stored, err := repository.WritePoints(context.Background(), scraper.Result{
+		Source: "binance_trades",
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "BTCUSDT", Value: 60000.5, Unit: "usdt", Timestamp: timestamp, Metadata: map[string]string{"side": "buy"}},
+			{Code: "ETHUSDT", Value: 3000, Unit: "usdt", Timestamp: timestamp},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored)
+
+	assert.Equal(t, "INSERT INTO data_points FORMAT JSONEachRow", query)
+	assert.Equal(t, "scraper", user)
+	assert.Equal(t, map[string]string{"database": "macrochain", "async_insert": "1", "wait_for_async_insert": "1"}, settings)
+	require.Len(t, rows, 2)
+	assert.Equal(t, map[string]any{
+		"source":   "binance_trades",
+		"code":     "BTCUSDT",
+		"time":     "2024-05-01 12:00:00.250",
+		"value":    60000.5,
+		"unit":     "usdt",
+		"metadata": map[string]any{"side": "buy"},
+	}, rows[0])
+	assert.Equal(t, map[string]any{}, rows[1]["metadata"])
+}
+
+func TestClickHouseRepository_Errors(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		http.Error(w, "Code: 60. DB::Exception: Table macrochain.data_points does not exist", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	repository := NewClickHouseRepository(ClickHouseOptions{URL: server.URL})
+	err := repository.Migrate(context.Background())
+	assert.ErrorContains(t, err, "Table macrochain.data_points does not exist")
+	assert.Contains(t, body, "CREATE TABLE IF NOT EXISTS data_points")
+
+	_, err = repository.WritePoints(context.Background(), scraper.Result{
+		Source: "binance_trades",
+		Data:   []scraper.TimeSeriesPoint{{Code: "BTCUSDT", Value: 1, Timestamp: time.Now()}},
+	})
+	assert.ErrorContains(t, err, "status 404")
+
+	// Results without points are not sent
+	body = ""
+	stored, err := repository.WritePoints(context.Background(), scraper.Result{Source: "contract_logs"})
+	require.NoError(t, err)
+	assert.Zero(t, stored)
+	assert.Empty(t, body)
+}
+
+func TestClickHouseRepository_Queries(t *testing.T) {
+	var queries []string
+	var params []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, _ := io.ReadAll(r.Body)
+		queries = append(queries, string(query))
+		params = append(params, r.URL.Query())
+
+		switch {
+		case strings.Contains(string(query), "GROUP BY"):
+			_, _ = io.WriteString(w, `{"source":"binance_trades","code":"BTCUSDT","unit":"usdt","first_seen":"2024-05-01 00:00:00.000","last_seen":"2024-05-02 00:00:00.000"}`+"\n")
+		case strings.Contains(string(query), "LIMIT 1") && r.URL.Query().Get("param_code") == "ETHUSDT":
+		default:
+			_, _ = io.WriteString(w, `{"source":"binance_trades","code":"BTCUSDT","time":"2024-05-01 12:00:00.250","value":60000.5,"unit":"usdt","metadata":{"side":"buy"}}`+"\n")
+			_, _ = io.WriteString(w, `{"source":"binance_trades","code":"BTCUSDT","time":"2024-05-01 12:00:01.000","value":60001,"unit":"usdt","metadata":{}}`+"\n")
+		}
+	}))
+	defer server.Close()
+
+	repository := NewClickHouseRepository(ClickHouseOptions{URL: server.URL})
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	points, err := repository.QueryRange(context.Background(), "binance_trades", "BTCUSDT", from, from.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, Point{
+		Source:    "binance_trades",
+		Code:      "BTCUSDT",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC),
+		Value:     60000.5,
+		Unit:      "usdt",
+		Metadata:  map[string]string{"side": "buy"},
+	}, points[0])
+	assert.Nil(t, points[1].Metadata)
+	assert.Contains(t, queries[0], "FROM data_points FINAL")
+	assert.Equal(t, "1714521600000", params[0].Get("param_from"))
+
+	_, err = repository.Latest(context.Background(), "binance_trades", "ETHUSDT")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	series, err := repository.ListSeries(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, "BTCUSDT", series[0].Code)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), series[0].LastSeen)
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+)
+
+// DualWriter is a Storage writing every result to a primary and a secondary backend while data
+// moves from one to the other, reads are served by the primary. A result is only reported as
+// stored once both backends stored it, so a failing secondary gets the result again on retry
+// and the backends do not drift apart. Every backend upserts so writing a result twice is harmless
+type DualWriter struct {
+	primary   Storage
+	secondary Storage
+}
+
+// NewDualWriter creates a storage writing to primary and secondary
+func NewDualWriter(primary, secondary Storage) *DualWriter {
+	return &DualWriter{primary: primary, secondary: secondary}
+}
+
+// Primary returns the backend serving reads
+func (w *DualWriter) Primary() Storage {
+	return w.primary
+}
+
+// Secondary returns the backend being migrated to
+func (w *DualWriter) Secondary() Storage {
+	return w.secondary
+}
+
+// WritePoints stores the points of a result in the primary then the secondary, returning how
+// many the primary wrote
+func (w *DualWriter) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	written, err := w.primary.WritePoints(ctx, result)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.secondary.WritePoints(ctx, result); err != nil {
+		return 0, fmt.Errorf("failed to write to secondary storage: %w", err)
+	}
+	return written, nil
+}
+
+// QueryRange returns the points of a series within [from, to) from the primary
+func (w *DualWriter) QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error) {
+	return w.primary.QueryRange(ctx, source, code, from, to)
+}
+
+// Latest returns the latest point of a series from the primary
+func (w *DualWriter) Latest(ctx context.Context, source, code string) (Point, error) {
+	return w.primary.Latest(ctx, source, code)
+}
+
+// ListSeries returns the series of a source from the primary
+func (w *DualWriter) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	return w.primary.ListSeries(ctx, source)
+}
+
+// SeriesCheck compares the points of a series in two backends over a time range
+type SeriesCheck struct {
+	Source          string
+	Code            string
+	PrimaryPoints   int
+	SecondaryPoints int
+	// PrimarySum and SecondarySum are order independent checksums of the times and values
+	PrimarySum   uint64
+	SecondarySum uint64
+	// Repaired is set when the points of the primary were written to the secondary
+	Repaired bool
+}
+
+// Match reports whether both backends hold the same points
+func (c SeriesCheck) Match() bool {
+	return c.PrimaryPoints == c.SecondaryPoints && c.PrimarySum == c.SecondarySum
+}
+
+// checksum returns an order independent checksum of the times and values of points, units and
+// metadata are left out as backends do not all read them back
+func checksum(points []Point) uint64 {
+	var sum uint64
+	for _, point := range points {
+		h := fnv.New64a()
+		var buf [16]byte
+		binary.BigEndian.PutUint64(buf[:8], uint64(point.Timestamp.UnixMilli()))
+		binary.BigEndian.PutUint64(buf[8:], math.Float64bits(point.Value))
+		h.Write(buf[:])
+		sum += h.Sum64()
+	}
+	return sum
+}
+
+// VerifyOptions configures a DualWriteVerifier
+type VerifyOptions struct {
+	// Interval is how often the backends are compared, defaults to 1 hour
+	Interval time.Duration
+	// Lookback is the time range compared, defaults to 24 hours
+	Lookback time.Duration
+	// Settle leaves out the most recent points that may still be written, defaults to 1 minute
+	Settle time.Duration
+	// Repair writes the points of the primary to the secondary for the series that differ
+	Repair bool
+}
+
+// DualWriteVerifier compares the series of the backends of a DualWriter, so the secondary can be
+// trusted before it becomes the primary
+type DualWriteVerifier struct {
+	writer  *DualWriter
+	options VerifyOptions
+}
+
+// NewDualWriteVerifier creates a verifier of the backends of writer
+func NewDualWriteVerifier(writer *DualWriter, options VerifyOptions) *DualWriteVerifier {
+	if options.Interval <= 0 {
+		options.Interval = 1 * time.Hour
+	}
+	if options.Lookback <= 0 {
+		options.Lookback = 24 * time.Hour
+	}
+	if options.Settle <= 0 {
+		options.Settle = 1 * time.Minute
+	}
+
+	return &DualWriteVerifier{
+		writer:  writer,
+		options: options,
+	}
+}
+
+// Verify compares the points within [from, to) of every series listed by either backend,
+// returning the checks of the series that differ. A failing series does not keep the others
+// from being compared
+func (v *DualWriteVerifier) Verify(ctx context.Context, from, to time.Time) ([]SeriesCheck, error) {
+	_, mismatches, err := v.verify(ctx, from, to)
+	return mismatches, err
+}
+
+// verify compares the backends like Verify, also returning how many series were compared
+func (v *DualWriteVerifier) verify(ctx context.Context, from, to time.Time) (int, []SeriesCheck, error) {
+	primary, secondary := v.writer.primary, v.writer.secondary
+	series, err := v.series(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var mismatches []SeriesCheck
+	var errs []error
+	for _, s := range series {
+		primaryPoints, err := primary.QueryRange(ctx, s.Source, s.Code, from, to)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		secondaryPoints, err := secondary.QueryRange(ctx, s.Source, s.Code, from, to)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		check := SeriesCheck{
+			Source:          s.Source,
+			Code:            s.Code,
+			PrimaryPoints:   len(primaryPoints),
+			SecondaryPoints: len(secondaryPoints),
+			PrimarySum:      checksum(primaryPoints),
+			SecondarySum:    checksum(secondaryPoints),
+		}
+		if check.Match() {
+			continue
+		}
+		if v.options.Repair && len(primaryPoints) > 0 {
+			if err := v.repair(ctx, s.Source, primaryPoints); err != nil {
+				errs = append(errs, err)
+			} else {
+				check.Repaired = true
+			}
+		}
+		mismatches = append(mismatches, check)
+	}
+	return len(series), mismatches, errors.Join(errs...)
+}
+
+// storedSeriesLister is implemented by backends whose ListSeries reads a catalog, StoredSeries
+// lists the series of their points instead so series missing from the catalog are compared too
+type storedSeriesLister interface {
+	StoredSeries(ctx context.Context) ([]Series, error)
+}
+
+// storedSeries returns the series holding points in a backend
+func storedSeries(ctx context.Context, backend Storage) ([]Series, error) {
+	if lister, ok := backend.(storedSeriesLister); ok {
+		return lister.StoredSeries(ctx)
+	}
+	return backend.ListSeries(ctx, "")
+}
+
+// series returns the series holding points in the primary or the secondary
+func (v *DualWriteVerifier) series(ctx context.Context) ([]Series, error) {
+	type key struct{ source, code string }
+	seen := make(map[key]bool)
+	var series []Series
+	for _, backend := range []Storage{v.writer.primary, v.writer.secondary} {
+		listed, err := storedSeries(ctx, backend)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range listed {
+			if !seen[key{s.Source, s.Code}] {
+				seen[key{s.Source, s.Code}] = true
+				series = append(series, s)
+			}
+		}
+	}
+	return series, nil
+}
+
+// backfillWindow is the time range of the points copied at once by Backfill
+const backfillWindow = 7 * 24 * time.Hour
+
+// Backfill copies the points within [from, to) of every series of the primary to the
+// secondary, returning how many were copied. It fills the history written before dual writes
+// started, which the verifier only compares over its lookback. Backends upsert so a backfill
+// that failed can be run again
+func (v *DualWriteVerifier) Backfill(ctx context.Context, from, to time.Time) (int, error) {
+	series, err := storedSeries(ctx, v.writer.primary)
+	if err != nil {
+		return 0, err
+	}
+
+	copied := 0
+	for _, s := range series {
+		start, end := from, to
+		if start.IsZero() {
+			start = time.Unix(0, 0)
+		}
+		if !s.FirstSeen.IsZero() && s.FirstSeen.After(start) {
+			start = s.FirstSeen
+		}
+		if !s.LastSeen.IsZero() && s.LastSeen.Before(end) {
+			end = s.LastSeen.Add(time.Nanosecond)
+		}
+
+		seriesCopied := 0
+		for windowStart := start; windowStart.Before(end); windowStart = windowStart.Add(backfillWindow) {
+			windowEnd := windowStart.Add(backfillWindow)
+			if windowEnd.After(end) {
+				windowEnd = end
+			}
+			points, err := v.writer.primary.QueryRange(ctx, s.Source, s.Code, windowStart, windowEnd)
+			if err != nil {
+				return copied + seriesCopied, err
+			}
+			if len(points) == 0 {
+				continue
+			}
+			if err := v.repair(ctx, s.Source, points); err != nil {
+				return copied + seriesCopied, err
+			}
+			seriesCopied += len(points)
+		}
+		copied += seriesCopied
+		slog.InfoContext(ctx, "Backfilled series", "source", s.Source, "code", s.Code, "points", seriesCopied)
+	}
+	return copied, nil
+}
+
+// repair writes the points of a series read from the primary to the secondary
+func (v *DualWriteVerifier) repair(ctx context.Context, source string, points []Point) error {
+	data := make([]scraper.TimeSeriesPoint, 0, len(points))
+	for _, point := range points {
+		data = append(data, scraper.TimeSeriesPoint{
+			Code:      point.Code,
+			Value:     point.Value,
+			Unit:      point.Unit,
+			Timestamp: point.Timestamp,
+			Metadata:  point.Metadata,
+		})
+	}
+	if _, err := v.writer.secondary.WritePoints(ctx, scraper.Result{Source: source, Timestamp: time.Now(), Data: data}); err != nil {
+		return fmt.Errorf("failed to repair %s %s: %w", source, points[0].Code, err)
+	}
+	return nil
+}
+
+// Run compares the backends each interval until the context is cancelled, every series that
+// differs is logged as a warning
+func (v *DualWriteVerifier) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Dual-write verifier started", "interval", v.options.Interval, "lookback", v.options.Lookback, "repair", v.options.Repair)
+
+	for {
+		to := time.Now().Add(-v.options.Settle)
+		checked, mismatches, err := v.verify(ctx, to.Add(-v.options.Lookback), to)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to verify dual-write backends", "error", err)
+		}
+		for _, check := range mismatches {
+			slog.WarnContext(ctx, "Dual-write backends differ",
+				"source", check.Source, "code", check.Code,
+				"primary_points", check.PrimaryPoints, "secondary_points", check.SecondaryPoints,
+				"repaired", check.Repaired)
+		}
+		switch {
+		case err != nil || len(mismatches) > 0:
+		case checked == 0:
+			slog.WarnContext(ctx, "No series to verify in dual-write backends")
+		default:
+			slog.InfoContext(ctx, "Successfully verified dual-write backends", "series", checked, "lookback", v.options.Lookback)
+		}
+
+		select {
+		case <-time.After(v.options.Interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Dual-write verifier stopped")
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seriesStorage keeps the written points by series and time, like the backends upserting them
+type seriesStorage struct {
+	Storage
+	failing bool
+	points  map[Series]map[time.Time]Point
+}
+
+func newSeriesStorage() *seriesStorage {
+	return &seriesStorage{points: make(map[Series]map[time.Time]Point)}
+}
+
+func (s *seriesStorage) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	if s.failing {
+		return 0, errors.New("connection refused")
+	}
+	points, err := Normalize(result)
+	for _, point := range points {
+		key := Series{Source: point.Source, Code: point.Code}
+		if s.points[key] == nil {
+			s.points[key] = make(map[time.Time]Point)
+		}
+		s.points[key][point.Timestamp] = point
+	}
+	return len(points), err
+}
+
+func (s *seriesStorage) QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error) {
+	var points []Point
+	for timestamp, point := range s.points[Series{Source: source, Code: code}] {
+		if !timestamp.Before(from) && timestamp.Before(to) {
+			points = append(points, point)
+		}
+	}
+	return points, nil
+}
+
+func (s *seriesStorage) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	var series []Series
+	for key := range s.points {
+		series = append(series, key)
+	}
+	return series, nil
+}
+
+func TestDualWriter(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newSeriesStorage(), newSeriesStorage()
+	writer := NewDualWriter(primary, secondary)
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	result := func(code string, offset time.Duration, value float64) scraper.Result {
+		return scraper.Result{
+			Source: "snb",
+			Data:   []scraper.TimeSeriesPoint{{Code: code, Value: value, Timestamp: start.Add(offset)}},
+		}
+	}
+
+	written, err := writer.WritePoints(ctx, result("saron", 0, 1.5))
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+
+	// A result the secondary missed is reported as failed so it is written again
+	secondary.failing = true
+	_, err = writer.WritePoints(ctx, result("saron", time.Hour, 1.6))
+	assert.Error(t, err)
+	secondary.failing = false
+
+	verifier := NewDualWriteVerifier(writer, VerifyOptions{})
+	mismatches, err := verifier.Verify(ctx, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, "saron", mismatches[0].Code)
+	assert.Equal(t, 2, mismatches[0].PrimaryPoints)
+	assert.Equal(t, 1, mismatches[0].SecondaryPoints)
+	assert.False(t, mismatches[0].Repaired)
+
+	// Same counts with different values still differ
+	_, err = secondary.WritePoints(ctx, result("saron", time.Hour, 1.7))
+	require.NoError(t, err)
+	_, err = secondary.WritePoints(ctx, result("policy_rate", 0, 1.75))
+	require.NoError(t, err)
+	mismatches, err = verifier.Verify(ctx, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+
+	repairing := NewDualWriteVerifier(writer, VerifyOptions{Repair: true})
+	mismatches, err = repairing.Verify(ctx, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	for _, check := range mismatches {
+		// Series only the secondary holds have nothing to copy
+		assert.Equal(t, check.Code == "saron", check.Repaired, check.Code)
+	}
+
+	mismatches, err = verifier.Verify(ctx, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, "policy_rate", mismatches[0].Code)
+}
+
+func TestChecksum(t *testing.T) {
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	a := Point{Timestamp: at, Value: 1.5}
+	b := Point{Timestamp: at.Add(time.Hour), Value: 1.6}
+
+	assert.Equal(t, checksum([]Point{a, b}), checksum([]Point{b, a}))
+	assert.NotEqual(t, checksum([]Point{a, b}), checksum([]Point{a, {Timestamp: b.Timestamp, Value: 1.7}}))
+	assert.Zero(t, checksum(nil))
+}
+
+// catalogStorage lists the series of a catalog nothing was registered in, like the Postgres
+// backend before the scrapers register their series
+type catalogStorage struct {
+	*seriesStorage
+}
+
+func (s catalogStorage) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	return nil, nil
+}
+
+func (s catalogStorage) StoredSeries(ctx context.Context) ([]Series, error) {
+	return s.seriesStorage.ListSeries(ctx, "")
+}
+
+func TestDualWriteVerifierComparesStoredSeries(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newSeriesStorage(), newSeriesStorage()
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err := primary.WritePoints(ctx, scraper.Result{
+		Source: "snb",
+		Data:   []scraper.TimeSeriesPoint{{Code: "saron", Value: 1.5, Timestamp: at}},
+	})
+	require.NoError(t, err)
+
+	verifier := NewDualWriteVerifier(NewDualWriter(catalogStorage{primary}, catalogStorage{secondary}), VerifyOptions{})
+	mismatches, err := verifier.Verify(ctx, at, at.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, "saron", mismatches[0].Code)
+}
+
+func TestDualWriteVerifierBackfill(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newSeriesStorage(), newSeriesStorage()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var data []scraper.TimeSeriesPoint
+	for day := 0; day < 60; day++ {
+		data = append(data, scraper.TimeSeriesPoint{Code: "saron", Value: float64(day), Timestamp: start.AddDate(0, 0, day)})
+	}
+	_, err := primary.WritePoints(ctx, scraper.Result{Source: "snb", Data: data})
+	require.NoError(t, err)
+
+	verifier := NewDualWriteVerifier(NewDualWriter(primary, secondary), VerifyOptions{})
+	copied, err := verifier.Backfill(ctx, start.AddDate(0, 0, 10), start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 50, copied)
+	assert.Len(t, secondary.points[Series{Source: "snb", Code: "saron"}], 50)
+
+	mismatches, err := verifier.Verify(ctx, start.AddDate(0, 0, 10), start.AddDate(0, 0, 60))
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
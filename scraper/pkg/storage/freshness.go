@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultStalenessBudgets are how old the newest observation of a series of each frequency may
+// get, they leave room for publication lags, weekends and holidays
+var defaultStalenessBudgets = map[string]time.Duration{
+	scraper.FrequencyTick:        15 * time.Minute,
+	scraper.FrequencyHourly:      3 * time.Hour,
+	scraper.FrequencyDaily:       3 * day,
+	scraper.FrequencyBusinessDay: 5 * day,
+	scraper.FrequencyWeekly:      10 * day,
+}
+
+// StalenessBudgets override the staleness budget of the frequency of series, by source or by
+// "source/code". A zero budget stops monitoring
+type StalenessBudgets map[string]time.Duration
+
+// ParseStalenessBudgets parses a list of "source=duration" and "source/code=duration" entries,
+// e.g. "fred/GDP=2400h" allows 100 days between two quarterly observations
+func ParseStalenessBudgets(entries []string) (StalenessBudgets, error) {
+	budgets := make(StalenessBudgets, len(entries))
+	for _, entry := range entries {
+		series, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || series == "" || strings.HasPrefix(series, "/") || strings.HasSuffix(series, "/") {
+			return nil, fmt.Errorf("invalid staleness budget %q, expected source=duration or source/code=duration", entry)
+		}
+		budget, err := time.ParseDuration(value)
+		if err != nil || budget < 0 {
+			return nil, fmt.Errorf("invalid duration %q in staleness budget %q", value, entry)
+		}
+		budgets[series] = budget
+	}
+	return budgets, nil
+}
+
+// Budget returns the staleness budget of a series, from the most specific override or from its
+// frequency, zero when the series is not monitored
+func (b StalenessBudgets) Budget(source, code, frequency string) time.Duration {
+	if budget, ok := b[source+"/"+code]; ok {
+		return budget
+	}
+	if budget, ok := b[source]; ok {
+		return budget
+	}
+	return defaultStalenessBudgets[frequency]
+}
+
+// SeriesFreshness is the newest stored observation of a cataloged series
+type SeriesFreshness struct {
+	Source    string
+	Code      string
+	Frequency string
+	// Newest is the time of the newest observation, zero when the series has none
+	Newest time.Time
+	// FirstSeen is when the series was registered, the age of series without observation
+	FirstSeen time.Time
+	// Budget is how old the newest observation may get, zero when the series is not monitored
+	Budget time.Duration
+}
+
+// Age returns how old the newest observation is, or how long ago a series without observation
+// was registered
+func (f SeriesFreshness) Age(now time.Time) time.Duration {
+	if f.Newest.IsZero() {
+		return now.Sub(f.FirstSeen)
+	}
+	return now.Sub(f.Newest)
+}
+
+// Stale reports whether the newest observation exceeds the staleness budget
+func (f SeriesFreshness) Stale(now time.Time) bool {
+	return f.Budget > 0 && f.Age(now) > f.Budget
+}
+
+// Freshness returns the newest observation of every cataloged series of a source ordered by
+// code, or of every source when source is empty, with its budget of budgets
+func (r *PostgresRepository) Freshness(ctx context.Context, source string, budgets StalenessBudgets) ([]SeriesFreshness, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.source, s.code, s.frequency, s.first_seen, p.time FROM series s
+		LEFT JOIN LATERAL (
+			SELECT time FROM data_points d
+			WHERE d.source = s.source AND d.code = s.code
+			ORDER BY time DESC LIMIT 1
+		) p ON true
+		WHERE $1 = '' OR s.source = $1
+		ORDER BY s.source, s.code`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series freshness: %w", err)
+	}
+
+	freshness, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (SeriesFreshness, error) {
+		var f SeriesFreshness
+		var newest *time.Time
+		if err := row.Scan(&f.Source, &f.Code, &f.Frequency, &f.FirstSeen, &newest); err != nil {
+			return f, err
+		}
+		if newest != nil {
+			f.Newest = *newest
+		}
+		f.Budget = budgets.Budget(f.Source, f.Code, f.Frequency)
+		return f, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read series freshness: %w", err)
+	}
+	return freshness, nil
+}
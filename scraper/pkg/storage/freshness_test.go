@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStalenessBudgets(t *testing.T) {
+	budgets, err := ParseStalenessBudgets([]string{"fred=96h", " fred/GDP=2400h ", "binance_stream/BTCUSDT=0s"})
+	require.NoError(t, err)
+	assert.Equal(t, StalenessBudgets{
+		"fred":                   96 * time.Hour,
+		"fred/GDP":               2400 * time.Hour,
+		"binance_stream/BTCUSDT": 0,
+	}, budgets)
+
+	for _, entry := range []string{"fred", "=96h", "/GDP=96h", "fred/=96h", "fred=four days", "fred=-1h"} {
+		_, err := ParseStalenessBudgets([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestStalenessBudgets_Budget(t *testing.T) {
+	budgets := StalenessBudgets{"fred": 96 * time.Hour, "fred/GDP": 2400 * time.Hour, "ecb/EUR": 0}
+
+	assert.Equal(t, 2400*time.Hour, budgets.Budget("fred", "GDP", scraper.FrequencyDaily), "series override")
+	assert.Equal(t, 96*time.Hour, budgets.Budget("fred", "DGS10", scraper.FrequencyDaily), "source override")
+	assert.Equal(t, 3*day, budgets.Budget("eia", "wti", scraper.FrequencyDaily), "frequency default")
+	assert.Equal(t, 15*time.Minute, budgets.Budget("binance_stream", "BTCUSDT", scraper.FrequencyTick))
+	assert.Zero(t, budgets.Budget("ecb", "EUR", scraper.FrequencyDaily), "disabled")
+	assert.Zero(t, budgets.Budget("ecb", "USD", ""), "unknown frequency")
+	assert.Zero(t, StalenessBudgets(nil).Budget("eia", "wti", "quarterly"))
+}
+
+func TestSeriesFreshness_Stale(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	f := SeriesFreshness{Newest: now.Add(-2 * day), Budget: 3 * day}
+	assert.Equal(t, 2*day, f.Age(now))
+	assert.False(t, f.Stale(now))
+
+	f.Newest = now.Add(-4 * day)
+	assert.True(t, f.Stale(now))
+
+	f.Budget = 0
+	assert.False(t, f.Stale(now), "unmonitored series are never stale")
+
+	f = SeriesFreshness{FirstSeen: now.Add(-time.Hour), Budget: 15 * time.Minute}
+	assert.Equal(t, time.Hour, f.Age(now), "series without observation age from their registration")
+	assert.True(t, f.Stale(now))
+}
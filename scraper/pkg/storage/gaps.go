@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Gap is an observation date missing from a series
+type Gap struct {
+	Source     string
+	Code       string
+	Date       time.Time
+	Frequency  string
+	DetectedAt time.Time
+}
+
+// day is the length of the periods of daily and business-day series
+const day = 24 * time.Hour
+
+// periodStart returns the start of the period of a frequency containing t, weekly periods start
+// on Monday. It reports false for frequencies without a fixed calendar, e.g. ticks
+func periodStart(frequency string, t time.Time) (time.Time, bool) {
+	date := t.UTC().Truncate(day)
+	switch frequency {
+	case scraper.FrequencyDaily, scraper.FrequencyBusinessDay:
+		return date, true
+	case scraper.FrequencyWeekly:
+		return date.AddDate(0, 0, -(int(date.Weekday())+6)%7), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ExpectedPeriods returns the start of every period of a frequency that lies entirely within
+// [from, to) and should hold an observation. Business days are Monday to Friday, holidays are
+// left to the HolidayCalendars of the series
+func ExpectedPeriods(frequency string, from, to time.Time) []time.Time {
+	start, ok := periodStart(frequency, from)
+	if !ok {
+		return nil
+	}
+	step := day
+	if frequency == scraper.FrequencyWeekly {
+		step = 7 * day
+	}
+	if start.Before(from) {
+		start = start.Add(step)
+	}
+
+	var periods []time.Time
+	for period := start; !period.Add(step).After(to); period = period.Add(step) {
+		if frequency == scraper.FrequencyBusinessDay && (period.Weekday() == time.Saturday || period.Weekday() == time.Sunday) {
+			continue
+		}
+		periods = append(periods, period)
+	}
+	return periods
+}
+
+// MissingPeriods returns the expected periods of a frequency in [from, to) without any of the
+// observed times
+func MissingPeriods(frequency string, from, to time.Time, observed []time.Time) []time.Time {
+	seen := make(map[time.Time]bool, len(observed))
+	for _, t := range observed {
+		if period, ok := periodStart(frequency, t); ok {
+			seen[period] = true
+		}
+	}
+
+	var missing []time.Time
+	for _, period := range ExpectedPeriods(frequency, from, to) {
+		if !seen[period] {
+			missing = append(missing, period)
+		}
+	}
+	return missing
+}
+
+// HolidayCalendars holds the dates on which sources publish no observation, e.g. exchange
+// holidays, keyed by source or by "source/code". Holidays are not gaps of daily and business-day
+// series
+type HolidayCalendars map[string]map[time.Time]bool
+
+// ParseHolidayCalendars parses a list of "source=date" and "source/code=date" entries with dates
+// formatted as YYYY-MM-DD, e.g. "lbma=2024-12-25"
+func ParseHolidayCalendars(entries []string) (HolidayCalendars, error) {
+	calendars := make(HolidayCalendars)
+	for _, entry := range entries {
+		series, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || series == "" || strings.HasPrefix(series, "/") || strings.HasSuffix(series, "/") {
+			return nil, fmt.Errorf("invalid holiday %q, expected source=date or source/code=date", entry)
+		}
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q in holiday %q", value, entry)
+		}
+		if calendars[series] == nil {
+			calendars[series] = make(map[time.Time]bool)
+		}
+		calendars[series][date] = true
+	}
+	return calendars, nil
+}
+
+// Holiday reports whether the UTC day of date is a holiday of the source or of the series
+func (c HolidayCalendars) Holiday(source, code string, date time.Time) bool {
+	date = date.UTC().Truncate(day)
+	return c[source][date] || c[source+"/"+code][date]
+}
+
+// CheckGaps compares the observations of a series within [from, to) with its frequency. Missing
+// periods are recorded in series_gaps and the recorded gaps that were filled since, or that fall on
+// holidays of the series, are resolved. It returns the gaps that were not recorded before
+func (r *PostgresRepository) CheckGaps(ctx context.Context, series Series, from, to time.Time, holidays HolidayCalendars) ([]Gap, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT date_trunc('day', time, 'UTC') FROM data_points
+		WHERE source = $1 AND code = $2 AND time >= $3 AND time < $4`,
+		series.Source, series.Code, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query observations of %s %s: %w", series.Source, series.Code, err)
+	}
+	observed, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read observations of %s %s: %w", series.Source, series.Code, err)
+	}
+
+	// Gaps are only resolved within the expected periods, a partial first day may hold a gap
+	// recorded with a longer window. Empty slices keep the arrays from being NULL
+	expected := append([]time.Time{}, ExpectedPeriods(series.Frequency, from, to)...)
+	missing := []time.Time{}
+	for _, date := range MissingPeriods(series.Frequency, from, to, observed) {
+		if series.Frequency == scraper.FrequencyWeekly || !holidays.Holiday(series.Source, series.Code, date) {
+			missing = append(missing, date)
+		}
+	}
+	var detected []Gap
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, date := range missing {
+			var detectedAt time.Time
+			err := tx.QueryRow(ctx, `
+				INSERT INTO series_gaps (source, code, date, frequency) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (source, code, date) DO NOTHING
+				RETURNING detected_at`,
+				series.Source, series.Code, date, series.Frequency).Scan(&detectedAt)
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to record gap: %w", err)
+			}
+			detected = append(detected, Gap{Source: series.Source, Code: series.Code, Date: date, Frequency: series.Frequency, DetectedAt: detectedAt})
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE series_gaps SET resolved_at = now()
+			WHERE source = $1 AND code = $2 AND resolved_at IS NULL
+				AND date = ANY($3) AND NOT date = ANY($4)`,
+			series.Source, series.Code, expected, missing)
+		if err != nil {
+			return fmt.Errorf("failed to resolve gaps: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record gaps of %s %s: %w", series.Source, series.Code, err)
+	}
+	return detected, nil
+}
+
+// OpenGaps returns the unresolved gaps of a source ordered by code and date, or of every source
+// when source is empty
+func (r *PostgresRepository) OpenGaps(ctx context.Context, source string) ([]Gap, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, date, frequency, detected_at FROM series_gaps
+		WHERE resolved_at IS NULL AND ($1 = '' OR source = $1)
+		ORDER BY source, code, date`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gaps: %w", err)
+	}
+
+	gaps, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Gap, error) {
+		var gap Gap
+		err := row.Scan(&gap.Source, &gap.Code, &gap.Date, &gap.Frequency, &gap.DetectedAt)
+		return gap, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gaps: %w", err)
+	}
+	return gaps, nil
+}
+
+// GapOptions configures the window checked by a GapDetector
+type GapOptions struct {
+	// Lookback is how far back series are checked, defaults to 30 days
+	Lookback time.Duration
+	// Lag is how long after its period an observation may be published, defaults to 1 day
+	Lag time.Duration
+	// Holidays are the days without observation of the sources, they are not reported as gaps
+	Holidays HolidayCalendars
+}
+
+// GapDetector checks the cataloged series with a daily, business-day or weekly frequency for
+// missing observations in the background, so failing scrapers are noticed from the stored data
+type GapDetector struct {
+	repository *PostgresRepository
+	interval   time.Duration
+	options    GapOptions
+}
+
+// NewGapDetector creates a detector checking every series each interval, defaulting to 1 hour
+func NewGapDetector(repository *PostgresRepository, interval time.Duration, options GapOptions) *GapDetector {
+	if interval <= 0 {
+		interval = 1 * time.Hour
+	}
+	if options.Lookback <= 0 {
+		options.Lookback = 30 * day
+	}
+	if options.Lag <= 0 {
+		options.Lag = day
+	}
+
+	return &GapDetector{
+		repository: repository,
+		interval:   interval,
+		options:    options,
+	}
+}
+
+// Detect checks every cataloged series as of now and returns the newly detected gaps, a failing
+// series does not keep the others from being checked. Series are not checked before they were
+// first seen, so new scrapers do not report their history as missing
+func (d *GapDetector) Detect(ctx context.Context, now time.Time) ([]Gap, error) {
+	catalog, err := d.repository.ListSeries(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	to := now.UTC().Add(-d.options.Lag)
+	var detected []Gap
+	var errs []error
+	for _, series := range catalog {
+		if _, ok := periodStart(series.Frequency, now); !ok {
+			continue
+		}
+		from := now.UTC().Add(-d.options.Lookback)
+		if series.FirstSeen.After(from) {
+			from = series.FirstSeen.UTC()
+		}
+		if !from.Before(to) {
+			continue
+		}
+
+		gaps, err := d.repository.CheckGaps(ctx, series, from, to, d.options.Holidays)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		detected = append(detected, gaps...)
+	}
+	return detected, errors.Join(errs...)
+}
+
+// Run checks for gaps each interval until the context is cancelled, new gaps are logged as
+// warnings once per series
+func (d *GapDetector) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Gap detector started", "interval", d.interval, "lookback", d.options.Lookback, "lag", d.options.Lag)
+
+	for {
+		gaps, err := d.Detect(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to detect gaps", "error", err)
+		}
+		logGaps(ctx, gaps)
+
+		select {
+		case <-time.After(d.interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Gap detector stopped")
+			return
+		}
+	}
+}
+
+// logGaps warns about the gaps of every series
+func logGaps(ctx context.Context, gaps []Gap) {
+	for len(gaps) > 0 {
+		n := 1
+		for n < len(gaps) && gaps[n].Source == gaps[0].Source && gaps[n].Code == gaps[0].Code {
+			n++
+		}
+		dates := make([]string, 0, n)
+		for _, gap := range gaps[:n] {
+			dates = append(dates, gap.Date.Format(time.DateOnly))
+		}
+		slog.WarnContext(ctx, "Detected missing observations",
+			"source", gaps[0].Source, "code", gaps[0].Code, "frequency", gaps[0].Frequency, "dates", dates)
+		gaps = gaps[n:]
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedPeriods(t *testing.T) {
+	// Wednesday noon to the Wednesday two weeks later
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	date := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	daily := ExpectedPeriods(scraper.FrequencyDaily, from, to)
+	assert.Len(t, daily, 13)
+	assert.Equal(t, date(2), daily[0], "The partial first day is not expected")
+	assert.Equal(t, date(14), daily[12])
+
+	assert.Equal(t, []time.Time{date(2), date(3), date(6), date(7), date(8), date(9), date(10), date(13), date(14)},
+		ExpectedPeriods(scraper.FrequencyBusinessDay, from, to))
+	assert.Equal(t, []time.Time{date(6)}, ExpectedPeriods(scraper.FrequencyWeekly, from, to),
+		"Only complete weeks starting on Monday are expected")
+	assert.Empty(t, ExpectedPeriods(scraper.FrequencyTick, from, to))
+}
+
+func TestMissingPeriods(t *testing.T) {
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+	date := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	observed := []time.Time{date(6), date(7).Add(16 * time.Hour), date(9), date(10), date(13), date(14), date(15), date(16), date(17)}
+
+	assert.Equal(t, []time.Time{date(8)}, MissingPeriods(scraper.FrequencyBusinessDay, from, to, observed))
+	assert.Equal(t, []time.Time{date(8), date(11), date(12), date(18), date(19)},
+		MissingPeriods(scraper.FrequencyDaily, from, to, observed))
+	assert.Empty(t, MissingPeriods(scraper.FrequencyWeekly, from, to, []time.Time{date(10), date(13)}),
+		"An observation on any day of a week fills it")
+	assert.Equal(t, []time.Time{date(13)}, MissingPeriods(scraper.FrequencyWeekly, from, to, []time.Time{date(10)}))
+}
+
+func TestParseHolidayCalendars(t *testing.T) {
+	calendars, err := ParseHolidayCalendars([]string{"lbma=2024-12-25", "lbma=2024-12-26", "fx/usd_chf=2024-08-01"})
+	require.NoError(t, err)
+
+	christmas := time.Date(2024, 12, 25, 15, 0, 0, 0, time.UTC)
+	assert.True(t, calendars.Holiday("lbma", "gold_am", christmas))
+	assert.True(t, calendars.Holiday("lbma", "gold_am", christmas.AddDate(0, 0, 1)))
+	assert.False(t, calendars.Holiday("lbma", "gold_am", christmas.AddDate(0, 0, 2)))
+	assert.True(t, calendars.Holiday("fx", "usd_chf", time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, calendars.Holiday("fx", "eur_chf", time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, HolidayCalendars(nil).Holiday("lbma", "gold_am", christmas))
+
+	for _, entry := range []string{"lbma", "=2024-12-25", "lbma/=2024-12-25", "lbma=25.12.2024"} {
+		_, err := ParseHolidayCalendars([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+)
+
+// InfluxOptions configures the connection and writes of an InfluxRepository
+type InfluxOptions struct {
+	// URL is the address of the InfluxDB v2 API, e.g. http://localhost:8086
+	URL    string
+	Org    string
+	Bucket string
+	Token  string
+	// Measurement defaults to data_points
+	Measurement string
+	// Timeout bounds every request, defaults to 30 seconds
+	Timeout time.Duration
+}
+
+// InfluxRepository persists scrape results as data points in an InfluxDB v2 bucket using the
+// line protocol. Source, code and unit are tags, the value and the metadata are fields
+type InfluxRepository struct {
+	client  *http.Client
+	options InfluxOptions
+}
+
+var (
+	// measurementEscaper escapes measurement names in the line protocol
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	// tagEscaper escapes tag keys, tag values and field keys in the line protocol
+	tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	// stringFieldEscaper escapes string field values in the line protocol
+	stringFieldEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// NewInfluxRepository creates a repository writing its points to the InfluxDB bucket of options
+func NewInfluxRepository(options InfluxOptions) *InfluxRepository {
+	if options.Measurement == "" {
+		options.Measurement = "data_points"
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	return &InfluxRepository{
+		client:  &http.Client{Timeout: options.Timeout},
+		options: options,
+	}
+}
+
+// WritePoints writes the points of a result in a single request, returning how many were written.
+// InfluxDB overwrites a point with the same tags and timestamp, so storing a result again never
+// duplicates points
+func (r *InfluxRepository) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	points, err := Normalize(result)
+	if err != nil {
+		return 0, err
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	var body bytes.Buffer
+	for _, point := range points {
+		body.WriteString(r.line(point))
+		body.WriteByte('\n')
+	}
+
+	if err := r.write(ctx, &body); err != nil {
+		return 0, fmt.Errorf("failed to store points of %s: %w", result.Source, err)
+	}
+
+	slog.DebugContext(ctx, "Successfully stored result", "source", result.Source, "points", len(points), "backend", "influxdb")
+	return len(points), nil
+}
+
+// line encodes a point in the line protocol with a nanosecond timestamp
+func (r *InfluxRepository) line(point Point) string {
+	var line strings.Builder
+	line.WriteString(measurementEscaper.Replace(r.options.Measurement))
+	line.WriteString(",source=" + tagEscaper.Replace(point.Source))
+	line.WriteString(",code=" + tagEscaper.Replace(point.Code))
+	// Empty tag values are invalid in the line protocol
+	if point.Unit != "" {
+		line.WriteString(",unit=" + tagEscaper.Replace(point.Unit))
+	}
+
+	line.WriteString(" value=" + strconv.FormatFloat(point.Value, 'g', -1, 64))
+	// Metadata such as block numbers has too many values to be tags, the value field is reserved
+	for _, key := range slices.Sorted(maps.Keys(point.Metadata)) {
+		if key == "value" {
+			continue
+		}
+		line.WriteString("," + tagEscaper.Replace(key) + `="` + stringFieldEscaper.Replace(point.Metadata[key]) + `"`)
+	}
+
+	line.WriteString(" " + strconv.FormatInt(point.Timestamp.UnixNano(), 10))
+	return line.String()
+}
+
+// fluxEscaper escapes values embedded in Flux string literals
+var fluxEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`)
+
+// QueryRange returns the points of a series within [from, to) ordered by time. Metadata fields
+// are not read back
+func (r *InfluxRepository) QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error) {
+	query := r.fluxSeries(from, to, source, code) + `
+		|> sort(columns: ["_time"])`
+
+	points, err := r.queryPoints(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points of %s %s: %w", source, code, err)
+	}
+	return points, nil
+}
+
+// Latest returns the latest point of a series, ErrNotFound when it has none
+func (r *InfluxRepository) Latest(ctx context.Context, source, code string) (Point, error) {
+	query := r.fluxSeries(time.Unix(0, 0), time.Now().Add(time.Hour), source, code) + `
+		|> last()`
+
+	points, err := r.queryPoints(ctx, query)
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to query latest point of %s %s: %w", source, code, err)
+	}
+	if len(points) == 0 {
+		return Point{}, fmt.Errorf("latest point of %s %s: %w", source, code, ErrNotFound)
+	}
+	return points[0], nil
+}
+
+// ListSeries returns the series stored for a source ordered by code, or of every source when
+// source is empty. InfluxDB has no catalog so only the unit and the latest time are known
+func (r *InfluxRepository) ListSeries(ctx context.Context, source string) ([]Series, error) {
+	query := r.fluxSeries(time.Unix(0, 0), time.Now().Add(time.Hour), source, "") + `
+		|> last()
+		|> group()
+		|> sort(columns: ["source", "code"])`
+
+	points, err := r.queryPoints(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list series: %w", err)
+	}
+
+	series := make([]Series, 0, len(points))
+	for _, point := range points {
+		series = append(series, Series{Source: point.Source, Code: point.Code, Unit: point.Unit, LastSeen: point.Timestamp})
+	}
+	return series, nil
+}
+
+// fluxSeries returns a Flux query selecting the values within [from, to) of the series matching
+// source and code, an empty source or code matches every one
+func (r *InfluxRepository) fluxSeries(from, to time.Time, source, code string) string {
+	filter := fmt.Sprintf(`r._measurement == "%s" and r._field == "value"`, fluxEscaper.Replace(r.options.Measurement))
+	if source != "" {
+		filter += fmt.Sprintf(` and r.source == "%s"`, fluxEscaper.Replace(source))
+	}
+	if code != "" {
+		filter += fmt.Sprintf(` and r.code == "%s"`, fluxEscaper.Replace(code))
+	}
+
+	return fmt.Sprintf(`from(bucket: "%s")
+		|> range(start: %s, stop: %s)
+		|> filter(fn: (r) => %s)`,
+		fluxEscaper.Replace(r.options.Bucket), from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), filter)
+}
+
+// queryPoints runs a Flux query returning value rows and reads them as points
+func (r *InfluxRepository) queryPoints(ctx context.Context, query string) ([]Point, error) {
+	payload, err := json.Marshal(map[string]any{
+		"query":   query,
+		"type":    "flux",
+		"dialect": map[string]any{"header": true, "annotations": []string{}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("org", r.options.Org)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.options.URL+"/api/v2/query?"+params.Encode(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/csv")
+
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Every table of the response starts with its own header row, empty lines separate them
+	reader := csv.NewReader(resp.Body)
+	reader.FieldsPerRecord = -1
+	var columns map[string]int
+	var points []Point
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read influxdb response: %w", err)
+		}
+		if slices.Contains(record, "_value") {
+			columns = make(map[string]int, len(record))
+			for i, name := range record {
+				columns[name] = i
+			}
+			continue
+		}
+		if columns == nil {
+			return nil, fmt.Errorf("influxdb response has no header")
+		}
+
+		column := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		timestamp, err := time.Parse(time.RFC3339Nano, column("_time"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time in influxdb response: %w", err)
+		}
+		value, err := strconv.ParseFloat(column("_value"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in influxdb response: %w", err)
+		}
+		points = append(points, Point{
+			Source:    column("source"),
+			Code:      column("code"),
+			Timestamp: timestamp,
+			Value:     value,
+			Unit:      column("unit"),
+		})
+	}
+	return points, nil
+}
+
+// write sends line protocol data to the write endpoint of the bucket
+func (r *InfluxRepository) write(ctx context.Context, data io.Reader) error {
+	params := url.Values{}
+	params.Set("org", r.options.Org)
+	params.Set("bucket", r.options.Bucket)
+	params.Set("precision", "ns")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.options.URL+"/api/v2/write?"+params.Encode(), data)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do authenticates and sends a request, failing on error statuses
+func (r *InfluxRepository) do(req *http.Request) (*http.Response, error) {
+	if r.options.Token != "" {
+		req.Header.Set("Authorization", "Token "+r.options.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach influxdb: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		// InfluxDB explains the failure in the body
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("influxdb responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfluxRepository_SaveResult(t *testing.T) {
+	var path, token string
+	var params map[string]string
+	var lines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		token = r.Header.Get("Authorization")
+		params = map[string]string{
+			"org":       r.URL.Query().Get("org"),
+			"bucket":    r.URL.Query().Get("bucket"),
+			"precision": r.URL.Query().Get("precision"),
+		}
+		data, _ := io.ReadAll(r.Body)
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repository := NewInfluxRepository(InfluxOptions{URL: server.URL, Org: "macrochain", Bucket: "series", Token: "secret"})
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC)
+	stored, err := repository.WritePoints(context.Background(), scraper.Result{
+		Source: "snb_interest_rates",
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "SNBLZ", Value: 1.5, Unit: "percent", Timestamp: timestamp, Metadata: map[string]string{"description": `SNB "policy" rate`, "value": "ignored"}},
+			{Code: "R 10,Y", Value: 0.386, Timestamp: timestamp},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored)
+
+	assert.Equal(t, "/api/v2/write", path)
+	assert.Equal(t, "Token secret", token)
+	assert.Equal(t, map[string]string{"org": "macrochain", "bucket": "series", "precision": "ns"}, params)
+	assert.Equal(t, []string{
+		`data_points,source=snb_interest_rates,code=SNBLZ,unit=percent value=1.5,description="SNB \"policy\" rate" 1714564800250000000`,
+		`data_points,source=snb_interest_rates,code=R\ 10\,Y value=0.386 1714564800250000000`,
+	}, lines)
+}
+
+func TestInfluxRepository_Errors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, `{"code":"not found","message":"bucket \"series\" not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	repository := NewInfluxRepository(InfluxOptions{URL: server.URL, Bucket: "series"})
+	_, err := repository.WritePoints(context.Background(), scraper.Result{
+		Source: "binance_trades",
+		Data:   []scraper.TimeSeriesPoint{{Code: "BTCUSDT", Value: 1, Timestamp: time.Now()}},
+	})
+	assert.ErrorContains(t, err, "status 404")
+	assert.ErrorContains(t, err, `bucket \"series\" not found`)
+
+	// Results without points are not sent
+	stored, err := repository.WritePoints(context.Background(), scraper.Result{Source: "contract_logs"})
+	require.NoError(t, err)
+	assert.Zero(t, stored)
+	assert.Equal(t, 1, requests)
+}
+
+func TestInfluxRepository_Queries(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		query = request.Query
+		assert.Equal(t, "/api/v2/query", r.URL.Path)
+		assert.Equal(t, "macrochain", r.URL.Query().Get("org"))
+
+		if strings.Contains(query, `r.code == "missing"`) {
+			return
+		}
+		_, _ = io.WriteString(w, ",result,table,_start,_stop,_time,_value,_field,_measurement,code,source,unit\r\n"+
+			",_result,0,2024-05-01T00:00:00Z,2024-05-02T00:00:00Z,2024-05-01T12:00:00.25Z,1.5,value,data_points,SNBLZ,snb_interest_rates,percent\r\n"+
+			"\r\n"+
+			",result,table,_start,_stop,_time,_value,_field,_measurement,code,source\r\n"+
+			",_result,1,2024-05-01T00:00:00Z,2024-05-02T00:00:00Z,2024-05-01T13:00:00Z,0.386,value,data_points,R10,snb_interest_rates\r\n")
+	}))
+	defer server.Close()
+
+	repository := NewInfluxRepository(InfluxOptions{URL: server.URL, Org: "macrochain", Bucket: "series"})
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	points, err := repository.QueryRange(context.Background(), "snb_interest_rates", `SNB"LZ`, from, from.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Contains(t, query, `from(bucket: "series")`)
+	assert.Contains(t, query, `range(start: 2024-05-01T00:00:00Z, stop: 2024-05-02T00:00:00Z)`)
+	assert.Contains(t, query, `r.code == "SNB\"LZ"`)
+	assert.Equal(t, []Point{
+		{Source: "snb_interest_rates", Code: "SNBLZ", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC), Value: 1.5, Unit: "percent"},
+		{Source: "snb_interest_rates", Code: "R10", Timestamp: time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC), Value: 0.386},
+	}, points)
+
+	_, err = repository.Latest(context.Background(), "snb_interest_rates", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	series, err := repository.ListSeries(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	assert.NotContains(t, query, "r.source ==")
+	assert.Equal(t, "percent", series[0].Unit)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned when a series has no stored observation
+var ErrNotFound = errors.New("not found")
+
+// Latest returns the latest observation of a series from latest_data_points, which Save keeps up
+// to date, ErrNotFound when the series has none
+func (r *PostgresRepository) Latest(ctx context.Context, source, code string) (Point, error) {
+	var point Point
+	err := r.pool.QueryRow(ctx, `
+		SELECT source, code, time, value, unit, metadata FROM latest_data_points
+		WHERE source = $1 AND code = $2`, source, code).
+		Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Point{}, fmt.Errorf("latest point of %s %s: %w", source, code, ErrNotFound)
+	}
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to query latest point of %s %s: %w", source, code, err)
+	}
+	return point, nil
+}
+
+// LatestOfSource returns the latest observation of every series of a source ordered by code
+func (r *PostgresRepository) LatestOfSource(ctx context.Context, source string) ([]Point, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, time, value, unit, metadata FROM latest_data_points
+		WHERE source = $1
+		ORDER BY code`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest points of %s: %w", source, err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Point, error) {
+		var point Point
+		err := row.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata)
+		return point, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read latest points of %s: %w", source, err)
+	}
+	return points, nil
+}
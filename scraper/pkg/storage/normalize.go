@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+)
+
+// Point is a normalized observation of a series, identified by its source, code and time
+type Point struct {
+	Source    string
+	Code      string
+	Timestamp time.Time
+	Value     float64
+	Unit      string
+	Metadata  map[string]string
+	// RunID is the ID of the scraper run that produced the point, from the result metadata
+	RunID string
+}
+
+// Normalize converts the data of a result into points. Results without numeric series,
+// such as contract events, have no points. Result metadata is kept on every point unless
+// the point has its own value for the key
+func Normalize(result scraper.Result) ([]Point, error) {
+	var points []Point
+	add := func(code string, timestamp time.Time, value float64, unit string, metadata map[string]string) {
+		merged := maps.Clone(result.Metadata)
+		if merged == nil && len(metadata) > 0 {
+			merged = make(map[string]string, len(metadata))
+		}
+		maps.Copy(merged, metadata)
+
+		points = append(points, Point{
+			Source:    result.Source,
+			Code:      code,
+			Timestamp: timestamp.UTC(),
+			Value:     value,
+			Unit:      unit,
+			Metadata:  merged,
+			RunID:     result.Metadata[scraper.MetadataRunID],
+		})
+	}
+
+	switch data := result.Data.(type) {
+	case []scraper.TimeSeriesPoint:
+		for _, point := range data {
+			add(point.Code, point.Timestamp, point.Value, point.Unit, point.Metadata)
+		}
+	case []scraper.SNBInterestRate:
+		for _, rate := range data {
+			var metadata map[string]string
+			if rate.Description != "" {
+				metadata = map[string]string{"description": rate.Description}
+			}
+			add(rate.Code, rate.Date, rate.Value, rate.Unit, metadata)
+		}
+	case nil, []scraper.ContractEvent:
+	default:
+		return nil, fmt.Errorf("unsupported result data %T of source %s", result.Data, result.Source)
+	}
+	return points, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize_TimeSeries(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	result := scraper.Result{
+		Source: "ethereum_blocks",
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "base_fee", Value: 20, Unit: "gwei", Timestamp: timestamp, Metadata: map[string]string{"block": "100"}},
+			{Code: "block_time", Value: 12, Unit: "seconds", Timestamp: timestamp},
+		},
+		Metadata: map[string]string{"from_block": "99", "block": "ignored", scraper.MetadataRunID: "run-1"},
+	}
+
+	points, err := Normalize(result)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+
+	assert.Equal(t, "ethereum_blocks", points[0].Source)
+	assert.Equal(t, "base_fee", points[0].Code)
+	assert.Equal(t, 20.0, points[0].Value)
+	assert.Equal(t, "gwei", points[0].Unit)
+	assert.Equal(t, time.UTC, points[0].Timestamp.Location(), "Timestamps should be stored in UTC")
+	assert.True(t, points[0].Timestamp.Equal(timestamp))
+	assert.Equal(t, map[string]string{"from_block": "99", "block": "100", scraper.MetadataRunID: "run-1"}, points[0].Metadata, "Point metadata should take precedence")
+	assert.Equal(t, map[string]string{"from_block": "99", "block": "ignored", scraper.MetadataRunID: "run-1"}, points[1].Metadata)
+	assert.Equal(t, "run-1", points[1].RunID)
+}
+
+func TestNormalize_SNBRates(t *testing.T) {
+	date := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+	points, err := Normalize(scraper.Result{
+		Source: "snb_interest_rates",
+		Data:   []scraper.SNBInterestRate{{Code: "SARON", Value: 1.45, Date: date, Description: "Swiss Average Rate Overnight", Unit: "%"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, Point{
+		Source:    "snb_interest_rates",
+		Code:      "SARON",
+		Timestamp: date,
+		Value:     1.45,
+		Unit:      "%",
+		Metadata:  map[string]string{"description": "Swiss Average Rate Overnight"},
+	}, points[0])
+}
+
+func TestNormalize_NonNumericData(t *testing.T) {
+	points, err := Normalize(scraper.Result{Source: "contract_logs", Data: []scraper.ContractEvent{{Event: "Transfer"}}})
+	require.NoError(t, err)
+	assert.Empty(t, points, "Contract events should not produce points")
+
+	_, err = Normalize(scraper.Result{Source: "unknown", Data: map[string]int{"a": 1}})
+	assert.Error(t, err, "Unknown data should be rejected")
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PartitionOptions configures the native monthly partitioning of the data point table
+type PartitionOptions struct {
+	// Enabled partitions the data point table by month, it cannot be combined with TimescaleDB
+	Enabled bool
+	// Premake is the number of months partitions are created ahead of the current one, defaults to 3
+	Premake int
+	// RetainFor is the age after which monthly partitions are dropped, zero keeps every point
+	RetainFor time.Duration
+}
+
+// Names of the partitions that are not monthly. The history partition holds the points older than
+// the month the table was partitioned in, the default one points beyond the premade months
+const (
+	historyPartition = "data_points_history"
+	defaultPartition = "data_points_default"
+)
+
+// partitionLockID is the advisory lock serializing the partition changes of replicas starting or
+// maintaining the partitions at the same time
+const partitionLockID = 4_841_720_114
+
+// monthlyPartitionLayout names the partition of a month, e.g. data_points_p202405
+const monthlyPartitionLayout = "data_points_p200601"
+
+// monthStart returns the start of the UTC month of t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// timestampLiteral formats t as a SQL timestamptz literal
+func timestampLiteral(t time.Time) string {
+	return "'" + t.UTC().Format(time.RFC3339) + "'::timestamptz"
+}
+
+// lockPartitions holds the partition lock until tx ends
+func lockPartitions(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, partitionLockID); err != nil {
+		return fmt.Errorf("failed to lock data point partitions: %w", err)
+	}
+	return nil
+}
+
+// migratePartitions turns the data point table into a table partitioned by time. An existing
+// table becomes the history partition once the points of the current month and later are
+// moved out of it, so only recent rows are copied. Replicas starting together wait for the first
+// one to partition the table
+func migratePartitions(ctx context.Context, pool *pgxpool.Pool, now time.Time) error {
+	bound := monthStart(now)
+	partitioned := false
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if err := lockPartitions(ctx, tx); err != nil {
+			return err
+		}
+		var kind string
+		if err := tx.QueryRow(ctx, `SELECT relkind FROM pg_class WHERE oid = 'data_points'::regclass`).Scan(&kind); err != nil {
+			return fmt.Errorf("failed to read data point table kind: %w", err)
+		}
+		if kind == "p" {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, `LOCK TABLE data_points IN ACCESS EXCLUSIVE MODE`); err != nil {
+			return fmt.Errorf("failed to lock data points: %w", err)
+		}
+
+		// Partition bounds cannot be parameters
+		since := timestampLiteral(bound)
+		statements := []string{
+			`ALTER TABLE data_points RENAME TO ` + historyPartition,
+			`ALTER INDEX IF EXISTS data_points_pkey RENAME TO data_points_history_pkey`,
+			`ALTER INDEX IF EXISTS data_points_run_id_idx RENAME TO data_points_history_run_id_idx`,
+			`CREATE TABLE data_points (LIKE ` + historyPartition + ` INCLUDING DEFAULTS) PARTITION BY RANGE (time)`,
+			`ALTER TABLE data_points ADD PRIMARY KEY (source, code, time)`,
+			`CREATE INDEX IF NOT EXISTS data_points_run_id_idx ON data_points (run_id) WHERE run_id IS NOT NULL`,
+			`CREATE TABLE ` + defaultPartition + ` PARTITION OF data_points DEFAULT`,
+			`CREATE TEMPORARY TABLE data_points_recent ON COMMIT DROP AS SELECT * FROM ` + historyPartition + ` WHERE time >= ` + since,
+			`DELETE FROM ` + historyPartition + ` WHERE time >= ` + since,
+			`ALTER TABLE data_points ATTACH PARTITION ` + historyPartition + ` FOR VALUES FROM (MINVALUE) TO (` + since + `)`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("failed to partition data points: %w", err)
+			}
+		}
+
+		if err := createPartitions(ctx, tx, bound, bound); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO data_points SELECT * FROM data_points_recent`); err != nil {
+			return fmt.Errorf("failed to move recent data points: %w", err)
+		}
+
+		partitioned = true
+		return nil
+	})
+	if err != nil || !partitioned {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully partitioned data points", "history_before", bound.Format(time.DateOnly))
+	return nil
+}
+
+// createPartitions creates the monthly partitions of the months from first to last that do not
+// exist yet. The points of a new month that were stored in the default partition are moved to it
+func createPartitions(ctx context.Context, tx pgx.Tx, first, last time.Time) error {
+	for month := monthStart(first); !month.After(last); month = month.AddDate(0, 1, 0) {
+		name := month.Format(monthlyPartitionLayout)
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up partition %s: %w", name, err)
+		}
+		if exists {
+			continue
+		}
+
+		// A partition cannot be created while the default partition holds rows of its range
+		from, to := timestampLiteral(month), timestampLiteral(month.AddDate(0, 1, 0))
+		statements := []string{
+			`CREATE TEMPORARY TABLE data_points_moved ON COMMIT DROP AS SELECT * FROM ` + defaultPartition + ` WHERE time >= ` + from + ` AND time < ` + to,
+			`DELETE FROM ` + defaultPartition + ` WHERE time >= ` + from + ` AND time < ` + to,
+			`CREATE TABLE ` + name + ` PARTITION OF data_points FOR VALUES FROM (` + from + `) TO (` + to + `)`,
+			`INSERT INTO data_points SELECT * FROM data_points_moved`,
+			`DROP TABLE data_points_moved`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return fmt.Errorf("failed to create partition %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MaintainPartitions creates the partitions of the current month and the premade months as of
+// now and, when a retention is set, drops the monthly partitions that ended before it and
+// deletes the expired points of the history partition. It returns the dropped partitions, replicas
+// maintaining the partitions at the same time take turns
+func (r *PostgresRepository) MaintainPartitions(ctx context.Context, now time.Time) ([]string, error) {
+	options := r.options.Partitioning
+	if options.Premake <= 0 {
+		options.Premake = 3
+	}
+	current := monthStart(now)
+
+	var dropped []string
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := lockPartitions(ctx, tx); err != nil {
+			return err
+		}
+		if err := createPartitions(ctx, tx, current, current.AddDate(0, options.Premake, 0)); err != nil {
+			return err
+		}
+		if options.RetainFor <= 0 {
+			return nil
+		}
+
+		cutoff := now.UTC().Add(-options.RetainFor)
+		rows, err := tx.Query(ctx, `
+			SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+			WHERE i.inhparent = 'data_points'::regclass
+			ORDER BY c.relname`)
+		if err != nil {
+			return fmt.Errorf("failed to list partitions: %w", err)
+		}
+		partitions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("failed to read partitions: %w", err)
+		}
+
+		for _, name := range partitions {
+			month, err := time.Parse(monthlyPartitionLayout, name)
+			if err != nil || month.AddDate(0, 1, 0).After(cutoff) {
+				continue
+			}
+			if _, err := tx.Exec(ctx, `DROP TABLE `+pgx.Identifier{name}.Sanitize()); err != nil {
+				return fmt.Errorf("failed to drop partition %s: %w", name, err)
+			}
+			dropped = append(dropped, name)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM `+historyPartition+` WHERE time < $1`, cutoff); err != nil {
+			return fmt.Errorf("failed to purge history partition: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to maintain data point partitions: %w", err)
+	}
+	return dropped, nil
+}
+
+// PartitionJob maintains the monthly partitions of the data point table in the background
+type PartitionJob struct {
+	repository *PostgresRepository
+	interval   time.Duration
+}
+
+// NewPartitionJob creates a job maintaining the partitions every interval, defaulting to 6 hours
+func NewPartitionJob(repository *PostgresRepository, interval time.Duration) *PartitionJob {
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+
+	return &PartitionJob{
+		repository: repository,
+		interval:   interval,
+	}
+}
+
+// Run maintains the partitions each interval until the context is cancelled
+func (j *PartitionJob) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Partition job started", "interval", j.interval)
+
+	for {
+		dropped, err := j.repository.MaintainPartitions(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to maintain partitions", "error", err)
+		} else if len(dropped) > 0 {
+			slog.InfoContext(ctx, "Successfully dropped expired partitions", "partitions", dropped)
+		}
+
+		select {
+		case <-time.After(j.interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Partition job stopped")
+			return
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthlyPartitionNames(t *testing.T) {
+	month := monthStart(time.Date(2024, 5, 31, 23, 0, 0, 0, time.FixedZone("PDT", -7*3600)))
+	assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), month, "Months are UTC months")
+
+	name := month.Format(monthlyPartitionLayout)
+	assert.Equal(t, "data_points_p202406", name)
+	parsed, err := time.Parse(monthlyPartitionLayout, name)
+	require.NoError(t, err)
+	assert.Equal(t, month, parsed)
+
+	for _, name := range []string{historyPartition, defaultPartition} {
+		_, err := time.Parse(monthlyPartitionLayout, name)
+		assert.Error(t, err, name)
+	}
+	assert.Equal(t, "'2024-06-01T00:00:00Z'::timestamptz", timestampLiteral(month))
+}
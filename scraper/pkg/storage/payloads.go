@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/klauspost/compress/zstd"
+)
+
+// payloadEncoding is the compression of stored payloads
+const payloadEncoding = "zstd"
+
+var (
+	payloadEncoder, _ = zstd.NewWriter(nil)
+	payloadDecoder, _ = zstd.NewReader(nil)
+)
+
+// StoredPayload is a raw payload fetched by a scrape of a source
+type StoredPayload struct {
+	scraper.Payload
+	Source string
+	// SHA256 is the content address of the body
+	SHA256 string
+}
+
+// payloadHash returns the content address of a payload body
+func payloadHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// decodePayload decompresses a stored payload body
+func decodePayload(encoding string, body []byte) ([]byte, error) {
+	if encoding != payloadEncoding {
+		return nil, fmt.Errorf("unsupported payload encoding %q", encoding)
+	}
+	return payloadDecoder.DecodeAll(body, nil)
+}
+
+// SavePayloads stores the raw payloads fetched by the run of source with the given ID. Bodies are
+// addressed by their SHA-256 so a source returning the same document on every scrape stores it once
+func (r *PostgresRepository) SavePayloads(ctx context.Context, source, runID string, payloads []scraper.Payload) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, payload := range payloads {
+		hash := payloadHash(payload.Body)
+		batch.Queue(`
+			INSERT INTO raw_payloads (sha256, content_type, encoding, size, body)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (sha256) DO NOTHING`,
+			hash, payload.ContentType, payloadEncoding, len(payload.Body), payloadEncoder.EncodeAll(payload.Body, nil))
+		batch.Queue(`INSERT INTO scrape_payloads (source, url, fetched_at, sha256, run_id) VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+			source, payload.URL, payload.FetchedAt, hash, runID)
+	}
+
+	if err := r.pool.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to store payloads of %s: %w", source, err)
+	}
+
+	slog.DebugContext(ctx, "Successfully stored payloads", "source", source, "payloads", len(payloads))
+	return nil
+}
+
+// Payloads returns the raw payloads fetched by the scrapes of source within [from, to) ordered by
+// fetch time, so they can be parsed again after a parsing bug is fixed
+func (r *PostgresRepository) Payloads(ctx context.Context, source string, from, to time.Time) ([]scraper.Payload, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.url, s.fetched_at, p.content_type, p.encoding, p.body
+		FROM scrape_payloads s JOIN raw_payloads p ON p.sha256 = s.sha256
+		WHERE s.source = $1 AND s.fetched_at >= $2 AND s.fetched_at < $3
+		ORDER BY s.fetched_at, s.id`, source, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payloads of %s: %w", source, err)
+	}
+
+	payloads, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (scraper.Payload, error) {
+		var payload scraper.Payload
+		var encoding string
+		var body []byte
+		if err := row.Scan(&payload.URL, &payload.FetchedAt, &payload.ContentType, &encoding, &body); err != nil {
+			return payload, err
+		}
+		payload.Body, err = decodePayload(encoding, body)
+		return payload, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payloads of %s: %w", source, err)
+	}
+	return payloads, nil
+}
+
+// EachPayload passes the raw payloads fetched by every source within [from, to) to fn ordered by
+// source and fetch time. Rows are streamed so large ranges do not have to fit in memory, it stops
+// at the first error of fn
+func (r *PostgresRepository) EachPayload(ctx context.Context, from, to time.Time, fn func(StoredPayload) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT s.source, s.url, s.fetched_at, s.sha256, p.content_type, p.encoding, p.body
+		FROM scrape_payloads s JOIN raw_payloads p ON p.sha256 = s.sha256
+		WHERE s.fetched_at >= $1 AND s.fetched_at < $2
+		ORDER BY s.source, s.fetched_at, s.id`, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query payloads: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload StoredPayload
+		var encoding string
+		var body []byte
+		if err := rows.Scan(&payload.Source, &payload.URL, &payload.FetchedAt, &payload.SHA256, &payload.ContentType, &encoding, &body); err != nil {
+			return fmt.Errorf("failed to read payload: %w", err)
+		}
+		if payload.Body, err = decodePayload(encoding, body); err != nil {
+			return fmt.Errorf("failed to decode payload %s: %w", payload.SHA256, err)
+		}
+		if err := fn(payload); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read payloads: %w", err)
+	}
+	return nil
+}
+
+// LastPayloadChange returns when payloads fetched within [from, to) were last stored, zero when
+// there are none
+func (r *PostgresRepository) LastPayloadChange(ctx context.Context, from, to time.Time) (time.Time, error) {
+	var changed *time.Time
+	err := r.pool.QueryRow(ctx, `SELECT max(stored_at) FROM scrape_payloads WHERE fetched_at >= $1 AND fetched_at < $2`, from, to).Scan(&changed)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query last change of payloads: %w", err)
+	}
+	if changed == nil {
+		return time.Time{}, nil
+	}
+	return *changed, nil
+}
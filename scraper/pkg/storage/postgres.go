@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// insertPoint stores a point, the conflict clause of the ConflictPolicy is appended
+const insertPoint = `
+INSERT INTO data_points (source, code, time, value, unit, metadata, run_id)
+VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+ON CONFLICT (source, code, time) `
+
+// upsertSeries adds a stored series to the catalog, its last sighting is only refreshed hourly
+// so every save does not rewrite the row
+const upsertSeries = `
+INSERT INTO series (source, code, unit)
+VALUES ($1, $2, $3)
+ON CONFLICT (source, code) DO UPDATE
+SET unit = COALESCE(NULLIF(EXCLUDED.unit, ''), series.unit), last_seen = now()
+WHERE series.last_seen < now() - interval '1 hour'
+	OR (EXCLUDED.unit <> '' AND EXCLUDED.unit <> series.unit)`
+
+// upsertLatest copies the point of a series stored at a time to latest_data_points unless a newer
+// one is there. The point is read back from data_points so the copy follows the conflict policy
+const upsertLatest = `
+INSERT INTO latest_data_points (source, code, time, value, unit, metadata)
+SELECT source, code, time, value, unit, metadata FROM data_points
+WHERE source = $1 AND code = $2 AND time = $3
+ON CONFLICT (source, code) DO UPDATE
+SET time = EXCLUDED.time, value = EXCLUDED.value, unit = EXCLUDED.unit, metadata = EXCLUDED.metadata
+WHERE latest_data_points.time < EXCLUDED.time
+	OR (latest_data_points.time = EXCLUDED.time
+		AND (latest_data_points.value, latest_data_points.unit, latest_data_points.metadata)
+		IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.unit, EXCLUDED.metadata))`
+
+// ConflictPolicy decides what happens when a stored observation is scraped again
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite replaces the stored observation when the source revised it, unchanged
+	// observations are left alone so re-scrapes do not rewrite rows
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictKeep keeps the first stored observation and ignores revisions
+	ConflictKeep ConflictPolicy = "keep"
+)
+
+// ParseConflictPolicy returns the named conflict policy, an empty name is ConflictOverwrite
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(name); policy {
+	case "":
+		return ConflictOverwrite, nil
+	case ConflictOverwrite, ConflictKeep:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported conflict policy %q", name)
+	}
+}
+
+// clause returns the ON CONFLICT action of the policy
+func (p ConflictPolicy) clause() string {
+	if p == ConflictKeep {
+		return "DO NOTHING"
+	}
+	return `DO UPDATE
+SET value = EXCLUDED.value, unit = EXCLUDED.unit, metadata = EXCLUDED.metadata, run_id = EXCLUDED.run_id,
+	updated_at = now()
+WHERE (data_points.value, data_points.unit, data_points.metadata)
+	IS DISTINCT FROM (EXCLUDED.value, EXCLUDED.unit, EXCLUDED.metadata)`
+}
+
+// DB runs statements, it is implemented by pgx connections, pools and transactions
+type DB interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	SendBatch(ctx context.Context, batch *pgx.Batch) pgx.BatchResults
+}
+
+// PostgresOptions configures the schema managed by a PostgresRepository
+type PostgresOptions struct {
+	// Conflict decides how observations scraped again are stored, defaults to ConflictOverwrite
+	Conflict ConflictPolicy
+	// Vintages keeps every distinct value observed for a point in data_point_vintages, so revised
+	// series can be read as known on a past date. data_points keeps holding the current values
+	Vintages bool
+	// Timescale turns the data point table into a TimescaleDB hypertable with compression and
+	// continuous aggregates, the timescaledb extension must be available
+	Timescale TimescaleOptions
+	// Partitioning partitions the data point table by month with native Postgres partitions
+	Partitioning PartitionOptions
+}
+
+// PostgresRepository persists scrape results as normalized data points
+type PostgresRepository struct {
+	pool    *pgxpool.Pool
+	options PostgresOptions
+}
+
+// NewPostgresRepository creates a repository storing its points through the given pool
+func NewPostgresRepository(pool *pgxpool.Pool, options PostgresOptions) *PostgresRepository {
+	return &PostgresRepository{
+		pool:    pool,
+		options: options,
+	}
+}
+
+// Ping returns an error when the database is unreachable
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// Migrate sets up the TimescaleDB objects or partitions of the data point table when enabled. The
+// tables themselves are created by the schema migrations, which have to be applied first
+func (r *PostgresRepository) Migrate(ctx context.Context) error {
+	if r.options.Timescale.Enabled && r.options.Partitioning.Enabled {
+		return fmt.Errorf("native partitioning cannot be combined with timescaledb, hypertables are partitioned already")
+	}
+	if r.options.Timescale.Enabled {
+		return migrateTimescale(ctx, r.pool, r.options.Timescale)
+	}
+	if r.options.Partitioning.Enabled {
+		if err := migratePartitions(ctx, r.pool, time.Now()); err != nil {
+			return err
+		}
+		_, err := r.MaintainPartitions(ctx, time.Now())
+		return err
+	}
+	return nil
+}
+
+// QueryRange returns the points of a series within [from, to) ordered by time
+func (r *PostgresRepository) QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, time, value, unit, metadata FROM data_points
+		WHERE source = $1 AND code = $2 AND time >= $3 AND time < $4
+		ORDER BY time`, source, code, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points of %s %s: %w", source, code, err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Point, error) {
+		var point Point
+		err := row.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata)
+		return point, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read points of %s %s: %w", source, code, err)
+	}
+	return points, nil
+}
+
+// EachPoint passes every point within [from, to) to fn ordered by source, code and time, rows are
+// streamed so large ranges do not have to fit in memory. It stops at the first error of fn
+func (r *PostgresRepository) EachPoint(ctx context.Context, from, to time.Time, fn func(Point) error) error {
+	return r.eachPoint(ctx, fn, `
+		SELECT source, code, time, value, unit, metadata FROM data_points
+		WHERE time >= $1 AND time < $2
+		ORDER BY source, code, time`, from, to)
+}
+
+// EachSourcePoint passes every point of source within [from, to) to fn ordered by code and time,
+// rows are streamed like the ones of EachPoint
+func (r *PostgresRepository) EachSourcePoint(ctx context.Context, source string, from, to time.Time, fn func(Point) error) error {
+	return r.eachPoint(ctx, fn, `
+		SELECT source, code, time, value, unit, metadata FROM data_points
+		WHERE source = $1 AND time >= $2 AND time < $3
+		ORDER BY code, time`, source, from, to)
+}
+
+// LastPointChange returns when the points within [from, to) were last stored or revised, zero when
+// there are none
+func (r *PostgresRepository) LastPointChange(ctx context.Context, from, to time.Time) (time.Time, error) {
+	var changed *time.Time
+	err := r.pool.QueryRow(ctx, `SELECT max(updated_at) FROM data_points WHERE time >= $1 AND time < $2`, from, to).Scan(&changed)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query last change of points: %w", err)
+	}
+	if changed == nil {
+		return time.Time{}, nil
+	}
+	return *changed, nil
+}
+
+// eachPoint streams the points selected by query to fn
+func (r *PostgresRepository) eachPoint(ctx context.Context, fn func(Point) error, query string, args ...any) error {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point Point
+		if err := rows.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata); err != nil {
+			return fmt.Errorf("failed to read point: %w", err)
+		}
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read points: %w", err)
+	}
+	return nil
+}
+
+// WritePoints stores the points of a result, returning how many were inserted or revised
+func (r *PostgresRepository) WritePoints(ctx context.Context, result scraper.Result) (int, error) {
+	return r.Save(ctx, r.pool, result)
+}
+
+// Save stores the points of a result using db, which may be a transaction shared with the outbox.
+// Points are keyed by source, code and time so storing a result again never duplicates rows,
+// the returned count excludes the points that were already stored unchanged. The series of the
+// points are added to the catalog, so it covers every stored series, and their newest points to
+// latest_data_points
+func (r *PostgresRepository) Save(ctx context.Context, db DB, result scraper.Result) (int, error) {
+	points, err := Normalize(result)
+	if err != nil {
+		return 0, err
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	upsert := insertPoint + r.options.Conflict.clause()
+	observedAt := result.Timestamp
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+
+	batch := &pgx.Batch{}
+	units := make(map[string]string)
+	newest := make(map[string]time.Time)
+	for _, point := range points {
+		// The run ID has its own column, in the metadata it would make every re-scrape a revision
+		delete(point.Metadata, scraper.MetadataRunID)
+
+		var metadata []byte
+		if len(point.Metadata) > 0 {
+			if metadata, err = json.Marshal(point.Metadata); err != nil {
+				return 0, fmt.Errorf("failed to marshal metadata of %s: %w", point.Code, err)
+			}
+		}
+		batch.Queue(upsert, point.Source, point.Code, point.Timestamp, point.Value, point.Unit, metadata, point.RunID)
+		if r.options.Vintages {
+			batch.Queue(insertVintage, point.Source, point.Code, point.Timestamp, point.Value, point.Unit, metadata, observedAt, point.RunID)
+		}
+		if _, ok := units[point.Code]; !ok || point.Unit != "" {
+			units[point.Code] = point.Unit
+		}
+		if point.Timestamp.After(newest[point.Code]) {
+			newest[point.Code] = point.Timestamp
+		}
+	}
+	codes := slices.Sorted(maps.Keys(units))
+	for _, code := range codes {
+		batch.Queue(upsertSeries, result.Source, code, units[code])
+		batch.Queue(upsertLatest, result.Source, code, newest[code])
+	}
+
+	results := db.SendBatch(ctx, batch)
+	written := 0
+	for range points {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			return 0, fmt.Errorf("failed to store points of %s: %w", result.Source, err)
+		}
+		written += int(tag.RowsAffected())
+
+		if r.options.Vintages {
+			if _, err := results.Exec(); err != nil {
+				results.Close()
+				return 0, fmt.Errorf("failed to store vintages of %s: %w", result.Source, err)
+			}
+		}
+	}
+	for range codes {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("failed to catalog series of %s: %w", result.Source, err)
+		}
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return 0, fmt.Errorf("failed to store latest points of %s: %w", result.Source, err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("failed to store points of %s: %w", result.Source, err)
+	}
+
+	slog.DebugContext(ctx, "Successfully stored result", "source", result.Source, "points", len(points), "written", written)
+	return written, nil
+}
@@ -0,0 +1,739 @@
+//go:build integration
+// +build integration
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"macrochain/scraper/pkg/migrations"
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func newTestPool(t *testing.T, ctx context.Context) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		getEnv("DB_USER", "postgres"),
+		getEnv("DB_PASSWORD", "postgres"),
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", "5432"),
+		getEnv("DB_NAME", "macrochain_test"),
+	)
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err, "Failed to connect to database")
+	t.Cleanup(pool.Close)
+	migrateTestPool(t, ctx, pool)
+	return pool
+}
+
+// migrateTestPool applies the schema migrations to the database of pool
+func migrateTestPool(t *testing.T, ctx context.Context, pool *pgxpool.Pool) {
+	t.Helper()
+
+	migrator, err := migrations.New(pool)
+	require.NoError(t, err)
+	defer migrator.Close()
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err, "Failed to migrate database")
+}
+
+func TestPostgresRepository_SaveResult(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+	require.NoError(t, repository.Migrate(ctx))
+
+	source := fmt.Sprintf("test_source_%d", time.Now().UnixNano())
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	result := scraper.Result{
+		Source: source,
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "base_fee", Value: 20, Unit: "gwei", Timestamp: timestamp, Metadata: map[string]string{"block": "100"}},
+			{Code: "block_time", Value: 12, Unit: "seconds", Timestamp: timestamp},
+		},
+	}
+
+	stored, err := repository.WritePoints(ctx, result)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored)
+	day := timestamp.Truncate(24 * time.Hour)
+	inserted, err := repository.LastPointChange(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	// Scraping the same points again leaves the rows alone
+	stored, err = repository.WritePoints(ctx, result)
+	require.NoError(t, err)
+	assert.Zero(t, stored)
+
+	// A revised point replaces the stored one and counts as a change of its day
+	result.Data = []scraper.TimeSeriesPoint{{Code: "base_fee", Value: 25, Unit: "gwei", Timestamp: timestamp}}
+	stored, err = repository.WritePoints(ctx, result)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored)
+	revised, err := repository.LastPointChange(ctx, day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.True(t, revised.After(inserted))
+
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM data_points WHERE source = $1`, source).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	var value float64
+	var metadata map[string]string
+	err = pool.QueryRow(ctx, `SELECT value, metadata FROM data_points WHERE source = $1 AND code = 'base_fee'`, source).Scan(&value, &metadata)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, value)
+	assert.Nil(t, metadata)
+}
+
+func TestPostgresRepository_ConflictKeep(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{Conflict: ConflictKeep})
+	require.NoError(t, repository.Migrate(ctx))
+
+	source := fmt.Sprintf("test_source_%d", time.Now().UnixNano())
+	date := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+	for _, value := range []float64{1.5, 1.25} {
+		_, err := repository.WritePoints(ctx, scraper.Result{
+			Source: source,
+			Data:   []scraper.TimeSeriesPoint{{Code: "SARON", Value: value, Unit: "%", Timestamp: date}},
+		})
+		require.NoError(t, err)
+	}
+
+	points, err := repository.QueryRange(ctx, source, "SARON", date, date.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, 1.5, points[0].Value)
+}
+
+func TestPostgresRepository_Timescale(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{Timescale: TimescaleOptions{Enabled: true}})
+	require.NoError(t, repository.Migrate(ctx))
+	// Migrating again keeps the existing hypertable, policies and aggregates
+	require.NoError(t, repository.Migrate(ctx))
+
+	var hypertables int
+	err := pool.QueryRow(ctx, `SELECT count(*) FROM timescaledb_information.hypertables WHERE hypertable_name = 'data_points'`).Scan(&hypertables)
+	require.NoError(t, err)
+	assert.Equal(t, 1, hypertables)
+
+	source := fmt.Sprintf("test_source_%d", time.Now().UnixNano())
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var points []scraper.TimeSeriesPoint
+	for i := range 4 {
+		points = append(points, scraper.TimeSeriesPoint{Code: "btc_usd", Value: float64(60000 + i), Unit: "usd", Timestamp: start.Add(time.Duration(i) * 30 * time.Minute)})
+	}
+	_, err = repository.WritePoints(ctx, scraper.Result{Source: source, Data: points})
+	require.NoError(t, err)
+
+	stored, err := repository.QueryRange(ctx, source, "btc_usd", start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, 60000.0, stored[0].Value)
+	assert.True(t, start.Add(30*time.Minute).Equal(stored[1].Timestamp))
+
+	_, err = pool.Exec(ctx, `CALL refresh_continuous_aggregate('data_points_hourly', $1::timestamptz, $2::timestamptz)`, start, start.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	var opening, closing float64
+	var count int
+	err = pool.QueryRow(ctx, `SELECT open, close, points FROM data_points_hourly WHERE source = $1 AND code = 'btc_usd' AND bucket = $2`, source, start).Scan(&opening, &closing, &count)
+	require.NoError(t, err)
+	assert.Equal(t, 60000.0, opening)
+	assert.Equal(t, 60001.0, closing)
+	assert.Equal(t, 2, count)
+}
+
+func TestPostgresRepository_ApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_ticks_%d", time.Now().UnixNano())
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	old := time.Date(2024, 5, 20, 10, 0, 0, 0, time.UTC)
+	points := []scraper.TimeSeriesPoint{
+		{Code: "BTCUSDT", Value: 100, Unit: "usdt", Timestamp: old.Add(5 * time.Minute)},
+		{Code: "BTCUSDT", Value: 120, Unit: "usdt", Timestamp: old.Add(20 * time.Minute)},
+		{Code: "BTCUSDT", Value: 90, Unit: "usdt", Timestamp: old.Add(40 * time.Minute)},
+		{Code: "BTCUSDT", Value: 110, Unit: "usdt", Timestamp: old.Add(2 * time.Hour)},
+		{Code: "BTCUSDT", Value: 130, Unit: "usdt", Timestamp: now.Add(-time.Hour)},
+	}
+	_, err := repository.WritePoints(ctx, scraper.Result{Source: source, Data: points})
+	require.NoError(t, err)
+
+	policy := RetentionPolicy{Sources: source, Raw: 7 * 24 * time.Hour, Hourly: 30 * 24 * time.Hour}
+	stats, err := repository.ApplyRetention(ctx, policy, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Downsampled)
+
+	remaining, err := repository.QueryRange(ctx, source, "BTCUSDT", old, now)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1, "Recent points should be kept raw")
+
+	var open, high, low, closing, average float64
+	var count int
+	err = pool.QueryRow(ctx, `
+		SELECT open, high, low, close, average, points FROM data_point_aggregates
+		WHERE source = $1 AND resolution = '1h' AND bucket = $2`, source, old).Scan(&open, &high, &low, &closing, &average, &count)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{100, 120, 90, 90, 310.0 / 3}, []float64{open, high, low, closing, average})
+	assert.Equal(t, 3, count)
+
+	// A month later the recent point is downsampled too and every hourly aggregate becomes daily
+	stats, err = repository.ApplyRetention(ctx, policy, now.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Downsampled, "1 raw point and 3 hourly aggregates should be downsampled")
+
+	err = pool.QueryRow(ctx, `
+		SELECT open, close, points FROM data_point_aggregates
+		WHERE source = $1 AND resolution = '1d' AND bucket = $2`, source, old.Truncate(24*time.Hour)).Scan(&open, &closing, &count)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, open)
+	assert.Equal(t, 110.0, closing)
+	assert.Equal(t, 4, count)
+}
+
+func TestPostgresRepository_Latest(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_rates_%d", time.Now().UnixNano())
+	first := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+	_, err := repository.WritePoints(ctx, scraper.Result{
+		Source: source,
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "policy_rate", Value: 1.5, Unit: "percent", Timestamp: first},
+			{Code: "policy_rate", Value: 1.25, Unit: "percent", Timestamp: first.AddDate(0, 3, 0)},
+			{Code: "saron", Value: 1.2, Unit: "percent", Timestamp: first},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = repository.Latest(ctx, source, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	point, err := repository.Latest(ctx, source, "policy_rate")
+	require.NoError(t, err)
+	assert.Equal(t, 1.25, point.Value)
+	assert.True(t, point.Timestamp.Equal(first.AddDate(0, 3, 0)))
+
+	// Late points leave the newer latest point alone, revisions of it replace it
+	_, err = repository.WritePoints(ctx, scraper.Result{
+		Source: source,
+		Data: []scraper.TimeSeriesPoint{
+			{Code: "policy_rate", Value: 1.75, Unit: "percent", Timestamp: first.AddDate(0, 1, 0)},
+			{Code: "saron", Value: 1.1, Unit: "percent", Timestamp: first},
+		},
+	})
+	require.NoError(t, err)
+	point, err = repository.Latest(ctx, source, "policy_rate")
+	require.NoError(t, err)
+	assert.Equal(t, 1.25, point.Value)
+	point, err = repository.Latest(ctx, source, "saron")
+	require.NoError(t, err)
+	assert.Equal(t, 1.1, point.Value)
+
+	// Points kept by the conflict policy are not copied either
+	keep := NewPostgresRepository(pool, PostgresOptions{Conflict: ConflictKeep})
+	_, err = keep.WritePoints(ctx, scraper.Result{
+		Source: source,
+		Data:   []scraper.TimeSeriesPoint{{Code: "saron", Value: 1.0, Unit: "percent", Timestamp: first}},
+	})
+	require.NoError(t, err)
+	point, err = repository.Latest(ctx, source, "saron")
+	require.NoError(t, err)
+	assert.Equal(t, 1.1, point.Value)
+
+	points, err := repository.LatestOfSource(ctx, source)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "policy_rate", points[0].Code)
+	assert.Equal(t, "saron", points[1].Code)
+}
+
+func TestPostgresRepository_Payloads(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_feed_%d", time.Now().UnixNano())
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	feed := []byte(`<rss><channel><item><code>SNBLZ</code><value>1.5</value></item></channel></rss>`)
+	for i := range 2 {
+		payload := scraper.Payload{URL: "https://example.com/rss", ContentType: "application/xml", Body: feed, FetchedAt: fetchedAt.Add(time.Duration(i) * time.Hour)}
+		require.NoError(t, repository.SavePayloads(ctx, source, "", []scraper.Payload{payload}))
+	}
+
+	payloads, err := repository.Payloads(ctx, source, fetchedAt, fetchedAt.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, feed, payloads[1].Body)
+	assert.Equal(t, "application/xml", payloads[1].ContentType)
+	assert.True(t, payloads[1].FetchedAt.Equal(fetchedAt.Add(time.Hour)))
+
+	var stored int
+	err = pool.QueryRow(ctx, `SELECT count(*) FROM raw_payloads WHERE sha256 = $1`, payloadHash(feed)).Scan(&stored)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored, "An unchanged payload should be stored once")
+
+	var archived []StoredPayload
+	err = repository.EachPayload(ctx, fetchedAt, fetchedAt.Add(24*time.Hour), func(payload StoredPayload) error {
+		if payload.Source == source {
+			archived = append(archived, payload)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+	assert.Equal(t, feed, archived[0].Body)
+	assert.Equal(t, payloadHash(feed), archived[0].SHA256)
+
+	changed, err := repository.LastPayloadChange(ctx, fetchedAt, fetchedAt.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, changed.IsZero())
+}
+
+func TestPostgresRepository_Vintages(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{Vintages: true})
+
+	source := fmt.Sprintf("test_gdp_%d", time.Now().UnixNano())
+	quarter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	release := time.Date(2024, 4, 25, 12, 0, 0, 0, time.UTC)
+	scrape := func(observedAt time.Time, value float64) {
+		t.Helper()
+		_, err := repository.WritePoints(ctx, scraper.Result{
+			Source:    source,
+			Timestamp: observedAt,
+			Data:      []scraper.TimeSeriesPoint{{Code: "gdp", Value: value, Unit: "percent", Timestamp: quarter}},
+		})
+		require.NoError(t, err)
+	}
+	scrape(release, 1.6)
+	scrape(release.AddDate(0, 0, 1), 1.6) // Unrevised, no new vintage
+	scrape(release.AddDate(0, 1, 0), 1.3)
+
+	vintages, err := repository.Vintages(ctx, source, "gdp", quarter)
+	require.NoError(t, err)
+	require.Len(t, vintages, 2)
+	assert.Equal(t, 1.6, vintages[0].Value)
+	assert.Equal(t, 1.3, vintages[1].Value)
+
+	points, err := repository.PointsAsOf(ctx, source, "gdp", quarter, quarter.AddDate(0, 3, 0), release.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, 1.6, points[0].Value, "The first release was known a week after it")
+
+	points, err = repository.PointsAsOf(ctx, source, "gdp", quarter, quarter.AddDate(0, 3, 0), release.AddDate(-1, 0, 0))
+	require.NoError(t, err)
+	assert.Empty(t, points)
+
+	latest, err := repository.QueryRange(ctx, source, "gdp", quarter, quarter.AddDate(0, 3, 0))
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, 1.3, latest[0].Value)
+}
+
+func TestPostgresRepository_Catalog(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_energy_%d", time.Now().UnixNano())
+	require.NoError(t, repository.RegisterSeries(ctx, source, []scraper.SeriesInfo{
+		{Code: "wti", Description: "WTI spot price", Unit: "$/BBL", Frequency: scraper.FrequencyDaily, Country: "US"},
+		{Code: "brent", Description: "Brent spot price", Frequency: scraper.FrequencyDaily},
+	}))
+	// Registering again without a unit keeps the stored one
+	require.NoError(t, repository.RegisterSeries(ctx, source, []scraper.SeriesInfo{
+		{Code: "wti", Description: "WTI Cushing spot price", Frequency: scraper.FrequencyDaily},
+	}))
+
+	series, err := repository.CatalogSeries(ctx, source, "wti")
+	require.NoError(t, err)
+	assert.NotZero(t, series.ID)
+	assert.Equal(t, "WTI Cushing spot price", series.Description)
+	assert.Equal(t, "$/BBL", series.Unit)
+	assert.Equal(t, "US", series.Country)
+
+	_, err = repository.CatalogSeries(ctx, source, "henry_hub")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	catalog, err := repository.ListSeries(ctx, source)
+	require.NoError(t, err)
+	require.Len(t, catalog, 2)
+	assert.Equal(t, "brent", catalog[0].Code)
+
+	// Stored series are cataloged even when their scraper never registered them
+	_, err = repository.WritePoints(ctx, scraper.Result{Source: source, Data: []scraper.TimeSeriesPoint{
+		{Code: "henry_hub", Value: 2.5, Unit: "$/MMBTU", Timestamp: time.Now().UTC()},
+	}})
+	require.NoError(t, err)
+	series, err = repository.CatalogSeries(ctx, source, "henry_hub")
+	require.NoError(t, err)
+	assert.Equal(t, "$/MMBTU", series.Unit)
+
+	// Only series holding points are stored series
+	stored, err := repository.StoredSeries(ctx)
+	require.NoError(t, err)
+	var codes []string
+	for _, s := range stored {
+		if s.Source == source {
+			codes = append(codes, s.Code)
+			assert.False(t, s.FirstSeen.IsZero())
+		}
+	}
+	assert.Equal(t, []string{"henry_hub"}, codes)
+
+	sources, err := repository.StoredSources(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, sources, source)
+	var points []Point
+	err = repository.EachSourcePoint(ctx, source, time.Unix(0, 0), time.Now().AddDate(1, 0, 0), func(point Point) error {
+		points = append(points, point)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, points)
+	for _, point := range points {
+		assert.Equal(t, source, point.Source)
+	}
+}
+
+func TestPostgresRepository_CheckGaps(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_fx_%d", time.Now().UnixNano())
+	monday := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	store := func(days ...int) {
+		t.Helper()
+		var data []scraper.TimeSeriesPoint
+		for _, d := range days {
+			data = append(data, scraper.TimeSeriesPoint{Code: "usd_chf", Value: 0.9, Unit: "CHF", Timestamp: monday.AddDate(0, 0, d).Add(16 * time.Hour)})
+		}
+		_, err := repository.WritePoints(ctx, scraper.Result{Source: source, Data: data})
+		require.NoError(t, err)
+	}
+	store(0, 1, 3)
+
+	series := Series{Source: source, Code: "usd_chf", Frequency: scraper.FrequencyBusinessDay}
+	from, to := monday, monday.AddDate(0, 0, 7)
+	gaps, err := repository.CheckGaps(ctx, series, from, to, nil)
+	require.NoError(t, err)
+	require.Len(t, gaps, 2)
+	assert.Equal(t, monday.AddDate(0, 0, 2), gaps[0].Date.UTC())
+	assert.Equal(t, monday.AddDate(0, 0, 4), gaps[1].Date.UTC())
+
+	// Known gaps are not reported again
+	gaps, err = repository.CheckGaps(ctx, series, from, to, nil)
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+
+	// Backfilling resolves the gap
+	store(2)
+	gaps, err = repository.CheckGaps(ctx, series, from, to, nil)
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+
+	open, err := repository.OpenGaps(ctx, source)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, monday.AddDate(0, 0, 4), open[0].Date.UTC())
+
+	// A gap on a holiday of the source is resolved
+	holidays, err := ParseHolidayCalendars([]string{source + "=2024-05-10"})
+	require.NoError(t, err)
+	gaps, err = repository.CheckGaps(ctx, series, from, to, holidays)
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+	open, err = repository.OpenGaps(ctx, source)
+	require.NoError(t, err)
+	assert.Empty(t, open)
+}
+
+func TestPostgresRepository_Freshness(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_energy_%d", time.Now().UnixNano())
+	require.NoError(t, repository.RegisterSeries(ctx, source, []scraper.SeriesInfo{
+		{Code: "brent", Frequency: scraper.FrequencyDaily},
+		{Code: "henry_hub", Frequency: scraper.FrequencyDaily},
+		{Code: "wti", Frequency: scraper.FrequencyDaily},
+	}))
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := repository.WritePoints(ctx, scraper.Result{Source: source, Data: []scraper.TimeSeriesPoint{
+		{Code: "brent", Value: 82.1, Timestamp: now.Add(-5 * 24 * time.Hour)},
+		{Code: "wti", Value: 78.3, Timestamp: now.Add(-48 * time.Hour)},
+		{Code: "wti", Value: 78.9, Timestamp: now.Add(-24 * time.Hour)},
+	}})
+	require.NoError(t, err)
+
+	freshness, err := repository.Freshness(ctx, source, StalenessBudgets{source + "/henry_hub": 0})
+	require.NoError(t, err)
+	require.Len(t, freshness, 3)
+
+	assert.Equal(t, "brent", freshness[0].Code)
+	assert.True(t, freshness[0].Stale(now))
+	assert.Equal(t, "henry_hub", freshness[1].Code)
+	assert.True(t, freshness[1].Newest.IsZero())
+	assert.False(t, freshness[1].Stale(now), "monitoring disabled")
+	assert.Equal(t, "wti", freshness[2].Code)
+	assert.Equal(t, now.Add(-24*time.Hour), freshness[2].Newest.UTC())
+	assert.False(t, freshness[2].Stale(now))
+}
+
+func TestPostgresRepository_RecentAndQuarantine(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_snb_%d", time.Now().UnixNano())
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var data []scraper.TimeSeriesPoint
+	for i := range 5 {
+		data = append(data, scraper.TimeSeriesPoint{Code: "policy_rate", Value: float64(i), Unit: "%", Timestamp: start.AddDate(0, 0, i)})
+	}
+	_, err := repository.WritePoints(ctx, scraper.Result{Source: source, Data: data})
+	require.NoError(t, err)
+
+	recent, err := repository.Recent(ctx, source, "policy_rate", start.AddDate(0, 0, 4), 3)
+	require.NoError(t, err)
+	require.Len(t, recent, 3)
+	assert.Equal(t, []float64{1, 2, 3}, []float64{recent[0].Value, recent[1].Value, recent[2].Value})
+
+	point := Point{Source: source, Code: "policy_rate", Timestamp: start.AddDate(0, 0, 5), Value: 25, Unit: "%", RunID: "run-1"}
+	anomaly := Anomaly{Point: point, Reason: AnomalyZScore, Score: 12.5, Previous: 4, Quarantined: true}
+	require.NoError(t, repository.Quarantine(ctx, []Anomaly{anomaly}))
+	anomaly.Score = 13
+	require.NoError(t, repository.Quarantine(ctx, []Anomaly{anomaly}), "quarantining again replaces the point")
+
+	var score float64
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*), max(score) FROM quarantined_points WHERE source = $1`, source).Scan(&count, &score))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 13.0, score)
+
+	quarantined, err := repository.QuarantinedPoints(ctx, source, "")
+	require.NoError(t, err)
+	require.Len(t, quarantined, 1)
+	assert.Equal(t, 25.0, quarantined[0].Point.Value)
+	assert.Equal(t, "run-1", quarantined[0].Point.RunID)
+	assert.Equal(t, 4.0, quarantined[0].Previous)
+
+	written, err := ReleaseQuarantined(ctx, repository, repository, quarantined)
+	require.NoError(t, err)
+	assert.Equal(t, 1, written)
+	quarantined, err = repository.QuarantinedPoints(ctx, source, "policy_rate")
+	require.NoError(t, err)
+	assert.Empty(t, quarantined)
+	released, err := repository.QueryRange(ctx, source, "policy_rate", point.Timestamp, point.Timestamp.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, released, 1)
+	assert.Equal(t, 25.0, released[0].Value)
+	assert.Equal(t, AnomalyZScore, released[0].Metadata[MetadataAnomaly])
+}
+
+func TestPostgresRepository_Lineage(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_snb_%d", time.Now().UnixNano())
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	store := func(runID string) {
+		t.Helper()
+		run := Run{ID: runID, Source: source, StartedAt: date.Add(time.Hour)}
+		require.NoError(t, repository.SavePayloads(ctx, source, runID, []scraper.Payload{
+			{URL: "https://example.com/rss", Body: []byte(runID), FetchedAt: run.StartedAt},
+		}))
+		_, err := repository.WritePoints(ctx, scraper.Result{
+			Source:   source,
+			Data:     []scraper.TimeSeriesPoint{{Code: "SNBLZ", Value: 1.5, Unit: "percent", Timestamp: date}},
+			Metadata: map[string]string{scraper.MetadataRunID: runID, "feed": "rss"},
+		})
+		require.NoError(t, err)
+		run.FinishedAt, run.Status, run.Points = run.StartedAt.Add(time.Second), RunSucceeded, 1
+		require.NoError(t, repository.SaveRun(ctx, run))
+	}
+	first := fmt.Sprintf("run-%d", time.Now().UnixNano())
+	store(first)
+	// An unchanged re-scrape keeps the run that produced the value
+	store(first + "-again")
+
+	lineage, err := repository.Lineage(ctx, source, "SNBLZ", date)
+	require.NoError(t, err)
+	assert.Equal(t, first, lineage.Point.RunID)
+	assert.Equal(t, map[string]string{"feed": "rss"}, lineage.Point.Metadata)
+	assert.Equal(t, RunSucceeded, lineage.Run.Status)
+	assert.Equal(t, 1, lineage.Run.Points)
+	require.Len(t, lineage.Payloads, 1)
+	assert.Equal(t, payloadHash([]byte(first)), lineage.Payloads[0].SHA256)
+
+	_, err = repository.Lineage(ctx, source, "LSFF", date)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestPostgresRepository_Runs(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	repository := NewPostgresRepository(pool, PostgresOptions{})
+
+	source := fmt.Sprintf("test_fred_%d", time.Now().UnixNano())
+	started := time.Now().UTC().Truncate(time.Millisecond)
+	failed := Run{
+		ID: source + "-1", Source: source, StartedAt: started.Add(-time.Hour), FinishedAt: started.Add(-time.Hour + 2*time.Second),
+		Status: RunFailed, Error: "timeout", ErrorClass: "transient", BytesFetched: 512,
+	}
+	running := Run{ID: source + "-2", Source: source, StartedAt: started, Status: RunRunning}
+	require.NoError(t, repository.SaveRun(ctx, failed))
+	require.NoError(t, repository.SaveRun(ctx, running))
+
+	runs, err := repository.Runs(ctx, RunFilter{Source: source})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, running.ID, runs[0].ID, "The latest run comes first")
+	assert.True(t, runs[0].FinishedAt.IsZero())
+	assert.Equal(t, failed.ID, runs[1].ID)
+	assert.Equal(t, "timeout", runs[1].Error)
+	assert.Equal(t, "transient", runs[1].ErrorClass)
+	assert.Equal(t, int64(512), runs[1].BytesFetched)
+	assert.Equal(t, 2*time.Second, runs[1].Duration())
+
+	var durationMS int64
+	require.NoError(t, pool.QueryRow(ctx, `SELECT duration_ms FROM scrape_runs WHERE run_id = $1`, failed.ID).Scan(&durationMS))
+	assert.Equal(t, int64(2000), durationMS)
+
+	runs, err = repository.Runs(ctx, RunFilter{Source: source, Status: RunFailed})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, failed.ID, runs[0].ID)
+
+	runs, err = repository.Runs(ctx, RunFilter{Source: source, ErrorClass: "source_changed"})
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+
+	runs, err = repository.Runs(ctx, RunFilter{Source: source, Since: started.Add(-time.Minute)})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, running.ID, runs[0].ID)
+
+	// Finishing a run updates its record
+	running.FinishedAt, running.Status, running.Points = started.Add(time.Second), RunSucceeded, 3
+	require.NoError(t, repository.SaveRun(ctx, running))
+	runs, err = repository.Runs(ctx, RunFilter{Source: source, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunSucceeded, runs[0].Status)
+	assert.Equal(t, 3, runs[0].Points)
+}
+
+func TestPostgresRepository_Partitioning(t *testing.T) {
+	ctx := context.Background()
+	pool := newTestPool(t, ctx)
+
+	// The conversion runs in its own schema so the shared table is left alone
+	schema := fmt.Sprintf("test_partitions_%d", time.Now().UnixNano())
+	_, err := pool.Exec(ctx, `CREATE SCHEMA `+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+	config := pool.Config().Copy()
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	scoped, err := pgxpool.NewWithConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(scoped.Close)
+
+	migrateTestPool(t, ctx, scoped)
+	plain := NewPostgresRepository(scoped, PostgresOptions{})
+
+	now := time.Now().UTC()
+	old := now.AddDate(-2, 0, 0)
+	store := func(repository *PostgresRepository, timestamps ...time.Time) {
+		t.Helper()
+		var data []scraper.TimeSeriesPoint
+		for i, timestamp := range timestamps {
+			data = append(data, scraper.TimeSeriesPoint{Code: "rate", Value: float64(i), Timestamp: timestamp})
+		}
+		_, err := repository.WritePoints(ctx, scraper.Result{Source: "snb", Data: data})
+		require.NoError(t, err)
+	}
+	store(plain, old, now)
+
+	repository := NewPostgresRepository(scoped, PostgresOptions{
+		Partitioning: PartitionOptions{Enabled: true, Premake: 2, RetainFor: 3 * 365 * 24 * time.Hour},
+	})
+	require.NoError(t, repository.Migrate(ctx))
+	// Migrating again leaves the partitioned table alone
+	require.NoError(t, repository.Migrate(ctx))
+
+	var kind string
+	require.NoError(t, scoped.QueryRow(ctx, `SELECT relkind FROM pg_class WHERE oid = 'data_points'::regclass`).Scan(&kind))
+	assert.Equal(t, "p", kind)
+	points, err := repository.QueryRange(ctx, "snb", "rate", old, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+	latest, err := repository.Latest(ctx, "snb", "rate")
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, latest.Timestamp, time.Millisecond, "The latest points survive partitioning")
+
+	// Points beyond the premade months wait in the default partition for theirs
+	later := monthStart(now).AddDate(0, 4, 0)
+	store(repository, later)
+	var inDefault int
+	require.NoError(t, scoped.QueryRow(ctx, `SELECT count(*) FROM `+defaultPartition).Scan(&inDefault))
+	assert.Equal(t, 1, inDefault)
+
+	dropped, err := repository.MaintainPartitions(ctx, later)
+	require.NoError(t, err)
+	assert.Empty(t, dropped)
+	require.NoError(t, scoped.QueryRow(ctx, `SELECT count(*) FROM `+defaultPartition).Scan(&inDefault))
+	assert.Zero(t, inDefault)
+
+	// Three years later the partition of the conversion month has expired
+	dropped, err = repository.MaintainPartitions(ctx, monthStart(now).AddDate(3, 3, 0))
+	require.NoError(t, err)
+	assert.Contains(t, dropped, monthStart(now).Format(monthlyPartitionLayout))
+	points, err = repository.QueryRange(ctx, "snb", "rate", old, later.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, points, 1, "The old point was purged from the history partition")
+	assert.Equal(t, later, points[0].Timestamp.UTC())
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictOverwrite, policy)
+
+	policy, err = ParseConflictPolicy("keep")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictKeep, policy)
+
+	_, err = ParseConflictPolicy("merge")
+	assert.Error(t, err)
+}
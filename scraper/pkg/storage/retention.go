@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RetentionPolicy decides how long the points of matching sources are kept at each resolution.
+// A zero duration keeps the data at that resolution forever
+type RetentionPolicy struct {
+	// Sources is a glob pattern of source names, e.g. "binance_*"
+	Sources string
+	// Raw is how long raw points are kept before they are downsampled to hourly aggregates
+	Raw time.Duration
+	// Hourly is how long hourly aggregates are kept before they are downsampled to daily aggregates
+	Hourly time.Duration
+	// Purge is the age after which points and aggregates of every resolution are deleted
+	Purge time.Duration
+}
+
+// RetentionStats counts the rows changed by a retention run
+type RetentionStats struct {
+	Downsampled int64
+	Purged      int64
+}
+
+// ParseRetentionPolicies parses a list of "sources:raw_days:hourly_days:purge_days" entries,
+// e.g. "binance_stream:7:90:0" keeps ticks for a week and hourly aggregates for 90 days
+func ParseRetentionPolicies(entries []string) ([]RetentionPolicy, error) {
+	policies := make([]RetentionPolicy, 0, len(entries))
+	for _, entry := range entries {
+		fields := strings.Split(strings.TrimSpace(entry), ":")
+		if len(fields) != 4 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid retention policy %q, expected sources:raw_days:hourly_days:purge_days", entry)
+		}
+
+		policy := RetentionPolicy{Sources: fields[0]}
+		for i, target := range []*time.Duration{&policy.Raw, &policy.Hourly, &policy.Purge} {
+			days, err := strconv.Atoi(fields[i+1])
+			if err != nil || days < 0 {
+				return nil, fmt.Errorf("invalid number of days %q in retention policy %q", fields[i+1], entry)
+			}
+			*target = time.Duration(days) * 24 * time.Hour
+		}
+		if err := policy.validate(); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// validate checks that every resolution is kept at least as long as the finer one
+func (p RetentionPolicy) validate() error {
+	if p.Raw > 0 && p.Hourly > 0 && p.Hourly <= p.Raw {
+		return fmt.Errorf("retention policy of %s keeps hourly aggregates for less than raw points", p.Sources)
+	}
+	if p.Purge > 0 && (p.Purge <= p.Raw || p.Purge <= p.Hourly) {
+		return fmt.Errorf("retention policy of %s purges data before it is downsampled", p.Sources)
+	}
+	return nil
+}
+
+// likePattern converts the glob pattern of the policy to a SQL LIKE pattern
+func (p RetentionPolicy) likePattern() string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(p.Sources)
+	return strings.ReplaceAll(escaped, "*", "%")
+}
+
+// downsampleRaw moves the raw points before the cutoff into hourly buckets, merging with
+// buckets downsampled earlier in case points arrived late. Deleting and aggregating in one
+// statement reads a single snapshot, so a point written meanwhile is neither lost nor counted
+// twice. It returns the number of points moved
+const downsampleRaw = `
+WITH moved AS (
+	DELETE FROM data_points WHERE source LIKE $1 AND time < $2
+	RETURNING source, code, time, value, unit
+), aggregated AS (
+	INSERT INTO data_point_aggregates (source, code, resolution, bucket, open, high, low, close, average, points, unit)
+	SELECT source, code, '1h', date_trunc('hour', time, 'UTC') AS bucket,
+		(array_agg(value ORDER BY time))[1], max(value), min(value), (array_agg(value ORDER BY time DESC))[1],
+		avg(value), count(*), max(unit)
+	FROM moved
+	GROUP BY source, code, bucket
+	ON CONFLICT (source, code, resolution, bucket) DO UPDATE SET ` + mergeAggregate + `
+)
+SELECT count(*) FROM moved`
+
+// downsampleHourly moves the hourly buckets before the cutoff into daily buckets, returning
+// the number of hourly buckets moved
+const downsampleHourly = `
+WITH moved AS (
+	DELETE FROM data_point_aggregates WHERE resolution = '1h' AND source LIKE $1 AND bucket < $2
+	RETURNING source, code, bucket, open, high, low, close, average, points, unit
+), aggregated AS (
+	INSERT INTO data_point_aggregates (source, code, resolution, bucket, open, high, low, close, average, points, unit)
+	SELECT source, code, '1d', date_trunc('day', bucket, 'UTC') AS day,
+		(array_agg(open ORDER BY bucket))[1], max(high), min(low), (array_agg(close ORDER BY bucket DESC))[1],
+		sum(average * points) / sum(points), sum(points), max(unit)
+	FROM moved
+	GROUP BY source, code, day
+	ON CONFLICT (source, code, resolution, bucket) DO UPDATE SET ` + mergeAggregate + `
+)
+SELECT count(*) FROM moved`
+
+// mergeAggregate combines an aggregate with the one already stored for its bucket
+const mergeAggregate = `
+	high = GREATEST(data_point_aggregates.high, EXCLUDED.high),
+	low = LEAST(data_point_aggregates.low, EXCLUDED.low),
+	close = EXCLUDED.close,
+	average = (data_point_aggregates.average * data_point_aggregates.points + EXCLUDED.average * EXCLUDED.points)
+		/ (data_point_aggregates.points + EXCLUDED.points),
+	points = data_point_aggregates.points + EXCLUDED.points`
+
+// CheckTimescaleRetention checks that policies fit the continuous aggregates of a TimescaleDB
+// hypertable. They hold the hourly and daily buckets already, so raw points are only deleted
+// once they are past the refresh window of every aggregate and hourly buckets are not moved
+func CheckTimescaleRetention(policies []RetentionPolicy) error {
+	var refreshed time.Duration
+	for _, aggregate := range aggregates {
+		refreshed = max(refreshed, aggregate.lookback)
+	}
+	for _, policy := range policies {
+		if policy.Raw > 0 && policy.Raw <= refreshed {
+			return fmt.Errorf("retention policy of %s deletes raw points within the %s refreshed by the continuous aggregates", policy.Sources, refreshed)
+		}
+		if policy.Hourly > 0 {
+			return fmt.Errorf("retention policy of %s downsamples hourly aggregates, the continuous aggregates of timescaledb keep both resolutions", policy.Sources)
+		}
+	}
+	return nil
+}
+
+// ApplyRetention downsamples and purges the data of the sources matching policy as of now.
+// Cutoffs are aligned to whole hours and days so only complete buckets are downsampled. With
+// TimescaleDB the buckets are the data_points_hourly and data_points_daily continuous
+// aggregates, so raw points past the raw retention are only deleted
+func (r *PostgresRepository) ApplyRetention(ctx context.Context, policy RetentionPolicy, now time.Time) (RetentionStats, error) {
+	var stats RetentionStats
+	sources := policy.likePattern()
+	now = now.UTC()
+	if r.options.Timescale.Enabled {
+		if err := CheckTimescaleRetention([]RetentionPolicy{policy}); err != nil {
+			return RetentionStats{}, err
+		}
+	}
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		exec := func(sql string, cutoff time.Time) (int64, error) {
+			tag, err := tx.Exec(ctx, sql, sources, cutoff)
+			return tag.RowsAffected(), err
+		}
+		move := func(sql string, cutoff time.Time) (int64, error) {
+			var moved int64
+			err := tx.QueryRow(ctx, sql, sources, cutoff).Scan(&moved)
+			return moved, err
+		}
+
+		if policy.Raw > 0 && r.options.Timescale.Enabled {
+			cutoff := now.Add(-policy.Raw).Truncate(time.Hour)
+			deleted, err := exec(`DELETE FROM data_points WHERE source LIKE $1 AND time < $2`, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to delete aggregated points: %w", err)
+			}
+			stats.Downsampled += deleted
+		} else if policy.Raw > 0 {
+			cutoff := now.Add(-policy.Raw).Truncate(time.Hour)
+			moved, err := move(downsampleRaw, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to downsample raw points: %w", err)
+			}
+			stats.Downsampled += moved
+		}
+
+		if policy.Hourly > 0 {
+			cutoff := now.Add(-policy.Hourly).Truncate(24 * time.Hour)
+			moved, err := move(downsampleHourly, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to downsample hourly aggregates: %w", err)
+			}
+			stats.Downsampled += moved
+		}
+
+		if policy.Purge > 0 {
+			cutoff := now.Add(-policy.Purge)
+			points, err := exec(`DELETE FROM data_points WHERE source LIKE $1 AND time < $2`, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to purge points: %w", err)
+			}
+			aggregates, err := exec(`DELETE FROM data_point_aggregates WHERE source LIKE $1 AND bucket < $2`, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to purge aggregates: %w", err)
+			}
+			// Series without a point left have no latest point either
+			if _, err := exec(`DELETE FROM latest_data_points WHERE source LIKE $1 AND time < $2`, cutoff); err != nil {
+				return fmt.Errorf("failed to purge latest points: %w", err)
+			}
+			stats.Purged += points + aggregates
+		}
+		return nil
+	})
+	if err != nil {
+		return RetentionStats{}, fmt.Errorf("failed to apply retention policy of %s: %w", policy.Sources, err)
+	}
+	return stats, nil
+}
+
+// RetentionJob applies retention policies in the background
+type RetentionJob struct {
+	repository *PostgresRepository
+	policies   []RetentionPolicy
+	interval   time.Duration
+}
+
+// NewRetentionJob creates a job applying policies every interval, defaulting to 1 hour
+func NewRetentionJob(repository *PostgresRepository, policies []RetentionPolicy, interval time.Duration) *RetentionJob {
+	if interval <= 0 {
+		interval = 1 * time.Hour
+	}
+
+	return &RetentionJob{
+		repository: repository,
+		policies:   policies,
+		interval:   interval,
+	}
+}
+
+// Run applies every policy each interval until the context is cancelled, a failing policy
+// does not keep the others from running
+func (j *RetentionJob) Run(ctx context.Context) {
+	slog.InfoContext(ctx, "Retention job started", "interval", j.interval, "policies", len(j.policies))
+
+	for {
+		for _, policy := range j.policies {
+			stats, err := j.repository.ApplyRetention(ctx, policy, time.Now())
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.ErrorContext(ctx, "Failed to apply retention policy", "sources", policy.Sources, "error", err)
+				}
+				continue
+			}
+			slog.InfoContext(ctx, "Successfully applied retention policy",
+				"sources", policy.Sources, "downsampled", stats.Downsampled, "purged", stats.Purged)
+		}
+
+		select {
+		case <-time.After(j.interval):
+		case <-ctx.Done():
+			slog.InfoContext(context.Background(), "Retention job stopped")
+			return
+		}
+	}
+}
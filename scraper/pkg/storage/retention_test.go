@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicies(t *testing.T) {
+	policies, err := ParseRetentionPolicies([]string{"binance_stream:7:90:0", " coinbase_*:0:0:365 "})
+	require.NoError(t, err)
+	assert.Equal(t, []RetentionPolicy{
+		{Sources: "binance_stream", Raw: 7 * 24 * time.Hour, Hourly: 90 * 24 * time.Hour},
+		{Sources: "coinbase_*", Purge: 365 * 24 * time.Hour},
+	}, policies)
+
+	for _, entry := range []string{
+		"binance_stream:7:90",
+		":7:90:0",
+		"binance_stream:seven:90:0",
+		"binance_stream:-1:90:0",
+		"binance_stream:90:7:0",
+		"binance_stream:7:90:30",
+	} {
+		_, err := ParseRetentionPolicies([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestRetentionPolicy_LikePattern(t *testing.T) {
+	assert.Equal(t, `binance\_%`, RetentionPolicy{Sources: "binance_*"}.likePattern())
+	assert.Equal(t, `100\%\_stream`, RetentionPolicy{Sources: "100%_stream"}.likePattern())
+}
+
+func TestCheckTimescaleRetention(t *testing.T) {
+	policies, err := ParseRetentionPolicies([]string{"binance_stream:60:0:365"})
+	require.NoError(t, err)
+	assert.NoError(t, CheckTimescaleRetention(policies))
+
+	for _, entry := range []string{"binance_stream:7:0:0", "binance_stream:60:90:0"} {
+		policies, err := ParseRetentionPolicies([]string{entry})
+		require.NoError(t, err)
+		assert.Error(t, CheckTimescaleRetention(policies), entry)
+	}
+}
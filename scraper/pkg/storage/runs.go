@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Statuses of scraper runs
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// Run is a scraper run, the points and payloads it produced reference it by ID
+type Run struct {
+	ID         string
+	Source     string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     string
+	Points     int
+	Error      string
+	// ErrorClass is the errclass class of Error, e.g. "rate_limited"
+	ErrorClass string
+	// BytesFetched is the size of the response bodies read from the HTTP sources
+	BytesFetched int64
+}
+
+// Duration returns how long a finished run took, zero while it is running
+func (r Run) Duration() time.Duration {
+	if r.FinishedAt.IsZero() {
+		return 0
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// SaveRun records a run in scrape_runs, saving a run again updates its outcome
+func (r *PostgresRepository) SaveRun(ctx context.Context, run Run) error {
+	var finishedAt *time.Time
+	var durationMS *int64
+	if !run.FinishedAt.IsZero() {
+		finishedAt = &run.FinishedAt
+		duration := run.Duration().Milliseconds()
+		durationMS = &duration
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO scrape_runs (run_id, source, started_at, finished_at, duration_ms, status, points, bytes_fetched, error, error_class)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
+		ON CONFLICT (run_id) DO UPDATE SET
+			finished_at = EXCLUDED.finished_at,
+			duration_ms = EXCLUDED.duration_ms,
+			status = EXCLUDED.status,
+			points = EXCLUDED.points,
+			bytes_fetched = EXCLUDED.bytes_fetched,
+			error = EXCLUDED.error,
+			error_class = EXCLUDED.error_class`,
+		run.ID, run.Source, run.StartedAt, finishedAt, durationMS, run.Status, run.Points, run.BytesFetched, run.Error, run.ErrorClass)
+	if err != nil {
+		return fmt.Errorf("failed to save run %s of %s: %w", run.ID, run.Source, err)
+	}
+	return nil
+}
+
+// RunFilter selects the runs returned by Runs, zero fields do not filter
+type RunFilter struct {
+	Source string
+	Status string
+	// ErrorClass selects the failed runs of an errclass class, e.g. "source_changed"
+	ErrorClass string
+	// Since and Until bound the start of the runs, Until is exclusive
+	Since time.Time
+	Until time.Time
+	// Limit caps the number of runs, defaults to 100
+	Limit int
+}
+
+// runColumns are the columns of scrape_runs read by scanRun
+const runColumns = `run_id, source, started_at, finished_at, status, points, bytes_fetched, error, error_class`
+
+// scanRun reads a row of runColumns
+func scanRun(row pgx.Row) (Run, error) {
+	var run Run
+	var finishedAt *time.Time
+	var runError, errorClass *string
+	err := row.Scan(&run.ID, &run.Source, &run.StartedAt, &finishedAt, &run.Status, &run.Points, &run.BytesFetched, &runError, &errorClass)
+	if finishedAt != nil {
+		run.FinishedAt = *finishedAt
+	}
+	if runError != nil {
+		run.Error = *runError
+	}
+	if errorClass != nil {
+		run.ErrorClass = *errorClass
+	}
+	return run, err
+}
+
+// Runs returns the recorded runs matching filter, the latest first
+func (r *PostgresRepository) Runs(ctx context.Context, filter RunFilter) ([]Run, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	var since, until *time.Time
+	if !filter.Since.IsZero() {
+		since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		until = &filter.Until
+	}
+
+	// Runs recorded before run IDs were introduced cannot be referenced and are left out
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+runColumns+` FROM scrape_runs
+		WHERE run_id IS NOT NULL
+			AND ($1 = '' OR source = $1)
+			AND ($2 = '' OR status = $2)
+			AND ($3::timestamptz IS NULL OR started_at >= $3)
+			AND ($4::timestamptz IS NULL OR started_at < $4)
+			AND ($6 = '' OR error_class = $6)
+		ORDER BY started_at DESC, id DESC
+		LIMIT $5`, filter.Source, filter.Status, since, until, filter.Limit, filter.ErrorClass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+
+	runs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Run, error) {
+		return scanRun(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+	return runs, nil
+}
+
+// PayloadRef identifies a stored raw payload, its body is returned by Payloads
+type PayloadRef struct {
+	URL       string
+	FetchedAt time.Time
+	SHA256    string
+}
+
+// Lineage is the origin of a stored point. Run is empty when the run was not recorded and
+// Payloads when payload archiving was disabled
+type Lineage struct {
+	Point    Point
+	Run      Run
+	Payloads []PayloadRef
+}
+
+// Lineage returns the run and raw payloads that produced the stored point of a series at a time,
+// ErrNotFound when there is no such point
+func (r *PostgresRepository) Lineage(ctx context.Context, source, code string, timestamp time.Time) (Lineage, error) {
+	var lineage Lineage
+	var runID *string
+	point := &lineage.Point
+	err := r.pool.QueryRow(ctx, `
+		SELECT source, code, time, value, unit, metadata, run_id FROM data_points
+		WHERE source = $1 AND code = $2 AND time = $3`, source, code, timestamp).
+		Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata, &runID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Lineage{}, fmt.Errorf("point %s %s at %s: %w", source, code, timestamp, ErrNotFound)
+	}
+	if err != nil {
+		return Lineage{}, fmt.Errorf("failed to query point %s %s at %s: %w", source, code, timestamp, err)
+	}
+	if runID == nil {
+		return lineage, nil
+	}
+	point.RunID = *runID
+
+	run, err := scanRun(r.pool.QueryRow(ctx, `
+		SELECT `+runColumns+` FROM scrape_runs
+		WHERE run_id = $1`, point.RunID))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Lineage{}, fmt.Errorf("failed to query run %s: %w", point.RunID, err)
+	}
+	if err == nil {
+		lineage.Run = run
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT url, fetched_at, sha256 FROM scrape_payloads
+		WHERE run_id = $1
+		ORDER BY fetched_at, id`, point.RunID)
+	if err != nil {
+		return Lineage{}, fmt.Errorf("failed to query payloads of run %s: %w", point.RunID, err)
+	}
+	lineage.Payloads, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (PayloadRef, error) {
+		var payload PayloadRef
+		err := row.Scan(&payload.URL, &payload.FetchedAt, &payload.SHA256)
+		return payload, err
+	})
+	if err != nil {
+		return Lineage{}, fmt.Errorf("failed to read payloads of run %s: %w", point.RunID, err)
+	}
+	return lineage, nil
+}
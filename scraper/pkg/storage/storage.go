@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Storage persists and queries data points, it is implemented by PostgresRepository (with or
+// without TimescaleDB), ClickHouseRepository and InfluxRepository so the persister and readers
+// do not depend on a backend
+type Storage interface {
+	// WritePoints stores the points of a result, returning how many were written
+	WritePoints(ctx context.Context, result scraper.Result) (int, error)
+	// QueryRange returns the points of a series within [from, to) ordered by time
+	QueryRange(ctx context.Context, source, code string, from, to time.Time) ([]Point, error)
+	// Latest returns the latest point of a series, ErrNotFound when it has none
+	Latest(ctx context.Context, source, code string) (Point, error)
+	// ListSeries returns the series of a source ordered by code, or of every source when source is empty
+	ListSeries(ctx context.Context, source string) ([]Series, error)
+}
+
+// Pinger is implemented by the backends that can check the connection to their database
+type Pinger interface {
+	// Ping returns an error when the database is unreachable
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Storage = (*PostgresRepository)(nil)
+	_ Storage = (*ClickHouseRepository)(nil)
+	_ Storage = (*InfluxRepository)(nil)
+)
+
+// IsUnavailable reports whether err means the backend could not be reached, as opposed to
+// rejecting the data, so the write can succeed unchanged once the backend is back
+func IsUnavailable(err error) bool {
+	if errors.Is(err, errclass.ErrTransient) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	// Connection exceptions and server shutdowns
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || pgconn.SafeToRetry(err)
+}
+
+// Classify marks the errors of unavailable backends with errclass.ErrTransient, so consumers
+// retry the write and report the failure as transient. Other errors are returned unchanged
+func Classify(err error) error {
+	if err == nil || errors.Is(err, errclass.ErrTransient) || !IsUnavailable(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", errclass.ErrTransient, err)
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TimescaleOptions configures the TimescaleDB objects created for the data point table
+type TimescaleOptions struct {
+	// Enabled turns the data point table into a hypertable
+	Enabled bool
+	// ChunkInterval is the time range stored per chunk, defaults to 7 days
+	ChunkInterval time.Duration
+	// CompressAfter is the age after which chunks are compressed, defaults to 30 days,
+	// a negative value disables compression
+	CompressAfter time.Duration
+	// RetainFor is the age after which chunks are dropped, zero keeps every point
+	RetainFor time.Duration
+}
+
+// aggregates are the continuous aggregates maintained over the data points, refreshed over their lookback
+var aggregates = []struct {
+	view     string
+	bucket   time.Duration
+	lookback time.Duration
+}{
+	{view: "data_points_hourly", bucket: time.Hour, lookback: 3 * 24 * time.Hour},
+	{view: "data_points_daily", bucket: 24 * time.Hour, lookback: 30 * 24 * time.Hour},
+}
+
+// migrateTimescale creates the hypertable, its compression and retention policies and the
+// continuous aggregates. Every statement is idempotent so it runs on each start
+func migrateTimescale(ctx context.Context, pool *pgxpool.Pool, options TimescaleOptions) error {
+	if options.ChunkInterval <= 0 {
+		options.ChunkInterval = 7 * 24 * time.Hour
+	}
+	if options.CompressAfter == 0 {
+		options.CompressAfter = 30 * 24 * time.Hour
+	}
+
+	if _, err := pool.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+		return fmt.Errorf("failed to create timescaledb extension: %w", err)
+	}
+
+	_, err := pool.Exec(ctx, `
+		SELECT create_hypertable('data_points', 'time',
+			chunk_time_interval => $1::interval, if_not_exists => TRUE, migrate_data => TRUE)`,
+		options.ChunkInterval)
+	if err != nil {
+		return fmt.Errorf("failed to create data point hypertable: %w", err)
+	}
+
+	if options.CompressAfter > 0 {
+		if err := enableCompression(ctx, pool, options.CompressAfter); err != nil {
+			return err
+		}
+	}
+
+	if options.RetainFor > 0 {
+		_, err := pool.Exec(ctx, `SELECT add_retention_policy('data_points', $1::interval, if_not_exists => TRUE)`, options.RetainFor)
+		if err != nil {
+			return fmt.Errorf("failed to add data point retention policy: %w", err)
+		}
+	}
+
+	for _, aggregate := range aggregates {
+		_, err := pool.Exec(ctx, fmt.Sprintf(`
+			CREATE MATERIALIZED VIEW IF NOT EXISTS %s
+			WITH (timescaledb.continuous) AS
+			SELECT source, code, time_bucket(INTERVAL '%d seconds', time) AS bucket,
+				first(value, time) AS open, max(value) AS high, min(value) AS low,
+				last(value, time) AS close, avg(value) AS average, count(*) AS points
+			FROM data_points
+			GROUP BY source, code, bucket
+			WITH NO DATA`, aggregate.view, int64(aggregate.bucket.Seconds())))
+		if err != nil {
+			return fmt.Errorf("failed to create continuous aggregate %s: %w", aggregate.view, err)
+		}
+
+		_, err = pool.Exec(ctx, `
+			SELECT add_continuous_aggregate_policy($1::regclass,
+				start_offset => $2::interval, end_offset => $3::interval,
+				schedule_interval => $3::interval, if_not_exists => TRUE)`,
+			aggregate.view, aggregate.lookback, aggregate.bucket)
+		if err != nil {
+			return fmt.Errorf("failed to add refresh policy of %s: %w", aggregate.view, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Successfully migrated TimescaleDB objects",
+		"chunk_interval", options.ChunkInterval, "compress_after", options.CompressAfter, "retain_for", options.RetainFor)
+	return nil
+}
+
+// enableCompression compresses chunks older than after, segmented by series so a range query of
+// one series only decompresses its own rows
+func enableCompression(ctx context.Context, pool *pgxpool.Pool, after time.Duration) error {
+	// The compression settings cannot be changed once chunks are compressed, so they are only set once
+	var enabled bool
+	err := pool.QueryRow(ctx, `
+		SELECT compression_enabled FROM timescaledb_information.hypertables
+		WHERE hypertable_name = 'data_points'`).Scan(&enabled)
+	if err != nil {
+		return fmt.Errorf("failed to read data point compression state: %w", err)
+	}
+
+	if !enabled {
+		_, err := pool.Exec(ctx, `
+			ALTER TABLE data_points SET (
+				timescaledb.compress,
+				timescaledb.compress_segmentby = 'source, code',
+				timescaledb.compress_orderby = 'time DESC'
+			)`)
+		if err != nil {
+			return fmt.Errorf("failed to enable data point compression: %w", err)
+		}
+	}
+
+	_, err = pool.Exec(ctx, `SELECT add_compression_policy('data_points', $1::interval, if_not_exists => TRUE)`, after)
+	if err != nil {
+		return fmt.Errorf("failed to add data point compression policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// insertVintage records an observed value unless it equals the latest vintage of the point,
+// so re-scraping an unrevised series adds nothing
+const insertVintage = `
+INSERT INTO data_point_vintages (source, code, time, observed_at, value, unit, metadata, run_id)
+SELECT $1::text, $2::text, $3::timestamptz, $7::timestamptz, $4::float8, $5::text, $6::jsonb, NULLIF($8::text, '')
+WHERE NOT EXISTS (
+	SELECT 1 FROM (
+		SELECT value, unit, metadata FROM data_point_vintages
+		WHERE source = $1 AND code = $2 AND time = $3
+		ORDER BY observed_at DESC LIMIT 1
+	) latest
+	WHERE (latest.value, latest.unit, latest.metadata) IS NOT DISTINCT FROM ($4::float8, $5::text, $6::jsonb)
+)
+ON CONFLICT DO NOTHING`
+
+// Vintage is a value of a data point as it was observed at a given time
+type Vintage struct {
+	Point
+	ObservedAt time.Time
+}
+
+// PointsAsOf returns the points of a series within [from, to) as they were known at asOf,
+// ordered by time. Points first observed after asOf are left out. Vintages must be enabled
+func (r *PostgresRepository) PointsAsOf(ctx context.Context, source, code string, from, to, asOf time.Time) ([]Point, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (time) source, code, time, value, unit, metadata FROM data_point_vintages
+		WHERE source = $1 AND code = $2 AND time >= $3 AND time < $4 AND observed_at <= $5
+		ORDER BY time, observed_at DESC`, source, code, from, to, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points of %s %s as of %s: %w", source, code, asOf, err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Point, error) {
+		var point Point
+		err := row.Scan(&point.Source, &point.Code, &point.Timestamp, &point.Value, &point.Unit, &point.Metadata)
+		return point, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read points of %s %s as of %s: %w", source, code, asOf, err)
+	}
+	return points, nil
+}
+
+// Vintages returns every observed value of a data point ordered by observation time, the
+// revision history of e.g. a GDP release
+func (r *PostgresRepository) Vintages(ctx context.Context, source, code string, at time.Time) ([]Vintage, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source, code, time, value, unit, metadata, observed_at FROM data_point_vintages
+		WHERE source = $1 AND code = $2 AND time = $3
+		ORDER BY observed_at`, source, code, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vintages of %s %s: %w", source, code, err)
+	}
+
+	vintages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Vintage, error) {
+		var vintage Vintage
+		err := row.Scan(&vintage.Source, &vintage.Code, &vintage.Timestamp, &vintage.Value, &vintage.Unit, &vintage.Metadata, &vintage.ObservedAt)
+		return vintage, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vintages of %s %s: %w", source, code, err)
+	}
+	return vintages, nil
+}
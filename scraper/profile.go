@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// profiles are the named sets of defaults selected with PROFILE, values set in the config file,
+// the environment or the flags still take precedence. prod keeps the defaults, which use the
+// real endpoints of every source
+var profiles = map[string]map[string]any{
+	"dev": {
+		"LOG_LEVEL":             "debug",
+		"DB_HOST":               "localhost",
+		"SCRAPE_INTERVAL":       10,
+		"ENABLED_SCRAPERS":      []string{"mock"},
+		"CONFIG_WATCH_INTERVAL": 2,
+		"STREAMING_RESULT_TTL":  60,
+	},
+	"staging": {
+		"LOG_LEVEL":       "debug",
+		"SCRAPE_INTERVAL": 30,
+	},
+	"prod": {},
+}
+
+// applyProfile sets the defaults of the profile selected in v, if any
+func applyProfile(v *viper.Viper) error {
+	name := strings.ToLower(strings.TrimSpace(v.GetString("PROFILE")))
+	if name == "" {
+		return nil
+	}
+	defaults, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	return nil
+}
+
+// devProfile reports whether the dev profile is selected
+func (c *Config) devProfile() bool {
+	return strings.EqualFold(strings.TrimSpace(c.Profile), "dev")
+}
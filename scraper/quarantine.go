@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"macrochain/scraper/pkg/featureflag"
+	"macrochain/scraper/pkg/storage"
+)
+
+// runQuarantineListCommand runs the quarantine list subcommand, it prints the points held back
+// by the anomaly detector
+func runQuarantineListCommand(ctx context.Context, out io.Writer, config *Config, source, code string) error {
+	pool, err := newDBPool(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	anomalies, err := storage.NewPostgresRepository(pool, storage.PostgresOptions{}).QuarantinedPoints(ctx, source, code)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tCODE\tTIME\tVALUE\tPREVIOUS\tREASON\tSCORE\tRUN")
+	for _, anomaly := range anomalies {
+		point := anomaly.Point
+		previous := "-"
+		if !math.IsNaN(anomaly.Previous) {
+			previous = strconv.FormatFloat(anomaly.Previous, 'g', -1, 64)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%g\t%s\t%s\t%.4g\t%s\n", point.Source, point.Code, point.Timestamp.UTC().Format(time.RFC3339),
+			point.Value, previous, anomaly.Reason, anomaly.Score, point.RunID)
+	}
+	return w.Flush()
+}
+
+// runQuarantineReleaseCommand runs the quarantine release subcommand, it stores the quarantined
+// points of a source, or of one of its series, tagged as anomalous. With discard the points are
+// deleted instead
+func runQuarantineReleaseCommand(ctx context.Context, out io.Writer, config *Config, source, code string, discard bool) error {
+	store, closeStorage, err := newStorageBackend(ctx, config, featureflag.New(config.FeatureFlags))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	primary := store
+	if writer, ok := store.(*storage.DualWriter); ok {
+		primary = writer.Primary()
+	}
+	repository, ok := primary.(*storage.PostgresRepository)
+	if !ok {
+		return fmt.Errorf("quarantine needs the postgres or timescale storage backend")
+	}
+
+	anomalies, err := repository.QuarantinedPoints(ctx, source, code)
+	if err != nil {
+		return err
+	}
+	if discard {
+		points := make([]storage.Point, 0, len(anomalies))
+		for _, anomaly := range anomalies {
+			points = append(points, anomaly.Point)
+		}
+		if err := repository.Release(ctx, points); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Discarded %d quarantined points of %s\n", len(points), source)
+		return nil
+	}
+
+	written, err := storage.ReleaseQuarantined(ctx, store, repository, anomalies)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Released %d quarantined points of %s\n", written, source)
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"macrochain/scraper/pkg/metrics"
+	"macrochain/scraper/pkg/queue"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newQueue creates the queue backend selected in the configuration, it records its published,
+// consumed and dropped messages in recorder
+func newQueue(ctx context.Context, config *Config, recorder *queue.MetricsRecorder) (queue.Queue, error) {
+	codec, err := queue.NewCodec(config.QueueCodec, queue.Compression{
+		Algorithm: config.QueueCompression,
+		Threshold: config.QueueCompressionThreshold,
+		MaxSize:   config.QueueMaxDecompressedSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	redisConnection := newRedisConnection(config)
+
+	streamsOptions := queue.RedisStreamsOptions{
+		Group:      config.RedisStreamGroup,
+		Consumer:   config.RedisStreamConsumer,
+		MaxLen:     config.RedisStreamMaxLen,
+		ClaimIdle:  time.Duration(config.RedisStreamClaimIdle) * time.Second,
+		Codec:      codec,
+		Connection: redisConnection,
+		Metrics:    recorder,
+	}
+
+	switch config.QueueBackend {
+	case "redis":
+		return queue.NewRedisQueue(ctx, config.RedisHost, config.RedisPort, queue.RedisOptions{
+			Connection:    redisConnection,
+			Codec:         codec,
+			DurableTopics: config.RedisDurableTopics,
+			Durable:       streamsOptions,
+			LogPayloads:   config.LogPayloads,
+			Metrics:       recorder,
+		})
+	case "redis_streams":
+		return queue.NewRedisStreamsQueue(ctx, config.RedisHost, config.RedisPort, streamsOptions)
+	case "kafka":
+		return queue.NewKafkaQueue(ctx, config.KafkaBrokers, queue.KafkaOptions{
+			GroupID:           config.KafkaGroupID,
+			Partitions:        config.KafkaPartitions,
+			ReplicationFactor: config.KafkaReplicationFactor,
+			PartitionKey:      config.KafkaPartitionKey,
+			Codec:             codec,
+			Metrics:           recorder,
+		})
+	}
+	return nil, fmt.Errorf("unsupported queue backend %q", config.QueueBackend)
+}
+
+// newRedisConnection returns the connection settings of the Redis clients of the configuration
+func newRedisConnection(config *Config) queue.RedisConnection {
+	return queue.RedisConnection{
+		Username: config.RedisUsername,
+		Password: config.RedisPassword,
+		DB:       config.RedisDB,
+		TLS:      config.RedisTLS,
+		CAFile:   config.RedisTLSCA,
+		CertFile: config.RedisTLSCert,
+		KeyFile:  config.RedisTLSKey,
+
+		PoolSize:     config.RedisPoolSize,
+		MinIdleConns: config.RedisMinIdleConns,
+		DialTimeout:  time.Duration(config.RedisDialTimeout) * time.Millisecond,
+		ReadTimeout:  time.Duration(config.RedisReadTimeout) * time.Millisecond,
+		WriteTimeout: time.Duration(config.RedisWriteTimeout) * time.Millisecond,
+	}
+}
+
+// backlogTimeout bounds the backlog queries of a metrics scrape
+const backlogTimeout = 5 * time.Second
+
+// Backlog metrics of the topics
+var (
+	pendingDesc = prometheus.NewDesc("macrochain_queue_pending_messages",
+		"Messages delivered to the consumer group and not acknowledged yet", []string{"topic", "group"}, nil)
+	consumerLagDesc = prometheus.NewDesc("macrochain_queue_consumer_lag",
+		"Messages of the topic not delivered to the consumer group yet", []string{"topic", "group"}, nil)
+)
+
+// backlogCollector returns the pending messages and consumer lag of the topics matching pattern,
+// so a persister falling behind shows before the data it stores goes stale
+func backlogCollector(reporter queue.BacklogReporter, pattern string) metrics.CollectorFunc {
+	return func(ch chan<- prometheus.Metric) {
+		ctx, cancel := context.WithTimeout(context.Background(), backlogTimeout)
+		defer cancel()
+
+		backlog, err := reporter.Backlog(ctx, pattern)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read queue backlog", "pattern", pattern, "error", err)
+			return
+		}
+		for _, entry := range backlog {
+			// Backends that cannot tell report -1, a missing sample is not mistaken for an empty backlog
+			if entry.Pending >= 0 {
+				ch <- prometheus.MustNewConstMetric(pendingDesc, prometheus.GaugeValue, float64(entry.Pending), entry.Topic, entry.Group)
+			}
+			if entry.Lag >= 0 {
+				ch <- prometheus.MustNewConstMetric(consumerLagDesc, prometheus.GaugeValue, float64(entry.Lag), entry.Topic, entry.Group)
+			}
+		}
+	}
+}
+
+// Metrics of the messages by topic as recorded by the queue backend
+var (
+	publishedDesc = prometheus.NewDesc("macrochain_queue_published_total",
+		"Messages published to the topic", []string{"topic"}, nil)
+	publishErrorsDesc = prometheus.NewDesc("macrochain_queue_publish_errors_total",
+		"Messages the backend failed to publish to the topic", []string{"topic"}, nil)
+	publishSecondsDesc = prometheus.NewDesc("macrochain_queue_publish_seconds_total",
+		"Time the backend took to accept the messages published to the topic", []string{"topic"}, nil)
+	consumedDesc = prometheus.NewDesc("macrochain_queue_consumed_total",
+		"Messages of the topic handed to a subscriber", []string{"topic"}, nil)
+	droppedDesc = prometheus.NewDesc("macrochain_queue_dropped_total",
+		"Messages of the topic discarded before they reached a subscriber, by reason", []string{"topic", "reason"}, nil)
+	bufferedDesc = prometheus.NewDesc("macrochain_queue_buffered_messages",
+		"Messages waiting in the buffer of the subscription", []string{"topic"}, nil)
+)
+
+// queueMetricsCollector returns the messages published, consumed and dropped by topic as
+// recorded by the queue backend
+func queueMetricsCollector(recorder *queue.MetricsRecorder) metrics.CollectorFunc {
+	return func(ch chan<- prometheus.Metric) {
+		for topic, topicMetrics := range recorder.Snapshot() {
+			ch <- prometheus.MustNewConstMetric(publishedDesc, prometheus.CounterValue, float64(topicMetrics.Published), topic)
+			ch <- prometheus.MustNewConstMetric(publishErrorsDesc, prometheus.CounterValue, float64(topicMetrics.PublishErrors), topic)
+			ch <- prometheus.MustNewConstMetric(publishSecondsDesc, prometheus.CounterValue, topicMetrics.LatencySum.Seconds(), topic)
+			ch <- prometheus.MustNewConstMetric(consumedDesc, prometheus.CounterValue, float64(topicMetrics.Consumed), topic)
+			ch <- prometheus.MustNewConstMetric(bufferedDesc, prometheus.GaugeValue, float64(topicMetrics.Buffered), topic)
+			for reason, dropped := range topicMetrics.Dropped {
+				ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(dropped), topic, reason)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// quotaRegistry keeps the request quotas of the scrapers across reloads, so a reloaded
+// configuration changes their limits without resetting their usage
+type quotaRegistry struct {
+	mu     sync.Mutex
+	quotas map[string]*scraper.Quota
+	// store keeps the windows of the quotas, nil when they are kept in memory
+	store *scraper.RedisQuotaStore
+}
+
+// newQuotaRegistry creates a registry without quotas, keeping their windows in Redis when
+// QUOTA_REDIS is set
+func newQuotaRegistry(config *Config) (*quotaRegistry, error) {
+	registry := &quotaRegistry{quotas: make(map[string]*scraper.Quota)}
+	if !config.QuotaRedis {
+		return registry, nil
+	}
+	client, err := queue.NewRedisClient(config.RedisHost, config.RedisPort, newRedisConnection(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	registry.store = scraper.NewRedisQuotaStore(client, config.QuotaRedisPrefix+":")
+	return registry, nil
+}
+
+// Close closes the Redis client of the quotas, if any
+func (r *quotaRegistry) Close() error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.Close()
+}
+
+// quota returns the quota of the scraper of settings with its limits, the scrapers of a quota
+// group share one. Every scraper has a quota, so its requests are counted even without limits.
+// Scrapers whose API has a known quota get it unless their section sets limits
+func (r *quotaRegistry) quota(s scraper.Scraper, settings scraper.Settings) *scraper.Quota {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limits := settings.QuotaLimits()
+	if defaulter, ok := s.(scraper.QuotaDefaulter); ok && limits == (scraper.QuotaLimits{}) {
+		limits = defaulter.DefaultQuota()
+	}
+
+	group := cmp.Or(settings.QuotaGroup, s.Name())
+	quota, ok := r.quotas[group]
+	if !ok {
+		var store scraper.QuotaStore
+		if r.store != nil {
+			store = r.store
+		}
+		quota = scraper.NewQuota(group, limits, store)
+		r.quotas[group] = quota
+		return quota
+	}
+	// The limits of a group are set by the section of any of its scrapers, they should agree
+	if limits != (scraper.QuotaLimits{}) {
+		quota.SetLimits(limits)
+	}
+	return quota
+}
+
+// Request and quota metrics of the quotas
+var (
+	requestsDesc = prometheus.NewDesc("macrochain_source_requests_total",
+		"Requests sent to the API of the quota since the process started", []string{"quota"}, nil)
+	requestCostDesc = prometheus.NewDesc("macrochain_source_request_cost_total",
+		"Cost of the requests sent to the API of the quota since the process started", []string{"quota"}, nil)
+	quotaUsedDesc = prometheus.NewDesc("macrochain_source_quota_used",
+		"Requests counted in the current window of the quota", []string{"quota", "window"}, nil)
+	quotaLimitDesc = prometheus.NewDesc("macrochain_source_quota_limit",
+		"Requests allowed in a window of the quota", []string{"quota", "window"}, nil)
+)
+
+// collect sends the request and quota metrics of every quota
+func (r *quotaRegistry) collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	quotas := maps.Clone(r.quotas)
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for name, quota := range quotas {
+		usage, err := quota.Usage(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read quota usage", "quota", name, "error", err)
+		}
+		ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.CounterValue, float64(usage.Requests), name)
+		ch <- prometheus.MustNewConstMetric(requestCostDesc, prometheus.CounterValue, usage.Cost, name)
+		for _, window := range usage.Windows {
+			if window.Limit == 0 {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(quotaUsedDesc, prometheus.GaugeValue, float64(window.Used), name, window.Window)
+			ch <- prometheus.MustNewConstMetric(quotaLimitDesc, prometheus.GaugeValue, float64(window.Limit), name, window.Window)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the value of a secret option
+const redacted = "********"
+
+// sensitiveWords mark the options holding secrets by a word of their key, e.g. S3_SECRET_KEY
+var sensitiveWords = []string{"PASSWORD", "TOKEN", "SECRET"}
+
+// credentialURLKeys are the URL options whose path usually embeds an API key, e.g. Infura or The
+// Graph gateway URLs, or a token like Slack webhooks
+var credentialURLKeys = []string{"ETH_RPC_URL", "BITCOIN_RPC_URL", "UNISWAP_SUBGRAPH_URL", "ALERT_SLACK_WEBHOOK_URL", "ALERT_WEBHOOK_URL", "HEARTBEAT_PING_URL"}
+
+// sensitive reports whether the option of key holds a secret, every value of the API key registry
+// does, as well as the options named like a password, token, secret or API or access key
+func sensitive(key string) bool {
+	key = strings.ToUpper(key)
+	if strings.HasPrefix(key, "API_KEYS.") {
+		return true
+	}
+	words := strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '.' })
+	for i, word := range words {
+		if slices.Contains(sensitiveWords, word) {
+			return true
+		}
+		if word == "KEY" && i > 0 && (words[i-1] == "API" || words[i-1] == "ACCESS") {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL masks the password of a URL and, for the options of credentialURLKeys, its path and
+// query. Values that are not URLs are returned as is
+func redactURL(key, value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return value
+	}
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	if slices.Contains(credentialURLKeys, strings.ToUpper(key)) {
+		if strings.Trim(parsed.Path, "/") != "" {
+			parsed.Path = "/" + redacted
+		}
+		if parsed.RawQuery != "" {
+			parsed.RawQuery = redacted
+		}
+	}
+	// Undo the escaping of the mask so it reads the same as the other redacted values
+	unescaped, err := url.PathUnescape(parsed.String())
+	if err != nil {
+		return parsed.String()
+	}
+	return unescaped
+}
+
+// redactedConfig returns the options of the configuration by key with the secrets masked, scraper
+// sections and credentials are nested by name
+func redactedConfig(config *Config) map[string]any {
+	return redactedStruct("", reflect.ValueOf(*config))
+}
+
+// redactedStruct returns the fields of a struct with a mapstructure tag by key, keys are prefixed
+// with the key of the parent to decide whether they are sensitive
+func redactedStruct(parent string, value reflect.Value) map[string]any {
+	fields := make(map[string]any)
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		fields[key] = redactedValue(parent+key, value.Field(i))
+	}
+	return fields
+}
+
+// redactedValue returns a value of the configuration with the secrets masked
+func redactedValue(key string, value reflect.Value) any {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		return redactedStruct(key+".", value)
+	case reflect.Map:
+		entries := make(map[string]any, value.Len())
+		for _, name := range value.MapKeys() {
+			entries[name.String()] = redactedValue(key+"."+name.String(), value.MapIndex(name))
+		}
+		return entries
+	case reflect.Slice:
+		items := make([]any, value.Len())
+		for i := range value.Len() {
+			items[i] = redactedValue(key, value.Index(i))
+		}
+		return items
+	}
+
+	if sensitive(key) && !value.IsZero() {
+		return redacted
+	}
+	if value.Kind() == reflect.String {
+		return redactURL(key, value.String())
+	}
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	return value.Interface()
+}
+
+// runConfigShowCommand runs the config show subcommand, it prints the effective configuration
+// with the secrets masked as yaml or json
+func runConfigShowCommand(out io.Writer, config *Config, format string) error {
+	values := redactedConfig(config)
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(out)
+		defer encoder.Close()
+		return encoder.Encode(values)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	}
+	return fmt.Errorf("unsupported format %q, expected yaml or json", format)
+}
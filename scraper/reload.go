@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+)
+
+// reloadableKeys are the options applied without a restart, the schedule fields of the scraper
+// sections are reloadable as well
+var reloadableKeys = []string{"LOG_LEVEL", "SCRAPE_INTERVAL", "ENABLED_SCRAPERS", "FEATURE_FLAGS", "QUOTA_DEFER_THRESHOLD"}
+
+// reloadableSettings are the fields of a scraper section applied without a restart
+var reloadableSettings = []string{"enabled", "interval", "cron", "rate_limit", "burst", "proxy", "flag", "max_age",
+	"requests_per_minute", "requests_per_day", "requests_per_month", "cost_per_request", "quota_group"}
+
+// configChange is an option whose value differs between two configurations
+type configChange struct {
+	key      string
+	from, to any
+}
+
+// reloadable reports whether the change is applied without a restart
+func (c configChange) reloadable() bool {
+	if slices.Contains(reloadableKeys, c.key) {
+		return true
+	}
+	section, ok := strings.CutPrefix(c.key, "SCRAPERS.")
+	if !ok {
+		return false
+	}
+	_, field, _ := strings.Cut(section, ".")
+	return slices.Contains(reloadableSettings, field)
+}
+
+// configChanges returns the options that differ between two configurations, named by their
+// keys. Scraper sections are compared field by field
+func configChanges(old, updated *Config) []configChange {
+	var changes []configChange
+	oldValue, newValue := reflect.ValueOf(*old), reflect.ValueOf(*updated)
+	for i := range oldValue.NumField() {
+		field := oldValue.Type().Field(i)
+		key := field.Tag.Get("mapstructure")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		if key == "SCRAPERS" {
+			changes = append(changes, sectionChanges(old.Scrapers, updated.Scrapers)...)
+			continue
+		}
+		from, to := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, configChange{key: key, from: from, to: to})
+		}
+	}
+	return changes
+}
+
+// sectionChanges returns the fields that differ between the scraper sections of two configurations
+func sectionChanges(old, updated map[string]scraper.Settings) []configChange {
+	names := make(map[string]scraper.Settings, len(old)+len(updated))
+	maps.Copy(names, old)
+	maps.Copy(names, updated)
+
+	var changes []configChange
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		oldValue, newValue := reflect.ValueOf(old[name]), reflect.ValueOf(updated[name])
+		for i := range oldValue.NumField() {
+			from, to := indirect(oldValue.Field(i)), indirect(newValue.Field(i))
+			if !reflect.DeepEqual(from, to) {
+				key := "SCRAPERS." + name + "." + oldValue.Type().Field(i).Tag.Get("mapstructure")
+				changes = append(changes, configChange{key: key, from: from, to: to})
+			}
+		}
+	}
+	return changes
+}
+
+// indirect returns the value a pointer points to, nil for a nil pointer
+func indirect(value reflect.Value) any {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	return value.Interface()
+}
+
+// watchConfig reloads the configuration with load on SIGHUP, whenever remote signals a change of
+// the remote configuration and, when path is set, whenever the config file is modified, checking
+// it each interval. Every change is logged, the values of the options that
+// need a restart are left out as they may be secrets. The log level is applied here and the
+// reloaded configuration is passed to apply, if set. An invalid configuration is not applied
+func watchConfig(ctx context.Context, path string, interval time.Duration, remote <-chan struct{}, config *Config, load func() (*Config, error), apply func(*Config)) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	defer signal.Stop(hangup)
+
+	var modified time.Time
+	var tick <-chan time.Time
+	if path != "" && interval > 0 {
+		if info, err := os.Stat(path); err == nil {
+			modified = info.ModTime()
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-hangup:
+			slog.InfoContext(ctx, "Reloading configuration on SIGHUP")
+		case <-tick:
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(modified) {
+				continue
+			}
+			modified = info.ModTime()
+			slog.InfoContext(ctx, "Reloading modified configuration file", "path", path)
+		case <-remote:
+			slog.InfoContext(ctx, "Reloading modified remote configuration")
+		case <-ctx.Done():
+			return
+		}
+
+		updated, err := load()
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to reload configuration, keeping the current one", "error", err)
+			continue
+		}
+
+		changes := configChanges(config, updated)
+		for _, change := range changes {
+			if change.reloadable() {
+				slog.InfoContext(ctx, "Configuration changed", "key", change.key, "from", fmt.Sprint(change.from), "to", fmt.Sprint(change.to))
+			} else {
+				slog.WarnContext(ctx, "Configuration change needs a restart to apply", "key", change.key)
+			}
+		}
+		if len(changes) == 0 {
+			slog.InfoContext(ctx, "Configuration unchanged")
+			continue
+		}
+
+		setLogLevel(updated.LogLevel)
+		if apply != nil {
+			apply(updated)
+		}
+		config = updated
+	}
+}
+
+// reloadScrapers applies the enabled scrapers and schedules of a reloaded configuration. Scrapers
+// that stay enabled keep running with their state, new ones are initialized, and the next run of
+// the ones whose schedule changed follows the new schedule. It returns the scrapers and their plans
+func reloadScrapers(ctx context.Context, old, updated *Config, current []scraper.Scraper, nextRun map[string]time.Time, catalog *storage.PostgresRepository, quotas *quotaRegistry) ([]scraper.Scraper, map[string]scraperPlan, error) {
+	built, err := buildScrapers(updated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	running := make(map[string]scraper.Scraper, len(current))
+	for _, s := range current {
+		running[s.Name()] = s
+	}
+	var scrapers, added []scraper.Scraper
+	for _, s := range built {
+		if existing, ok := running[s.Name()]; ok {
+			scrapers = append(scrapers, existing)
+		} else {
+			added = append(added, s)
+		}
+	}
+	added = initScrapers(ctx, added, catalog)
+	scrapers = append(scrapers, added...)
+
+	plans, err := planScrapers(updated, scrapers, quotas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	for _, s := range scrapers {
+		name := s.Name()
+		if _, ok := running[name]; !ok {
+			nextRun[name] = plans[name].schedule.Start(now)
+			continue
+		}
+		before, after := old.Scrapers[name], updated.Scrapers[name]
+		if before.Interval != after.Interval || before.Cron != after.Cron {
+			nextRun[name] = plans[name].schedule.Next(now)
+		}
+	}
+	for name := range running {
+		if _, ok := plans[name]; !ok {
+			delete(nextRun, name)
+		}
+	}
+	return scrapers, plans, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"macrochain/scraper/pkg/remoteconfig"
+
+	"github.com/spf13/viper"
+)
+
+// newRemoteViper creates a viper reading the document of the remote store selected in v with its
+// remote provider
+func newRemoteViper(v *viper.Viper) (*viper.Viper, error) {
+	provider, err := remoteconfig.ViperProvider(v.GetString("REMOTE_CONFIG_STORE"))
+	if err != nil {
+		return nil, err
+	}
+	remoteconfig.Configure(remoteconfig.Options{
+		Token:    v.GetString("REMOTE_CONFIG_TOKEN"),
+		Interval: time.Duration(v.GetInt("REMOTE_CONFIG_WATCH_INTERVAL")) * time.Second,
+	})
+
+	remote := viper.New()
+	remote.SetConfigType(v.GetString("REMOTE_CONFIG_FORMAT"))
+	if err := remote.AddRemoteProvider(provider, v.GetString("REMOTE_CONFIG_ADDRESS"), v.GetString("REMOTE_CONFIG_KEY")); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// readRemoteConfig reads the shared configuration of the remote store selected in v over its
+// config file, the environment and flags of an instance still override it. It returns the
+// settings read, nil without a remote store. An unreachable store is logged and the instance
+// starts with its local configuration, the watcher reloads it once the store is back
+func readRemoteConfig(v *viper.Viper) (map[string]any, error) {
+	if v.GetString("REMOTE_CONFIG_STORE") == "" {
+		return nil, nil
+	}
+	remote, err := newRemoteViper(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := remote.ReadRemoteConfig(); err != nil {
+		slog.Warn("Remote configuration unavailable, using the local configuration",
+			"store", v.GetString("REMOTE_CONFIG_STORE"), "key", v.GetString("REMOTE_CONFIG_KEY"))
+		return nil, nil
+	}
+
+	settings := remote.AllSettings()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// watchRemoteConfig signals changed whenever the settings of the remote configuration differ from
+// the ones read, checking them each REMOTE_CONFIG_WATCH_INTERVAL. It returns nil without a remote
+// store or watch interval
+func watchRemoteConfig(ctx context.Context, config *Config) (<-chan struct{}, error) {
+	if config.RemoteConfigStore == "" || config.RemoteConfigWatchInterval <= 0 {
+		return nil, nil
+	}
+	v := viper.New()
+	v.Set("REMOTE_CONFIG_STORE", config.RemoteConfigStore)
+	v.Set("REMOTE_CONFIG_ADDRESS", config.RemoteConfigAddress)
+	v.Set("REMOTE_CONFIG_KEY", config.RemoteConfigKey)
+	v.Set("REMOTE_CONFIG_FORMAT", config.RemoteConfigFormat)
+	v.Set("REMOTE_CONFIG_TOKEN", config.RemoteConfigToken)
+	v.Set("REMOTE_CONFIG_WATCH_INTERVAL", config.RemoteConfigWatchInterval)
+
+	changed := make(chan struct{}, 1)
+	go func() {
+		slog.InfoContext(ctx, "Remote configuration watcher started", "key", config.RemoteConfigKey, "interval", config.RemoteConfigWatchInterval)
+		previous := config.remote
+		for {
+			// A fresh viper forgets the keys removed from the document
+			remote, err := newRemoteViper(v)
+			if err != nil {
+				slog.ErrorContext(ctx, "Remote configuration watcher failed", "error", err)
+				return
+			}
+			err = remote.WatchRemoteConfig()
+			if ctx.Err() != nil {
+				slog.InfoContext(context.Background(), "Remote configuration watcher stopped")
+				return
+			}
+			if err != nil {
+				// The failure was logged by the store, the next check comes after the interval
+				continue
+			}
+			if settings := remote.AllSettings(); !reflect.DeepEqual(settings, previous) {
+				previous = settings
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changed, nil
+}
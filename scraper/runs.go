@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scraperRuns is the outcome of the last runs of a scraper
+type scraperRuns struct {
+	// LastRun is the end of the last run, zero before the first one
+	LastRun time.Time
+	// LastSuccess is the end of the last successful run, zero before the first one
+	LastSuccess time.Time
+	// LastStatus, LastError and LastErrorClass are the outcome of the last run
+	LastStatus     string
+	LastError      string
+	LastErrorClass string
+	// LastItems is the number of points of the last run
+	LastItems int
+	// ConsecutiveFailures is the number of failed runs since the last success
+	ConsecutiveFailures int
+	// Failures counts the failed runs since the process started by error class
+	Failures map[string]int
+}
+
+// runTracker keeps the outcome of the runs of every scraper in memory, for the metrics
+type runTracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	scrapers map[string]*scraperRuns
+}
+
+// newRunTracker creates a tracker without runs, scrapers count as stale from now on
+func newRunTracker() *runTracker {
+	return &runTracker{started: time.Now(), scrapers: make(map[string]*scraperRuns)}
+}
+
+// track adds scrapers that have not run yet, so their staleness is reported before their first run
+func (t *runTracker) track(names ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, name := range names {
+		if _, ok := t.scrapers[name]; !ok {
+			t.scrapers[name] = &scraperRuns{}
+		}
+	}
+}
+
+// record updates the outcome of the scraper of a finished run
+func (t *runTracker) record(run storage.Run) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	runs, ok := t.scrapers[run.Source]
+	if !ok {
+		runs = &scraperRuns{}
+		t.scrapers[run.Source] = runs
+	}
+	runs.LastRun = run.FinishedAt
+	runs.LastStatus = run.Status
+	runs.LastError = run.Error
+	runs.LastErrorClass = run.ErrorClass
+	runs.LastItems = run.Points
+	if run.Status == storage.RunSucceeded {
+		runs.LastSuccess = run.FinishedAt
+		runs.ConsecutiveFailures = 0
+	} else {
+		runs.ConsecutiveFailures++
+		if runs.Failures == nil {
+			runs.Failures = make(map[string]int)
+		}
+		runs.Failures[cmp.Or(run.ErrorClass, errclass.Unknown)]++
+	}
+}
+
+// snapshot returns a copy of the outcome of the runs by scraper name
+func (t *runTracker) snapshot() map[string]scraperRuns {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot := make(map[string]scraperRuns, len(t.scrapers))
+	for name, runs := range t.scrapers {
+		copied := *runs
+		copied.Failures = maps.Clone(runs.Failures)
+		snapshot[name] = copied
+	}
+	return snapshot
+}
+
+// sinceSuccess returns how long ago the last successful run of runs ended, or since the tracker
+// started without one
+func (t *runTracker) sinceSuccess(runs scraperRuns, now time.Time) time.Duration {
+	if runs.LastSuccess.IsZero() {
+		return now.Sub(t.started)
+	}
+	return now.Sub(runs.LastSuccess)
+}
+
+// Freshness metrics of the scrapers
+var (
+	sinceSuccessDesc = prometheus.NewDesc("macrochain_scraper_seconds_since_success",
+		"Seconds since the last successful run of the scraper, or since the process started without one", []string{"scraper"}, nil)
+	lastRunItemsDesc = prometheus.NewDesc("macrochain_scraper_last_run_items",
+		"Data points produced by the last run of the scraper", []string{"scraper"}, nil)
+	lastRunSuccessDesc = prometheus.NewDesc("macrochain_scraper_last_run_success",
+		"Whether the last run of the scraper succeeded", []string{"scraper"}, nil)
+	consecutiveFailuresDesc = prometheus.NewDesc("macrochain_scraper_consecutive_failures",
+		"Failed runs of the scraper since its last success", []string{"scraper"}, nil)
+	failuresDesc = prometheus.NewDesc("macrochain_scraper_failures_total",
+		"Failed runs of the scraper since the process started by error class", []string{"scraper", "class"}, nil)
+)
+
+// collect sends the freshness metrics of every scraper
+func (t *runTracker) collect(ch chan<- prometheus.Metric) {
+	snapshot, now := t.snapshot(), time.Now()
+	for name, runs := range snapshot {
+		ch <- prometheus.MustNewConstMetric(sinceSuccessDesc, prometheus.GaugeValue, t.sinceSuccess(runs, now).Seconds(), name)
+		if runs.LastRun.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(lastRunItemsDesc, prometheus.GaugeValue, float64(runs.LastItems), name)
+		succeeded := 0.0
+		if runs.LastStatus == storage.RunSucceeded {
+			succeeded = 1
+		}
+		ch <- prometheus.MustNewConstMetric(lastRunSuccessDesc, prometheus.GaugeValue, succeeded, name)
+		ch <- prometheus.MustNewConstMetric(consecutiveFailuresDesc, prometheus.GaugeValue, float64(runs.ConsecutiveFailures), name)
+		for class, failures := range runs.Failures {
+			ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.CounterValue, float64(failures), name, class)
+		}
+	}
+}
+
+// runRunsCommand runs the runs subcommand, it prints the recorded runs matching filter, the latest first
+func runRunsCommand(ctx context.Context, out io.Writer, config *Config, filter storage.RunFilter) error {
+	pool, err := newDBPool(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	runs, err := storage.NewPostgresRepository(pool, storage.PostgresOptions{}).Runs(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tSCRAPER\tSTATUS\tDURATION\tPOINTS\tBYTES\tRUN\tCLASS\tERROR")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", run.StartedAt.UTC().Format(time.RFC3339), run.Source, run.Status,
+			run.Duration().Round(time.Millisecond), run.Points, run.BytesFetched, run.ID, cmp.Or(run.ErrorClass, "-"), run.Error)
+	}
+	return w.Flush()
+}
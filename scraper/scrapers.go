@@ -0,0 +1,530 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/logging"
+	"macrochain/scraper/pkg/persister"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/time/rate"
+)
+
+// buildScrapers creates the scrapers enabled in the configuration, the options of disabled
+// scrapers are not parsed so a bad value in an unused section does not stop startup
+func buildScrapers(config *Config) ([]scraper.Scraper, error) {
+	var enabled []scraper.Scraper
+	for _, factory := range scraperFactories(config) {
+		settings := config.Scrapers[factory.name].WithCredential(config.APIKeys[factory.name])
+		if !settings.IsEnabled(slices.Contains(config.EnabledScrapers, factory.name)) {
+			continue
+		}
+		s, err := factory.build(settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s: %w", factory.name, err)
+		}
+		enabled = append(enabled, s)
+	}
+	return enabled, nil
+}
+
+// scraperFactory builds a scraper from its configuration section, name is the name of the scraper
+type scraperFactory struct {
+	name  string
+	build func(settings scraper.Settings) (scraper.Scraper, error)
+}
+
+// newFactory returns the factory of the scrapers built by build, named by their Name so the
+// configuration sections cannot drift from the scrapers
+func newFactory[S scraper.Scraper](build func(settings scraper.Settings) (S, error)) scraperFactory {
+	return scraperFactory{
+		name: nameOf[S](),
+		build: func(settings scraper.Settings) (scraper.Scraper, error) {
+			return build(settings)
+		},
+	}
+}
+
+// nameOf returns the name of the scrapers of type S, read from a nil scraper since names are
+// constants
+func nameOf[S interface{ Name() string }]() string {
+	var s S
+	return s.Name()
+}
+
+// scraperFactories returns the factories of every polling scraper, the scraper options of the
+// configuration are only parsed when a scraper is built
+func scraperFactories(config *Config) []scraperFactory {
+	derivativesVenues := scraper.DerivativesVenues{
+		BinanceURL: config.BinanceFuturesAPIURL,
+		BybitURL:   config.BybitAPIURL,
+		OKXURL:     config.OKXAPIURL,
+		DeribitURL: config.DeribitAPIURL,
+	}
+
+	// Every scraper is built from its configuration section, which may override its URL and API key
+	factories := []scraperFactory{
+		newFactory(func(s scraper.Settings) (*scraper.SNBScraper, error) {
+			return scraper.NewSNBScraper(s.URLOr(scraper.DefaultSNBRSSURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.EthereumScraper, error) {
+			return scraper.NewEthereumScraper(s.URLOr(config.EthRPCURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.StablecoinScraper, error) {
+			stablecoins, err := scraper.ParseContracts(config.StablecoinContracts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stablecoin contracts: %w", err)
+			}
+			return scraper.NewStablecoinScraper(s.URLOr(config.EthRPCURL), stablecoins), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.ContractLogScraper, error) {
+			eventFilters, err := scraper.ParseEventFilters(config.ContractLogFilters)
+			if err != nil {
+				return nil, fmt.Errorf("invalid contract log filters: %w", err)
+			}
+			return scraper.NewContractLogScraper(s.URLOr(config.EthRPCURL), eventFilters, config.ContractLogStartBlock), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.MempoolScraper, error) {
+			return scraper.NewMempoolScraper(s.URLOr(config.EthRPCURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.BeaconScraper, error) {
+			return scraper.NewBeaconScraper(s.URLOr(config.BeaconchainAPIURL), s.APIKeyOr(config.BeaconchainAPIKey)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.BitcoinScraper, error) {
+			return scraper.NewBitcoinScraper(s.URLOr(config.BitcoinRPCURL), config.BitcoinRPCUser, config.BitcoinRPCPassword), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.MempoolSpaceScraper, error) {
+			return scraper.NewMempoolSpaceScraper(s.URLOr(config.MempoolSpaceAPIURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.DefiLlamaScraper, error) {
+			return scraper.NewDefiLlamaScraper(s.URLOr(config.DefiLlamaAPIURL), config.DefiLlamaProtocols), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.UniswapScraper, error) {
+			return scraper.NewUniswapScraper(s.URLOr(config.UniswapSubgraphURL), config.UniswapPools), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.AaveScraper, error) {
+			aaveReserves, err := scraper.ParseContracts(config.AaveReserves)
+			if err != nil {
+				return nil, fmt.Errorf("invalid aave reserves: %w", err)
+			}
+			return scraper.NewAaveScraper(s.URLOr(config.EthRPCURL), common.HexToAddress(config.AaveDataProvider), aaveReserves), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.CompoundScraper, error) {
+			compoundMarkets, err := scraper.ParseContracts(config.CompoundMarkets)
+			if err != nil {
+				return nil, fmt.Errorf("invalid compound markets: %w", err)
+			}
+			return scraper.NewCompoundScraper(s.URLOr(config.EthRPCURL), compoundMarkets), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.CurveScraper, error) {
+			curvePools, err := scraper.ParseContracts(config.CurvePools)
+			if err != nil {
+				return nil, fmt.Errorf("invalid curve pools: %w", err)
+			}
+			return scraper.NewCurveScraper(s.URLOr(config.EthRPCURL), curvePools), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.LidoScraper, error) {
+			return scraper.NewLidoScraper(config.EthRPCURL, s.URLOr(config.LidoAPIURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.MakerScraper, error) {
+			makerVaultTypes, err := scraper.ParseContracts(config.MakerVaultTypes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maker vault types: %w", err)
+			}
+			return scraper.NewMakerScraper(s.URLOr(config.EthRPCURL), makerVaultTypes), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.EtherscanScraper, error) {
+			return scraper.NewEtherscanScraper(s.URLOr(config.EtherscanAPIURL), s.APIKeyOr(config.EtherscanAPIKey)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.ChainlinkScraper, error) {
+			chainlinkFeeds, err := scraper.ParseContracts(config.ChainlinkFeeds)
+			if err != nil {
+				return nil, fmt.Errorf("invalid chainlink feeds: %w", err)
+			}
+			return scraper.NewChainlinkScraper(s.URLOr(config.EthRPCURL), chainlinkFeeds), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.CoinGeckoScraper, error) {
+			return scraper.NewCoinGeckoScraper(s.APIKeyOr(config.CoinGeckoAPIKey), config.CoinGeckoPro, config.CoinGeckoCoins, config.CoinGeckoMonthlyBudget), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.BinanceScraper, error) {
+			return scraper.NewBinanceScraper(s.URLOr(config.BinanceAPIURL), config.BinanceSymbols), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.CoinbaseScraper, error) {
+			return scraper.NewCoinbaseScraper(s.URLOr(config.CoinbaseAPIURL), config.CoinbaseProducts, config.CoinbaseCandleGranularity), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.KrakenScraper, error) {
+			return scraper.NewKrakenScraper(s.URLOr(config.KrakenAPIURL), config.KrakenPairs), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.FundingScraper, error) {
+			return scraper.NewFundingScraper(derivativesVenues, config.DerivativesAssets), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.OpenInterestScraper, error) {
+			return scraper.NewOpenInterestScraper(derivativesVenues, config.DerivativesAssets), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.L2Scraper, error) {
+			l2Chains, err := scraper.ParseL2Chains(config.L2Chains)
+			if err != nil {
+				return nil, fmt.Errorf("invalid l2 chains: %w", err)
+			}
+			return scraper.NewL2Scraper(l2Chains), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.EquityScraper, error) {
+			equityIndices, err := scraper.ParseEquityIndices(config.EquityIndices)
+			if err != nil {
+				return nil, fmt.Errorf("invalid equity indices: %w", err)
+			}
+			return scraper.NewEquityScraper(s.URLOr(config.YahooFinanceAPIURL), equityIndices), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.VIXScraper, error) {
+			return scraper.NewVIXScraper(s.URLOr(config.VIXHistoryURL)), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.LBMAScraper, error) {
+			return scraper.NewLBMAScraper(s.URLOr(config.LBMAAPIURL), config.FrankfurterAPIURL), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.EIAScraper, error) {
+			eiaSeries, err := scraper.ParseEIASeries(config.EIASeries)
+			if err != nil {
+				return nil, fmt.Errorf("invalid eia series: %w", err)
+			}
+			return scraper.NewEIAScraper(s.URLOr(config.EIAAPIURL), s.APIKeyOr(config.EIAAPIKey), eiaSeries), nil
+		}),
+		newFactory(func(s scraper.Settings) (*scraper.FXScraper, error) {
+			return scraper.NewFXScraper(s.URLOr(config.FrankfurterAPIURL), config.FXBase, config.FXSymbols), nil
+		}),
+	}
+	// The mock scraper generates fake data for local development only
+	if config.devProfile() {
+		factories = append(factories, newFactory(func(s scraper.Settings) (*scraper.MockScraper, error) {
+			return scraper.NewMockScraper(config.MockSeries), nil
+		}))
+	}
+	return factories
+}
+
+// scraperNames returns the names of scrapers
+func scraperNames(scrapers []scraper.Scraper) []string {
+	names := make([]string, len(scrapers))
+	for i, s := range scrapers {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// proxyConfig returns the proxy configuration of the scrapers, the proxy environment variables
+// with the options of the configuration taking precedence
+func proxyConfig(config *Config) httpproxy.Config {
+	proxy := *httpproxy.FromEnvironment()
+	if config.HTTPProxy != "" {
+		proxy.HTTPProxy = config.HTTPProxy
+	}
+	if config.HTTPSProxy != "" {
+		proxy.HTTPSProxy = config.HTTPSProxy
+	}
+	if config.NoProxy != "" {
+		proxy.NoProxy = config.NoProxy
+	}
+	return proxy
+}
+
+// scraperPlan is the schedule, rate limiter, request quota, proxy and feature flag of a scraper
+// from its configuration section
+type scraperPlan struct {
+	schedule *scraper.Schedule
+	limiter  *rate.Limiter
+	quota    *scraper.Quota
+	proxy    *scraper.Proxy
+	// flag gates the runs of the scraper, empty when it always runs
+	flag string
+}
+
+// planScrapers returns the plans of scrapers by name, their quotas are kept in quotas
+func planScrapers(config *Config, scrapers []scraper.Scraper, quotas *quotaRegistry) (map[string]scraperPlan, error) {
+	plans := make(map[string]scraperPlan, len(scrapers))
+	for _, s := range scrapers {
+		settings := config.Scrapers[s.Name()]
+		schedule, err := scraper.NewSchedule(settings, s.Schedule())
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule of %s: %w", s.Name(), err)
+		}
+		proxy, err := settings.ProxyOverride()
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy of %s: %w", s.Name(), err)
+		}
+		plans[s.Name()] = scraperPlan{
+			schedule: schedule,
+			limiter:  settings.Limiter(),
+			quota:    quotas.quota(s, settings),
+			proxy:    proxy,
+			flag:     settings.Flag,
+		}
+	}
+	return plans, nil
+}
+
+// streamingScraperNames are the names of the streaming scrapers
+var streamingScraperNames = []string{nameOf[*scraper.BinanceStreamScraper]()}
+
+// buildStreamingScrapers creates all streaming scrapers enabled in the configuration
+func buildStreamingScrapers(config *Config) []scraper.StreamingScraper {
+	var enabled []scraper.StreamingScraper
+	name := nameOf[*scraper.BinanceStreamScraper]()
+	if settings := config.Scrapers[name]; settings.IsEnabled(slices.Contains(config.EnabledScrapers, name)) {
+		enabled = append(enabled, scraper.NewBinanceStreamScraper(settings.URLOr(config.BinanceStreamURL), config.BinanceStreams))
+	}
+	return enabled
+}
+
+// initScrapers validates and initializes scrapers, skipping the ones that fail. The series of
+// cataloged scrapers are registered in catalog unless it is nil
+func initScrapers(ctx context.Context, scrapers []scraper.Scraper, catalog *storage.PostgresRepository) []scraper.Scraper {
+	var ready []scraper.Scraper
+	for _, s := range scrapers {
+		checkCredential(ctx, s)
+		if err := s.Validate(ctx); err != nil {
+			slog.ErrorContext(ctx, "Invalid scraper configuration", "scraper", s.Name(), "error", err)
+			continue
+		}
+		if err := s.Init(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to initialize scraper", "scraper", s.Name(), "error", err)
+			continue
+		}
+		if cataloged, ok := s.(scraper.Cataloged); ok && catalog != nil {
+			if err := catalog.RegisterSeries(ctx, s.Name(), cataloged.Series()); err != nil {
+				slog.ErrorContext(ctx, "Failed to register series", "scraper", s.Name(), "error", err)
+			}
+		}
+		ready = append(ready, s)
+	}
+	return ready
+}
+
+// registerCatalog registers the series of the enabled cataloged scrapers, so the persister owning
+// the database describes them even when the scrapers run without one
+func registerCatalog(ctx context.Context, config *Config, catalog *storage.PostgresRepository) error {
+	scrapers, err := buildScrapers(config)
+	if err != nil {
+		return err
+	}
+	for _, s := range scrapers {
+		cataloged, ok := s.(scraper.Cataloged)
+		if !ok {
+			continue
+		}
+		if err := catalog.RegisterSeries(ctx, s.Name(), cataloged.Series()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCredential reports a scraper without the API key it needs, or runs with lower limits without
+func checkCredential(ctx context.Context, s scraper.Scraper) {
+	credentialed, ok := s.(scraper.Credentialed)
+	if !ok || credentialed.HasAPIKey() {
+		return
+	}
+	if credentialed.RequiresAPIKey() {
+		slog.WarnContext(ctx, "Scraper is missing its API key, set it in API_KEYS", "scraper", s.Name())
+	} else {
+		slog.InfoContext(ctx, "Scraper runs without an API key, set one in API_KEYS for higher limits", "scraper", s.Name())
+	}
+}
+
+// runScraper performs a single scrape and passes the results through the pipeline. The run gets
+// an ID that is carried by its results, payloads and log records, so stored values and logs can be
+// traced back to it
+func runScraper(ctx context.Context, p *pipeline, s scraper.Scraper) error {
+	run := storage.Run{ID: uuid.New().String(), Source: s.Name(), StartedAt: time.Now()}
+	ctx = logging.With(ctx, "scraper", s.Name(), "run", run.ID)
+	ctx, span := tracer.Start(ctx, "scrape "+s.Name(), trace.WithAttributes(
+		attribute.String("scraper", s.Name()),
+		attribute.String("run.id", run.ID),
+	))
+	err := scrapeRun(ctx, p, s, &run)
+	span.SetAttributes(attribute.Int("run.points", run.Points))
+	endSpan(span, err)
+
+	run.FinishedAt = time.Now()
+	run.Status = storage.RunSucceeded
+	if err != nil {
+		run.Status = storage.RunFailed
+		run.Error = err.Error()
+		run.ErrorClass = errclass.Of(err)
+	}
+	p.tracker.record(run)
+	saveRun(ctx, p, run)
+	return err
+}
+
+// scrapeRun performs the scrape of a run and passes the results through the pipeline, counting
+// the points and fetched bytes of the run
+func scrapeRun(ctx context.Context, p *pipeline, s scraper.Scraper, run *storage.Run) (err error) {
+	fetched := &scraper.FetchCounter{}
+	scrapeCtx := scraper.WithFetchCounter(ctx, fetched)
+	defer func() { run.BytesFetched = fetched.Bytes() }()
+	recorder := &scraper.PayloadRecorder{}
+	archive := p.payloads != nil && p.flags.Enabled(flagPayloadArchive)
+	// Reported failures carry the start of the last payload
+	if archive || p.reporter != nil {
+		scrapeCtx = scraper.WithPayloadRecorder(scrapeCtx, recorder)
+	}
+	// A panicking scraper fails its run instead of the process
+	defer func() {
+		if r := recover(); r != nil {
+			if p.reporter != nil {
+				p.reporter.capturePanic(ctx, *run, r, recorder.Payloads())
+			}
+			err = fmt.Errorf("scraper panicked: %v", r)
+			return
+		}
+		if err != nil && p.reporter != nil {
+			p.reporter.captureRun(ctx, *run, err, recorder.Payloads())
+		}
+	}()
+
+	results, err := s.Scrape(scrapeCtx)
+
+	// Payloads are kept even when parsing failed, they are what a fixed parser needs to reprocess
+	if archive {
+		if err := p.payloads.SavePayloads(ctx, s.Name(), run.ID, recorder.Payloads()); err != nil {
+			slog.ErrorContext(ctx, "Failed to store raw payloads", "error", err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scrape: %w", err)
+	}
+
+	for _, result := range results {
+		result = withRunID(result, run.ID)
+		if err := p.process(ctx, 0, result); err != nil {
+			return err
+		}
+		points, _ := storage.Normalize(result)
+		run.Points += len(points)
+	}
+
+	slog.InfoContext(ctx, "Scraper run completed", "results", len(results))
+	return nil
+}
+
+// withRunID returns result with the run ID in its metadata, the metadata of the scraper is not modified
+func withRunID(result scraper.Result, runID string) scraper.Result {
+	result.Metadata = maps.Clone(result.Metadata)
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]string, 1)
+	}
+	result.Metadata[scraper.MetadataRunID] = runID
+	return result
+}
+
+// saveRun records a run when runs are recorded, failures are only logged with the context of the run
+func saveRun(ctx context.Context, p *pipeline, run storage.Run) {
+	if p.runs == nil {
+		return
+	}
+	if err := p.runs.SaveRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record scraper run", "error", err)
+	}
+}
+
+// startStreamingScrapers validates, initializes and runs every streaming scraper in the background,
+// it returns the names of the scrapers started
+func startStreamingScrapers(ctx context.Context, p *pipeline, ttl time.Duration, scrapers []scraper.StreamingScraper) []string {
+	var started []string
+	for _, s := range scrapers {
+		if err := s.Validate(ctx); err != nil {
+			slog.ErrorContext(ctx, "Invalid scraper configuration", "scraper", s.Name(), "error", err)
+			continue
+		}
+		if err := s.Init(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to initialize scraper", "scraper", s.Name(), "error", err)
+			continue
+		}
+
+		started = append(started, s.Name())
+		go func() {
+			// A streaming run lasts until the stream stops, it is recorded as running meanwhile
+			run := storage.Run{ID: uuid.New().String(), Source: s.Name(), StartedAt: time.Now(), Status: storage.RunRunning}
+			ctx := logging.With(ctx, "scraper", s.Name(), "run", run.ID)
+			saveRun(ctx, p, run)
+
+			emit := func(result scraper.Result) error {
+				if err := p.process(ctx, ttl, withRunID(result, run.ID)); err != nil {
+					return err
+				}
+				// Every result of the stream counts as a successful run for the freshness of the scraper
+				points, _ := storage.Normalize(result)
+				p.tracker.record(storage.Run{Source: s.Name(), FinishedAt: time.Now(), Status: storage.RunSucceeded, Points: len(points)})
+				return nil
+			}
+			err := s.Run(ctx, emit)
+
+			run.FinishedAt = time.Now()
+			run.Status = storage.RunSucceeded
+			if err != nil {
+				slog.ErrorContext(ctx, "Streaming scraper stopped", "error", err, "class", errclass.Of(err))
+				run.Status = storage.RunFailed
+				run.Error = err.Error()
+				run.ErrorClass = errclass.Of(err)
+				if p.reporter != nil {
+					p.reporter.captureRun(ctx, run, err, nil)
+				}
+			}
+			p.tracker.record(run)
+			saveRun(context.WithoutCancel(ctx), p, run)
+		}()
+	}
+	return started
+}
+
+// resultMessage builds the queue message announcing a scrape result, the body is encoded
+// as protobuf when the queue uses the protobuf codec. A non-zero ttl lets consumers
+// discard results that are no longer useful, such as real-time ticks
+func resultMessage(codec string, ttl time.Duration, result scraper.Result) (queue.Message, error) {
+	var body []byte
+	var err error
+	contentType := queue.ContentTypeJSON
+	if codec == "protobuf" {
+		body, err = scraper.MarshalResultProto(result)
+		contentType = queue.ContentTypeProtobuf
+	} else {
+		body, err = json.Marshal(result)
+	}
+	if err != nil {
+		return queue.Message{}, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	message := queue.Message{
+		Type:          persister.ResultType,
+		SchemaVersion: persister.ResultSchemaVersion,
+		Body:          body,
+		TTL:           ttl,
+		Metadata: map[string]string{
+			"source":                  result.Source,
+			queue.MetadataType:        persister.ResultType,
+			queue.MetadataContentType: contentType,
+		},
+	}
+	if runID := result.Metadata[scraper.MetadataRunID]; runID != "" {
+		message.Metadata[scraper.MetadataRunID] = runID
+	}
+	return message, nil
+}
+
+// resultTopic returns the queue topic the results of a source are published to
+func resultTopic(source string) string {
+	return "scraper_results." + source
+}
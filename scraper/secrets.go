@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"macrochain/scraper/pkg/secrets"
+)
+
+// resolveSecrets replaces the secret references of the configuration with their values from the
+// secrets backend of the configuration
+func resolveSecrets(config *Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var provider secrets.Provider
+	switch config.SecretsBackend {
+	case "vault":
+		provider = secrets.NewVaultProvider(secrets.VaultOptions{
+			Address:   config.VaultAddr,
+			Token:     config.VaultToken,
+			Namespace: config.VaultNamespace,
+		})
+	case "aws":
+		aws, err := secrets.NewAWSProvider(ctx, secrets.AWSOptions{
+			Region:       config.AWSRegion,
+			AccessKey:    config.AWSAccessKeyID,
+			SecretKey:    config.AWSSecretAccessKey,
+			SessionToken: config.AWSSessionToken,
+			Endpoint:     config.SecretsManagerEndpoint,
+		})
+		if err != nil {
+			return err
+		}
+		provider = aws
+	default:
+		return fmt.Errorf("unsupported secrets backend %q", config.SecretsBackend)
+	}
+
+	resolver := secrets.NewResolver(provider, time.Duration(config.SecretsCacheTTL)*time.Minute)
+	if err := resolver.ResolveStruct(ctx, config); err != nil {
+		return fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	config.secrets = resolver
+	return nil
+}
+
+// errSecretsRotated stops the command when a secret was rotated, main exits with
+// exitSecretsRotated so the process is restarted with the new values
+var errSecretsRotated = errors.New("secrets rotated, restarting to apply them")
+
+// exitSecretsRotated is the exit code after a secret rotation, EX_TEMPFAIL, so restart policies
+// restarting failed processes only apply the rotation too
+const exitSecretsRotated = 75
+
+// watchSecrets checks the secrets of the configuration for rotations in the background. The
+// resolved values are held by clients built at startup, so a rotation cancels the context of the
+// command with errSecretsRotated. The command shuts down as on SIGTERM, e.g. the persist command
+// finishes its messages in progress, and the process must be restarted by its supervisor
+func watchSecrets(ctx context.Context, config *Config, stop context.CancelCauseFunc) {
+	if config.secrets == nil || config.SecretsRefreshInterval <= 0 {
+		return
+	}
+
+	interval := time.Duration(config.SecretsRefreshInterval) * time.Minute
+	go config.secrets.Watch(ctx, interval, func(names []string) {
+		slog.WarnContext(ctx, "Secrets rotated, stopping to restart with them", "secrets", names)
+		stop(errSecretsRotated)
+	})
+}
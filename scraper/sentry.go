@@ -0,0 +1,133 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"macrochain/scraper/pkg/errclass"
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+)
+
+// errorReporter captures the errors and panics of the scraper runs and the dead-lettered messages
+// of the persister in Sentry, or a server compatible with its protocol
+type errorReporter struct {
+	hub *sentry.Hub
+	// snippet is the number of bytes of a raw payload attached to an event, zero attaches none
+	snippet int
+}
+
+// newErrorReporter creates a reporter sending to the DSN of the configuration, nil when none is
+// set, service names the process in the events. The returned function flushes the pending events
+func newErrorReporter(config *Config, service string) (*errorReporter, func(), error) {
+	if config.SentryDSN == "" {
+		return nil, func() {}, nil
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         config.SentryDSN,
+		Environment: cmp.Or(config.SentryEnvironment, config.Profile),
+		SampleRate:  config.SentrySampleRate,
+		ServerName:  service,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sentry client: %w", err)
+	}
+	reporter := &errorReporter{
+		hub:     sentry.NewHub(client, sentry.NewScope()),
+		snippet: config.SentryPayloadSnippet,
+	}
+	return reporter, func() { client.Flush(5 * time.Second) }, nil
+}
+
+// captureRun reports the failure of a run with the payloads it fetched
+func (r *errorReporter) captureRun(ctx context.Context, run storage.Run, err error, payloads []scraper.Payload) {
+	r.hub.WithScope(func(scope *sentry.Scope) {
+		r.runScope(ctx, scope, run, payloads)
+		scope.SetTag("error_class", errclass.Of(err))
+		r.hub.CaptureException(err)
+	})
+}
+
+// capturePanic reports a panic of a run with its stack trace and the payloads it fetched, it must be
+// called from the deferred function recovering the panic
+func (r *errorReporter) capturePanic(ctx context.Context, run storage.Run, recovered any, payloads []scraper.Payload) {
+	r.hub.WithScope(func(scope *sentry.Scope) {
+		r.runScope(ctx, scope, run, payloads)
+		r.hub.RecoverWithContext(ctx, recovered)
+	})
+}
+
+// runScope tags the events of a run with its scraper, ID and trace, and attaches the start of its
+// last payload
+func (r *errorReporter) runScope(ctx context.Context, scope *sentry.Scope, run storage.Run, payloads []scraper.Payload) {
+	scope.SetTag("scraper", run.Source)
+	scope.SetTag("run", run.ID)
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		scope.SetTag("trace_id", span.TraceID().String())
+	}
+	// Errors of a scraper are grouped by scraper rather than by the call site they share
+	scope.SetFingerprint([]string{"{{ default }}", run.Source})
+	scope.SetContext("run", sentry.Context{"started_at": run.StartedAt, "points": run.Points})
+	if len(payloads) > 0 && r.snippet > 0 {
+		last := payloads[len(payloads)-1]
+		scope.SetContext("payload", sentry.Context{
+			"url":          withoutQuery(last.URL),
+			"content_type": last.ContentType,
+			"size":         len(last.Body),
+			"snippet":      snippet(last.Body, r.snippet),
+		})
+	}
+}
+
+// captureDeadLetter reports a message the persister could not store with the start of its body
+func (r *errorReporter) captureDeadLetter(ctx context.Context, topic string, message queue.Message, cause error) {
+	r.hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("topic", topic)
+		scope.SetTag("message", message.ID)
+		if source := message.Metadata["source"]; source != "" {
+			scope.SetTag("scraper", source)
+		}
+		if runID := message.Metadata[scraper.MetadataRunID]; runID != "" {
+			scope.SetTag("run", runID)
+		}
+		if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+			scope.SetTag("trace_id", span.TraceID().String())
+		}
+		if r.snippet > 0 {
+			scope.SetContext("payload", sentry.Context{
+				"type":    message.Type,
+				"size":    len(message.Body),
+				"snippet": snippet(message.Body, r.snippet),
+			})
+		}
+		r.hub.CaptureException(fmt.Errorf("message dead-lettered on %s: %w", topic, cause))
+	})
+}
+
+// snippet returns up to limit bytes of body as text
+func snippet(body []byte, limit int) string {
+	if len(body) > limit {
+		body = body[:limit]
+	}
+	return strings.ToValidUTF8(string(body), "�")
+}
+
+// withoutQuery removes the query of a URL, where sources usually take their API key
+func withoutQuery(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	parsed.RawQuery = ""
+	parsed.User = nil
+	return parsed.String()
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"macrochain/scraper/pkg/featureflag"
+	"macrochain/scraper/pkg/storage"
+)
+
+// Health of a scraper in the status
+const (
+	// healthDisabled is a scraper that is not enabled in the configuration
+	healthDisabled = "disabled"
+	// healthUnavailable is an enabled scraper that failed its validation or initialization
+	healthUnavailable = "unavailable"
+	// healthPaused is a scraper whose feature flag is disabled
+	healthPaused = "paused"
+	// healthPending is a scraper that has not run yet
+	healthPending = "pending"
+	// healthFailing is a scraper whose last run failed
+	healthFailing = "failing"
+	// healthStale is a scraper that has not succeeded within its max_age
+	healthStale = "stale"
+	// healthHealthy is a scraper whose last run succeeded within its max_age
+	healthHealthy = "healthy"
+)
+
+// scraperStatus is the state of a scraper served by /status
+type scraperStatus struct {
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	Streaming bool   `json:"streaming,omitempty"`
+	// Schedule describes when the scraper runs, e.g. "every 1h0m0s" or "cron 0 22 * * 1-5"
+	Schedule            string     `json:"schedule,omitempty"`
+	Flag                string     `json:"flag,omitempty"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastStatus          string     `json:"last_status,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorClass      string     `json:"last_error_class,omitempty"`
+	LastItems           int        `json:"last_items"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	NextRun             *time.Time `json:"next_run,omitempty"`
+	Health              string     `json:"health"`
+}
+
+// scheduledScraper is the schedule of a scraper known to the board
+type scheduledScraper struct {
+	enabled   bool
+	streaming bool
+	schedule  string
+	flag      string
+	maxAge    time.Duration
+	// running is false for enabled scrapers that could not be started
+	running bool
+	nextRun time.Time
+}
+
+// statusBoard combines the schedule of the scrapers, kept up to date by the scheduler loop, with
+// the outcome of their runs for the /status endpoint
+type statusBoard struct {
+	tracker *runTracker
+	flags   *featureflag.Flags
+
+	mu       sync.Mutex
+	scrapers map[string]scheduledScraper
+}
+
+// newStatusBoard creates a board without scrapers
+func newStatusBoard(tracker *runTracker, flags *featureflag.Flags) *statusBoard {
+	return &statusBoard{tracker: tracker, flags: flags, scrapers: make(map[string]scheduledScraper)}
+}
+
+// update replaces the scrapers of the board with every scraper registered in the configuration,
+// plans and nextRun are the schedule of the running polling scrapers and streaming the names of
+// the running streaming scrapers
+func (b *statusBoard) update(config *Config, plans map[string]scraperPlan, nextRun map[string]time.Time, streaming []string) {
+	scrapers := make(map[string]scheduledScraper)
+	for _, factory := range scraperFactories(config) {
+		settings := config.Scrapers[factory.name]
+		entry := scheduledScraper{
+			enabled: settings.IsEnabled(slices.Contains(config.EnabledScrapers, factory.name)),
+			flag:    settings.Flag,
+			maxAge:  settings.MaxAge,
+		}
+		if plan, ok := plans[factory.name]; ok {
+			entry.running = true
+			entry.schedule = plan.schedule.String()
+			entry.nextRun = nextRun[factory.name]
+		}
+		scrapers[factory.name] = entry
+	}
+	for _, name := range streamingScraperNames {
+		settings := config.Scrapers[name]
+		scrapers[name] = scheduledScraper{
+			enabled:   settings.IsEnabled(slices.Contains(config.EnabledScrapers, name)),
+			streaming: true,
+			flag:      settings.Flag,
+			maxAge:    settings.MaxAge,
+			running:   slices.Contains(streaming, name),
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scrapers = scrapers
+}
+
+// reschedule updates the next runs of the polling scrapers
+func (b *statusBoard) reschedule(nextRun map[string]time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, next := range nextRun {
+		if entry, ok := b.scrapers[name]; ok && entry.running {
+			entry.nextRun = next
+			b.scrapers[name] = entry
+		}
+	}
+}
+
+// status returns the state of every scraper sorted by name
+func (b *statusBoard) status(now time.Time) []scraperStatus {
+	b.mu.Lock()
+	scrapers := maps.Clone(b.scrapers)
+	b.mu.Unlock()
+	runs := b.tracker.snapshot()
+
+	statuses := make([]scraperStatus, 0, len(scrapers))
+	for _, name := range slices.Sorted(maps.Keys(scrapers)) {
+		entry := scrapers[name]
+		status := scraperStatus{
+			Name:      name,
+			Enabled:   entry.enabled,
+			Streaming: entry.streaming,
+			Schedule:  entry.schedule,
+			Flag:      entry.flag,
+		}
+		if !entry.nextRun.IsZero() {
+			status.NextRun = &entry.nextRun
+		}
+		run, ok := runs[name]
+		if ok && !run.LastRun.IsZero() {
+			status.LastRun = &run.LastRun
+			status.LastStatus = run.LastStatus
+			status.LastError = run.LastError
+			status.LastErrorClass = run.LastErrorClass
+			status.LastItems = run.LastItems
+			status.ConsecutiveFailures = run.ConsecutiveFailures
+		}
+		if ok && !run.LastSuccess.IsZero() {
+			status.LastSuccess = &run.LastSuccess
+		}
+		status.Health = b.health(entry, run, ok, now)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// health classifies a scraper from its schedule and runs, ran is false before its first run
+func (b *statusBoard) health(entry scheduledScraper, runs scraperRuns, ran bool, now time.Time) string {
+	switch {
+	case !entry.enabled:
+		return healthDisabled
+	case !entry.running:
+		return healthUnavailable
+	case entry.flag != "" && !b.flags.Enabled(entry.flag):
+		return healthPaused
+	case entry.maxAge > 0 && b.tracker.sinceSuccess(runs, now) > entry.maxAge:
+		return healthStale
+	case !ran || runs.LastRun.IsZero():
+		return healthPending
+	case runs.LastStatus == storage.RunFailed:
+		return healthFailing
+	default:
+		return healthHealthy
+	}
+}
+
+// ServeHTTP serves the state of every scraper as JSON
+func (b *statusBoard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"scrapers": b.status(time.Now())})
+}
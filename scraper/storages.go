@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"macrochain/scraper/pkg/featureflag"
+	"macrochain/scraper/pkg/storage"
+)
+
+// newStorageBackend creates the storage backend selected in the configuration, migrating its
+// schema. When a secondary backend is configured and the secondary_storage flag is enabled the
+// storage writes to both. The returned function releases the connections of the backends
+func newStorageBackend(ctx context.Context, config *Config, flags *featureflag.Flags) (storage.Storage, func(), error) {
+	primary, closePrimary, err := openStorageBackend(ctx, config, "")
+	if err != nil || config.StorageSecondaryBackend == "" || !flags.Enabled(flagSecondaryStorage) {
+		return primary, closePrimary, err
+	}
+
+	// The secondary only gets the options of its own backend, it is not partitioned as the
+	// partitions are maintained on the primary
+	secondaryConfig := *config
+	secondaryConfig.StorageBackend = config.StorageSecondaryBackend
+	secondaryConfig.StorageTimescale = false
+	secondaryConfig.StoragePartitioning = false
+	secondary, closeSecondary, err := openStorageBackend(ctx, &secondaryConfig, config.StorageSecondaryDBURL)
+	if err != nil {
+		closePrimary()
+		return nil, nil, fmt.Errorf("failed to open secondary storage: %w", err)
+	}
+	return storage.NewDualWriter(primary, secondary), func() {
+		closeSecondary()
+		closePrimary()
+	}, nil
+}
+
+// openStorageBackend creates the storage backend of the configuration, a postgres or timescale
+// backend connects to dsn when it is set
+func openStorageBackend(ctx context.Context, config *Config, dsn string) (storage.Storage, func(), error) {
+	switch config.StorageBackend {
+	case "postgres", "timescale":
+		pool, err := connectDB(ctx, config, dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if config.MigrateOnStart {
+			if err := migrate(ctx, pool); err != nil {
+				pool.Close()
+				return nil, nil, err
+			}
+		}
+		repository, err := newStorage(pool, config)
+		if err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		if err := repository.Migrate(ctx); err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		return repository, pool.Close, nil
+	case "clickhouse":
+		repository := storage.NewClickHouseRepository(storage.ClickHouseOptions{
+			URL:         config.ClickHouseURL,
+			Database:    config.ClickHouseDatabase,
+			Username:    config.ClickHouseUser,
+			Password:    config.ClickHousePassword,
+			AsyncInsert: config.ClickHouseAsyncInsert,
+		})
+		if err := repository.Migrate(ctx); err != nil {
+			return nil, nil, err
+		}
+		return repository, func() {}, nil
+	case "influxdb":
+		repository := storage.NewInfluxRepository(storage.InfluxOptions{
+			URL:    config.InfluxURL,
+			Org:    config.InfluxOrg,
+			Bucket: config.InfluxBucket,
+			Token:  config.InfluxToken,
+		})
+		return repository, func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported storage backend %q", config.StorageBackend)
+}
+
+// runBackfillCommand runs the backfill-secondary subcommand, it copies the points stored in the
+// primary backend to the secondary backend, from the since date on when it is set
+func runBackfillCommand(ctx context.Context, config *Config, since string) error {
+	var from time.Time
+	if since != "" {
+		parsed, err := time.Parse(time.DateOnly, since)
+		if err != nil {
+			return fmt.Errorf("invalid since date %q: %w", since, err)
+		}
+		from = parsed
+	}
+
+	store, closeStorage, err := newStorageBackend(ctx, config, featureflag.New(config.FeatureFlags))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	writer, ok := store.(*storage.DualWriter)
+	if !ok {
+		return fmt.Errorf("backfilling needs STORAGE_SECONDARY_BACKEND and the %s flag enabled", flagSecondaryStorage)
+	}
+	copied, err := storage.NewDualWriteVerifier(writer, storage.VerifyOptions{}).Backfill(ctx, from, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to backfill secondary storage after %d points: %w", copied, err)
+	}
+	fmt.Printf("Copied %d points to %s\n", copied, config.StorageSecondaryBackend)
+	return nil
+}
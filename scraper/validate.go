@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"macrochain/scraper/pkg/queue"
+	"macrochain/scraper/pkg/scraper"
+	"macrochain/scraper/pkg/storage"
+)
+
+// Allowed values of the options selecting an implementation
+var (
+	logLevels       = []string{"debug", "info", "warn", "error"}
+	queueBackends   = []string{"redis", "redis_streams", "kafka"}
+	compressions    = []string{"", "gzip", "zstd"}
+	storageBackends = []string{"postgres", "timescale", "clickhouse", "influxdb"}
+	backupFormats   = []string{"csv", "parquet"}
+	secretsBackends = []string{"", "vault", "aws"}
+	remoteStores    = []string{"", "consul", "etcd"}
+	anomalyActions  = []string{"tag", "quarantine"}
+)
+
+// problems collects the problems found in a configuration
+type problems []string
+
+// addf records a problem
+func (p *problems) addf(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// oneOf records a problem when value is not one of allowed
+func (p *problems) oneOf(key, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		p.addf("%s must be one of %s, got %q", key, strings.Join(quoted(allowed), ", "), value)
+	}
+}
+
+// required records a problem when value is empty
+func (p *problems) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		p.addf("%s is required", key)
+	}
+}
+
+// port records a problem when value is not a TCP port
+func (p *problems) port(key string, value int) {
+	if value < 1 || value > 65535 {
+		p.addf("%s must be between 1 and 65535, got %d", key, value)
+	}
+}
+
+// atLeast records a problem when value is below minimum
+func (p *problems) atLeast(key string, value, minimum int) {
+	if value < minimum {
+		p.addf("%s must be at least %d, got %d", key, minimum, value)
+	}
+}
+
+// check records err as a problem of key
+func (p *problems) check(key string, err error) {
+	if err != nil {
+		p.addf("%s: %v", key, err)
+	}
+}
+
+// quoted quotes values for a problem message so an allowed empty value shows
+func quoted(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = fmt.Sprintf("%q", value)
+	}
+	return out
+}
+
+// Validate checks the configuration, returning every problem found at once so they can all be
+// fixed before the next start instead of surfacing one by one as connection failures
+func (c *Config) Validate() error {
+	var p problems
+
+	p.oneOf("LOG_LEVEL", c.LogLevel, logLevels)
+	p.atLeast("SCRAPE_INTERVAL", c.ScrapeInterval, 1)
+	p.atLeast("CONFIG_WATCH_INTERVAL", c.ConfigWatchInterval, 0)
+	if c.HTTPAddr != "" && c.HealthStallTimeout <= c.ScrapeInterval {
+		p.addf("HEALTH_STALL_TIMEOUT (%d) must exceed SCRAPE_INTERVAL (%d)", c.HealthStallTimeout, c.ScrapeInterval)
+	}
+	if c.TracingEnabled {
+		p.required("TRACING_ENDPOINT", c.TracingEndpoint)
+		if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+			p.addf("TRACING_SAMPLE_RATIO (%v) must be between 0 and 1", c.TracingSampleRatio)
+		}
+	}
+	if c.FeatureFlagsRedis {
+		p.required("FEATURE_FLAGS_REDIS_KEY", c.FeatureFlagsRedisKey)
+		p.atLeast("FEATURE_FLAGS_REFRESH_INTERVAL", c.FeatureFlagsRefreshInterval, 1)
+	}
+	p.oneOf("REMOTE_CONFIG_STORE", c.RemoteConfigStore, remoteStores)
+	if c.RemoteConfigStore != "" {
+		p.required("REMOTE_CONFIG_ADDRESS", c.RemoteConfigAddress)
+		p.required("REMOTE_CONFIG_KEY", c.RemoteConfigKey)
+		p.atLeast("REMOTE_CONFIG_WATCH_INTERVAL", c.RemoteConfigWatchInterval, 0)
+	}
+
+	if c.StorageEnabled || c.OutboxEnabled || c.PayloadArchiveEnabled {
+		p.required("DB_HOST", c.DBHost)
+	}
+	p.port("DB_PORT", c.DBPort)
+	p.required("DB_USER", c.DBUser)
+	p.required("DB_NAME", c.DBName)
+	if c.DBMaxConns > 0 && c.DBMinConns > c.DBMaxConns {
+		p.addf("DB_MIN_CONNS (%d) must not exceed DB_MAX_CONNS (%d)", c.DBMinConns, c.DBMaxConns)
+	}
+	p.atLeast("DB_STATEMENT_TIMEOUT", c.DBStatementTimeout, 0)
+
+	p.oneOf("QUEUE_BACKEND", c.QueueBackend, queueBackends)
+	_, err := queue.NewCodec(c.QueueCodec, queue.Compression{})
+	p.check("QUEUE_CODEC", err)
+	p.oneOf("QUEUE_COMPRESSION", c.QueueCompression, compressions)
+	p.atLeast("QUEUE_MAX_DECOMPRESSED_SIZE", c.QueueMaxDecompressedSize, 1)
+	switch c.QueueBackend {
+	case "redis", "redis_streams":
+		p.required("REDIS_HOST", c.RedisHost)
+		p.port("REDIS_PORT", c.RedisPort)
+		p.atLeast("REDIS_DB", c.RedisDB, 0)
+		p.atLeast("REDIS_POOL_SIZE", c.RedisPoolSize, 1)
+		p.atLeast("REDIS_MIN_IDLE_CONNS", c.RedisMinIdleConns, 0)
+		if c.RedisMinIdleConns > c.RedisPoolSize {
+			p.addf("REDIS_MIN_IDLE_CONNS (%d) must not exceed REDIS_POOL_SIZE (%d)", c.RedisMinIdleConns, c.RedisPoolSize)
+		}
+		p.atLeast("REDIS_DIAL_TIMEOUT", c.RedisDialTimeout, 1)
+		p.atLeast("REDIS_READ_TIMEOUT", c.RedisReadTimeout, 1)
+		p.atLeast("REDIS_WRITE_TIMEOUT", c.RedisWriteTimeout, 1)
+		if (c.RedisTLSCert == "") != (c.RedisTLSKey == "") {
+			p.addf("REDIS_TLS_CERT_FILE and REDIS_TLS_KEY_FILE must be set together")
+		}
+	case "kafka":
+		if len(c.KafkaBrokers) == 0 {
+			p.addf("KAFKA_BROKERS is required with the kafka queue backend")
+		}
+		p.atLeast("KAFKA_PARTITIONS", c.KafkaPartitions, 1)
+		p.atLeast("KAFKA_REPLICATION_FACTOR", c.KafkaReplicationFactor, 1)
+	}
+	p.atLeast("STREAMING_RESULT_TTL", c.StreamingResultTTL, 0)
+
+	_, err = storage.ParseConflictPolicy(c.StorageConflictPolicy)
+	p.check("STORAGE_CONFLICT_POLICY", err)
+	p.oneOf("STORAGE_BACKEND", c.StorageBackend, storageBackends)
+	if c.StorageSecondaryBackend != "" {
+		p.oneOf("STORAGE_SECONDARY_BACKEND", c.StorageSecondaryBackend, storageBackends)
+		if c.StorageSecondaryBackend == c.StorageBackend && c.StorageSecondaryDBURL == "" {
+			p.addf("STORAGE_SECONDARY_BACKEND is the primary backend, set STORAGE_SECONDARY_DB_URL to write to another database")
+		}
+		p.atLeast("DUAL_WRITE_VERIFY_INTERVAL", c.DualWriteVerifyInterval, 1)
+	}
+	if c.StoragePartitioning && (c.StorageTimescale || c.StorageBackend == "timescale") {
+		p.addf("STORAGE_PARTITIONING cannot be combined with TimescaleDB, hypertables are partitioned already")
+	}
+	if c.StoragePartitioning {
+		p.atLeast("STORAGE_PARTITION_PREMAKE", c.StoragePartitionPremake, 1)
+		p.atLeast("STORAGE_PARTITION_INTERVAL", c.StoragePartitionInterval, 1)
+	}
+	p.atLeast("STORAGE_RETENTION", c.StorageRetention, 0)
+
+	if c.RetentionEnabled {
+		policies, err := storage.ParseRetentionPolicies(c.RetentionPolicies)
+		p.check("RETENTION_POLICIES", err)
+		if err == nil && c.StorageTimescale {
+			p.check("RETENTION_POLICIES", storage.CheckTimescaleRetention(policies))
+		}
+		p.atLeast("RETENTION_INTERVAL", c.RetentionInterval, 1)
+	}
+	if c.GapsEnabled {
+		p.atLeast("GAPS_INTERVAL", c.GapsInterval, 1)
+		p.atLeast("GAPS_LOOKBACK", c.GapsLookback, 1)
+		_, err := storage.ParseHolidayCalendars(c.GapsHolidays)
+		p.check("GAPS_HOLIDAYS", err)
+	}
+	if c.OHLCEnabled {
+		_, err := storage.ParseBarIntervals(c.OHLCIntervals)
+		p.check("OHLC_INTERVALS", err)
+	}
+	if c.BackupEnabled {
+		p.required("BACKUP_DIR", c.BackupDir)
+		p.atLeast("BACKUP_INTERVAL", c.BackupInterval, 1)
+	}
+	p.atLeast("BACKUP_KEEP", c.BackupKeep, 0)
+	p.oneOf("BACKUP_FORMAT", c.BackupFormat, backupFormats)
+	if c.ArchiveEnabled {
+		p.required("S3_ENDPOINT", c.S3Endpoint)
+		p.required("S3_BUCKET", c.S3Bucket)
+		p.atLeast("ARCHIVE_INTERVAL", c.ArchiveInterval, 1)
+	}
+	if c.OutboxEnabled {
+		p.atLeast("OUTBOX_INTERVAL", c.OutboxInterval, 1)
+		p.atLeast("OUTBOX_BATCH_SIZE", c.OutboxBatchSize, 1)
+	}
+	p.atLeast("PERSISTER_CONCURRENCY", c.PersisterConcurrency, 1)
+	if c.PersisterBufferDir != "" {
+		p.atLeast("PERSISTER_BUFFER_INTERVAL", c.PersisterBufferInterval, 1)
+	}
+
+	if c.AlertSMTPHost != "" {
+		p.port("ALERT_SMTP_PORT", c.AlertSMTPPort)
+		p.required("ALERT_EMAIL_FROM", c.AlertEmailFrom)
+		if len(c.AlertEmailTo) == 0 {
+			p.addf("ALERT_EMAIL_TO is required with ALERT_SMTP_HOST")
+		}
+	}
+	p.atLeast("ALERT_FAILURE_THRESHOLD", c.AlertFailureThreshold, 1)
+	p.atLeast("ALERT_REPEAT_INTERVAL", c.AlertRepeatInterval, 1)
+
+	if c.SentryDSN != "" {
+		if c.SentrySampleRate <= 0 || c.SentrySampleRate > 1 {
+			p.addf("SENTRY_SAMPLE_RATE (%v) must be above 0 and at most 1", c.SentrySampleRate)
+		}
+		p.atLeast("SENTRY_PAYLOAD_SNIPPET", c.SentryPayloadSnippet, 0)
+	}
+	p.atLeast("LOG_SAMPLING_FIRST", c.LogSamplingFirst, 0)
+	p.atLeast("LOG_SAMPLING_THEREAFTER", c.LogSamplingThereafter, 0)
+
+	if c.HeartbeatEnabled {
+		p.atLeast("HEARTBEAT_INTERVAL", c.HeartbeatInterval, 1)
+		if c.HeartbeatTopic == "" && c.HeartbeatPingURL == "" {
+			p.addf("HEARTBEAT_TOPIC or HEARTBEAT_PING_URL is required with HEARTBEAT_ENABLED")
+		}
+	}
+
+	_, err = storage.ParseStalenessBudgets(c.FreshnessBudgets)
+	p.check("FRESHNESS_BUDGETS", err)
+	if c.FreshnessEnabled {
+		p.atLeast("FRESHNESS_INTERVAL", c.FreshnessInterval, 1)
+	}
+
+	if c.AnomalyEnabled {
+		p.oneOf("ANOMALY_ACTION", c.AnomalyAction, anomalyActions)
+		if c.AnomalyAction == "quarantine" && c.StorageBackend != "postgres" && c.StorageBackend != "timescale" {
+			p.addf("ANOMALY_ACTION quarantine needs the postgres or timescale storage backend")
+		}
+		p.atLeast("ANOMALY_WINDOW", c.AnomalyWindow, 2)
+		p.atLeast("ANOMALY_MIN_HISTORY", c.AnomalyMinHistory, 2)
+		if c.AnomalyZScore <= 0 || c.AnomalyMinDeviation < 0 || c.AnomalyMaxJump < 0 {
+			p.addf("ANOMALY_ZSCORE must be above 0, ANOMALY_MIN_DEVIATION and ANOMALY_MAX_JUMP at least 0")
+		}
+		if c.AnomalyRegimeChange != 0 {
+			p.atLeast("ANOMALY_REGIME_CHANGE", c.AnomalyRegimeChange, 2)
+		}
+	}
+
+	if c.QuotaDeferThreshold <= 0 || c.QuotaDeferThreshold > 1 {
+		p.addf("QUOTA_DEFER_THRESHOLD (%v) must be above 0 and at most 1", c.QuotaDeferThreshold)
+	}
+	if c.QuotaRedis {
+		p.required("QUOTA_REDIS_PREFIX", c.QuotaRedisPrefix)
+	}
+	if c.CursorRedis {
+		p.required("CURSOR_REDIS_PREFIX", c.CursorRedisPrefix)
+	}
+
+	p.oneOf("SECRETS_BACKEND", c.SecretsBackend, secretsBackends)
+	p.atLeast("SECRETS_REFRESH_INTERVAL", c.SecretsRefreshInterval, 0)
+
+	c.validateScrapers(&p)
+
+	if len(p) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration:\n  - " + strings.Join(p, "\n  - "))
+}
+
+// validateScrapers checks the scraper names and sections of the configuration, and the options
+// of the enabled scrapers
+func (c *Config) validateScrapers(p *problems) {
+	names := slices.Clone(streamingScraperNames)
+	for _, factory := range scraperFactories(c) {
+		names = append(names, factory.name)
+		settings := c.Scrapers[factory.name].WithCredential(c.APIKeys[factory.name])
+		if settings.IsEnabled(slices.Contains(c.EnabledScrapers, factory.name)) {
+			_, err := factory.build(settings)
+			p.check(factory.name, err)
+		}
+	}
+
+	for _, name := range c.EnabledScrapers {
+		if !slices.Contains(names, name) {
+			p.addf("ENABLED_SCRAPERS contains unknown scraper %q", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Scrapers)) {
+		settings := c.Scrapers[name]
+		if !slices.Contains(names, name) {
+			p.addf("SCRAPERS has a section for unknown scraper %q", name)
+			continue
+		}
+		if settings.Interval < 0 {
+			p.addf("SCRAPERS.%s.interval must not be negative", name)
+		}
+		if settings.MaxAge < 0 {
+			p.addf("SCRAPERS.%s.max_age must not be negative", name)
+		}
+		if settings.RateLimit < 0 || settings.Burst < 0 {
+			p.addf("SCRAPERS.%s.rate_limit and burst must not be negative", name)
+		}
+		if settings.RequestsPerMinute < 0 || settings.RequestsPerDay < 0 || settings.RequestsPerMonth < 0 || settings.CostPerRequest < 0 {
+			p.addf("SCRAPERS.%s.requests_per_minute, requests_per_day, requests_per_month and cost_per_request must not be negative", name)
+		}
+		// Unknown flags are disabled, a misspelled flag would stop the scraper silently
+		if _, ok := c.FeatureFlags[settings.Flag]; settings.Flag != "" && !ok && !c.FeatureFlagsRedis {
+			p.addf("SCRAPERS.%s.flag %q is not set in FEATURE_FLAGS", name, settings.Flag)
+		}
+		_, err := scraper.NewSchedule(settings, 0)
+		p.check("SCRAPERS."+name+".cron", err)
+		_, err = settings.ProxyOverride()
+		p.check("SCRAPERS."+name+".proxy", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.APIKeys)) {
+		if !slices.Contains(names, name) {
+			p.addf("API_KEYS has a credential for unknown scraper %q", name)
+		}
+	}
+}